@@ -1,7 +1,11 @@
 package config
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
@@ -18,6 +22,7 @@ import (
 	"github.com/gofrs/uuid/v5"
 	"github.com/gorilla/securecookie"
 	"github.com/hackclub/hackatime/data"
+	"github.com/hackclub/hackatime/i18n"
 	"github.com/hackclub/hackatime/utils"
 	"github.com/jinzhu/configor"
 	"github.com/robfig/cron/v3"
@@ -35,19 +40,27 @@ const (
 	KeyLatestTotalUsers             = "latest_total_users"
 	KeyLastImport                   = "last_import"            // import attempt
 	KeyLastImportSuccess            = "last_successful_import" // last actual successful import
+	KeyLastExport                   = "last_export"
+	KeyLastEmailVerification        = "last_email_verification"
+	KeyEmailVerificationSecret      = "email_verification_secret"
 	KeyFirstHeartbeat               = "first_heartbeat"
 	KeySubscriptionNotificationSent = "sub_reminder"
 	KeyNewsbox                      = "newsbox"
-	KeyInviteCode                   = "invite"
+	KeyRegistrationPolicy           = "registration_policy"
+	KeyNotificationTemplate         = "notification_template"
 
 	SessionKeyDefault = "default"
 
+	EmailVerificationTokenTtl = 48 * time.Hour
+
 	SimpleDateFormat     = "2006-01-02"
 	SimpleDateTimeFormat = "2006-01-02 15:04:05"
 
 	ErrUnauthorized        = "401 unauthorized"
 	ErrBadRequest          = "400 bad request"
+	ErrForbidden           = "403 forbidden"
 	ErrNotFound            = "404 not found"
+	ErrEntityTooLarge      = "413 request entity too large"
 	ErrInternalServerError = "500 internal server error"
 )
 
@@ -66,10 +79,34 @@ const (
 	MailProviderSmtp = "smtp"
 )
 
+const (
+	SeasonPeriodWeekly  = "weekly"
+	SeasonPeriodMonthly = "monthly"
+)
+
+const (
+	RegistrationModeOpen   = "open"   // anyone can sign up
+	RegistrationModeInvite = "invite" // only with a valid invite code
+	RegistrationModeDomain = "domain" // only with an e-mail address of an allowed domain (or an invite code)
+	RegistrationModeClosed = "closed" // only admins can create users
+)
+
+var RegistrationModes = []string{RegistrationModeOpen, RegistrationModeInvite, RegistrationModeDomain, RegistrationModeClosed}
+
 var emailProviders = []string{
 	MailProviderSmtp,
 }
 
+const (
+	ObjectsProviderLocal = "local"
+	ObjectsProviderS3    = "s3"
+)
+
+var objectsProviders = []string{
+	ObjectsProviderLocal,
+	ObjectsProviderS3,
+}
+
 // first wakatime commit was on this day ;-) so no real heartbeats should exist before
 // https://github.com/wakatime/legacy-python-cli/commit/3da94756aa1903c1cca5035803e3f704e818c086
 const heartbeatsMinDate = "2013-07-06"
@@ -84,37 +121,69 @@ type appConfig struct {
 	IgnoreUserLeaderboardPreference bool                         `yaml:"ignore_user_leaderboard_preference" default:"false" env:"WAKAPI_IGNORE_USER_LEADERBOARD_PREFERENCE"`
 	LeaderboardScope                string                       `yaml:"leaderboard_scope" default:"7_days" env:"WAKAPI_LEADERBOARD_SCOPE"`
 	LeaderboardGenerationTime       string                       `yaml:"leaderboard_generation_time" default:"0 0 6 * * *,0 0 18 * * *" env:"WAKAPI_LEADERBOARD_GENERATION_TIME"`
+	LeaderboardMinAccountAgeDays    int                          `yaml:"leaderboard_min_account_age_days" default:"0" env:"WAKAPI_LEADERBOARD_MIN_ACCOUNT_AGE_DAYS"`
+	LeaderboardRequireEmail         bool                         `yaml:"leaderboard_require_email" default:"false" env:"WAKAPI_LEADERBOARD_REQUIRE_EMAIL"`
+	LeaderboardMinActiveDays        int                          `yaml:"leaderboard_min_active_days" default:"0" env:"WAKAPI_LEADERBOARD_MIN_ACTIVE_DAYS"`
+	LeaderboardExcludedLanguages    string                       `yaml:"leaderboard_excluded_languages" default:"" env:"WAKAPI_LEADERBOARD_EXCLUDED_LANGUAGES"`      // comma-separated list of languages
+	LeaderboardSources              string                       `yaml:"leaderboard_sources" default:"" env:"WAKAPI_LEADERBOARD_SOURCES"`                            // comma-separated list of heartbeat sources (plugin, import, backfill, synthesized), all if blank
+	LeaderboardCategories           string                       `yaml:"leaderboard_categories" default:"" env:"WAKAPI_LEADERBOARD_CATEGORIES"`                      // comma-separated list of heartbeat categories (e.g. coding, debugging), all if blank
+	LeaderboardExcludeBackfilled    bool                         `yaml:"leaderboard_exclude_backfilled" default:"false" env:"WAKAPI_LEADERBOARD_EXCLUDE_BACKFILLED"` // never count imported or backfilled heartbeats, nor any from before the current season
+	LeaderboardExcludeManual        bool                         `yaml:"leaderboard_exclude_manual" default:"false" env:"WAKAPI_LEADERBOARD_EXCLUDE_MANUAL"`         // never count manually entered time or external durations pushed by integrations
+	LeaderboardWebhooks             bool                         `yaml:"leaderboard_webhooks" default:"false" env:"WAKAPI_LEADERBOARD_WEBHOOKS"`                     // let users set up webhooks to be notified about changes of their rank
 	AggregationTime                 string                       `yaml:"aggregation_time" default:"0 15 2 * * *" env:"WAKAPI_AGGREGATION_TIME"`
+	ResummarizeThrottleMs           int                          `yaml:"resummarize_throttle_ms" default:"100" env:"WAKAPI_RESUMMARIZE_THROTTLE_MS"` // pause between days when re-materializing summaries
 	ReportTimeWeekly                string                       `yaml:"report_time_weekly" default:"0 0 18 * * 5" env:"WAKAPI_REPORT_TIME_WEEKLY"`
 	DataCleanupTime                 string                       `yaml:"data_cleanup_time" default:"0 0 6 * * 0" env:"WAKAPI_DATA_CLEANUP_TIME"`
+	JanitorTime                     string                       `yaml:"janitor_time" default:"0 45 4 * * *" env:"WAKAPI_JANITOR_TIME"` // removal of stale key-values, invite codes and share links, disabled if blank
+	YearReviewTime                  string                       `yaml:"year_review_time" default:"0 0 4 2 1 *" env:"WAKAPI_YEAR_REVIEW_TIME"`
+	ActivityGraphTime               string                       `yaml:"activity_graph_time" default:"0 30 3 * * *" env:"WAKAPI_ACTIVITY_GRAPH_TIME"`
+	TeamDigestTime                  string                       `yaml:"team_digest_time" default:"0 0 9 * * 1" env:"WAKAPI_TEAM_DIGEST_TIME"` // weekly digest of every team, posted to its slack channel
 	ImportEnabled                   bool                         `yaml:"import_enabled" default:"true" env:"WAKAPI_IMPORT_ENABLED"`
 	ImportBackoffMin                int                          `yaml:"import_backoff_min" default:"5" env:"WAKAPI_IMPORT_BACKOFF_MIN"`
 	ImportMaxRate                   int                          `yaml:"import_max_rate" default:"24" env:"WAKAPI_IMPORT_MAX_RATE"` // at max one successful import every x hours
 	ImportBatchSize                 int                          `yaml:"import_batch_size" default:"50" env:"WAKAPI_IMPORT_BATCH_SIZE"`
+	ImportMaxUploadMb               int                          `yaml:"import_max_upload_mb" default:"8192" env:"WAKAPI_IMPORT_MAX_UPLOAD_MB"` // maximum size of archives uploaded via pre-signed urls
 	InactiveDays                    int                          `yaml:"inactive_days" default:"7" env:"WAKAPI_INACTIVE_DAYS"`
 	HeartbeatMaxAge                 string                       `yaml:"heartbeat_max_age" default:"4320h" env:"WAKAPI_HEARTBEAT_MAX_AGE"`
+	HeartbeatMaxFutureSkew          string                       `yaml:"heartbeat_max_future_skew" default:"1h" env:"WAKAPI_HEARTBEAT_MAX_FUTURE_SKEW"`
+	HeartbeatClampFutureSkew        bool                         `yaml:"heartbeat_clamp_future_skew" default:"false" env:"WAKAPI_HEARTBEAT_CLAMP_FUTURE_SKEW"`
+	IngestionBlocklist              []string                     `yaml:"ingestion_blocklist" env:"WAKAPI_INGESTION_BLOCKLIST"`                               // case-insensitive regexes, heartbeats whose project or entity match are dropped for all users
+	HeartbeatMaxBodyKb              int64                        `yaml:"heartbeat_max_body_kb" default:"64" env:"WAKAPI_HEARTBEAT_MAX_BODY_KB"`              // max. request size for single heartbeats
+	HeartbeatBulkMaxBodyKb          int64                        `yaml:"heartbeat_bulk_max_body_kb" default:"16384" env:"WAKAPI_HEARTBEAT_BULK_MAX_BODY_KB"` // max. request size for bulk heartbeats
+	MaxQuarantinedHeartbeats        int64                        `yaml:"max_quarantined_heartbeats" default:"10000" env:"WAKAPI_MAX_QUARANTINED_HEARTBEATS"` // per machine pending approval, further heartbeats are dropped (-1 for infinity)
 	CountCacheTTLMin                int                          `yaml:"count_cache_ttl_min" default:"30" env:"WAKAPI_COUNT_CACHE_TTL_MIN"`
 	DataRetentionMonths             int                          `yaml:"data_retention_months" default:"-1" env:"WAKAPI_DATA_RETENTION_MONTHS"`
-	DataCleanupDryRun               bool                         `yaml:"data_cleanup_dry_run" default:"false" env:"WAKAPI_DATA_CLEANUP_DRY_RUN"` // for debugging only
+	DataCleanupDryRun               bool                         `yaml:"data_cleanup_dry_run" default:"false" env:"WAKAPI_DATA_CLEANUP_DRY_RUN"`          // for debugging only
+	GeoIpDb                         string                       `yaml:"geoip_db" default:"" env:"WAKAPI_GEOIP_DB"`                                       // csv file mapping ip ranges to countries (start,end,country), geo features are disabled if blank
+	ConcurrencyRetentionDays        int                          `yaml:"concurrency_retention_days" default:"90" env:"WAKAPI_CONCURRENCY_RETENTION_DAYS"` // how long to keep per-minute samples of concurrently active users (-1 for infinity)
+	InviteRetentionDays             int                          `yaml:"invite_retention_days" default:"30" env:"WAKAPI_INVITE_RETENTION_DAYS"`           // how long to keep expired or used up invite codes (-1 for infinity)
 	MaxInactiveMonths               int                          `yaml:"max_inactive_months" default:"-1" env:"WAKAPI_MAX_INACTIVE_MONTHS"`
 	AvatarURLTemplate               string                       `yaml:"avatar_url_template" default:"api/avatar/{username_hash}.svg" env:"WAKAPI_AVATAR_URL_TEMPLATE"`
 	SupportContact                  string                       `yaml:"support_contact" default:"hostmaster@wakapi.dev" env:"WAKAPI_SUPPORT_CONTACT"`
 	DateFormat                      string                       `yaml:"date_format" default:"Mon, 02 Jan 2006" env:"WAKAPI_DATE_FORMAT"`
 	DateTimeFormat                  string                       `yaml:"datetime_format" default:"Mon, 02 Jan 2006 15:04" env:"WAKAPI_DATETIME_FORMAT"`
+	DefaultLocale                   string                       `yaml:"default_locale" default:"en" env:"WAKAPI_DEFAULT_LOCALE"` // language of generated texts for users who didn't choose one
+	LocalesDir                      string                       `yaml:"locales_dir" default:"" env:"WAKAPI_LOCALES_DIR"`         // directory of additional message catalogs, e.g. fr.json, which may also override built-in messages
 	CustomLanguages                 map[string]string            `yaml:"custom_languages"`
 	Colors                          map[string]map[string]string `yaml:"-"`
 }
 
 type securityConfig struct {
-	AllowSignup      bool   `yaml:"allow_signup" default:"true" env:"WAKAPI_ALLOW_SIGNUP"`
-	SignupCaptcha    bool   `yaml:"signup_captcha" default:"false" env:"WAKAPI_SIGNUP_CAPTCHA"`
-	InviteCodes      bool   `yaml:"invite_codes" default:"true" env:"WAKAPI_INVITE_CODES"`
-	ExposeMetrics    bool   `yaml:"expose_metrics" default:"false" env:"WAKAPI_EXPOSE_METRICS"`
-	EnableProxy      bool   `yaml:"enable_proxy" default:"false" env:"WAKAPI_ENABLE_PROXY"` // only intended for production instance at wakapi.dev
-	DisableFrontpage bool   `yaml:"disable_frontpage" default:"false" env:"WAKAPI_DISABLE_FRONTPAGE"`
-	AdminToken       string `yaml:"admin_token" default:"blahaji_rulz_da_world" env:"WAKAPI_ADMIN_TOKEN"`
+	AllowSignup            bool   `yaml:"allow_signup" default:"true" env:"WAKAPI_ALLOW_SIGNUP"`
+	SignupCaptcha          bool   `yaml:"signup_captcha" default:"false" env:"WAKAPI_SIGNUP_CAPTCHA"`
+	InviteCodes            bool   `yaml:"invite_codes" default:"true" env:"WAKAPI_INVITE_CODES"`
+	ExposeMetrics          bool   `yaml:"expose_metrics" default:"false" env:"WAKAPI_EXPOSE_METRICS"`
+	ExposeUserDailyMetrics bool   `yaml:"expose_user_daily_metrics" default:"false" env:"WAKAPI_EXPOSE_USER_DAILY_METRICS"` // per-user daily totals of leaderboard participants, only exposed to admins
+	EnableProxy            bool   `yaml:"enable_proxy" default:"false" env:"WAKAPI_ENABLE_PROXY"`                           // only intended for production instance at wakapi.dev
+	DisableFrontpage       bool   `yaml:"disable_frontpage" default:"false" env:"WAKAPI_DISABLE_FRONTPAGE"`
+	AdminToken             string `yaml:"admin_token" default:"blahaji_rulz_da_world" env:"WAKAPI_ADMIN_TOKEN"`
 	// this is actually a pepper (https://en.wikipedia.org/wiki/Pepper_(cryptography))
 	PasswordSalt               string                     `yaml:"password_salt" default:"" env:"WAKAPI_PASSWORD_SALT"`
+	PasswordHashAlgorithm      string                     `yaml:"password_hash_algorithm" default:"argon2id" env:"WAKAPI_PASSWORD_HASH_ALGORITHM"` // one of argon2id, bcrypt
+	Argon2Memory               uint32                     `yaml:"argon2_memory" default:"65536" env:"WAKAPI_ARGON2_MEMORY"`                        // in kib
+	Argon2Iterations           uint32                     `yaml:"argon2_iterations" default:"1" env:"WAKAPI_ARGON2_ITERATIONS"`
+	Argon2Parallelism          uint8                      `yaml:"argon2_parallelism" default:"2" env:"WAKAPI_ARGON2_PARALLELISM"`
+	BcryptCost                 int                        `yaml:"bcrypt_cost" default:"10" env:"WAKAPI_BCRYPT_COST"`
 	InsecureCookies            bool                       `yaml:"insecure_cookies" default:"false" env:"WAKAPI_INSECURE_COOKIES"`
 	CookieMaxAgeSec            int                        `yaml:"cookie_max_age" default:"172800" env:"WAKAPI_COOKIE_MAX_AGE"`
 	TrustedHeaderAuth          bool                       `yaml:"trusted_header_auth" default:"false" env:"WAKAPI_TRUSTED_HEADER_AUTH"`
@@ -123,9 +192,27 @@ type securityConfig struct {
 	SignupMaxRate              string                     `yaml:"signup_max_rate" default:"5/1h" env:"WAKAPI_SIGNUP_MAX_RATE"`
 	LoginMaxRate               string                     `yaml:"login_max_rate" default:"10/1m" env:"WAKAPI_LOGIN_MAX_RATE"`
 	PasswordResetMaxRate       string                     `yaml:"password_reset_max_rate" default:"5/1h" env:"WAKAPI_PASSWORD_RESET_MAX_RATE"`
+	LoginLockoutThreshold      int                        `yaml:"login_lockout_threshold" default:"5" env:"WAKAPI_LOGIN_LOCKOUT_THRESHOLD"` // failed attempts after which an ip or account is locked out, 0 to disable
+	LoginLockoutBase           string                     `yaml:"login_lockout_base" default:"1m" env:"WAKAPI_LOGIN_LOCKOUT_BASE"`          // initial lockout duration, doubled with every further failed attempt
+	LoginLockoutMax            string                     `yaml:"login_lockout_max" default:"1h" env:"WAKAPI_LOGIN_LOCKOUT_MAX"`
+	LoginCaptchaThreshold      int                        `yaml:"login_captcha_threshold" default:"3" env:"WAKAPI_LOGIN_CAPTCHA_THRESHOLD"`           // failed attempts after which a captcha is required to log in, 0 to disable
+	SecretScanning             bool                       `yaml:"secret_scanning" default:"false" env:"WAKAPI_SECRET_SCANNING"`                       // whether to accept leaked key reports from github secret scanning
+	ScimToken                  string                     `yaml:"scim_token" default:"" env:"WAKAPI_SCIM_TOKEN"`                                      // bearer token for scim user provisioning, leave blank to disable
+	IngestionAllowIps          string                     `yaml:"ingestion_allow_ips" default:"" env:"WAKAPI_INGESTION_ALLOW_IPS"`                    // comma-separated list of ips or cidr ranges allowed to send heartbeats, any if blank
+	IngestionDenyIps           string                     `yaml:"ingestion_deny_ips" default:"" env:"WAKAPI_INGESTION_DENY_IPS"`                      // comma-separated list of ips or cidr ranges never allowed to send heartbeats
+	RequireEmailVerification   bool                       `yaml:"require_email_verification" default:"false" env:"WAKAPI_REQUIRE_EMAIL_VERIFICATION"` // whether to only send notifications and reports to verified e-mail addresses
+	ApiKeyStorage              string                     `yaml:"api_key_storage" default:"plain" env:"WAKAPI_API_KEY_STORAGE"`                       // one of plain, hashed, encrypted
+	ApiKeySecretFile           string                     `yaml:"api_key_secret_file" default:"" env:"WAKAPI_API_KEY_SECRET_FILE"`                    // secret to key the hash or encryption of api keys, password_salt is used for hashing if blank
+	RegistrationMode           string                     `yaml:"registration_mode" default:"" env:"WAKAPI_REGISTRATION_MODE"`                        // one of open, invite, domain, closed, derived from allow_signup and invite_codes if blank, can be changed by admins at runtime
+	RegistrationDomains        string                     `yaml:"registration_domains" default:"" env:"WAKAPI_REGISTRATION_DOMAINS"`                  // comma-separated list of e-mail domains allowed to sign up in domain mode
+	DeviceFlow                 bool                       `yaml:"device_flow" default:"true" env:"WAKAPI_DEVICE_FLOW"`                                // whether plugins may obtain api keys by having the user enter a short code on the website
+	DeviceCodeTTLMin           int                        `yaml:"device_code_ttl_min" default:"15" env:"WAKAPI_DEVICE_CODE_TTL_MIN"`
 	SecureCookie               *securecookie.SecureCookie `yaml:"-"`
 	SessionKey                 []byte                     `yaml:"-"`
 	trustReverseProxyIpsParsed []net.IPNet
+	ingestionAllowIpsParsed    []net.IPNet
+	ingestionDenyIpsParsed     []net.IPNet
+	apiKeyStore                utils.ApiKeyStore
 }
 
 type dbConfig struct {
@@ -145,16 +232,24 @@ type dbConfig struct {
 }
 
 type serverConfig struct {
-	Port             int    `default:"3000" env:"PORT"`
-	ListenIpV4       string `yaml:"listen_ipv4" default:"127.0.0.1" env:"WAKAPI_LISTEN_IPV4"`
-	ListenIpV6       string `yaml:"listen_ipv6" default:"::1" env:"WAKAPI_LISTEN_IPV6"`
-	ListenSocket     string `yaml:"listen_socket" default:"" env:"WAKAPI_LISTEN_SOCKET"`
-	ListenSocketMode uint32 `yaml:"listen_socket_mode" default:"0666" env:"WAKAPI_LISTEN_SOCKET_MODE"`
-	TimeoutSec       int    `yaml:"timeout_sec" default:"30" env:"WAKAPI_TIMEOUT_SEC"`
-	BasePath         string `yaml:"base_path" default:"/" env:"WAKAPI_BASE_PATH"`
-	PublicUrl        string `yaml:"public_url" default:"http://localhost:3000" env:"WAKAPI_PUBLIC_URL"`
-	TlsCertPath      string `yaml:"tls_cert_path" default:"" env:"WAKAPI_TLS_CERT_PATH"`
-	TlsKeyPath       string `yaml:"tls_key_path" default:"" env:"WAKAPI_TLS_KEY_PATH"`
+	Port               int                   `default:"3000" env:"PORT"`
+	ListenIpV4         string                `yaml:"listen_ipv4" default:"127.0.0.1" env:"WAKAPI_LISTEN_IPV4"`
+	ListenIpV6         string                `yaml:"listen_ipv6" default:"::1" env:"WAKAPI_LISTEN_IPV6"`
+	ListenSocket       string                `yaml:"listen_socket" default:"" env:"WAKAPI_LISTEN_SOCKET"`
+	ListenSocketMode   uint32                `yaml:"listen_socket_mode" default:"0666" env:"WAKAPI_LISTEN_SOCKET_MODE"`
+	TimeoutSec         int                   `yaml:"timeout_sec" default:"30" env:"WAKAPI_TIMEOUT_SEC"`
+	ShutdownTimeoutSec int                   `yaml:"shutdown_timeout_sec" default:"30" env:"WAKAPI_SHUTDOWN_TIMEOUT_SEC"` // how long to wait for in-flight requests and pending jobs to complete upon shutdown
+	BasePath           string                `yaml:"base_path" default:"/" env:"WAKAPI_BASE_PATH"`
+	PublicUrl          string                `yaml:"public_url" default:"http://localhost:3000" env:"WAKAPI_PUBLIC_URL"`
+	TlsCertPath        string                `yaml:"tls_cert_path" default:"" env:"WAKAPI_TLS_CERT_PATH"`
+	TlsKeyPath         string                `yaml:"tls_key_path" default:"" env:"WAKAPI_TLS_KEY_PATH"`
+	RequestTimeouts    requestTimeoutsConfig `yaml:"request_timeouts"`
+}
+
+// requestTimeoutsConfig holds deadlines of expensive routes, after which their database queries are canceled
+type requestTimeoutsConfig struct {
+	Summary string `yaml:"summary" default:"25s" env:"WAKAPI_REQUEST_TIMEOUT_SUMMARY"` // summary api and page, wakatime-compatible summaries and status bar
+	Stats   string `yaml:"stats" default:"25s" env:"WAKAPI_REQUEST_TIMEOUT_STATS"`     // wakatime-compatible stats and all time
 }
 
 type subscriptionsConfig struct {
@@ -199,6 +294,139 @@ type shopConfig struct {
 	AirtableProductTableName string `env:"WAKAPI_SHOP_AIRTABLE_PRODUCT_TABLE_NAME"`
 }
 
+type exportsConfig struct {
+	Enabled bool                `yaml:"enabled" default:"false" env:"WAKAPI_EXPORTS_ENABLED"`
+	Time    string              `yaml:"time" default:"0 0 7 * * 1" env:"WAKAPI_EXPORTS_TIME"`
+	Users   string              `yaml:"users" default:"" env:"WAKAPI_EXPORTS_USERS"` // comma-separated list of user ids to include, leave blank for all users
+	Webhook ExportWebhookConfig `yaml:"webhook"`
+	S3      ExportS3Config      `yaml:"s3"`
+	Sftp    ExportSftpConfig    `yaml:"sftp"`
+}
+
+type ExportWebhookConfig struct {
+	Url    string `yaml:"url" env:"WAKAPI_EXPORTS_WEBHOOK_URL"`
+	Secret string `yaml:"secret" env:"WAKAPI_EXPORTS_WEBHOOK_SECRET"`
+}
+
+type ExportS3Config struct {
+	Endpoint  string `yaml:"endpoint" env:"WAKAPI_EXPORTS_S3_ENDPOINT"`
+	Region    string `yaml:"region" default:"us-east-1" env:"WAKAPI_EXPORTS_S3_REGION"`
+	Bucket    string `yaml:"bucket" env:"WAKAPI_EXPORTS_S3_BUCKET"`
+	Prefix    string `yaml:"prefix" env:"WAKAPI_EXPORTS_S3_PREFIX"`
+	AccessKey string `yaml:"access_key" env:"WAKAPI_EXPORTS_S3_ACCESS_KEY"`
+	SecretKey string `yaml:"secret_key" env:"WAKAPI_EXPORTS_S3_SECRET_KEY"`
+}
+
+type ExportSftpConfig struct {
+	Host                string `yaml:"host" env:"WAKAPI_EXPORTS_SFTP_HOST"`
+	Port                uint   `yaml:"port" default:"22" env:"WAKAPI_EXPORTS_SFTP_PORT"`
+	Username            string `yaml:"username" env:"WAKAPI_EXPORTS_SFTP_USER"`
+	Password            string `yaml:"password" env:"WAKAPI_EXPORTS_SFTP_PASS"`
+	HostKey             string `yaml:"host_key" env:"WAKAPI_EXPORTS_SFTP_HOST_KEY"`                                             // public key of the server in authorized_keys format
+	InsecureSkipHostKey bool   `yaml:"insecure_skip_host_key" default:"false" env:"WAKAPI_EXPORTS_SFTP_INSECURE_SKIP_HOST_KEY"` // connect without verifying the server's identity, if no host key is given
+	Path                string `yaml:"path" default:"." env:"WAKAPI_EXPORTS_SFTP_PATH"`
+}
+
+type streamsConfig struct {
+	Enabled         bool              `yaml:"enabled" default:"false" env:"WAKAPI_STREAMS_ENABLED"`
+	HeartbeatsTopic string            `yaml:"heartbeats_topic" default:"hackatime.heartbeats" env:"WAKAPI_STREAMS_HEARTBEATS_TOPIC"`
+	SummariesTopic  string            `yaml:"summaries_topic" default:"hackatime.summaries" env:"WAKAPI_STREAMS_SUMMARIES_TOPIC"`
+	BufferSize      int               `yaml:"buffer_size" default:"10000" env:"WAKAPI_STREAMS_BUFFER_SIZE"` // events are dropped once the buffer is full, e.g. while a broker is unavailable
+	Nats            StreamNatsConfig  `yaml:"nats"`
+	Kafka           StreamKafkaConfig `yaml:"kafka"`
+}
+
+type StreamNatsConfig struct {
+	Url      string `yaml:"url" env:"WAKAPI_STREAMS_NATS_URL"` // e.g. nats://localhost:4222 or tls://nats.example.org:4222
+	Username string `yaml:"username" env:"WAKAPI_STREAMS_NATS_USER"`
+	Password string `yaml:"password" env:"WAKAPI_STREAMS_NATS_PASS"`
+	Token    string `yaml:"token" env:"WAKAPI_STREAMS_NATS_TOKEN"`
+}
+
+type StreamKafkaConfig struct {
+	RestUrl  string `yaml:"rest_url" env:"WAKAPI_STREAMS_KAFKA_REST_URL"` // base url of a kafka rest proxy (v2 api), e.g. http://localhost:8082
+	Username string `yaml:"username" env:"WAKAPI_STREAMS_KAFKA_USER"`
+	Password string `yaml:"password" env:"WAKAPI_STREAMS_KAFKA_PASS"`
+}
+
+type objectsConfig struct {
+	Provider      string          `yaml:"provider" default:"local" env:"WAKAPI_OBJECTS_PROVIDER"`
+	Path          string          `yaml:"path" default:"data/objects" env:"WAKAPI_OBJECTS_PATH"`
+	Ttl           string          `yaml:"ttl" default:"168h" env:"WAKAPI_OBJECTS_TTL"`                   // stored objects are deleted after this duration
+	LinkExpiry    string          `yaml:"link_expiry" default:"24h" env:"WAKAPI_OBJECTS_LINK_EXPIRY"`    // validity of signed download links
+	SigningSecret string          `yaml:"signing_secret" default:"" env:"WAKAPI_OBJECTS_SIGNING_SECRET"` // used to sign local download links, random if left blank
+	S3            ObjectsS3Config `yaml:"s3"`
+}
+
+type ObjectsS3Config struct {
+	Endpoint  string `yaml:"endpoint" env:"WAKAPI_OBJECTS_S3_ENDPOINT"`
+	Region    string `yaml:"region" default:"us-east-1" env:"WAKAPI_OBJECTS_S3_REGION"`
+	Bucket    string `yaml:"bucket" env:"WAKAPI_OBJECTS_S3_BUCKET"`
+	Prefix    string `yaml:"prefix" env:"WAKAPI_OBJECTS_S3_PREFIX"`
+	AccessKey string `yaml:"access_key" env:"WAKAPI_OBJECTS_S3_ACCESS_KEY"`
+	SecretKey string `yaml:"secret_key" env:"WAKAPI_OBJECTS_S3_SECRET_KEY"`
+}
+
+type leaderboardSeasonsConfig struct {
+	Period          string `yaml:"period" default:"" env:"WAKAPI_LEADERBOARD_SEASONS_PERIOD"` // weekly, monthly or empty to disable seasons
+	NumWinners      int    `yaml:"num_winners" default:"3" env:"WAKAPI_LEADERBOARD_SEASONS_NUM_WINNERS"`
+	WebhookUrl      string `yaml:"webhook_url" env:"WAKAPI_LEADERBOARD_SEASONS_WEBHOOK_URL"`
+	WebhookSecret   string `yaml:"webhook_secret" env:"WAKAPI_LEADERBOARD_SEASONS_WEBHOOK_SECRET"`
+	SlackWebhookUrl string `yaml:"slack_webhook_url" env:"WAKAPI_LEADERBOARD_SEASONS_SLACK_WEBHOOK_URL"`
+}
+
+type inactivityAlertsConfig struct {
+	Enabled       bool   `yaml:"enabled" default:"false" env:"WAKAPI_INACTIVITY_ALERTS_ENABLED"`
+	WorkDays      string `yaml:"work_days" default:"monday,tuesday,wednesday,thursday,friday" env:"WAKAPI_INACTIVITY_ALERTS_WORK_DAYS"` // comma-separated list of weekdays, only time on these days counts towards a user's inactivity
+	WebhookUrl    string `yaml:"webhook_url" env:"WAKAPI_INACTIVITY_ALERTS_WEBHOOK_URL"`                                                // additionally notified about every alert, e.g. for team leads
+	WebhookSecret string `yaml:"webhook_secret" env:"WAKAPI_INACTIVITY_ALERTS_WEBHOOK_SECRET"`
+}
+
+type scrapbookConfig struct {
+	Enabled         bool   `yaml:"enabled" default:"false" env:"WAKAPI_SCRAPBOOK_ENABLED"`
+	ApiUrl          string `yaml:"api_url" env:"WAKAPI_SCRAPBOOK_API_URL"` // receives a json payload for every completed coding session of opted-in users
+	ApiToken        string `yaml:"api_token" env:"WAKAPI_SCRAPBOOK_API_TOKEN"`
+	SessionGapMin   int    `yaml:"session_gap_min" default:"30" env:"WAKAPI_SCRAPBOOK_SESSION_GAP_MIN"`     // a session is considered completed after this many minutes without heartbeats
+	MinSessionMin   int    `yaml:"min_session_min" default:"15" env:"WAKAPI_SCRAPBOOK_MIN_SESSION_MIN"`     // shorter sessions are not posted
+	DefaultTemplate string `yaml:"default_template" default:"Worked on {{ .Project }} for {{ .Duration }}"` // go text template, users may override it
+}
+
+type pluginUpdatesConfig struct {
+	Enabled     bool   `yaml:"enabled" default:"false" env:"WAKAPI_PLUGIN_UPDATES_ENABLED"`
+	Path        string `yaml:"path" default:"data/releases" env:"WAKAPI_PLUGIN_UPDATES_PATH"`    // one sub directory per release tag, containing its assets
+	UpstreamUrl string `yaml:"upstream_url" default:"" env:"WAKAPI_PLUGIN_UPDATES_UPSTREAM_URL"` // github repository api url to mirror releases from, e.g. https://api.github.com/repos/wakatime/wakatime-cli, leave blank for air-gapped setups
+	CacheTtl    string `yaml:"cache_ttl" default:"1h" env:"WAKAPI_PLUGIN_UPDATES_CACHE_TTL"`     // how long to cache the upstream's latest release
+}
+
+type telegramConfig struct {
+	BotToken      string `yaml:"bot_token" env:"WAKAPI_TELEGRAM_BOT_TOKEN"` // the bot is disabled if blank
+	BotUsername   string `yaml:"bot_username" env:"WAKAPI_TELEGRAM_BOT_USERNAME"`
+	WebhookSecret string `yaml:"webhook_secret" env:"WAKAPI_TELEGRAM_WEBHOOK_SECRET"` // derived from the bot token if blank
+	ApiUrl        string `yaml:"api_url" default:"https://api.telegram.org" env:"WAKAPI_TELEGRAM_API_URL"`
+}
+
+type queryConsoleConfig struct {
+	Enabled    bool   `yaml:"enabled" default:"false" env:"WAKAPI_QUERY_CONSOLE_ENABLED"`
+	DSN        string `yaml:"dsn" env:"WAKAPI_QUERY_CONSOLE_DSN"` // connection string of a restricted, read-only database role, required for mysql and postgres
+	MaxRows    int    `yaml:"max_rows" default:"500" env:"WAKAPI_QUERY_CONSOLE_MAX_ROWS"`
+	TimeoutSec int    `yaml:"timeout_sec" default:"10" env:"WAKAPI_QUERY_CONSOLE_TIMEOUT_SEC"`
+}
+
+type legalConfig struct {
+	Version     string `yaml:"version" env:"WAKAPI_LEGAL_VERSION"` // bumping it requires every user to accept the terms again upon their next login, leave blank to disable consent tracking
+	TermsFile   string `yaml:"terms_file" env:"WAKAPI_LEGAL_TERMS_FILE"`
+	PrivacyFile string `yaml:"privacy_file" env:"WAKAPI_LEGAL_PRIVACY_FILE"`
+}
+
+type loadSheddingConfig struct {
+	Enabled        bool `yaml:"enabled" default:"false" env:"WAKAPI_LOAD_SHEDDING_ENABLED"`
+	MaxDbLatencyMs int  `yaml:"max_db_latency_ms" default:"1000" env:"WAKAPI_LOAD_SHEDDING_MAX_DB_LATENCY_MS"`
+	MaxQueueDepth  int  `yaml:"max_queue_depth" default:"2000" env:"WAKAPI_LOAD_SHEDDING_MAX_QUEUE_DEPTH"` // pending jobs across all queues
+	MaxSpoolSize   int  `yaml:"max_spool_size" default:"100000" env:"WAKAPI_LOAD_SHEDDING_MAX_SPOOL_SIZE"`
+	CheckInterval  int  `yaml:"check_interval_sec" default:"5" env:"WAKAPI_LOAD_SHEDDING_CHECK_INTERVAL_SEC"`
+	RetryAfterSec  int  `yaml:"retry_after_sec" default:"30" env:"WAKAPI_LOAD_SHEDDING_RETRY_AFTER_SEC"`
+}
+
 type Config struct {
 	Env            string `default:"dev" env:"ENVIRONMENT"`
 	Version        string `yaml:"-"`
@@ -214,6 +442,34 @@ type Config struct {
 	Sentry         sentryConfig
 	Mail           mailConfig
 	Shop           shopConfig
+	Exports        exportsConfig
+	Streams        streamsConfig
+	Objects        objectsConfig
+	LoadShedding   loadSheddingConfig       `yaml:"load_shedding"`
+	Seasons        leaderboardSeasonsConfig `yaml:"leaderboard_seasons"`
+	Legal          legalConfig
+	Inactivity     inactivityAlertsConfig `yaml:"inactivity_alerts"`
+	PluginUpdates  pluginUpdatesConfig    `yaml:"plugin_updates"`
+	Scrapbook      scrapbookConfig
+	Telegram       telegramConfig
+	QueryConsole   queryConsoleConfig `yaml:"query_console"`
+}
+
+func (c *telegramConfig) Enabled() bool {
+	return c.BotToken != ""
+}
+
+// GetWebhookSecret returns the token telegram is asked to send along with every update, to tell them apart from forged ones
+func (c *telegramConfig) GetWebhookSecret() string {
+	if c.WebhookSecret != "" {
+		return c.WebhookSecret
+	}
+	hash := sha256.Sum256([]byte("telegram-webhook:" + c.BotToken))
+	return hex.EncodeToString(hash[:])
+}
+
+func (c *legalConfig) RequiresConsent() bool {
+	return c.Version != ""
 }
 
 func (c *Config) CreateCookie(name, value string) *http.Cookie {
@@ -303,6 +559,18 @@ func (c *appConfig) GetWeeklyReportCron() string {
 	return utils.CronPadToSecondly(c.ReportTimeWeekly)
 }
 
+func (c *appConfig) GetYearReviewCron() string {
+	return utils.CronPadToSecondly(c.YearReviewTime)
+}
+
+func (c *appConfig) GetActivityGraphCron() string {
+	return utils.CronPadToSecondly(c.ActivityGraphTime)
+}
+
+func (c *appConfig) GetTeamDigestCron() string {
+	return utils.CronPadToSecondly(c.TeamDigestTime)
+}
+
 func (c *appConfig) GetLeaderboardGenerationTimeCron() []string {
 	crons := []string{}
 
@@ -337,19 +605,196 @@ func (c *appConfig) GetLeaderboardGenerationTimeCron() []string {
 	return crons
 }
 
+func (c *exportsConfig) GetTimeCron() string {
+	return utils.CronPadToSecondly(c.Time)
+}
+
+func (c *exportsConfig) GetUserIds() []string {
+	userIds := make([]string, 0)
+	for _, s := range strings.Split(c.Users, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			userIds = append(userIds, s)
+		}
+	}
+	return userIds
+}
+
+func (c *objectsConfig) GetTtl() time.Duration {
+	d, _ := time.ParseDuration(c.Ttl)
+	return d
+}
+
+func (c *objectsConfig) GetLinkExpiry() time.Duration {
+	d, _ := time.ParseDuration(c.LinkExpiry)
+	return d
+}
+
+func (c *leaderboardSeasonsConfig) Enabled() bool {
+	return c.Period != ""
+}
+
+// GetScope returns the interval live leaderboards are computed for while seasons are enabled, i.e. the current season so far
+func (c *leaderboardSeasonsConfig) GetScope() string {
+	if c.Period == SeasonPeriodMonthly {
+		return "month"
+	}
+	return "week"
+}
+
+// GetCron returns the cron expression to close seasons with, shortly after they ended (only relevant if enabled)
+func (c *leaderboardSeasonsConfig) GetCron() string {
+	if c.Period == SeasonPeriodMonthly {
+		return "0 5 0 1 * *"
+	}
+	return "0 5 0 * * 1"
+}
+
+func (c *requestTimeoutsConfig) GetSummary() time.Duration {
+	d, _ := time.ParseDuration(c.Summary)
+	return d
+}
+
+func (c *requestTimeoutsConfig) GetStats() time.Duration {
+	d, _ := time.ParseDuration(c.Stats)
+	return d
+}
+
+func (c *pluginUpdatesConfig) GetCacheTtl() time.Duration {
+	d, _ := time.ParseDuration(c.CacheTtl)
+	return d
+}
+
+func (c *inactivityAlertsConfig) GetWorkDays() []time.Weekday {
+	days := make([]time.Weekday, 0)
+	for _, s := range strings.Split(c.WorkDays, ",") {
+		s = strings.ToLower(strings.TrimSpace(s))
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			if strings.ToLower(d.String()) == s {
+				days = append(days, d)
+			}
+		}
+	}
+	return days
+}
+
+func (c *appConfig) GetLeaderboardExcludedLanguages() []string {
+	languages := make([]string, 0)
+	for _, s := range strings.Split(c.LeaderboardExcludedLanguages, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			languages = append(languages, strings.ToLower(s))
+		}
+	}
+	return languages
+}
+
+func (c *appConfig) GetLeaderboardSources() []string {
+	sources := make([]string, 0)
+	for _, s := range strings.Split(c.LeaderboardSources, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			sources = append(sources, strings.ToLower(s))
+		}
+	}
+	return sources
+}
+
+func (c *appConfig) GetLeaderboardCategories() []string {
+	categories := make([]string, 0)
+	for _, s := range strings.Split(c.LeaderboardCategories, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			categories = append(categories, s)
+		}
+	}
+	return categories
+}
+
 func (c *appConfig) HeartbeatsMaxAge() time.Duration {
 	d, _ := time.ParseDuration(c.HeartbeatMaxAge)
 	return d
 }
 
+func (c *appConfig) HeartbeatsMaxFutureSkew() time.Duration {
+	d, _ := time.ParseDuration(c.HeartbeatMaxFutureSkew)
+	return d
+}
+
+// PasswordHasher returns the hasher for newly set passwords, existing hashes created differently are upgraded upon the next login
+func (c *securityConfig) PasswordHasher() utils.PasswordHasher {
+	if c.PasswordHashAlgorithm == utils.PasswordHashBcrypt {
+		return utils.NewBcryptHasher(c.BcryptCost)
+	}
+	return utils.NewArgon2IdHasher(c.Argon2Memory, c.Argon2Iterations, c.Argon2Parallelism)
+}
+
+// ApiKeyStore returns the store to seal api keys at rest with, nil if they're stored in plain text
+func (c *securityConfig) ApiKeyStore() utils.ApiKeyStore {
+	return c.apiKeyStore
+}
+
+func (c *securityConfig) initApiKeyStore() error {
+	switch c.ApiKeyStorage {
+	case utils.ApiKeyStoragePlain, "":
+		c.apiKeyStore = nil
+		return nil
+	case utils.ApiKeyStorageHashed, utils.ApiKeyStorageEncrypted:
+	default:
+		return fmt.Errorf("unknown api key storage '%s'", c.ApiKeyStorage)
+	}
+
+	secret := []byte(c.PasswordSalt)
+	if c.ApiKeySecretFile != "" {
+		data, err := os.ReadFile(c.ApiKeySecretFile)
+		if err != nil {
+			return err
+		}
+		secret = bytes.TrimSpace(data)
+	}
+
+	if c.ApiKeyStorage == utils.ApiKeyStorageHashed {
+		c.apiKeyStore = utils.NewHashedApiKeyStore(secret)
+		return nil
+	}
+	if c.ApiKeySecretFile == "" {
+		return errors.New("encrypted api key storage requires api_key_secret_file")
+	}
+	store, err := utils.NewEncryptedApiKeyStore(secret)
+	c.apiKeyStore = store
+	return err
+}
+
 func (c *securityConfig) ParseTrustReverseProxyIPs() {
-	c.trustReverseProxyIpsParsed = make([]net.IPNet, 0)
+	c.trustReverseProxyIpsParsed = parseIpNets(c.TrustReverseProxyIps, "reverse proxy")
+}
+
+func (c *securityConfig) TrustReverseProxyIPs() []net.IPNet {
+	return c.trustReverseProxyIpsParsed
+}
+
+func (c *securityConfig) ParseIngestionIPs() {
+	c.ingestionAllowIpsParsed = parseIpNets(c.IngestionAllowIps, "ingestion allowlist")
+	c.ingestionDenyIpsParsed = parseIpNets(c.IngestionDenyIps, "ingestion denylist")
+}
+
+func (c *securityConfig) IngestionAllowIPs() []net.IPNet {
+	return c.ingestionAllowIpsParsed
+}
+
+func (c *securityConfig) IngestionDenyIPs() []net.IPNet {
+	return c.ingestionDenyIpsParsed
+}
+
+// parseIpNets parses a comma-separated list of single ip addresses and address ranges (cidr notation)
+func parseIpNets(list string, name string) []net.IPNet {
+	ipNets := make([]net.IPNet, 0)
+
+	for _, ip := range strings.Split(list, ",") {
+		if strings.TrimSpace(ip) == "" {
+			continue
+		}
 
-	for _, ip := range strings.Split(c.TrustReverseProxyIps, ",") {
 		// try parse as address range
-		_, parsedIpNet, err := net.ParseCIDR(ip)
+		_, parsedIpNet, err := net.ParseCIDR(strings.TrimSpace(ip))
 		if err == nil {
-			c.trustReverseProxyIpsParsed = append(c.trustReverseProxyIpsParsed, *parsedIpNet)
+			ipNets = append(ipNets, *parsedIpNet)
 			continue
 		}
 
@@ -361,16 +806,38 @@ func (c *securityConfig) ParseTrustReverseProxyIPs() {
 				ipBits = net.IPv6len * 8
 			}
 			ipNet := net.IPNet{IP: parsedIp, Mask: net.CIDRMask(ipBits, ipBits)}
-			c.trustReverseProxyIpsParsed = append(c.trustReverseProxyIpsParsed, ipNet)
+			ipNets = append(ipNets, ipNet)
 			continue
 		}
 
-		slog.Warn("failed to parse reverse proxy ip ranges")
+		slog.Warn(fmt.Sprintf("failed to parse %s ip ranges", name))
+	}
+
+	return ipNets
+}
+
+// GetRegistrationMode returns the configured registration mode, falling back to the one implied by the legacy allow_signup and invite_codes options
+func (c *securityConfig) GetRegistrationMode() string {
+	if c.RegistrationMode != "" {
+		return strings.ToLower(c.RegistrationMode)
 	}
+	if c.AllowSignup {
+		return RegistrationModeOpen
+	}
+	if c.InviteCodes {
+		return RegistrationModeInvite
+	}
+	return RegistrationModeClosed
 }
 
-func (c *securityConfig) TrustReverseProxyIPs() []net.IPNet {
-	return c.trustReverseProxyIpsParsed
+func (c *securityConfig) GetRegistrationDomains() []string {
+	domains := make([]string, 0)
+	for _, d := range strings.Split(c.RegistrationDomains, ",") {
+		if d = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(d), "@")); d != "" {
+			domains = append(domains, d)
+		}
+	}
+	return domains
 }
 
 func (c *securityConfig) GetSignupMaxRate() (int, time.Duration) {
@@ -385,6 +852,16 @@ func (c *securityConfig) GetPasswordResetMaxRate() (int, time.Duration) {
 	return c.parseRate(c.PasswordResetMaxRate)
 }
 
+func (c *securityConfig) GetLoginLockoutBase() time.Duration {
+	d, _ := time.ParseDuration(c.LoginLockoutBase)
+	return d
+}
+
+func (c *securityConfig) GetLoginLockoutMax() time.Duration {
+	d, _ := time.ParseDuration(c.LoginLockoutMax)
+	return d
+}
+
 func (c *securityConfig) parseRate(rate string) (int, time.Duration) {
 	pattern := regexp.MustCompile("(\\d+)/(\\d+)([smh])")
 	matches := pattern.FindStringSubmatch(rate)
@@ -513,6 +990,7 @@ func Load(configFlag string, version string) *Config {
 	config.Security.SecureCookie = securecookie.New(hashKey, blockKey)
 	config.Security.SessionKey = sessionKey
 	config.Security.ParseTrustReverseProxyIPs()
+	config.Security.ParseIngestionIPs()
 
 	config.Server.BasePath = strings.TrimSuffix(config.Server.BasePath, "/")
 
@@ -557,6 +1035,80 @@ func Load(configFlag string, version string) *Config {
 	if _, err := time.ParseDuration(config.App.HeartbeatMaxAge); err != nil {
 		Log().Fatal("invalid duration set for heartbeat_max_age")
 	}
+	if _, err := time.ParseDuration(config.App.HeartbeatMaxFutureSkew); err != nil {
+		Log().Fatal("invalid duration set for heartbeat_max_future_skew")
+	}
+	for _, p := range config.App.IngestionBlocklist {
+		if _, err := regexp.Compile("(?i)" + p); err != nil {
+			Log().Fatal("invalid pattern in ingestion_blocklist", "pattern", p, "error", err)
+		}
+	}
+	for _, t := range []string{config.Server.RequestTimeouts.Summary, config.Server.RequestTimeouts.Stats} {
+		if _, err := time.ParseDuration(t); err != nil {
+			Log().Fatal("invalid duration set for server.request_timeouts", "value", t)
+		}
+	}
+	if config.App.HeartbeatMaxBodyKb <= 0 || config.App.HeartbeatBulkMaxBodyKb <= 0 {
+		Log().Fatal("heartbeat body size limits must be positive")
+	}
+	if utils.FindString(config.Objects.Provider, objectsProviders, "") == "" {
+		Log().Fatal("unknown object storage provider", "provider", config.Objects.Provider)
+	}
+	if config.Security.LoginLockoutThreshold > 0 && (config.Security.GetLoginLockoutBase() <= 0 || config.Security.GetLoginLockoutMax() < config.Security.GetLoginLockoutBase()) {
+		Log().Fatal("invalid duration set for login_lockout_base or login_lockout_max")
+	}
+	if config.Security.PasswordHashAlgorithm != utils.PasswordHashArgon2Id && config.Security.PasswordHashAlgorithm != utils.PasswordHashBcrypt {
+		Log().Fatal("unknown password hash algorithm", "algorithm", config.Security.PasswordHashAlgorithm)
+	}
+	if err := config.Security.initApiKeyStore(); err != nil {
+		Log().Fatal("invalid api key storage configuration", "error", err)
+	}
+	if config.Objects.Provider == ObjectsProviderS3 && config.Objects.S3.Bucket == "" {
+		Log().Fatal("object storage provider s3 requires a bucket")
+	}
+	if config.Exports.Sftp.Host != "" && config.Exports.Sftp.HostKey == "" && !config.Exports.Sftp.InsecureSkipHostKey {
+		Log().Fatal("sftp exports require exports.sftp.host_key (or explicitly setting insecure_skip_host_key)")
+	}
+	if config.Objects.GetTtl() <= 0 || config.Objects.GetLinkExpiry() <= 0 {
+		Log().Fatal("invalid duration set for objects.ttl or objects.link_expiry")
+	}
+	if config.App.LocalesDir != "" {
+		if err := i18n.LoadDir(config.App.LocalesDir); err != nil {
+			Log().Fatal("failed to load message catalogs", "dir", config.App.LocalesDir, "error", err)
+		}
+	}
+	if !i18n.IsSupported(config.App.DefaultLocale) {
+		Log().Fatal("unsupported default locale", "locale", config.App.DefaultLocale, "supported", i18n.Locales())
+	}
+	if config.Seasons.Enabled() && config.Seasons.Period != SeasonPeriodWeekly && config.Seasons.Period != SeasonPeriodMonthly {
+		Log().Fatal("leaderboard season period must be either weekly or monthly")
+	}
+	if _, err := time.ParseDuration(config.PluginUpdates.CacheTtl); config.PluginUpdates.Enabled && err != nil {
+		Log().Fatal("invalid duration set for plugin_updates.cache_ttl")
+	}
+	if config.Inactivity.Enabled && len(config.Inactivity.GetWorkDays()) == 0 {
+		Log().Fatal("inactivity alerts require at least one valid work day")
+	}
+	if mode := config.Security.GetRegistrationMode(); !slice.Contain(RegistrationModes, mode) {
+		Log().Fatal("unknown registration mode", "mode", mode)
+	} else if mode == RegistrationModeDomain && len(config.Security.GetRegistrationDomains()) == 0 {
+		Log().Fatal("registration mode domain requires at least one registration domain")
+	}
+	if config.Scrapbook.Enabled && (config.Scrapbook.ApiUrl == "" || config.Scrapbook.SessionGapMin <= 0) {
+		Log().Fatal("scrapbook integration requires an api url and a positive session gap")
+	}
+	if config.QueryConsole.Enabled && (config.Db.IsMssql() || config.QueryConsole.MaxRows <= 0 || config.QueryConsole.TimeoutSec <= 0) {
+		Log().Fatal("query console requires mysql, postgres or sqlite as well as positive row and time limits")
+	}
+	if config.QueryConsole.Enabled && (config.Db.IsMySQL() || config.Db.IsPostgres()) && config.QueryConsole.DSN == "" {
+		Log().Fatal("query console requires the connection string of a restricted, read-only database role (query_console.dsn) for mysql and postgres")
+	}
+	if config.LoadShedding.Enabled && (config.LoadShedding.CheckInterval <= 0 || config.LoadShedding.MaxSpoolSize < 0) {
+		Log().Fatal("invalid load shedding configuration")
+	}
+	if config.Objects.SigningSecret == "" {
+		config.Objects.SigningSecret = string(securecookie.GenerateRandomKey(32))
+	}
 	if config.Security.TrustedHeaderAuth && len(config.Security.trustReverseProxyIpsParsed) == 0 {
 		config.Security.TrustedHeaderAuth = false
 	}
@@ -572,9 +1124,26 @@ func Load(configFlag string, version string) *Config {
 	if _, err := cronParser.Parse(config.App.GetWeeklyReportCron()); err != nil {
 		Log().Fatal("invalid cron expression for report_time_weekly")
 	}
+	if _, err := cronParser.Parse(config.App.GetYearReviewCron()); err != nil {
+		Log().Fatal("invalid cron expression for year_review_time")
+	}
+	if _, err := cronParser.Parse(config.App.GetActivityGraphCron()); err != nil {
+		Log().Fatal("invalid cron expression for activity_graph_time")
+	}
+	if _, err := cronParser.Parse(config.App.GetTeamDigestCron()); err != nil {
+		Log().Fatal("invalid cron expression for team_digest_time")
+	}
 	if _, err := cronParser.Parse(config.App.GetAggregationTimeCron()); err != nil {
 		Log().Fatal("invalid cron expression for aggregation_time")
 	}
+	if _, err := cronParser.Parse(config.Exports.GetTimeCron()); err != nil {
+		Log().Fatal("invalid cron expression for exports.time")
+	}
+
+	if config.Streams.Enabled && config.Streams.BufferSize <= 0 {
+		Log().Fatal("streams.buffer_size must be positive")
+	}
+
 	for _, c := range config.App.GetLeaderboardGenerationTimeCron() {
 		if _, err := cronParser.Parse(c); err != nil {
 			Log().Fatal("invalid cron expression for leaderboard_generation_time")
@@ -59,6 +59,25 @@ func (c *dbConfig) GetDialector() gorm.Dialector {
 	return nil
 }
 
+// GetDialector returns the dialector of the query console's dedicated connection, or nil if it shares the main connection.
+// Sqlite databases are always opened a second time in read-only mode, as sqlite can't restrict single transactions to reading.
+func (c *queryConsoleConfig) GetDialector(db *dbConfig) gorm.Dialector {
+	if db.IsSQLite() {
+		dsn := c.DSN
+		if dsn == "" {
+			dsn = fmt.Sprintf("file:%s?mode=ro&_pragma=query_only(1)", db.Name)
+		}
+		return sqlite.Open(dsn)
+	}
+	if c.DSN == "" {
+		return nil
+	}
+
+	restricted := *db
+	restricted.DSN = c.DSN
+	return restricted.GetDialector()
+}
+
 func mysqlConnectionString(config *dbConfig) string {
 	if len(config.DSN) > 0 {
 		return config.DSN
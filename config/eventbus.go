@@ -11,12 +11,28 @@ const (
 	TopicUser               = "user.*"
 	TopicHeartbeat          = "heartbeat.*"
 	TopicProjectLabel       = "project_label.*"
+	TopicTimeEntry          = "time_entry.*"
+	TopicLoadShedding       = "load_shedding.*"
+	TopicLogin              = "login.*"
+	TopicSummary            = "summary.*"
+	TopicLeaderboard        = "leaderboard.*"
 	EventUserUpdate         = "user.update"
 	EventUserDelete         = "user.delete"
 	EventHeartbeatCreate    = "heartbeat.create"
+	EventHeartbeatUpdate    = "heartbeat.update" // existing heartbeats were modified or deleted, either of a single user or, if no user id is given, of all users
+	EventSummaryCreate      = "summary.create"
+	EventLeaderboardUpdate  = "leaderboard.update" // the live leaderboard was re-generated, payload are the ranks before and after
 	EventProjectLabelCreate = "project_label.create"
 	EventProjectLabelDelete = "project_label.delete"
+	EventTimeEntryCreate    = "time_entry.create"
+	EventTimeEntryDelete    = "time_entry.delete"
+	EventTimeEntryUpdate    = "time_entry.update"
 	EventWakatimeFailure    = "wakatime.failure"
+	EventLoadSheddingStart  = "load_shedding.start"
+	EventLoadSheddingStop   = "load_shedding.stop"
+	EventLoginSuccess       = "login.success"
+	EventLoginFailure       = "login.failure"
+	EventLoginLockout       = "login.lockout"
 	FieldPayload            = "payload"
 	FieldUser               = "user"
 	FieldUserId             = "user.id"
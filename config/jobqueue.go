@@ -1,8 +1,10 @@
 package config
 
 import (
+	"context"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/hackclub/hackatime/utils"
 	"github.com/muety/artifex/v2"
@@ -72,6 +74,30 @@ func GetQueueMetrics() []*JobQueueMetrics {
 	return metrics
 }
 
+// CountPendingJobs returns the number of jobs across all queues, which were enqueued, but not picked up by a worker yet
+func CountPendingJobs() int {
+	var pending int
+	for _, queue := range jobQueues {
+		pending += queue.CountEnqueued() // only counts jobs while waiting for a worker, as opposed to CountDispatched()
+	}
+	return pending
+}
+
+// DrainQueues waits until all enqueued jobs were picked up by a worker, or until the context is done, and reports whether the queues were drained
+func DrainQueues(ctx context.Context) bool {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for CountPendingJobs() > 0 {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+	return true
+}
+
 func CloseQueues() {
 	for _, q := range jobQueues {
 		q.Stop()
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrainQueues(t *testing.T) {
+	const name = "wakapi.test"
+
+	assert.Nil(t, InitQueue(name, 1))
+	queue := GetQueue(name)
+	t.Cleanup(func() {
+		queue.Stop()
+		delete(jobQueues, name)
+	})
+
+	// jobs processed before must not offset those still waiting
+	for i := 0; i < 3; i++ {
+		done := make(chan bool)
+		queue.Dispatch(func() { close(done) })
+		<-done
+	}
+
+	// occupy the only worker, jobs aren't necessarily picked up in the order they were enqueued
+	started, release := make(chan bool), make(chan bool)
+	queue.Dispatch(func() {
+		close(started)
+		<-release
+	})
+	<-started
+	queue.Dispatch(func() {})
+	queue.Dispatch(func() {})
+
+	assert.Eventually(t, func() bool { return CountPendingJobs() == 2 }, time.Second, 5*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.False(t, DrainQueues(ctx))
+
+	close(release)
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.True(t, DrainQueues(ctx))
+	assert.Zero(t, CountPendingJobs())
+}
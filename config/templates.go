@@ -4,6 +4,8 @@ const (
 	IndexTemplate         = "index.tpl.html"
 	LoginTemplate         = "login.tpl.html"
 	ImprintTemplate       = "imprint.tpl.html"
+	LegalTemplate         = "legal.tpl.html"
+	ProfileTemplate       = "profile.tpl.html"
 	SignupTemplate        = "signup.tpl.html"
 	SetPasswordTemplate   = "set-password.tpl.html"
 	ResetPasswordTemplate = "reset-password.tpl.html"
@@ -12,4 +14,6 @@ const (
 	LeaderboardTemplate   = "leaderboard.tpl.html"
 	ProjectsTemplate      = "projects.tpl.html"
 	ShopTemplate          = "shop.tpl.html"
+	DeviceTemplate        = "device.tpl.html"
+	MentorTemplate        = "mentor-unsubscribe.tpl.html"
 )
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/i18n"
+	"github.com/hackclub/hackatime/models"
 )
 
 // ParseDateTimeTZ attempts to parse the given date string from multiple formats.
@@ -48,3 +50,21 @@ func FmtWakatimeDuration(d time.Duration) string {
 	m := d / time.Minute
 	return fmt.Sprintf("%d hrs %d mins", h, m)
 }
+
+// FmtUserDuration formats the given duration according to the user's display preferences, i.e. rounding, units and language
+func FmtUserDuration(d time.Duration, user *models.User) string {
+	if user == nil {
+		return FmtWakatimeDuration(d)
+	}
+	d = user.RoundDuration(d)
+	switch user.DurationFormat {
+	case models.DurationFormatDecimal:
+		return i18n.T(user.Lang(), "duration.decimal", d.Hours())
+	case models.DurationFormatClock:
+		d = d.Round(time.Minute)
+		return fmt.Sprintf("%d:%02d", d/time.Hour, (d%time.Hour)/time.Minute)
+	default:
+		d = d.Round(time.Minute)
+		return i18n.T(user.Lang(), "duration.default", d/time.Hour, (d%time.Hour)/time.Minute)
+	}
+}
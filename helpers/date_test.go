@@ -0,0 +1,23 @@
+package helpers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFmtUserDuration(t *testing.T) {
+	d := 2*time.Hour + 17*time.Minute + 40*time.Second
+
+	assert.Equal(t, "2 hrs 18 mins", FmtUserDuration(d, nil))
+	assert.Equal(t, "2 hrs 18 mins", FmtUserDuration(d, &models.User{}))
+	assert.Equal(t, "2 hrs 15 mins", FmtUserDuration(d, &models.User{DurationRoundingMin: 15}))
+	assert.Equal(t, "2 hrs 20 mins", FmtUserDuration(d, &models.User{DurationRoundingMin: 5}))
+	assert.Equal(t, "2.25 hrs", FmtUserDuration(d, &models.User{DurationRoundingMin: 15, DurationFormat: models.DurationFormatDecimal}))
+	assert.Equal(t, "2:18", FmtUserDuration(d, &models.User{DurationFormat: models.DurationFormatClock}))
+	assert.Equal(t, "0:05", FmtUserDuration(4*time.Minute, &models.User{DurationRoundingMin: 5, DurationFormat: models.DurationFormatClock}))
+	assert.Equal(t, "2 Std. 18 Min.", FmtUserDuration(d, &models.User{Locale: "de"}))
+	assert.Equal(t, "2.25 Std.", FmtUserDuration(d, &models.User{Locale: "de", DurationRoundingMin: 15, DurationFormat: models.DurationFormatDecimal}))
+}
@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/go-chi/chi/v5/middleware"
 	"github.com/hackclub/hackatime/config"
 	"github.com/hackclub/hackatime/models"
 )
@@ -29,3 +32,26 @@ func RespondJSON(w http.ResponseWriter, r *http.Request, status int, object inte
 		config.Log().Request(r).Error("error while writing json response", "error", err)
 	}
 }
+
+// RespondError writes a models.ApiError, its retry hint is taken from a retry-after header previously set on the response (e.g. by a rate limiter), if any
+func RespondError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	apiErr := models.NewApiError(status, code, message)
+	apiErr.RequestId = middleware.GetReqID(r.Context())
+	if retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After")); err == nil && retryAfter > 0 {
+		apiErr.RetryAfter = retryAfter
+	}
+	RespondJSON(w, r, status, apiErr)
+}
+
+// RespondErrorRetryAfter writes a models.ApiError and advises the client to not retry before the given delay has passed
+func RespondErrorRetryAfter(w http.ResponseWriter, r *http.Request, status int, code, message string, retryAfter time.Duration) {
+	if seconds := int(retryAfter.Round(time.Second).Seconds()); seconds > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+	RespondError(w, r, status, code, message)
+}
+
+// RespondRateLimited is meant to be passed to httprate.WithLimitHandler to answer with a models.ApiError instead of plain text
+func RespondRateLimited(w http.ResponseWriter, r *http.Request) {
+	RespondError(w, r, http.StatusTooManyRequests, models.ApiErrorRateLimited, "too many requests")
+}
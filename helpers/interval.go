@@ -2,12 +2,39 @@ package helpers
 
 import (
 	"errors"
+	"regexp"
+	"strconv"
 	"time"
 
 	"github.com/hackclub/hackatime/models"
 	"github.com/hackclub/hackatime/utils"
 )
 
+// MaxSlidingWindow limits sliding windows, as they're always computed from raw heartbeats
+const MaxSlidingWindow = 7 * 24 * time.Hour
+
+var slidingWindowPattern = regexp.MustCompile(`^last_(\d+)(m|h)$`)
+
+// ParseSlidingWindow parses custom sliding window identifiers like last_24h or last_90m, which end right now
+func ParseSlidingWindow(interval string) (time.Duration, bool) {
+	match := slidingWindowPattern.FindStringSubmatch(interval)
+	if match == nil {
+		return 0, false
+	}
+	n, err := strconv.Atoi(match[1])
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	unit := time.Minute
+	if match[2] == "h" {
+		unit = time.Hour
+	}
+	if window := time.Duration(n) * unit; window/unit == time.Duration(n) && window <= MaxSlidingWindow {
+		return window, true
+	}
+	return 0, false
+}
+
 func ParseInterval(interval string) (*models.IntervalKey, error) {
 	for _, i := range models.AllIntervals {
 		if i.HasAlias(interval) {
@@ -35,7 +62,20 @@ func ResolveIntervalRawTZ(interval string, tz *time.Location) (err error, from,
 	return ResolveIntervalTZ(parsed, tz)
 }
 
+// ResolveIntervalRawTZWeekStart is like ResolveIntervalRawTZ, but lets weekly intervals begin on the given day instead of monday
+func ResolveIntervalRawTZWeekStart(interval string, tz *time.Location, weekStart time.Weekday) (err error, from, to time.Time) {
+	parsed, err := ParseInterval(interval)
+	if err != nil {
+		return err, time.Time{}, time.Time{}
+	}
+	return ResolveIntervalTZWeekStart(parsed, tz, weekStart)
+}
+
 func ResolveIntervalTZ(interval *models.IntervalKey, tz *time.Location) (err error, from, to time.Time) {
+	return ResolveIntervalTZWeekStart(interval, tz, time.Monday)
+}
+
+func ResolveIntervalTZWeekStart(interval *models.IntervalKey, tz *time.Location, weekStart time.Weekday) (err error, from, to time.Time) {
 	now := time.Now().In(tz)
 	to = now
 
@@ -48,10 +88,10 @@ func ResolveIntervalTZ(interval *models.IntervalKey, tz *time.Location) (err err
 	case models.IntervalPastDay:
 		from = now.Add(-24 * time.Hour)
 	case models.IntervalThisWeek:
-		from = utils.BeginOfThisWeek(tz)
+		from = utils.BeginOfThisWeekFrom(tz, weekStart)
 	case models.IntervalLastWeek:
-		from = utils.BeginOfThisWeek(tz).AddDate(0, 0, -7)
-		to = utils.BeginOfThisWeek(tz)
+		from = utils.BeginOfThisWeekFrom(tz, weekStart).AddDate(0, 0, -7)
+		to = utils.BeginOfThisWeekFrom(tz, weekStart)
 	case models.IntervalThisMonth:
 		from = utils.BeginOfThisMonth(tz)
 	case models.IntervalLastMonth:
@@ -26,3 +26,18 @@ func TestResolveMaximumRange_EdgeCases(t *testing.T) {
 	_, maximumInterval := ResolveMaximumRange(-1)
 	assert.Equal(t, models.IntervalAny, maximumInterval)
 }
+
+func TestParseSlidingWindow(t *testing.T) {
+	window, ok := ParseSlidingWindow("last_24h")
+	assert.True(t, ok)
+	assert.Equal(t, 24*time.Hour, window)
+
+	window, ok = ParseSlidingWindow("last_90m")
+	assert.True(t, ok)
+	assert.Equal(t, 90*time.Minute, window)
+
+	for _, s := range []string{"last_7_days", "last_0h", "last_169h", "last_99999999999999999999m", "24h", "last_24d"} {
+		_, ok = ParseSlidingWindow(s)
+		assert.False(t, ok, s)
+	}
+}
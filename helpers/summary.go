@@ -3,6 +3,7 @@ package helpers
 import (
 	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/hackclub/hackatime/models"
@@ -15,10 +16,17 @@ func ParseSummaryParams(r *http.Request) (*models.SummaryParams, error) {
 	var err error
 	var from, to time.Time
 
-	if interval := params.Get("interval"); interval != "" {
-		err, from, to = ResolveIntervalRawTZ(interval, user.TZ())
+	recompute := params.Get("recompute") != "" && params.Get("recompute") != "false"
+
+	if window, ok := ParseSlidingWindow(params.Get("interval")); ok {
+		// summaries are materialized per day, so sliding windows are always summarized from heartbeats directly
+		to = time.Now().In(user.TZ())
+		from = to.Add(-window)
+		recompute = true
+	} else if interval := params.Get("interval"); interval != "" {
+		err, from, to = ResolveIntervalRawTZWeekStart(interval, user.TZ(), user.WeekStart())
 	} else if start := params.Get("start"); start != "" {
-		err, from, to = ResolveIntervalRawTZ(start, user.TZ())
+		err, from, to = ResolveIntervalRawTZWeekStart(start, user.TZ(), user.WeekStart())
 	} else {
 		from, err = ParseDateTimeTZ(params.Get("from"), user.TZ())
 		if err != nil {
@@ -31,16 +39,17 @@ func ParseSummaryParams(r *http.Request) (*models.SummaryParams, error) {
 		}
 	}
 
-	recompute := params.Get("recompute") != "" && params.Get("recompute") != "false"
+	includeArchived := params.Get("include_archived") != "" && params.Get("include_archived") != "false"
 
 	filters := ParseSummaryFilters(r)
 
 	return &models.SummaryParams{
-		From:      from,
-		To:        to,
-		User:      user,
-		Recompute: recompute,
-		Filters:   filters,
+		From:            from,
+		To:              to,
+		User:            user,
+		Recompute:       recompute,
+		Filters:         filters,
+		IncludeArchived: includeArchived,
 	}, nil
 }
 
@@ -67,15 +76,44 @@ func ParseSummaryFilters(r *http.Request) *models.Filters {
 	if q := r.URL.Query().Get("branch"); q != "" {
 		filters.With(models.SummaryBranch, q)
 	}
+	if q := r.URL.Query().Get("branches"); q != "" { // wakatime-style, comma-separated
+		filters.WithMultiple(models.SummaryBranch, strings.Split(q, ","))
+	}
 	if q := r.URL.Query().Get("entity"); q != "" {
-		filters.With(models.SummaryBranch, q)
+		filters.With(models.SummaryEntity, q)
 	}
 	if q := r.URL.Query().Get("category"); q != "" {
 		filters.With(models.SummaryCategory, q)
 	}
+	if q := r.URL.Query().Get("source"); q != "" {
+		filters.WithSources(strings.Split(q, ","))
+	}
+	if q := r.URL.Query().Get("tag"); q != "" {
+		filters.WithTags([]string{q})
+	}
+	if q := r.URL.Query().Get("tags"); q != "" { // comma-separated, matching heartbeats with any of them
+		filters.WithTags(strings.Split(q, ","))
+	}
 	return filters
 }
 
+// ParseSummaryGroupBy resolves the comma-separated group_by parameter (e.g. "category" or "project,language") to the summary types to keep
+func ParseSummaryGroupBy(r *http.Request) (map[uint8]bool, error) {
+	types := make(map[uint8]bool)
+	q := r.URL.Query().Get("group_by")
+	if q == "" {
+		return types, nil
+	}
+	for _, name := range strings.Split(q, ",") {
+		t, ok := models.ParseSummaryType(strings.TrimSpace(name))
+		if !ok {
+			return nil, errors.New("invalid 'group_by' parameter")
+		}
+		types[t] = true
+	}
+	return types, nil
+}
+
 func extractUser(r *http.Request) *models.User {
 	type principalGetter interface {
 		GetPrincipal() *models.User
@@ -0,0 +1,126 @@
+// Package i18n translates the human-readable strings the server generates, e.g. durations in text fields, report mails or their subjects.
+// Messages are looked up in per-locale catalogs, which are built in and can be extended or overridden by operators.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale is the locale whose catalog is complete, messages missing in other catalogs fall back to it
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// Catalog maps message keys to fmt format strings
+type Catalog map[string]string
+
+var (
+	catalogs = map[string]Catalog{}
+	mu       sync.RWMutex
+)
+
+func init() {
+	if err := loadFS(localeFiles, "locales"); err != nil {
+		panic(err)
+	}
+}
+
+// Register adds the messages to the locale's catalog, replacing existing ones with the same key
+func Register(locale string, catalog Catalog) {
+	locale = normalize(locale)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if _, ok := catalogs[locale]; !ok {
+		catalogs[locale] = Catalog{}
+	}
+	for key, message := range catalog {
+		catalogs[locale][key] = message
+	}
+}
+
+// LoadDir registers all catalogs in the directory, which are json files named after their locale, e.g. "fr.json"
+func LoadDir(dir string) error {
+	return loadFS(os.DirFS(dir), ".")
+}
+
+// IsSupported tells whether there is a catalog for the locale
+func IsSupported(locale string) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	_, ok := catalogs[normalize(locale)]
+	return ok
+}
+
+// Locales lists all locales there is a catalog for
+func Locales() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// T formats the message with the given key in the locale, falling back to the locale's base language (e.g. "de" for "de-at"),
+// then to the default locale and finally to the key itself
+func T(locale, key string, args ...interface{}) string {
+	message, ok := lookup(locale, key)
+	if !ok {
+		message = key
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+func lookup(locale, key string) (string, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	locale = normalize(locale)
+	base, _, _ := strings.Cut(locale, "-")
+	for _, l := range []string{locale, base, DefaultLocale} {
+		if message, ok := catalogs[l][key]; ok {
+			return message, true
+		}
+	}
+	return "", false
+}
+
+func loadFS(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return err
+		}
+		var catalog Catalog
+		if err := json.Unmarshal(data, &catalog); err != nil {
+			return fmt.Errorf("invalid catalog %s: %w", file, err)
+		}
+		Register(strings.TrimSuffix(filepath.Base(file), ".json"), catalog)
+	}
+	return nil
+}
+
+func normalize(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
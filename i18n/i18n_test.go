@@ -0,0 +1,45 @@
+package i18n
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestT(t *testing.T) {
+	assert.Equal(t, "2 hrs 5 mins", T("en", "duration.default", 2, 5))
+	assert.Equal(t, "2 Std. 5 Min.", T("de", "duration.default", 2, 5))
+	assert.Equal(t, "2 Std. 5 Min.", T("de_AT", "duration.default", 2, 5))
+	assert.Equal(t, "2 hrs 5 mins", T("", "duration.default", 2, 5))
+	assert.Equal(t, "2 hrs 5 mins", T("xx", "duration.default", 2, 5))
+	assert.Equal(t, "unknown.key", T("de", "unknown.key"))
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "fr.json"), []byte(`{"report.projects": "Projets"}`), 0644))
+
+	assert.False(t, IsSupported("fr"))
+	assert.Nil(t, LoadDir(dir))
+	assert.True(t, IsSupported("fr"))
+	assert.Contains(t, Locales(), "fr")
+	assert.Equal(t, "Projets", T("fr", "report.projects"))
+	assert.Equal(t, "Languages", T("fr", "report.languages"))
+
+	assert.Nil(t, os.WriteFile(filepath.Join(dir, "es.json"), []byte(`{`), 0644))
+	assert.NotNil(t, LoadDir(dir))
+}
+
+func TestCatalogsComplete(t *testing.T) {
+	for _, locale := range Locales() {
+		if locale == DefaultLocale {
+			continue
+		}
+		for key := range catalogs[locale] {
+			_, ok := catalogs[DefaultLocale][key]
+			assert.True(t, ok, "%s: %s missing in default catalog", locale, key)
+		}
+	}
+}
@@ -1,18 +1,26 @@
 package main
 
 import (
+	"context"
 	"embed"
+	"errors"
 	"flag"
+	"fmt"
 	"io/fs"
 	"log"
 	"log/slog"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/duke-git/lancet/v2/condition"
+	"github.com/duke-git/lancet/v2/slice"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -27,8 +35,11 @@ import (
 	"gorm.io/gorm/logger"
 
 	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
 	"github.com/hackclub/hackatime/middlewares"
+	customMiddleware "github.com/hackclub/hackatime/middlewares/custom"
 	"github.com/hackclub/hackatime/migrations"
+	"github.com/hackclub/hackatime/models"
 	"github.com/hackclub/hackatime/repositories"
 	"github.com/hackclub/hackatime/routes"
 	"github.com/hackclub/hackatime/routes/api"
@@ -59,16 +70,40 @@ var (
 )
 
 var (
-	aliasRepository           repositories.IAliasRepository
-	heartbeatRepository       repositories.IHeartbeatRepository
-	userRepository            repositories.IUserRepository
-	languageMappingRepository repositories.ILanguageMappingRepository
-	projectLabelRepository    repositories.IProjectLabelRepository
-	summaryRepository         repositories.ISummaryRepository
-	leaderboardRepository     *repositories.LeaderboardRepository
-	keyValueRepository        repositories.IKeyValueRepository
-	diagnosticsRepository     repositories.IDiagnosticsRepository
-	metricsRepository         *repositories.MetricsRepository
+	aliasRepository             repositories.IAliasRepository
+	heartbeatRepository         repositories.IHeartbeatRepository
+	userRepository              repositories.IUserRepository
+	languageMappingRepository   repositories.ILanguageMappingRepository
+	projectLabelRepository      repositories.IProjectLabelRepository
+	projectMetadataRepository   repositories.IProjectMetadataRepository
+	userPreferenceRepository    repositories.IUserPreferenceRepository
+	concurrencyRepository       repositories.IConcurrencyRepository
+	canonicalNameRepository     repositories.ICanonicalNameRepository
+	projectOverrideRepository   repositories.IProjectOverrideRepository
+	relayRuleRepository         repositories.IRelayRuleRepository
+	teamRepository              repositories.ITeamRepository
+	compareConsentRepository    repositories.ICompareConsentRepository
+	languageGoalRepository      repositories.ILanguageGoalRepository
+	timeEntryRepository         repositories.ITimeEntryRepository
+	userAvatarRepository        repositories.IUserAvatarRepository
+	summaryRepository           repositories.ISummaryRepository
+	leaderboardRepository       *repositories.LeaderboardRepository
+	leaderboardSeasonRepository repositories.ILeaderboardSeasonRepository
+	keyValueRepository          repositories.IKeyValueRepository
+	inviteCodeRepository        repositories.IInviteCodeRepository
+	diagnosticsRepository       repositories.IDiagnosticsRepository
+	metricsRepository           *repositories.MetricsRepository
+	machineRepository           repositories.IMachineRepository
+	personalRecordsRepository   repositories.IPersonalRecordsRepository
+	yearReviewRepository        repositories.IYearReviewRepository
+	activityGraphRepository     repositories.IActivityGraphRepository
+	userExternalIdRepository    repositories.IUserExternalIdRepository
+	legalConsentRepository      repositories.ILegalConsentRepository
+	trackingPauseRepository     repositories.ITrackingPauseRepository
+	tagRuleRepository           repositories.ITagRuleRepository
+	queryConsoleRepository      repositories.IQueryConsoleRepository
+	mentorRepository            repositories.IMentorRepository
+	rankWebhookRepository       repositories.ILeaderboardWebhookRepository
 )
 
 var (
@@ -77,18 +112,62 @@ var (
 	userService            services.IUserService
 	languageMappingService services.ILanguageMappingService
 	projectLabelService    services.IProjectLabelService
+	projectMetadataService services.IProjectMetadataService
+	userPreferenceService  services.IUserPreferenceService
+	concurrencyService     services.IConcurrencyService
+	canonicalNameService   services.ICanonicalNameService
+	projectOverrideService services.IProjectOverrideService
+	relayRuleService       services.IRelayRuleService
+	teamService            services.ITeamService
+	compareService         services.ICompareService
+	geoService             services.IGeoService
+	analyticsService       services.IAnalyticsService
+	projectRenameService   services.IProjectRenameService
+	trackingPauseService   services.ITrackingPauseService
+	tagRuleService         services.ITagRuleService
 	durationService        services.IDurationService
 	summaryService         services.ISummaryService
 	leaderboardService     services.ILeaderboardService
 	aggregationService     services.IAggregationService
 	mailService            services.IMailService
 	keyValueService        services.IKeyValueService
+	registrationService    services.IRegistrationService
 	reportService          services.IReportService
+	exportService          services.IExportService
+	streamService          services.IStreamService
+	profileService         services.IProfileService
+	activityGraphService   services.IActivityGraphService
+	inactivityAlertService services.IInactivityAlertService
+	scrapbookService       services.IScrapbookService
+	languageGoalService    services.ILanguageGoalService
+	timeEntryService       services.ITimeEntryService
+	userAvatarService      services.IUserAvatarService
+	objectStorageService   services.IObjectStorageService
 	activityService        services.IActivityService
+	chartService           services.IChartService
 	diagnosticsService     services.IDiagnosticsService
 	housekeepingService    services.IHousekeepingService
+	janitorService         services.IJanitorService
+	telegramService        services.ITelegramService
+	notificationTplService services.INotificationTemplateService
+	devDataService         services.IDevDataService
+	loadSheddingService    services.ILoadSheddingService
+	personalRecordsService services.IPersonalRecordsService
+	yearReviewService      services.IYearReviewService
+	legalService           services.ILegalService
 	miscService            services.IMiscService
 	shopService            services.IShopService
+	machineService         services.IMachineService
+	secretScanningService  services.ISecretScanningService
+	emailVerificationSrvc  services.IEmailVerificationService
+	loginThrottleService   services.ILoginThrottleService
+	deviceAuthService      services.IDeviceAuthService
+	pluginReleaseService   services.IPluginReleaseService
+	configCheckService     services.IConfigCheckService
+	queryConsoleService    services.IQueryConsoleService
+	mentorService          services.IMentorService
+	rankWebhookService     services.ILeaderboardWebhookService
+	importService          services.IImportService
 )
 
 // TODO: Refactor entire project to be structured after business domains
@@ -118,6 +197,29 @@ var (
 func main() {
 	var versionFlag = flag.Bool("version", false, "print version")
 	var configFlag = flag.String("config", conf.DefaultConfigPath, "config file location")
+
+	var dryRunFlag = flag.Bool("dry-run", false, "only print the statements migrate or reindex would execute")
+	var stepsFlag = flag.Int("steps", 1, "number of migrations to revert with migrate down")
+	var userFlag = flag.String("user", "", "user to re-generate summaries for with resummarize, all users if blank")
+	var fromFlag = flag.String("from", "", "first day to re-generate summaries for with resummarize (e.g. 2006-01-02)")
+	var toFlag = flag.String("to", "", "day until which to re-generate summaries with resummarize (exclusive), today if blank")
+
+	// subcommands come first, i.e. hackatime check-config -config config.yml or hackatime migrate status -config config.yml
+	var checkConfigCmd, resummarizeCmd, reindexCmd bool
+	var migrateCmd string
+	if len(os.Args) > 1 && os.Args[1] == "check-config" {
+		checkConfigCmd = true
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	} else if len(os.Args) > 1 && os.Args[1] == "resummarize" {
+		resummarizeCmd = true
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	} else if len(os.Args) > 1 && os.Args[1] == "reindex" {
+		reindexCmd = true
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	} else if len(os.Args) > 2 && os.Args[1] == "migrate" {
+		migrateCmd = os.Args[2]
+		os.Args = append(os.Args[:1], os.Args[3:]...)
+	}
 	flag.Parse()
 
 	if *versionFlag {
@@ -126,6 +228,10 @@ func main() {
 	}
 	config = conf.Load(*configFlag, version)
 
+	if checkConfigCmd {
+		os.Exit(checkConfig())
+	}
+
 	// Configure Swagger docs
 	docs.SwaggerInfo.BasePath = config.Server.BasePath + "/api"
 
@@ -160,9 +266,32 @@ func main() {
 	sqlDb.SetMaxOpenConns(int(config.Db.MaxConn))
 	defer sqlDb.Close()
 
+	if migrateCmd != "" {
+		os.Exit(migrate(migrateCmd, *dryRunFlag, *stepsFlag))
+	}
+	if reindexCmd {
+		os.Exit(reindex(*dryRunFlag))
+	}
+
 	// Migrate database schema
 	if !config.SkipMigrations {
 		migrations.Run(db, config)
+	} else if pending, err := migrations.CountPending(db); err == nil && pending > 0 {
+		slog.Warn("database has pending migrations, apply them using 'migrate up'", "count", pending)
+	}
+
+	// Connect to database a second time for the query console, if it doesn't share the main connection
+	queryConsoleDb := db
+	if dialector := config.QueryConsole.GetDialector(&config.Db); config.QueryConsole.Enabled && dialector != nil {
+		if queryConsoleDb, err = gorm.Open(dialector, &gorm.Config{Logger: gormLogger}); err != nil {
+			conf.Log().Fatal("could not connect to database for query console", "error", err)
+		}
+		queryConsoleSqlDb, err := queryConsoleDb.DB()
+		if err != nil {
+			conf.Log().Fatal("could not connect to database for query console", "error", err)
+		}
+		queryConsoleSqlDb.SetMaxOpenConns(1)
+		defer queryConsoleSqlDb.Close()
 	}
 
 	// Repositories
@@ -171,40 +300,131 @@ func main() {
 	userRepository = repositories.NewUserRepository(db)
 	languageMappingRepository = repositories.NewLanguageMappingRepository(db)
 	projectLabelRepository = repositories.NewProjectLabelRepository(db)
+	projectMetadataRepository = repositories.NewProjectMetadataRepository(db)
+	userPreferenceRepository = repositories.NewUserPreferenceRepository(db)
+	concurrencyRepository = repositories.NewConcurrencyRepository(db)
+	canonicalNameRepository = repositories.NewCanonicalNameRepository(db)
+	projectOverrideRepository = repositories.NewProjectOverrideRepository(db)
+	relayRuleRepository = repositories.NewRelayRuleRepository(db)
+	teamRepository = repositories.NewTeamRepository(db)
+	compareConsentRepository = repositories.NewCompareConsentRepository(db)
+	languageGoalRepository = repositories.NewLanguageGoalRepository(db)
+	timeEntryRepository = repositories.NewTimeEntryRepository(db)
+	userAvatarRepository = repositories.NewUserAvatarRepository(db)
 	summaryRepository = repositories.NewSummaryRepository(db)
 	leaderboardRepository = repositories.NewLeaderboardRepository(db)
+	leaderboardSeasonRepository = repositories.NewLeaderboardSeasonRepository(db)
 	keyValueRepository = repositories.NewKeyValueRepository(db)
+	inviteCodeRepository = repositories.NewInviteCodeRepository(db)
 	diagnosticsRepository = repositories.NewDiagnosticsRepository(db)
 	metricsRepository = repositories.NewMetricsRepository(db)
+	machineRepository = repositories.NewMachineRepository(db)
+	personalRecordsRepository = repositories.NewPersonalRecordsRepository(db)
+	yearReviewRepository = repositories.NewYearReviewRepository(db)
+	activityGraphRepository = repositories.NewActivityGraphRepository(db)
+	userExternalIdRepository = repositories.NewUserExternalIdRepository(db)
+	legalConsentRepository = repositories.NewLegalConsentRepository(db)
+	trackingPauseRepository = repositories.NewTrackingPauseRepository(db)
+	tagRuleRepository = repositories.NewTagRuleRepository(db)
+	queryConsoleRepository = repositories.NewQueryConsoleRepository(queryConsoleDb)
+	mentorRepository = repositories.NewMentorRepository(db)
+	rankWebhookRepository = repositories.NewLeaderboardWebhookRepository(db)
 
 	// Services
-	mailService = mail.NewMailService()
+	keyValueService = services.NewKeyValueService(keyValueRepository)
+	notificationTplService = services.NewNotificationTemplateService(keyValueService)
+	mailService = mail.NewMailService(notificationTplService)
 	aliasService = services.NewAliasService(aliasRepository)
-	userService = services.NewUserService(mailService, userRepository)
+	userService = services.NewUserService(mailService, userRepository, userExternalIdRepository)
 	languageMappingService = services.NewLanguageMappingService(languageMappingRepository)
 	projectLabelService = services.NewProjectLabelService(projectLabelRepository)
-	heartbeatService = services.NewHeartbeatService(heartbeatRepository, languageMappingService)
-	durationService = services.NewDurationService(heartbeatService)
+	projectMetadataService = services.NewProjectMetadataService(projectMetadataRepository)
+	userPreferenceService = services.NewUserPreferenceService(userPreferenceRepository)
+	concurrencyService = services.NewConcurrencyService(concurrencyRepository)
+	projectOverrideService = services.NewProjectOverrideService(projectOverrideRepository)
+	relayRuleService = services.NewRelayRuleService(relayRuleRepository)
+	trackingPauseService = services.NewTrackingPauseService(trackingPauseRepository)
+	tagRuleService = services.NewTagRuleService(tagRuleRepository)
+	canonicalNameService = services.NewCanonicalNameService(canonicalNameRepository, heartbeatRepository, userService)
+	heartbeatService = services.NewHeartbeatService(heartbeatRepository, languageMappingService, canonicalNameService)
+	timeEntryService = services.NewTimeEntryService(timeEntryRepository)
+	durationService = services.NewDurationService(heartbeatService, timeEntryService)
 	summaryService = services.NewSummaryService(summaryRepository, heartbeatService, durationService, aliasService, projectLabelService)
 	aggregationService = services.NewAggregationService(userService, summaryService, heartbeatService)
-	keyValueService = services.NewKeyValueService(keyValueRepository)
+	canonicalNameService.SetAggregationService(aggregationService)
+	projectRenameService = services.NewProjectRenameService(heartbeatService, timeEntryService, aggregationService)
+	registrationService = services.NewRegistrationService(keyValueService, inviteCodeRepository)
 	reportService = services.NewReportService(summaryService, userService, mailService)
+	mentorService = services.NewMentorService(mentorRepository, userService, summaryService, mailService)
+	teamService = services.NewTeamService(teamRepository, userService, summaryService, durationService)
+	compareService = services.NewCompareService(compareConsentRepository, userService, summaryService)
+	analyticsService = services.NewAnalyticsService(durationService)
+	objectStorageService = services.NewObjectStorageService()
+	exportService = services.NewExportService(summaryService, heartbeatService, userService, objectStorageService)
+	importService = services.NewImportService(heartbeatService, userService, summaryService, aggregationService, objectStorageService, mailService)
+	streamService = services.NewStreamService()
 	activityService = services.NewActivityService(summaryService)
+	chartService = services.NewChartService(summaryService)
+	profileService = services.NewProfileService(summaryService)
+	activityGraphService = services.NewActivityGraphService(activityGraphRepository, summaryService, userService)
 	diagnosticsService = services.NewDiagnosticsService(diagnosticsRepository)
 	housekeepingService = services.NewHousekeepingService(userService, heartbeatService, summaryService)
+	janitorService = services.NewJanitorService(userService, keyValueService, registrationService, yearReviewRepository)
 	miscService = services.NewMiscService(userService, heartbeatService, summaryService, keyValueService, mailService)
 	shopService = services.NewShopService()
+	machineService = services.NewMachineService(machineRepository, heartbeatService, mailService)
+	geoService = services.NewGeoService(machineService, summaryService)
+	secretScanningService = services.NewSecretScanningService(userService, mailService)
+	emailVerificationSrvc = services.NewEmailVerificationService(userService, mailService, keyValueService)
+	loginThrottleService = services.NewLoginThrottleService()
+	deviceAuthService = services.NewDeviceAuthService(userService)
+	pluginReleaseService = services.NewPluginReleaseService()
+	configCheckService = services.NewConfigCheckService(mailService)
+	queryConsoleService = services.NewQueryConsoleService(queryConsoleRepository)
+	loadSheddingService = services.NewLoadSheddingService(heartbeatService, metricsRepository)
+	devDataService = services.NewDevDataService(userService, heartbeatService, aggregationService)
+	personalRecordsService = services.NewPersonalRecordsService(personalRecordsRepository, heartbeatService, userService)
+	yearReviewService = services.NewYearReviewService(yearReviewRepository, summaryService, userService, trackingPauseService)
+	legalService = services.NewLegalService(legalConsentRepository)
+	telegramService = services.NewTelegramService(userService, summaryService, notificationTplService)
+	inactivityAlertService = services.NewInactivityAlertService(userService, heartbeatService, mailService, telegramService)
+	scrapbookService = services.NewScrapbookService(userService, heartbeatService)
+	languageGoalService = services.NewLanguageGoalService(languageGoalRepository, summaryService, userService, mailService, telegramService)
+	userAvatarService = services.NewUserAvatarService(userAvatarRepository, userService)
 
 	if config.App.LeaderboardEnabled {
-		leaderboardService = services.NewLeaderboardService(leaderboardRepository, summaryService, userService)
+		leaderboardService = services.NewLeaderboardService(leaderboardRepository, leaderboardSeasonRepository, summaryService, heartbeatService, userService, notificationTplService)
+		if config.App.LeaderboardWebhooks {
+			rankWebhookService = services.NewLeaderboardWebhookService(rankWebhookRepository)
+		}
+	}
+
+	if resummarizeCmd {
+		os.Exit(resummarize(*userFlag, *fromFlag, *toFlag))
 	}
 
 	// Schedule background tasks
 	go conf.StartJobs()
 	go aggregationService.Schedule()
 	go reportService.Schedule()
+	go mentorService.Schedule()
+	go exportService.Schedule()
+	go streamService.Schedule()
+	go objectStorageService.Schedule()
+	go loadSheddingService.Schedule()
+	go concurrencyService.Schedule()
+	go personalRecordsService.Schedule()
+	go yearReviewService.Schedule()
+	go activityGraphService.Schedule()
 	go housekeepingService.Schedule()
+	go janitorService.Schedule()
+	go telegramService.Setup()
+	go inactivityAlertService.Schedule()
+	go teamService.Schedule()
+	go scrapbookService.Schedule()
+	go languageGoalService.Schedule()
 	go miscService.Schedule()
+	go configCheckService.LogAll()
 
 	if config.App.LeaderboardEnabled {
 		go leaderboardService.Schedule()
@@ -214,36 +434,89 @@ func main() {
 
 	// API Handlers
 	healthApiHandler := api.NewHealthApiHandler(db)
-	heartbeatApiHandler := api.NewHeartbeatApiHandler(userService, heartbeatService, languageMappingService)
-	summaryApiHandler := api.NewSummaryApiHandler(userService, summaryService)
+	metaApiHandler := api.NewMetaApiHandler()
+	heartbeatApiHandler := api.NewHeartbeatApiHandler(userService, heartbeatService, languageMappingService, machineService, loadSheddingService, projectOverrideService, relayRuleService, geoService, trackingPauseService, tagRuleService)
+	summaryApiHandler := api.NewSummaryApiHandler(userService, summaryService, projectMetadataService)
+	summaryPdfApiHandler := api.NewSummaryPdfApiHandler(userService, exportService)
 	specialApiHandler := api.NewSpecialApiHandler(userService)
-	metricsHandler := api.NewMetricsHandler(userService, summaryService, heartbeatService, leaderboardService, keyValueService, metricsRepository)
+	metricsHandler := api.NewMetricsHandler(userService, summaryService, heartbeatService, leaderboardService, keyValueService, loadSheddingService, janitorService, metricsRepository)
 	diagnosticsHandler := api.NewDiagnosticsApiHandler(userService, diagnosticsService)
 	avatarHandler := api.NewAvatarHandler()
 	activityHandler := api.NewActivityApiHandler(userService, activityService)
+	chartsHandler := api.NewChartsApiHandler(userService, chartService)
 	badgeHandler := api.NewBadgeHandler(userService, summaryService)
 	captchaHandler := api.NewCaptchaHandler()
+	secretScanningHandler := api.NewSecretScanningHandler(secretScanningService)
+	storageHandler := api.NewStorageApiHandler(userService, metricsRepository)
+	serviceAccountsHandler := api.NewServiceAccountsApiHandler(userService)
+	userInvitesHandler := api.NewUserInvitesApiHandler(userService)
+	userSuspensionHandler := api.NewUserSuspensionApiHandler(userService)
+	userExternalIdsHandler := api.NewUserExternalIdsApiHandler(userService)
+	simpleHandler := api.NewSimpleApiHandler(userService, summaryService)
+	settingsApiHandler := api.NewSettingsApiHandler(userService)
+	scimHandler := api.NewScimHandler(userService)
+	objectsHandler := api.NewObjectsHandler(objectStorageService)
+	devHandler := api.NewDevApiHandler(devDataService)
+	personalRecordsHandler := api.NewPersonalRecordsApiHandler(userService, personalRecordsService)
+	yearReviewHandler := api.NewYearReviewApiHandler(userService, yearReviewService)
+	leaderboardSeasonsHandler := api.NewLeaderboardSeasonsApiHandler(leaderboardService)
+	projectMetadataHandler := api.NewProjectMetadataApiHandler(userService, projectMetadataService)
+	userPreferencesHandler := api.NewUserPreferencesApiHandler(userService, userPreferenceService)
+	concurrencyApiHandler := api.NewConcurrencyApiHandler(userService, concurrencyService)
+	canonicalNamesApiHandler := api.NewCanonicalNamesApiHandler(userService, canonicalNameService)
+	registrationApiHandler := api.NewRegistrationApiHandler(userService, registrationService)
+	deviceApiHandler := api.NewDeviceApiHandler(userService, deviceAuthService)
+	projectOverrideHandler := api.NewProjectOverrideApiHandler(userService, projectOverrideService)
+	relayRulesHandler := api.NewRelayRulesApiHandler(userService, relayRuleService)
+	teamsHandler := api.NewTeamsApiHandler(userService, teamService)
+	compareHandler := api.NewCompareApiHandler(userService, compareService)
+	analyticsHandler := api.NewAnalyticsApiHandler(userService, analyticsService)
+	locationsHandler := api.NewLocationsApiHandler(userService, geoService)
+	projectRenameHandler := api.NewProjectRenameApiHandler(userService, projectRenameService)
+	machinesHandler := api.NewMachinesApiHandler(userService, machineService, aggregationService, durationService)
+	telegramHandler := api.NewTelegramApiHandler(userService, telegramService)
+	notificationTemplatesHandler := api.NewNotificationTemplatesApiHandler(userService, notificationTplService)
+	languageGoalHandler := api.NewLanguageGoalApiHandler(userService, languageGoalService)
+	timeEntriesHandler := api.NewTimeEntriesApiHandler(userService, timeEntryService, aggregationService)
+	trackingPausesHandler := api.NewTrackingPausesApiHandler(userService, trackingPauseService)
+	tagRulesHandler := api.NewTagRulesApiHandler(userService, tagRuleService)
+	userProfileHandler := api.NewUserProfileApiHandler(userService, userAvatarService)
+	profileApiHandler := api.NewProfileApiHandler(userService, profileService)
+	activityGraphHandler := api.NewActivityGraphApiHandler(userService, activityGraphService)
+	pluginReleasesHandler := api.NewPluginReleasesHandler(pluginReleaseService)
+	resummarizeHandler := api.NewResummarizeApiHandler(userService, aggregationService)
+	queryConsoleHandler := api.NewQueryConsoleApiHandler(userService, queryConsoleService)
+	mentorsApiHandler := api.NewMentorsApiHandler(userService, mentorService)
+	leaderboardWebhookHandler := api.NewLeaderboardWebhookApiHandler(userService, rankWebhookService)
+	importsApiHandler := api.NewImportsApiHandler(userService, importService)
 
 	// Compat Handlers
 	wakatimeV1StatusBarHandler := wtV1Routes.NewStatusBarHandler(userService, summaryService)
 	wakatimeV1AllHandler := wtV1Routes.NewAllTimeHandler(userService, summaryService)
-	wakatimeV1SummariesHandler := wtV1Routes.NewSummariesHandler(userService, summaryService)
-	wakatimeV1StatsHandler := wtV1Routes.NewStatsHandler(userService, summaryService)
+	wakatimeV1SummariesHandler := wtV1Routes.NewSummariesHandler(userService, summaryService, heartbeatService)
+	wakatimeV1StatsHandler := wtV1Routes.NewStatsHandler(userService, summaryService, heartbeatService)
 	wakatimeV1UsersHandler := wtV1Routes.NewUsersHandler(userService, heartbeatService)
-	wakatimeV1ProjectsHandler := wtV1Routes.NewProjectsHandler(userService, heartbeatService)
+	wakatimeV1ProjectsHandler := wtV1Routes.NewProjectsHandler(userService, heartbeatService, summaryService, projectMetadataService)
+	wakatimeV1UserAgentsHandler := wtV1Routes.NewUserAgentsHandler(userService, heartbeatService)
+	wakatimeV1MachineNamesHandler := wtV1Routes.NewMachineNamesHandler(userService, heartbeatService)
 	wakatimeV1HeartbeatsHandler := wtV1Routes.NewHeartbeatHandler(userService, heartbeatService)
+	wakatimeV1ExternalDurationsHandler := wtV1Routes.NewExternalDurationsHandler(userService, timeEntryService, aggregationService)
 	wakatimeV1LeadersHandler := wtV1Routes.NewLeadersHandler(userService, leaderboardService)
 	shieldV1BadgeHandler := shieldsV1Routes.NewBadgeHandler(summaryService, userService)
 
 	// MVC Handlers
 	summaryHandler := routes.NewSummaryHandler(summaryService, userService, keyValueService)
-	settingsHandler := routes.NewSettingsHandler(userService, heartbeatService, summaryService, aliasService, aggregationService, languageMappingService, projectLabelService, keyValueService, mailService)
+	settingsHandler := routes.NewSettingsHandler(userService, heartbeatService, summaryService, aliasService, aggregationService, languageMappingService, projectLabelService, keyValueService, mailService, machineService, exportService, emailVerificationSrvc, registrationService, trackingPauseService, telegramService, mentorService)
 	subscriptionHandler := routes.NewSubscriptionHandler(userService, mailService, keyValueService)
 	projectsHandler := routes.NewProjectsHandler(userService, heartbeatService)
 	shopHandler := routes.NewShopHandler(userService, shopService)
+	deviceHandler := routes.NewDeviceHandler(userService, deviceAuthService)
 	homeHandler := routes.NewHomeHandler(userService, keyValueService)
-	loginHandler := routes.NewLoginHandler(userService, mailService, keyValueService)
+	loginHandler := routes.NewLoginHandler(userService, mailService, emailVerificationSrvc, loginThrottleService, legalService, registrationService)
 	imprintHandler := routes.NewImprintHandler(keyValueService)
+	legalHandler := routes.NewLegalHandler(legalService)
+	mentorsHandler := routes.NewMentorsHandler(userService, mentorService)
+	profileHandler := routes.NewProfileHandler(userService, profileService)
 	leaderboardHandler := condition.TernaryOperator[bool, routes.Handler](config.App.LeaderboardEnabled, routes.NewLeaderboardHandler(userService, leaderboardService), routes.NewNoopHandler())
 
 	// Other Handlers
@@ -253,14 +526,15 @@ func main() {
 	router := chi.NewRouter()
 	router.Use(
 		middleware.CleanPath,
+		middlewares.NewRequestIdMiddleware(),
 		middlewares.ForceSsl,
 		cors.Handler(cors.Options{
 			// AllowedOrigins:   []string{"https://foo.com"}, // Use this to allow specific origin hosts
 			AllowedOrigins: []string{"https://*", "http://*", "chrome-extension://*"},
 			// AllowOriginFunc:  func(r *http.Request, origin string) bool { return true },
-			AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-			ExposedHeaders:   []string{"Link"},
+			AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+			AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", middlewares.HeaderRequestId},
+			ExposedHeaders:   []string{"Link", "Retry-After", middlewares.HeaderRequestId},
 			AllowCredentials: false,
 			MaxAge:           300, // Maximum value not ignored by any of major browsers
 		}),
@@ -273,11 +547,16 @@ func main() {
 			"/service-worker.js",
 			"/api/health",
 			"/api/avatar",
+			"/api/simple", // don't leak tokens into logs
+			"/api/objects",
 		}),
 	)
 	if config.Sentry.Dsn != "" {
 		router.Use(middlewares.NewSentryMiddleware())
 	}
+	if config.LoadShedding.Enabled {
+		router.Use(middlewares.NewLoadSheddingMiddleware(loadSheddingService))
+	}
 
 	// Setup Sub Routers
 	rootRouter := chi.NewRouter()
@@ -293,23 +572,32 @@ func main() {
 	homeHandler.RegisterRoutes(rootRouter)
 	loginHandler.RegisterRoutes(rootRouter)
 	imprintHandler.RegisterRoutes(rootRouter)
+	legalHandler.RegisterRoutes(rootRouter)
+	mentorsHandler.RegisterRoutes(rootRouter)
+	profileHandler.RegisterRoutes(rootRouter)
 	summaryHandler.RegisterRoutes(rootRouter)
 	leaderboardHandler.RegisterRoutes(rootRouter)
 	projectsHandler.RegisterRoutes(rootRouter)
 	shopHandler.RegisterRoutes(rootRouter)
+	deviceHandler.RegisterRoutes(rootRouter)
 	settingsHandler.RegisterRoutes(rootRouter)
 	subscriptionHandler.RegisterRoutes(rootRouter)
 	relayHandler.RegisterRoutes(rootRouter)
 
 	// API route registrations
 	summaryApiHandler.RegisterRoutes(apiRouter)
+	summaryPdfApiHandler.RegisterRoutes(apiRouter)
 	specialApiHandler.RegisterRoutes(apiRouter)
 	healthApiHandler.RegisterRoutes(apiRouter)
+	metaApiHandler.RegisterRoutes(apiRouter)
 	heartbeatApiHandler.RegisterRoutes(apiRouter)
 	metricsHandler.RegisterRoutes(apiRouter)
 	diagnosticsHandler.RegisterRoutes(apiRouter)
 	avatarHandler.RegisterRoutes(apiRouter)
 	activityHandler.RegisterRoutes(apiRouter)
+	chartsHandler.RegisterRoutes(apiRouter)
+	profileApiHandler.RegisterRoutes(apiRouter)
+	activityGraphHandler.RegisterRoutes(apiRouter)
 	badgeHandler.RegisterRoutes(apiRouter)
 	wakatimeV1StatusBarHandler.RegisterRoutes(apiRouter)
 	wakatimeV1AllHandler.RegisterRoutes(apiRouter)
@@ -317,10 +605,54 @@ func main() {
 	wakatimeV1StatsHandler.RegisterRoutes(apiRouter)
 	wakatimeV1UsersHandler.RegisterRoutes(apiRouter)
 	wakatimeV1ProjectsHandler.RegisterRoutes(apiRouter)
+	wakatimeV1UserAgentsHandler.RegisterRoutes(apiRouter)
+	wakatimeV1MachineNamesHandler.RegisterRoutes(apiRouter)
 	wakatimeV1HeartbeatsHandler.RegisterRoutes(apiRouter)
+	wakatimeV1ExternalDurationsHandler.RegisterRoutes(apiRouter)
 	wakatimeV1LeadersHandler.RegisterRoutes(apiRouter)
 	shieldV1BadgeHandler.RegisterRoutes(apiRouter)
 	captchaHandler.RegisterRoutes(apiRouter)
+	secretScanningHandler.RegisterRoutes(apiRouter)
+	storageHandler.RegisterRoutes(apiRouter)
+	serviceAccountsHandler.RegisterRoutes(apiRouter)
+	userInvitesHandler.RegisterRoutes(apiRouter)
+	userSuspensionHandler.RegisterRoutes(apiRouter)
+	userExternalIdsHandler.RegisterRoutes(apiRouter)
+	simpleHandler.RegisterRoutes(apiRouter)
+	settingsApiHandler.RegisterRoutes(apiRouter)
+	scimHandler.RegisterRoutes(apiRouter)
+	objectsHandler.RegisterRoutes(apiRouter)
+	personalRecordsHandler.RegisterRoutes(apiRouter)
+	yearReviewHandler.RegisterRoutes(apiRouter)
+	leaderboardSeasonsHandler.RegisterRoutes(apiRouter)
+	pluginReleasesHandler.RegisterRoutes(apiRouter)
+	resummarizeHandler.RegisterRoutes(apiRouter)
+	queryConsoleHandler.RegisterRoutes(apiRouter)
+	mentorsApiHandler.RegisterRoutes(apiRouter)
+	leaderboardWebhookHandler.RegisterRoutes(apiRouter)
+	importsApiHandler.RegisterRoutes(apiRouter)
+	projectMetadataHandler.RegisterRoutes(apiRouter)
+	userPreferencesHandler.RegisterRoutes(apiRouter)
+	concurrencyApiHandler.RegisterRoutes(apiRouter)
+	canonicalNamesApiHandler.RegisterRoutes(apiRouter)
+	registrationApiHandler.RegisterRoutes(apiRouter)
+	deviceApiHandler.RegisterRoutes(apiRouter)
+	projectOverrideHandler.RegisterRoutes(apiRouter)
+	relayRulesHandler.RegisterRoutes(apiRouter)
+	teamsHandler.RegisterRoutes(apiRouter)
+	compareHandler.RegisterRoutes(apiRouter)
+	analyticsHandler.RegisterRoutes(apiRouter)
+	locationsHandler.RegisterRoutes(apiRouter)
+	projectRenameHandler.RegisterRoutes(apiRouter)
+	machinesHandler.RegisterRoutes(apiRouter)
+	telegramHandler.RegisterRoutes(apiRouter)
+	notificationTemplatesHandler.RegisterRoutes(apiRouter)
+	languageGoalHandler.RegisterRoutes(apiRouter)
+	timeEntriesHandler.RegisterRoutes(apiRouter)
+	trackingPausesHandler.RegisterRoutes(apiRouter)
+	tagRulesHandler.RegisterRoutes(apiRouter)
+	userProfileHandler.RegisterRoutes(apiRouter)
+	devHandler.RegisterRoutes(apiRouter)
 
 	// Static Routes
 	// https://github.com/golang/go/issues/43431
@@ -395,7 +727,7 @@ func listen(handler http.Handler) {
 		if s4 != nil {
 			slog.Info("👉 Listening for HTTPS... ✅", "address", s4.Addr)
 			go func() {
-				if err := s4.ListenAndServeTLS(config.Server.TlsCertPath, config.Server.TlsKeyPath); err != nil {
+				if err := s4.ListenAndServeTLS(config.Server.TlsCertPath, config.Server.TlsKeyPath); err != nil && !errors.Is(err, http.ErrServerClosed) {
 					conf.Log().Fatal(err.Error())
 				}
 			}()
@@ -403,7 +735,7 @@ func listen(handler http.Handler) {
 		if s6 != nil {
 			slog.Info("👉 Listening for HTTPS... ✅", "address", s6.Addr)
 			go func() {
-				if err := s6.ListenAndServeTLS(config.Server.TlsCertPath, config.Server.TlsKeyPath); err != nil {
+				if err := s6.ListenAndServeTLS(config.Server.TlsCertPath, config.Server.TlsKeyPath); err != nil && !errors.Is(err, http.ErrServerClosed) {
 					conf.Log().Fatal(err.Error())
 				}
 			}()
@@ -418,7 +750,7 @@ func listen(handler http.Handler) {
 				if err := os.Chmod(config.Server.ListenSocket, os.FileMode(config.Server.ListenSocketMode)); err != nil {
 					slog.Warn("failed to set user permissions for unix socket", "error", err)
 				}
-				if err := sSocket.ServeTLS(unixListener, config.Server.TlsCertPath, config.Server.TlsKeyPath); err != nil {
+				if err := sSocket.ServeTLS(unixListener, config.Server.TlsCertPath, config.Server.TlsKeyPath); err != nil && !errors.Is(err, http.ErrServerClosed) {
 					conf.Log().Fatal(err.Error())
 				}
 			}()
@@ -427,7 +759,7 @@ func listen(handler http.Handler) {
 		if s4 != nil {
 			slog.Info("👉 Listening for HTTP... ✅", "address", s4.Addr)
 			go func() {
-				if err := s4.ListenAndServe(); err != nil {
+				if err := s4.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 					conf.Log().Fatal(err.Error())
 				}
 			}()
@@ -435,7 +767,7 @@ func listen(handler http.Handler) {
 		if s6 != nil {
 			slog.Info("👉 Listening for HTTP... ✅", "address", s6.Addr)
 			go func() {
-				if err := s6.ListenAndServe(); err != nil {
+				if err := s6.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 					conf.Log().Fatal(err.Error())
 				}
 			}()
@@ -450,12 +782,208 @@ func listen(handler http.Handler) {
 				if err := os.Chmod(config.Server.ListenSocket, os.FileMode(config.Server.ListenSocketMode)); err != nil {
 					slog.Warn("failed to set user permissions for unix socket", "error", err)
 				}
-				if err := sSocket.Serve(unixListener); err != nil {
+				if err := sSocket.Serve(unixListener); err != nil && !errors.Is(err, http.ErrServerClosed) {
 					conf.Log().Fatal(err.Error())
 				}
 			}()
 		}
 	}
 
-	<-make(chan interface{}, 1)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+
+	shutdown(slice.Filter([]*http.Server{s4, s6, sSocket}, func(_ int, s *http.Server) bool { return s != nil }))
+}
+
+// shutdown stops accepting new requests and waits for in-flight ones, relayed heartbeats, spooled heartbeats and queued jobs
+// to complete, at most for the configured timeout. database connections are closed once main returns.
+func shutdown(servers []*http.Server) {
+	timeout := time.Duration(config.Server.ShutdownTimeoutSec) * time.Second
+	slog.Info("shutting down gracefully", "timeout", timeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, s := range servers {
+		wg.Add(1)
+		go func(s *http.Server) {
+			defer wg.Done()
+			if err := s.Shutdown(ctx); err != nil {
+				slog.Warn("failed to complete in-flight requests", "error", err)
+			}
+		}(s)
+	}
+	wg.Wait()
+
+	if !customMiddleware.WaitForPendingRelays(ctx) {
+		slog.Warn("gave up waiting for relayed heartbeats to be sent")
+	}
+	if err := loadSheddingService.FlushSpool(); err != nil {
+		conf.Log().Error("failed to flush spooled heartbeats, they are lost", "count", loadSheddingService.CountSpooled(), "error", err)
+	}
+	if !conf.DrainQueues(ctx) {
+		slog.Warn("gave up waiting for queued jobs to complete", "pending", conf.CountPendingJobs())
+	}
+	conf.CloseQueues()
+
+	slog.Info("shutdown complete, closing database connections")
+}
+
+// migrate runs the migrate subcommand, i.e. up, down or status, against the configured database
+func migrate(cmd string, dryRun bool, steps int) int {
+	var plans []*migrations.MigrationPlan
+	var err error
+
+	switch cmd {
+	case "status":
+		status, err := migrations.Status(db)
+		if err != nil {
+			fmt.Printf("failed to get migration status: %v\n", err)
+			return 1
+		}
+		for _, s := range status {
+			state := "pending"
+			if s.Applied {
+				state = "applied " + s.AppliedAt
+			}
+			reversible := ""
+			if !s.Reversible {
+				reversible = " (irreversible)"
+			}
+			fmt.Printf("[%s] %s: %s%s\n", state, s.Version, s.Description, reversible)
+		}
+		return 0
+	case "up":
+		if dryRun {
+			fmt.Println("schema auto-migration and legacy data migrations are skipped in dry run mode")
+		} else {
+			migrations.RunPreMigrations(db, config)
+			migrations.RunSchemaMigrations(db, config)
+			migrations.RunPostMigrations(db, config)
+		}
+		plans, err = migrations.Up(db, config, dryRun)
+	case "down":
+		plans, err = migrations.Down(db, config, steps, dryRun)
+	default:
+		fmt.Printf("unknown migrate command '%s', use up, down or status\n", cmd)
+		return 1
+	}
+
+	for _, p := range plans {
+		fmt.Printf("[%s] %s\n", cmd, p.Version)
+		for _, s := range p.Statements {
+			fmt.Printf("    %s;\n", s)
+		}
+	}
+	if len(plans) == 0 && err == nil {
+		fmt.Println("nothing to migrate")
+	}
+	if err != nil {
+		fmt.Println(err)
+		return 1
+	}
+	return 0
+}
+
+// reindex builds the recommended indexes without locking their tables and prints how the query plans changed
+func reindex(dryRun bool) int {
+	exitCode := 0
+	for _, r := range migrations.Reindex(db, config, dryRun) {
+		fmt.Printf("[%s] %s on %s", strings.ToUpper(r.Status), r.Index, r.Table)
+		if r.Took > 0 {
+			fmt.Printf(" (took %s)", r.Took.Round(time.Millisecond))
+		}
+		fmt.Println()
+
+		switch r.Status {
+		case migrations.ReindexStatusPlanned:
+			fmt.Printf("    %s;\n", r.Statement)
+			fmt.Printf("    current plan:\n%s\n", indent(r.PlanBefore, 8))
+		case migrations.ReindexStatusCreated:
+			fmt.Printf("    plan before:\n%s\n", indent(r.PlanBefore, 8))
+			fmt.Printf("    plan after:\n%s\n", indent(r.PlanAfter, 8))
+			if !r.UsesIndex {
+				fmt.Println("    hint: the planner doesn't use the index yet, which is expected for small tables or outdated statistics")
+			}
+		case migrations.ReindexStatusFailed:
+			fmt.Printf("    %v\n", r.Error)
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
+
+func indent(text string, n int) string {
+	prefix := strings.Repeat(" ", n)
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
+
+// resummarize re-generates the summaries of the given user, or of all users, within the given date range and reports progress
+func resummarize(username, fromDate, toDate string) int {
+	from, err := helpers.ParseDateTimeTZ(fromDate, time.Local)
+	if err != nil {
+		fmt.Println("invalid or missing -from date")
+		return 1
+	}
+	to := time.Now()
+	if toDate != "" {
+		if to, err = helpers.ParseDateTimeTZ(toDate, time.Local); err != nil {
+			fmt.Println("invalid -to date")
+			return 1
+		}
+	}
+
+	var users []*models.User
+	if username != "" {
+		user, err := userService.GetUserById(username)
+		if err != nil {
+			fmt.Printf("user '%s' not found\n", username)
+			return 1
+		}
+		users = []*models.User{user}
+	} else if users, err = userService.GetAll(); err != nil {
+		fmt.Printf("failed to fetch users: %v\n", err)
+		return 1
+	}
+
+	exitCode := 0
+	for _, u := range users {
+		job, err := aggregationService.Resummarize(u, from, to)
+		if err != nil {
+			fmt.Printf("[%s] %v\n", u.ID, err)
+			exitCode = 1
+			continue
+		}
+		for job.IsRunning() {
+			time.Sleep(1 * time.Second)
+			fmt.Printf("\r[%s] %.0f%%", u.ID, job.Progress()*100)
+		}
+		result := job.Snapshot()
+		fmt.Printf("\r[%s] %s: %d days done, %d failed\n", u.ID, result.Status, result.DaysDone, result.DaysFailed)
+		if result.Status != models.ResummarizeStatusFinished || result.DaysFailed > 0 {
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
+
+// checkConfig validates the loaded config against the environment, prints the results and returns the exit code
+func checkConfig() int {
+	exitCode := 0
+	for _, c := range services.NewConfigCheckService(mail.NewMailService(nil)).RunAll() {
+		fmt.Printf("[%s] %s", strings.ToUpper(c.Status), c.Name)
+		if c.Message != "" {
+			fmt.Printf(": %s", c.Message)
+		}
+		fmt.Println()
+		if c.Hint != "" {
+			fmt.Printf("    hint: %s\n", c.Hint)
+		}
+		if c.Failed() {
+			exitCode = 1
+		}
+	}
+	return exitCode
 }
@@ -22,7 +22,11 @@ const (
 )
 
 var (
-	errEmptyKey = fmt.Errorf("the api_key is empty")
+	errEmptyKey       = fmt.Errorf("the api_key is empty")
+	errDeactivated    = fmt.Errorf("the user is deactivated")
+	errInvitePending  = fmt.Errorf("the user hasn't accepted their invite, yet")
+	errServiceAccount = fmt.Errorf("service accounts may only authenticate via api key")
+	errSuspended      = fmt.Errorf("the user is suspended")
 )
 
 type AuthenticateMiddleware struct {
@@ -66,6 +70,9 @@ func (m *AuthenticateMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Reques
 	var user *models.User
 
 	user, err := m.tryGetUserByCookie(r)
+	if err == nil && user != nil && user.IsServiceAccount {
+		err = errServiceAccount
+	}
 	if err != nil {
 		user, err = m.tryGetUserByApiKeyHeader(r)
 	}
@@ -74,6 +81,23 @@ func (m *AuthenticateMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Reques
 	}
 	if err != nil && m.config.Security.TrustedHeaderAuth {
 		user, err = m.tryGetUserByTrustedHeader(r)
+		if err == nil && user != nil && user.IsServiceAccount {
+			err = errServiceAccount
+		}
+	}
+	if err == nil && user != nil && user.Deactivated {
+		err = errDeactivated
+	}
+	if err == nil && user != nil && user.InvitePending {
+		err = errInvitePending
+	}
+
+	if err == nil && user != nil && user.IsSuspended() {
+		if !m.isOptional(r.URL.Path) {
+			m.rejectSuspended(w, r, user)
+			return
+		}
+		user, err = nil, errSuspended // suspended users may still browse public pages, but only anonymously
 	}
 
 	if err != nil || user == nil {
@@ -83,8 +107,7 @@ func (m *AuthenticateMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Reques
 		}
 
 		if m.redirectTarget == "" {
-			w.WriteHeader(http.StatusUnauthorized)
-			w.Write([]byte(conf.ErrUnauthorized))
+			helpers.RespondError(w, r, http.StatusUnauthorized, models.ApiErrorUnauthorized, conf.ErrUnauthorized)
 		} else {
 			if m.redirectErrorMessage != "" {
 				session, _ := conf.GetSessionStore().Get(r, conf.SessionKeyDefault)
@@ -101,6 +124,20 @@ func (m *AuthenticateMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Reques
 	next(w, r)
 }
 
+// rejectSuspended responds with an explicit message instead of a generic 401, so that users (and plugins, which display it) learn why they're locked out
+func (m *AuthenticateMiddleware) rejectSuspended(w http.ResponseWriter, r *http.Request, user *models.User) {
+	if m.redirectTarget == "" {
+		helpers.RespondError(w, r, http.StatusForbidden, models.ApiErrorSuspended, user.SuspensionMessage())
+		return
+	}
+
+	session, _ := conf.GetSessionStore().Get(r, conf.SessionKeyDefault)
+	session.AddFlash(user.SuspensionMessage(), "error")
+	session.Save(r, w)
+	http.SetCookie(w, m.config.GetClearCookie(models.AuthCookieKey))
+	http.Redirect(w, r, m.redirectTarget, http.StatusFound)
+}
+
 func (m *AuthenticateMiddleware) isOptional(requestPath string) bool {
 	for _, p := range m.optionalForPaths {
 		if strings.HasPrefix(requestPath, p) || requestPath == p {
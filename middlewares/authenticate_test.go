@@ -1,11 +1,15 @@
 package middlewares
 
 import (
+	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/hackclub/hackatime/config"
 
@@ -186,4 +190,72 @@ func TestAuthenticateMiddleware_tryGetUserByTrustedHeader_Success(t *testing.T)
 	}
 }
 
+func TestAuthenticateMiddleware_ServiceAccount(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Security.TrustedHeaderAuth = true
+	cfg.Security.TrustedHeaderAuthKey = "Remote-User"
+	cfg.Security.TrustReverseProxyIps = "127.0.0.1"
+	cfg.Security.ParseTrustReverseProxyIPs()
+	config.Set(cfg)
+
+	testApiKey := "86648d74-19c5-452b-ba01-fb3ec70d4c2f"
+	testUser := &models.User{ID: "importer", ApiKey: testApiKey, IsServiceAccount: true}
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserById", testUser.ID).Return(testUser, nil)
+	userServiceMock.On("GetUserByKey", testApiKey).Return(testUser, nil)
+
+	sut := NewAuthenticateMiddleware(userServiceMock)
+	serve := func(header http.Header) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/summary", nil)
+		req.RemoteAddr = "127.0.0.1:54654"
+		req.Header = header
+		rec := httptest.NewRecorder()
+		sut.ServeHTTP(rec, req, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		})
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, serve(http.Header{"Remote-User": []string{testUser.ID}}))
+	assert.Equal(t, http.StatusOK, serve(http.Header{"Authorization": []string{"Bearer " + base64.StdEncoding.EncodeToString([]byte(testApiKey))}}))
+}
+
+func TestAuthenticateMiddleware_Suspended(t *testing.T) {
+	config.Set(config.Empty())
+
+	testApiKey := "86648d74-19c5-452b-ba01-fb3ec70d4c2f"
+	suspendedAt := models.CustomTime(time.Now())
+	testUser := &models.User{ID: "user1", ApiKey: testApiKey, SuspendedAt: &suspendedAt, SuspensionReason: "spam"}
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserByKey", testApiKey).Return(testUser, nil)
+
+	sut := NewAuthenticateMiddleware(userServiceMock).WithOptionalFor("/api/badge")
+	serve := func(path string) (*httptest.ResponseRecorder, *models.User) {
+		var principal *models.User
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		req.Header.Set("Authorization", "Bearer "+base64.StdEncoding.EncodeToString([]byte(testApiKey)))
+		req = req.WithContext(context.WithValue(req.Context(), keyPrincipal, &PrincipalContainer{}))
+		rec := httptest.NewRecorder()
+		sut.ServeHTTP(rec, req, func(w http.ResponseWriter, r *http.Request) {
+			principal = GetPrincipal(r)
+			w.WriteHeader(http.StatusOK)
+		})
+		return rec, principal
+	}
+
+	rec, _ := serve("/api/heartbeat")
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	var apiErr models.ApiError
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&apiErr))
+	assert.Equal(t, "your account was suspended by an administrator (spam)", apiErr.Error)
+	assert.Equal(t, models.ApiErrorSuspended, apiErr.Code)
+	assert.False(t, apiErr.Retryable)
+
+	rec, principal := serve("/api/badge/user1")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Nil(t, principal)
+}
+
 // TODO: somehow test cookie auth function
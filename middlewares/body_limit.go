@@ -0,0 +1,58 @@
+package middlewares
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/models"
+)
+
+// BodyLimitMiddleware rejects requests whose body exceeds the given size before any downstream handler gets to decode it
+// the body is buffered upfront, because some middlewares (e.g. the wakatime relay) read it while discarding errors
+type BodyLimitMiddleware struct {
+	handler  http.Handler
+	maxBytes int64
+}
+
+func NewBodyLimitMiddleware(maxBytes int64) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return &BodyLimitMiddleware{
+			handler:  h,
+			maxBytes: maxBytes,
+		}
+	}
+}
+
+func (m *BodyLimitMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ContentLength > m.maxBytes {
+		m.reject(w, r)
+		return
+	}
+
+	if r.Body != nil && r.Body != http.NoBody {
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, m.maxBytes))
+		r.Body.Close()
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				m.reject(w, r)
+				return
+			}
+			helpers.RespondError(w, r, http.StatusBadRequest, models.ApiErrorBadRequest, conf.ErrBadRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	m.handler.ServeHTTP(w, r)
+}
+
+func (m *BodyLimitMiddleware) reject(w http.ResponseWriter, r *http.Request) {
+	conf.Log().Request(r).Warn("rejecting request with oversized body", "contentLength", r.ContentLength, "maxBytes", m.maxBytes)
+	helpers.RespondError(w, r, http.StatusRequestEntityTooLarge, models.ApiErrorTooLarge, fmt.Sprintf("%s: request body must not exceed %d bytes", conf.ErrEntityTooLarge, m.maxBytes))
+}
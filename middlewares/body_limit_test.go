@@ -0,0 +1,42 @@
+package middlewares
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	var received string
+	sut := NewBodyLimitMiddleware(16)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	// within limit
+	rec := httptest.NewRecorder()
+	sut.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/heartbeat", strings.NewReader(`{"entity":"a"}`)))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, `{"entity":"a"}`, received)
+
+	// content length exceeded
+	received = ""
+	rec = httptest.NewRecorder()
+	sut.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/heartbeat", strings.NewReader(`{"entity":"too long"}`)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Contains(t, rec.Body.String(), "16 bytes")
+	assert.Empty(t, received)
+
+	// unknown content length (e.g. chunked)
+	req := httptest.NewRequest(http.MethodPost, "/api/heartbeat", io.NopCloser(strings.NewReader(`{"entity":"too long"}`)))
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	sut.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Empty(t, received)
+}
@@ -2,6 +2,7 @@ package relay
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
@@ -9,34 +10,56 @@ import (
 	"io"
 	"log/slog"
 	"net/http"
+	"sync/atomic"
 	"time"
 
 	"github.com/hackclub/hackatime/config"
 	"github.com/hackclub/hackatime/middlewares"
 	"github.com/hackclub/hackatime/models"
 	routeutils "github.com/hackclub/hackatime/routes/utils"
+	"github.com/hackclub/hackatime/services"
 	"github.com/leandro-lugaresi/hub"
 	"github.com/patrickmn/go-cache"
 )
 
 const maxFailuresPerDay = 100
 
+// number of relays that are still being sent, to be awaited upon shutdown
+var pendingRelays atomic.Int32
+
+// WaitForPendingRelays blocks until all heartbeats currently being relayed were sent, or until the context is done, and reports whether all were sent
+func WaitForPendingRelays(ctx context.Context) bool {
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for pendingRelays.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+	return true
+}
+
 // WakatimeRelayMiddleware is a middleware to conditionally relay heartbeats to Wakatime (and other compatible services)
 type WakatimeRelayMiddleware struct {
-	httpClient   *http.Client
-	hashCache    *cache.Cache
-	failureCache *cache.Cache
-	eventBus     *hub.Hub
+	httpClient    *http.Client
+	hashCache     *cache.Cache
+	failureCache  *cache.Cache
+	eventBus      *hub.Hub
+	relayRuleSrvc services.IRelayRuleService
 }
 
-func NewWakatimeRelayMiddleware() *WakatimeRelayMiddleware {
+func NewWakatimeRelayMiddleware(relayRuleService services.IRelayRuleService) *WakatimeRelayMiddleware {
 	return &WakatimeRelayMiddleware{
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
 		},
-		hashCache:    cache.New(10*time.Minute, 10*time.Minute),
-		failureCache: cache.New(24*time.Hour, 1*time.Hour),
-		eventBus:     config.EventBus(),
+		hashCache:     cache.New(10*time.Minute, 10*time.Minute),
+		failureCache:  cache.New(24*time.Hour, 1*time.Hour),
+		eventBus:      config.EventBus(),
+		relayRuleSrvc: relayRuleService,
 	}
 }
 
@@ -71,6 +94,13 @@ func (m *WakatimeRelayMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Reque
 	r.Body.Close()
 	r.Body = io.NopCloser(bytes.NewBuffer(body))
 
+	// rather not relay at all than leaking what the user's rules were supposed to hide
+	relayBody, err := m.applyRules(body, user)
+	if err != nil {
+		slog.Warn("failed to apply relay rules", "userID", user.ID, "error", err)
+		return
+	}
+
 	// prevent cycles
 	downstreamInstanceId := ownInstanceId
 	if originInstanceId != "" {
@@ -93,13 +123,17 @@ func (m *WakatimeRelayMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Reque
 
 	url := user.WakaTimeURL(config.WakatimeApiUrl) + config.WakatimeApiHeartbeatsBulkUrl
 
-	go m.send(
-		http.MethodPost,
-		url,
-		bytes.NewReader(body),
-		headers,
-		user,
-	)
+	pendingRelays.Add(1)
+	go func() {
+		defer pendingRelays.Add(-1)
+		m.send(
+			http.MethodPost,
+			url,
+			bytes.NewReader(relayBody),
+			headers,
+			user,
+		)
+	}()
 }
 
 func (m *WakatimeRelayMiddleware) send(method, url string, body io.Reader, headers http.Header, forUser *models.User) {
@@ -194,3 +228,28 @@ func (m *WakatimeRelayMiddleware) filterByCache(r *http.Request) error {
 
 	return nil
 }
+
+// applyRules transforms the heartbeats to be relayed according to the user's relay rules and returns the new body.
+// the body is expected to be a json list, as produced by filterByCache. The request itself is left untouched, as rules must not affect what is stored locally.
+func (m *WakatimeRelayMiddleware) applyRules(body []byte, user *models.User) ([]byte, error) {
+	if m.relayRuleSrvc == nil {
+		return body, nil
+	}
+
+	rules, err := m.relayRuleSrvc.GetByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return body, nil
+	}
+
+	var rawData []map[string]interface{}
+	if err := json.Unmarshal(body, &rawData); err != nil {
+		return nil, err
+	}
+	for _, hb := range rawData {
+		rules.Apply(hb)
+	}
+	return json.Marshal(rawData)
+}
@@ -0,0 +1,64 @@
+package relay
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaitForPendingRelays(t *testing.T) {
+	cfg := config.Empty()
+	cfg.InstanceId = "instance"
+	config.Set(cfg)
+
+	received, release := make(chan string, 1), make(chan bool)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- r.URL.Path + " " + string(body)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer upstream.Close()
+
+	user := &models.User{ID: "user", WakatimeApiKey: "wakatime-api-key", WakatimeApiUrl: upstream.URL}
+
+	var handled bool
+	sut := NewWakatimeRelayMiddleware(nil)
+	handler := middlewares.NewPrincipalMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		middlewares.SetPrincipal(r, user)
+		sut.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handled = true
+		})).ServeHTTP(w, r)
+	}))
+
+	body := `[{"entity":"main.go","type":"file","category":"coding","project":"wakapi","language":"Go","time":1760000000}]`
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/heartbeats", strings.NewReader(body)))
+	assert.True(t, handled) // not blocked by the relay
+
+	select {
+	case req := <-received:
+		assert.True(t, strings.HasPrefix(req, config.WakatimeApiHeartbeatsBulkUrl+" "))
+		assert.Contains(t, req, `"entity":"main.go"`)
+	case <-time.After(time.Second):
+		t.Fatal("heartbeat was not relayed")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.False(t, WaitForPendingRelays(ctx))
+
+	close(release)
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.True(t, WaitForPendingRelays(ctx))
+}
@@ -0,0 +1,130 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/models"
+	"github.com/patrickmn/go-cache"
+)
+
+const (
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotentReplayed  = "Idempotent-Replayed"
+	idempotencyKeyMaxLength   = 255
+	idempotencyKeyRetention   = 24 * time.Hour
+	idempotencyCleanupPeriod  = 1 * time.Hour
+	idempotencyInFlightMarker = "in_flight"
+)
+
+type idempotentResponse struct {
+	bodyHash [32]byte
+	status   int
+	header   http.Header
+	body     []byte
+}
+
+// IdempotencyMiddleware remembers responses to requests carrying an idempotency key for a day and replays them
+// to retries of the same request, instead of processing it again. It must come after authentication, since keys are scoped per user.
+// Responses with server errors are not remembered, so that clients may retry those.
+type IdempotencyMiddleware struct {
+	handler   http.Handler
+	responses *cache.Cache
+	lock      *sync.Mutex
+}
+
+func NewIdempotencyMiddleware() func(http.Handler) http.Handler {
+	responses := cache.New(idempotencyKeyRetention, idempotencyCleanupPeriod)
+	lock := &sync.Mutex{}
+	return func(h http.Handler) http.Handler {
+		return &IdempotencyMiddleware{
+			handler:   h,
+			responses: responses,
+			lock:      lock,
+		}
+	}
+}
+
+func (m *IdempotencyMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	idempotencyKey := r.Header.Get(HeaderIdempotencyKey)
+	user := GetPrincipal(r)
+	if idempotencyKey == "" || user == nil || r.Method != http.MethodPost {
+		m.handler.ServeHTTP(w, r)
+		return
+	}
+
+	if len(idempotencyKey) > idempotencyKeyMaxLength {
+		helpers.RespondError(w, r, http.StatusBadRequest, models.ApiErrorInvalidInput, fmt.Sprintf("idempotency key must not be longer than %d characters", idempotencyKeyMaxLength))
+		return
+	}
+
+	body, _ := io.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewBuffer(body))
+	bodyHash := sha256.Sum256(body)
+
+	cacheKey := fmt.Sprintf("%s:%s", user.ID, idempotencyKey)
+
+	m.lock.Lock()
+	cached, found := m.responses.Get(cacheKey)
+	if !found {
+		m.responses.SetDefault(cacheKey, idempotencyInFlightMarker)
+	}
+	m.lock.Unlock()
+
+	if found {
+		response, ok := cached.(*idempotentResponse)
+		if !ok {
+			helpers.RespondErrorRetryAfter(w, r, http.StatusConflict, models.ApiErrorConflict, "a request with the same idempotency key is still being processed", time.Second)
+			return
+		}
+		if response.bodyHash != bodyHash {
+			helpers.RespondError(w, r, http.StatusUnprocessableEntity, models.ApiErrorIdempotencyUsed, "idempotency key was already used for a different request")
+			return
+		}
+		for k, v := range response.header {
+			w.Header()[k] = v
+		}
+		w.Header().Set(HeaderIdempotentReplayed, "true")
+		w.WriteHeader(response.status)
+		w.Write(response.body)
+		return
+	}
+
+	rec := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+	m.handler.ServeHTTP(rec, r)
+
+	if rec.status >= http.StatusInternalServerError {
+		m.responses.Delete(cacheKey)
+		return
+	}
+	m.responses.SetDefault(cacheKey, &idempotentResponse{
+		bodyHash: bodyHash,
+		status:   rec.status,
+		header:   w.Header().Clone(),
+		body:     rec.body.Bytes(),
+	})
+}
+
+// recordingWriter captures the status and body written to the underlying response writer
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
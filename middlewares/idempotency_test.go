@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	var calls int
+	sut := NewIdempotencyMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"responses":[]}`))
+	}))
+
+	newRequest := func(key, body string) *http.Request {
+		ctx := context.WithValue(context.Background(), keyPrincipal, &PrincipalContainer{principal: &models.User{ID: "user1"}})
+		r := httptest.NewRequest(http.MethodPost, "/api/heartbeats", strings.NewReader(body)).WithContext(ctx)
+		r.Header.Set(HeaderIdempotencyKey, key)
+		return r
+	}
+
+	rec := httptest.NewRecorder()
+	sut.ServeHTTP(rec, newRequest("key1", `[{"entity":"a"}]`))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Empty(t, rec.Header().Get(HeaderIdempotentReplayed))
+
+	// replay
+	rec = httptest.NewRecorder()
+	sut.ServeHTTP(rec, newRequest("key1", `[{"entity":"a"}]`))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, `{"responses":[]}`, rec.Body.String())
+	assert.Equal(t, "true", rec.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal(t, 1, calls)
+
+	// same key, different payload
+	rec = httptest.NewRecorder()
+	sut.ServeHTTP(rec, newRequest("key1", `[{"entity":"b"}]`))
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	assert.Equal(t, 1, calls)
+
+	// different key
+	rec = httptest.NewRecorder()
+	sut.ServeHTTP(rec, newRequest("key2", `[{"entity":"a"}]`))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, 2, calls)
+
+	// no key
+	rec = httptest.NewRecorder()
+	sut.ServeHTTP(rec, newRequest("", `[{"entity":"a"}]`))
+	sut.ServeHTTP(rec, newRequest("", `[{"entity":"a"}]`))
+	assert.Equal(t, 4, calls)
+}
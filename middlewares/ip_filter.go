@@ -0,0 +1,165 @@
+package middlewares
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/duke-git/lancet/v2/slice"
+	"github.com/go-chi/httprate"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/models"
+)
+
+const (
+	IpBlockedReasonDenied        = "denied"
+	IpBlockedReasonNotAllowed    = "not_allowed"
+	IpBlockedReasonUnknownOrigin = "unknown_origin"
+)
+
+var ipBlockedCounts = map[string]*atomic.Int64{
+	IpBlockedReasonDenied:        {},
+	IpBlockedReasonNotAllowed:    {},
+	IpBlockedReasonUnknownOrigin: {},
+}
+
+// IpFilterMiddleware rejects requests from clients on the denylist or, if an allowlist is configured, not on the allowlist
+type IpFilterMiddleware struct {
+	config  *conf.Config
+	handler http.Handler
+}
+
+func NewIpFilterMiddleware() func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return &IpFilterMiddleware{
+			config:  conf.Get(),
+			handler: h,
+		}
+	}
+}
+
+func (m *IpFilterMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	allowed, denied := m.config.Security.IngestionAllowIPs(), m.config.Security.IngestionDenyIPs()
+	if len(allowed) == 0 && len(denied) == 0 {
+		m.handler.ServeHTTP(w, r)
+		return
+	}
+
+	if reason := m.check(GetClientIp(r), allowed, denied); reason != "" {
+		ipBlockedCounts[reason].Add(1)
+		helpers.RespondError(w, r, http.StatusForbidden, models.ApiErrorForbidden, conf.ErrForbidden)
+		return
+	}
+
+	m.handler.ServeHTTP(w, r)
+}
+
+// check returns the reason why the given ip is blocked, or an empty string, if it is not
+func (m *IpFilterMiddleware) check(ip net.IP, allowed, denied []net.IPNet) string {
+	if ip == nil {
+		return IpBlockedReasonUnknownOrigin
+	}
+	contains := func(ipNet net.IPNet) bool { return ipNet.Contains(ip) }
+	if slice.ContainBy[net.IPNet](denied, contains) {
+		return IpBlockedReasonDenied
+	}
+	if len(allowed) > 0 && !slice.ContainBy[net.IPNet](allowed, contains) {
+		return IpBlockedReasonNotAllowed
+	}
+	return ""
+}
+
+// GetClientIp returns the ip address of the client that sent the request, or nil, if it can't be determined
+// the client ip is only taken from forwarding headers, if the request was sent by a trusted reverse proxy
+func GetClientIp(r *http.Request) net.IP {
+	trustedProxies := conf.Get().Security.TrustReverseProxyIPs()
+	isTrustedProxy := func(ip net.IP) bool {
+		return slice.ContainBy[net.IPNet](trustedProxies, func(ipNet net.IPNet) bool {
+			return ipNet.Contains(ip)
+		})
+	}
+
+	remoteIp := parseIp(r.RemoteAddr)
+	if remoteIp == nil || !isTrustedProxy(remoteIp) {
+		return remoteIp
+	}
+
+	hops := forwardedHops(r)
+	// clients may send arbitrary entries themselves, so take the last one not appended by any of our proxies
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := parseIp(hops[i])
+		if ip == nil {
+			return nil
+		}
+		if !isTrustedProxy(ip) || i == 0 {
+			return ip
+		}
+	}
+	if ip := parseIp(r.Header.Get("X-Real-Ip")); ip != nil {
+		return ip
+	}
+	return remoteIp
+}
+
+// KeyByClientIp is a rate limiting key func, which, unlike httprate.KeyByRealIP, only trusts forwarding headers set by trusted reverse proxies
+func KeyByClientIp(r *http.Request) (string, error) {
+	if ip := GetClientIp(r); ip != nil {
+		return ip.String(), nil
+	}
+	return r.RemoteAddr, nil
+}
+
+// LimitByClientIp is a drop-in replacement for httprate.LimitByRealIP, which can't be tricked into using spoofed forwarding headers
+func LimitByClientIp(requestLimit int, windowLength time.Duration) func(http.Handler) http.Handler {
+	return httprate.Limit(requestLimit, windowLength, httprate.WithKeyFuncs(KeyByClientIp))
+}
+
+// forwardedHops returns the addresses from the X-Forwarded-For header or, if absent, from the standardized Forwarded header (rfc 7239)
+func forwardedHops(r *http.Request) []string {
+	if forwardedFor := r.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+		return strings.Split(forwardedFor, ",")
+	}
+
+	hops := make([]string, 0)
+	for _, header := range r.Header.Values("Forwarded") {
+		for _, element := range strings.Split(header, ",") {
+			for _, pair := range strings.Split(element, ";") {
+				if k, v, ok := strings.Cut(strings.TrimSpace(pair), "="); ok && strings.EqualFold(k, "for") {
+					hops = append(hops, v)
+				}
+			}
+		}
+	}
+	return hops
+}
+
+// parseIp parses an ip address as found in the remote address or forwarding headers, which may be quoted, bracketed, followed by a port or carry a zone,
+// e.g. "203.0.113.5:1234", "[2001:db8::1]:1234", "\"[2001:db8::1]\"" or "fe80::1%eth0". ipv4-mapped ipv6 addresses are returned as plain ipv4 addresses.
+func parseIp(addr string) net.IP {
+	addr = strings.Trim(strings.TrimSpace(addr), "\"")
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	addr = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+	if i := strings.IndexByte(addr, '%'); i >= 0 {
+		addr = addr[:i]
+	}
+
+	ip := net.ParseIP(addr)
+	if ip4 := ip.To4(); ip4 != nil {
+		return ip4
+	}
+	return ip
+}
+
+// GetIpBlockedCounts returns the number of requests rejected by ip filtering so far, by reason
+func GetIpBlockedCounts() map[string]int64 {
+	counts := make(map[string]int64, len(ipBlockedCounts))
+	for reason, count := range ipBlockedCounts {
+		counts[reason] = count.Load()
+	}
+	return counts
+}
@@ -0,0 +1,104 @@
+package middlewares
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIpFilterMiddleware(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Security.TrustReverseProxyIps = "127.0.0.1,10.0.0.0/8"
+	cfg.Security.IngestionAllowIps = "192.168.178.0/24,2001:db8::/32"
+	cfg.Security.IngestionDenyIps = "192.168.178.66"
+	cfg.Security.ParseTrustReverseProxyIPs()
+	cfg.Security.ParseIngestionIPs()
+	config.Set(cfg)
+
+	sut := NewIpFilterMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	serve := func(remoteAddr, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/heartbeat", nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		rec := httptest.NewRecorder()
+		sut.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	before := GetIpBlockedCounts()
+
+	assert.Equal(t, http.StatusCreated, serve("192.168.178.10:1234", ""))
+	assert.Equal(t, http.StatusCreated, serve("[2001:db8::1]:1234", ""))
+	assert.Equal(t, http.StatusForbidden, serve("192.168.178.66:1234", ""))            // denylist takes precedence
+	assert.Equal(t, http.StatusForbidden, serve("203.0.113.5:1234", ""))               // not on allowlist
+	assert.Equal(t, http.StatusForbidden, serve("203.0.113.5:1234", "192.168.178.10")) // headers from untrusted origins are ignored
+
+	// behind trusted proxies
+	assert.Equal(t, http.StatusCreated, serve("127.0.0.1:1234", "192.168.178.10"))
+	assert.Equal(t, http.StatusCreated, serve("127.0.0.1:1234", "192.168.178.10, 10.0.0.2"))
+	assert.Equal(t, http.StatusForbidden, serve("127.0.0.1:1234", "192.168.178.10, 203.0.113.5")) // spoofed leftmost entry
+	assert.Equal(t, http.StatusForbidden, serve("127.0.0.1:1234", "192.168.178.66"))
+	assert.Equal(t, http.StatusForbidden, serve("127.0.0.1:1234", "garbage"))
+
+	after := GetIpBlockedCounts()
+	assert.Equal(t, int64(2), after[IpBlockedReasonDenied]-before[IpBlockedReasonDenied])
+	assert.Equal(t, int64(3), after[IpBlockedReasonNotAllowed]-before[IpBlockedReasonNotAllowed])
+	assert.Equal(t, int64(1), after[IpBlockedReasonUnknownOrigin]-before[IpBlockedReasonUnknownOrigin])
+}
+
+func TestIpFilterMiddleware_Disabled(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Security.ParseIngestionIPs()
+	config.Set(cfg)
+
+	sut := NewIpFilterMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/heartbeat", nil)
+	req.RemoteAddr = "203.0.113.5:1234"
+	rec := httptest.NewRecorder()
+	sut.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+}
+
+func TestGetClientIp(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Security.TrustReverseProxyIps = "127.0.0.1,::1,10.0.0.0/8"
+	cfg.Security.ParseTrustReverseProxyIPs()
+	config.Set(cfg)
+
+	clientIp := func(remoteAddr string, headers map[string]string) string {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		if ip := GetClientIp(req); ip != nil {
+			return ip.String()
+		}
+		return ""
+	}
+
+	assert.Equal(t, "203.0.113.5", clientIp("203.0.113.5:1234", nil))
+	assert.Equal(t, "2001:db8::1", clientIp("[2001:db8::1]:1234", nil))
+	assert.Equal(t, "203.0.113.5", clientIp("[::ffff:203.0.113.5]:1234", nil))
+	assert.Equal(t, "fe80::1", clientIp("[fe80::1%eth0]:1234", nil))
+	assert.Equal(t, "203.0.113.5", clientIp("203.0.113.5:1234", map[string]string{"X-Real-Ip": "198.51.100.1"}))
+
+	// behind trusted proxies
+	assert.Equal(t, "2001:db8::1", clientIp("[::1]:1234", map[string]string{"X-Forwarded-For": "2001:db8::1"}))
+	assert.Equal(t, "2001:db8::1", clientIp("[::1]:1234", map[string]string{"X-Forwarded-For": "[2001:db8::1]:4711, 10.0.0.2"}))
+	assert.Equal(t, "198.51.100.1", clientIp("127.0.0.1:1234", map[string]string{"X-Forwarded-For": "203.0.113.5, 198.51.100.1:80"}))
+	assert.Equal(t, "2001:db8:cafe::17", clientIp("127.0.0.1:1234", map[string]string{"Forwarded": `for=192.0.2.43, for="[2001:db8:cafe::17]:4711";proto=https`}))
+	assert.Equal(t, "198.51.100.1", clientIp("127.0.0.1:1234", map[string]string{"X-Real-Ip": "198.51.100.1"}))
+	assert.Equal(t, "", clientIp("127.0.0.1:1234", map[string]string{"X-Forwarded-For": "garbage"}))
+}
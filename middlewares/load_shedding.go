@@ -0,0 +1,81 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+// requests to these endpoints can't be served from cache and are refused while in degraded mode
+var expensiveEndpoints = []*regexp.Regexp{
+	regexp.MustCompile(`^/api/users/[^/]+/heartbeats/changes$`),
+	regexp.MustCompile(`^/api/users/[^/]+/summary\.pdf$`),
+	regexp.MustCompile(`^/api/(compat/wakatime/)?v1/users/[^/]+/(heartbeats|projects|user_agents)(/.*)?$`),
+	regexp.MustCompile(`^/api/(compat/wakatime/)?v1/leaders$`),
+	regexp.MustCompile(`^/api/activity/chart/`),
+	regexp.MustCompile(`^/projects$`),
+}
+
+// LoadSheddingMiddleware rejects requests to expensive endpoints while the application is in degraded mode and advises clients when to retry
+type LoadSheddingMiddleware struct {
+	handler          http.Handler
+	loadSheddingSrvc services.ILoadSheddingService
+}
+
+func NewLoadSheddingMiddleware(loadSheddingService services.ILoadSheddingService) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return &LoadSheddingMiddleware{
+			handler:          h,
+			loadSheddingSrvc: loadSheddingService,
+		}
+	}
+}
+
+func (m *LoadSheddingMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !m.loadSheddingSrvc.IsDegraded() {
+		m.handler.ServeHTTP(w, r)
+		return
+	}
+
+	if r.Method == http.MethodGet && isExpensiveEndpoint(r.URL.Path) {
+		m.loadSheddingSrvc.RecordShed(services.ShedReasonRejected, 1)
+		helpers.RespondErrorRetryAfter(w, r, http.StatusServiceUnavailable, models.ApiErrorOverloaded, services.ErrDegraded.Error(), m.loadSheddingSrvc.RetryAfter())
+		return
+	}
+
+	retryAfter := fmt.Sprintf("%d", int(m.loadSheddingSrvc.RetryAfter().Seconds()))
+
+	m.handler.ServeHTTP(&retryAfterWriter{ResponseWriter: w, retryAfter: retryAfter, onUnavailable: func() {
+		m.loadSheddingSrvc.RecordShed(services.ShedReasonUnavailable, 1)
+	}}, r)
+}
+
+func isExpensiveEndpoint(path string) bool {
+	for _, re := range expensiveEndpoints {
+		if re.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// retryAfterWriter adds a retry-after header to responses, which indicate that a downstream handler couldn't serve the request due to high load
+type retryAfterWriter struct {
+	http.ResponseWriter
+	retryAfter    string
+	onUnavailable func()
+}
+
+func (w *retryAfterWriter) WriteHeader(status int) {
+	if status == http.StatusServiceUnavailable {
+		if w.Header().Get("Retry-After") == "" {
+			w.Header().Set("Retry-After", w.retryAfter)
+		}
+		w.onUnavailable()
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 type logFunc func(string, ...interface{})
@@ -51,18 +53,15 @@ func (lg *LoggingMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		"bytes", ww.BytesWritten(),
 		"addr", readUserIP(r),
 		"user", readUserID(r),
+		"requestID", middleware.GetReqID(r.Context()),
 	)
 }
 
 func readUserIP(r *http.Request) string {
-	ip := r.Header.Get("X-Real-Ip")
-	if ip == "" {
-		ip = r.Header.Get("X-Forwarded-For")
-	}
-	if ip == "" {
-		ip = r.RemoteAddr
+	if ip := GetClientIp(r); ip != nil {
+		return ip.String()
 	}
-	return ip
+	return r.RemoteAddr
 }
 
 func readUserID(r *http.Request) string {
@@ -0,0 +1,37 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/gofrs/uuid/v5"
+)
+
+const HeaderRequestId = "X-Request-Id"
+
+// ids passed by reverse proxies are adopted, as long as they can't mess up logs
+var validRequestId = regexp.MustCompile(`^[a-zA-Z0-9._:-]{1,128}$`)
+
+// RequestIdMiddleware assigns a correlation id to every request, which is echoed back to the client and included in error responses and logs,
+// so that users can refer to a particular failed request when reporting problems
+type RequestIdMiddleware struct {
+	handler http.Handler
+}
+
+func NewRequestIdMiddleware() func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return &RequestIdMiddleware{handler: h}
+	}
+}
+
+func (m *RequestIdMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestId := r.Header.Get(HeaderRequestId)
+	if !validRequestId.MatchString(requestId) {
+		requestId = uuid.Must(uuid.NewV4()).String()
+	}
+
+	w.Header().Set(HeaderRequestId, requestId)
+	m.handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), middleware.RequestIDKey, requestId))) // compatible with chi's own request id middleware
+}
@@ -0,0 +1,51 @@
+package middlewares
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestIdMiddleware(t *testing.T) {
+	var received string
+	sut := NewRequestIdMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = middleware.GetReqID(r.Context())
+		helpers.RespondErrorRetryAfter(w, r, http.StatusTooManyRequests, models.ApiErrorRateLimited, "too many requests", 30*time.Second+500*time.Millisecond)
+	}))
+
+	// generated
+	rec := httptest.NewRecorder()
+	sut.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/heartbeat", nil))
+	assert.NotEmpty(t, received)
+	assert.Equal(t, received, rec.Header().Get(HeaderRequestId))
+	assert.Equal(t, "31", rec.Header().Get("Retry-After"))
+
+	var apiErr models.ApiError
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&apiErr))
+	assert.Equal(t, models.ApiErrorRateLimited, apiErr.Code)
+	assert.Equal(t, received, apiErr.RequestId)
+	assert.Equal(t, 31, apiErr.RetryAfter)
+	assert.True(t, apiErr.Retryable)
+
+	// adopted from reverse proxy
+	req := httptest.NewRequest(http.MethodPost, "/api/heartbeat", nil)
+	req.Header.Set(HeaderRequestId, "abc-123")
+	rec = httptest.NewRecorder()
+	sut.ServeHTTP(rec, req)
+	assert.Equal(t, "abc-123", received)
+	assert.Equal(t, "abc-123", rec.Header().Get(HeaderRequestId))
+
+	// replaced if malformed
+	req.Header.Set(HeaderRequestId, "abc\n123")
+	rec = httptest.NewRecorder()
+	sut.ServeHTTP(rec, req)
+	assert.NotEqual(t, "abc\n123", received)
+	assert.Equal(t, received, rec.Header().Get(HeaderRequestId))
+}
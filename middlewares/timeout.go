@@ -0,0 +1,34 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// TimeoutMiddleware sets a deadline on the request's context, so that database queries bound to it are canceled in time
+// responding with 504 is up to the downstream handler, as it knows whether it can still serve a partial or cached result
+type TimeoutMiddleware struct {
+	handler http.Handler
+	timeout time.Duration
+}
+
+func NewTimeoutMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
+	return func(h http.Handler) http.Handler {
+		return &TimeoutMiddleware{
+			handler: h,
+			timeout: timeout,
+		}
+	}
+}
+
+func (m *TimeoutMiddleware) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if m.timeout <= 0 {
+		m.handler.ServeHTTP(w, r)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), m.timeout)
+	defer cancel()
+	m.handler.ServeHTTP(w, r.WithContext(ctx))
+}
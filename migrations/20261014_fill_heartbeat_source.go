@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"log/slog"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+)
+
+func init() {
+	const name = "20261014-fill_heartbeat_source"
+	f := migrationFunc{
+		name: name,
+		f: func(db *gorm.DB, cfg *config.Config) error {
+			if hasRun(name, db) {
+				return nil
+			}
+
+			// existing heartbeats got the column's default (plugin) assigned, but those with an origin were actually imported
+			result := db.
+				Model(&models.Heartbeat{}).
+				Where("origin is not null and origin <> ''").
+				Update("source", models.HeartbeatSourceImport)
+			if result.Error != nil {
+				return result.Error
+			}
+			slog.Info("labeled imported heartbeats", "count", result.RowsAffected)
+
+			setHasRun(name, db)
+			return nil
+		},
+	}
+
+	registerPostMigration(f)
+}
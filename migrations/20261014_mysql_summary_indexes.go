@@ -0,0 +1,39 @@
+package migrations
+
+import (
+	"log/slog"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+)
+
+// mysql and mariadb only get a single-column index on summary_items.summary_id (implicitly created for the foreign key)
+// a composite one, which also covers the item type, lets the join of summaries and their items be resolved from the index alone
+// postgres and sqlite don't benefit notably, so this is deliberately not part of the model definition
+func init() {
+	const name = "20261014-mysql_summary_indexes"
+	const indexName = "idx_summary_items_summary_type"
+
+	f := migrationFunc{
+		name: name,
+		f: func(db *gorm.DB, cfg *config.Config) error {
+			if !cfg.Db.IsMySQL() || hasRun(name, db) {
+				return nil
+			}
+
+			migrator := db.Migrator()
+			if !migrator.HasIndex(&models.SummaryItem{}, indexName) {
+				slog.Info("creating index on summary items, this may take a while")
+				if err := db.Exec("CREATE INDEX " + indexName + " ON summary_items (summary_id, type)").Error; err != nil {
+					return err
+				}
+			}
+
+			setHasRun(name, db)
+			return nil
+		},
+	}
+
+	registerPostMigration(f)
+}
@@ -0,0 +1,28 @@
+package migrations
+
+import (
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+)
+
+func init() {
+	const indexName = "idx_heartbeats_user_source"
+
+	registerVersionedMigration(&versionedMigration{
+		version:     "20261015-add_heartbeats_source_idx",
+		description: "index heartbeats by user and source to speed up excluding imported and backfilled data",
+		up: func(db *gorm.DB, cfg *config.Config) error {
+			if !db.DryRun && db.Migrator().HasIndex(&models.Heartbeat{}, indexName) {
+				return nil
+			}
+			return db.Exec("create index " + indexName + " on heartbeats (user_id, source)").Error
+		},
+		down: func(db *gorm.DB, cfg *config.Config) error {
+			if !db.DryRun && !db.Migrator().HasIndex(&models.Heartbeat{}, indexName) {
+				return nil
+			}
+			return db.Migrator().DropIndex(&models.Heartbeat{}, indexName)
+		},
+	})
+}
@@ -0,0 +1,50 @@
+package migrations
+
+import (
+	"log/slog"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/utils"
+	"gorm.io/gorm"
+)
+
+func init() {
+	const name = "20261015-seal_api_keys"
+	f := migrationFunc{
+		name: name,
+		f: func(db *gorm.DB, cfg *config.Config) error {
+			// not tracked via hasRun, because keys stored in plain text need to be sealed whenever (re-)configured to do so
+			store := cfg.Security.ApiKeyStore()
+			if store == nil {
+				return nil
+			}
+
+			var users []*models.User
+			if err := db.Where("api_key is not null and api_key <> ''").Find(&users).Error; err != nil {
+				return err
+			}
+
+			for _, u := range users {
+				sealed, err := store.Seal(u.ApiKey)
+				if err != nil {
+					return err
+				}
+				if err := db.Model(&models.User{}).Where("id = ?", u.ID).Updates(map[string]interface{}{
+					"api_key":        nil,
+					"api_key_prefix": utils.ApiKeyPrefix(u.ApiKey),
+					"api_key_sealed": sealed,
+				}).Error; err != nil {
+					return err
+				}
+			}
+
+			if len(users) > 0 {
+				slog.Info("sealed api keys stored in plain text", "count", len(users), "storage", cfg.Security.ApiKeyStorage)
+			}
+			return nil
+		},
+	}
+
+	registerPostMigration(f)
+}
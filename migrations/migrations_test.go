@@ -0,0 +1,67 @@
+package migrations
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDb(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// every connection would get an in-memory database of its own
+	sqlDb, _ := db.DB()
+	sqlDb.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.KeyStringValue{}, &models.Heartbeat{}, &models.Summary{}, &models.SummaryItem{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func findPostMigration(name string) *migrationFunc {
+	for i := range postMigrations {
+		if postMigrations[i].name == name {
+			return &postMigrations[i]
+		}
+	}
+	return nil
+}
+
+func TestMysqlSummaryIndexes(t *testing.T) {
+	const indexName = "idx_summary_items_summary_type"
+
+	m := findPostMigration("20261014-mysql_summary_indexes")
+	assert.NotNil(t, m)
+
+	t.Run("when not on mysql", func(t *testing.T) {
+		db := newTestDb(t)
+		cfg := config.Empty()
+		cfg.Db.Dialect = config.SQLDialectSqlite
+
+		assert.Nil(t, m.f(db, cfg))
+		assert.False(t, db.Migrator().HasIndex(&models.SummaryItem{}, indexName))
+		assert.False(t, hasRun(m.name, db))
+	})
+
+	t.Run("when on mysql", func(t *testing.T) {
+		// the statement itself is portable, so it can be run against sqlite nevertheless
+		db := newTestDb(t)
+		cfg := config.Empty()
+		cfg.Db.Dialect = config.SQLDialectMysql
+
+		assert.Nil(t, m.f(db, cfg))
+		assert.True(t, db.Migrator().HasIndex(&models.SummaryItem{}, indexName))
+		assert.True(t, hasRun(m.name, db))
+
+		assert.Nil(t, db.Migrator().DropIndex(&models.SummaryItem{}, indexName))
+		assert.Nil(t, m.f(db, cfg))
+		assert.False(t, db.Migrator().HasIndex(&models.SummaryItem{}, indexName)) // not run a second time
+	})
+}
@@ -0,0 +1,179 @@
+package migrations
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/duke-git/lancet/v2/condition"
+	"github.com/hackclub/hackatime/config"
+	"gorm.io/gorm"
+)
+
+const (
+	ReindexStatusExists  = "exists"
+	ReindexStatusPlanned = "planned" // dry run
+	ReindexStatusCreated = "created"
+	ReindexStatusFailed  = "failed"
+)
+
+// recommendedIndex is an index, which is (or will be) created by a migration, but can be built up front without locking its table
+type recommendedIndex struct {
+	name    string
+	table   string
+	columns string
+	query   string                        // representative query, whose plan is compared before and after building the index
+	applies func(cfg *config.Config) bool // nil if recommended for all dialects
+}
+
+// ReindexResult describes the outcome of building a recommended index
+type ReindexResult struct {
+	Index      string
+	Table      string
+	Status     string
+	Statement  string
+	PlanBefore string
+	PlanAfter  string // empty unless the index was created
+	UsesIndex  bool   // whether the representative query's plan uses the index after it was created
+	Took       time.Duration
+	Error      error
+}
+
+var recommendedIndexes = []*recommendedIndex{
+	{
+		name:    "idx_heartbeats_user_source",
+		table:   "heartbeats",
+		columns: "user_id, source",
+		query:   "SELECT id FROM heartbeats WHERE user_id = 'reindex' AND source = 'import'",
+	},
+	{
+		name:    "idx_summary_items_summary_type",
+		table:   "summary_items",
+		columns: "summary_id, type",
+		query:   "SELECT id FROM summary_items WHERE summary_id = 0 AND type = 0",
+		applies: func(cfg *config.Config) bool {
+			return cfg.Db.IsMySQL()
+		},
+	},
+}
+
+// Reindex builds all recommended indexes that don't exist yet without blocking writes to their tables, i.e. concurrently on postgres
+// and in-place on mysql, verifies them and compares the query plans before and after. Sqlite can only build indexes while locking the table.
+// The migrations creating the same indexes later on are no-ops then.
+func Reindex(db *gorm.DB, cfg *config.Config, dryRun bool) []*ReindexResult {
+	results := make([]*ReindexResult, 0, len(recommendedIndexes))
+
+	for _, idx := range recommendedIndexes {
+		if idx.applies != nil && !idx.applies(cfg) {
+			continue
+		}
+
+		result := &ReindexResult{Index: idx.name, Table: idx.table, Statement: createIndexStatement(idx, cfg)}
+		results = append(results, result)
+
+		if db.Migrator().HasIndex(idx.table, idx.name) {
+			result.Status = ReindexStatusExists
+			continue
+		}
+
+		result.PlanBefore, result.Error = explain(db, cfg, idx.query)
+		if dryRun || result.Error != nil {
+			result.Status = condition.TernaryOperator(result.Error == nil, ReindexStatusPlanned, ReindexStatusFailed)
+			continue
+		}
+
+		slog.Info("building index", "index", idx.name, "table", idx.table)
+		start := time.Now()
+		if err := buildIndex(db, cfg, idx, result.Statement); err != nil {
+			result.Status, result.Error = ReindexStatusFailed, err
+			continue
+		}
+		result.Took = time.Since(start)
+		result.Status = ReindexStatusCreated
+
+		if plan, err := explain(db, cfg, idx.query); err == nil {
+			result.PlanAfter, result.UsesIndex = plan, strings.Contains(plan, idx.name)
+		}
+	}
+
+	return results
+}
+
+func createIndexStatement(idx *recommendedIndex, cfg *config.Config) string {
+	switch {
+	case cfg.Db.IsPostgres():
+		return fmt.Sprintf("CREATE INDEX CONCURRENTLY %s ON %s (%s)", idx.name, idx.table, idx.columns)
+	case cfg.Db.IsMySQL():
+		return fmt.Sprintf("CREATE INDEX %s ON %s (%s) ALGORITHM=INPLACE LOCK=NONE", idx.name, idx.table, idx.columns)
+	default:
+		return fmt.Sprintf("CREATE INDEX %s ON %s (%s)", idx.name, idx.table, idx.columns)
+	}
+}
+
+// buildIndex runs the statement and verifies the resulting index, a failed concurrent build on postgres leaves an invalid index behind, which is dropped again
+func buildIndex(db *gorm.DB, cfg *config.Config, idx *recommendedIndex, statement string) error {
+	err := db.Exec(statement).Error
+
+	if cfg.Db.IsPostgres() {
+		var valid sql.NullBool
+		if err := db.Raw("SELECT i.indisvalid FROM pg_index i JOIN pg_class c ON c.oid = i.indexrelid WHERE c.relname = ?", idx.name).Scan(&valid).Error; err != nil {
+			return err
+		}
+		if valid.Valid && !valid.Bool {
+			if dropErr := db.Exec("DROP INDEX CONCURRENTLY IF EXISTS " + idx.name).Error; dropErr != nil {
+				slog.Warn("failed to drop invalid index", "index", idx.name, "error", dropErr)
+			}
+			return errors.Join(errors.New("index was built, but is invalid"), err)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if !db.Migrator().HasIndex(idx.table, idx.name) {
+		return errors.New("index not found after building it")
+	}
+	return nil
+}
+
+// explain returns the query plan of the query as text, one line per row of the dialect's explain output
+func explain(db *gorm.DB, cfg *config.Config, query string) (string, error) {
+	prefix := "EXPLAIN "
+	if cfg.Db.IsSQLite() {
+		prefix = "EXPLAIN QUERY PLAN "
+	}
+
+	rows, err := db.Raw(prefix + query).Rows()
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+
+	lines := make([]string, 0)
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return "", err
+		}
+		fields := make([]string, 0, len(values))
+		for _, v := range values {
+			if v.Valid && v.String != "" {
+				fields = append(fields, v.String)
+			}
+		}
+		lines = append(lines, strings.Join(fields, " "))
+	}
+	return strings.Join(lines, "\n"), rows.Err()
+}
@@ -0,0 +1,98 @@
+package migrations
+
+import (
+	"testing"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReindex(t *testing.T) {
+	const indexName = "idx_heartbeats_user_source"
+
+	db := newTestDb(t)
+	cfg := config.Empty()
+	cfg.Db.Dialect = config.SQLDialectSqlite
+
+	// dry run only plans the index, the mysql-only one is skipped
+	results := Reindex(db, cfg, true)
+	assert.Len(t, results, 1)
+	assert.Equal(t, indexName, results[0].Index)
+	assert.Equal(t, "heartbeats", results[0].Table)
+	assert.Equal(t, ReindexStatusPlanned, results[0].Status)
+	assert.Equal(t, "CREATE INDEX idx_heartbeats_user_source ON heartbeats (user_id, source)", results[0].Statement)
+	assert.NotEmpty(t, results[0].PlanBefore)
+	assert.Empty(t, results[0].PlanAfter)
+	assert.Nil(t, results[0].Error)
+	assert.False(t, db.Migrator().HasIndex(&models.Heartbeat{}, indexName))
+
+	results = Reindex(db, cfg, false)
+	assert.Len(t, results, 1)
+	assert.Equal(t, ReindexStatusCreated, results[0].Status)
+	assert.Nil(t, results[0].Error)
+	assert.True(t, results[0].UsesIndex)
+	assert.Contains(t, results[0].PlanAfter, indexName)
+	assert.NotContains(t, results[0].PlanBefore, indexName)
+	assert.True(t, db.Migrator().HasIndex(&models.Heartbeat{}, indexName))
+
+	results = Reindex(db, cfg, false)
+	assert.Len(t, results, 1)
+	assert.Equal(t, ReindexStatusExists, results[0].Status)
+	assert.Empty(t, results[0].PlanBefore)
+
+	// the versioned migration creating the same index is a no-op afterwards
+	m := findVersionedMigration("20261015-add_heartbeats_source_idx")
+	assert.NotNil(t, m)
+	assert.Nil(t, m.up(db, cfg))
+}
+
+func TestReindex_Failed(t *testing.T) {
+	db := newTestDb(t)
+	cfg := config.Empty()
+	cfg.Db.Dialect = config.SQLDialectSqlite
+
+	assert.Nil(t, db.Migrator().DropTable(&models.Heartbeat{}))
+
+	results := Reindex(db, cfg, false)
+	assert.Len(t, results, 1)
+	assert.Equal(t, ReindexStatusFailed, results[0].Status)
+	assert.Error(t, results[0].Error)
+}
+
+func TestCreateIndexStatement(t *testing.T) {
+	idx := &recommendedIndex{name: "idx_heartbeats_user_source", table: "heartbeats", columns: "user_id, source"}
+
+	cases := map[string]string{
+		config.SQLDialectPostgres: "CREATE INDEX CONCURRENTLY idx_heartbeats_user_source ON heartbeats (user_id, source)",
+		config.SQLDialectMysql:    "CREATE INDEX idx_heartbeats_user_source ON heartbeats (user_id, source) ALGORITHM=INPLACE LOCK=NONE",
+		config.SQLDialectSqlite:   "CREATE INDEX idx_heartbeats_user_source ON heartbeats (user_id, source)",
+	}
+
+	for dialect, expected := range cases {
+		cfg := config.Empty()
+		cfg.Db.Dialect = dialect
+		assert.Equal(t, expected, createIndexStatement(idx, cfg), dialect)
+	}
+}
+
+func TestRecommendedIndexes_Applies(t *testing.T) {
+	for _, dialect := range []string{config.SQLDialectMysql, config.SQLDialectPostgres, config.SQLDialectSqlite} {
+		cfg := config.Empty()
+		cfg.Db.Dialect = dialect
+
+		var applicable []string
+		for _, idx := range recommendedIndexes {
+			if idx.applies == nil || idx.applies(cfg) {
+				applicable = append(applicable, idx.name)
+			}
+		}
+
+		assert.Contains(t, applicable, "idx_heartbeats_user_source", dialect)
+		if dialect == config.SQLDialectMysql {
+			assert.Contains(t, applicable, "idx_summary_items_summary_type", dialect)
+		} else {
+			assert.NotContains(t, applicable, "idx_summary_items_summary_type", dialect)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const versionKeyPrefix = "schema_version_"
+
+var ErrMigrationIrreversible = errors.New("migration can't be reverted")
+
+// versionedMigration is an explicit, optionally reversible migration, which is tracked by its version, as opposed to the legacy
+// pre- and post-migrations, which are idempotent and checked for their preconditions upon every start
+type versionedMigration struct {
+	version     string // applied in lexical order, e.g. 20261015-add_heartbeats_source_idx
+	description string
+	up          func(db *gorm.DB, cfg *config.Config) error
+	down        func(db *gorm.DB, cfg *config.Config) error // nil if irreversible
+}
+
+type versionedMigrations []*versionedMigration
+
+var allVersionedMigrations versionedMigrations
+
+type MigrationStatus struct {
+	Version     string
+	Description string
+	Applied     bool
+	AppliedAt   string
+	Reversible  bool
+}
+
+// MigrationPlan describes a migration that was (or would have been, in case of a dry run) applied or reverted
+type MigrationPlan struct {
+	Version    string
+	Statements []string // only recorded for dry runs
+}
+
+func registerVersionedMigration(m *versionedMigration) {
+	allVersionedMigrations = append(allVersionedMigrations, m)
+}
+
+// Status lists all versioned migrations in the order they're applied
+func Status(db *gorm.DB) ([]*MigrationStatus, error) {
+	applied, err := getAppliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(allVersionedMigrations)
+	result := make([]*MigrationStatus, len(allVersionedMigrations))
+	for i, m := range allVersionedMigrations {
+		appliedAt, ok := applied[m.version]
+		result[i] = &MigrationStatus{
+			Version:     m.version,
+			Description: m.description,
+			Applied:     ok,
+			AppliedAt:   appliedAt,
+			Reversible:  m.down != nil,
+		}
+	}
+	return result, nil
+}
+
+// CountPending returns the number of versioned migrations not applied yet
+func CountPending(db *gorm.DB) (int, error) {
+	status, err := Status(db)
+	if err != nil {
+		return 0, err
+	}
+	var count int
+	for _, s := range status {
+		if !s.Applied {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Up applies all pending versioned migrations, each in a transaction of its own. For dry runs, the sql statements are only recorded, but not executed.
+func Up(db *gorm.DB, cfg *config.Config, dryRun bool) ([]*MigrationPlan, error) {
+	applied, err := getAppliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(allVersionedMigrations)
+	plans := make([]*MigrationPlan, 0)
+	for _, m := range allVersionedMigrations {
+		if _, ok := applied[m.version]; ok {
+			continue
+		}
+		slog.Info("applying migration", "version", m.version, "dryRun", dryRun)
+		plan, err := runVersioned(db, cfg, m.version, m.up, dryRun, setVersionApplied)
+		if err != nil {
+			return plans, fmt.Errorf("failed to apply migration %s: %w", m.version, err)
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+// Down reverts the given number of most recently applied versioned migrations
+func Down(db *gorm.DB, cfg *config.Config, steps int, dryRun bool) ([]*MigrationPlan, error) {
+	applied, err := getAppliedVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Sort(sort.Reverse(allVersionedMigrations))
+	defer sort.Sort(allVersionedMigrations)
+
+	plans := make([]*MigrationPlan, 0, steps)
+	for _, m := range allVersionedMigrations {
+		if len(plans) >= steps {
+			break
+		}
+		if _, ok := applied[m.version]; !ok {
+			continue
+		}
+		if m.down == nil {
+			return plans, fmt.Errorf("failed to revert migration %s: %w", m.version, ErrMigrationIrreversible)
+		}
+		slog.Info("reverting migration", "version", m.version, "dryRun", dryRun)
+		plan, err := runVersioned(db, cfg, m.version, m.down, dryRun, setVersionReverted)
+		if err != nil {
+			return plans, fmt.Errorf("failed to revert migration %s: %w", m.version, err)
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+func RunVersionedMigrations(db *gorm.DB, cfg *config.Config) {
+	if _, err := Up(db, cfg, false); err != nil {
+		config.Log().Fatal("migration failed", "error", err)
+	}
+}
+
+func runVersioned(db *gorm.DB, cfg *config.Config, version string, f func(*gorm.DB, *config.Config) error, dryRun bool, track func(*gorm.DB, string) error) (*MigrationPlan, error) {
+	plan := &MigrationPlan{Version: version}
+
+	if dryRun {
+		recorder := &statementRecorder{}
+		// in dry run mode, gorm only builds statements without running them, so migrations need to skip precondition checks, if db.DryRun is set
+		if err := f(db.Session(&gorm.Session{DryRun: true, Logger: recorder}), cfg); err != nil {
+			return nil, err
+		}
+		plan.Statements = recorder.statements
+		return plan, nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := f(tx, cfg); err != nil {
+			return err
+		}
+		return track(tx, version)
+	})
+	return plan, err
+}
+
+func getAppliedVersions(db *gorm.DB) (map[string]string, error) {
+	if !db.Migrator().HasTable(&models.KeyStringValue{}) {
+		return map[string]string{}, nil // fresh database
+	}
+
+	var rows []*models.KeyStringValue
+	if err := db.Where(utils.QuoteSql(db, "%s like ?", "key"), versionKeyPrefix+"%").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[string]string, len(rows))
+	for _, r := range rows {
+		applied[r.Key[len(versionKeyPrefix):]] = r.Value
+	}
+	return applied, nil
+}
+
+func setVersionApplied(db *gorm.DB, version string) error {
+	return db.Create(&models.KeyStringValue{
+		Key:   versionKeyPrefix + version,
+		Value: time.Now().Format(time.RFC3339),
+	}).Error
+}
+
+func setVersionReverted(db *gorm.DB, version string) error {
+	return db.Where(utils.QuoteSql(db, "%s = ?", "key"), versionKeyPrefix+version).Delete(&models.KeyStringValue{}).Error
+}
+
+func (m versionedMigrations) Len() int {
+	return len(m)
+}
+
+func (m versionedMigrations) Less(i, j int) bool {
+	return m[i].version < m[j].version
+}
+
+func (m versionedMigrations) Swap(i, j int) {
+	m[i], m[j] = m[j], m[i]
+}
+
+// statementRecorder is a gorm logger, which collects all statements of a dry run
+type statementRecorder struct {
+	statements []string
+}
+
+func (r *statementRecorder) LogMode(logger.LogLevel) logger.Interface {
+	return r
+}
+
+func (r *statementRecorder) Info(context.Context, string, ...interface{}) {}
+
+func (r *statementRecorder) Warn(context.Context, string, ...interface{}) {}
+
+func (r *statementRecorder) Error(context.Context, string, ...interface{}) {}
+
+func (r *statementRecorder) Trace(_ context.Context, _ time.Time, fc func() (string, int64), _ error) {
+	if sql, _ := fc(); sql != "" {
+		r.statements = append(r.statements, sql)
+	}
+}
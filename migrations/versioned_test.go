@@ -0,0 +1,179 @@
+package migrations
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+const testVersion = "20261015-add_heartbeats_source_idx"
+
+func findVersionedMigration(version string) *versionedMigration {
+	for _, m := range allVersionedMigrations {
+		if m.version == version {
+			return m
+		}
+	}
+	return nil
+}
+
+// withVersionedMigrations temporarily replaces all registered versioned migrations
+func withVersionedMigrations(t *testing.T, migrations ...*versionedMigration) {
+	registered := allVersionedMigrations
+	allVersionedMigrations = migrations
+	t.Cleanup(func() {
+		allVersionedMigrations = registered
+	})
+}
+
+func TestVersionedMigrations_UpDown(t *testing.T) {
+	const indexName = "idx_heartbeats_user_source"
+
+	m := findVersionedMigration(testVersion)
+	assert.NotNil(t, m)
+	withVersionedMigrations(t, m)
+
+	db := newTestDb(t)
+	cfg := config.Empty()
+
+	pending, err := CountPending(db)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, pending)
+
+	// dry run only records statements
+	plans, err := Up(db, cfg, true)
+	assert.Nil(t, err)
+	assert.Len(t, plans, 1)
+	assert.Equal(t, testVersion, plans[0].Version)
+	assert.Equal(t, []string{"create index " + indexName + " on heartbeats (user_id, source)"}, plans[0].Statements)
+	assert.False(t, db.Migrator().HasIndex(&models.Heartbeat{}, indexName))
+	pending, _ = CountPending(db)
+	assert.Equal(t, 1, pending)
+
+	plans, err = Up(db, cfg, false)
+	assert.Nil(t, err)
+	assert.Len(t, plans, 1)
+	assert.Empty(t, plans[0].Statements)
+	assert.True(t, db.Migrator().HasIndex(&models.Heartbeat{}, indexName))
+
+	status, err := Status(db)
+	assert.Nil(t, err)
+	assert.Len(t, status, 1)
+	assert.True(t, status[0].Applied)
+	assert.NotEmpty(t, status[0].AppliedAt)
+	assert.True(t, status[0].Reversible)
+	pending, _ = CountPending(db)
+	assert.Zero(t, pending)
+
+	// already applied migrations are skipped
+	plans, err = Up(db, cfg, false)
+	assert.Nil(t, err)
+	assert.Empty(t, plans)
+
+	plans, err = Down(db, cfg, 1, true)
+	assert.Nil(t, err)
+	assert.Len(t, plans, 1)
+	assert.Len(t, plans[0].Statements, 1)
+	assert.Contains(t, plans[0].Statements[0], indexName)
+	assert.True(t, db.Migrator().HasIndex(&models.Heartbeat{}, indexName))
+
+	plans, err = Down(db, cfg, 1, false)
+	assert.Nil(t, err)
+	assert.Len(t, plans, 1)
+	assert.False(t, db.Migrator().HasIndex(&models.Heartbeat{}, indexName))
+	pending, _ = CountPending(db)
+	assert.Equal(t, 1, pending)
+
+	// nothing left to revert
+	plans, err = Down(db, cfg, 1, false)
+	assert.Nil(t, err)
+	assert.Empty(t, plans)
+}
+
+func TestVersionedMigrations_Order(t *testing.T) {
+	var applied []string
+	track := func(version string) *versionedMigration {
+		return &versionedMigration{
+			version: version,
+			up: func(db *gorm.DB, cfg *config.Config) error {
+				applied = append(applied, version)
+				return nil
+			},
+			down: func(db *gorm.DB, cfg *config.Config) error {
+				applied = append(applied, "-"+version)
+				return nil
+			},
+		}
+	}
+	withVersionedMigrations(t, track("20261002-b"), track("20261001-a"), track("20261003-c"))
+
+	db := newTestDb(t)
+	cfg := config.Empty()
+
+	_, err := Up(db, cfg, false)
+	assert.Nil(t, err)
+	_, err = Down(db, cfg, 2, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"20261001-a", "20261002-b", "20261003-c", "-20261003-c", "-20261002-b"}, applied)
+
+	status, _ := Status(db)
+	assert.Equal(t, "20261001-a", status[0].Version)
+	assert.True(t, status[0].Applied)
+	assert.False(t, status[1].Applied)
+	assert.False(t, status[2].Applied)
+}
+
+func TestVersionedMigrations_Failing(t *testing.T) {
+	withVersionedMigrations(t, &versionedMigration{
+		version: "20261001-failing",
+		up: func(db *gorm.DB, cfg *config.Config) error {
+			if err := db.Create(&models.KeyStringValue{Key: "half_done", Value: "true"}).Error; err != nil {
+				return err
+			}
+			return errors.New("failed")
+		},
+	})
+
+	db := newTestDb(t)
+
+	_, err := Up(db, config.Empty(), false)
+	assert.ErrorContains(t, err, "20261001-failing")
+
+	// rolled back, including the version record
+	var count int64
+	db.Model(&models.KeyStringValue{}).Count(&count)
+	assert.Zero(t, count)
+	pending, _ := CountPending(db)
+	assert.Equal(t, 1, pending)
+}
+
+func TestVersionedMigrations_Irreversible(t *testing.T) {
+	noop := func(db *gorm.DB, cfg *config.Config) error { return nil }
+	withVersionedMigrations(t,
+		&versionedMigration{version: "20261001-irreversible", up: noop},
+		&versionedMigration{version: "20261002-reversible", up: noop, down: noop},
+	)
+
+	db := newTestDb(t)
+	cfg := config.Empty()
+
+	_, err := Up(db, cfg, false)
+	assert.Nil(t, err)
+
+	status, _ := Status(db)
+	assert.False(t, status[0].Reversible)
+	assert.True(t, status[1].Reversible)
+
+	// the reversible one is reverted before hitting the irreversible one
+	plans, err := Down(db, cfg, 2, false)
+	assert.ErrorIs(t, err, ErrMigrationIrreversible)
+	assert.Len(t, plans, 1)
+	assert.Equal(t, "20261002-reversible", plans[0].Version)
+
+	pending, _ := CountPending(db)
+	assert.Equal(t, 1, pending)
+}
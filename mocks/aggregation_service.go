@@ -0,0 +1,38 @@
+package mocks
+
+import (
+	"time"
+
+	datastructure "github.com/duke-git/lancet/v2/datastructure/set"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type AggregationServiceMock struct {
+	mock.Mock
+}
+
+func (m *AggregationServiceMock) Schedule() {
+	m.Called()
+}
+
+func (m *AggregationServiceMock) AggregateSummaries(set datastructure.Set[string]) error {
+	args := m.Called(set)
+	return args.Error(0)
+}
+
+func (m *AggregationServiceMock) Resummarize(user *models.User, from, to time.Time) (*models.ResummarizeJob, error) {
+	args := m.Called(user, from, to)
+	if job := args.Get(0); job != nil {
+		return job.(*models.ResummarizeJob), args.Error(1)
+	}
+	return nil, args.Error(1)
+}
+
+func (m *AggregationServiceMock) GetResummarizeJob(id string) (*models.ResummarizeJob, bool) {
+	args := m.Called(id)
+	if job := args.Get(0); job != nil {
+		return job.(*models.ResummarizeJob), args.Bool(1)
+	}
+	return nil, args.Bool(1)
+}
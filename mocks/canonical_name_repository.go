@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type CanonicalNameRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *CanonicalNameRepositoryMock) GetAll() ([]*models.CanonicalName, error) {
+	args := m.Called()
+	return args.Get(0).([]*models.CanonicalName), args.Error(1)
+}
+
+func (m *CanonicalNameRepositoryMock) GetById(id uint) (*models.CanonicalName, error) {
+	args := m.Called(id)
+	return args.Get(0).(*models.CanonicalName), args.Error(1)
+}
+
+func (m *CanonicalNameRepositoryMock) Insert(c *models.CanonicalName) (*models.CanonicalName, error) {
+	args := m.Called(c)
+	return args.Get(0).(*models.CanonicalName), args.Error(1)
+}
+
+func (m *CanonicalNameRepositoryMock) Delete(id uint) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
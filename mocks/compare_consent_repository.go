@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type CompareConsentRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *CompareConsentRepositoryMock) GetByUser(s string) ([]*models.CompareConsent, error) {
+	args := m.Called(s)
+	return args.Get(0).([]*models.CompareConsent), args.Error(1)
+}
+
+func (m *CompareConsentRepositoryMock) Exists(s1, s2 string) (bool, error) {
+	args := m.Called(s1, s2)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *CompareConsentRepositoryMock) Insert(c *models.CompareConsent) (*models.CompareConsent, error) {
+	args := m.Called(c)
+	return args.Get(0).(*models.CompareConsent), args.Error(1)
+}
+
+func (m *CompareConsentRepositoryMock) Delete(s1, s2 string) error {
+	args := m.Called(s1, s2)
+	return args.Error(0)
+}
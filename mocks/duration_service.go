@@ -15,3 +15,8 @@ func (m *DurationServiceMock) Get(time time.Time, time2 time.Time, user *models.
 	args := m.Called(time, time2, user, f)
 	return args.Get(0).(models.Durations), args.Error(1)
 }
+
+func (m *DurationServiceMock) GetOverlap(time time.Time, time2 time.Time, user *models.User) (*models.DurationOverlap, error) {
+	args := m.Called(time, time2, user)
+	return args.Get(0).(*models.DurationOverlap), args.Error(1)
+}
@@ -22,6 +22,21 @@ func (m *HeartbeatServiceMock) InsertBatch(h []*models.Heartbeat) error {
 	return args.Error(0)
 }
 
+func (m *HeartbeatServiceMock) Normalize(h *models.Heartbeat) *models.Heartbeat {
+	args := m.Called(h)
+	return args.Get(0).(*models.Heartbeat)
+}
+
+func (m *HeartbeatServiceMock) IsBlocked(u *models.User, h *models.Heartbeat) bool {
+	args := m.Called(u, h)
+	return args.Bool(0)
+}
+
+func (m *HeartbeatServiceMock) GetTruncatedCounts() map[string]int64 {
+	args := m.Called()
+	return args.Get(0).(map[string]int64)
+}
+
 func (m *HeartbeatServiceMock) Count(a bool) (int64, error) {
 	args := m.Called(a)
 	return int64(args.Int(0)), args.Error(1)
@@ -32,6 +47,11 @@ func (m *HeartbeatServiceMock) CountByUser(user *models.User) (int64, error) {
 	return args.Get(0).(int64), args.Error(0)
 }
 
+func (m *HeartbeatServiceMock) CountActiveDaysByUser(user *models.User, days [][]time.Time, f *models.Filters) (int64, error) {
+	args := m.Called(user, days, f)
+	return args.Get(0).(int64), args.Error(1)
+}
+
 func (m *HeartbeatServiceMock) CountByUsers(users []*models.User) ([]*models.CountByUser, error) {
 	args := m.Called(users)
 	return args.Get(0).([]*models.CountByUser), args.Error(0)
@@ -91,3 +111,49 @@ func (m *HeartbeatServiceMock) GetUserProjectStats(u *models.User, t, t2 time.Ti
 	args := m.Called(u, t, t2, p, b)
 	return args.Get(0).([]*models.ProjectStats), args.Error(1)
 }
+
+func (m *HeartbeatServiceMock) GetUserAgentStats(u *models.User) ([]*models.UserAgentStats, error) {
+	args := m.Called(u)
+	return args.Get(0).([]*models.UserAgentStats), args.Error(1)
+}
+
+func (m *HeartbeatServiceMock) GetMachineStats(u *models.User) ([]*models.MachineStats, error) {
+	args := m.Called(u)
+	return args.Get(0).([]*models.MachineStats), args.Error(1)
+}
+
+func (m *HeartbeatServiceMock) GetChangesSince(u *models.User, c *models.HeartbeatChangesCursor, l int) (*models.HeartbeatChanges, error) {
+	args := m.Called(u, c, l)
+	return args.Get(0).(*models.HeartbeatChanges), args.Error(1)
+}
+
+func (m *HeartbeatServiceMock) GetProjectIntervalByUser(user *models.User, project string) (*models.Interval, error) {
+	args := m.Called(user, project)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Interval), args.Error(1)
+}
+
+func (m *HeartbeatServiceMock) RenameProject(user *models.User, oldProject, newProject string, from, to time.Time) (int64, error) {
+	args := m.Called(user, oldProject, newProject, from, to)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *HeartbeatServiceMock) GetMachineIntervalByUser(user *models.User, machine string) (*models.Interval, error) {
+	args := m.Called(user, machine)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Interval), args.Error(1)
+}
+
+func (m *HeartbeatServiceMock) CopyMachineToUser(user, target *models.User, machine string, from, to time.Time) (int64, error) {
+	args := m.Called(user, target, machine, from, to)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *HeartbeatServiceMock) DeleteByUserAndMachineBefore(user *models.User, machine string, t time.Time) (int64, error) {
+	args := m.Called(user, machine, t)
+	return args.Get(0).(int64), args.Error(1)
+}
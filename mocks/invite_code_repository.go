@@ -0,0 +1,35 @@
+package mocks
+
+import (
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type InviteCodeRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *InviteCodeRepositoryMock) GetAll() ([]*models.InviteCode, error) {
+	args := m.Called()
+	return args.Get(0).([]*models.InviteCode), args.Error(1)
+}
+
+func (m *InviteCodeRepositoryMock) GetByCode(s string) (*models.InviteCode, error) {
+	args := m.Called(s)
+	return args.Get(0).(*models.InviteCode), args.Error(1)
+}
+
+func (m *InviteCodeRepositoryMock) Insert(c *models.InviteCode) (*models.InviteCode, error) {
+	args := m.Called(c)
+	return args.Get(0).(*models.InviteCode), args.Error(1)
+}
+
+func (m *InviteCodeRepositoryMock) IncrementUses(s string) error {
+	args := m.Called(s)
+	return args.Error(0)
+}
+
+func (m *InviteCodeRepositoryMock) Delete(s string) error {
+	args := m.Called(s)
+	return args.Error(0)
+}
@@ -0,0 +1,20 @@
+package mocks
+
+import (
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type LegalConsentRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *LegalConsentRepositoryMock) CountByUserAndVersion(s1, s2 string) (int64, error) {
+	args := m.Called(s1, s2)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *LegalConsentRepositoryMock) Insert(c *models.LegalConsent) error {
+	args := m.Called(c)
+	return args.Error(0)
+}
@@ -0,0 +1,76 @@
+package mocks
+
+import (
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MachineRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *MachineRepositoryMock) GetByUser(s string) ([]*models.Machine, error) {
+	args := m.Called(s)
+	return args.Get(0).([]*models.Machine), args.Error(1)
+}
+
+func (m *MachineRepositoryMock) GetByUserAndName(s string, s2 string) (*models.Machine, error) {
+	args := m.Called(s, s2)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Machine), args.Error(1)
+}
+
+func (m *MachineRepositoryMock) Insert(machine *models.Machine) (*models.Machine, error) {
+	args := m.Called(machine)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Machine), args.Error(1)
+}
+
+func (m *MachineRepositoryMock) Update(machine *models.Machine) (*models.Machine, error) {
+	args := m.Called(machine)
+	return args.Get(0).(*models.Machine), args.Error(1)
+}
+
+func (m *MachineRepositoryMock) UpdateCountry(machine *models.Machine) (*models.Machine, error) {
+	args := m.Called(machine)
+	return args.Get(0).(*models.Machine), args.Error(1)
+}
+
+func (m *MachineRepositoryMock) ClearCountryByUser(s string) error {
+	args := m.Called(s)
+	return args.Error(0)
+}
+
+func (m *MachineRepositoryMock) Delete(u uint) error {
+	args := m.Called(u)
+	return args.Error(0)
+}
+
+func (m *MachineRepositoryMock) InsertQuarantined(heartbeats []*models.QuarantinedHeartbeat) error {
+	args := m.Called(heartbeats)
+	return args.Error(0)
+}
+
+func (m *MachineRepositoryMock) GetQuarantinedByUserAndMachine(s string, s2 string) ([]*models.QuarantinedHeartbeat, error) {
+	args := m.Called(s, s2)
+	return args.Get(0).([]*models.QuarantinedHeartbeat), args.Error(1)
+}
+
+func (m *MachineRepositoryMock) CountQuarantinedByUser(s string) (map[string]int64, error) {
+	args := m.Called(s)
+	return args.Get(0).(map[string]int64), args.Error(1)
+}
+
+func (m *MachineRepositoryMock) CountQuarantinedByUserAndMachine(s string, s2 string) (int64, error) {
+	args := m.Called(s, s2)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MachineRepositoryMock) DeleteQuarantinedByUserAndMachine(s string, s2 string) error {
+	args := m.Called(s, s2)
+	return args.Error(0)
+}
@@ -0,0 +1,82 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MailServiceMock struct {
+	mock.Mock
+}
+
+func (m *MailServiceMock) Check() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MailServiceMock) SendWelcome(u *models.User) error {
+	args := m.Called(u)
+	return args.Error(0)
+}
+
+func (m *MailServiceMock) SendPasswordReset(u *models.User, s string) error {
+	args := m.Called(u, s)
+	return args.Error(0)
+}
+
+func (m *MailServiceMock) SendWakatimeFailureNotification(u *models.User, i int) error {
+	args := m.Called(u, i)
+	return args.Error(0)
+}
+
+func (m *MailServiceMock) SendImportNotification(u *models.User, d time.Duration, i int) error {
+	args := m.Called(u, d, i)
+	return args.Error(0)
+}
+
+func (m *MailServiceMock) SendReport(u *models.User, r *models.Report) error {
+	args := m.Called(u, r)
+	return args.Error(0)
+}
+
+func (m *MailServiceMock) SendSubscriptionNotification(u *models.User, b bool) error {
+	args := m.Called(u, b)
+	return args.Error(0)
+}
+
+func (m *MailServiceMock) SendMachineApprovalRequest(u *models.User, s string) error {
+	args := m.Called(u, s)
+	return args.Error(0)
+}
+
+func (m *MailServiceMock) SendApiKeyRevokedNotification(u *models.User, s string) error {
+	args := m.Called(u, s)
+	return args.Error(0)
+}
+
+func (m *MailServiceMock) SendExportNotification(u *models.User, s string) error {
+	args := m.Called(u, s)
+	return args.Error(0)
+}
+
+func (m *MailServiceMock) SendEmailVerification(u *models.User, s string) error {
+	args := m.Called(u, s)
+	return args.Error(0)
+}
+
+func (m *MailServiceMock) SendInactivityAlert(u *models.User, t time.Time) error {
+	args := m.Called(u, t)
+	return args.Error(0)
+}
+
+func (m *MailServiceMock) SendLanguageGoalsReport(u *models.User, p []*models.LanguageGoalProgress) error {
+	args := m.Called(u, p)
+	return args.Error(0)
+}
+
+func (m *MailServiceMock) SendMentorSummary(mentor *models.Mentor, s *models.MentorSummary) error {
+	args := m.Called(mentor, s)
+	return args.Error(0)
+}
@@ -0,0 +1,40 @@
+package mocks
+
+import (
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MentorRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *MentorRepositoryMock) GetAll() ([]*models.Mentor, error) {
+	args := m.Called()
+	return args.Get(0).([]*models.Mentor), args.Error(1)
+}
+
+func (m *MentorRepositoryMock) GetByUser(s string) ([]*models.Mentor, error) {
+	args := m.Called(s)
+	return args.Get(0).([]*models.Mentor), args.Error(1)
+}
+
+func (m *MentorRepositoryMock) GetByToken(s string) (*models.Mentor, error) {
+	args := m.Called(s)
+	return args.Get(0).(*models.Mentor), args.Error(1)
+}
+
+func (m *MentorRepositoryMock) Insert(mentor *models.Mentor) (*models.Mentor, error) {
+	args := m.Called(mentor)
+	return args.Get(0).(*models.Mentor), args.Error(1)
+}
+
+func (m *MentorRepositoryMock) DeleteByUserAndId(s string, id uint) error {
+	args := m.Called(s, id)
+	return args.Error(0)
+}
+
+func (m *MentorRepositoryMock) DeleteByToken(s string) error {
+	args := m.Called(s)
+	return args.Error(0)
+}
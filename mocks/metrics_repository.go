@@ -0,0 +1,27 @@
+package mocks
+
+import (
+	"time"
+
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type MetricsRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *MetricsRepositoryMock) GetDatabaseSize() (int64, error) {
+	args := m.Called()
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MetricsRepositoryMock) MeasureLatency() (time.Duration, error) {
+	args := m.Called()
+	return args.Get(0).(time.Duration), args.Error(1)
+}
+
+func (m *MetricsRepositoryMock) GetStorageStatsByUser() ([]*models.UserStorageStats, error) {
+	args := m.Called()
+	return args.Get(0).([]*models.UserStorageStats), args.Error(1)
+}
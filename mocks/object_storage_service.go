@@ -0,0 +1,73 @@
+package mocks
+
+import (
+	"io"
+	"time"
+
+	"github.com/hackclub/hackatime/services/objects"
+	"github.com/stretchr/testify/mock"
+)
+
+type ObjectStorageServiceMock struct {
+	mock.Mock
+}
+
+func (m *ObjectStorageServiceMock) Schedule() {
+	m.Called()
+}
+
+func (m *ObjectStorageServiceMock) Store(key string, data []byte, contentType string) (string, error) {
+	args := m.Called(key, data, contentType)
+	return args.String(0), args.Error(1)
+}
+
+func (m *ObjectStorageServiceMock) SignedUrl(key string) (string, error) {
+	args := m.Called(key)
+	return args.String(0), args.Error(1)
+}
+
+func (m *ObjectStorageServiceMock) Retrieve(key, expires, signature string) ([]byte, error) {
+	args := m.Called(key, expires, signature)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *ObjectStorageServiceMock) SignedUploadUrl(key string, expiry time.Duration, maxSize int64) (*objects.UploadTarget, error) {
+	args := m.Called(key, expiry, maxSize)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*objects.UploadTarget), args.Error(1)
+}
+
+func (m *ObjectStorageServiceMock) Upload(key, expires, signature string, offset int64, data io.Reader, maxSize int64) (int64, error) {
+	args := m.Called(key, expires, signature, offset, data, maxSize)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *ObjectStorageServiceMock) CompleteUpload(key string) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *ObjectStorageServiceMock) Size(key string) (int64, error) {
+	args := m.Called(key)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *ObjectStorageServiceMock) Open(key string) (io.ReadCloser, error) {
+	args := m.Called(key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(io.ReadCloser), args.Error(1)
+}
+
+func (m *ObjectStorageServiceMock) Delete(key string) error {
+	args := m.Called(key)
+	return args.Error(0)
+}
+
+func (m *ObjectStorageServiceMock) DeleteExpired() (int, error) {
+	args := m.Called()
+	return args.Int(0), args.Error(1)
+}
@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type ProjectMetadataServiceMock struct {
+	mock.Mock
+}
+
+func (p *ProjectMetadataServiceMock) GetByUser(s string) ([]*models.ProjectMetadata, error) {
+	args := p.Called(s)
+	return args.Get(0).([]*models.ProjectMetadata), args.Error(1)
+}
+
+func (p *ProjectMetadataServiceMock) GetByUserMapped(s string) (map[string]*models.ProjectMetadata, error) {
+	args := p.Called(s)
+	return args.Get(0).(map[string]*models.ProjectMetadata), args.Error(1)
+}
+
+func (p *ProjectMetadataServiceMock) Upsert(m *models.ProjectMetadata) (*models.ProjectMetadata, error) {
+	args := p.Called(m)
+	return args.Get(0).(*models.ProjectMetadata), args.Error(1)
+}
+
+func (p *ProjectMetadataServiceMock) Delete(s string, s2 string) error {
+	args := p.Called(s, s2)
+	return args.Error(0)
+}
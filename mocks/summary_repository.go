@@ -1,9 +1,11 @@
 package mocks
 
 import (
+	"context"
 	"time"
 
 	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -11,6 +13,10 @@ type SummaryRepositoryMock struct {
 	mock.Mock
 }
 
+func (m *SummaryRepositoryMock) WithContext(ctx context.Context) repositories.ISummaryRepository {
+	return m
+}
+
 func (m *SummaryRepositoryMock) Insert(s *models.Summary) error {
 	args := m.Called(s)
 	return args.Error(0)
@@ -40,3 +46,8 @@ func (m *SummaryRepositoryMock) DeleteByUserBefore(s string, t time.Time) error
 	args := m.Called(s, t)
 	return args.Error(0)
 }
+
+func (m *SummaryRepositoryMock) DeleteByUserWithin(s string, t1, t2 time.Time) error {
+	args := m.Called(s, t1, t2)
+	return args.Error(0)
+}
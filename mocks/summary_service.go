@@ -42,7 +42,17 @@ func (m *SummaryServiceMock) DeleteByUserBefore(s string, t time.Time) error {
 	return args.Error(0)
 }
 
+func (m *SummaryServiceMock) DeleteByUserWithin(s string, t1, t2 time.Time) error {
+	args := m.Called(s, t1, t2)
+	return args.Error(0)
+}
+
 func (m *SummaryServiceMock) Insert(s *models.Summary) error {
 	args := m.Called(s)
 	return args.Error(0)
 }
+
+func (m *SummaryServiceMock) GetVersion(s string) int64 {
+	args := m.Called(s)
+	return args.Get(0).(int64)
+}
@@ -0,0 +1,30 @@
+package mocks
+
+import (
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/mock"
+)
+
+type TrackingPauseRepositoryMock struct {
+	mock.Mock
+}
+
+func (m *TrackingPauseRepositoryMock) GetByUser(s string) ([]*models.TrackingPause, error) {
+	args := m.Called(s)
+	return args.Get(0).([]*models.TrackingPause), args.Error(1)
+}
+
+func (m *TrackingPauseRepositoryMock) Insert(p *models.TrackingPause) (*models.TrackingPause, error) {
+	args := m.Called(p)
+	return args.Get(0).(*models.TrackingPause), args.Error(1)
+}
+
+func (m *TrackingPauseRepositoryMock) Update(p *models.TrackingPause) (*models.TrackingPause, error) {
+	args := m.Called(p)
+	return args.Get(0).(*models.TrackingPause), args.Error(1)
+}
+
+func (m *TrackingPauseRepositoryMock) DeleteByUserAndId(s string, id uint) error {
+	args := m.Called(s, id)
+	return args.Error(0)
+}
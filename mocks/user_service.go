@@ -29,6 +29,11 @@ func (m *UserServiceMock) GetUserByResetToken(s string) (*models.User, error) {
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *UserServiceMock) GetUserBySimpleToken(s string) (*models.User, error) {
+	args := m.Called(s)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
 func (m *UserServiceMock) GetAll() ([]*models.User, error) {
 	args := m.Called()
 	return args.Get(0).([]*models.User), args.Error(1)
@@ -79,11 +84,36 @@ func (m *UserServiceMock) CreateOrGet(signup *models.Signup, isAdmin bool) (*mod
 	return args.Get(0).(*models.User), args.Bool(1), args.Error(2)
 }
 
+func (m *UserServiceMock) CreateServiceAccount(username, name string) (*models.User, bool, error) {
+	args := m.Called(username, name)
+	return args.Get(0).(*models.User), args.Bool(1), args.Error(2)
+}
+
+func (m *UserServiceMock) CreateInvited(invite *models.Invite, invitedBy string) (*models.User, bool, error) {
+	args := m.Called(invite, invitedBy)
+	return args.Get(0).(*models.User), args.Bool(1), args.Error(2)
+}
+
+func (m *UserServiceMock) Suspend(user *models.User, reason string, admin *models.User) (*models.User, error) {
+	args := m.Called(user, reason, admin)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *UserServiceMock) Unsuspend(user *models.User, admin *models.User) (*models.User, error) {
+	args := m.Called(user, admin)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
 func (m *UserServiceMock) Update(user *models.User) (*models.User, error) {
 	args := m.Called(user)
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *UserServiceMock) UpdateField(user *models.User, key string, value interface{}) (*models.User, error) {
+	args := m.Called(user, key, value)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
 func (m *UserServiceMock) Delete(user *models.User) error {
 	args := m.Called(user)
 	return args.Error(0)
@@ -94,6 +124,11 @@ func (m *UserServiceMock) ResetApiKey(user *models.User) (*models.User, error) {
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *UserServiceMock) ResetSimpleToken(user *models.User) (*models.User, error) {
+	args := m.Called(user)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
 func (m *UserServiceMock) ToggleBadges(user *models.User) (*models.User, error) {
 	args := m.Called(user)
 	return args.Get(0).(*models.User), args.Error(1)
@@ -116,3 +151,23 @@ func (m *UserServiceMock) FlushCache() {
 func (m *UserServiceMock) FlushUserCache(s string) {
 	m.Called(s)
 }
+
+func (m *UserServiceMock) GetUserByRef(s string) (*models.User, error) {
+	args := m.Called(s)
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *UserServiceMock) GetExternalIds(user *models.User) ([]*models.UserExternalId, error) {
+	args := m.Called(user)
+	return args.Get(0).([]*models.UserExternalId), args.Error(1)
+}
+
+func (m *UserServiceMock) SetExternalId(user *models.User, provider, externalId string) (*models.UserExternalId, error) {
+	args := m.Called(user, provider, externalId)
+	return args.Get(0).(*models.UserExternalId), args.Error(1)
+}
+
+func (m *UserServiceMock) DeleteExternalId(user *models.User, provider string) (bool, error) {
+	args := m.Called(user, provider)
+	return args.Bool(0), args.Error(1)
+}
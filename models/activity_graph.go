@@ -0,0 +1,69 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const ActivityGraphMaxLevel = 4
+
+// ActivityGraph holds a user's coding time per day of one calendar year, bucketed into intensity levels, suitable for rendering a
+// GitHub-style contribution calendar. Graphs are precomputed daily, so days since the last update are missing.
+type ActivityGraph struct {
+	ID        uint              `json:"-" gorm:"primary_key"`
+	UserID    string            `json:"-" gorm:"not null; uniqueIndex:idx_activity_graph_user_year"`
+	User      *User             `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Year      int               `json:"year" gorm:"not null; uniqueIndex:idx_activity_graph_user_year"`
+	Days      ActivityGraphDays `json:"days" gorm:"type:text"`
+	UpdatedAt CustomTime        `json:"updated_at" gorm:"default:CURRENT_TIMESTAMP" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+}
+
+type ActivityGraphDay struct {
+	Date  string        `json:"date"` // yyyy-mm-dd in the user's time zone
+	Total time.Duration `json:"total" swaggertype:"primitive,integer"`
+	Level int           `json:"level"` // 0 (no activity) to ActivityGraphMaxLevel, relative to the year's busiest day
+}
+
+type ActivityGraphDays []*ActivityGraphDay
+
+// NewActivityGraphDays buckets the given daily totals into intensity levels by their share of the maximum
+func NewActivityGraphDays(dates []time.Time, totals []time.Duration) ActivityGraphDays {
+	var max time.Duration
+	for _, t := range totals {
+		if t > max {
+			max = t
+		}
+	}
+
+	days := make(ActivityGraphDays, len(dates))
+	for i, d := range dates {
+		day := &ActivityGraphDay{Date: d.Format(time.DateOnly), Total: totals[i]}
+		if totals[i] > 0 {
+			day.Level = int(math.Ceil(float64(totals[i]) / float64(max) * ActivityGraphMaxLevel))
+		}
+		days[i] = day
+	}
+	return days
+}
+
+func (d *ActivityGraphDays) Scan(value interface{}) error {
+	switch value.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(value.(string)), d)
+	case []byte:
+		return json.Unmarshal(value.([]byte), d)
+	default:
+		return errors.New(fmt.Sprintf("unsupported type: %T", value))
+	}
+}
+
+func (d ActivityGraphDays) Value() (driver.Value, error) {
+	data, err := json.Marshal(d)
+	return string(data), err
+}
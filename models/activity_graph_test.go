@@ -0,0 +1,24 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewActivityGraphDays(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	dates := []time.Time{from, from.AddDate(0, 0, 1), from.AddDate(0, 0, 2), from.AddDate(0, 0, 3)}
+	totals := []time.Duration{0, 1 * time.Minute, 4 * time.Hour, 8 * time.Hour}
+
+	days := NewActivityGraphDays(dates, totals)
+
+	assert.Len(t, days, 4)
+	assert.Equal(t, "2024-01-01", days[0].Date)
+	assert.Equal(t, 0, days[0].Level)
+	assert.Equal(t, 1, days[1].Level) // any activity at all counts
+	assert.Equal(t, 2, days[2].Level)
+	assert.Equal(t, ActivityGraphMaxLevel, days[3].Level)
+	assert.Equal(t, 8*time.Hour, days[3].Total)
+}
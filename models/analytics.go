@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+const (
+	AnalyticsMetricTotalSeconds = "total_seconds"
+	AnalyticsMetricHeartbeats   = "heartbeats"
+	AnalyticsMetricDays         = "days"
+	AnalyticsMetricLinesAdded   = "lines_added"
+	AnalyticsMetricLinesRemoved = "lines_removed"
+)
+
+const (
+	AnalyticsGrainNone  = "none"
+	AnalyticsGrainDay   = "day"
+	AnalyticsGrainWeek  = "week"
+	AnalyticsGrainMonth = "month"
+)
+
+// AnalyticsQuery describes a custom report in terms of what to group by (dimensions), what to compute (metrics) and which heartbeats to consider (filters).
+// Only whitelisted dimensions, metrics and filters are accepted, so a query can never be more than a constrained, parameterized repository query.
+type AnalyticsQuery struct {
+	From       string              `json:"from" example:"2024-01-01"`
+	To         string              `json:"to" example:"2024-02-01"`
+	Dimensions []string            `json:"dimensions" example:"project,language"`
+	Metrics    []string            `json:"metrics" example:"total_seconds,days"`
+	Filters    map[string][]string `json:"filters"`
+	Grain      string              `json:"grain" example:"week"`
+	Limit      int                 `json:"limit" example:"100"`
+}
+
+type AnalyticsResult struct {
+	From       time.Time       `json:"from"`
+	To         time.Time       `json:"to"`
+	Grain      string          `json:"grain"`
+	Dimensions []string        `json:"dimensions"`
+	Metrics    []string        `json:"metrics"`
+	Rows       []*AnalyticsRow `json:"rows"`
+	Truncated  bool            `json:"truncated"` // whether more rows than the limit matched the query
+}
+
+type AnalyticsRow struct {
+	Period     *time.Time        `json:"period,omitempty"` // beginning of the row's day, week or month, unless grain is none
+	Dimensions map[string]string `json:"dimensions"`
+	Metrics    map[string]int64  `json:"metrics"`
+}
+
+// AnalyticsMetrics returns all metrics an analytics query may compute
+func AnalyticsMetrics() []string {
+	return []string{AnalyticsMetricTotalSeconds, AnalyticsMetricHeartbeats, AnalyticsMetricDays, AnalyticsMetricLinesAdded, AnalyticsMetricLinesRemoved}
+}
+
+// AnalyticsDimensions returns all summary types an analytics query may group or filter by.
+// Labels only exist on summaries and entities are not retained in durations, so neither of them is included.
+func AnalyticsDimensions() []uint8 {
+	return []uint8{SummaryProject, SummaryLanguage, SummaryEditor, SummaryOS, SummaryMachine, SummaryBranch, SummaryCategory}
+}
@@ -0,0 +1,73 @@
+package models
+
+import "net/http"
+
+const (
+	ApiErrorBadRequest      = "bad_request"
+	ApiErrorUnauthorized    = "unauthorized"
+	ApiErrorForbidden       = "forbidden"
+	ApiErrorSuspended       = "account_suspended"
+	ApiErrorNotFound        = "not_found"
+	ApiErrorConflict        = "conflict"
+	ApiErrorTooLarge        = "payload_too_large"
+	ApiErrorRateLimited     = "rate_limited"
+	ApiErrorOverloaded      = "service_unavailable"
+	ApiErrorInternal        = "internal_error"
+	ApiErrorInvalidInput    = "invalid_input"
+	ApiErrorIdempotencyUsed = "idempotency_key_reused"
+)
+
+// ApiError is the uniform body of failed api requests, so that clients can decide whether (and when) to retry without parsing messages
+// retry_after is a hint in seconds, clients should still back off exponentially when it is absent, but the error is retryable
+type ApiError struct {
+	Error      string `json:"error"`
+	Code       string `json:"code"`
+	Retryable  bool   `json:"retryable"`
+	RetryAfter int    `json:"retry_after,omitempty"`
+	RequestId  string `json:"request_id,omitempty"`
+}
+
+func NewApiError(status int, code, message string) *ApiError {
+	return &ApiError{
+		Error:     message,
+		Code:      code,
+		Retryable: IsRetryableStatus(status),
+	}
+}
+
+// IsRetryableStatus tells whether a request that failed with the given status might succeed when sent again unmodified
+func IsRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusConflict, http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// ApiErrorCodeFor returns the generic error code for the given status, for errors which don't warrant a more specific one
+func ApiErrorCodeFor(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return ApiErrorBadRequest
+	case http.StatusUnauthorized:
+		return ApiErrorUnauthorized
+	case http.StatusForbidden:
+		return ApiErrorForbidden
+	case http.StatusNotFound:
+		return ApiErrorNotFound
+	case http.StatusConflict:
+		return ApiErrorConflict
+	case http.StatusRequestEntityTooLarge:
+		return ApiErrorTooLarge
+	case http.StatusTooManyRequests:
+		return ApiErrorRateLimited
+	case http.StatusServiceUnavailable:
+		return ApiErrorOverloaded
+	default:
+		if status >= 500 {
+			return ApiErrorInternal
+		}
+		return ApiErrorBadRequest
+	}
+}
@@ -0,0 +1,96 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	CanonicalNameTypeEditor = "editor"
+	CanonicalNameTypeOS     = "os"
+)
+
+// CanonicalName maps a spelling variant of an editor or operating system name, as reported by different plugins or plugin versions
+// (e.g. "vscode", "Code - Insiders"), to the one name it is stored as, so that breakdowns don't split across variants
+type CanonicalName struct {
+	ID        uint       `json:"id" gorm:"primary_key"`
+	Type      string     `json:"type" gorm:"not null; type:varchar(16); uniqueIndex:idx_canonical_name_type_variant"`
+	Variant   string     `json:"variant" gorm:"not null; type:varchar(191); uniqueIndex:idx_canonical_name_type_variant"`
+	Canonical string     `json:"canonical" gorm:"not null; type:varchar(255)"`
+	CreatedAt CustomTime `json:"created_at" gorm:"default:CURRENT_TIMESTAMP" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+}
+
+func (c *CanonicalName) IsValid() bool {
+	return (c.Type == CanonicalNameTypeEditor || c.Type == CanonicalNameTypeOS) &&
+		CanonicalNameKey(c.Variant) != "" &&
+		strings.TrimSpace(c.Canonical) != "" &&
+		utf8.RuneCountInString(c.Variant) <= 191 &&
+		utf8.RuneCountInString(c.Canonical) <= 255
+}
+
+// Column returns the heartbeat column holding values of the mapping's type
+func (c *CanonicalName) Column() string {
+	if c.Type == CanonicalNameTypeOS {
+		return "operating_system"
+	}
+	return "editor"
+}
+
+// CanonicalNameKey reduces a name to lower-case letters and digits, so that variants differing only in case, spaces or punctuation
+// (e.g. "VS Code", "vs-code" and "vscode") are matched by a single mapping
+func CanonicalNameKey(name string) string {
+	var sb strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return sb.String()
+}
+
+// CanonicalNames resolves names by type and key
+type CanonicalNames map[string]map[string]string
+
+func NewCanonicalNamesFrom(mappings []*CanonicalName) CanonicalNames {
+	names := CanonicalNames{
+		CanonicalNameTypeEditor: map[string]string{},
+		CanonicalNameTypeOS:     map[string]string{},
+	}
+	for _, m := range mappings {
+		if _, ok := names[m.Type]; !ok {
+			continue
+		}
+		names[m.Type][CanonicalNameKey(m.Variant)] = m.Canonical
+		if key := CanonicalNameKey(m.Canonical); key != "" {
+			if _, ok := names[m.Type][key]; !ok {
+				names[m.Type][key] = m.Canonical // differently spelled canonical names are variants themselves
+			}
+		}
+	}
+	return names
+}
+
+// Resolve returns the canonical name for the given one, or the name itself if no mapping applies
+func (c CanonicalNames) Resolve(nameType, name string) string {
+	if name == "" {
+		return name
+	}
+	if canonical, ok := c[nameType][CanonicalNameKey(name)]; ok {
+		return canonical
+	}
+	return name
+}
+
+func (c CanonicalNames) Apply(hb *Heartbeat) {
+	hb.Editor = c.Resolve(CanonicalNameTypeEditor, hb.Editor)
+	hb.OperatingSystem = c.Resolve(CanonicalNameTypeOS, hb.OperatingSystem)
+}
+
+// CanonicalizationResult tells how many heartbeats were rewritten when applying mappings retroactively, from when on each affected
+// user's summaries need to be re-generated and for how many users this is in progress
+type CanonicalizationResult struct {
+	Updated       int64                 `json:"updated"`
+	Resummarizing int                   `json:"resummarizing"`
+	AffectedSince map[string]CustomTime `json:"-"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCanonicalNameKey(t *testing.T) {
+	assert.Equal(t, "vscode", CanonicalNameKey("VS Code"))
+	assert.Equal(t, "vscode", CanonicalNameKey("vs-code"))
+	assert.Equal(t, "codeinsiders", CanonicalNameKey("Code - Insiders"))
+	assert.Equal(t, "", CanonicalNameKey(" - "))
+}
+
+func TestCanonicalNames_Resolve(t *testing.T) {
+	names := NewCanonicalNamesFrom([]*CanonicalName{
+		{Type: CanonicalNameTypeEditor, Variant: "Code - Insiders", Canonical: "vscode"},
+		{Type: CanonicalNameTypeEditor, Variant: "codium", Canonical: "vscode"},
+		{Type: CanonicalNameTypeOS, Variant: "darwin", Canonical: "Mac"},
+	})
+
+	assert.Equal(t, "vscode", names.Resolve(CanonicalNameTypeEditor, "code-insiders"))
+	assert.Equal(t, "vscode", names.Resolve(CanonicalNameTypeEditor, "VS Code")) // spelling variant of the canonical name itself
+	assert.Equal(t, "vscode", names.Resolve(CanonicalNameTypeEditor, "vscode"))
+	assert.Equal(t, "goland", names.Resolve(CanonicalNameTypeEditor, "goland"))
+	assert.Equal(t, "darwin", names.Resolve(CanonicalNameTypeEditor, "darwin")) // mappings only apply to their type
+	assert.Equal(t, "", names.Resolve(CanonicalNameTypeEditor, ""))
+
+	hb := &Heartbeat{Editor: "Codium", OperatingSystem: "Darwin"}
+	names.Apply(hb)
+	assert.Equal(t, "vscode", hb.Editor)
+	assert.Equal(t, "Mac", hb.OperatingSystem)
+}
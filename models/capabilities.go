@@ -0,0 +1,31 @@
+package models
+
+// Capabilities describes what an instance supports and how it is configured, so that plugins can adapt to it instead of assuming wakatime's behavior
+type Capabilities struct {
+	Version        string               `json:"version"`
+	ApiUrl         string               `json:"api_url"`
+	WakatimeCompat *CapabilitiesCompat  `json:"wakatime_compat"`
+	Limits         *CapabilitiesLimits  `json:"limits"`
+	Features       map[string]bool      `json:"features"`
+	Headers        *CapabilitiesHeaders `json:"headers"`
+}
+
+type CapabilitiesCompat struct {
+	BaseUrl   string   `json:"base_url"`  // to be configured as api_url in wakatime-cli
+	Endpoints []string `json:"endpoints"` // method and path pattern relative to the instance's api url, e.g. "GET /compat/wakatime/v1/users/{user}/stats/{range}"
+}
+
+type CapabilitiesLimits struct {
+	HeartbeatMaxBodyBytes     int64 `json:"heartbeat_max_body_bytes"`
+	HeartbeatBulkMaxBodyBytes int64 `json:"heartbeat_bulk_max_body_bytes"` // bulk requests are only limited by size, not by number of heartbeats
+	HeartbeatMaxAgeSec        int64 `json:"heartbeat_max_age_sec"`         // older heartbeats are rejected
+	HeartbeatMaxFutureSkewSec int64 `json:"heartbeat_max_future_skew_sec"` // heartbeats further in the future are rejected, unless clamped
+	HeartbeatClampFutureSkew  bool  `json:"heartbeat_clamp_future_skew"`   // whether heartbeats from the future are moved to the present instead
+	RequestTimeoutSec         int   `json:"request_timeout_sec"`
+}
+
+type CapabilitiesHeaders struct {
+	IdempotencyKey string `json:"idempotency_key"`
+	MachineName    string `json:"machine_name"`
+	RequestId      string `json:"request_id"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// CompareConsent states that a user agrees to compare their coding activity with another user, e.g. for a friendly rivalry within a club.
+// Two users can only be compared once both consented to each other.
+type CompareConsent struct {
+	ID        uint       `json:"-" gorm:"primary_key"`
+	User      *User      `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID    string     `json:"user_id" gorm:"not null; uniqueIndex:idx_compare_consent_user_other"`
+	Other     *User      `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	OtherID   string     `json:"other_id" gorm:"not null; uniqueIndex:idx_compare_consent_user_other; index:idx_compare_consent_other"`
+	CreatedAt CustomTime `json:"created_at" gorm:"default:CURRENT_TIMESTAMP" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+}
+
+// CompareConsents lists whom a user consented to compare with and who consented to compare with the user in turn
+type CompareConsents struct {
+	Given    []string `json:"given"`
+	Received []string `json:"received"`
+	Mutual   []string `json:"mutual"`
+}
+
+// UserComparison holds the summaries of two users side by side. Projects and machines are only included for users who publicly share them.
+type UserComparison struct {
+	From  time.Time       `json:"from"`
+	To    time.Time       `json:"to"`
+	Users []*ComparedUser `json:"users"`
+}
+
+type ComparedUser struct {
+	UserID  string          `json:"user_id"`
+	Summary *CompactSummary `json:"summary"`
+}
+
+func NewComparedUser(user *User, summary *Summary) *ComparedUser {
+	compact := NewCompactSummary(summary)
+	if !user.ShareProjects {
+		compact.Projects = []*CompactSummaryItem{}
+	}
+	if !user.ShareMachines {
+		compact.Machines = []*CompactSummaryItem{}
+	}
+	return &ComparedUser{UserID: user.ID, Summary: compact}
+}
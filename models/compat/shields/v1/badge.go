@@ -19,11 +19,11 @@ type BadgeData struct {
 	Color         string `json:"color"`
 }
 
-func NewBadgeDataFrom(summary *models.Summary) *BadgeData {
+func NewBadgeDataFrom(summary *models.Summary, user *models.User) *BadgeData {
 	return &BadgeData{
 		SchemaVersion: 1,
 		Label:         defaultLabel,
-		Message:       helpers.FmtWakatimeDuration(summary.TotalTime()),
+		Message:       helpers.FmtUserDuration(summary.TotalTime(), user),
 		Color:         defaultColor,
 	}
 }
@@ -28,13 +28,13 @@ type AllTimeRange struct {
 	Timezone  string `json:"timezone"`
 }
 
-func NewAllTimeFrom(summary *models.Summary) *AllTimeViewModel {
+func NewAllTimeFrom(summary *models.Summary, user *models.User) *AllTimeViewModel {
 	total := summary.TotalTime()
 	tzName, _ := summary.FromTime.T().Zone()
 	return &AllTimeViewModel{
 		Data: &AllTimeData{
 			TotalSeconds: float32(total.Seconds()),
-			Text:         helpers.FmtWakatimeDuration(total),
+			Text:         helpers.FmtUserDuration(total, user),
 			IsUpToDate:   true,
 			Range: &AllTimeRange{
 				End:       summary.ToTime.T().Format(time.RFC3339),
@@ -0,0 +1,81 @@
+package v1
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hackclub/hackatime/models"
+)
+
+// https://wakatime.com/developers#external_durations
+
+type ExternalDurationsViewModel struct {
+	Data     []*ExternalDurationEntry `json:"data"`
+	Start    string                   `json:"start"`
+	End      string                   `json:"end"`
+	Timezone string                   `json:"timezone"`
+}
+
+type ExternalDurationViewModel struct {
+	Data *ExternalDurationEntry `json:"data"`
+}
+
+// ExternalDurationEntry is a block of time measured by a third-party integration, e.g. a meeting tracker or design tool
+// the entry type (file, app, domain, ...) is accepted for compatibility, but not stored
+type ExternalDurationEntry struct {
+	Id         string  `json:"id"`
+	ExternalId string  `json:"external_id"`
+	Entity     string  `json:"entity"`
+	Type       string  `json:"type,omitempty"`
+	Category   string  `json:"category"`
+	StartTime  float64 `json:"start_time"`
+	EndTime    float64 `json:"end_time"`
+	Project    string  `json:"project"`
+	Branch     string  `json:"branch"`
+	Language   string  `json:"language"`
+}
+
+// NewExternalDurationEntry converts the given time entry, hiding its project name, entity and branch, if the user opted to
+func NewExternalDurationEntry(entry *models.TimeEntry, user *models.User) *ExternalDurationEntry {
+	e := &ExternalDurationEntry{
+		Id:         strconv.FormatUint(uint64(entry.ID), 10),
+		ExternalId: entry.ExternalID,
+		Entity:     entry.Note,
+		Category:   entry.Category,
+		StartTime:  float64(entry.FromTime.T().UnixMilli()) / 1000,
+		EndTime:    float64(entry.ToTime.T().UnixMilli()) / 1000,
+		Project:    entry.Project,
+		Branch:     entry.Branch,
+		Language:   entry.Language,
+	}
+	if user != nil && user.HideProjectNames {
+		e.Project = user.AnonymizedProjectName(entry.Project)
+		e.Entity, e.Branch = "", ""
+	}
+	return e
+}
+
+// ToTimeEntry converts the entry into a time entry of the given user, which is merged into summaries under its category ("external", if none given)
+func (e *ExternalDurationEntry) ToTimeEntry(userId string) *models.TimeEntry {
+	category := strings.TrimSpace(e.Category)
+	if category == "" {
+		category = models.TimeEntryDefaultExternalCategory
+	}
+	return &models.TimeEntry{
+		UserID:     userId,
+		Type:       models.TimeEntryTypeExternal,
+		ExternalID: strings.TrimSpace(e.ExternalId),
+		Project:    strings.TrimSpace(e.Project),
+		Branch:     strings.TrimSpace(e.Branch),
+		Language:   strings.TrimSpace(e.Language),
+		Category:   category,
+		Note:       strings.TrimSpace(e.Entity),
+		FromTime:   models.CustomTime(unixSecondsToTime(e.StartTime)),
+		ToTime:     models.CustomTime(unixSecondsToTime(e.EndTime)),
+	}
+}
+
+func unixSecondsToTime(t float64) time.Time {
+	return time.UnixMilli(int64(t * 1000))
+}
@@ -30,7 +30,8 @@ type HeartbeatEntry struct {
 	CreatedAt     time.Time `json:"created_at"`
 }
 
-func HeartbeatsToCompat(entries []*models.Heartbeat) []*HeartbeatEntry {
+// HeartbeatsToCompat converts the given heartbeats, hiding project names, file paths and branches, if the user opted to (user may be nil)
+func HeartbeatsToCompat(entries []*models.Heartbeat, user *models.User) []*HeartbeatEntry {
 	out := make([]*HeartbeatEntry, len(entries))
 	for i := 0; i < len(entries); i++ {
 		entry := entries[i]
@@ -49,6 +50,10 @@ func HeartbeatsToCompat(entries []*models.Heartbeat) []*HeartbeatEntry {
 			UserAgentId:   entry.UserAgent,
 			CreatedAt:     entry.CreatedAt.T(),
 		}
+		if user != nil && user.HideProjectNames {
+			out[i].Project = user.AnonymizedProjectName(entry.Project)
+			out[i].Entity, out[i].Branch = "", ""
+		}
 	}
 	return out
 }
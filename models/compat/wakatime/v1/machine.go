@@ -1,5 +1,11 @@
 package v1
 
+import (
+	"time"
+
+	"github.com/hackclub/hackatime/models"
+)
+
 // https://wakatime.com/api/v1/users/current/machine_names
 
 type MachineViewModel struct {
@@ -8,6 +14,20 @@ type MachineViewModel struct {
 }
 
 type MachineEntry struct {
-	Id    string `json:"id"`
-	Value string `json:"value"`
+	Id        string    `json:"id"`
+	Name      string    `json:"name"`
+	Value     string    `json:"value"`
+	LastSeen  time.Time `json:"last_seen_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewMachineEntry uses the machine's name as its id, because that is what heartbeats reference as machine_name_id
+func NewMachineEntry(stats *models.MachineStats) *MachineEntry {
+	return &MachineEntry{
+		Id:        stats.Machine,
+		Name:      stats.Machine,
+		Value:     stats.Machine,
+		LastSeen:  stats.Last.T(),
+		CreatedAt: stats.First.T(),
+	}
 }
@@ -17,4 +17,10 @@ type Project struct {
 	HumanReadableLastHeartbeatAt string    `json:"human_readable_last_heartbeat_at"`
 	UrlencodedName               string    `json:"urlencoded_name"`
 	CreatedAt                    time.Time `json:"created_at"`
+	Color                        string    `json:"color"`
+	Icon                         string    `json:"icon,omitempty"`                 // not part of wakatime api
+	Description                  string    `json:"description,omitempty"`          // not part of wakatime api
+	TopLanguage                  string    `json:"top_language,omitempty"`         // not part of wakatime api
+	TotalSeconds                 *float64  `json:"total_seconds,omitempty"`        // not part of wakatime api, only present if totals were requested
+	HumanReadableTotal           string    `json:"human_readable_total,omitempty"` // not part of wakatime api
 }
@@ -39,7 +39,7 @@ type StatsData struct {
 	Categories                []*SummariesEntry `json:"categories"`
 }
 
-func NewStatsFrom(summary *models.Summary, filters *models.Filters) *StatsViewModel {
+func NewStatsFrom(summary *models.Summary, filters *models.Filters, user *models.User) *StatsViewModel {
 	totalTime := summary.TotalTime()
 	numDays := int(summary.ToTime.T().Sub(summary.FromTime.T()).Hours() / 24)
 
@@ -51,12 +51,12 @@ func NewStatsFrom(summary *models.Summary, filters *models.Filters) *StatsViewMo
 		Status:                "ok",
 		TotalSeconds:          totalTime.Seconds(),
 		DaysIncludingHolidays: numDays,
-		HumanReadableTotal:    helpers.FmtWakatimeDuration(totalTime),
+		HumanReadableTotal:    helpers.FmtUserDuration(totalTime, user),
 	}
 
 	if numDays > 0 {
 		data.DailyAverage = totalTime.Seconds() / float64(numDays)
-		data.HumanReadableDailyAverage = helpers.FmtWakatimeDuration(totalTime / time.Duration(numDays))
+		data.HumanReadableDailyAverage = helpers.FmtUserDuration(totalTime/time.Duration(numDays), user)
 	}
 	if math.IsInf(data.DailyAverage, 0) || math.IsNaN(data.DailyAverage) {
 		data.DailyAverage = 0
@@ -64,37 +64,38 @@ func NewStatsFrom(summary *models.Summary, filters *models.Filters) *StatsViewMo
 
 	editors := make([]*SummariesEntry, len(summary.Editors))
 	for i, e := range summary.Editors {
-		editors[i] = convertEntry(e, summary.TotalTimeBy(models.SummaryEditor))
+		editors[i] = convertEntry(e, summary.TotalTimeBy(models.SummaryEditor), user)
 	}
 
 	languages := make([]*SummariesEntry, len(summary.Languages))
 	for i, e := range summary.Languages {
-		languages[i] = convertEntry(e, summary.TotalTimeBy(models.SummaryLanguage))
+		languages[i] = convertEntry(e, summary.TotalTimeBy(models.SummaryLanguage), user)
 	}
 
 	machines := make([]*SummariesEntry, len(summary.Machines))
 	for i, e := range summary.Machines {
-		machines[i] = convertEntry(e, summary.TotalTimeBy(models.SummaryMachine))
+		machines[i] = convertEntry(e, summary.TotalTimeBy(models.SummaryMachine), user)
 	}
 
 	projects := make([]*SummariesEntry, len(summary.Projects))
 	for i, e := range summary.Projects {
-		projects[i] = convertEntry(e, summary.TotalTimeBy(models.SummaryProject))
+		projects[i] = convertEntry(e, summary.TotalTimeBy(models.SummaryProject), user)
+		projects[i].Name = user.DisplayedProjectName(e.Key)
 	}
 
 	oss := make([]*SummariesEntry, len(summary.OperatingSystems))
 	for i, e := range summary.OperatingSystems {
-		oss[i] = convertEntry(e, summary.TotalTimeBy(models.SummaryOS))
+		oss[i] = convertEntry(e, summary.TotalTimeBy(models.SummaryOS), user)
 	}
 
 	branches := make([]*SummariesEntry, len(summary.Branches))
 	for i, e := range summary.Branches {
-		branches[i] = convertEntry(e, summary.TotalTimeBy(models.SummaryBranch))
+		branches[i] = convertEntry(e, summary.TotalTimeBy(models.SummaryBranch), user)
 	}
 
 	categories := make([]*SummariesEntry, len(summary.Categories))
 	for i, e := range summary.Categories {
-		categories[i] = convertEntry(e, summary.TotalTimeBy(models.SummaryCategory))
+		categories[i] = convertEntry(e, summary.TotalTimeBy(models.SummaryCategory), user)
 	}
 
 	// entities omitted intentionally
@@ -109,6 +110,8 @@ func NewStatsFrom(summary *models.Summary, filters *models.Filters) *StatsViewMo
 
 	if summary.Branches == nil {
 		data.Branches = nil
+	} else if user != nil && user.HideProjectNames {
+		data.Branches = make([]*SummariesEntry, 0) // likely to give away the project's name
 	}
 
 	return &StatsViewModel{
@@ -95,12 +95,12 @@ func (s *SummariesData) MarshalJSON() ([]byte, error) {
 	return json.Marshal((*alias)(s))
 }
 
-func NewSummariesFrom(summaries []*models.Summary) *SummariesViewModel {
+func NewSummariesFrom(summaries []*models.Summary, user *models.User) *SummariesViewModel {
 	data := make([]*SummariesData, len(summaries))
 	minDate, maxDate := time.Now().Add(1*time.Second), time.Time{}
 
 	for i, s := range summaries {
-		data[i] = newDataFrom(s)
+		data[i] = newDataFrom(s, user)
 
 		if s.FromTime.T().Before(minDate) {
 			minDate = s.FromTime.T()
@@ -131,7 +131,7 @@ func NewSummariesFrom(summaries []*models.Summary) *SummariesViewModel {
 			Decimal: fmt.Sprintf("%.2f", totalHrs),
 			Digital: fmt.Sprintf("%d:%d", int(totalHrs), int(totalMins)),
 			Seconds: totalSecs,
-			Text:    helpers.FmtWakatimeDuration(totalTime),
+			Text:    helpers.FmtUserDuration(totalTime, user),
 		},
 		DailyAverage: &SummariesDailyAverage{
 			DaysIncludingHolidays:         totalDays,
@@ -139,13 +139,13 @@ func NewSummariesFrom(summaries []*models.Summary) *SummariesViewModel {
 			Holidays:                      0, // not implemented, because we don't track user location
 			Seconds:                       totalSecsKnownAvg,
 			SecondsIncludingOtherLanguage: totalSecsAvg,
-			Text:                          helpers.FmtWakatimeDuration(totalTimeKnownAvg),
-			TextIncludingOtherLanguage:    helpers.FmtWakatimeDuration(totalTimeAvg),
+			Text:                          helpers.FmtUserDuration(totalTimeKnownAvg, user),
+			TextIncludingOtherLanguage:    helpers.FmtUserDuration(totalTimeAvg, user),
 		},
 	}
 }
 
-func newDataFrom(s *models.Summary) *SummariesData {
+func newDataFrom(s *models.Summary, user *models.User) *SummariesData {
 	zone, _ := time.Now().Zone()
 	total := s.TotalTime()
 	totalHrs, totalMins := int(total.Hours()), int((total - time.Duration(total.Hours())*time.Hour).Minutes())
@@ -164,7 +164,7 @@ func newDataFrom(s *models.Summary) *SummariesData {
 			Digital:      fmt.Sprintf("%d:%d", totalHrs, totalMins),
 			Hours:        totalHrs,
 			Minutes:      totalMins,
-			Text:         helpers.FmtWakatimeDuration(total),
+			Text:         helpers.FmtUserDuration(total, user),
 			TotalSeconds: total.Seconds(),
 		},
 		Range: &SummariesRange{
@@ -182,7 +182,8 @@ func newDataFrom(s *models.Summary) *SummariesData {
 	go utils.WithRecovery1[*SummariesData](func(data *SummariesData) {
 		defer wg.Done()
 		for i, e := range s.Projects {
-			data.Projects[i] = convertEntry(e, s.TotalTimeBy(models.SummaryProject))
+			data.Projects[i] = convertEntry(e, s.TotalTimeBy(models.SummaryProject), user)
+			data.Projects[i].Name = user.DisplayedProjectName(e.Key)
 		}
 	}, data)
 
@@ -190,7 +191,7 @@ func newDataFrom(s *models.Summary) *SummariesData {
 	go utils.WithRecovery1[*SummariesData](func(data *SummariesData) {
 		defer wg.Done()
 		for i, e := range s.Editors {
-			data.Editors[i] = convertEntry(e, s.TotalTimeBy(models.SummaryEditor))
+			data.Editors[i] = convertEntry(e, s.TotalTimeBy(models.SummaryEditor), user)
 		}
 	}, data)
 
@@ -198,7 +199,7 @@ func newDataFrom(s *models.Summary) *SummariesData {
 	go utils.WithRecovery1[*SummariesData](func(data *SummariesData) {
 		defer wg.Done()
 		for i, e := range s.Languages {
-			data.Languages[i] = convertEntry(e, s.TotalTimeBy(models.SummaryLanguage))
+			data.Languages[i] = convertEntry(e, s.TotalTimeBy(models.SummaryLanguage), user)
 		}
 	}, data)
 
@@ -206,7 +207,7 @@ func newDataFrom(s *models.Summary) *SummariesData {
 	go utils.WithRecovery1[*SummariesData](func(data *SummariesData) {
 		defer wg.Done()
 		for i, e := range s.OperatingSystems {
-			data.OperatingSystems[i] = convertEntry(e, s.TotalTimeBy(models.SummaryOS))
+			data.OperatingSystems[i] = convertEntry(e, s.TotalTimeBy(models.SummaryOS), user)
 		}
 	}, data)
 
@@ -214,7 +215,7 @@ func newDataFrom(s *models.Summary) *SummariesData {
 	go utils.WithRecovery1[*SummariesData](func(data *SummariesData) {
 		defer wg.Done()
 		for i, e := range s.Machines {
-			data.Machines[i] = convertEntry(e, s.TotalTimeBy(models.SummaryMachine))
+			data.Machines[i] = convertEntry(e, s.TotalTimeBy(models.SummaryMachine), user)
 		}
 	}, data)
 
@@ -222,7 +223,7 @@ func newDataFrom(s *models.Summary) *SummariesData {
 	go utils.WithRecovery1[*SummariesData](func(data *SummariesData) {
 		defer wg.Done()
 		for i, e := range s.Branches {
-			data.Branches[i] = convertEntry(e, s.TotalTimeBy(models.SummaryBranch))
+			data.Branches[i] = convertEntry(e, s.TotalTimeBy(models.SummaryBranch), user)
 		}
 	}, data)
 
@@ -230,7 +231,7 @@ func newDataFrom(s *models.Summary) *SummariesData {
 	go utils.WithRecovery1[*SummariesData](func(data *SummariesData) {
 		defer wg.Done()
 		for i, e := range s.Entities {
-			data.Entities[i] = convertEntry(e, s.TotalTimeBy(models.SummaryEntity))
+			data.Entities[i] = convertEntry(e, s.TotalTimeBy(models.SummaryEntity), user)
 		}
 	}, data)
 
@@ -238,22 +239,32 @@ func newDataFrom(s *models.Summary) *SummariesData {
 	go utils.WithRecovery1[*SummariesData](func(data *SummariesData) {
 		defer wg.Done()
 		for i, e := range s.Categories {
-			data.Categories[i] = convertEntry(e, s.TotalTimeBy(models.SummaryCategory))
+			data.Categories[i] = convertEntry(e, s.TotalTimeBy(models.SummaryCategory), user)
 		}
 	}, data)
 
+	wg.Wait()
+
 	if s.Branches == nil {
 		data.Branches = nil
 	}
 	if s.Entities == nil {
 		data.Entities = nil
 	}
+	if user != nil && user.HideProjectNames {
+		// branch names and file paths are likely to give away the project's name
+		if data.Branches != nil {
+			data.Branches = make([]*SummariesEntry, 0)
+		}
+		if data.Entities != nil {
+			data.Entities = make([]*SummariesEntry, 0)
+		}
+	}
 
-	wg.Wait()
 	return data
 }
 
-func convertEntry(e *models.SummaryItem, entityTotal time.Duration) *SummariesEntry {
+func convertEntry(e *models.SummaryItem, entityTotal time.Duration, user *models.User) *SummariesEntry {
 	total := e.TotalFixed()
 	hrs := int(total.Hours())
 	mins := int((total - time.Duration(hrs)*time.Hour).Minutes())
@@ -270,7 +281,7 @@ func convertEntry(e *models.SummaryItem, entityTotal time.Duration) *SummariesEn
 		Name:         e.Key,
 		Percent:      percentage,
 		Seconds:      secs,
-		Text:         helpers.FmtWakatimeDuration(total),
+		Text:         helpers.FmtUserDuration(total, user),
 		TotalSeconds: total.Seconds(),
 	}
 }
@@ -50,7 +50,8 @@ func NewFromUser(user *models.User) *User {
 
 	return &User{
 		ID:          user.ID,
-		DisplayName: user.ID,
+		DisplayName: user.DisplayName(),
+		FullName:    user.Name,
 		Email:       user.Email,
 		TimeZone:    tz,
 		Username:    user.ID,
@@ -60,9 +61,9 @@ func NewFromUser(user *models.User) *User {
 	}
 }
 
-func (u *User) WithLatestHeartbeat(h *models.Heartbeat) *User {
+func (u *User) WithLatestHeartbeat(h *models.Heartbeat, user *models.User) *User {
 	u.LastHeartbeatAt = h.Time
-	u.LastProject = h.Project
+	u.LastProject = user.DisplayedProjectName(h.Project)
 	u.LastPluginName = h.Editor
 	return u
 }
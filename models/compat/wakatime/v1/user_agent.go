@@ -1,13 +1,36 @@
 package v1
 
+import (
+	"crypto/md5"
+	"fmt"
+	"time"
+
+	"github.com/hackclub/hackatime/models"
+)
+
 type UserAgentsViewModel struct {
 	Data       []*UserAgentEntry `json:"data"`
 	TotalPages int               `json:"total_pages"`
 }
 
 type UserAgentEntry struct {
-	Id     string `json:"id"`
-	Editor string `json:"editor"`
-	Os     string `json:"os"`
-	Value  string `json:"value"`
+	Id        string    `json:"id"`
+	Editor    string    `json:"editor"`
+	Os        string    `json:"os"`
+	Value     string    `json:"value"`
+	FirstSeen time.Time `json:"first_seen_at"`
+	LastSeen  time.Time `json:"last_seen_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewUserAgentEntry(stats *models.UserAgentStats) *UserAgentEntry {
+	return &UserAgentEntry{
+		Id:        fmt.Sprintf("%x", md5.Sum([]byte(stats.UserAgent))),
+		Editor:    stats.Editor,
+		Os:        stats.OperatingSystem,
+		Value:     stats.UserAgent,
+		FirstSeen: stats.First.T(),
+		LastSeen:  stats.Last.T(),
+		CreatedAt: stats.First.T(),
+	}
 }
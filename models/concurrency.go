@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// ConcurrencySample is the number of distinct users who sent heartbeats within the default heartbeat timeout, taken once per minute
+type ConcurrencySample struct {
+	Time        CustomTime `json:"time" gorm:"primary_key; timeScale:0" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+	ActiveUsers int        `json:"active_users" gorm:"not null"`
+}
+
+// ConcurrencyHeatmap aggregates concurrency samples by weekday and hour to tell at which times of the week load peaks
+type ConcurrencyHeatmap struct {
+	From     time.Time          `json:"from"`
+	To       time.Time          `json:"to"`
+	Timezone string             `json:"timezone"`
+	Samples  int                `json:"samples"`
+	Average  float64            `json:"average"`
+	Peak     *ConcurrencySample `json:"peak"`
+	Max      [7][24]int         `json:"max"` // maximum concurrent users, indexed by weekday (0 = sunday) and hour of day
+}
+
+func NewConcurrencyHeatmapFrom(samples []*ConcurrencySample, from, to time.Time, tz *time.Location) *ConcurrencyHeatmap {
+	heatmap := &ConcurrencyHeatmap{
+		From:     from.In(tz),
+		To:       to.In(tz),
+		Timezone: tz.String(),
+		Samples:  len(samples),
+	}
+
+	var sum int
+	for _, s := range samples {
+		t := s.Time.T().In(tz)
+		cell := &heatmap.Max[t.Weekday()][t.Hour()]
+		*cell = max(*cell, s.ActiveUsers)
+		if heatmap.Peak == nil || s.ActiveUsers > heatmap.Peak.ActiveUsers {
+			heatmap.Peak = s
+		}
+		sum += s.ActiveUsers
+	}
+	if len(samples) > 0 {
+		heatmap.Average = float64(sum) / float64(len(samples))
+	}
+
+	return heatmap
+}
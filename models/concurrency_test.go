@@ -0,0 +1,30 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewConcurrencyHeatmapFrom(t *testing.T) {
+	tz, _ := time.LoadLocation("Europe/Berlin")
+	t0 := time.Date(2024, 3, 1, 22, 30, 0, 0, time.UTC) // friday, 23:30 in berlin
+	samples := []*ConcurrencySample{
+		{Time: CustomTime(t0), ActiveUsers: 4},
+		{Time: CustomTime(t0.Add(time.Minute)), ActiveUsers: 10},
+		{Time: CustomTime(t0.Add(time.Hour)), ActiveUsers: 1},
+	}
+
+	heatmap := NewConcurrencyHeatmapFrom(samples, t0, t0.Add(2*time.Hour), tz)
+	assert.Equal(t, 3, heatmap.Samples)
+	assert.Equal(t, 5.0, heatmap.Average)
+	assert.Equal(t, samples[1], heatmap.Peak)
+	assert.Equal(t, 10, heatmap.Max[time.Friday][23])
+	assert.Equal(t, 1, heatmap.Max[time.Saturday][0])
+	assert.Equal(t, 0, heatmap.Max[time.Friday][22])
+
+	empty := NewConcurrencyHeatmapFrom([]*ConcurrencySample{}, t0, t0, tz)
+	assert.Nil(t, empty.Peak)
+	assert.Zero(t, empty.Average)
+}
@@ -0,0 +1,20 @@
+package models
+
+const (
+	ConfigCheckOk      = "ok"
+	ConfigCheckFailed  = "failed"
+	ConfigCheckWarning = "warning" // not fatal, e.g. an optional upstream being unreachable
+	ConfigCheckSkipped = "skipped"
+)
+
+// ConfigCheck is the outcome of validating a part of the configuration against the actual environment, e.g. whether the database is reachable
+type ConfigCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+	Hint    string `json:"hint,omitempty"` // what to change in the config, if the check didn't pass
+}
+
+func (c *ConfigCheck) Failed() bool {
+	return c.Status == ConfigCheckFailed
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"errors"
+)
+
+const (
+	DevDataMaxUsers = 50
+	DevDataMaxDays  = 180
+)
+
+// DevDataParams configures the synthetic heartbeats generated for development and testing purposes
+type DevDataParams struct {
+	Users     int                `json:"users"`
+	Days      int                `json:"days"`
+	Languages map[string]float64 `json:"languages"` // relative weights, e.g. {"Go": 3, "Python": 1}
+	Seed      int64              `json:"seed"`      // random if zero
+}
+
+type DevDataResult struct {
+	Users      []string `json:"users"`
+	Heartbeats int      `json:"heartbeats"`
+}
+
+func (p *DevDataParams) WithDefaults() *DevDataParams {
+	if p.Users == 0 {
+		p.Users = 3
+	}
+	if p.Days == 0 {
+		p.Days = 14
+	}
+	if len(p.Languages) == 0 {
+		p.Languages = map[string]float64{"Go": 4, "TypeScript": 3, "Python": 2, "Markdown": 1}
+	}
+	return p
+}
+
+func (p *DevDataParams) Validate() error {
+	if p.Users < 1 || p.Users > DevDataMaxUsers {
+		return errors.New("users must be between 1 and 50")
+	}
+	if p.Days < 1 || p.Days > DevDataMaxDays {
+		return errors.New("days must be between 1 and 180")
+	}
+	for _, w := range p.Languages {
+		if w < 0 {
+			return errors.New("language weights must not be negative")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	DeviceUserCodeCharset = "BCDFGHJKLMNPQRSTVWXZ" // no vowels to not accidentally form words, no digits to avoid confusion, see rfc 8628
+	DeviceUserCodeLength  = 8
+)
+
+// DeviceAuthorization is a pending request of a plugin on a (headless) machine to obtain the api key of the user, who enters the user code on the website,
+// modeled after the oauth 2.0 device authorization grant (rfc 8628)
+type DeviceAuthorization struct {
+	DeviceCode   string
+	UserCode     string
+	ClientName   string
+	ExpiresAt    time.Time
+	Interval     time.Duration
+	LastPolledAt time.Time
+	UserID       string // set once approved
+	ApiKey       string // plain api key to hand out, set once approved
+	Denied       bool
+}
+
+func (a *DeviceAuthorization) IsExpired() bool {
+	return time.Now().After(a.ExpiresAt)
+}
+
+func (a *DeviceAuthorization) IsApproved() bool {
+	return a.UserID != ""
+}
+
+// FormattedUserCode splits the user code into two halves for better readability, e.g. BDWP-HQPK
+func (a *DeviceAuthorization) FormattedUserCode() string {
+	if len(a.UserCode) != DeviceUserCodeLength {
+		return a.UserCode
+	}
+	return a.UserCode[:DeviceUserCodeLength/2] + "-" + a.UserCode[DeviceUserCodeLength/2:]
+}
+
+// NormalizeDeviceUserCode makes user codes comparable regardless of case and separators as entered by the user
+func NormalizeDeviceUserCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+type DeviceCodeResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationUri         string `json:"verification_uri"`
+	VerificationUriComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"` // seconds
+	Interval                int    `json:"interval"`   // seconds
+}
+
+type DeviceTokenResponse struct {
+	ApiKey   string `json:"api_key"`
+	Username string `json:"username"`
+	ApiUrl   string `json:"api_url"`
+}
+
+// DeviceTokenError is returned while polling for the api key, error is one of authorization_pending, slow_down, access_denied, expired_token
+type DeviceTokenError struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
@@ -20,7 +20,10 @@ type Duration struct {
 	Category        string        `json:"category"`
 	Branch          string        `json:"branch"`
 	Entity          string        `json:"Entity"`
+	Source          string        `json:"source"`
+	Tags            Tags          `json:"tags"`
 	NumHeartbeats   int           `json:"-" hash:"ignore"`
+	EditStats       EditStats     `json:"-" hash:"ignore"`
 	GroupHash       string        `json:"-" hash:"ignore"`
 	excludeEntity   bool          `json:"-" hash:"ignore"`
 }
@@ -32,6 +35,7 @@ func (d *Duration) HashInclude(field string, v interface{}) (bool, error) {
 	if field == "Time" ||
 		field == "Duration" ||
 		field == "NumHeartbeats" ||
+		field == "EditStats" ||
 		field == "GroupHash" ||
 		unicode.IsLower(rune(field[0])) {
 		return false, nil
@@ -52,7 +56,10 @@ func NewDurationFromHeartbeat(h *Heartbeat) *Duration {
 		Category:        h.Category,
 		Branch:          h.Branch,
 		Entity:          h.Entity,
+		Source:          h.Source,
+		Tags:            h.Tags,
 		NumHeartbeats:   1,
+		EditStats:       NewEditStatsFromHeartbeat(h),
 	}
 	return d.Hashed()
 }
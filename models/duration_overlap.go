@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// DurationOverlap reports how much time several machines were active in parallel within a range and how much of it was collapsed by the user's overlap strategy
+type DurationOverlap struct {
+	From        time.Time         `json:"from"`
+	To          time.Time         `json:"to"`
+	Strategy    string            `json:"strategy"`
+	Total       time.Duration     `json:"total" swaggertype:"primitive,integer"`       // counted after resolving overlaps
+	Overlapping time.Duration     `json:"overlapping" swaggertype:"primitive,integer"` // during which at least two machines were active
+	Collapsed   time.Duration     `json:"collapsed" swaggertype:"primitive,integer"`   // sum of all machines' active time minus the counted total
+	Machines    []*MachineOverlap `json:"machines"`
+}
+
+// MachineOverlap compares the time a machine was active on its own with the time counted for it
+type MachineOverlap struct {
+	Machine string        `json:"machine"`
+	Active  time.Duration `json:"active" swaggertype:"primitive,integer"`
+	Counted time.Duration `json:"counted" swaggertype:"primitive,integer"`
+}
@@ -24,6 +24,14 @@ func (d Durations) TotalNumHeartbeats() int {
 	return total
 }
 
+func (d Durations) TotalEditStats() EditStats {
+	var total EditStats
+	for _, e := range d {
+		total = total.Add(e.EditStats)
+	}
+	return total
+}
+
 func (d Durations) Sorted() Durations {
 	sort.Sort(d)
 	return d
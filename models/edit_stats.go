@@ -0,0 +1,27 @@
+package models
+
+// EditStats aggregates the edit volume, which some plugins (e.g. language server based ones) report along with heartbeats
+type EditStats struct {
+	LinesAdded   int64 `json:"lines_added"`
+	LinesRemoved int64 `json:"lines_removed"`
+	NumWrites    int   `json:"num_writes"`
+}
+
+func NewEditStatsFromHeartbeat(h *Heartbeat) EditStats {
+	stats := EditStats{
+		LinesAdded:   int64(h.LineAdditions),
+		LinesRemoved: int64(h.LineDeletions),
+	}
+	if h.IsWrite {
+		stats.NumWrites = 1
+	}
+	return stats
+}
+
+func (s EditStats) Add(other EditStats) EditStats {
+	return EditStats{
+		LinesAdded:   s.LinesAdded + other.LinesAdded,
+		LinesRemoved: s.LinesRemoved + other.LinesRemoved,
+		NumWrites:    s.NumWrites + other.NumWrites,
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/mitchellh/hashstructure/v2"
 	"log/slog"
+	"strings"
 )
 
 type Filters struct {
@@ -16,7 +17,9 @@ type Filters struct {
 	Branch             OrFilter
 	Entity             OrFilter
 	Category           OrFilter
-	SelectFilteredOnly bool // flag indicating to drop all Entity types from a summary except the single one filtered by
+	Source             OrFilter // not a summary type, but the heartbeats' origin, e.g. to exclude imported data
+	Tag                OrFilter // not a summary type either, matches heartbeats carrying any of the given tags
+	SelectFilteredOnly bool     // flag indicating to drop all Entity types from a summary except the single one filtered by
 }
 
 type OrFilter []string
@@ -34,6 +37,19 @@ func (f OrFilter) MatchAny(search string) bool {
 	return false
 }
 
+// MatchAnyTag reports whether any of the given tags is included in the filter, or whether there are none, if filtering for "-"
+func (f OrFilter) MatchAnyTag(tags Tags) bool {
+	if len(tags) == 0 {
+		return f.MatchAny("")
+	}
+	for _, t := range tags {
+		if f.MatchAny(t) {
+			return true
+		}
+	}
+	return false
+}
+
 type FilterElement struct {
 	Entity uint8
 	Filter OrFilter
@@ -85,6 +101,21 @@ func (f *Filters) WithMultiple(entity uint8, keys []string) *Filters {
 	return f
 }
 
+func (f *Filters) WithSources(sources []string) *Filters {
+	f.Source = append(f.Source, sources...)
+	return f
+}
+
+func (f *Filters) WithTags(tags []string) *Filters {
+	for _, t := range tags {
+		if t = strings.TrimSpace(t); t != "-" {
+			t = NormalizeTag(t)
+		}
+		f.Tag = append(f.Tag, t)
+	}
+	return f
+}
+
 func (f *Filters) One() (bool, uint8, OrFilter) {
 	if f.Project != nil && f.Project.Exists() {
 		return true, SummaryProject, f.Project
@@ -117,7 +148,7 @@ func (f *Filters) OneOrEmpty() FilterElement {
 
 func (f *Filters) IsEmpty() bool {
 	nonEmpty, _, _ := f.One()
-	return !nonEmpty
+	return !nonEmpty && !f.Source.Exists() && !f.Tag.Exists()
 }
 
 func (f *Filters) Count() int {
@@ -191,7 +222,10 @@ func (f *Filters) MatchHeartbeat(h *Heartbeat) bool {
 		(f.Language == nil || f.Language.MatchAny(h.Language)) &&
 		(f.Editor == nil || f.Editor.MatchAny(h.Editor)) &&
 		(f.Machine == nil || f.Machine.MatchAny(h.Machine)) &&
-		(f.Category == nil || f.Machine.MatchAny(h.Category))
+		(f.Branch == nil || f.Branch.MatchAny(h.Branch)) &&
+		(f.Category == nil || f.Category.MatchAny(h.Category)) &&
+		(f.Source == nil || f.Source.MatchAny(h.Source)) &&
+		(f.Tag == nil || f.Tag.MatchAnyTag(h.Tags))
 }
 
 func (f *Filters) MatchDuration(d *Duration) bool {
@@ -200,7 +234,10 @@ func (f *Filters) MatchDuration(d *Duration) bool {
 		(f.Language == nil || f.Language.MatchAny(d.Language)) &&
 		(f.Editor == nil || f.Editor.MatchAny(d.Editor)) &&
 		(f.Machine == nil || f.Machine.MatchAny(d.Machine)) &&
-		(f.Category == nil || f.Category.MatchAny(d.Category))
+		(f.Branch == nil || f.Branch.MatchAny(d.Branch)) &&
+		(f.Category == nil || f.Category.MatchAny(d.Category)) &&
+		(f.Source == nil || f.Source.MatchAny(d.Source)) &&
+		(f.Tag == nil || f.Tag.MatchAnyTag(d.Tags))
 }
 
 // WithAliases adds OR-conditions for every alias of a Filter key as additional Filter keys
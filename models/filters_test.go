@@ -80,6 +80,8 @@ func TestFiltersTestSuite(t *testing.T) {
 func (suite *FiltersTestSuite) TestFilters_IsEmpty() {
 	assert.False(suite.T(), NewFiltersWith(SummaryProject, "wakapi").IsEmpty())
 	assert.True(suite.T(), (&Filters{}).IsEmpty())
+	assert.False(suite.T(), (&Filters{}).WithSources([]string{HeartbeatSourcePlugin}).IsEmpty())
+	assert.False(suite.T(), (&Filters{}).WithTags([]string{"client-a"}).IsEmpty())
 }
 
 func (suite *FiltersTestSuite) TestFilters_Match() {
@@ -105,6 +107,45 @@ func (suite *FiltersTestSuite) TestFilters_Match() {
 	assert.True(suite.T(), sut4.MatchHeartbeat(heartbeats[1]))
 }
 
+func (suite *FiltersTestSuite) TestFilters_Match_Branch() {
+	sut := NewFiltersWith(SummaryProject, "wakapi").WithMultiple(SummaryBranch, []string{"master", "dev"})
+	assert.True(suite.T(), sut.MatchHeartbeat(&Heartbeat{Project: "wakapi", Branch: "dev"}))
+	assert.False(suite.T(), sut.MatchHeartbeat(&Heartbeat{Project: "wakapi", Branch: "feature"}))
+	assert.True(suite.T(), sut.MatchDuration(&Duration{Project: "wakapi", Branch: "master"}))
+	assert.False(suite.T(), sut.MatchDuration(&Duration{Project: "anchr", Branch: "master"}))
+	assert.False(suite.T(), sut.MatchDuration(&Duration{Project: "wakapi", Branch: "feature"}))
+}
+
+func (suite *FiltersTestSuite) TestFilters_Match_Source() {
+	sut := (&Filters{}).WithSources([]string{HeartbeatSourcePlugin, HeartbeatSourceBackfill})
+	assert.True(suite.T(), sut.MatchHeartbeat(&Heartbeat{Source: HeartbeatSourcePlugin}))
+	assert.False(suite.T(), sut.MatchHeartbeat(&Heartbeat{Source: HeartbeatSourceImport}))
+	assert.True(suite.T(), sut.MatchDuration(&Duration{Source: HeartbeatSourceBackfill}))
+	assert.False(suite.T(), sut.MatchDuration(&Duration{Source: HeartbeatSourceSynthesized}))
+
+	// source doesn't count as a summary type
+	ok, _, _ := sut.One()
+	assert.False(suite.T(), ok)
+}
+
+func (suite *FiltersTestSuite) TestFilters_Match_Tag() {
+	sut1 := (&Filters{}).WithTags([]string{" Client-A", "oss"})
+	assert.Equal(suite.T(), OrFilter{"client-a", "oss"}, sut1.Tag)
+	assert.True(suite.T(), sut1.MatchHeartbeat(&Heartbeat{Tags: Tags{"client-a", "urgent"}}))
+	assert.False(suite.T(), sut1.MatchHeartbeat(&Heartbeat{Tags: Tags{"client-b"}}))
+	assert.False(suite.T(), sut1.MatchHeartbeat(&Heartbeat{}))
+	assert.True(suite.T(), sut1.MatchDuration(&Duration{Tags: Tags{"oss"}}))
+	assert.False(suite.T(), sut1.MatchDuration(&Duration{}))
+
+	sut2 := NewFiltersWith(SummaryProject, "wakapi").WithTags([]string{"-"})
+	assert.True(suite.T(), sut2.MatchDuration(&Duration{Project: "wakapi"}))
+	assert.False(suite.T(), sut2.MatchDuration(&Duration{Project: "wakapi", Tags: Tags{"oss"}}))
+
+	// tags don't count as a summary type
+	ok, _, _ := sut1.One()
+	assert.False(suite.T(), ok)
+}
+
 func (suite *FiltersTestSuite) TestFilters_One() {
 	sut1 := NewFiltersWith(SummaryLanguage, "Java")
 	ok1, type1, filters1 := sut1.One()
@@ -1,16 +1,60 @@
 package models
 
 import (
+	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"log/slog"
 
+	"github.com/duke-git/lancet/v2/condition"
+	"github.com/duke-git/lancet/v2/slice"
 	"github.com/duke-git/lancet/v2/strutil"
 	"github.com/mitchellh/hashstructure/v2"
+	"golang.org/x/text/unicode/norm"
 )
 
+var (
+	homeDirPattern          = regexp.MustCompile(`^(?:[A-Za-z]:)?/(?:Users|home)/[^/]+|^/root(?:/|$)`)
+	duplicateSlashesPattern = regexp.MustCompile(`/{2,}`)
+)
+
+const (
+	HeartbeatSourcePlugin      = "plugin"      // sent by an editor plugin (or any other client) while coding
+	HeartbeatSourceImport      = "import"      // imported from wakatime or another compatible service
+	HeartbeatSourceBackfill    = "backfill"    // sent in retrospect, as declared by the client
+	HeartbeatSourceSynthesized = "synthesized" // generated by the server, e.g. as sample data
+	HeartbeatSourceManual      = "manual"      // entered manually by the user as a time entry, see TimeEntry
+	HeartbeatSourceExternal    = "external"    // pushed by a third-party integration as an external duration, see TimeEntry
+)
+
+const (
+	HeartbeatFieldEntity  = "entity"
+	HeartbeatFieldProject = "project"
+	HeartbeatFieldBranch  = "branch"
+	HeartbeatFieldMachine = "machine"
+)
+
+// max. lengths (in characters) of free-text heartbeat fields, longer values are truncated at ingestion
+// indexed string columns are created as varchar(191) on mysql, see gorm's mysql dialector
+const (
+	HeartbeatMaxEntityLength  = 2048
+	HeartbeatMaxProjectLength = 191
+	HeartbeatMaxBranchLength  = 191
+	HeartbeatMaxMachineLength = 191
+)
+
+func HeartbeatNormalizedFields() []string {
+	return []string{HeartbeatFieldEntity, HeartbeatFieldProject, HeartbeatFieldBranch, HeartbeatFieldMachine}
+}
+
+func HeartbeatSources() []string {
+	return []string{HeartbeatSourcePlugin, HeartbeatSourceImport, HeartbeatSourceBackfill, HeartbeatSourceSynthesized, HeartbeatSourceManual, HeartbeatSourceExternal}
+}
+
 type Heartbeat struct {
 	ID               uint64     `gorm:"primary_key" hash:"ignore"`
 	User             *User      `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" hash:"ignore"`
@@ -20,6 +64,7 @@ type Heartbeat struct {
 	Category         string     `json:"category" gorm:"size:255"`
 	Project          string     `json:"project" gorm:"index:idx_user_project"`
 	ProjectRootCount uint64     `json:"project_root_count"`
+	AlternateProject string     `json:"alternate_project" gorm:"-" hash:"ignore"` // fallback project name sent by some clients, not persisted
 	Branch           string     `json:"branch" gorm:"index:idx_branch"`
 	Language         string     `json:"language"`
 	IsWrite          bool       `json:"is_write"`
@@ -34,6 +79,8 @@ type Heartbeat struct {
 	Hash             string     `json:"-" gorm:"type:varchar(17); uniqueIndex"`
 	Origin           string     `json:"-" hash:"ignore" gorm:"type:varchar(255)"`
 	OriginId         string     `json:"-" hash:"ignore" gorm:"type:varchar(255)"`
+	Source           string     `json:"source" hash:"ignore" gorm:"type:varchar(16); default:plugin"`
+	Tags             Tags       `json:"tags" hash:"ignore" gorm:"type:varchar(255)"`                                // sent by supporting clients or added by the user's tag rules, see TagRule
 	CreatedAt        CustomTime `json:"created_at" gorm:"timeScale:3" swaggertype:"primitive,number" hash:"ignore"` // https://gorm.io/docs/conventions.html#CreatedAt
 }
 
@@ -41,9 +88,43 @@ func (h *Heartbeat) Valid() bool {
 	return h.User != nil && h.UserID != "" && h.User.ID == h.UserID && h.Time != CustomTime(time.Time{})
 }
 
-func (h *Heartbeat) Timely(maxAge time.Duration) bool {
+// SanitizeSource falls back to the plugin source, unless the client declared a known one
+func (h *Heartbeat) SanitizeSource() *Heartbeat {
+	if !slice.Contain(HeartbeatSources(), h.Source) {
+		h.Source = HeartbeatSourcePlugin
+	}
+	return h
+}
+
+func (h *Heartbeat) Timely(maxAge, maxFutureSkew time.Duration) bool {
 	now := time.Now()
-	return now.Sub(h.Time.T()) <= maxAge && h.Time.T().Sub(now) < 1*time.Hour
+	return now.Sub(h.Time.T()) <= maxAge && !h.FromFuture(maxFutureSkew)
+}
+
+// FromFuture returns whether the heartbeat's timestamp lies further in the future than the given tolerance, usually due to a client's clock being off
+func (h *Heartbeat) FromFuture(maxFutureSkew time.Duration) bool {
+	return h.Time.T().Sub(time.Now()) > maxFutureSkew
+}
+
+// UnmarshalJSON additionally accepts line changes under the field names sent by some language server based plugins
+func (h *Heartbeat) UnmarshalJSON(b []byte) error {
+	type heartbeatAlias Heartbeat
+	aux := &struct {
+		*heartbeatAlias
+		LinesAdded   *uint32 `json:"lines_added"`
+		LinesRemoved *uint32 `json:"lines_removed"`
+	}{heartbeatAlias: (*heartbeatAlias)(h)}
+
+	if err := json.Unmarshal(b, aux); err != nil {
+		return err
+	}
+	if aux.LinesAdded != nil && h.LineAdditions == 0 {
+		h.LineAdditions = *aux.LinesAdded
+	}
+	if aux.LinesRemoved != nil && h.LineDeletions == 0 {
+		h.LineDeletions = *aux.LinesRemoved
+	}
+	return nil
 }
 
 func (h *Heartbeat) Sanitize() *Heartbeat {
@@ -62,6 +143,103 @@ func (h *Heartbeat) Sanitize() *Heartbeat {
 	return h
 }
 
+// NormalizeFields brings free-text fields into unicode nfc form, strips what databases refuse to store (invalid utf-8, null bytes) and truncates them to their max. length
+// entities keep their end, because the file name is the most relevant part of a path (and required for language mappings)
+// returns the names of all fields that had to be truncated
+func (h *Heartbeat) NormalizeFields() (truncated []string) {
+	var ok bool
+	if h.Entity, ok = normalizeField(h.Entity, HeartbeatMaxEntityLength, true); !ok {
+		truncated = append(truncated, HeartbeatFieldEntity)
+	}
+	if h.Project, ok = normalizeField(h.Project, HeartbeatMaxProjectLength, false); !ok {
+		truncated = append(truncated, HeartbeatFieldProject)
+	}
+	if h.Branch, ok = normalizeField(h.Branch, HeartbeatMaxBranchLength, false); !ok {
+		truncated = append(truncated, HeartbeatFieldBranch)
+	}
+	if h.Machine, ok = normalizeField(h.Machine, HeartbeatMaxMachineLength, false); !ok {
+		truncated = append(truncated, HeartbeatFieldMachine)
+	}
+	h.Tags = NewTags(h.Tags...)
+	return truncated
+}
+
+// NormalizeEntity rewrites file paths, so that stats of the same files merge across different machines
+// converting backslashes to forward slashes is implied by either of the other options
+func (h *Heartbeat) NormalizeEntity(unixSeparators, scrubHomeDir, projectRelative bool) *Heartbeat {
+	if h.Type != "file" || !(unixSeparators || scrubHomeDir || projectRelative) {
+		return h
+	}
+
+	entity := strings.ReplaceAll(h.Entity, "\\", "/")
+	entity = duplicateSlashesPattern.ReplaceAllString(entity, "/")
+
+	if projectRelative && h.Project != "" {
+		if i := strings.LastIndex(entity, "/"+h.Project+"/"); i >= 0 {
+			h.Entity = entity[i+len(h.Project)+2:]
+			return h
+		}
+	}
+
+	if scrubHomeDir {
+		entity = homeDirPattern.ReplaceAllStringFunc(entity, func(s string) string {
+			return condition.TernaryOperator[bool, string](strings.HasSuffix(s, "/"), "~/", "~")
+		})
+	}
+
+	h.Entity = entity
+	return h
+}
+
+// ProjectRoot returns the path of the project folder the heartbeat's file lies in, with forward slashes
+// it is derived from project_root_count, i.e. the number of slashes in the project folder path (including a trailing one) as sent by wakatime-cli
+// and from the location of a .git directory otherwise
+func (h *Heartbeat) ProjectRoot() string {
+	if h.Type != "file" || h.Entity == "" {
+		return ""
+	}
+
+	entity := strings.ReplaceAll(h.Entity, "\\", "/")
+	if h.ProjectRootCount > 0 {
+		var count uint64
+		for i, c := range entity {
+			if c != '/' {
+				continue
+			}
+			if count++; count == h.ProjectRootCount {
+				if i < len(entity)-1 {
+					return entity[:i]
+				}
+				break
+			}
+		}
+	}
+
+	if i := strings.Index(entity, "/.git/"); i > 0 {
+		return entity[:i]
+	}
+	return ""
+}
+
+// InferProject returns the name of the heartbeat's project folder, if known
+func (h *Heartbeat) InferProject() string {
+	root := h.ProjectRoot()
+	if root == "" {
+		return ""
+	}
+	return root[strings.LastIndex(root, "/")+1:]
+}
+
+// MatchesBlocklist reports whether the heartbeat's project or entity matches any of the given patterns
+func (h *Heartbeat) MatchesBlocklist(patterns []*regexp.Regexp) bool {
+	for _, p := range patterns {
+		if (h.Project != "" && p.MatchString(h.Project)) || (h.Entity != "" && p.MatchString(h.Entity)) {
+			return true
+		}
+	}
+	return false
+}
+
 func (h *Heartbeat) Augment(languageMappings map[string]string) {
 	maxPrec := -1 // precision / mapping complexity -> more concrete ones shall take precedence
 	for ending, value := range languageMappings {
@@ -141,5 +319,41 @@ func GetEntityColumn(t uint8) string {
 		"machine",
 		"label",
 		"branch",
+		"entity",
+		"category",
 	}[t]
 }
+
+// normalizeField returns the normalized value and whether it fit into max. length without being truncated
+func normalizeField(value string, maxLength int, keepEnd bool) (string, bool) {
+	if !utf8.ValidString(value) {
+		value = strings.ToValidUTF8(value, "")
+	}
+	value = norm.NFC.String(strings.ReplaceAll(value, "\x00", ""))
+
+	if utf8.RuneCountInString(value) <= maxLength {
+		return value, true
+	}
+
+	runes := []rune(value)
+	if keepEnd {
+		return string(runes[len(runes)-maxLength:]), false
+	}
+	return string(runes[:maxLength]), false
+}
+
+// CompileBlocklist compiles the given ingestion blocklist patterns, which are matched case-insensitively
+func CompileBlocklist(patterns []string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, p := range patterns {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		re, err := regexp.Compile("(?i)" + p)
+		if err != nil {
+			return nil, err
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
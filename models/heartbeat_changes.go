@@ -0,0 +1,70 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidChangesCursor = errors.New("invalid cursor")
+
+// HeartbeatDeletion is a tombstone recording that a user's heartbeats (before a certain time, or all of them, optionally only those from a single machine) were deleted,
+// so that mirrors following the changes feed can replay the deletion
+type HeartbeatDeletion struct {
+	ID        uint64      `json:"id" gorm:"primary_key"`
+	User      *User       `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE;"`
+	UserID    string      `json:"-" gorm:"not null; index:idx_heartbeat_deletion_user"`
+	Before    *CustomTime `json:"before" swaggertype:"primitive,number"`      // nil if all heartbeats were deleted
+	Machine   string      `json:"machine,omitempty" gorm:"type:varchar(255)"` // only heartbeats from this machine were deleted, if set
+	CreatedAt CustomTime  `json:"created_at" swaggertype:"primitive,number"`
+}
+
+// HeartbeatChanges is a page of the changes feed, heartbeats contained in it still exist, so clients should apply the deletions first
+type HeartbeatChanges struct {
+	Heartbeats []*Heartbeat
+	Deletions  []*HeartbeatDeletion
+	Cursor     *HeartbeatChangesCursor
+	HasMore    bool
+}
+
+// HeartbeatChangesCursor points to the latest heartbeat and deletion a client has seen
+// it is serialized as "<heartbeat id>.<deletion id>" and meant to be treated as opaque by clients
+type HeartbeatChangesCursor struct {
+	HeartbeatId uint64
+	DeletionId  uint64
+}
+
+func ParseHeartbeatChangesCursor(cursor string) (*HeartbeatChangesCursor, error) {
+	if cursor == "" {
+		return &HeartbeatChangesCursor{}, nil
+	}
+
+	parts := strings.Split(cursor, ".")
+	if len(parts) != 2 {
+		return nil, ErrInvalidChangesCursor
+	}
+	heartbeatId, err1 := strconv.ParseUint(parts[0], 10, 64)
+	deletionId, err2 := strconv.ParseUint(parts[1], 10, 64)
+	if err1 != nil || err2 != nil {
+		return nil, ErrInvalidChangesCursor
+	}
+
+	return &HeartbeatChangesCursor{HeartbeatId: heartbeatId, DeletionId: deletionId}, nil
+}
+
+func (c *HeartbeatChangesCursor) String() string {
+	return fmt.Sprintf("%d.%d", c.HeartbeatId, c.DeletionId)
+}
+
+// Advance moves the cursor past the given heartbeats and deletions, which are expected to be sorted by id
+func (c *HeartbeatChangesCursor) Advance(heartbeats []*Heartbeat, deletions []*HeartbeatDeletion) *HeartbeatChangesCursor {
+	next := *c
+	if len(heartbeats) > 0 {
+		next.HeartbeatId = heartbeats[len(heartbeats)-1].ID
+	}
+	if len(deletions) > 0 {
+		next.DeletionId = deletions[len(deletions)-1].ID
+	}
+	return &next
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHeartbeatChangesCursor(t *testing.T) {
+	cursor, err := ParseHeartbeatChangesCursor("")
+	assert.Nil(t, err)
+	assert.Equal(t, &HeartbeatChangesCursor{}, cursor)
+
+	cursor, err = ParseHeartbeatChangesCursor("42.7")
+	assert.Nil(t, err)
+	assert.Equal(t, &HeartbeatChangesCursor{HeartbeatId: 42, DeletionId: 7}, cursor)
+	assert.Equal(t, "42.7", cursor.String())
+
+	for _, c := range []string{"42", "42.", "a.b", "1.2.3", "-1.0"} {
+		_, err = ParseHeartbeatChangesCursor(c)
+		assert.ErrorIs(t, err, ErrInvalidChangesCursor, c)
+	}
+}
+
+func TestHeartbeatChangesCursor_Advance(t *testing.T) {
+	cursor := &HeartbeatChangesCursor{HeartbeatId: 10, DeletionId: 2}
+
+	next := cursor.Advance([]*Heartbeat{{ID: 11}, {ID: 15}}, nil)
+	assert.Equal(t, "15.2", next.String())
+	assert.Equal(t, "10.2", cursor.String())
+
+	next = next.Advance(nil, []*HeartbeatDeletion{{ID: 3}})
+	assert.Equal(t, "15.3", next.String())
+}
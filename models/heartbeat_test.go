@@ -1,6 +1,10 @@
 package models
 
 import (
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+
 	"github.com/stretchr/testify/assert"
 	"testing"
 	"time"
@@ -24,6 +28,17 @@ func TestHeartbeat_Valid_MissingUser(t *testing.T) {
 	assert.False(t, sut.Valid())
 }
 
+func TestHeartbeat_Timely(t *testing.T) {
+	maxAge, maxSkew := 24*time.Hour, 10*time.Minute
+
+	assert.True(t, (&Heartbeat{Time: CustomTime(time.Now().Add(-1 * time.Hour))}).Timely(maxAge, maxSkew))
+	assert.True(t, (&Heartbeat{Time: CustomTime(time.Now().Add(5 * time.Minute))}).Timely(maxAge, maxSkew))
+	assert.False(t, (&Heartbeat{Time: CustomTime(time.Now().Add(-25 * time.Hour))}).Timely(maxAge, maxSkew))
+	assert.False(t, (&Heartbeat{Time: CustomTime(time.Now().Add(15 * time.Minute))}).Timely(maxAge, maxSkew))
+	assert.True(t, (&Heartbeat{Time: CustomTime(time.Now().Add(15 * time.Minute))}).FromFuture(maxSkew))
+	assert.False(t, (&Heartbeat{Time: CustomTime(time.Now())}).FromFuture(maxSkew))
+}
+
 func TestHeartbeat_Augment(t *testing.T) {
 	testMappings := map[string]string{
 		"py":        "Python3",
@@ -104,3 +119,113 @@ func TestHeartbeat_Hashed_NoCollision(t *testing.T) {
 		hashes[sut.Hash] = true
 	}
 }
+
+func TestHeartbeat_NormalizeEntity(t *testing.T) {
+	testCases := []struct {
+		entity   string
+		project  string
+		opts     [3]bool
+		expected string
+	}{
+		{`C:\Users\john\dev\wakapi\main.go`, "wakapi", [3]bool{true, false, false}, "C:/Users/john/dev/wakapi/main.go"},
+		{`C:\Users\john\dev\wakapi\main.go`, "wakapi", [3]bool{false, true, false}, "~/dev/wakapi/main.go"},
+		{"/home/john//dev/wakapi/main.go", "wakapi", [3]bool{false, true, false}, "~/dev/wakapi/main.go"},
+		{"/Users/john/dev/wakapi/routes/api/heartbeat.go", "wakapi", [3]bool{false, false, true}, "routes/api/heartbeat.go"},
+		{"/root/wakapi.go", "", [3]bool{false, true, true}, "~/wakapi.go"},
+		{"/home/john/dev/wakapi/main.go", "wakapi", [3]bool{false, false, false}, "/home/john/dev/wakapi/main.go"},
+	}
+
+	for _, tc := range testCases {
+		sut := &Heartbeat{Type: "file", Entity: tc.entity, Project: tc.project}
+		sut.NormalizeEntity(tc.opts[0], tc.opts[1], tc.opts[2])
+		assert.Equal(t, tc.expected, sut.Entity)
+	}
+
+	sut := &Heartbeat{Type: "domain", Entity: "/home/john/wakapi.dev"}
+	sut.NormalizeEntity(true, true, true)
+	assert.Equal(t, "/home/john/wakapi.dev", sut.Entity)
+}
+
+func TestHeartbeat_UnmarshalJSON_LineChanges(t *testing.T) {
+	var sut Heartbeat
+	assert.Nil(t, json.Unmarshal([]byte(`{"entity":"main.go","time":1700000000,"is_write":true,"lines_added":12,"lines_removed":3}`), &sut))
+	assert.Equal(t, "main.go", sut.Entity)
+	assert.True(t, sut.IsWrite)
+	assert.Equal(t, uint32(12), sut.LineAdditions)
+	assert.Equal(t, uint32(3), sut.LineDeletions)
+	assert.Equal(t, int64(1700000000), sut.Time.T().Unix())
+
+	// wakatime's own field names take precedence
+	sut = Heartbeat{}
+	assert.Nil(t, json.Unmarshal([]byte(`{"line_additions":5,"lines_added":12}`), &sut))
+	assert.Equal(t, uint32(5), sut.LineAdditions)
+
+	var bulk []*Heartbeat
+	assert.Nil(t, json.Unmarshal([]byte(`[{"lines_removed":7},{"line_deletions":1}]`), &bulk))
+	assert.Equal(t, uint32(7), bulk[0].LineDeletions)
+	assert.Equal(t, uint32(1), bulk[1].LineDeletions)
+}
+
+func TestHeartbeat_JSONRoundTrip(t *testing.T) {
+	// e.g. for quarantined heartbeats, which are stored serialized
+	original := &Heartbeat{Entity: "main.go", Time: CustomTime(time.Date(2024, 3, 1, 12, 30, 15, 123000000, time.UTC))}
+	data, err := json.Marshal(original)
+	assert.Nil(t, err)
+
+	var sut Heartbeat
+	assert.Nil(t, json.Unmarshal(data, &sut))
+	assert.Equal(t, "main.go", sut.Entity)
+	assert.True(t, original.Time.T().Equal(sut.Time.T()))
+}
+
+func TestHeartbeat_SanitizeSource(t *testing.T) {
+	assert.Equal(t, HeartbeatSourcePlugin, (&Heartbeat{}).SanitizeSource().Source)
+	assert.Equal(t, HeartbeatSourcePlugin, (&Heartbeat{Source: "foo"}).SanitizeSource().Source)
+	assert.Equal(t, HeartbeatSourceBackfill, (&Heartbeat{Source: HeartbeatSourceBackfill}).SanitizeSource().Source)
+}
+
+func TestHeartbeat_NormalizeFields(t *testing.T) {
+	longPath := "/home/user/" + strings.Repeat("a/", HeartbeatMaxEntityLength) + "main.go"
+
+	sut := &Heartbeat{
+		Entity:  longPath,
+		Project: "cafe\u0301",                                     // decomposed é
+		Branch:  strings.Repeat("ü", HeartbeatMaxBranchLength+10), // multi-byte characters count as one
+		Machine: "work\x00station\xff",
+	}
+
+	truncated := sut.NormalizeFields()
+
+	assert.Equal(t, []string{HeartbeatFieldEntity, HeartbeatFieldBranch}, truncated)
+	assert.Equal(t, HeartbeatMaxEntityLength, utf8.RuneCountInString(sut.Entity))
+	assert.True(t, strings.HasSuffix(sut.Entity, "/main.go"))
+	assert.Equal(t, "caf\u00e9", sut.Project)
+	assert.Equal(t, strings.Repeat("ü", HeartbeatMaxBranchLength), sut.Branch)
+	assert.Equal(t, "workstation", sut.Machine)
+
+	assert.Empty(t, sut.NormalizeFields())
+}
+
+func TestHeartbeat_MatchesBlocklist(t *testing.T) {
+	patterns, err := CompileBlocklist([]string{"node_modules", "COMMIT_EDITMSG$", "^scratch$", " "})
+	assert.Nil(t, err)
+	assert.Len(t, patterns, 3)
+
+	assert.True(t, (&Heartbeat{Project: "wakapi", Entity: "/home/user/wakapi/node_modules/foo/index.js"}).MatchesBlocklist(patterns))
+	assert.True(t, (&Heartbeat{Project: "wakapi", Entity: "/home/user/wakapi/.git/commit_editmsg"}).MatchesBlocklist(patterns))
+	assert.True(t, (&Heartbeat{Project: "Scratch", Entity: "buffer"}).MatchesBlocklist(patterns))
+	assert.False(t, (&Heartbeat{Project: "scratchpad", Entity: "/home/user/scratchpad/main.go"}).MatchesBlocklist(patterns))
+	assert.False(t, (&Heartbeat{Project: "wakapi", Entity: "main.go"}).MatchesBlocklist(nil))
+
+	_, err = CompileBlocklist([]string{"("})
+	assert.NotNil(t, err)
+}
+
+func TestHeartbeat_InferProject(t *testing.T) {
+	assert.Equal(t, "wakapi", (&Heartbeat{Type: "file", Entity: "/home/user/wakapi/models/heartbeat.go", ProjectRootCount: 4}).InferProject())
+	assert.Equal(t, "wakapi", (&Heartbeat{Type: "file", Entity: "C:\\dev\\wakapi\\main.go", ProjectRootCount: 3}).InferProject())
+	assert.Equal(t, "wakapi", (&Heartbeat{Type: "file", Entity: "/home/user/wakapi/.git/COMMIT_EDITMSG"}).InferProject())
+	assert.Equal(t, "/home/user/wakapi", (&Heartbeat{Type: "file", Entity: "/home/user/wakapi/.git/COMMIT_EDITMSG", ProjectRootCount: 10}).ProjectRoot())
+	assert.Empty(t, (&Heartbeat{Type: "file", Entity: "/home/user/wakapi/main.go", ProjectRootCount: 5}).InferProject()) // root count exceeds the path depth
+	assert.Empty(t, (&Heartbeat{Type: "domain", Entity: "github.com", ProjectRootCount: 1}).InferProject())
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	ImportStatusAwaitingUpload = "awaiting_upload"
+	ImportStatusProcessing     = "processing"
+	ImportStatusFinished       = "finished"
+	ImportStatusFailed         = "failed"
+)
+
+// ImportJob tracks an import of an archive, which the user uploads to a pre-signed url first, instead of sending it to the api directly.
+// Once the upload was completed, the archive is processed in the background. Jobs are only kept in memory, so jobs are lost upon
+// a restart, while their uploaded archives are left to the expired objects cleanup.
+type ImportJob struct {
+	ID              string            `json:"id"`
+	UserID          string            `json:"user_id"`
+	Status          string            `json:"status"`
+	UploadUrl       string            `json:"upload_url,omitempty"` // only set while awaiting the upload
+	UploadMethod    string            `json:"upload_method,omitempty"`
+	UploadFields    map[string]string `json:"upload_fields,omitempty"` // form fields to send along with the archive as multipart request, if the method is post
+	UploadExpiresAt time.Time         `json:"upload_expires_at"`
+	UploadMaxBytes  int64             `json:"upload_max_bytes"`
+	HeartbeatsRead  int               `json:"heartbeats_read"`
+	HeartbeatsAdded int               `json:"heartbeats_added"`
+	CreatedAt       time.Time         `json:"created_at"`
+	StartedAt       *time.Time        `json:"started_at"`
+	FinishedAt      *time.Time        `json:"finished_at"`
+	Error           string            `json:"error,omitempty"`
+	ObjectKey       string            `json:"-"`
+	mutex           sync.RWMutex
+}
+
+// Snapshot returns a copy of the job's current state, which is safe to read while the job is still running
+func (j *ImportJob) Snapshot() *ImportJob {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return &ImportJob{
+		ID:              j.ID,
+		UserID:          j.UserID,
+		Status:          j.Status,
+		UploadUrl:       j.UploadUrl,
+		UploadMethod:    j.UploadMethod,
+		UploadFields:    j.UploadFields,
+		UploadExpiresAt: j.UploadExpiresAt,
+		UploadMaxBytes:  j.UploadMaxBytes,
+		HeartbeatsRead:  j.HeartbeatsRead,
+		HeartbeatsAdded: j.HeartbeatsAdded,
+		CreatedAt:       j.CreatedAt,
+		StartedAt:       j.StartedAt,
+		FinishedAt:      j.FinishedAt,
+		Error:           j.Error,
+		ObjectKey:       j.ObjectKey,
+	}
+}
+
+func (j *ImportJob) IsAwaitingUpload() bool {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.Status == ImportStatusAwaitingUpload && time.Now().Before(j.UploadExpiresAt)
+}
+
+func (j *ImportJob) IsActive() bool {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.Status == ImportStatusProcessing || (j.Status == ImportStatusAwaitingUpload && time.Now().Before(j.UploadExpiresAt))
+}
+
+// Start marks the upload as completed, so that the upload link can't be used for this job anymore
+func (j *ImportJob) Start() {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	now := time.Now()
+	j.StartedAt = &now
+	j.Status = ImportStatusProcessing
+	j.UploadUrl, j.UploadMethod, j.UploadFields = "", "", nil
+}
+
+// IsStarted returns whether the upload was completed, regardless of whether the job is still processing or already finished
+func (j *ImportJob) IsStarted() bool {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.StartedAt != nil
+}
+
+func (j *ImportJob) Advance(read int) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.HeartbeatsRead += read
+}
+
+// Finish records the number of heartbeats, which were actually added, i.e. weren't present before
+func (j *ImportJob) Finish(added int, err error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	now := time.Now()
+	j.FinishedAt = &now
+	j.HeartbeatsAdded = added
+	j.Status = ImportStatusFinished
+	if err != nil {
+		j.Status = ImportStatusFailed
+		j.Error = err.Error()
+	}
+}
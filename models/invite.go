@@ -0,0 +1,74 @@
+package models
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const MaxBulkInvites = 500
+
+// Invite is a single row of a bulk invite csv, i.e. a user to be created on behalf of an admin, e.g. for a whole classroom at once
+type Invite struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+}
+
+// ParseInviteCsv reads invites from csv with a header row, which names a "username" and / or an "email" column (in any order).
+// Usernames default to the local part of the e-mail address, if missing.
+func ParseInviteCsv(r io.Reader) ([]*Invite, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header row: %w", err)
+	}
+
+	usernameCol, emailCol := -1, -1
+	for i, h := range header {
+		switch strings.ToLower(strings.TrimSpace(h)) {
+		case "username":
+			usernameCol = i
+		case "email":
+			emailCol = i
+		}
+	}
+	if usernameCol < 0 && emailCol < 0 {
+		return nil, errors.New("header row must contain a 'username' or an 'email' column")
+	}
+
+	invites := make([]*Invite, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		invite := &Invite{}
+		if usernameCol >= 0 && usernameCol < len(record) {
+			invite.Username = strings.TrimSpace(record[usernameCol])
+		}
+		if emailCol >= 0 && emailCol < len(record) {
+			invite.Email = strings.TrimSpace(record[emailCol])
+		}
+		if invite.Username == "" && invite.Email == "" {
+			continue // blank line
+		}
+		if invite.Username == "" {
+			invite.Username = strings.SplitN(invite.Email, "@", 2)[0]
+		}
+
+		if len(invites) >= MaxBulkInvites {
+			return nil, fmt.Errorf("at most %d invites are allowed at once", MaxBulkInvites)
+		}
+		invites = append(invites, invite)
+	}
+	return invites, nil
+}
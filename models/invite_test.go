@@ -0,0 +1,28 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInviteCsv(t *testing.T) {
+	invites, err := ParseInviteCsv(strings.NewReader("Email,Username\nada@example.org,ada\n\ngrace@example.org,\n,linus\n"))
+	assert.Nil(t, err)
+	assert.Equal(t, []*Invite{
+		{Username: "ada", Email: "ada@example.org"},
+		{Username: "grace", Email: "grace@example.org"},
+		{Username: "linus"},
+	}, invites)
+
+	invites, err = ParseInviteCsv(strings.NewReader("username\nalan"))
+	assert.Nil(t, err)
+	assert.Equal(t, []*Invite{{Username: "alan"}}, invites)
+
+	_, err = ParseInviteCsv(strings.NewReader("name,mail\nada,ada@example.org"))
+	assert.NotNil(t, err)
+
+	_, err = ParseInviteCsv(strings.NewReader("username\n" + strings.Repeat("user\n", MaxBulkInvites+1)))
+	assert.NotNil(t, err)
+}
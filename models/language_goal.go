@@ -0,0 +1,58 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// LanguageGoal is a learning goal to spend at least the given share of every week's coding time in a certain language
+// optionally, only time of a certain activity category (e.g. "coding", but not "code reviewing") is taken into account
+type LanguageGoal struct {
+	ID             uint        `json:"id" gorm:"primary_key"`
+	User           *User       `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID         string      `json:"-" gorm:"not null; uniqueIndex:idx_language_goal_user_language"`
+	Language       string      `json:"language" gorm:"not null; type:varchar(191); uniqueIndex:idx_language_goal_user_language"`
+	MinPercent     float64     `json:"min_percent" gorm:"not null"`
+	Category       string      `json:"category" gorm:"type:varchar(255)"`
+	CreatedAt      CustomTime  `json:"created_at" gorm:"default:CURRENT_TIMESTAMP" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+	LastNotifiedAt *CustomTime `json:"-"` // when the user was last told about the outcome of a closed week
+}
+
+// LanguageGoalProgress is the share of coding time a goal's language took up within one week
+type LanguageGoalProgress struct {
+	Goal         *LanguageGoal `json:"goal"`
+	From         time.Time     `json:"from"`
+	To           time.Time     `json:"to"`
+	LanguageTime time.Duration `json:"language_time" swaggertype:"primitive,integer"`
+	TotalTime    time.Duration `json:"total_time" swaggertype:"primitive,integer"`
+	Percent      float64       `json:"percent"`
+	Reached      bool          `json:"reached"`
+}
+
+func (g *LanguageGoal) IsValid() bool {
+	return strings.TrimSpace(g.Language) != "" &&
+		utf8.RuneCountInString(g.Language) <= 191 &&
+		g.MinPercent > 0 && g.MinPercent <= 100 &&
+		utf8.RuneCountInString(g.Category) <= 255
+}
+
+// NewLanguageGoalProgress computes the goal's progress from the week's summary, which is expected to be filtered by the goal's category already
+func NewLanguageGoalProgress(goal *LanguageGoal, summary *Summary) *LanguageGoalProgress {
+	progress := &LanguageGoalProgress{
+		Goal:      goal,
+		From:      summary.FromTime.T(),
+		To:        summary.ToTime.T(),
+		TotalTime: summary.TotalTimeBy(SummaryLanguage),
+	}
+	for _, item := range summary.Languages {
+		if strings.EqualFold(item.Key, goal.Language) {
+			progress.LanguageTime += item.TotalFixed()
+		}
+	}
+	if progress.TotalTime > 0 {
+		progress.Percent = float64(progress.LanguageTime) / float64(progress.TotalTime) * 100
+	}
+	progress.Reached = progress.TotalTime > 0 && progress.Percent >= goal.MinPercent
+	return progress
+}
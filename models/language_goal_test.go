@@ -0,0 +1,38 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLanguageGoalProgress(t *testing.T) {
+	summary := &Summary{
+		Languages: []*SummaryItem{
+			{Type: SummaryLanguage, Key: "Rust", Total: 30 * 60},
+			{Type: SummaryLanguage, Key: "Go", Total: 70 * 60},
+		},
+	}
+
+	progress := NewLanguageGoalProgress(&LanguageGoal{Language: "rust", MinPercent: 30}, summary)
+	assert.Equal(t, 30*time.Minute, progress.LanguageTime)
+	assert.Equal(t, 100*time.Minute, progress.TotalTime)
+	assert.InDelta(t, 30, progress.Percent, 0.001)
+	assert.True(t, progress.Reached)
+
+	progress = NewLanguageGoalProgress(&LanguageGoal{Language: "Go", MinPercent: 75}, summary)
+	assert.False(t, progress.Reached)
+
+	progress = NewLanguageGoalProgress(&LanguageGoal{Language: "Rust", MinPercent: 30}, &Summary{})
+	assert.Zero(t, progress.Percent)
+	assert.False(t, progress.Reached)
+}
+
+func TestLanguageGoal_IsValid(t *testing.T) {
+	assert.True(t, (&LanguageGoal{Language: "Rust", MinPercent: 30}).IsValid())
+	assert.True(t, (&LanguageGoal{Language: "Rust", MinPercent: 30, Category: "coding"}).IsValid())
+	assert.False(t, (&LanguageGoal{Language: " ", MinPercent: 30}).IsValid())
+	assert.False(t, (&LanguageGoal{Language: "Rust", MinPercent: 0}).IsValid())
+	assert.False(t, (&LanguageGoal{Language: "Rust", MinPercent: 101}).IsValid())
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+)
+
+// LeaderboardSeason is a finished, archived leaderboard period
+type LeaderboardSeason struct {
+	ID        uint       `json:"-" gorm:"primary_key"`
+	Name      string     `json:"name" gorm:"not null; uniqueIndex; size:32"` // e.g. 2024-W03 or 2024-01
+	Period    string     `json:"period" gorm:"not null; size:32"`
+	FromTime  CustomTime `json:"from" gorm:"not null" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+	ToTime    CustomTime `json:"to" gorm:"not null" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+	CreatedAt CustomTime `json:"-" gorm:"default:CURRENT_TIMESTAMP"`
+}
+
+type LeaderboardSeasonStanding struct {
+	ID       uint               `json:"-" gorm:"primary_key"`
+	Season   *LeaderboardSeason `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	SeasonID uint               `json:"-" gorm:"not null; index:idx_season_standing_season"`
+	User     *User              `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID   string             `json:"user_id" gorm:"not null; index:idx_season_standing_user"`
+	Rank     uint               `json:"rank" gorm:"not null"`
+	Total    time.Duration      `json:"total" gorm:"not null" swaggertype:"primitive,integer"`
+}
+
+// SeasonName derives a season's name from its start, i.e. the iso week for weekly and the month for monthly seasons
+func SeasonName(period string, from time.Time) string {
+	if period == config.SeasonPeriodMonthly {
+		return from.Format("2006-01")
+	}
+	year, week := from.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
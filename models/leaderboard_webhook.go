@@ -0,0 +1,103 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	LeaderboardWebhookFormatJson  = "json"  // signed json payload, see LeaderboardRankEvent
+	LeaderboardWebhookFormatSlack = "slack" // plain text message to a slack incoming webhook
+)
+
+const (
+	LeaderboardEventRankChanged = "rank_changed"
+	LeaderboardEventOvertaken   = "overtaken"
+)
+
+const LeaderboardWebhookMaxRankChange = 1000
+
+// LeaderboardWebhook is called whenever the user's rank on the live leaderboard changes by at least MinRankChange positions
+// or, if NotifyOvertaken is set, whenever others overtake the user, to be posted to a chat for example
+type LeaderboardWebhook struct {
+	UserID          string     `json:"-" gorm:"primary_key"`
+	User            *User      `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Url             string     `json:"url" gorm:"not null; type:varchar(1024)"`
+	Format          string     `json:"format" gorm:"not null; type:varchar(16)"`
+	Secret          string     `json:"secret" gorm:"type:varchar(255)"` // signs json payloads, if set
+	MinRankChange   int        `json:"min_rank_change"`                 // 0 to not be notified about rank changes
+	NotifyOvertaken bool       `json:"notify_overtaken"`
+	UpdatedAt       CustomTime `json:"updated_at" gorm:"default:CURRENT_TIMESTAMP" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+}
+
+func (w *LeaderboardWebhook) IsValid() bool {
+	if !strings.HasPrefix(w.Url, "https://") || len(w.Url) > 1024 || len(w.Secret) > 255 {
+		return false
+	}
+	if w.Format != LeaderboardWebhookFormatJson && w.Format != LeaderboardWebhookFormatSlack {
+		return false
+	}
+	if w.MinRankChange < 0 || w.MinRankChange > LeaderboardWebhookMaxRankChange {
+		return false
+	}
+	return w.MinRankChange > 0 || w.NotifyOvertaken
+}
+
+// LeaderboardRankChanges are the ranks of all users on the live leaderboard before and after it was re-generated
+type LeaderboardRankChanges struct {
+	Interval string
+	Before   map[string]int
+	After    map[string]int
+}
+
+// LeaderboardRankEvent is the payload of json leaderboard webhooks
+type LeaderboardRankEvent struct {
+	Event       string   `json:"event"`
+	UserID      string   `json:"user_id"`
+	Interval    string   `json:"interval"`
+	OldRank     int      `json:"old_rank"`
+	NewRank     int      `json:"new_rank"`
+	OvertakenBy []string `json:"overtaken_by,omitempty"` // users ranked behind the user before and ahead of them now
+}
+
+// EventsFor returns the events the webhook is to be called with, users who entered or left the leaderboard in the meantime are skipped
+func (c *LeaderboardRankChanges) EventsFor(w *LeaderboardWebhook) []*LeaderboardRankEvent {
+	events := make([]*LeaderboardRankEvent, 0, 2)
+
+	oldRank, ok1 := c.Before[w.UserID]
+	newRank, ok2 := c.After[w.UserID]
+	if !ok1 || !ok2 {
+		return events
+	}
+
+	if w.MinRankChange > 0 && abs(newRank-oldRank) >= w.MinRankChange {
+		events = append(events, &LeaderboardRankEvent{Event: LeaderboardEventRankChanged, UserID: w.UserID, Interval: c.Interval, OldRank: oldRank, NewRank: newRank})
+	}
+
+	if w.NotifyOvertaken {
+		overtakenBy := make([]string, 0)
+		for userId, rank := range c.After {
+			if before, ok := c.Before[userId]; ok && before > oldRank && rank < newRank {
+				overtakenBy = append(overtakenBy, userId)
+			}
+		}
+		if len(overtakenBy) > 0 {
+			sort.Slice(overtakenBy, func(i, j int) bool {
+				if c.After[overtakenBy[i]] != c.After[overtakenBy[j]] {
+					return c.After[overtakenBy[i]] < c.After[overtakenBy[j]]
+				}
+				return overtakenBy[i] < overtakenBy[j]
+			})
+			events = append(events, &LeaderboardRankEvent{Event: LeaderboardEventOvertaken, UserID: w.UserID, Interval: c.Interval, OldRank: oldRank, NewRank: newRank, OvertakenBy: overtakenBy})
+		}
+	}
+
+	return events
+}
+
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
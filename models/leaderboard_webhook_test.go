@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeaderboardWebhook_IsValid(t *testing.T) {
+	assert.True(t, (&LeaderboardWebhook{Url: "https://example.org/hook", Format: LeaderboardWebhookFormatJson, MinRankChange: 3}).IsValid())
+	assert.True(t, (&LeaderboardWebhook{Url: "https://hooks.slack.com/services/x", Format: LeaderboardWebhookFormatSlack, NotifyOvertaken: true}).IsValid())
+	assert.False(t, (&LeaderboardWebhook{Url: "http://example.org/hook", Format: LeaderboardWebhookFormatJson, MinRankChange: 3}).IsValid())
+	assert.False(t, (&LeaderboardWebhook{Url: "https://example.org/hook", Format: "xml", MinRankChange: 3}).IsValid())
+	assert.False(t, (&LeaderboardWebhook{Url: "https://example.org/hook", Format: LeaderboardWebhookFormatJson}).IsValid())
+	assert.False(t, (&LeaderboardWebhook{Url: "https://example.org/hook", Format: LeaderboardWebhookFormatJson, MinRankChange: -1, NotifyOvertaken: true}).IsValid())
+}
+
+func TestLeaderboardRankChanges_EventsFor(t *testing.T) {
+	changes := &LeaderboardRankChanges{
+		Interval: "7_days",
+		Before:   map[string]int{"alice": 1, "bob": 2, "carol": 3, "dave": 4, "eve": 5},
+		After:    map[string]int{"dave": 1, "carol": 2, "alice": 3, "bob": 4, "frank": 5},
+	}
+
+	events := changes.EventsFor(&LeaderboardWebhook{UserID: "bob", MinRankChange: 2, NotifyOvertaken: true})
+	assert.Len(t, events, 2)
+	assert.Equal(t, &LeaderboardRankEvent{Event: LeaderboardEventRankChanged, UserID: "bob", Interval: "7_days", OldRank: 2, NewRank: 4}, events[0])
+	assert.Equal(t, LeaderboardEventOvertaken, events[1].Event)
+	assert.Equal(t, []string{"dave", "carol"}, events[1].OvertakenBy) // not alice, who was ahead before, nor frank, who is new
+
+	events = changes.EventsFor(&LeaderboardWebhook{UserID: "dave", MinRankChange: 4, NotifyOvertaken: true})
+	assert.Empty(t, events)
+
+	events = changes.EventsFor(&LeaderboardWebhook{UserID: "alice", MinRankChange: 3, NotifyOvertaken: false})
+	assert.Empty(t, events)
+
+	events = changes.EventsFor(&LeaderboardWebhook{UserID: "eve", MinRankChange: 1, NotifyOvertaken: true})
+	assert.Empty(t, events)
+}
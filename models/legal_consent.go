@@ -0,0 +1,11 @@
+package models
+
+// LegalConsent records a user's acceptance of a certain version of the instance's terms of service and privacy policy
+type LegalConsent struct {
+	ID         uint       `json:"-" gorm:"primary_key"`
+	UserID     string     `json:"-" gorm:"not null; uniqueIndex:idx_legal_consent_user_version"`
+	User       *User      `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Version    string     `json:"version" gorm:"not null; type:varchar(64); uniqueIndex:idx_legal_consent_user_version"`
+	Ip         string     `json:"ip" gorm:"type:varchar(64)"`
+	AcceptedAt CustomTime `json:"accepted_at" gorm:"default:CURRENT_TIMESTAMP" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+}
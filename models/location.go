@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// LocationSummary breaks down a user's coding time by the countries their machines sent heartbeats from
+type LocationSummary struct {
+	From      time.Time              `json:"from"`
+	To        time.Time              `json:"to"`
+	Countries []*LocationSummaryItem `json:"countries"`
+}
+
+type LocationSummaryItem struct {
+	Country      string `json:"country"` // iso 3166-1 alpha-2 code or "unknown"
+	TotalSeconds int64  `json:"total_seconds"`
+}
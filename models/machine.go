@@ -0,0 +1,22 @@
+package models
+
+type Machine struct {
+	ID        uint       `json:"id" gorm:"primary_key"`
+	User      *User      `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID    string     `json:"-" gorm:"not null; uniqueIndex:idx_machine_user_name"`
+	Name      string     `json:"name" gorm:"type:varchar(255); uniqueIndex:idx_machine_user_name"`
+	Approved  bool       `json:"approved" gorm:"default:false; type:bool"`
+	Rejected  bool       `json:"rejected" gorm:"default:false; type:bool"` // heartbeats from rejected machines are dropped without notice
+	Country   string     `json:"country,omitempty" gorm:"type:varchar(2)"` // iso 3166-1 alpha-2 code of where the machine last sent heartbeats from, if known
+	CreatedAt CustomTime `json:"created_at" gorm:"default:CURRENT_TIMESTAMP" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+}
+
+// QuarantinedHeartbeat holds a serialized heartbeat sent from a machine, which was not approved by the user, yet
+type QuarantinedHeartbeat struct {
+	ID        uint64     `gorm:"primary_key"`
+	User      *User      `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID    string     `json:"-" gorm:"not null; index:idx_quarantined_heartbeat_user_machine"`
+	Machine   string     `json:"machine" gorm:"type:varchar(255); index:idx_quarantined_heartbeat_user_machine"`
+	Data      string     `json:"-" gorm:"type:text"`
+	CreatedAt CustomTime `gorm:"default:CURRENT_TIMESTAMP"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+const MaxMentorsPerUser = 3
+
+// Mentor is someone without an account, e.g. a teacher or parent, who receives a weekly mail with the user's total coding time and top project, but no further details.
+// Users can remove their mentors at any time, mentors can unsubscribe themselves via the link included in every mail.
+type Mentor struct {
+	ID        uint       `json:"id" gorm:"primary_key"`
+	User      *User      `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID    string     `json:"-" gorm:"not null; index:idx_mentor_user"`
+	Email     string     `json:"email" gorm:"not null; type:varchar(255)"`
+	Token     string     `json:"-" gorm:"not null; type:varchar(64); uniqueIndex:idx_mentor_token"` // identifies the mentor in unsubscribe links
+	CreatedAt CustomTime `json:"created_at" gorm:"default:CURRENT_TIMESTAMP" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+}
+
+func NewMentor(email string) *Mentor {
+	return &Mentor{Email: strings.ToLower(strings.TrimSpace(email))}
+}
+
+func (m *Mentor) IsValid() bool {
+	return m.Email != "" && len(m.Email) <= 255 && ValidateEmail(m.Email)
+}
+
+// MentorSummary is the content of the weekly mail to a mentor
+type MentorSummary struct {
+	User            *User
+	From            time.Time
+	To              time.Time
+	Total           time.Duration
+	TopProject      string // empty if the user didn't code at all
+	TopProjectTotal time.Duration
+	UnsubscribeUrl  string
+}
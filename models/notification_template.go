@@ -0,0 +1,135 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	NotificationKindInactivityAlert     = "inactivity_alert"
+	NotificationKindLanguageGoalsReport = "language_goals_report"
+	NotificationKindSeasonWinners       = "season_winners"
+
+	NotificationChannelMail     = "mail"
+	NotificationChannelTelegram = "telegram"
+	NotificationChannelSlack    = "slack"
+)
+
+const NotificationTemplateMaxLength = 16384
+
+// NotificationTemplate replaces the built-in content of one kind of notification on one channel for the whole instance
+type NotificationTemplate struct {
+	Kind     string `json:"kind"`
+	Channel  string `json:"channel"`
+	Subject  string `json:"subject,omitempty"` // go text/template for the subject of mails, the built-in one is used if blank
+	Template string `json:"template"`          // go template rendered with the kind's notification data, html/template for mails and text/template otherwise
+}
+
+// RenderedNotification is the content of a notification as produced by a custom template
+type RenderedNotification struct {
+	Subject string `json:"subject,omitempty"`
+	Body    string `json:"body"`
+}
+
+// InactivityAlertNotification is what inactivity alert templates are rendered with
+type InactivityAlertNotification struct {
+	UserID        string
+	Name          string
+	PublicUrl     string
+	LastHeartbeat time.Time // in the user's time zone
+}
+
+// LanguageGoalsReportNotification is what language goals report templates are rendered with
+type LanguageGoalsReportNotification struct {
+	UserID    string
+	Name      string
+	PublicUrl string
+	From      time.Time
+	To        time.Time // last day of the week, inclusive
+	Goals     []*LanguageGoalsReportNotificationItem
+}
+
+type LanguageGoalsReportNotificationItem struct {
+	Language     string
+	Category     string
+	MinPercent   float64
+	Percent      float64
+	LanguageTime time.Duration
+	Reached      bool
+}
+
+// SeasonWinnersNotification is what season winners templates are rendered with
+type SeasonWinnersNotification struct {
+	Season  string
+	From    time.Time
+	To      time.Time
+	Winners []*SeasonWinnersNotificationItem
+}
+
+type SeasonWinnersNotificationItem struct {
+	Rank   uint
+	UserID string
+	Total  time.Duration
+}
+
+// NotificationChannels lists the channels each kind of notification is sent on
+func NotificationChannels() map[string][]string {
+	return map[string][]string{
+		NotificationKindInactivityAlert:     {NotificationChannelMail, NotificationChannelTelegram},
+		NotificationKindLanguageGoalsReport: {NotificationChannelMail, NotificationChannelTelegram},
+		NotificationKindSeasonWinners:       {NotificationChannelSlack},
+	}
+}
+
+func IsValidNotificationChannel(kind, channel string) bool {
+	for _, c := range NotificationChannels()[kind] {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+func NewInactivityAlertNotification(user *User, lastHeartbeat time.Time, publicUrl string) *InactivityAlertNotification {
+	return &InactivityAlertNotification{
+		UserID:        user.ID,
+		Name:          user.Name,
+		PublicUrl:     publicUrl,
+		LastHeartbeat: lastHeartbeat.In(user.TZ()),
+	}
+}
+
+func NewLanguageGoalsReportNotification(user *User, progress []*LanguageGoalProgress, publicUrl string) *LanguageGoalsReportNotification {
+	n := &LanguageGoalsReportNotification{
+		UserID:    user.ID,
+		Name:      user.Name,
+		PublicUrl: publicUrl,
+		Goals:     make([]*LanguageGoalsReportNotificationItem, 0, len(progress)),
+	}
+	if len(progress) > 0 {
+		n.From, n.To = progress[0].From, progress[0].To.AddDate(0, 0, -1)
+	}
+	for _, p := range progress {
+		n.Goals = append(n.Goals, &LanguageGoalsReportNotificationItem{
+			Language:     p.Goal.Language,
+			Category:     p.Goal.Category,
+			MinPercent:   p.Goal.MinPercent,
+			Percent:      p.Percent,
+			LanguageTime: p.LanguageTime,
+			Reached:      p.Reached,
+		})
+	}
+	return n
+}
+
+func NewSeasonWinnersNotification(season *LeaderboardSeason, winners []*LeaderboardSeasonStanding) *SeasonWinnersNotification {
+	n := &SeasonWinnersNotification{
+		Season:  season.Name,
+		From:    season.FromTime.T(),
+		To:      season.ToTime.T(),
+		Winners: make([]*SeasonWinnersNotificationItem, len(winners)),
+	}
+	for i, w := range winners {
+		n.Winners[i] = &SeasonWinnersNotificationItem{Rank: w.Rank, UserID: w.UserID, Total: w.Total}
+	}
+	return n
+}
@@ -0,0 +1,175 @@
+package models
+
+import (
+	"sort"
+	"time"
+
+	"github.com/duke-git/lancet/v2/datetime"
+)
+
+const (
+	recordsHistogramBucket = 15 * time.Minute
+	recordsHistogramSize   = int(24*time.Hour/recordsHistogramBucket) + 1
+)
+
+// PersonalRecords holds a user's all-time records, which are updated incrementally by folding in heartbeats of every completed day.
+// Besides the records themselves, it keeps the state required to resume processing, e.g. a session or week that is still ongoing.
+type PersonalRecords struct {
+	UserID              string        `gorm:"primary_key"`
+	User                *User         `gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	BestDay             *CustomTime   `gorm:"timeScale:3"`
+	BestDayTotal        time.Duration `gorm:"default:0"`
+	BestWeek            *CustomTime   `gorm:"timeScale:3"`
+	BestWeekTotal       time.Duration `gorm:"default:0"`
+	LongestSessionStart *CustomTime   `gorm:"timeScale:3"`
+	LongestSession      time.Duration `gorm:"default:0"`
+	HourTotals          Int64List     `gorm:"type:text"` // coding time per hour of day in nanoseconds
+	DayHistogram        Int64List     `gorm:"type:text"` // number of active days per 15 minutes of daily coding time
+
+	ProcessedUntil      CustomTime    `gorm:"timeScale:3"`
+	LastHeartbeatAt     *CustomTime   `gorm:"timeScale:3"`
+	CurrentSessionStart *CustomTime   `gorm:"timeScale:3"`
+	CurrentSession      time.Duration `gorm:"default:0"`
+	CurrentDay          *CustomTime   `gorm:"timeScale:3"`
+	CurrentDayTotal     time.Duration `gorm:"default:0"`
+	CurrentWeek         *CustomTime   `gorm:"timeScale:3"`
+	CurrentWeekTotal    time.Duration `gorm:"default:0"`
+	UpdatedAt           CustomTime    `gorm:"timeScale:3"`
+}
+
+func NewPersonalRecords(user *User) *PersonalRecords {
+	return &PersonalRecords{
+		UserID:       user.ID,
+		HourTotals:   make(Int64List, 24),
+		DayHistogram: make(Int64List, recordsHistogramSize),
+	}
+}
+
+// Process folds the given heartbeats, which are expected to be sorted and to lie between ProcessedUntil and until, into the records.
+// Just like summaries, time between two heartbeats on different days isn't counted, so a day is final once until has passed it.
+func (r *PersonalRecords) Process(heartbeats []*Heartbeat, until time.Time, timeout time.Duration, tz *time.Location, weekStart time.Weekday) {
+	r.ensureLists()
+
+	for _, h := range heartbeats {
+		t := h.Time.T().In(tz)
+
+		if r.LastHeartbeatAt == nil {
+			r.startSession(t)
+		} else {
+			prev := r.LastHeartbeatAt.T().In(tz)
+			gap := t.Sub(prev)
+			if gap < 0 {
+				continue
+			}
+
+			if gap > timeout {
+				r.startSession(t)
+			} else {
+				r.CurrentSession += gap
+			}
+
+			if datetime.BeginOfDay(prev).Equal(datetime.BeginOfDay(t)) {
+				r.credit(prev, min(gap, timeout), weekStart)
+			}
+		}
+
+		if r.CurrentSession > r.LongestSession {
+			r.LongestSession = r.CurrentSession
+			r.LongestSessionStart = r.CurrentSessionStart
+		}
+
+		r.LastHeartbeatAt = customTimePtr(t)
+	}
+
+	if r.CurrentDay != nil && !r.CurrentDay.T().AddDate(0, 0, 1).After(until) {
+		r.finishDay()
+	}
+
+	r.ProcessedUntil = CustomTime(until)
+}
+
+// MostProductiveHour returns the hour of day (in the user's time zone) with the most coding time overall, or -1 if there is none
+func (r *PersonalRecords) MostProductiveHour() (int, time.Duration) {
+	hour, total := -1, int64(0)
+	for h, t := range r.HourTotals {
+		if t > total {
+			hour, total = h, t
+		}
+	}
+	return hour, time.Duration(total)
+}
+
+// DailyPercentile returns the daily coding time, which the given percentage (between 0 and 100) of active days did not exceed
+// the result is an upper bound, accurate to 15 minutes
+func (r *PersonalRecords) DailyPercentile(p float64) time.Duration {
+	var days int64
+	for _, n := range r.DayHistogram {
+		days += n
+	}
+	if days == 0 {
+		return 0
+	}
+
+	rank := int64(p / 100 * float64(days))
+	cumulative := make([]int64, len(r.DayHistogram))
+	var sum int64
+	for i, n := range r.DayHistogram {
+		sum += n
+		cumulative[i] = sum
+	}
+	i := sort.Search(len(cumulative), func(i int) bool { return cumulative[i] >= max(rank, 1) })
+	return min(time.Duration(i+1)*recordsHistogramBucket, 24*time.Hour)
+}
+
+func (r *PersonalRecords) startSession(t time.Time) {
+	r.CurrentSessionStart = customTimePtr(t)
+	r.CurrentSession = 0
+}
+
+func (r *PersonalRecords) credit(t time.Time, d time.Duration, weekStart time.Weekday) {
+	day := datetime.BeginOfDay(t)
+	if r.CurrentDay == nil || !r.CurrentDay.T().Equal(day) {
+		r.finishDay()
+		r.CurrentDay = customTimePtr(day)
+	}
+	r.CurrentDayTotal += d
+	if r.CurrentDayTotal > r.BestDayTotal {
+		r.BestDayTotal = r.CurrentDayTotal
+		r.BestDay = r.CurrentDay
+	}
+
+	week := datetime.BeginOfWeek(t, weekStart)
+	if r.CurrentWeek == nil || !r.CurrentWeek.T().Equal(week) {
+		r.CurrentWeek = customTimePtr(week)
+		r.CurrentWeekTotal = 0
+	}
+	r.CurrentWeekTotal += d
+	if r.CurrentWeekTotal > r.BestWeekTotal {
+		r.BestWeekTotal = r.CurrentWeekTotal
+		r.BestWeek = r.CurrentWeek
+	}
+
+	r.HourTotals[t.Hour()] += int64(d)
+}
+
+func (r *PersonalRecords) finishDay() {
+	if r.CurrentDay != nil && r.CurrentDayTotal > 0 {
+		r.DayHistogram[min(int(r.CurrentDayTotal/recordsHistogramBucket), recordsHistogramSize-1)]++
+	}
+	r.CurrentDay = nil
+	r.CurrentDayTotal = 0
+}
+
+func (r *PersonalRecords) ensureLists() {
+	if len(r.HourTotals) != 24 {
+		r.HourTotals = make(Int64List, 24)
+	}
+	if len(r.DayHistogram) != recordsHistogramSize {
+		r.DayHistogram = make(Int64List, recordsHistogramSize)
+	}
+}
+
+func customTimePtr(t time.Time) *CustomTime {
+	ct := CustomTime(t)
+	return &ct
+}
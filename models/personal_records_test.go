@@ -0,0 +1,76 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersonalRecords_Process(t *testing.T) {
+	user := &User{ID: "user1"}
+	day1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC) // monday
+	day2 := day1.AddDate(0, 0, 1)
+
+	heartbeatsAt := func(times ...time.Time) []*Heartbeat {
+		heartbeats := make([]*Heartbeat, len(times))
+		for i, t := range times {
+			heartbeats[i] = &Heartbeat{UserID: user.ID, Time: CustomTime(t)}
+		}
+		return heartbeats
+	}
+
+	sut := NewPersonalRecords(user)
+	sut.ProcessedUntil = CustomTime(day1)
+
+	// day 1: two sessions of two and one minutes, plus the gap in between counted up to the timeout, like for summaries
+	sut.Process(heartbeatsAt(
+		day1.Add(10*time.Hour),
+		day1.Add(10*time.Hour+1*time.Minute),
+		day1.Add(10*time.Hour+2*time.Minute),
+		day1.Add(14*time.Hour),
+		day1.Add(14*time.Hour+1*time.Minute),
+	), day2, DefaultHeartbeatsTimeout, time.UTC, time.Monday)
+
+	assert.Equal(t, 5*time.Minute, sut.BestDayTotal)
+	assert.True(t, sut.BestDay.T().Equal(day1))
+	assert.Equal(t, 2*time.Minute, sut.LongestSession)
+	assert.True(t, sut.LongestSessionStart.T().Equal(day1.Add(10*time.Hour)))
+	assert.Nil(t, sut.CurrentDay)
+	assert.Equal(t, int64(1), sut.DayHistogram[0])
+	assert.True(t, sut.ProcessedUntil.T().Equal(day2))
+
+	// day 2: one session of ten minutes, processed separately
+	times := make([]time.Time, 11)
+	for i := range times {
+		times[i] = day2.Add(9*time.Hour + time.Duration(i)*time.Minute)
+	}
+	sut.Process(heartbeatsAt(times...), day2.AddDate(0, 0, 1), DefaultHeartbeatsTimeout, time.UTC, time.Monday)
+
+	assert.Equal(t, 10*time.Minute, sut.BestDayTotal)
+	assert.True(t, sut.BestDay.T().Equal(day2))
+	assert.Equal(t, 15*time.Minute, sut.BestWeekTotal)
+	assert.True(t, sut.BestWeek.T().Equal(day1))
+	assert.Equal(t, 10*time.Minute, sut.LongestSession)
+	assert.True(t, sut.LongestSessionStart.T().Equal(day2.Add(9*time.Hour)))
+
+	hour, total := sut.MostProductiveHour()
+	assert.Equal(t, 9, hour)
+	assert.Equal(t, 10*time.Minute, total)
+
+	assert.Equal(t, int64(2), sut.DayHistogram[0])
+	assert.Equal(t, 15*time.Minute, sut.DailyPercentile(50))
+}
+
+func TestPersonalRecords_DailyPercentile(t *testing.T) {
+	sut := NewPersonalRecords(&User{ID: "user1"})
+	assert.Zero(t, sut.DailyPercentile(50))
+
+	sut.DayHistogram[0] = 5 // 5 days of less than 15 min
+	sut.DayHistogram[4] = 4 // 4 days of 1 - 1.25 h
+	sut.DayHistogram[8] = 1 // 1 day of 2 - 2.25 h
+
+	assert.Equal(t, 15*time.Minute, sut.DailyPercentile(50))
+	assert.Equal(t, 75*time.Minute, sut.DailyPercentile(90))
+	assert.Equal(t, 135*time.Minute, sut.DailyPercentile(100))
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var pluginReleaseNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,127}$`)
+
+// PluginRelease mirrors the subset of github's release object, which wakatime-cli and the editor plugins evaluate for updates
+type PluginRelease struct {
+	TagName     string                `json:"tag_name"`
+	Name        string                `json:"name"`
+	PublishedAt time.Time             `json:"published_at"`
+	Assets      []*PluginReleaseAsset `json:"assets"`
+}
+
+type PluginReleaseAsset struct {
+	Name               string `json:"name"`
+	Size               int64  `json:"size"`
+	BrowserDownloadUrl string `json:"browser_download_url"`
+}
+
+// ValidatePluginReleaseName checks release tags and asset names, which are used as file names
+func ValidatePluginReleaseName(name string) bool {
+	return pluginReleaseNamePattern.MatchString(name) && !strings.Contains(name, "..")
+}
+
+// ComparePluginVersions compares two version tags like v1.102.1 numerically, component by component
+func ComparePluginVersions(a, b string) int {
+	pa, pb := strings.Split(strings.TrimPrefix(a, "v"), "."), strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var na, nb int
+		if i < len(pa) {
+			na, _ = strconv.Atoi(strings.SplitN(pa[i], "-", 2)[0])
+		}
+		if i < len(pb) {
+			nb, _ = strconv.Atoi(strings.SplitN(pb[i], "-", 2)[0])
+		}
+		if na != nb {
+			if na < nb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return strings.Compare(a, b)
+}
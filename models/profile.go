@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Profile is a user's public, GitHub-style profile, served under /@{username}. It only includes the widgets the user chose to show
+// and always covers the past 12 months.
+type Profile struct {
+	Username         string          `json:"username"`
+	DisplayName      string          `json:"display_name"`
+	TotalTime        time.Duration   `json:"total_time" swaggertype:"primitive,integer"`
+	Languages        []*ProfileEntry `json:"languages,omitempty"`
+	Projects         []*ProfileEntry `json:"projects,omitempty"`
+	ActivityChartUrl string          `json:"activity_chart_url,omitempty"`
+}
+
+type ProfileEntry struct {
+	Key     string        `json:"key"`
+	Total   time.Duration `json:"total" swaggertype:"primitive,integer"`
+	Percent float64       `json:"percent"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+var projectColorPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// ProjectMetadata holds user-defined presentation details of a project, so that all clients render it consistently
+type ProjectMetadata struct {
+	ID          uint   `json:"-" gorm:"primary_key"`
+	User        *User  `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID      string `json:"-" gorm:"not null; uniqueIndex:idx_project_metadata_user_project"`
+	ProjectKey  string `json:"project" gorm:"not null; type:varchar(255); uniqueIndex:idx_project_metadata_user_project"`
+	Color       string `json:"color" gorm:"type:varchar(7)"`         // hex code, e.g. #2f855a
+	Icon        string `json:"icon" gorm:"type:varchar(64)"`         // emoji or icon name
+	Description string `json:"description" gorm:"type:varchar(255)"` // short, human-readable description
+}
+
+func (m *ProjectMetadata) IsValid() bool {
+	return m.ProjectKey != "" &&
+		(m.Color == "" || projectColorPattern.MatchString(m.Color)) &&
+		utf8.RuneCountInString(m.Icon) <= 16 &&
+		utf8.RuneCountInString(m.Description) <= 255
+}
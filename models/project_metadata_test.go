@@ -0,0 +1,18 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectMetadata_IsValid(t *testing.T) {
+	assert.True(t, (&ProjectMetadata{ProjectKey: "wakapi"}).IsValid())
+	assert.True(t, (&ProjectMetadata{ProjectKey: "wakapi", Color: "#2f855A", Icon: "🚀", Description: "coding statistics"}).IsValid())
+	assert.False(t, (&ProjectMetadata{Color: "#2f855a"}).IsValid())
+	assert.False(t, (&ProjectMetadata{ProjectKey: "wakapi", Color: "green"}).IsValid())
+	assert.False(t, (&ProjectMetadata{ProjectKey: "wakapi", Color: "#2f855"}).IsValid())
+	assert.False(t, (&ProjectMetadata{ProjectKey: "wakapi", Icon: strings.Repeat("a", 17)}).IsValid())
+	assert.False(t, (&ProjectMetadata{ProjectKey: "wakapi", Description: strings.Repeat("a", 256)}).IsValid())
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// ProjectOverride assigns all heartbeats of files below the given path to a fixed project at ingestion, regardless of what the client inferred
+type ProjectOverride struct {
+	ID      uint   `json:"-" gorm:"primary_key"`
+	User    *User  `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID  string `json:"-" gorm:"not null; uniqueIndex:idx_project_override_user_path"`
+	Path    string `json:"path" gorm:"not null; type:varchar(255); uniqueIndex:idx_project_override_user_path"` // absolute directory path, with forward slashes
+	Project string `json:"project" gorm:"not null; type:varchar(191)"`
+}
+
+func (o *ProjectOverride) IsValid() bool {
+	return o.Path != "" && o.Project != "" &&
+		utf8.RuneCountInString(o.Path) <= 255 &&
+		utf8.RuneCountInString(o.Project) <= HeartbeatMaxProjectLength
+}
+
+// Matches reports whether the given entity, with forward slashes, denotes a file below the override's path
+func (o *ProjectOverride) Matches(entity string) bool {
+	return strings.HasPrefix(entity, o.Path+"/")
+}
+
+// NormalizeProjectOverridePath converts backslashes to forward slashes and strips trailing ones, so that paths compare like entities
+func NormalizeProjectOverridePath(path string) string {
+	return strings.TrimRight(strings.ReplaceAll(strings.TrimSpace(path), "\\", "/"), "/")
+}
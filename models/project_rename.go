@@ -0,0 +1,108 @@
+package models
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	ProjectRenameStatusRunning  = "running"
+	ProjectRenameStatusFinished = "finished"
+	ProjectRenameStatusFailed   = "failed"
+)
+
+// ProjectRenameJob tracks the progress of renaming a project across all of a user's heartbeats and time entries, followed by
+// re-materializing the affected summaries. Jobs are only kept in memory.
+type ProjectRenameJob struct {
+	ID                 string     `json:"id"`
+	UserID             string     `json:"user_id"`
+	OldProject         string     `json:"old_project"`
+	NewProject         string     `json:"new_project"`
+	Status             string     `json:"status"`
+	ChunksTotal        int        `json:"chunks_total"` // heartbeats are renamed month by month
+	ChunksDone         int        `json:"chunks_done"`
+	HeartbeatsUpdated  int64      `json:"heartbeats_updated"`
+	TimeEntriesUpdated int64      `json:"time_entries_updated"`
+	ResummarizeJobID   string     `json:"resummarize_job_id,omitempty"`
+	StartedAt          time.Time  `json:"started_at"`
+	FinishedAt         *time.Time `json:"finished_at"`
+	Error              string     `json:"error,omitempty"`
+	resummarizeJob     *ResummarizeJob
+	mutex              sync.RWMutex
+}
+
+// Snapshot returns a copy of the job's current state, which is safe to read while the job is still running
+func (j *ProjectRenameJob) Snapshot() *ProjectRenameJob {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return &ProjectRenameJob{
+		ID:                 j.ID,
+		UserID:             j.UserID,
+		OldProject:         j.OldProject,
+		NewProject:         j.NewProject,
+		Status:             j.Status,
+		ChunksTotal:        j.ChunksTotal,
+		ChunksDone:         j.ChunksDone,
+		HeartbeatsUpdated:  j.HeartbeatsUpdated,
+		TimeEntriesUpdated: j.TimeEntriesUpdated,
+		ResummarizeJobID:   j.ResummarizeJobID,
+		StartedAt:          j.StartedAt,
+		FinishedAt:         j.FinishedAt,
+		Error:              j.Error,
+	}
+}
+
+// Progress returns the share of work done between 0 and 1, where renaming heartbeats and re-generating summaries account for one half each
+func (j *ProjectRenameJob) Progress() float64 {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	if j.Status != ProjectRenameStatusRunning {
+		return 1
+	}
+	var progress float64
+	if j.ChunksTotal > 0 {
+		progress += float64(j.ChunksDone) / float64(j.ChunksTotal) / 2
+	}
+	if j.resummarizeJob != nil {
+		progress += j.resummarizeJob.Progress() / 2
+	}
+	return progress
+}
+
+func (j *ProjectRenameJob) IsRunning() bool {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.Status == ProjectRenameStatusRunning
+}
+
+func (j *ProjectRenameJob) AdvanceHeartbeats(updated int64) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.ChunksDone++
+	j.HeartbeatsUpdated += updated
+}
+
+func (j *ProjectRenameJob) SetTimeEntriesUpdated(updated int64) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.TimeEntriesUpdated = updated
+}
+
+func (j *ProjectRenameJob) SetResummarizeJob(job *ResummarizeJob) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.resummarizeJob = job
+	j.ResummarizeJobID = job.ID
+}
+
+func (j *ProjectRenameJob) Finish(err error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	now := time.Now()
+	j.FinishedAt = &now
+	j.Status = ProjectRenameStatusFinished
+	if err != nil {
+		j.Status = ProjectRenameStatusFailed
+		j.Error = err.Error()
+	}
+}
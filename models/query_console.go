@@ -0,0 +1,12 @@
+package models
+
+type QueryConsoleRequest struct {
+	Query string `json:"query"`
+}
+
+type QueryConsoleResult struct {
+	Columns   []string        `json:"columns"`
+	Rows      [][]interface{} `json:"rows"`
+	Truncated bool            `json:"truncated"` // whether there were more rows than the configured limit
+	TookMs    int64           `json:"took_ms"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/duke-git/lancet/v2/slice"
+	conf "github.com/hackclub/hackatime/config"
+)
+
+// RegistrationPolicy determines who may sign up on this instance, initially taken from the config, but can be changed by admins at runtime
+type RegistrationPolicy struct {
+	Mode    string   `json:"mode"`    // one of open, invite, domain, closed
+	Domains []string `json:"domains"` // e-mail domains allowed to sign up in domain mode, e.g. school.edu
+}
+
+func NewRegistrationPolicyFrom(config *conf.Config) *RegistrationPolicy {
+	return &RegistrationPolicy{
+		Mode:    config.Security.GetRegistrationMode(),
+		Domains: config.Security.GetRegistrationDomains(),
+	}
+}
+
+func (p *RegistrationPolicy) IsValid() bool {
+	return slice.Contain(conf.RegistrationModes, p.Mode) &&
+		(p.Mode != conf.RegistrationModeDomain || len(p.Domains) > 0)
+}
+
+// AllowsSignupForm tells whether anybody may sign up without having been invited
+func (p *RegistrationPolicy) AllowsSignupForm() bool {
+	return p.Mode == conf.RegistrationModeOpen || p.Mode == conf.RegistrationModeDomain
+}
+
+// MatchesDomain checks whether the e-mail address belongs to one of the allowed domains or any of their subdomains
+func (p *RegistrationPolicy) MatchesDomain(email string) bool {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(email)), "@")
+	if len(parts) != 2 || parts[1] == "" {
+		return false
+	}
+	for _, d := range p.Domains {
+		if parts[1] == d || strings.HasSuffix(parts[1], "."+d) {
+			return true
+		}
+	}
+	return false
+}
+
+// InviteCode admits new users in invite mode (and in addition to the allowed domains in domain mode), up to a number of uses and until it expires
+type InviteCode struct {
+	Code      string      `json:"code" gorm:"primary_key; type:varchar(32)"`
+	CreatedBy string      `json:"created_by" gorm:"type:varchar(255)"`                                             // id of the inviting user
+	MaxUses   int         `json:"max_uses" gorm:"default:1"`                                                       // 0 for unlimited
+	Uses      int         `json:"uses" gorm:"default:0"`                                                           // number of users who signed up using this code
+	ExpiresAt *CustomTime `json:"expires_at" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"` // never expires if nil
+	CreatedAt CustomTime  `json:"created_at" gorm:"default:CURRENT_TIMESTAMP" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+}
+
+func (c *InviteCode) IsUsable(now time.Time) bool {
+	return (c.MaxUses == 0 || c.Uses < c.MaxUses) &&
+		(c.ExpiresAt == nil || now.Before(c.ExpiresAt.T()))
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistrationPolicy_MatchesDomain(t *testing.T) {
+	sut := &RegistrationPolicy{Mode: conf.RegistrationModeDomain, Domains: []string{"school.edu"}}
+
+	assert.True(t, sut.MatchesDomain("student@school.edu"))
+	assert.True(t, sut.MatchesDomain(" Student@CS.School.edu"))
+	assert.False(t, sut.MatchesDomain("student@myschool.edu"))
+	assert.False(t, sut.MatchesDomain("school.edu"))
+	assert.False(t, sut.MatchesDomain(""))
+}
+
+func TestRegistrationPolicy_IsValid(t *testing.T) {
+	assert.True(t, (&RegistrationPolicy{Mode: conf.RegistrationModeOpen}).IsValid())
+	assert.True(t, (&RegistrationPolicy{Mode: conf.RegistrationModeDomain, Domains: []string{"school.edu"}}).IsValid())
+	assert.False(t, (&RegistrationPolicy{Mode: conf.RegistrationModeDomain}).IsValid())
+	assert.False(t, (&RegistrationPolicy{Mode: "sometimes"}).IsValid())
+}
+
+func TestInviteCode_IsUsable(t *testing.T) {
+	now := time.Now()
+	future, past := CustomTime(now.Add(time.Hour)), CustomTime(now.Add(-time.Hour))
+
+	assert.True(t, (&InviteCode{MaxUses: 0, Uses: 100}).IsUsable(now))
+	assert.True(t, (&InviteCode{MaxUses: 2, Uses: 1, ExpiresAt: &future}).IsUsable(now))
+	assert.False(t, (&InviteCode{MaxUses: 1, Uses: 1}).IsUsable(now))
+	assert.False(t, (&InviteCode{ExpiresAt: &past}).IsUsable(now))
+}
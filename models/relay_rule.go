@@ -0,0 +1,71 @@
+package models
+
+import (
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	RelayRuleHideEntity    = "hide_entity"    // replaces the file path (or url, app name, ...) by a placeholder, keeping only the file extension
+	RelayRuleRenameProject = "rename_project" // replaces the project name by the rule's value
+)
+
+const relayHiddenEntity = "HIDDEN" // same as wakatime-cli's hide_file_names option
+
+// RelayRule transforms the user's heartbeats before relaying them to wakatime (or another compatible instance), e.g. to not leak private file names.
+// heartbeats stored on this instance are not affected.
+type RelayRule struct {
+	ID      uint   `json:"id" gorm:"primary_key"`
+	User    *User  `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID  string `json:"-" gorm:"not null; index:idx_relay_rule_user"`
+	Type    string `json:"type" gorm:"not null; type:varchar(32)"`
+	Project string `json:"project" gorm:"type:varchar(191)"` // project the rule applies to, all projects if blank
+	Value   string `json:"value" gorm:"type:varchar(191)"`   // new project name for rename_project
+}
+
+type RelayRules []*RelayRule
+
+func (r *RelayRule) IsValid() bool {
+	if utf8.RuneCountInString(r.Project) > HeartbeatMaxProjectLength || utf8.RuneCountInString(r.Value) > HeartbeatMaxProjectLength {
+		return false
+	}
+	switch r.Type {
+	case RelayRuleHideEntity:
+		return r.Value == ""
+	case RelayRuleRenameProject:
+		return r.Value != ""
+	default:
+		return false
+	}
+}
+
+func (r *RelayRule) Matches(project string) bool {
+	return r.Project == "" || r.Project == project
+}
+
+// Apply transforms a single heartbeat in place, given in its raw json representation as sent by the client, which is what gets relayed.
+// rules are matched against the heartbeat's original project, so they don't depend on each other's order.
+func (rules RelayRules) Apply(heartbeat map[string]interface{}) {
+	project, _ := heartbeat["project"].(string)
+	if project == "" {
+		project, _ = heartbeat["alternate_project"].(string)
+	}
+
+	for _, r := range rules {
+		if !r.Matches(project) {
+			continue
+		}
+		switch r.Type {
+		case RelayRuleHideEntity:
+			entity, _ := heartbeat["entity"].(string)
+			heartbeat["entity"] = relayHiddenEntity
+			if heartbeatType, _ := heartbeat["type"].(string); heartbeatType == "file" || heartbeatType == "" {
+				heartbeat["entity"] = relayHiddenEntity + path.Ext(strings.ReplaceAll(entity, "\\", "/"))
+			}
+		case RelayRuleRenameProject:
+			heartbeat["project"] = r.Value
+			delete(heartbeat, "alternate_project")
+		}
+	}
+}
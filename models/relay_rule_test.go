@@ -0,0 +1,43 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelayRule_IsValid(t *testing.T) {
+	assert.True(t, (&RelayRule{Type: RelayRuleHideEntity}).IsValid())
+	assert.True(t, (&RelayRule{Type: RelayRuleHideEntity, Project: "secret"}).IsValid())
+	assert.False(t, (&RelayRule{Type: RelayRuleHideEntity, Value: "foo"}).IsValid())
+	assert.True(t, (&RelayRule{Type: RelayRuleRenameProject, Project: "secret", Value: "freelance"}).IsValid())
+	assert.False(t, (&RelayRule{Type: RelayRuleRenameProject, Project: "secret"}).IsValid())
+	assert.False(t, (&RelayRule{Type: "unknown"}).IsValid())
+}
+
+func TestRelayRules_Apply(t *testing.T) {
+	rules := RelayRules{
+		{Type: RelayRuleHideEntity, Project: "secret"},
+		{Type: RelayRuleRenameProject, Project: "secret", Value: "freelance"},
+		{Type: RelayRuleHideEntity, Project: "website"},
+	}
+
+	hb1 := map[string]interface{}{"entity": "/home/user/dev/secret/src/customer_export.go", "type": "file", "project": "secret"}
+	hb2 := map[string]interface{}{"entity": "C:\\dev\\secret\\Makefile", "type": "file", "alternate_project": "secret"}
+	hb3 := map[string]interface{}{"entity": "intranet.example.org", "type": "domain", "project": "website"}
+	hb4 := map[string]interface{}{"entity": "/home/user/dev/wakapi/main.go", "type": "file", "project": "wakapi"}
+
+	for _, hb := range []map[string]interface{}{hb1, hb2, hb3, hb4} {
+		rules.Apply(hb)
+	}
+
+	assert.Equal(t, "HIDDEN.go", hb1["entity"])
+	assert.Equal(t, "freelance", hb1["project"])
+	assert.Equal(t, "HIDDEN", hb2["entity"])
+	assert.Equal(t, "freelance", hb2["project"])
+	assert.NotContains(t, hb2, "alternate_project")
+	assert.Equal(t, "HIDDEN", hb3["entity"])
+	assert.Equal(t, "website", hb3["project"])
+	assert.Equal(t, "/home/user/dev/wakapi/main.go", hb4["entity"])
+	assert.Equal(t, "wakapi", hb4["project"])
+}
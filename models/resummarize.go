@@ -0,0 +1,86 @@
+package models
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	ResummarizeStatusRunning  = "running"
+	ResummarizeStatusFinished = "finished"
+	ResummarizeStatusFailed   = "failed"
+)
+
+// ResummarizeJob tracks the progress of re-materializing a user's daily summaries within a date range, e.g. after language
+// mappings or project aliases were changed. Jobs are only kept in memory.
+type ResummarizeJob struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"user_id"`
+	From       time.Time  `json:"from"`
+	To         time.Time  `json:"to"`
+	Status     string     `json:"status"`
+	DaysTotal  int        `json:"days_total"`
+	DaysDone   int        `json:"days_done"`
+	DaysFailed int        `json:"days_failed"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	Error      string     `json:"error,omitempty"`
+	mutex      sync.RWMutex
+}
+
+// Snapshot returns a copy of the job's current state, which is safe to read while the job is still running
+func (j *ResummarizeJob) Snapshot() *ResummarizeJob {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return &ResummarizeJob{
+		ID:         j.ID,
+		UserID:     j.UserID,
+		From:       j.From,
+		To:         j.To,
+		Status:     j.Status,
+		DaysTotal:  j.DaysTotal,
+		DaysDone:   j.DaysDone,
+		DaysFailed: j.DaysFailed,
+		StartedAt:  j.StartedAt,
+		FinishedAt: j.FinishedAt,
+		Error:      j.Error,
+	}
+}
+
+// Progress returns the share of processed days between 0 and 1
+func (j *ResummarizeJob) Progress() float64 {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	if j.DaysTotal == 0 {
+		return 1
+	}
+	return float64(j.DaysDone+j.DaysFailed) / float64(j.DaysTotal)
+}
+
+func (j *ResummarizeJob) IsRunning() bool {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	return j.Status == ResummarizeStatusRunning
+}
+
+func (j *ResummarizeJob) Advance(failed bool) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if failed {
+		j.DaysFailed++
+	} else {
+		j.DaysDone++
+	}
+}
+
+func (j *ResummarizeJob) Finish(err error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	now := time.Now()
+	j.FinishedAt = &now
+	j.Status = ResummarizeStatusFinished
+	if err != nil {
+		j.Status = ResummarizeStatusFailed
+		j.Error = err.Error()
+	}
+}
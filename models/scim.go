@@ -0,0 +1,224 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// see https://datatracker.ietf.org/doc/html/rfc7643 and https://datatracker.ietf.org/doc/html/rfc7644
+
+const (
+	ScimSchemaUser         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	ScimSchemaGroup        = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	ScimSchemaListResponse = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	ScimSchemaPatchOp      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	ScimSchemaError        = "urn:ietf:params:scim:api:messages:2.0:Error"
+	ScimSchemaSpConfig     = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	ScimAdminsGroupId      = "admins"
+)
+
+type ScimUser struct {
+	Schemas     []string     `json:"schemas"`
+	Id          string       `json:"id,omitempty"`
+	UserName    string       `json:"userName"`
+	DisplayName string       `json:"displayName,omitempty"`
+	Name        *ScimName    `json:"name,omitempty"`
+	Emails      []*ScimEmail `json:"emails,omitempty"`
+	Active      *bool        `json:"active,omitempty"`
+	Meta        *ScimMeta    `json:"meta,omitempty"`
+}
+
+type ScimName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type ScimEmail struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type ScimMeta struct {
+	ResourceType string `json:"resourceType"`
+	Created      string `json:"created,omitempty"`
+	Location     string `json:"location,omitempty"`
+}
+
+type ScimGroup struct {
+	Schemas     []string      `json:"schemas"`
+	Id          string        `json:"id"`
+	DisplayName string        `json:"displayName"`
+	Members     []*ScimMember `json:"members"`
+	Meta        *ScimMeta     `json:"meta,omitempty"`
+}
+
+type ScimMember struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+}
+
+type ScimListResponse struct {
+	Schemas      []string    `json:"schemas"`
+	TotalResults int         `json:"totalResults"`
+	StartIndex   int         `json:"startIndex"`
+	ItemsPerPage int         `json:"itemsPerPage"`
+	Resources    interface{} `json:"Resources"`
+}
+
+type ScimPatchRequest struct {
+	Schemas    []string              `json:"schemas"`
+	Operations []*ScimPatchOperation `json:"Operations"`
+}
+
+type ScimPatchOperation struct {
+	Op    string      `json:"op"`
+	Path  string      `json:"path,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+type ScimError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+func NewScimUserFrom(user *User, baseUrl string) *ScimUser {
+	active := !user.Deactivated
+	scimUser := &ScimUser{
+		Schemas:     []string{ScimSchemaUser},
+		Id:          user.ID,
+		UserName:    user.ID,
+		DisplayName: user.Name,
+		Active:      &active,
+		Meta: &ScimMeta{
+			ResourceType: "User",
+			Created:      user.CreatedAt.T().UTC().Format("2006-01-02T15:04:05Z"),
+			Location:     fmt.Sprintf("%s/Users/%s", baseUrl, user.ID),
+		},
+	}
+	if user.Name != "" {
+		scimUser.Name = &ScimName{Formatted: user.Name}
+	}
+	if user.Email != "" {
+		scimUser.Emails = []*ScimEmail{{Value: user.Email, Type: "work", Primary: true}}
+	}
+	return scimUser
+}
+
+func NewScimError(status int, scimType, detail string) *ScimError {
+	return &ScimError{
+		Schemas:  []string{ScimSchemaError},
+		Status:   fmt.Sprintf("%d", status),
+		ScimType: scimType,
+		Detail:   detail,
+	}
+}
+
+// PrimaryEmail returns the primary e-mail address or the first one, if none is marked as primary
+func (u *ScimUser) PrimaryEmail() string {
+	for _, e := range u.Emails {
+		if e.Primary {
+			return e.Value
+		}
+	}
+	if len(u.Emails) > 0 {
+		return u.Emails[0].Value
+	}
+	return ""
+}
+
+// FullName returns the display name or falls back to the name components
+func (u *ScimUser) FullName() string {
+	if u.DisplayName != "" {
+		return u.DisplayName
+	}
+	if u.Name == nil {
+		return ""
+	}
+	if u.Name.Formatted != "" {
+		return u.Name.Formatted
+	}
+	return strings.TrimSpace(u.Name.GivenName + " " + u.Name.FamilyName)
+}
+
+// ApplyTo writes a (partial) user resource as sent by the identity provider to the given user
+func (u *ScimUser) ApplyTo(user *User) {
+	if name := u.FullName(); name != "" {
+		user.Name = name
+	}
+	if email := u.PrimaryEmail(); email != "" {
+		user.Email = email
+	}
+	if u.Active != nil {
+		user.Deactivated = !*u.Active
+	}
+}
+
+// ApplyTo performs the patch operations on the given user, only a few common attributes are supported
+func (p *ScimPatchRequest) ApplyTo(user *User) error {
+	for _, op := range p.Operations {
+		switch strings.ToLower(op.Op) {
+		case "add", "replace":
+		default:
+			return fmt.Errorf("unsupported patch operation '%s'", op.Op)
+		}
+
+		values := map[string]interface{}{}
+		if op.Path == "" {
+			if m, ok := op.Value.(map[string]interface{}); ok {
+				values = m
+			} else {
+				return fmt.Errorf("invalid value for patch operation without path")
+			}
+		} else {
+			values[op.Path] = op.Value
+		}
+
+		for path, value := range values {
+			if err := applyScimUserAttribute(user, path, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func applyScimUserAttribute(user *User, path string, value interface{}) error {
+	path = strings.ToLower(path)
+	switch {
+	case path == "active":
+		switch v := value.(type) {
+		case bool:
+			user.Deactivated = !v
+		case string: // azure ad sends booleans as strings
+			user.Deactivated = strings.ToLower(v) != "true"
+		default:
+			return fmt.Errorf("invalid value for attribute 'active'")
+		}
+	case path == "displayname" || path == "name.formatted":
+		if v, ok := value.(string); ok {
+			user.Name = v
+		}
+	case strings.HasPrefix(path, "emails"):
+		switch v := value.(type) {
+		case string:
+			user.Email = v
+		case []interface{}:
+			if len(v) > 0 {
+				if m, ok := v[0].(map[string]interface{}); ok {
+					if email, ok := m["value"].(string); ok {
+						user.Email = email
+					}
+				}
+			}
+		}
+	case path == "username" || path == "externalid" || strings.HasPrefix(path, "name."):
+		// usernames are immutable, the other attributes are not stored
+	default:
+		return fmt.Errorf("unsupported attribute '%s'", path)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScimPatchRequest_ApplyTo(t *testing.T) {
+	sut := &User{ID: "john", Name: "John", Email: "john@example.org"}
+
+	var okta ScimPatchRequest
+	json.Unmarshal([]byte(`{"Operations": [{"op": "replace", "value": {"active": false, "displayName": "John Doe"}}]}`), &okta)
+	assert.Nil(t, okta.ApplyTo(sut))
+	assert.True(t, sut.Deactivated)
+	assert.Equal(t, "John Doe", sut.Name)
+
+	var azure ScimPatchRequest
+	json.Unmarshal([]byte(`{"Operations": [{"op": "Replace", "path": "active", "value": "True"}, {"op": "Add", "path": "emails[type eq \"work\"].value", "value": "jd@example.org"}]}`), &azure)
+	assert.Nil(t, azure.ApplyTo(sut))
+	assert.False(t, sut.Deactivated)
+	assert.Equal(t, "jd@example.org", sut.Email)
+
+	var invalid ScimPatchRequest
+	json.Unmarshal([]byte(`{"Operations": [{"op": "remove", "path": "active"}]}`), &invalid)
+	assert.NotNil(t, invalid.ApplyTo(sut))
+}
+
+func TestScimUser_ApplyTo(t *testing.T) {
+	active := false
+	sut := &User{ID: "john"}
+
+	(&ScimUser{
+		Name:   &ScimName{GivenName: "John", FamilyName: "Doe"},
+		Emails: []*ScimEmail{{Value: "other@example.org"}, {Value: "john@example.org", Primary: true}},
+		Active: &active,
+	}).ApplyTo(sut)
+
+	assert.Equal(t, "John Doe", sut.Name)
+	assert.Equal(t, "john@example.org", sut.Email)
+	assert.True(t, sut.Deactivated)
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"text/template"
+	"time"
+)
+
+const MaxScrapbookTemplateLength = 1024
+
+// ScrapbookSession is a completed coding session, as posted to hack club's scrapbook / arcade
+type ScrapbookSession struct {
+	Project  string
+	Start    time.Time
+	End      time.Time
+	Duration time.Duration
+}
+
+type ScrapbookPost struct {
+	UserID        string    `json:"user_id"`
+	Project       string    `json:"project"`
+	Start         time.Time `json:"start"`
+	End           time.Time `json:"end"`
+	DurationSec   int64     `json:"duration_sec"`
+	Text          string    `json:"text"`
+	ScreenshotUrl string    `json:"screenshot_url"` // placeholder, to be filled by the user on scrapbook
+}
+
+type scrapbookTemplateData struct {
+	Project  string
+	Duration string
+	Start    string
+	End      string
+}
+
+// ParseScrapbookTemplate parses a user-provided session template and makes sure it only refers to known fields
+func ParseScrapbookTemplate(tpl string) (*template.Template, error) {
+	if len(tpl) > MaxScrapbookTemplateLength {
+		return nil, fmt.Errorf("template must not be longer than %d characters", MaxScrapbookTemplateLength)
+	}
+	t, err := template.New("scrapbook").Parse(tpl)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.Execute(io.Discard, &scrapbookTemplateData{}); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// FindLastScrapbookSession finds the latest session within the given heartbeats, which are expected to be sorted by time, ascending.
+// Heartbeats with less than gap in between belong to the same session. Time between two heartbeats is counted up to timeout
+// and attributed to the project of the earlier one, the session's project is the one most time was spent on.
+func FindLastScrapbookSession(heartbeats []*Heartbeat, gap, timeout time.Duration) *ScrapbookSession {
+	if len(heartbeats) == 0 {
+		return nil
+	}
+
+	start := len(heartbeats) - 1
+	for start > 0 && heartbeats[start].Time.T().Sub(heartbeats[start-1].Time.T()) < gap {
+		start--
+	}
+
+	session := &ScrapbookSession{
+		Start: heartbeats[start].Time.T(),
+		End:   heartbeats[len(heartbeats)-1].Time.T(),
+	}
+
+	projectTimes := make(map[string]time.Duration)
+	for i := start; i < len(heartbeats)-1; i++ {
+		d := heartbeats[i+1].Time.T().Sub(heartbeats[i].Time.T())
+		if d > timeout {
+			d = timeout
+		}
+		projectTimes[heartbeats[i].Project] += d
+		session.Duration += d
+	}
+
+	var max time.Duration
+	session.Project = heartbeats[len(heartbeats)-1].Project
+	for p, d := range projectTimes {
+		if d > max || (d == max && p < session.Project) {
+			max, session.Project = d, p
+		}
+	}
+	if session.Project == "" {
+		session.Project = UnknownSummaryKey
+	}
+
+	return session
+}
+
+// NewScrapbookPost renders the session using the given template
+func NewScrapbookPost(user *User, session *ScrapbookSession, tpl *template.Template) (*ScrapbookPost, error) {
+	duration := session.Duration.Round(time.Minute)
+
+	var text bytes.Buffer
+	if err := tpl.Execute(&text, &scrapbookTemplateData{
+		Project:  session.Project,
+		Duration: fmt.Sprintf("%dh %dm", int64(duration.Hours()), int64(duration.Minutes())%60),
+		Start:    session.Start.In(user.TZ()).Format("15:04"),
+		End:      session.End.In(user.TZ()).Format("15:04"),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &ScrapbookPost{
+		UserID:      user.ID,
+		Project:     session.Project,
+		Start:       session.Start,
+		End:         session.End,
+		DurationSec: int64(session.Duration.Seconds()),
+		Text:        text.String(),
+	}, nil
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindLastScrapbookSession(t *testing.T) {
+	t0 := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	hb := func(min int, project string) *Heartbeat {
+		return &Heartbeat{Project: project, Time: CustomTime(t0.Add(time.Duration(min) * time.Minute))}
+	}
+
+	heartbeats := []*Heartbeat{
+		hb(0, "old"),
+		hb(1, "old"),
+		// gap of more than 30 minutes
+		hb(60, "wakapi"),
+		hb(62, "wakapi"),
+		hb(64, "wakapi"),
+		hb(74, "website"), // capped at 2 minutes timeout for the previous one
+		hb(75, "website"),
+	}
+
+	session := FindLastScrapbookSession(heartbeats, 30*time.Minute, 2*time.Minute)
+	assert.Equal(t, "wakapi", session.Project)
+	assert.True(t, session.Start.Equal(t0.Add(60*time.Minute)))
+	assert.True(t, session.End.Equal(t0.Add(75*time.Minute)))
+	assert.Equal(t, 7*time.Minute, session.Duration)
+
+	assert.Nil(t, FindLastScrapbookSession([]*Heartbeat{}, 30*time.Minute, 2*time.Minute))
+}
+
+func TestNewScrapbookPost(t *testing.T) {
+	tpl, err := ParseScrapbookTemplate("Worked on {{ .Project }} for {{ .Duration }}")
+	assert.Nil(t, err)
+
+	session := &ScrapbookSession{Project: "wakapi", Duration: 83 * time.Minute}
+	post, err := NewScrapbookPost(&User{ID: "user1"}, session, tpl)
+	assert.Nil(t, err)
+	assert.Equal(t, "Worked on wakapi for 1h 23m", post.Text)
+	assert.Equal(t, int64(83*60), post.DurationSec)
+	assert.Empty(t, post.ScreenshotUrl)
+}
+
+func TestParseScrapbookTemplate(t *testing.T) {
+	_, err := ParseScrapbookTemplate("{{ .Project")
+	assert.NotNil(t, err)
+
+	_, err = ParseScrapbookTemplate("{{ .Unknown }}")
+	assert.NotNil(t, err)
+}
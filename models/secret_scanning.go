@@ -0,0 +1,31 @@
+package models
+
+// see https://docs.github.com/en/code-security/secret-scanning/secret-scanning-partner-program
+
+const (
+	SecretScanningLabelTruePositive  = "true_positive"
+	SecretScanningLabelFalsePositive = "false_positive"
+)
+
+type SecretScanningAlert struct {
+	Token  string `json:"token"`
+	Type   string `json:"type"`
+	Url    string `json:"url"`
+	Source string `json:"source"`
+}
+
+type SecretScanningFeedback struct {
+	TokenRaw  string `json:"token_raw"`
+	TokenType string `json:"token_type"`
+	Label     string `json:"label"`
+}
+
+type SecretScanningPublicKeys struct {
+	PublicKeys []*SecretScanningPublicKey `json:"public_keys"`
+}
+
+type SecretScanningPublicKey struct {
+	KeyIdentifier string `json:"key_identifier"`
+	Key           string `json:"key"`
+	IsCurrent     bool   `json:"is_current"`
+}
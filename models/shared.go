@@ -64,7 +64,13 @@ func (j *CustomTime) UnmarshalJSON(b []byte) error {
 	s := strings.Trim(string(b), "\"")
 	ts, err := strconv.ParseFloat(s, 64)
 	if err != nil {
-		return err
+		// also accept what MarshalJSON produces
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return err
+		}
+		*j = CustomTime(t)
+		return nil
 	}
 	t := time.Unix(0, int64(ts*1e9)) // ms to ns
 	*j = CustomTime(t)
@@ -118,3 +124,53 @@ func (j CustomTime) T() time.Time {
 func (j CustomTime) Valid() bool {
 	return j.T().Unix() >= 0
 }
+
+// StringList is a list of strings persisted as a json array in a single text column
+type StringList []string
+
+func (l *StringList) Scan(value interface{}) error {
+	switch value.(type) {
+	case nil:
+		*l = StringList{}
+		return nil
+	case string:
+		return json.Unmarshal([]byte(value.(string)), l)
+	case []byte:
+		return json.Unmarshal(value.([]byte), l)
+	default:
+		return errors.New(fmt.Sprintf("unsupported type: %T", value))
+	}
+}
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(l)
+	return string(data), err
+}
+
+// Int64List is a list of integers persisted as a json array in a single text column
+type Int64List []int64
+
+func (l *Int64List) Scan(value interface{}) error {
+	switch value.(type) {
+	case nil:
+		*l = Int64List{}
+		return nil
+	case string:
+		return json.Unmarshal([]byte(value.(string)), l)
+	case []byte:
+		return json.Unmarshal(value.([]byte), l)
+	default:
+		return errors.New(fmt.Sprintf("unsupported type: %T", value))
+	}
+}
+
+func (l Int64List) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(l)
+	return string(data), err
+}
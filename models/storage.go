@@ -0,0 +1,42 @@
+package models
+
+// rough estimates of the average size of a single row (including indices) per table
+const (
+	EstimatedHeartbeatBytes       int64 = 640
+	EstimatedSummaryBytes         int64 = 96
+	EstimatedSummaryItemBytes     int64 = 128
+	EstimatedLeaderboardItemBytes int64 = 128
+)
+
+type UserStorageStats struct {
+	UserID            string `json:"user_id"`
+	Heartbeats        int64  `json:"heartbeats"`
+	Summaries         int64  `json:"summaries"`
+	SummaryItems      int64  `json:"summary_items"`
+	LeaderboardItems  int64  `json:"leaderboard_items"`
+	HeartbeatsLast7d  int64  `json:"heartbeats_last_7d"`
+	HeartbeatsLast30d int64  `json:"heartbeats_last_30d"`
+	EstimatedBytes    int64  `json:"estimated_bytes"`
+}
+
+func (s *UserStorageStats) Estimate() *UserStorageStats {
+	s.EstimatedBytes = s.Heartbeats*EstimatedHeartbeatBytes +
+		s.Summaries*EstimatedSummaryBytes +
+		s.SummaryItems*EstimatedSummaryItemBytes +
+		s.LeaderboardItems*EstimatedLeaderboardItemBytes
+	return s
+}
+
+// GrowthRate7d is the average number of new heartbeats per day within the past week
+func (s *UserStorageStats) GrowthRate7d() float64 {
+	return float64(s.HeartbeatsLast7d) / 7
+}
+
+type StorageStatsViewModel struct {
+	DatabaseBytes       int64               `json:"database_bytes"`
+	TotalEstimatedBytes int64               `json:"total_estimated_bytes"`
+	TotalHeartbeats     int64               `json:"total_heartbeats"`
+	TotalUsers          int                 `json:"total_users"`
+	TopConsumers        []*UserStorageStats `json:"top_consumers"`
+	TopGrowing          []*UserStorageStats `json:"top_growing"`
+}
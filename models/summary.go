@@ -24,6 +24,7 @@ const (
 )
 
 const UnknownSummaryKey = "unknown"
+const ArchivedSummaryKey = "archived" // time spent on archived projects, see Summary.WithoutProjects
 const DefaultProjectLabel = "default"
 
 type Summaries []*Summary
@@ -51,6 +52,7 @@ type Summary struct {
 	Entities         SummaryItems `json:"entities" gorm:"-"` // entities are not persisted, but calculated at runtime in case a project Filter is applied
 	Categories       SummaryItems `json:"categories" gorm:"-"`
 	NumHeartbeats    int          `json:"-"`
+	EditStats        EditStats    `json:"edit_stats" gorm:"embedded"`
 }
 
 type SummaryItems []*SummaryItem
@@ -70,11 +72,12 @@ type SummaryItemContainer struct {
 }
 
 type SummaryParams struct {
-	From      time.Time
-	To        time.Time
-	User      *User
-	Filters   *Filters
-	Recompute bool
+	From            time.Time
+	To              time.Time
+	User            *User
+	Filters         *Filters
+	Recompute       bool
+	IncludeArchived bool
 }
 
 func SummaryTypes() []uint8 {
@@ -89,6 +92,16 @@ func PersistedSummaryTypes() []uint8 {
 	return []uint8{SummaryProject, SummaryLanguage, SummaryEditor, SummaryOS, SummaryMachine, SummaryCategory}
 }
 
+// ParseSummaryType resolves a summary type from its entity name, e.g. "category"
+func ParseSummaryType(name string) (uint8, bool) {
+	for _, t := range SummaryTypes() {
+		if GetEntityColumn(t) == name {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
 func NewEmptySummary() *Summary {
 	return &Summary{
 		Projects:         SummaryItems{},
@@ -216,6 +229,31 @@ func (s *Summary) ApplyFilter(filter FilterElement) *Summary {
 	return s
 }
 
+// WithoutProjects returns a copy of the summary, in which all project items with one of the given keys are folded into a single
+// item with key ArchivedSummaryKey. Their time is still included in the totals, so that projects add up to the other types again.
+// The summary itself is left untouched, as it might be shared via the cache.
+func (s *Summary) WithoutProjects(projects []string) *Summary {
+	if len(projects) == 0 {
+		return s
+	}
+
+	copied := *s
+	copied.Projects = make(SummaryItems, 0, len(s.Projects))
+	var archived *SummaryItem
+	for _, item := range s.Projects {
+		if !slice.Contain[string](projects, item.Key) {
+			copied.Projects = append(copied.Projects, item)
+			continue
+		}
+		if archived == nil {
+			archived = &SummaryItem{Type: SummaryProject, Key: ArchivedSummaryKey}
+			copied.Projects = append(copied.Projects, archived)
+		}
+		archived.Total += item.Total
+	}
+	return &copied
+}
+
 /*
 Augments the summary in a way that at least one item is present for every type.
 
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const CompactSummaryTopN = 5
+
+// CompactSummary is a heavily reduced representation of a summary, intended for small clients like mobile widgets or watches
+type CompactSummary struct {
+	From             time.Time             `json:"from"`
+	To               time.Time             `json:"to"`
+	Total            int64                 `json:"total"` // seconds, rounded to full minutes
+	Text             string                `json:"text"`
+	Projects         []*CompactSummaryItem `json:"projects"`
+	Languages        []*CompactSummaryItem `json:"languages"`
+	Editors          []*CompactSummaryItem `json:"editors"`
+	OperatingSystems []*CompactSummaryItem `json:"operating_systems"`
+	Machines         []*CompactSummaryItem `json:"machines"`
+	Categories       []*CompactSummaryItem `json:"categories"`
+}
+
+type CompactSummaryItem struct {
+	Key   string `json:"key"`
+	Total int64  `json:"total"` // seconds, rounded to full minutes
+}
+
+func NewCompactSummary(s *Summary) *CompactSummary {
+	total := s.TotalTime().Round(time.Minute)
+	return &CompactSummary{
+		From:             s.FromTime.T(),
+		To:               s.ToTime.T(),
+		Total:            int64(total.Seconds()),
+		Text:             fmt.Sprintf("%dh %dm", int64(total.Hours()), int64(total.Minutes())%60),
+		Projects:         compactItems(s.Projects),
+		Languages:        compactItems(s.Languages),
+		Editors:          compactItems(s.Editors),
+		OperatingSystems: compactItems(s.OperatingSystems),
+		Machines:         compactItems(s.Machines),
+		Categories:       compactItems(s.Categories),
+	}
+}
+
+func compactItems(items SummaryItems) []*CompactSummaryItem {
+	sorted := make(SummaryItems, len(items))
+	copy(sorted, items)
+	sort.Sort(sort.Reverse(sorted))
+
+	compact := make([]*CompactSummaryItem, 0, CompactSummaryTopN)
+	for i := 0; i < len(sorted) && i < CompactSummaryTopN; i++ {
+		compact = append(compact, &CompactSummaryItem{
+			Key:   sorted[i].Key,
+			Total: int64(sorted[i].TotalFixed().Round(time.Minute).Seconds()),
+		})
+	}
+	return compact
+}
@@ -285,3 +285,79 @@ func TestSummary_ApplyFilter(t *testing.T) {
 	assert.Equal(t, key2, sut.Projects[0].Key)
 	assert.Equal(t, 20*time.Minute, sut.TotalTimeBy(SummaryProject))
 }
+
+func TestSummary_WithoutProjects(t *testing.T) {
+	sut := &Summary{
+		Projects: []*SummaryItem{
+			{Type: SummaryProject, Key: "wakapi", Total: 50 * time.Second},
+			{Type: SummaryProject, Key: "dead-project", Total: 20 * time.Second},
+			{Type: SummaryProject, Key: "another-dead-project", Total: 10 * time.Second},
+		},
+		Languages: []*SummaryItem{
+			{Type: SummaryLanguage, Key: "Go", Total: 80 * time.Second},
+		},
+	}
+
+	result := sut.WithoutProjects([]string{"dead-project", "another-dead-project"})
+	assert.Len(t, result.Projects, 2)
+	assert.Equal(t, "wakapi", result.Projects[0].Key)
+	assert.Equal(t, ArchivedSummaryKey, result.Projects[1].Key)
+	assert.Equal(t, 30*time.Second, result.Projects[1].Total)
+	assert.Equal(t, result.TotalTimeBy(SummaryLanguage), result.TotalTimeBy(SummaryProject))
+	assert.Len(t, result.Languages, 1)
+
+	// original is left untouched
+	assert.Len(t, sut.Projects, 3)
+	assert.Equal(t, "dead-project", sut.Projects[1].Key)
+
+	assert.Same(t, sut, sut.WithoutProjects(nil))
+}
+
+func TestNewCompactSummary(t *testing.T) {
+	sut := &Summary{
+		Projects: []*SummaryItem{
+			{Type: SummaryProject, Key: "p1", Total: 100},
+			{Type: SummaryProject, Key: "p2", Total: 3600},
+			{Type: SummaryProject, Key: "p3", Total: 50},
+			{Type: SummaryProject, Key: "p4", Total: 40},
+			{Type: SummaryProject, Key: "p5", Total: 30},
+			{Type: SummaryProject, Key: "p6", Total: 20},
+		},
+		Languages: []*SummaryItem{
+			{Type: SummaryLanguage, Key: "Go", Total: 3840},
+		},
+		Entities: []*SummaryItem{
+			{Type: SummaryEntity, Key: "main.go", Total: 3840},
+		},
+		Categories: []*SummaryItem{
+			{Type: SummaryCategory, Key: "coding", Total: 3000},
+			{Type: SummaryCategory, Key: "code reviewing", Total: 840},
+		},
+	}
+
+	compact := NewCompactSummary(sut)
+	assert.Equal(t, int64(3840), compact.Total)
+	assert.Equal(t, "1h 4m", compact.Text)
+	assert.Len(t, compact.Projects, CompactSummaryTopN)
+	assert.Equal(t, "p2", compact.Projects[0].Key)
+	assert.Equal(t, int64(3600), compact.Projects[0].Total)
+	assert.Equal(t, int64(120), compact.Projects[1].Total)
+	assert.Len(t, compact.Languages, 1)
+	assert.Len(t, compact.Editors, 0)
+	assert.Len(t, compact.Categories, 2)
+	assert.Equal(t, "coding", compact.Categories[0].Key)
+	assert.Equal(t, "p1", sut.Projects[0].Key) // original summary stays untouched
+}
+
+func TestParseSummaryType(t *testing.T) {
+	st, ok := ParseSummaryType("category")
+	assert.True(t, ok)
+	assert.Equal(t, SummaryCategory, st)
+
+	st, ok = ParseSummaryType("operating_system")
+	assert.True(t, ok)
+	assert.Equal(t, SummaryOS, st)
+
+	_, ok = ParseSummaryType("categories")
+	assert.False(t, ok)
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/duke-git/lancet/v2/slice"
+)
+
+const (
+	TagRuleFieldProject  = "project"
+	TagRuleFieldBranch   = "branch"
+	TagRuleFieldLanguage = "language"
+	TagRuleFieldCategory = "category"
+	TagRuleFieldMachine  = "machine"
+	TagRuleFieldEntity   = "entity" // matches all files below the given path
+)
+
+func TagRuleFields() []string {
+	return []string{TagRuleFieldProject, TagRuleFieldBranch, TagRuleFieldLanguage, TagRuleFieldCategory, TagRuleFieldMachine, TagRuleFieldEntity}
+}
+
+// TagRule adds a tag to all of the user's incoming heartbeats whose field equals the rule's value, e.g. to tag several projects with the client they're done for.
+// rules are applied at ingestion, so they don't affect previously stored heartbeats.
+type TagRule struct {
+	ID     uint   `json:"id" gorm:"primary_key"`
+	User   *User  `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID string `json:"-" gorm:"not null; index:idx_tag_rule_user"`
+	Field  string `json:"field" gorm:"not null; type:varchar(32)"`
+	Value  string `json:"value" gorm:"not null; type:varchar(255)"` // compared case-sensitively against the heartbeat's (normalized) field, a path prefix for entity rules
+	Tag    string `json:"tag" gorm:"not null; type:varchar(64)"`
+}
+
+type TagRules []*TagRule
+
+func (r *TagRule) IsValid() bool {
+	return slice.Contain(TagRuleFields(), r.Field) &&
+		r.Value != "" && utf8.RuneCountInString(r.Value) <= 255 &&
+		r.Tag != "" && r.Tag == NormalizeTag(r.Tag)
+}
+
+func (r *TagRule) Matches(h *Heartbeat) bool {
+	switch r.Field {
+	case TagRuleFieldProject:
+		return h.Project == r.Value
+	case TagRuleFieldBranch:
+		return h.Branch == r.Value
+	case TagRuleFieldLanguage:
+		return h.Language == r.Value
+	case TagRuleFieldCategory:
+		return h.Category == r.Value
+	case TagRuleFieldMachine:
+		return h.Machine == r.Value
+	case TagRuleFieldEntity:
+		return h.Type == "file" && strings.HasPrefix(strings.ReplaceAll(h.Entity, "\\", "/"), r.Value+"/")
+	default:
+		return false
+	}
+}
+
+// Apply adds the tags of all matching rules to the heartbeat, in addition to the ones sent by the client
+func (rules TagRules) Apply(h *Heartbeat) {
+	var tags []string
+	for _, r := range rules {
+		if r.Matches(h) {
+			tags = append(tags, r.Tag)
+		}
+	}
+	if len(tags) > 0 {
+		h.Tags = h.Tags.With(tags...)
+	}
+}
+
+// NormalizeTagRule trims the rule's value and tag, and brings entity paths into the same form as project override paths
+func NormalizeTagRule(r *TagRule) *TagRule {
+	r.Field = strings.ToLower(strings.TrimSpace(r.Field))
+	r.Value = strings.TrimSpace(r.Value)
+	if r.Field == TagRuleFieldEntity {
+		r.Value = NormalizeProjectOverridePath(r.Value)
+	}
+	r.Tag = NormalizeTag(r.Tag)
+	return r
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTags(t *testing.T) {
+	assert.Equal(t, Tags{"client-a", "urgent"}, NewTags("urgent", " Client-A ", "", "client-a"))
+	assert.Equal(t, Tags{"ab"}, NewTags("a,b\x00"))
+	assert.Empty(t, NewTags())
+
+	long := make([]string, 0, 50)
+	for i := 0; i < 50; i++ {
+		long = append(long, string(rune('a'+i%26))+"-some-longer-tag-"+string(rune('a'+i/26)))
+	}
+	assert.LessOrEqual(t, len(NewTags(long...).String()), TagsMaxLength)
+}
+
+func TestTags_Scan(t *testing.T) {
+	var tags Tags
+	assert.Nil(t, tags.Scan("client-a,urgent"))
+	assert.Equal(t, Tags{"client-a", "urgent"}, tags)
+	assert.Nil(t, tags.Scan([]byte("")))
+	assert.Nil(t, tags)
+	assert.Nil(t, tags.Scan(nil))
+	assert.Nil(t, tags)
+
+	value, _ := Tags{"client-a", "urgent"}.Value()
+	assert.Equal(t, "client-a,urgent", value)
+}
+
+func TestTags_UnmarshalJSON(t *testing.T) {
+	var hb1, hb2 Heartbeat
+	assert.Nil(t, json.Unmarshal([]byte(`{"tags": ["client-a", "urgent"]}`), &hb1))
+	assert.Equal(t, Tags{"client-a", "urgent"}, hb1.Tags)
+	assert.Nil(t, json.Unmarshal([]byte(`{"tags": "client-a,urgent"}`), &hb2))
+	assert.Equal(t, Tags{"client-a", "urgent"}, hb2.Tags)
+}
+
+func TestTagRule_IsValid(t *testing.T) {
+	assert.True(t, (&TagRule{Field: TagRuleFieldProject, Value: "shop-frontend", Tag: "client-a"}).IsValid())
+	assert.True(t, NormalizeTagRule(&TagRule{Field: "Entity", Value: "C:\\work\\client-a\\", Tag: "Client-A"}).IsValid())
+	assert.False(t, (&TagRule{Field: TagRuleFieldProject, Value: "shop-frontend", Tag: "Client-A"}).IsValid())
+	assert.False(t, (&TagRule{Field: TagRuleFieldProject, Tag: "client-a"}).IsValid())
+	assert.False(t, (&TagRule{Field: "editor", Value: "VSCode", Tag: "client-a"}).IsValid())
+}
+
+func TestTagRules_Apply(t *testing.T) {
+	rules := TagRules{
+		{Field: TagRuleFieldProject, Value: "shop-frontend", Tag: "client-a"},
+		{Field: TagRuleFieldProject, Value: "shop-backend", Tag: "client-a"},
+		NormalizeTagRule(&TagRule{Field: TagRuleFieldEntity, Value: "C:\\work\\client-b\\", Tag: "client-b"}),
+		{Field: TagRuleFieldBranch, Value: "hotfix", Tag: "urgent"},
+	}
+
+	hb1 := &Heartbeat{Project: "shop-frontend", Branch: "hotfix", Tags: Tags{"review"}}
+	hb2 := &Heartbeat{Project: "shop-backend"}
+	hb3 := &Heartbeat{Entity: "C:\\work\\client-b\\app\\main.go", Type: "file", Project: "app"}
+	hb4 := &Heartbeat{Entity: "C:\\work\\client-bb\\main.go", Type: "file", Project: "other"}
+
+	for _, hb := range []*Heartbeat{hb1, hb2, hb3, hb4} {
+		rules.Apply(hb)
+	}
+
+	assert.Equal(t, Tags{"client-a", "review", "urgent"}, hb1.Tags)
+	assert.Equal(t, Tags{"client-a"}, hb2.Tags)
+	assert.Equal(t, Tags{"client-b"}, hb3.Tags)
+	assert.Empty(t, hb4.Tags)
+}
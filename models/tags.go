@@ -0,0 +1,114 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	TagMaxLength     = 64  // max. length (in characters) of a single tag, longer ones are truncated
+	TagsMaxLength    = 255 // max. length of all of a heartbeat's tags, comma-joined, as stored in the database
+	tagsSeparator    = ","
+	tagsInvalidChars = ",\x00"
+)
+
+// Tags are free-form labels of a heartbeat, e.g. to slice coding time by client across several projects.
+// They are normalized to lower case, sorted and free of duplicates and stored as a single comma-separated column.
+type Tags []string
+
+// NewTags normalizes the given tags, dropping blank ones and any that would exceed the max. total length
+func NewTags(tags ...string) Tags {
+	seen := make(map[string]bool, len(tags))
+	result := make(Tags, 0, len(tags))
+	for _, t := range tags {
+		t = NormalizeTag(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		result = append(result, t)
+	}
+	sort.Strings(result)
+
+	length := -len(tagsSeparator)
+	for i, t := range result {
+		if length += len(tagsSeparator) + len(t); length > TagsMaxLength {
+			return result[:i]
+		}
+	}
+	return result
+}
+
+// NormalizeTag trims and lower-cases a single tag and strips characters that can't be stored
+func NormalizeTag(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(strings.Map(func(r rune) rune {
+		if strings.ContainsRune(tagsInvalidChars, r) || r == utf8.RuneError {
+			return -1
+		}
+		return r
+	}, tag)))
+	if utf8.RuneCountInString(tag) > TagMaxLength {
+		tag = strings.TrimSpace(string([]rune(tag)[:TagMaxLength]))
+	}
+	return tag
+}
+
+func (t Tags) Contains(tag string) bool {
+	for _, e := range t {
+		if e == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// With returns a copy of the tags, including the given ones
+func (t Tags) With(tags ...string) Tags {
+	return NewTags(append(append(make([]string, 0, len(t)+len(tags)), t...), tags...)...)
+}
+
+func (t Tags) String() string {
+	return strings.Join(t, tagsSeparator)
+}
+
+func (t Tags) Value() (driver.Value, error) {
+	return t.String(), nil
+}
+
+func (t *Tags) Scan(value interface{}) error {
+	var raw string
+	switch v := value.(type) {
+	case nil:
+	case string:
+		raw = v
+	case []byte:
+		raw = string(v)
+	default:
+		return fmt.Errorf("unsupported type for tags: %T", value)
+	}
+	if raw == "" {
+		*t = nil
+		return nil
+	}
+	*t = strings.Split(raw, tagsSeparator)
+	return nil
+}
+
+// UnmarshalJSON accepts tags either as an array or as a comma-separated string, as the latter is easier to pass by command-line clients
+func (t *Tags) UnmarshalJSON(b []byte) error {
+	var list []string
+	if err := json.Unmarshal(b, &list); err == nil {
+		*t = list
+		return nil
+	}
+	var raw string
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	*t = strings.Split(raw, tagsSeparator)
+	return nil
+}
@@ -0,0 +1,170 @@
+package models
+
+import (
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const TeamDigestTopProjects = 3
+
+const (
+	DefaultTeamWorkDays  = "monday,tuesday,wednesday,thursday,friday"
+	DefaultTeamWorkStart = "09:00"
+	DefaultTeamWorkEnd   = "17:00"
+	teamWorkTimeLayout   = "15:04"
+)
+
+// DefaultTeamDigestTemplate is used for teams without a custom template, formatted using slack's mrkdwn syntax
+const DefaultTeamDigestTemplate = `:calendar: *Weekly digest of {{ .Team }}* ({{ date .From }} – {{ date .To }})
+*{{ duration .Total }}* coded by {{ .ActiveMembers }} of {{ .Members }} members ({{ duration .WorkHours }} during work hours, {{ duration .OffHours }} off hours)
+{{- if .TopProjects }}
+
+*Top projects*
+{{- range $i, $p := .TopProjects }}
+{{ inc $i }}. {{ $p.Key }} – {{ duration $p.Total }}
+{{- end }}
+{{- end }}
+{{- if .OffHours }}
+
+*Off hours*
+{{- range .MemberHours }}{{ if .OffHours }}
+• {{ .UserID }} – {{ duration .OffHours }} of {{ duration .Total }}
+{{- end }}{{ end }}
+{{- end }}
+{{- with .MostImproved }}
+
+:rocket: Most improved: *{{ .UserID }}* ({{ duration .Previous }} → {{ duration .Total }})
+{{- end }}`
+
+// Team groups users, e.g. of a club or company, whose weekly activity is summarized in a digest posted to the team's slack channel
+type Team struct {
+	ID              uint          `json:"id" gorm:"primary_key"`
+	Name            string        `json:"name" gorm:"not null; type:varchar(191); uniqueIndex:idx_team_name"`
+	SlackWebhookUrl string        `json:"slack_webhook_url" gorm:"type:varchar(1024)"`                                            // incoming webhook of the channel to post the digest to, no digest is posted if blank
+	DigestTemplate  string        `json:"digest_template" gorm:"type:text"`                                                       // go text/template rendered with a TeamDigest, the default one is used if blank
+	WorkDays        string        `json:"work_days" gorm:"type:varchar(255); default:'monday,tuesday,wednesday,thursday,friday'"` // comma-separated week days, all time on other days counts as off hours
+	WorkStart       string        `json:"work_start" gorm:"type:varchar(5); default:'09:00'"`                                     // start of work hours as hh:mm, in each member's own time zone
+	WorkEnd         string        `json:"work_end" gorm:"type:varchar(5); default:'17:00'"`                                       // end of work hours as hh:mm, in each member's own time zone
+	Members         []*TeamMember `json:"members" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	CreatedAt       CustomTime    `json:"created_at" gorm:"default:CURRENT_TIMESTAMP" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+}
+
+type TeamMember struct {
+	ID     uint   `json:"-" gorm:"primary_key"`
+	TeamID uint   `json:"-" gorm:"not null; uniqueIndex:idx_team_member"`
+	User   *User  `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID string `json:"user_id" gorm:"not null; uniqueIndex:idx_team_member"`
+}
+
+// TeamDigest is what a team's digest template is rendered with
+type TeamDigest struct {
+	Team          string
+	From          time.Time
+	To            time.Time // inclusive
+	Total         time.Duration
+	Members       int
+	ActiveMembers int // members who coded at all within the digest's week
+	TopProjects   []*TeamDigestProject
+	MostImproved  *TeamDigestImprovement   // nil if no member coded more than in the week before
+	WorkHours     time.Duration            // coded within the team's work hours, in each member's time zone
+	OffHours      time.Duration            // coded outside the team's work hours
+	MemberHours   []*TeamDigestMemberHours // active members, the one with the most off hours first
+}
+
+type TeamDigestProject struct {
+	Key   string
+	Total time.Duration
+}
+
+type TeamDigestMemberHours struct {
+	UserID    string
+	Total     time.Duration
+	WorkHours time.Duration
+	OffHours  time.Duration
+}
+
+type TeamDigestImprovement struct {
+	UserID   string
+	Total    time.Duration
+	Previous time.Duration
+}
+
+func (t *Team) IsValid() bool {
+	name := strings.TrimSpace(t.Name)
+	if name == "" || utf8.RuneCountInString(name) > 191 || len(t.SlackWebhookUrl) > 1024 {
+		return false
+	}
+	if t.SlackWebhookUrl != "" && !strings.HasPrefix(t.SlackWebhookUrl, "https://") {
+		return false
+	}
+	if strings.TrimSpace(t.WorkDays) != "" && len(t.GetWorkDays()) != len(strings.Split(t.WorkDays, ",")) {
+		return false
+	}
+	start, errStart := time.Parse(teamWorkTimeLayout, t.WorkStart)
+	end, errEnd := time.Parse(teamWorkTimeLayout, t.WorkEnd)
+	return errStart == nil && errEnd == nil && start.Before(end)
+}
+
+// GetWorkDays returns the team's work days, unknown day names are skipped
+func (t *Team) GetWorkDays() []time.Weekday {
+	days := make([]time.Weekday, 0, 7)
+	for _, day := range strings.Split(t.WorkDays, ",") {
+		if weekday, ok := parseWeekday(day); ok {
+			days = append(days, weekday)
+		}
+	}
+	return days
+}
+
+// WorkTime returns how much of the interval [from, to) falls into the team's work hours in the given time zone
+func (t *Team) WorkTime(from, to time.Time, tz *time.Location) time.Duration {
+	start, errStart := time.Parse(teamWorkTimeLayout, t.WorkStart)
+	end, errEnd := time.Parse(teamWorkTimeLayout, t.WorkEnd)
+	if errStart != nil || errEnd != nil || !to.After(from) {
+		return 0
+	}
+
+	workDays := t.GetWorkDays()
+	from, to = from.In(tz), to.In(tz)
+
+	var total time.Duration
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, tz); day.Before(to); day = day.AddDate(0, 0, 1) {
+		if !slices.Contains(workDays, day.Weekday()) {
+			continue
+		}
+		// constructed by wall clock rather than by offset from midnight to be correct on days with a dst change
+		windowStart := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, tz)
+		windowEnd := time.Date(day.Year(), day.Month(), day.Day(), end.Hour(), end.Minute(), 0, 0, tz)
+		if windowStart.Before(from) {
+			windowStart = from
+		}
+		if windowEnd.After(to) {
+			windowEnd = to
+		}
+		if windowEnd.After(windowStart) {
+			total += windowEnd.Sub(windowStart)
+		}
+	}
+	return total
+}
+
+func (t *Team) HasMember(userId string) bool {
+	for _, m := range t.Members {
+		if m.UserID == userId {
+			return true
+		}
+	}
+	return false
+}
+
+func parseWeekday(s string) (time.Weekday, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.ToLower(d.String()) == s {
+			return d, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTeam_WorkTime(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	team := &Team{WorkDays: "monday, Tuesday,wednesday,thursday,friday", WorkStart: "09:00", WorkEnd: "17:00"}
+
+	// friday 16:00 until monday 10:00 in berlin
+	from, to := time.Date(2024, 6, 7, 16, 0, 0, 0, berlin), time.Date(2024, 6, 10, 10, 0, 0, 0, berlin)
+	assert.Equal(t, 2*time.Hour, team.WorkTime(from, to, berlin))
+	assert.Equal(t, 8*time.Hour, team.WorkTime(from, to, time.FixedZone("", 12*3600))) // saturday 02:00 until monday 20:00
+	assert.Equal(t, time.Duration(0), team.WorkTime(to, from, berlin))
+
+	// day of the switch to summer time
+	team.WorkDays = "sunday"
+	team.WorkStart, team.WorkEnd = "01:00", "04:00"
+	day := time.Date(2024, 3, 31, 0, 0, 0, 0, berlin)
+	assert.Equal(t, 2*time.Hour, team.WorkTime(day, day.AddDate(0, 0, 1), berlin))
+}
+
+func TestTeam_IsValid_WorkHours(t *testing.T) {
+	team := &Team{Name: "Team Rocket", WorkDays: DefaultTeamWorkDays, WorkStart: DefaultTeamWorkStart, WorkEnd: DefaultTeamWorkEnd}
+	assert.True(t, team.IsValid())
+	assert.Equal(t, []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}, team.GetWorkDays())
+
+	for _, invalid := range []Team{
+		{Name: "Team Rocket", WorkDays: "monday,mon", WorkStart: "09:00", WorkEnd: "17:00"},
+		{Name: "Team Rocket", WorkDays: "monday", WorkStart: "9am", WorkEnd: "17:00"},
+		{Name: "Team Rocket", WorkDays: "monday", WorkStart: "17:00", WorkEnd: "17:00"},
+	} {
+		assert.False(t, invalid.IsValid())
+	}
+}
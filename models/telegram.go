@@ -0,0 +1,45 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// ExternalIdProviderTelegram is the provider of the external ids holding the id of the telegram chat a user linked to their account
+const ExternalIdProviderTelegram = "telegram"
+
+// https://core.telegram.org/bots/api#update, only the fields needed for handling commands
+
+type TelegramUpdate struct {
+	UpdateId int64            `json:"update_id"`
+	Message  *TelegramMessage `json:"message"`
+}
+
+type TelegramMessage struct {
+	MessageId int64         `json:"message_id"`
+	Chat      *TelegramChat `json:"chat"`
+	Text      string        `json:"text"`
+}
+
+type TelegramChat struct {
+	Id   int64  `json:"id"`
+	Type string `json:"type"`
+}
+
+// TelegramLink is a pending link between a user and the telegram chat the code is sent from, only kept in memory until it expires
+type TelegramLink struct {
+	Code      string    `json:"code"`
+	Url       string    `json:"url,omitempty"` // opens a chat with the bot and sends the code, if the bot's username is known
+	ExpiresAt time.Time `json:"expires_at"`
+	UserID    string    `json:"-"`
+}
+
+// Command splits the message text into a bot command and its argument, e.g. "/start abc" or "/today@hackatime_bot"
+func (m *TelegramMessage) Command() (string, string) {
+	if !strings.HasPrefix(m.Text, "/") {
+		return "", ""
+	}
+	command, arg, _ := strings.Cut(strings.TrimSpace(m.Text), " ")
+	command, _, _ = strings.Cut(command, "@")
+	return strings.ToLower(command), strings.TrimSpace(arg)
+}
@@ -0,0 +1,89 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	TimeEntryTypeManual     = "manual"     // time not captured by any plugin, e.g. pair programming, counted towards summaries
+	TimeEntryTypeAnnotation = "annotation" // only a note on a period of time, which doesn't add any time
+	TimeEntryTypeExternal   = "external"   // time measured by a third-party integration, e.g. a meeting tracker, pushed via the external durations api and counted towards summaries
+
+	TimeEntryMaxDuration             = 24 * time.Hour
+	TimeEntryDefaultExternalCategory = "external"
+)
+
+// TimeEntry is a period of time entered manually by the user, either to add time or only to annotate what they did
+type TimeEntry struct {
+	ID         uint       `json:"id" gorm:"primary_key"`
+	User       *User      `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID     string     `json:"-" gorm:"not null; index:idx_time_entry_user_from; index:idx_time_entry_user_external"`
+	Type       string     `json:"type" gorm:"not null; type:varchar(16)"`
+	ExternalID string     `json:"external_id,omitempty" gorm:"type:varchar(255); index:idx_time_entry_user_external"` // the integration's own id of an external entry, used to update it when pushed again
+	Project    string     `json:"project" gorm:"type:varchar(191)"`
+	Branch     string     `json:"branch,omitempty" gorm:"type:varchar(255)"`
+	Language   string     `json:"language" gorm:"type:varchar(255)"`
+	Category   string     `json:"category" gorm:"type:varchar(255)"`
+	Note       string     `json:"note" gorm:"type:varchar(255)"`
+	FromTime   CustomTime `json:"from" gorm:"not null; timeScale:3; index:idx_time_entry_user_from" swaggertype:"primitive,number"` // unix timestamp, like for heartbeats
+	ToTime     CustomTime `json:"to" gorm:"not null; timeScale:3" swaggertype:"primitive,number"`
+	CreatedAt  CustomTime `json:"created_at" gorm:"default:CURRENT_TIMESTAMP" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+}
+
+func (e *TimeEntry) IsValid() bool {
+	return (e.Type == TimeEntryTypeManual || e.Type == TimeEntryTypeAnnotation || e.Type == TimeEntryTypeExternal) &&
+		e.ToTime.T().After(e.FromTime.T()) &&
+		e.ToTime.T().Sub(e.FromTime.T()) <= TimeEntryMaxDuration &&
+		utf8.RuneCountInString(e.Project) <= HeartbeatMaxProjectLength &&
+		utf8.RuneCountInString(e.Branch) <= 255 &&
+		utf8.RuneCountInString(e.Language) <= 255 &&
+		utf8.RuneCountInString(e.Category) <= 255 &&
+		utf8.RuneCountInString(e.Note) <= 255 &&
+		utf8.RuneCountInString(e.ExternalID) <= 255 &&
+		(e.Type != TimeEntryTypeAnnotation || strings.TrimSpace(e.Note) != "") &&
+		(e.Type != TimeEntryTypeExternal || strings.TrimSpace(e.ExternalID) != "")
+}
+
+// CountsTowardsSummaries tells whether the entry adds time, as opposed to annotations
+func (e *TimeEntry) CountsTowardsSummaries() bool {
+	return e.Type == TimeEntryTypeManual || e.Type == TimeEntryTypeExternal
+}
+
+// Duration converts a manual or external entry into a duration, cut to the given interval, to be merged with those derived from heartbeats
+// returns nil for annotations and entries outside the interval
+func (e *TimeEntry) Duration(from, to time.Time) *Duration {
+	if !e.CountsTowardsSummaries() {
+		return nil
+	}
+
+	start, end := e.FromTime.T(), e.ToTime.T()
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	if !end.After(start) {
+		return nil
+	}
+
+	source := HeartbeatSourceManual
+	if e.Type == TimeEntryTypeExternal {
+		source = HeartbeatSourceExternal
+	}
+
+	d := &Duration{
+		UserID:   e.UserID,
+		Time:     CustomTime(start),
+		Duration: end.Sub(start),
+		Project:  e.Project,
+		Branch:   e.Branch,
+		Language: e.Language,
+		Category: e.Category,
+		Entity:   e.Note,
+		Source:   source,
+	}
+	return d.Hashed()
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeEntry_IsValid(t *testing.T) {
+	t0 := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	entry := func(typ, note string, d time.Duration) *TimeEntry {
+		return &TimeEntry{Type: typ, Note: note, FromTime: CustomTime(t0), ToTime: CustomTime(t0.Add(d))}
+	}
+
+	assert.True(t, entry(TimeEntryTypeManual, "", time.Hour).IsValid())
+	assert.True(t, entry(TimeEntryTypeAnnotation, "refactoring", time.Hour).IsValid())
+	assert.False(t, entry(TimeEntryTypeAnnotation, " ", time.Hour).IsValid())
+	assert.False(t, entry("unknown", "", time.Hour).IsValid())
+	assert.False(t, entry(TimeEntryTypeManual, "", -time.Hour).IsValid())
+	assert.False(t, entry(TimeEntryTypeManual, "", 25*time.Hour).IsValid())
+	assert.False(t, entry(TimeEntryTypeExternal, "standup", time.Hour).IsValid())
+
+	external := entry(TimeEntryTypeExternal, "standup", time.Hour)
+	external.ExternalID = "meeting-42"
+	assert.True(t, external.IsValid())
+}
+
+func TestTimeEntry_Duration(t *testing.T) {
+	t0 := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	entry := &TimeEntry{
+		UserID:   "user1",
+		Type:     TimeEntryTypeManual,
+		Project:  "wakapi",
+		FromTime: CustomTime(t0),
+		ToTime:   CustomTime(t0.Add(2 * time.Hour)),
+	}
+
+	d := entry.Duration(t0.Add(-time.Hour), t0.Add(time.Hour))
+	assert.NotNil(t, d)
+	assert.Equal(t, time.Hour, d.Duration)
+	assert.Equal(t, "wakapi", d.Project)
+	assert.Equal(t, HeartbeatSourceManual, d.Source)
+	assert.True(t, d.Time.T().Equal(t0))
+
+	assert.Nil(t, entry.Duration(t0.Add(3*time.Hour), t0.Add(4*time.Hour)))
+
+	entry.Type = TimeEntryTypeExternal
+	assert.Equal(t, HeartbeatSourceExternal, entry.Duration(t0, t0.Add(time.Hour)).Source)
+
+	entry.Type = TimeEntryTypeAnnotation
+	assert.Nil(t, entry.Duration(t0, t0.Add(time.Hour)))
+}
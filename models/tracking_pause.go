@@ -0,0 +1,64 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const TrackingPauseMaxDuration = 366 * 24 * time.Hour
+
+// TrackingPause is a period, e.g. a vacation, during which all of the user's incoming heartbeats are discarded
+// days within a pause don't break the user's streak either, so there's no reason to backfill them
+type TrackingPause struct {
+	ID        uint       `json:"id" gorm:"primary_key"`
+	User      *User      `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID    string     `json:"-" gorm:"not null; index:idx_tracking_pause_user"`
+	FromTime  CustomTime `json:"from" gorm:"not null; timeScale:3" swaggertype:"string" format:"date" example:"2006-01-02T15:04:05Z"`
+	ToTime    CustomTime `json:"to" gorm:"not null; timeScale:3" swaggertype:"string" format:"date" example:"2006-01-02T15:04:05Z"` // exclusive
+	Note      string     `json:"note" gorm:"type:varchar(255)"`
+	CreatedAt CustomTime `json:"created_at" gorm:"default:CURRENT_TIMESTAMP" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+}
+
+// NewTrackingPause creates a pause from the beginning of the first to the end of the last of the given days, both inclusive
+func NewTrackingPause(firstDay, lastDay time.Time, note string) *TrackingPause {
+	return &TrackingPause{
+		FromTime: CustomTime(firstDay),
+		ToTime:   CustomTime(lastDay.AddDate(0, 0, 1)),
+		Note:     strings.TrimSpace(note),
+	}
+}
+
+func (p *TrackingPause) IsValid() bool {
+	return p.ToTime.T().After(p.FromTime.T()) &&
+		p.ToTime.T().Sub(p.FromTime.T()) <= TrackingPauseMaxDuration &&
+		utf8.RuneCountInString(p.Note) <= 255
+}
+
+// Contains reports whether the given point in time lies within the pause
+func (p *TrackingPause) Contains(t time.Time) bool {
+	return !t.Before(p.FromTime.T()) && t.Before(p.ToTime.T())
+}
+
+// Overlaps reports whether the pause intersects with the given interval
+func (p *TrackingPause) Overlaps(from, to time.Time) bool {
+	return p.FromTime.T().Before(to) && p.ToTime.T().After(from)
+}
+
+func (p *TrackingPause) IsActive() bool {
+	return p.Contains(time.Now())
+}
+
+func (p *TrackingPause) IsUpcoming() bool {
+	return p.FromTime.T().After(time.Now())
+}
+
+// IsPausedWithin reports whether any of the given pauses intersects with the given interval
+func IsPausedWithin(pauses []*TrackingPause, from, to time.Time) bool {
+	for _, p := range pauses {
+		if p.Overlaps(from, to) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,24 +1,51 @@
 package models
 
 import (
+	"crypto/hmac"
 	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"regexp"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/dchest/captcha"
 	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/i18n"
 	"github.com/hackclub/hackatime/utils"
+	"gorm.io/gorm"
 )
 
 const (
 	DefaultHeartbeatsTimeout = 2 * time.Minute
 	MinHeartbeatsTimeout     = 30 * time.Second
 	MaxHeartbeatsTimeout     = 5 * time.Minute
+	MaxInactivityAlertHours  = 7 * 24
+	MaxIngestionBlocklist    = 50
 )
 
+const (
+	DurationFormatDefault = ""        // e.g. "2 hrs 15 mins", like wakatime
+	DurationFormatDecimal = "decimal" // e.g. "2.25 hrs"
+	DurationFormatClock   = "clock"   // e.g. "2:15"
+)
+
+// how to count time, during which several machines sent heartbeats in parallel
+const (
+	OverlapStrategyMostRecent = "prefer-most-recent-machine" // time belongs to the machine, which sent the latest heartbeat (default)
+	OverlapStrategyMax        = "max"                        // time is counted once, for the machine with the longest duration
+	OverlapStrategySum        = "sum"                        // time is counted for every machine
+)
+
+var OverlapStrategies = []string{OverlapStrategyMostRecent, OverlapStrategyMax, OverlapStrategySum}
+
+// DurationRoundingOptions are the allowed values (in minutes) to round displayed durations to, 0 for no rounding
+var DurationRoundingOptions = []int{0, 5, 15}
+
 func init() {
 	mailRegex = regexp.MustCompile(MailPattern)
 }
@@ -26,8 +53,11 @@ func init() {
 type User struct {
 	ID                     string      `json:"id" gorm:"primary_key"`
 	Name                   string      `json:"name"`
-	ApiKey                 string      `json:"api_key" gorm:"unique; default:NULL"`
+	ApiKey                 string      `json:"api_key" gorm:"unique; default:NULL"`             // only persisted if stored in plain text, otherwise recovered from its sealed form, if possible
+	ApiKeyPrefix           string      `json:"-" gorm:"index:idx_user_api_key_prefix; size:16"` // to look up users by their sealed api key
+	ApiKeySealed           string      `json:"-" gorm:"size:255"`                               // hashed or encrypted api key, see security.api_key_storage
 	Email                  string      `json:"email" gorm:"index:idx_user_email; size:255"`
+	EmailVerified          bool        `json:"-" gorm:"default:false; type:bool"`
 	Location               string      `json:"location"`
 	Password               string      `json:"-"`
 	CreatedAt              CustomTime  `gorm:"default:CURRENT_TIMESTAMP" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
@@ -52,11 +82,57 @@ type User struct {
 	InvitedBy              string      `json:"-"`
 	ExcludeUnknownProjects bool        `json:"-"`
 	HeartbeatsTimeoutSec   int         `json:"-" gorm:"default:120"` // https://github.com/muety/wakapi/issues/156
+	RequireMachineApproval bool        `json:"-" gorm:"default:false; type:bool"`
+	TrackLocation          bool        `json:"-" gorm:"default:true; type:bool"` // store the country each machine codes from, if the instance has a geoip database
+	UnixEntitySeparators   bool        `json:"-" gorm:"default:false; type:bool"`
+	ScrubEntityHomeDirs    bool        `json:"-" gorm:"default:false; type:bool"`
+	RelativeEntityPaths    bool        `json:"-" gorm:"default:false; type:bool"`
+	HideProjectNames       bool        `json:"-" gorm:"default:false; type:bool"` // replace project names in wakatime-compatible responses, e.g. for third-party dashboards, by anonymized ones
+	ArchivedProjects       StringList  `json:"-" gorm:"type:text"`
+	ClockSkewEvents        int         `json:"-" gorm:"default:0"`
+	LastClockSkewAt        *CustomTime `json:"-" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+	SimpleToken            string      `json:"-" gorm:"index:idx_user_simple_token"` // read-only token for simple, url-authenticated endpoints
+	FirstDayOfWeek         string      `json:"-" gorm:"default:monday"`
+	Deactivated            bool        `json:"-" gorm:"default:false; type:bool"`                                      // e.g. deprovisioned via scim, user can't log in anymore
+	IsServiceAccount       bool        `json:"-" gorm:"default:false; type:bool"`                                      // for integrations, only authenticates via api key and is excluded from leaderboards and mails
+	InvitePending          bool        `json:"-" gorm:"default:false; type:bool"`                                      // bulk-invited by an admin, the pre-issued api key only works once the invite link was used to set a password
+	SuspendedAt            *CustomTime `json:"-" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"` // set by an admin, blocks logins and heartbeats, but keeps all data
+	SuspensionReason       string      `json:"-" gorm:"type:varchar(255)"`
+	InactivityAlertHours   int         `json:"-" gorm:"default:0"` // notify once no heartbeats were received for this many hours on work days, 0 to disable
+	LastInactivityAlertAt  *CustomTime `json:"-" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+	PublicProfile          bool        `json:"-" gorm:"default:false; type:bool"` // profile page under /@{username}
+	ProfileLanguages       bool        `json:"-" gorm:"default:true; type:bool"`
+	ProfileProjects        bool        `json:"-" gorm:"default:true; type:bool"`
+	ProfileActivity        bool        `json:"-" gorm:"default:true; type:bool"`
+	IngestionBlocklist     StringList  `json:"-" gorm:"type:text"` // case-insensitive regexes, heartbeats whose project or entity match are dropped
+	Bio                    string      `json:"-" gorm:"type:varchar(255)"`
+	ProfileLocation        string      `json:"-" gorm:"type:varchar(100)"` // free text, unlike location, which holds the time zone
+	AvatarSource           string      `json:"-" gorm:"type:varchar(16)"`  // empty for the server's avatar url template, gravatar or upload
+	AvatarUpdatedAt        *CustomTime `json:"-" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+	ScrapbookEnabled       bool        `json:"-" gorm:"default:false; type:bool"`                                      // post completed coding sessions to scrapbook
+	ScrapbookTemplate      string      `json:"-" gorm:"type:varchar(1024)"`                                            // empty for the server's default template
+	LastScrapbookPostAt    *CustomTime `json:"-" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"` // end of the last session that was handled
+	DurationRoundingMin    int         `json:"-" gorm:"default:0"`                                                     // round durations in text fields, reports and badges to the nearest this many minutes
+	DurationFormat         string      `json:"-" gorm:"type:varchar(16)"`                                              // how to display durations in text fields, reports and badges, see DurationFormat*
+	Locale                 string      `json:"-" gorm:"type:varchar(16)"`                                              // language of generated texts like durations and mails, empty for the server's default
+	OverlapStrategy        string      `json:"-" gorm:"type:varchar(32)"`                                              // how to count overlapping durations from different machines, empty for the default, see OverlapStrategy*
 }
 
 type Login struct {
-	Username string `schema:"username"`
-	Password string `schema:"password"`
+	Username    string `schema:"username"`
+	Password    string `schema:"password"`
+	CaptchaId   string `schema:"captcha_id"`
+	Captcha     string `schema:"captcha"`
+	AcceptLegal bool   `schema:"accept_legal"`
+}
+
+// LoginAttempt describes the outcome of a login attempt and is published as an audit event
+type LoginAttempt struct {
+	Username  string // as entered by the client
+	UserID    string // empty, if no such user exists
+	Ip        string
+	Success   bool
+	LockedFor time.Duration // lockout imposed as a consequence of this attempt
 }
 
 type Signup struct {
@@ -69,6 +145,7 @@ type Signup struct {
 	CaptchaId      string `schema:"captcha_id"`
 	Captcha        string `schema:"captcha"`
 	InviteCode     string `schema:"invite_code"`
+	AcceptLegal    bool   `schema:"accept_legal"`
 	InvitedBy      string `schema:"-"`
 }
 
@@ -106,6 +183,17 @@ type CountByUser struct {
 	Count int64
 }
 
+// AfterFind recovers the plain api key from its encrypted form, hashed ones remain blank
+func (u *User) AfterFind(tx *gorm.DB) error {
+	if u.ApiKey != "" || u.ApiKeySealed == "" {
+		return nil
+	}
+	if store := conf.Get().Security.ApiKeyStore(); store != nil {
+		u.ApiKey, _ = store.Open(u.ApiKeySealed)
+	}
+	return nil
+}
+
 func (u *User) Identity() string {
 	return u.ID
 }
@@ -128,7 +216,21 @@ func (u *User) TZOffset() time.Duration {
 	return time.Duration(offset * int(time.Second))
 }
 
+// AvatarURL returns the url of the user's avatar, either an uploaded one, their gravatar or the one generated from the given template
 func (u *User) AvatarURL(urlTemplate string) string {
+	switch u.AvatarSource {
+	case AvatarSourceUpload:
+		var version int64
+		if u.AvatarUpdatedAt != nil {
+			version = u.AvatarUpdatedAt.T().Unix()
+		}
+		return fmt.Sprintf("api/avatar/uploads/%s?v=%d", url.PathEscape(u.ID), version)
+	case AvatarSourceGravatar:
+		if u.Email != "" {
+			return fmt.Sprintf("https://www.gravatar.com/avatar/%x?d=identicon", md5.Sum([]byte(strings.ToLower(strings.TrimSpace(u.Email)))))
+		}
+	}
+
 	urlTemplate = strings.ReplaceAll(urlTemplate, "{username}", u.ID)
 	urlTemplate = strings.ReplaceAll(urlTemplate, "{email}", u.Email)
 	if strings.Contains(urlTemplate, "{username_hash}") {
@@ -140,6 +242,38 @@ func (u *User) AvatarURL(urlTemplate string) string {
 	return urlTemplate
 }
 
+// DisplayName returns the user's chosen display name and falls back to the username
+func (u *User) DisplayName() string {
+	if name := strings.TrimSpace(u.Name); name != "" {
+		return name
+	}
+	return u.ID
+}
+
+// WeekStart returns the day weekly intervals begin on for the user, defaults to monday
+func (u *User) WeekStart() time.Weekday {
+	return utils.ParseWeekday(u.FirstDayOfWeek)
+}
+
+// Lang returns the locale to generate texts for the user in, defaults to the server's default locale
+func (u *User) Lang() string {
+	if u.Locale != "" {
+		return u.Locale
+	}
+	if cfg := conf.Get(); cfg != nil && cfg.App.DefaultLocale != "" {
+		return cfg.App.DefaultLocale
+	}
+	return i18n.DefaultLocale
+}
+
+// RoundDuration rounds the given duration for display, according to the user's preference
+func (u *User) RoundDuration(d time.Duration) time.Duration {
+	if u.DurationRoundingMin > 0 {
+		return d.Round(time.Duration(u.DurationRoundingMin) * time.Minute)
+	}
+	return d
+}
+
 func (u *User) HeartbeatsTimeout() time.Duration {
 	if u.HeartbeatsTimeoutSec > 0 {
 		return time.Duration(u.HeartbeatsTimeoutSec) * time.Second
@@ -147,6 +281,13 @@ func (u *User) HeartbeatsTimeout() time.Duration {
 	return DefaultHeartbeatsTimeout
 }
 
+func (u *User) OverlapResolution() string {
+	if u.OverlapStrategy == "" {
+		return OverlapStrategyMostRecent
+	}
+	return u.OverlapStrategy
+}
+
 // WakaTimeURL returns the user's effective WakaTime URL, i.e. a custom one (which could also point to another Wakapi instance) or fallback if not specified otherwise.
 func (u *User) WakaTimeURL(fallback string) string {
 	if u.WakatimeApiUrl != "" {
@@ -160,6 +301,28 @@ func (u *User) HasActiveSubscription() bool {
 	return conf.Get().Subscriptions.Enabled && u.HasActiveSubscriptionStrict()
 }
 
+// HasTrustedEmail returns true if notifications and reports may be sent to the user's e-mail address, which requires it to be verified, if enforced by the server
+func (u *User) HasTrustedEmail() bool {
+	return !u.IsServiceAccount && !u.Deactivated && !u.IsSuspended() && u.Email != "" && (u.EmailVerified || !conf.Get().Security.RequireEmailVerification)
+}
+
+// HasPublicProfile returns whether the user's profile page may be shown to anyone
+func (u *User) HasPublicProfile() bool {
+	return u.PublicProfile && !u.IsServiceAccount && !u.Deactivated && !u.IsSuspended()
+}
+
+func (u *User) IsSuspended() bool {
+	return u.SuspendedAt != nil
+}
+
+// SuspensionMessage explains to the user (or their clients) why requests are rejected
+func (u *User) SuspensionMessage() string {
+	if u.SuspensionReason == "" {
+		return "your account was suspended by an administrator"
+	}
+	return fmt.Sprintf("your account was suspended by an administrator (%s)", u.SuspensionReason)
+}
+
 func (u *User) HasActiveSubscriptionStrict() bool {
 	return u.SubscribedUntil != nil && u.SubscribedUntil.T().After(time.Now())
 }
@@ -189,6 +352,59 @@ func (u *User) MinDataAge() time.Time {
 	return time.Now().AddDate(0, -retentionMonths, 0)
 }
 
+// AnonymizedProjectName returns a stable identifier for the project, which doesn't reveal its name, e.g. "project-3f2a9c1e".
+// it is keyed with the server's password salt, so that names can't be guessed by hashing candidates.
+func (u *User) AnonymizedProjectName(project string) string {
+	if project == "" || project == UnknownSummaryKey {
+		return project
+	}
+	var salt string
+	if cfg := conf.Get(); cfg != nil {
+		salt = cfg.Security.PasswordSalt
+	}
+	mac := hmac.New(sha256.New, []byte(salt))
+	mac.Write([]byte(u.ID + "/" + project))
+	return "project-" + hex.EncodeToString(mac.Sum(nil))[:8]
+}
+
+// DisplayedProjectName returns the project's name as to be shown to wakatime-compatible clients, which respects the user's preference to hide them
+func (u *User) DisplayedProjectName(project string) string {
+	if u != nil && u.HideProjectNames {
+		return u.AnonymizedProjectName(project)
+	}
+	return project
+}
+
+// ResolveProjectName maps a project name as shown to wakatime-compatible clients back to the actual one among the given projects.
+// if project names are hidden, actual names are not accepted, so that clients can't probe for them.
+func (u *User) ResolveProjectName(displayed string, projects []string) (string, bool) {
+	if u == nil || !u.HideProjectNames {
+		return displayed, true
+	}
+	for _, p := range projects {
+		if u.AnonymizedProjectName(p) == displayed {
+			return p, true
+		}
+	}
+	return "", false
+}
+
+func (u *User) IsProjectArchived(project string) bool {
+	return slices.Contains(u.ArchivedProjects, project)
+}
+
+func (u *User) ArchiveProject(project string) {
+	if project != "" && !u.IsProjectArchived(project) {
+		u.ArchivedProjects = append(u.ArchivedProjects, project)
+	}
+}
+
+func (u *User) UnarchiveProject(project string) {
+	u.ArchivedProjects = slices.DeleteFunc(u.ArchivedProjects, func(p string) bool {
+		return p == project
+	})
+}
+
 func (u *User) AnyDataShared() bool {
 	return u.ShareDataMaxDays != 0 && (u.ShareEditors || u.ShareLanguages || u.ShareProjects || u.ShareOSs || u.ShareMachines || u.ShareLabels)
 }
@@ -248,7 +464,7 @@ func (r *UserDataUpdate) IsValid() bool {
 }
 
 func ValidateUsername(username string) bool {
-	return len(username) >= 1 && username != "current" && !strings.Contains(username, " ")
+	return len(username) >= 1 && username != "current" && !strings.ContainsAny(username, " "+ExternalUserRefSeparator)
 }
 
 func ValidatePassword(password string) bool {
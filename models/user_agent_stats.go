@@ -0,0 +1,15 @@
+package models
+
+type UserAgentStats struct {
+	UserAgent       string
+	Editor          string
+	OperatingSystem string
+	First           CustomTime
+	Last            CustomTime
+}
+
+type MachineStats struct {
+	Machine string
+	First   CustomTime
+	Last    CustomTime
+}
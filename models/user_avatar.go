@@ -0,0 +1,34 @@
+package models
+
+import "net/http"
+
+const (
+	AvatarSourceDefault  = ""
+	AvatarSourceGravatar = "gravatar"
+	AvatarSourceUpload   = "upload"
+	MaxAvatarBytes       = 256 * 1024
+)
+
+var avatarContentTypes = map[string]bool{"image/png": true, "image/jpeg": true, "image/gif": true, "image/webp": true}
+
+// UserAvatar is an image uploaded by the user to be shown instead of the generated avatar
+type UserAvatar struct {
+	UserID      string     `gorm:"primary_key"`
+	User        *User      `gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Data        []byte     `gorm:"not null"`
+	ContentType string     `gorm:"type:varchar(32)"`
+	UpdatedAt   CustomTime `gorm:"default:CURRENT_TIMESTAMP" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+}
+
+// DetectAvatarContentType sniffs the image format of an uploaded avatar and returns false for anything but common raster images (notably svg, which could carry scripts)
+func DetectAvatarContentType(data []byte) (string, bool) {
+	if len(data) == 0 || len(data) > MaxAvatarBytes {
+		return "", false
+	}
+	contentType := http.DetectContentType(data)
+	return contentType, avatarContentTypes[contentType]
+}
+
+func ValidateAvatarSource(source string) bool {
+	return source == AvatarSourceDefault || source == AvatarSourceGravatar || source == AvatarSourceUpload
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+const ExternalUserRefSeparator = ":"
+
+var externalIdProviderRegex = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// UserExternalId maps an identifier of some external system, like a Slack member id or a student number, to a user.
+// Users have at most one id per provider. Integrations can address users as "{provider}:{external_id}" in place of their username.
+type UserExternalId struct {
+	ID         uint       `json:"-" gorm:"primary_key"`
+	UserID     string     `json:"-" gorm:"not null; uniqueIndex:idx_user_external_id_user_provider"`
+	User       *User      `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Provider   string     `json:"provider" gorm:"not null; size:32; uniqueIndex:idx_user_external_id_user_provider; uniqueIndex:idx_user_external_id_provider_ext"`
+	ExternalId string     `json:"external_id" gorm:"not null; size:191; uniqueIndex:idx_user_external_id_provider_ext"`
+	CreatedAt  CustomTime `json:"created_at" gorm:"default:CURRENT_TIMESTAMP" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+}
+
+func ValidateExternalIdProvider(provider string) bool {
+	return externalIdProviderRegex.MatchString(provider)
+}
+
+func ValidateExternalId(externalId string) bool {
+	return len(externalId) >= 1 && len(externalId) <= 191 && !strings.ContainsAny(externalId, " /")
+}
+
+// ParseExternalUserRef splits a user reference like "slack:U012AB3CD" into provider and external id
+func ParseExternalUserRef(ref string) (string, string, bool) {
+	provider, externalId, found := strings.Cut(ref, ExternalUserRefSeparator)
+	if !found || !ValidateExternalIdProvider(provider) || !ValidateExternalId(externalId) {
+		return "", "", false
+	}
+	return provider, externalId, true
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"encoding/json"
+	"regexp"
+	"unicode/utf8"
+)
+
+const (
+	MaxPreferenceKeyLength     = 128
+	MaxPreferenceValueLength   = 4096
+	MaxPreferencesPerNamespace = 100
+)
+
+var preferenceNamespacePattern = regexp.MustCompile(`^[a-z0-9_-]{1,32}$`)
+
+// UserPreference is a small, client-defined setting (e.g. of the vscode plugin, the web ui or the cli), stored on the server to sync it across devices.
+// Preferences are namespaced by client, so clients don't interfere with each other. Values are arbitrary json.
+type UserPreference struct {
+	ID        uint   `json:"-" gorm:"primary_key"`
+	User      *User  `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	UserID    string `json:"-" gorm:"not null; uniqueIndex:idx_user_preference_user_namespace_key"`
+	Namespace string `json:"namespace" gorm:"not null; type:varchar(32); uniqueIndex:idx_user_preference_user_namespace_key"`
+	Key       string `json:"key" gorm:"column:pref_key; not null; type:varchar(128); uniqueIndex:idx_user_preference_user_namespace_key"` // key is a reserved word in mysql
+	Value     string `json:"value" gorm:"type:text"`                                                                                      // json-encoded
+}
+
+func IsValidPreferenceNamespace(namespace string) bool {
+	return preferenceNamespacePattern.MatchString(namespace)
+}
+
+func (p *UserPreference) IsValid() bool {
+	return IsValidPreferenceNamespace(p.Namespace) &&
+		p.Key != "" &&
+		utf8.RuneCountInString(p.Key) <= MaxPreferenceKeyLength &&
+		len(p.Value) <= MaxPreferenceValueLength &&
+		json.Valid([]byte(p.Value))
+}
+
+// UserPreferences maps a namespace's keys to their json values
+type UserPreferences map[string]json.RawMessage
+
+func NewUserPreferencesFrom(preferences []*UserPreference) UserPreferences {
+	mapped := make(UserPreferences, len(preferences))
+	for _, p := range preferences {
+		mapped[p.Key] = json.RawMessage(p.Value)
+	}
+	return mapped
+}
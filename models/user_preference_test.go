@@ -0,0 +1,31 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserPreference_IsValid(t *testing.T) {
+	pref := func(namespace, key, value string) *UserPreference {
+		return &UserPreference{UserID: "user1", Namespace: namespace, Key: key, Value: value}
+	}
+
+	assert.True(t, pref("vscode", "chart", `"bar"`).IsValid())
+	assert.True(t, pref("my-cli_2", "pinned", `["wakapi", "hackatime"]`).IsValid())
+	assert.False(t, pref("VSCode", "chart", `"bar"`).IsValid())
+	assert.False(t, pref("", "chart", `"bar"`).IsValid())
+	assert.False(t, pref("vscode", "", `"bar"`).IsValid())
+	assert.False(t, pref("vscode", "chart", `bar`).IsValid())
+	assert.False(t, pref("vscode", "chart", `"`+strings.Repeat("a", MaxPreferenceValueLength)+`"`).IsValid())
+}
+
+func TestNewUserPreferencesFrom(t *testing.T) {
+	preferences := NewUserPreferencesFrom([]*UserPreference{
+		{Key: "chart", Value: `"bar"`},
+		{Key: "pinned", Value: `["wakapi"]`},
+	})
+	assert.Len(t, preferences, 2)
+	assert.JSONEq(t, `["wakapi"]`, string(preferences["pinned"]))
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	MaxDisplayNameLength     = 64
+	MaxBioLength             = 255
+	MaxProfileLocationLength = 100
+)
+
+// UserProfile holds the user's self-chosen identity, which is shown on leaderboards and their public profile
+type UserProfile struct {
+	Username     string `json:"username"`
+	DisplayName  string `json:"display_name"`
+	Bio          string `json:"bio"`
+	Location     string `json:"location"`
+	AvatarSource string `json:"avatar_source" enums:",gravatar,upload"`
+	AvatarUrl    string `json:"avatar_url"`
+}
+
+// UserProfileUpdate is a partial update of UserProfile, fields left out are not modified. avatar_source can only be set to upload once an avatar was uploaded.
+type UserProfileUpdate struct {
+	DisplayName  *string `json:"display_name"`
+	Bio          *string `json:"bio"`
+	Location     *string `json:"location"`
+	AvatarSource *string `json:"avatar_source"`
+}
+
+func NewUserProfileFrom(user *User, avatarUrl string) *UserProfile {
+	return &UserProfile{
+		Username:     user.ID,
+		DisplayName:  user.DisplayName(),
+		Bio:          user.Bio,
+		Location:     user.ProfileLocation,
+		AvatarSource: user.AvatarSource,
+		AvatarUrl:    avatarUrl,
+	}
+}
+
+// Apply validates the update and writes all present fields to the given user
+func (u *UserProfileUpdate) Apply(user *User) error {
+	if u.DisplayName != nil && utf8.RuneCountInString(strings.TrimSpace(*u.DisplayName)) > MaxDisplayNameLength {
+		return errors.New("display name too long")
+	}
+	if u.Bio != nil && utf8.RuneCountInString(strings.TrimSpace(*u.Bio)) > MaxBioLength {
+		return errors.New("bio too long")
+	}
+	if u.Location != nil && utf8.RuneCountInString(strings.TrimSpace(*u.Location)) > MaxProfileLocationLength {
+		return errors.New("location too long")
+	}
+	if u.AvatarSource != nil {
+		if !ValidateAvatarSource(*u.AvatarSource) {
+			return errors.New("invalid avatar source")
+		}
+		if *u.AvatarSource == AvatarSourceUpload && user.AvatarUpdatedAt == nil {
+			return errors.New("no avatar uploaded")
+		}
+		if *u.AvatarSource == AvatarSourceGravatar && user.Email == "" {
+			return errors.New("gravatar requires an e-mail address")
+		}
+	}
+
+	if u.DisplayName != nil {
+		user.Name = strings.TrimSpace(*u.DisplayName)
+	}
+	if u.Bio != nil {
+		user.Bio = strings.TrimSpace(*u.Bio)
+	}
+	if u.Location != nil {
+		user.ProfileLocation = strings.TrimSpace(*u.Location)
+	}
+	if u.AvatarSource != nil {
+		user.AvatarSource = *u.AvatarSource
+	}
+	return nil
+}
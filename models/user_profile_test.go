@@ -0,0 +1,33 @@
+package models
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserProfileUpdate_Apply(t *testing.T) {
+	name, bio, gravatar := "  Jane Doe ", "hello world", AvatarSourceGravatar
+	sut := &User{ID: "jane", Email: "jane@example.org", ProfileLocation: "Berlin"}
+
+	assert.Nil(t, (&UserProfileUpdate{DisplayName: &name, Bio: &bio, AvatarSource: &gravatar}).Apply(sut))
+	assert.Equal(t, "Jane Doe", sut.DisplayName())
+	assert.Equal(t, "hello world", sut.Bio)
+	assert.Equal(t, "Berlin", sut.ProfileLocation) // untouched
+	assert.Equal(t, AvatarSourceGravatar, sut.AvatarSource)
+
+	empty := ""
+	assert.Nil(t, (&UserProfileUpdate{DisplayName: &empty}).Apply(sut))
+	assert.Equal(t, "jane", sut.DisplayName())
+}
+
+func TestUserProfileUpdate_Apply_Invalid(t *testing.T) {
+	long, upload, unknown := strings.Repeat("a", MaxDisplayNameLength+1), AvatarSourceUpload, "myspace"
+	sut := &User{ID: "jane", Name: "Jane"}
+
+	assert.NotNil(t, (&UserProfileUpdate{DisplayName: &long}).Apply(sut))
+	assert.NotNil(t, (&UserProfileUpdate{AvatarSource: &upload}).Apply(sut)) // nothing uploaded yet
+	assert.NotNil(t, (&UserProfileUpdate{AvatarSource: &unknown}).Apply(sut))
+	assert.Equal(t, "Jane", sut.Name)
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hackclub/hackatime/i18n"
+)
+
+var weekdayNames = map[string]bool{"sunday": true, "monday": true, "tuesday": true, "wednesday": true, "thursday": true, "friday": true, "saturday": true}
+
+// UserSettings is the subset of a user's settings that can be read and written via the api
+type UserSettings struct {
+	Timezone               string   `json:"timezone"`
+	FirstDayOfWeek         string   `json:"first_day_of_week"`
+	HeartbeatsTimeoutSec   int      `json:"heartbeats_timeout_sec"`
+	ExcludeUnknownProjects bool     `json:"exclude_unknown_projects"`
+	ArchivedProjects       []string `json:"archived_projects"`
+	PublicLeaderboard      bool     `json:"public_leaderboard"`
+	InactivityAlertHours   int      `json:"inactivity_alert_hours"` // 0 if disabled
+	IngestionBlocklist     []string `json:"ingestion_blocklist"`
+	ScrapbookEnabled       bool     `json:"scrapbook_enabled"`
+	ScrapbookTemplate      string   `json:"scrapbook_template"`    // empty for the server's default
+	DurationRoundingMin    int      `json:"duration_rounding_min"` // 0, 5 or 15
+	DurationFormat         string   `json:"duration_format"`       // empty for the default, decimal or clock
+	Locale                 string   `json:"locale"`                // empty for the server's default, e.g. en or de
+	OverlapStrategy        string   `json:"overlap_strategy"`      // prefer-most-recent-machine, max or sum
+}
+
+// UserSettingsUpdate is a partial update of UserSettings, fields left out are not modified
+type UserSettingsUpdate struct {
+	Timezone               *string   `json:"timezone"`
+	FirstDayOfWeek         *string   `json:"first_day_of_week"`
+	HeartbeatsTimeoutSec   *int      `json:"heartbeats_timeout_sec"`
+	ExcludeUnknownProjects *bool     `json:"exclude_unknown_projects"`
+	ArchivedProjects       *[]string `json:"archived_projects"`
+	PublicLeaderboard      *bool     `json:"public_leaderboard"`
+	InactivityAlertHours   *int      `json:"inactivity_alert_hours"`
+	IngestionBlocklist     *[]string `json:"ingestion_blocklist"`
+	ScrapbookEnabled       *bool     `json:"scrapbook_enabled"`
+	ScrapbookTemplate      *string   `json:"scrapbook_template"`
+	DurationRoundingMin    *int      `json:"duration_rounding_min"`
+	DurationFormat         *string   `json:"duration_format"`
+	Locale                 *string   `json:"locale"`
+	OverlapStrategy        *string   `json:"overlap_strategy"`
+}
+
+func NewUserSettingsFrom(user *User) *UserSettings {
+	archived := user.ArchivedProjects
+	if archived == nil {
+		archived = StringList{}
+	}
+	blocklist := user.IngestionBlocklist
+	if blocklist == nil {
+		blocklist = StringList{}
+	}
+	return &UserSettings{
+		Timezone:               user.TZ().String(),
+		FirstDayOfWeek:         strings.ToLower(user.WeekStart().String()),
+		HeartbeatsTimeoutSec:   int(user.HeartbeatsTimeout().Seconds()),
+		ExcludeUnknownProjects: user.ExcludeUnknownProjects,
+		ArchivedProjects:       archived,
+		PublicLeaderboard:      user.PublicLeaderboard,
+		InactivityAlertHours:   user.InactivityAlertHours,
+		IngestionBlocklist:     blocklist,
+		ScrapbookEnabled:       user.ScrapbookEnabled,
+		ScrapbookTemplate:      user.ScrapbookTemplate,
+		DurationRoundingMin:    user.DurationRoundingMin,
+		DurationFormat:         user.DurationFormat,
+		Locale:                 user.Locale,
+		OverlapStrategy:        user.OverlapResolution(),
+	}
+}
+
+// Apply validates the update and writes all present fields to the given user
+func (u *UserSettingsUpdate) Apply(user *User) error {
+	if u.Timezone != nil && !ValidateTimezone(*u.Timezone) {
+		return errors.New("invalid timezone")
+	}
+	if u.FirstDayOfWeek != nil && !weekdayNames[strings.ToLower(*u.FirstDayOfWeek)] {
+		return errors.New("invalid first day of week")
+	}
+	if u.HeartbeatsTimeoutSec != nil {
+		if d := time.Duration(*u.HeartbeatsTimeoutSec) * time.Second; d < MinHeartbeatsTimeout || d > MaxHeartbeatsTimeout {
+			return errors.New("invalid heartbeats timeout")
+		}
+	}
+	if u.InactivityAlertHours != nil && (*u.InactivityAlertHours < 0 || *u.InactivityAlertHours > MaxInactivityAlertHours) {
+		return errors.New("invalid inactivity alert hours")
+	}
+	if u.IngestionBlocklist != nil {
+		if len(*u.IngestionBlocklist) > MaxIngestionBlocklist {
+			return errors.New("too many ingestion blocklist patterns")
+		}
+		if _, err := CompileBlocklist(*u.IngestionBlocklist); err != nil {
+			return errors.New("invalid ingestion blocklist pattern")
+		}
+	}
+	if u.ScrapbookTemplate != nil {
+		if _, err := ParseScrapbookTemplate(*u.ScrapbookTemplate); err != nil {
+			return errors.New("invalid scrapbook template")
+		}
+	}
+	if u.DurationRoundingMin != nil && !slices.Contains(DurationRoundingOptions, *u.DurationRoundingMin) {
+		return errors.New("invalid duration rounding")
+	}
+	if u.DurationFormat != nil && !slices.Contains([]string{DurationFormatDefault, DurationFormatDecimal, DurationFormatClock}, *u.DurationFormat) {
+		return errors.New("invalid duration format")
+	}
+	if u.Locale != nil && *u.Locale != "" && !i18n.IsSupported(*u.Locale) {
+		return errors.New("unsupported locale")
+	}
+	if u.OverlapStrategy != nil && !slices.Contains(OverlapStrategies, *u.OverlapStrategy) {
+		return errors.New("invalid overlap strategy")
+	}
+
+	if u.Timezone != nil {
+		user.Location = *u.Timezone
+	}
+	if u.FirstDayOfWeek != nil {
+		user.FirstDayOfWeek = strings.ToLower(*u.FirstDayOfWeek)
+	}
+	if u.HeartbeatsTimeoutSec != nil {
+		user.HeartbeatsTimeoutSec = *u.HeartbeatsTimeoutSec
+	}
+	if u.ExcludeUnknownProjects != nil {
+		user.ExcludeUnknownProjects = *u.ExcludeUnknownProjects
+	}
+	if u.ArchivedProjects != nil {
+		user.ArchivedProjects = StringList{}
+		for _, p := range *u.ArchivedProjects {
+			user.ArchiveProject(p)
+		}
+	}
+	if u.PublicLeaderboard != nil {
+		user.PublicLeaderboard = *u.PublicLeaderboard
+	}
+	if u.InactivityAlertHours != nil {
+		user.InactivityAlertHours = *u.InactivityAlertHours
+	}
+	if u.IngestionBlocklist != nil {
+		user.IngestionBlocklist = StringList(*u.IngestionBlocklist)
+	}
+	if u.ScrapbookEnabled != nil {
+		user.ScrapbookEnabled = *u.ScrapbookEnabled
+	}
+	if u.ScrapbookTemplate != nil {
+		user.ScrapbookTemplate = strings.TrimSpace(*u.ScrapbookTemplate)
+	}
+	if u.DurationRoundingMin != nil {
+		user.DurationRoundingMin = *u.DurationRoundingMin
+	}
+	if u.DurationFormat != nil {
+		user.DurationFormat = *u.DurationFormat
+	}
+	if u.Locale != nil {
+		user.Locale = strings.ToLower(strings.TrimSpace(*u.Locale))
+	}
+	if u.OverlapStrategy != nil {
+		user.OverlapStrategy = *u.OverlapStrategy
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserSettingsUpdate_Apply(t *testing.T) {
+	tz, weekday, timeout := "Europe/Berlin", "Sunday", 90
+	sut := &User{Location: "America/Los_Angeles", PublicLeaderboard: true}
+
+	err := (&UserSettingsUpdate{
+		Timezone:             &tz,
+		FirstDayOfWeek:       &weekday,
+		HeartbeatsTimeoutSec: &timeout,
+		ArchivedProjects:     &[]string{"foo", "foo", "bar"},
+	}).Apply(sut)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "Europe/Berlin", sut.Location)
+	assert.Equal(t, time.Sunday, sut.WeekStart())
+	assert.Equal(t, 90*time.Second, sut.HeartbeatsTimeout())
+	assert.Equal(t, StringList{"foo", "bar"}, sut.ArchivedProjects)
+	assert.True(t, sut.PublicLeaderboard) // untouched
+
+	settings := NewUserSettingsFrom(sut)
+	assert.Equal(t, "sunday", settings.FirstDayOfWeek)
+	assert.Equal(t, 90, settings.HeartbeatsTimeoutSec)
+}
+
+func TestUserSettingsUpdate_Apply_Invalid(t *testing.T) {
+	tz, weekday, timeout, rounding, format, locale, strategy := "Mars/Olympus_Mons", "someday", 1, 7, "minutes", "klingon", "min"
+	sut := &User{Location: "America/Los_Angeles"}
+
+	assert.NotNil(t, (&UserSettingsUpdate{Timezone: &tz}).Apply(sut))
+	assert.NotNil(t, (&UserSettingsUpdate{FirstDayOfWeek: &weekday}).Apply(sut))
+	assert.NotNil(t, (&UserSettingsUpdate{HeartbeatsTimeoutSec: &timeout}).Apply(sut))
+	assert.NotNil(t, (&UserSettingsUpdate{DurationRoundingMin: &rounding}).Apply(sut))
+	assert.NotNil(t, (&UserSettingsUpdate{DurationFormat: &format}).Apply(sut))
+	assert.NotNil(t, (&UserSettingsUpdate{Locale: &locale}).Apply(sut))
+	assert.NotNil(t, (&UserSettingsUpdate{OverlapStrategy: &strategy}).Apply(sut))
+	assert.Equal(t, "America/Los_Angeles", sut.Location)
+}
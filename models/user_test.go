@@ -21,6 +21,16 @@ func TestUser_TZ(t *testing.T) {
 	assert.InDelta(t, time.Duration(offset2*int(time.Second)), sut2.TZOffset(), float64(1*time.Second))
 }
 
+func TestUser_AvatarURL(t *testing.T) {
+	updatedAt := CustomTime(time.Unix(1700000000, 0))
+	tpl := "api/avatar/{username_hash}.svg"
+
+	assert.Equal(t, "api/avatar/ee11cbb19052e40b07aac0ca060c23ee.svg", (&User{ID: "user"}).AvatarURL(tpl))
+	assert.Equal(t, "https://www.gravatar.com/avatar/572c3489ea700045927076136a969e27?d=identicon", (&User{ID: "user", Email: " User@example.org", AvatarSource: AvatarSourceGravatar}).AvatarURL(tpl))
+	assert.Equal(t, "api/avatar/ee11cbb19052e40b07aac0ca060c23ee.svg", (&User{ID: "user", AvatarSource: AvatarSourceGravatar}).AvatarURL(tpl)) // no email
+	assert.Equal(t, "api/avatar/uploads/user?v=1700000000", (&User{ID: "user", AvatarSource: AvatarSourceUpload, AvatarUpdatedAt: &updatedAt}).AvatarURL(tpl))
+}
+
 func TestUser_MinDataAge(t *testing.T) {
 	c := conf.Load("", "")
 
@@ -58,3 +68,60 @@ func TestUser_MinDataAge(t *testing.T) {
 	sut = &User{SubscribedUntil: &until1}
 	assert.Zero(t, sut.MinDataAge())
 }
+
+func TestUser_HasTrustedEmail(t *testing.T) {
+	c := conf.Empty()
+	conf.Set(c)
+
+	suspendedAt := CustomTime(time.Now())
+
+	assert.True(t, (&User{Email: "user@example.org"}).HasTrustedEmail())
+	assert.False(t, (&User{}).HasTrustedEmail())
+	assert.False(t, (&User{Email: "user@example.org", IsServiceAccount: true}).HasTrustedEmail())
+	assert.False(t, (&User{Email: "user@example.org", SuspendedAt: &suspendedAt}).HasTrustedEmail())
+	assert.False(t, (&User{Email: "user@example.org", Deactivated: true}).HasTrustedEmail())
+
+	c.Security.RequireEmailVerification = true
+	assert.False(t, (&User{Email: "user@example.org"}).HasTrustedEmail())
+	assert.True(t, (&User{Email: "user@example.org", EmailVerified: true}).HasTrustedEmail())
+	assert.False(t, (&User{Email: "user@example.org", EmailVerified: true, Deactivated: true}).HasTrustedEmail())
+}
+
+func TestUser_ResolveProjectName(t *testing.T) {
+	conf.Set(conf.Empty())
+
+	projects := []string{"wakapi", "secret"}
+
+	sut := &User{ID: "user"}
+	resolved, ok := sut.ResolveProjectName("wakapi", projects)
+	assert.True(t, ok)
+	assert.Equal(t, "wakapi", resolved)
+
+	sut.HideProjectNames = true
+	resolved, ok = sut.ResolveProjectName(sut.AnonymizedProjectName("secret"), projects)
+	assert.True(t, ok)
+	assert.Equal(t, "secret", resolved)
+	assert.Equal(t, sut.AnonymizedProjectName("secret"), sut.DisplayedProjectName("secret"))
+
+	// actual names are not accepted, neither are names anonymized for another user
+	_, ok = sut.ResolveProjectName("wakapi", projects)
+	assert.False(t, ok)
+	_, ok = sut.ResolveProjectName((&User{ID: "other"}).AnonymizedProjectName("wakapi"), projects)
+	assert.False(t, ok)
+}
+
+func TestParseExternalUserRef(t *testing.T) {
+	provider, externalId, ok := ParseExternalUserRef("slack:U012AB3CD")
+	assert.True(t, ok)
+	assert.Equal(t, "slack", provider)
+	assert.Equal(t, "U012AB3CD", externalId)
+
+	_, _, ok = ParseExternalUserRef("user1")
+	assert.False(t, ok)
+	_, _, ok = ParseExternalUserRef("Slack:U012AB3CD")
+	assert.False(t, ok)
+	_, _, ok = ParseExternalUserRef("slack:")
+	assert.False(t, ok)
+
+	assert.False(t, ValidateUsername("slack:U012AB3CD"))
+}
@@ -0,0 +1,19 @@
+package view
+
+import "github.com/hackclub/hackatime/models"
+
+type DeviceViewModel struct {
+	SharedLoggedInViewModel
+	UserCode      string
+	Authorization *models.DeviceAuthorization // only set, if the entered user code belongs to a pending request
+}
+
+func (s *DeviceViewModel) WithSuccess(m string) *DeviceViewModel {
+	s.SetSuccess(m)
+	return s
+}
+
+func (s *DeviceViewModel) WithError(m string) *DeviceViewModel {
+	s.SetError(m)
+	return s
+}
@@ -0,0 +1,23 @@
+package view
+
+type LegalViewModel struct {
+	SharedViewModel
+	Title    string
+	Version  string
+	HtmlText string
+}
+
+func (s *LegalViewModel) WithSuccess(m string) *LegalViewModel {
+	s.SetSuccess(m)
+	return s
+}
+
+func (s *LegalViewModel) WithError(m string) *LegalViewModel {
+	s.SetError(m)
+	return s
+}
+
+func (s *LegalViewModel) WithHtmlText(t string) *LegalViewModel {
+	s.HtmlText = t
+	return s
+}
@@ -2,10 +2,14 @@ package view
 
 type LoginViewModel struct {
 	SharedViewModel
-	TotalUsers  int
-	AllowSignup bool
-	CaptchaId   string
-	InviteCode  string
+	TotalUsers     int
+	AllowSignup    bool
+	SignupDomains  []string // e-mail domains users are restricted to, if any
+	CaptchaId      string
+	InviteCode     string
+	Username       string // pre-filled when asking to accept updated terms
+	LegalVersion   string // only set, if users have to accept the instance's terms
+	RequireConsent bool
 }
 
 type SetPasswordViewModel struct {
@@ -22,3 +26,9 @@ func (s *LoginViewModel) WithError(m string) *LoginViewModel {
 	s.SetError(m)
 	return s
 }
+
+func (s *LoginViewModel) WithConsentRequired(username string) *LoginViewModel {
+	s.Username = username
+	s.RequireConsent = true
+	return s
+}
@@ -0,0 +1,18 @@
+package view
+
+type MentorUnsubscribeViewModel struct {
+	SharedViewModel
+	Token        string
+	UserName     string // whose summaries the mentor receives, empty if the token is unknown
+	Unsubscribed bool
+}
+
+func (s *MentorUnsubscribeViewModel) WithSuccess(m string) *MentorUnsubscribeViewModel {
+	s.SetSuccess(m)
+	return s
+}
+
+func (s *MentorUnsubscribeViewModel) WithError(m string) *MentorUnsubscribeViewModel {
+	s.SetError(m)
+	return s
+}
@@ -0,0 +1,19 @@
+package view
+
+import "github.com/hackclub/hackatime/models"
+
+type ProfileViewModel struct {
+	SharedViewModel
+	Profile   *models.Profile
+	AvatarURL string
+}
+
+func (s *ProfileViewModel) WithSuccess(m string) *ProfileViewModel {
+	s.SetSuccess(m)
+	return s
+}
+
+func (s *ProfileViewModel) WithError(m string) *ProfileViewModel {
+	s.SetError(m)
+	return s
+}
@@ -12,9 +12,14 @@ import (
 
 type ProjectsViewModel struct {
 	SharedLoggedInViewModel
-	Projects   []*models.ProjectStats
-	PageParams *utils.PageParams
-	maxCount   int64
+	Projects        []*models.ProjectStats
+	PageParams      *utils.PageParams
+	IncludeArchived bool
+	maxCount        int64
+}
+
+func (s *ProjectsViewModel) IsArchived(project string) bool {
+	return s.User != nil && s.User.IsProjectArchived(project)
 }
 
 func (s *ProjectsViewModel) LangIcon(lang string) string {
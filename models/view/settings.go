@@ -12,11 +12,20 @@ type SettingsViewModel struct {
 	Aliases             []*SettingsVMCombinedAlias
 	Labels              []*SettingsVMCombinedLabel
 	Projects            []string
+	Machines            []*models.Machine
+	QuarantinedCounts   map[string]int64
 	SubscriptionPrice   string
 	DataRetentionMonths int
 	UserFirstData       time.Time
 	SupportContact      string
 	InviteLink          string
+	GeoEnabled          bool
+	TrackingPauses      []*models.TrackingPause
+	TelegramEnabled     bool
+	TelegramLinked      bool
+	TelegramLink        *models.TelegramLink
+	MentorsEnabled      bool
+	Mentors             []*models.Mentor
 }
 
 type SettingsVMCombinedAlias struct {
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	HeartbeatCategoryWatching = "file watching" // heartbeats derived from file modification events, rather than sent by an editor
+	watcherEntityType         = "file"
+)
+
+// WatcherEvents is the payload sent by simple file watcher agents, which only observe file modifications on disk
+// and thus allow to track time spent in editors without a plugin
+type WatcherEvents struct {
+	Project string          `json:"project"` // default project for all events
+	Branch  string          `json:"branch"`
+	Machine string          `json:"machine"`
+	Events  []*WatcherEvent `json:"events"`
+}
+
+type WatcherEvent struct {
+	Path    string  `json:"path"`
+	Mtime   float64 `json:"mtime"`   // modification time as unix timestamp, fractional seconds allowed
+	Project string  `json:"project"` // overrides the default project, if set
+}
+
+func (e *WatcherEvent) Valid() bool {
+	return e != nil && e.Path != "" && e.Mtime > 0
+}
+
+func (e *WatcherEvent) Time() time.Time {
+	sec, frac := math.Modf(e.Mtime)
+	return time.Unix(int64(sec), int64(frac*1e9))
+}
+
+// Heartbeats converts the events into write heartbeats of their own category, user and editor are left to the caller
+func (w *WatcherEvents) Heartbeats() []*Heartbeat {
+	heartbeats := make([]*Heartbeat, 0, len(w.Events))
+	for _, e := range w.Events {
+		if !e.Valid() {
+			heartbeats = append(heartbeats, nil)
+			continue
+		}
+
+		project := w.Project
+		if e.Project != "" {
+			project = e.Project
+		}
+
+		heartbeats = append(heartbeats, &Heartbeat{
+			Entity:   e.Path,
+			Type:     watcherEntityType,
+			Category: HeartbeatCategoryWatching,
+			Project:  project,
+			Branch:   w.Branch,
+			Machine:  w.Machine,
+			IsWrite:  true,
+			Time:     CustomTime(e.Time()),
+		})
+	}
+	return heartbeats
+}
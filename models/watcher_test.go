@@ -0,0 +1,32 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatcherEvents_Heartbeats(t *testing.T) {
+	sut := &WatcherEvents{
+		Project: "hackatime",
+		Branch:  "main",
+		Events: []*WatcherEvent{
+			{Path: "/home/user/dev/hackatime/main.go", Mtime: 1700000000.25},
+			{Path: "/home/user/dev/other/README.md", Mtime: 1700000001, Project: "other"},
+			{Path: "", Mtime: 1700000002},
+		},
+	}
+
+	heartbeats := sut.Heartbeats()
+
+	assert.Len(t, heartbeats, 3)
+	assert.Equal(t, "/home/user/dev/hackatime/main.go", heartbeats[0].Entity)
+	assert.Equal(t, "hackatime", heartbeats[0].Project)
+	assert.Equal(t, "main", heartbeats[0].Branch)
+	assert.Equal(t, HeartbeatCategoryWatching, heartbeats[0].Category)
+	assert.Equal(t, "file", heartbeats[0].Type)
+	assert.True(t, heartbeats[0].IsWrite)
+	assert.Equal(t, int64(1700000000250), heartbeats[0].Time.T().UnixMilli())
+	assert.Equal(t, "other", heartbeats[1].Project)
+	assert.Nil(t, heartbeats[2]) // invalid events are rejected by the handler
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// YearReview is a "wrapped"-style summary of a user's coding activity throughout one calendar year, generated once after the year ended.
+// It can be shared publicly via its share token, without exposing the user's other statistics.
+type YearReview struct {
+	ID         uint             `json:"-" gorm:"primary_key"`
+	UserID     string           `json:"-" gorm:"not null; uniqueIndex:idx_year_review_user_year"`
+	User       *User            `json:"-" gorm:"not null; constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	Year       int              `json:"year" gorm:"not null; uniqueIndex:idx_year_review_user_year"`
+	ShareToken string           `json:"share_token" gorm:"not null; uniqueIndex:idx_year_review_share_token"`
+	Stats      *YearReviewStats `json:"stats" gorm:"type:text"`
+	CreatedAt  CustomTime       `json:"created_at" gorm:"default:CURRENT_TIMESTAMP" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+}
+
+type YearReviewStats struct {
+	Username      string             `json:"username"`
+	TotalTime     time.Duration      `json:"total_time" swaggertype:"primitive,integer"`
+	ActiveDays    int                `json:"active_days"`
+	DailyAverage  time.Duration      `json:"daily_average" swaggertype:"primitive,integer"` // averaged over active days only
+	TopLanguages  []*YearReviewEntry `json:"top_languages"`
+	TopProjects   []*YearReviewEntry `json:"top_projects"`
+	TopEditors    []*YearReviewEntry `json:"top_editors"`
+	BusiestDay    *YearReviewPeriod  `json:"busiest_day"`
+	BusiestWeek   *YearReviewPeriod  `json:"busiest_week"`
+	LongestStreak *YearReviewStreak  `json:"longest_streak"`
+	FunFacts      []string           `json:"fun_facts"`
+}
+
+type YearReviewEntry struct {
+	Key   string        `json:"key"`
+	Total time.Duration `json:"total" swaggertype:"primitive,integer"`
+}
+
+type YearReviewPeriod struct {
+	Start time.Time     `json:"start"`
+	Total time.Duration `json:"total" swaggertype:"primitive,integer"`
+}
+
+type YearReviewStreak struct {
+	Start time.Time `json:"start"`
+	Days  int       `json:"days"`
+}
+
+func (r *YearReview) IsEmpty() bool {
+	return r.Stats == nil || r.Stats.TotalTime == 0
+}
+
+func (s *YearReviewStats) Scan(value interface{}) error {
+	switch value.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(value.(string)), s)
+	case []byte:
+		return json.Unmarshal(value.([]byte), s)
+	default:
+		return errors.New(fmt.Sprintf("unsupported type: %T", value))
+	}
+}
+
+func (s *YearReviewStats) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(s)
+	return string(data), err
+}
@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ActivityGraphRepository struct {
+	db *gorm.DB
+}
+
+func NewActivityGraphRepository(db *gorm.DB) *ActivityGraphRepository {
+	return &ActivityGraphRepository{db: db}
+}
+
+func (r *ActivityGraphRepository) GetByUserAndYear(userId string, year int) (*models.ActivityGraph, error) {
+	graph := &models.ActivityGraph{}
+	if err := r.db.
+		Where(&models.ActivityGraph{UserID: userId, Year: year}).
+		First(graph).Error; err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+func (r *ActivityGraphRepository) Upsert(graph *models.ActivityGraph) error {
+	return r.db.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "year"}},
+			DoUpdates: clause.AssignmentColumns([]string{"days", "updated_at"}),
+		}).
+		Create(graph).Error
+}
@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+)
+
+type CanonicalNameRepository struct {
+	config *config.Config
+	db     *gorm.DB
+}
+
+func NewCanonicalNameRepository(db *gorm.DB) *CanonicalNameRepository {
+	return &CanonicalNameRepository{config: config.Get(), db: db}
+}
+
+func (r *CanonicalNameRepository) GetAll() ([]*models.CanonicalName, error) {
+	var mappings []*models.CanonicalName
+	if err := r.db.Order("type asc, canonical asc, variant asc").Find(&mappings).Error; err != nil {
+		return nil, err
+	}
+	return mappings, nil
+}
+
+func (r *CanonicalNameRepository) GetById(id uint) (*models.CanonicalName, error) {
+	mapping := &models.CanonicalName{}
+	if err := r.db.Where(&models.CanonicalName{ID: id}).First(mapping).Error; err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+func (r *CanonicalNameRepository) Insert(mapping *models.CanonicalName) (*models.CanonicalName, error) {
+	if err := r.db.Create(mapping).Error; err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+func (r *CanonicalNameRepository) Delete(id uint) error {
+	return r.db.Where("id = ?", id).Delete(&models.CanonicalName{}).Error
+}
@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+)
+
+type CompareConsentRepository struct {
+	config *config.Config
+	db     *gorm.DB
+}
+
+func NewCompareConsentRepository(db *gorm.DB) *CompareConsentRepository {
+	return &CompareConsentRepository{config: config.Get(), db: db}
+}
+
+// GetByUser returns all consents given by or to the given user
+func (r *CompareConsentRepository) GetByUser(userId string) ([]*models.CompareConsent, error) {
+	var consents []*models.CompareConsent
+	if err := r.db.
+		Where("user_id = ? OR other_id = ?", userId, userId).
+		Order("created_at asc").
+		Find(&consents).Error; err != nil {
+		return nil, err
+	}
+	return consents, nil
+}
+
+func (r *CompareConsentRepository) Exists(userId, otherId string) (bool, error) {
+	var count int64
+	if err := r.db.
+		Model(&models.CompareConsent{}).
+		Where(&models.CompareConsent{UserID: userId, OtherID: otherId}).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *CompareConsentRepository) Insert(consent *models.CompareConsent) (*models.CompareConsent, error) {
+	if err := r.db.Create(consent).Error; err != nil {
+		return nil, err
+	}
+	return consent, nil
+}
+
+func (r *CompareConsentRepository) Delete(userId, otherId string) error {
+	return r.db.
+		Where("user_id = ?", userId).
+		Where("other_id = ?", otherId).
+		Delete(&models.CompareConsent{}).Error
+}
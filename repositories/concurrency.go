@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ConcurrencyRepository struct {
+	config *config.Config
+	db     *gorm.DB
+}
+
+func NewConcurrencyRepository(db *gorm.DB) *ConcurrencyRepository {
+	return &ConcurrencyRepository{config: config.Get(), db: db}
+}
+
+func (r *ConcurrencyRepository) GetWithin(from, to time.Time) ([]*models.ConcurrencySample, error) {
+	var samples []*models.ConcurrencySample
+	if err := r.db.
+		Where("time >= ?", from.Local()).
+		Where("time < ?", to.Local()).
+		Order("time asc").
+		Find(&samples).Error; err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+func (r *ConcurrencyRepository) Upsert(sample *models.ConcurrencySample) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "time"}},
+		DoUpdates: clause.AssignmentColumns([]string{"active_users"}),
+	}).Create(sample).Error
+}
+
+func (r *ConcurrencyRepository) DeleteBefore(t time.Time) error {
+	return r.db.Where("time < ?", t.Local()).Delete(&models.ConcurrencySample{}).Error
+}
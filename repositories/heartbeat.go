@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"strings"
 	"time"
 
@@ -22,6 +23,11 @@ func NewHeartbeatRepository(db *gorm.DB) *HeartbeatRepository {
 	return &HeartbeatRepository{config: conf.Get(), db: db}
 }
 
+// WithContext returns a copy of the repository, whose queries are canceled once the given context is done
+func (r *HeartbeatRepository) WithContext(ctx context.Context) IHeartbeatRepository {
+	return &HeartbeatRepository{config: r.config, db: r.db.WithContext(ctx)}
+}
+
 // Use with caution!!
 func (r *HeartbeatRepository) GetAll() ([]*models.Heartbeat, error) {
 	var heartbeats []*models.Heartbeat
@@ -179,6 +185,54 @@ func (r *HeartbeatRepository) CountByUser(user *models.User) (int64, error) {
 	return count, nil
 }
 
+// activeDaysChunkSize limits the number of days bucketed per query, as every day takes three bind parameters and databases cap their
+// number per statement (e.g. 999 for older sqlite versions, 2100 for mssql)
+const activeDaysChunkSize = 100
+
+// CountActiveDaysByUser counts how many of the given days, each given as [start, end), contain at least one of the user's heartbeats.
+// Days are bucketed using a case expression rather than a date function to be independent of the dialect and of the user's time zone.
+// Long intervals are split into chunks of consecutive days, whose counts simply add up, as the days don't overlap.
+func (r *HeartbeatRepository) CountActiveDaysByUser(user *models.User, days [][]time.Time, filterMap map[string][]string) (int64, error) {
+	var total int64
+	for _, chunk := range slice.Chunk(days, activeDaysChunkSize) {
+		count, err := r.countActiveDaysByUser(user, chunk, filterMap)
+		if err != nil {
+			return 0, err
+		}
+		total += count
+	}
+	return total, nil
+}
+
+func (r *HeartbeatRepository) countActiveDaysByUser(user *models.User, days [][]time.Time, filterMap map[string][]string) (int64, error) {
+	if len(days) == 0 {
+		return 0, nil
+	}
+
+	var expr strings.Builder
+	args := make([]interface{}, 0, len(days)*3)
+	expr.WriteString("count(distinct case")
+	for i, day := range days {
+		expr.WriteString(" when time >= ? and time < ? then ?")
+		args = append(args, day[0].Local(), day[1].Local(), i)
+	}
+	expr.WriteString(" end)")
+
+	var count int64
+	q := r.db.
+		Model(&models.Heartbeat{}).
+		Select(expr.String(), args...).
+		Where(&models.Heartbeat{UserID: user.ID}).
+		Where("time >= ?", days[0][0].Local()).
+		Where("time < ?", days[len(days)-1][1].Local())
+	q = r.filteredQuery(q, filterMap)
+
+	if err := q.Scan(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
 func (r *HeartbeatRepository) CountByUsers(users []*models.User) ([]*models.CountByUser, error) {
 	var counts []*models.CountByUser
 
@@ -225,22 +279,55 @@ func (r *HeartbeatRepository) DeleteBefore(t time.Time) error {
 }
 
 func (r *HeartbeatRepository) DeleteByUser(user *models.User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Where("user_id = ?", user.ID).
+			Delete(models.Heartbeat{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.HeartbeatDeletion{UserID: user.ID}).Error
+	})
+}
+
+func (r *HeartbeatRepository) DeleteByUserBefore(user *models.User, t time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.
+			Where("user_id = ?", user.ID).
+			Where("time <= ?", t.Local()).
+			Delete(models.Heartbeat{}).Error; err != nil {
+			return err
+		}
+		before := models.CustomTime(t)
+		return tx.Create(&models.HeartbeatDeletion{UserID: user.ID, Before: &before}).Error
+	})
+}
+
+// GetAllByUserAfterId returns a user's heartbeats with an id greater than the given one, ordered by id
+func (r *HeartbeatRepository) GetAllByUserAfterId(user *models.User, afterId uint64, limit int) ([]*models.Heartbeat, error) {
+	var heartbeats []*models.Heartbeat
 	if err := r.db.
-		Where("user_id = ?", user.ID).
-		Delete(models.Heartbeat{}).Error; err != nil {
-		return err
+		Where(&models.Heartbeat{UserID: user.ID}).
+		Where("id > ?", afterId).
+		Order("id asc").
+		Limit(limit).
+		Find(&heartbeats).Error; err != nil {
+		return nil, err
 	}
-	return nil
+	return heartbeats, nil
 }
 
-func (r *HeartbeatRepository) DeleteByUserBefore(user *models.User, t time.Time) error {
+// GetDeletionsByUserAfterId returns a user's heartbeat deletion tombstones with an id greater than the given one, ordered by id
+func (r *HeartbeatRepository) GetDeletionsByUserAfterId(user *models.User, afterId uint64, limit int) ([]*models.HeartbeatDeletion, error) {
+	var deletions []*models.HeartbeatDeletion
 	if err := r.db.
-		Where("user_id = ?", user.ID).
-		Where("time <= ?", t.Local()).
-		Delete(models.Heartbeat{}).Error; err != nil {
-		return err
+		Where(&models.HeartbeatDeletion{UserID: user.ID}).
+		Where("id > ?", afterId).
+		Order("id asc").
+		Limit(limit).
+		Find(&deletions).Error; err != nil {
+		return nil, err
 	}
-	return nil
+	return deletions, nil
 }
 
 func (r *HeartbeatRepository) GetUserProjectStats(user *models.User, from, to time.Time, limit, offset int) ([]*models.ProjectStats, error) {
@@ -257,6 +344,11 @@ func (r *HeartbeatRepository) GetUserProjectStats(user *models.User, from, to ti
 		user.ID, from.Format(time.RFC3339), to.Format(time.RFC3339),
 	}
 
+	// mysql doesn't reliably convert rfc 3339 strings (e.g. with 'Z' suffix) to timestamps, which defeats the index on the time range
+	if r.config.Db.IsMySQL() {
+		args = []interface{}{user.ID, from.Local(), to.Local()}
+	}
+
 	limitOffsetClause := "limit ? offset ?"
 
 	if r.config.Db.IsMssql() {
@@ -289,6 +381,177 @@ func (r *HeartbeatRepository) GetUserProjectStats(user *models.User, from, to ti
 	return projectStats, nil
 }
 
+func (r *HeartbeatRepository) GetUserAgentStats(user *models.User) ([]*models.UserAgentStats, error) {
+	var userAgentStats []*models.UserAgentStats
+	if err := r.db.
+		Model(&models.Heartbeat{}).
+		Select("user_agent, editor, operating_system, min(time) as first, max(time) as last").
+		Where(&models.Heartbeat{UserID: user.ID}).
+		Where("user_agent != ''").
+		Group("user_agent, editor, operating_system").
+		Order("last desc").
+		Scan(&userAgentStats).Error; err != nil {
+		return nil, err
+	}
+	return userAgentStats, nil
+}
+
+func (r *HeartbeatRepository) GetMachineStats(user *models.User) ([]*models.MachineStats, error) {
+	var machineStats []*models.MachineStats
+	if err := r.db.
+		Model(&models.Heartbeat{}).
+		Select("machine, min(time) as first, max(time) as last").
+		Where(&models.Heartbeat{UserID: user.ID}).
+		Where("machine != ''").
+		Group("machine").
+		Order("last desc").
+		Scan(&machineStats).Error; err != nil {
+		return nil, err
+	}
+	return machineStats, nil
+}
+
+// GetDistinctValues returns all values of the given column across all users' heartbeats
+func (r *HeartbeatRepository) GetDistinctValues(column string) ([]string, error) {
+	var values []string
+	if err := r.db.
+		Model(&models.Heartbeat{}).
+		Distinct(column).
+		Where(column+" != ''").
+		Pluck(column, &values).Error; err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// GetFirstByUsersWithValues returns the time of each user's first heartbeat, which has one of the given values in the given column
+func (r *HeartbeatRepository) GetFirstByUsersWithValues(column string, values []string) ([]*models.TimeByUser, error) {
+	var result []*models.TimeByUser
+	if len(values) == 0 {
+		return result, nil
+	}
+	if err := r.db.
+		Model(&models.Heartbeat{}).
+		Select(utils.QuoteSql(r.db, "user_id as %s, min(time) as %s", "user", "time")).
+		Where(column+" in ?", values).
+		Group("user_id").
+		Scan(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ReplaceValues sets the given column to the replacement in all heartbeats, which have one of the given values
+func (r *HeartbeatRepository) ReplaceValues(column string, values []string, replacement string) (int64, error) {
+	if len(values) == 0 {
+		return 0, nil
+	}
+	result := r.db.
+		Model(&models.Heartbeat{}).
+		Where(column+" in ?", values).
+		Update(column, replacement)
+	return result.RowsAffected, result.Error
+}
+
+// GetProjectIntervalByUser returns the times of the user's first and last heartbeat of the given project, or nil if there are none
+func (r *HeartbeatRepository) GetProjectIntervalByUser(user *models.User, project string) (*models.Interval, error) {
+	var result struct {
+		First models.CustomTime
+		Last  models.CustomTime
+		Count int64
+	}
+	if err := r.db.
+		Model(&models.Heartbeat{}).
+		Select("min(time) as first, max(time) as last, count(*) as count").
+		Where("user_id = ?", user.ID).
+		Where("project = ?", project).
+		Scan(&result).Error; err != nil {
+		return nil, err
+	}
+	if result.Count == 0 {
+		return nil, nil
+	}
+	return &models.Interval{Start: result.First.T(), End: result.Last.T()}, nil
+}
+
+// RenameProject sets the project of all the user's heartbeats of the old project within the given time range to the new one
+func (r *HeartbeatRepository) RenameProject(user *models.User, oldProject, newProject string, from, to time.Time) (int64, error) {
+	result := r.db.
+		Model(&models.Heartbeat{}).
+		Where("user_id = ?", user.ID).
+		Where("project = ?", oldProject).
+		Where("time >= ?", from.Local()).
+		Where("time < ?", to.Local()).
+		Update("project", newProject)
+	return result.RowsAffected, result.Error
+}
+
+// GetMachineIntervalByUser returns the times of the user's first and last heartbeat from the given machine, or nil if there are none
+func (r *HeartbeatRepository) GetMachineIntervalByUser(user *models.User, machine string) (*models.Interval, error) {
+	var result struct {
+		First models.CustomTime
+		Last  models.CustomTime
+		Count int64
+	}
+	if err := r.db.
+		Model(&models.Heartbeat{}).
+		Select("min(time) as first, max(time) as last, count(*) as count").
+		Where("user_id = ?", user.ID).
+		Where("machine = ?", machine).
+		Scan(&result).Error; err != nil {
+		return nil, err
+	}
+	if result.Count == 0 {
+		return nil, nil
+	}
+	return &models.Interval{Start: result.First.T(), End: result.Last.T()}, nil
+}
+
+// CopyMachineToUser inserts copies of all the user's heartbeats from the given machine within the given time range for the target user
+// copies are re-hashed for the target user, so copying the same range again doesn't produce duplicates
+func (r *HeartbeatRepository) CopyMachineToUser(user, target *models.User, machine string, from, to time.Time) (int64, error) {
+	var heartbeats []*models.Heartbeat
+	if err := r.db.
+		Where(&models.Heartbeat{UserID: user.ID, Machine: machine}).
+		Where("time >= ?", from.Local()).
+		Where("time < ?", to.Local()).
+		Find(&heartbeats).Error; err != nil {
+		return 0, err
+	}
+	if len(heartbeats) == 0 {
+		return 0, nil
+	}
+
+	for _, hb := range heartbeats {
+		hb.ID = 0
+		hb.User = target
+		hb.UserID = target.ID
+		hb.Hashed()
+	}
+	if err := r.InsertBatch(heartbeats); err != nil {
+		return 0, err
+	}
+	return int64(len(heartbeats)), nil
+}
+
+func (r *HeartbeatRepository) DeleteByUserAndMachineBefore(user *models.User, machine string, t time.Time) (int64, error) {
+	var deleted int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.
+			Where("user_id = ?", user.ID).
+			Where("machine = ?", machine).
+			Where("time <= ?", t.Local()).
+			Delete(models.Heartbeat{})
+		if result.Error != nil {
+			return result.Error
+		}
+		deleted = result.RowsAffected
+		before := models.CustomTime(t)
+		return tx.Create(&models.HeartbeatDeletion{UserID: user.ID, Machine: machine, Before: &before}).Error
+	})
+	return deleted, err
+}
+
 func (r *HeartbeatRepository) filteredQuery(q *gorm.DB, filterMap map[string][]string) *gorm.DB {
 	for col, vals := range filterMap {
 		q = q.Where(col+" in ?", slice.Map[string, string](vals, func(i int, val string) string {
@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/utils"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDb(t *testing.T) *gorm.DB {
+	cfg := config.Empty()
+	cfg.Db.Dialect = config.SQLDialectSqlite
+	config.Set(cfg)
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	assert.Nil(t, err)
+	sqlDb, _ := db.DB()
+	sqlDb.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDb.Close() })
+
+	assert.Nil(t, db.AutoMigrate(&models.User{}, &models.Heartbeat{}))
+	return db
+}
+
+func insertTestHeartbeats(t *testing.T, db *gorm.DB, heartbeats ...*models.Heartbeat) {
+	for i, h := range heartbeats {
+		h.Entity, h.Hash = "main.go", fmt.Sprintf("%s-%d", h.UserID, i)
+		assert.Nil(t, db.Create(h).Error)
+	}
+}
+
+func TestHeartbeatRepository_GetUserAgentStats(t *testing.T) {
+	db := newTestDb(t)
+	assert.Nil(t, db.Create(&[]*models.User{{ID: "user1"}, {ID: "user2"}}).Error)
+
+	const (
+		vscode = "wakatime/v1.90.0 (linux-6.8.0-x86_64) go1.22.1 vscode/1.89.1 vscode-wakatime/24.5.0"
+		vim    = "wakatime/v1.90.0 (darwin-23.4.0-arm64) go1.22.1 vim/9.1.0 vim-wakatime/11.2.0"
+	)
+	t0 := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	insertTestHeartbeats(t, db,
+		&models.Heartbeat{UserID: "user1", UserAgent: vscode, Editor: "vscode", OperatingSystem: "Linux", Time: models.CustomTime(t0)},
+		&models.Heartbeat{UserID: "user1", UserAgent: vscode, Editor: "vscode", OperatingSystem: "Linux", Time: models.CustomTime(t0.Add(time.Hour))},
+		&models.Heartbeat{UserID: "user1", UserAgent: vim, Editor: "vim", OperatingSystem: "Mac", Time: models.CustomTime(t0.Add(2 * time.Hour))},
+		&models.Heartbeat{UserID: "user1", UserAgent: "", Editor: "emacs", Time: models.CustomTime(t0.Add(3 * time.Hour))}, // e.g. imported
+		&models.Heartbeat{UserID: "user2", UserAgent: vscode, Editor: "vscode", OperatingSystem: "Linux", Time: models.CustomTime(t0.Add(4 * time.Hour))},
+	)
+
+	stats, err := NewHeartbeatRepository(db).GetUserAgentStats(&models.User{ID: "user1"})
+	assert.Nil(t, err)
+	assert.Len(t, stats, 2)
+
+	// most recently seen first
+	assert.Equal(t, vim, stats[0].UserAgent)
+	assert.Equal(t, "vim", stats[0].Editor)
+	assert.Equal(t, "Mac", stats[0].OperatingSystem)
+
+	assert.Equal(t, vscode, stats[1].UserAgent)
+	assert.Equal(t, "vscode", stats[1].Editor)
+	assert.Equal(t, "Linux", stats[1].OperatingSystem)
+	assert.True(t, t0.Equal(stats[1].First.T()))
+	assert.True(t, t0.Add(time.Hour).Equal(stats[1].Last.T()))
+
+	stats, err = NewHeartbeatRepository(db).GetUserAgentStats(&models.User{ID: "unknown"})
+	assert.Nil(t, err)
+	assert.Empty(t, stats)
+}
+
+func TestHeartbeatRepository_CountActiveDaysByUser(t *testing.T) {
+	db := newTestDb(t)
+	assert.Nil(t, db.Create(&[]*models.User{{ID: "user1"}, {ID: "user2"}}).Error)
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	days := utils.SplitRangeByDays(from, from.AddDate(0, 0, 3*activeDaysChunkSize+10)) // spans multiple chunks
+
+	var heartbeats []*models.Heartbeat
+	for _, i := range []int{0, 1, activeDaysChunkSize - 1, activeDaysChunkSize, 2*activeDaysChunkSize + 5, len(days) - 1} {
+		start := days[i][0]
+		heartbeats = append(heartbeats,
+			&models.Heartbeat{UserID: "user1", Project: "wakapi", Time: models.CustomTime(start.Add(10 * time.Hour))},
+			&models.Heartbeat{UserID: "user1", Project: "wakapi", Time: models.CustomTime(start.Add(11 * time.Hour))}, // same day
+		)
+	}
+	heartbeats = append(heartbeats,
+		&models.Heartbeat{UserID: "user1", Project: "other", Time: models.CustomTime(days[3][0].Add(time.Hour))},
+		&models.Heartbeat{UserID: "user1", Project: "wakapi", Time: models.CustomTime(from.Add(-time.Hour))}, // before the interval
+		&models.Heartbeat{UserID: "user2", Project: "wakapi", Time: models.CustomTime(days[7][0].Add(time.Hour))},
+	)
+	insertTestHeartbeats(t, db, heartbeats...)
+
+	sut := NewHeartbeatRepository(db)
+	user := &models.User{ID: "user1"}
+
+	count, err := sut.CountActiveDaysByUser(user, days, map[string][]string{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), count)
+
+	count, err = sut.CountActiveDaysByUser(user, days, map[string][]string{"project": {"wakapi"}})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(6), count)
+
+	count, err = sut.CountActiveDaysByUser(user, days[:activeDaysChunkSize], map[string][]string{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(4), count)
+
+	count, err = sut.CountActiveDaysByUser(user, nil, map[string][]string{})
+	assert.Nil(t, err)
+	assert.Zero(t, count)
+}
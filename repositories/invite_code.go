@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+)
+
+type InviteCodeRepository struct {
+	config *config.Config
+	db     *gorm.DB
+}
+
+func NewInviteCodeRepository(db *gorm.DB) *InviteCodeRepository {
+	return &InviteCodeRepository{config: config.Get(), db: db}
+}
+
+func (r *InviteCodeRepository) GetAll() ([]*models.InviteCode, error) {
+	var codes []*models.InviteCode
+	if err := r.db.Order("created_at desc").Find(&codes).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+func (r *InviteCodeRepository) GetByCode(code string) (*models.InviteCode, error) {
+	inviteCode := &models.InviteCode{}
+	if err := r.db.Where(&models.InviteCode{Code: code}).First(inviteCode).Error; err != nil {
+		return nil, err
+	}
+	return inviteCode, nil
+}
+
+func (r *InviteCodeRepository) Insert(code *models.InviteCode) (*models.InviteCode, error) {
+	if err := r.db.Create(code).Error; err != nil {
+		return nil, err
+	}
+	return code, nil
+}
+
+// IncrementUses atomically counts another use of the code, unless it was used up in the meantime
+func (r *InviteCodeRepository) IncrementUses(code string) error {
+	result := r.db.Model(&models.InviteCode{}).
+		Where("code = ?", code).
+		Where("max_uses = 0 OR uses < max_uses").
+		Update("uses", gorm.Expr("uses + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("invite code used up")
+	}
+	return nil
+}
+
+func (r *InviteCodeRepository) Delete(code string) error {
+	return r.db.Where("code = ?", code).Delete(&models.InviteCode{}).Error
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+)
+
+type LanguageGoalRepository struct {
+	config *config.Config
+	db     *gorm.DB
+}
+
+func NewLanguageGoalRepository(db *gorm.DB) *LanguageGoalRepository {
+	return &LanguageGoalRepository{config: config.Get(), db: db}
+}
+
+func (r *LanguageGoalRepository) GetAll() ([]*models.LanguageGoal, error) {
+	var goals []*models.LanguageGoal
+	if err := r.db.
+		Order("user_id asc").
+		Order("language asc").
+		Find(&goals).Error; err != nil {
+		return goals, err
+	}
+	return goals, nil
+}
+
+func (r *LanguageGoalRepository) GetByUser(userId string) ([]*models.LanguageGoal, error) {
+	var goals []*models.LanguageGoal
+	if err := r.db.
+		Where(&models.LanguageGoal{UserID: userId}).
+		Order("language asc").
+		Find(&goals).Error; err != nil {
+		return goals, err
+	}
+	return goals, nil
+}
+
+func (r *LanguageGoalRepository) Insert(goal *models.LanguageGoal) (*models.LanguageGoal, error) {
+	if err := r.db.Create(goal).Error; err != nil {
+		return nil, err
+	}
+	return goal, nil
+}
+
+func (r *LanguageGoalRepository) UpdateLastNotified(goal *models.LanguageGoal) error {
+	return r.db.Model(goal).Update("last_notified_at", goal.LastNotifiedAt).Error
+}
+
+func (r *LanguageGoalRepository) DeleteByUserAndId(userId string, id uint) error {
+	return r.db.
+		Where("user_id = ?", userId).
+		Where("id = ?", id).
+		Delete(models.LanguageGoal{}).Error
+}
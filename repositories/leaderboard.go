@@ -98,6 +98,15 @@ func (r *LeaderboardRepository) DeleteByUserAndInterval(userId string, key *mode
 	return nil
 }
 
+func (r *LeaderboardRepository) DeleteByInterval(key *models.IntervalKey) error {
+	if err := r.db.
+		Where("\"interval\" in ?", *key).
+		Delete(models.LeaderboardItem{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
 func (r *LeaderboardRepository) withPaging(q *gorm.DB, limit, skip int) *gorm.DB {
 	if limit > 0 {
 		q = q.Where("\"rank\" <= ?", skip+limit)
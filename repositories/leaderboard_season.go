@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+)
+
+type LeaderboardSeasonRepository struct {
+	db *gorm.DB
+}
+
+func NewLeaderboardSeasonRepository(db *gorm.DB) *LeaderboardSeasonRepository {
+	return &LeaderboardSeasonRepository{db: db}
+}
+
+func (r *LeaderboardSeasonRepository) GetAll() ([]*models.LeaderboardSeason, error) {
+	var seasons []*models.LeaderboardSeason
+	if err := r.db.
+		Order("from_time desc").
+		Find(&seasons).Error; err != nil {
+		return nil, err
+	}
+	return seasons, nil
+}
+
+func (r *LeaderboardSeasonRepository) GetByName(name string) (*models.LeaderboardSeason, error) {
+	season := &models.LeaderboardSeason{}
+	if err := r.db.
+		Where(&models.LeaderboardSeason{Name: name}).
+		First(season).Error; err != nil {
+		return nil, err
+	}
+	return season, nil
+}
+
+func (r *LeaderboardSeasonRepository) GetStandings(seasonId uint, limit, skip int) ([]*models.LeaderboardSeasonStanding, error) {
+	var standings []*models.LeaderboardSeasonStanding
+	q := r.db.
+		Where(&models.LeaderboardSeasonStanding{SeasonID: seasonId}).
+		Order("\"rank\" asc")
+	if limit > 0 {
+		q = q.Where("\"rank\" <= ?", skip+limit)
+	}
+	if skip > 0 {
+		q = q.Where("\"rank\" > ?", skip)
+	}
+	if err := q.Find(&standings).Error; err != nil {
+		return nil, err
+	}
+	return standings, nil
+}
+
+// Insert archives a season along with its final standings
+func (r *LeaderboardSeasonRepository) Insert(season *models.LeaderboardSeason, standings []*models.LeaderboardSeasonStanding) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(season).Error; err != nil {
+			return err
+		}
+		if len(standings) == 0 {
+			return nil
+		}
+		for _, s := range standings {
+			s.SeasonID = season.ID
+		}
+		return tx.CreateInBatches(standings, 1000).Error
+	})
+}
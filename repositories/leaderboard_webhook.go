@@ -0,0 +1,53 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LeaderboardWebhookRepository struct {
+	db *gorm.DB
+}
+
+func NewLeaderboardWebhookRepository(db *gorm.DB) *LeaderboardWebhookRepository {
+	return &LeaderboardWebhookRepository{db: db}
+}
+
+func (r *LeaderboardWebhookRepository) GetAll() ([]*models.LeaderboardWebhook, error) {
+	var webhooks []*models.LeaderboardWebhook
+	if err := r.db.Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (r *LeaderboardWebhookRepository) GetByUser(userId string) (*models.LeaderboardWebhook, error) {
+	webhook := &models.LeaderboardWebhook{}
+	if err := r.db.
+		Where(&models.LeaderboardWebhook{UserID: userId}).
+		First(webhook).Error; err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (r *LeaderboardWebhookRepository) Upsert(webhook *models.LeaderboardWebhook) error {
+	if !webhook.IsValid() {
+		return errors.New("invalid leaderboard webhook")
+	}
+	return r.db.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"url", "format", "secret", "min_rank_change", "notify_overtaken", "updated_at"}),
+		}).
+		Create(webhook).Error
+}
+
+func (r *LeaderboardWebhookRepository) DeleteByUser(userId string) error {
+	return r.db.
+		Where("user_id = ?", userId).
+		Delete(models.LeaderboardWebhook{}).Error
+}
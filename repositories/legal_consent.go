@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LegalConsentRepository struct {
+	db *gorm.DB
+}
+
+func NewLegalConsentRepository(db *gorm.DB) *LegalConsentRepository {
+	return &LegalConsentRepository{db: db}
+}
+
+func (r *LegalConsentRepository) CountByUserAndVersion(userId, version string) (int64, error) {
+	var count int64
+	if err := r.db.
+		Model(&models.LegalConsent{}).
+		Where(&models.LegalConsent{UserID: userId, Version: version}).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Insert keeps the original record, if the user had already accepted the same version before
+func (r *LegalConsentRepository) Insert(consent *models.LegalConsent) error {
+	return r.db.
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(consent).Error
+}
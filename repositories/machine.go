@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+)
+
+type MachineRepository struct {
+	config *config.Config
+	db     *gorm.DB
+}
+
+func NewMachineRepository(db *gorm.DB) *MachineRepository {
+	return &MachineRepository{config: config.Get(), db: db}
+}
+
+func (r *MachineRepository) GetByUser(userId string) ([]*models.Machine, error) {
+	var machines []*models.Machine
+	if err := r.db.
+		Where(&models.Machine{UserID: userId}).
+		Order("created_at desc").
+		Find(&machines).Error; err != nil {
+		return machines, err
+	}
+	return machines, nil
+}
+
+func (r *MachineRepository) GetByUserAndName(userId, name string) (*models.Machine, error) {
+	machine := &models.Machine{}
+	if err := r.db.
+		Where("user_id = ?", userId).
+		Where("name = ?", name).
+		First(machine).Error; err != nil {
+		return nil, err
+	}
+	return machine, nil
+}
+
+func (r *MachineRepository) Insert(machine *models.Machine) (*models.Machine, error) {
+	if err := r.db.Create(machine).Error; err != nil {
+		return nil, err
+	}
+	return machine, nil
+}
+
+func (r *MachineRepository) Update(machine *models.Machine) (*models.Machine, error) {
+	if err := r.db.Model(machine).Updates(map[string]interface{}{
+		"approved": machine.Approved,
+		"rejected": machine.Rejected,
+	}).Error; err != nil {
+		return nil, err
+	}
+	return machine, nil
+}
+
+func (r *MachineRepository) UpdateCountry(machine *models.Machine) (*models.Machine, error) {
+	if err := r.db.Model(machine).Update("country", machine.Country).Error; err != nil {
+		return nil, err
+	}
+	return machine, nil
+}
+
+func (r *MachineRepository) ClearCountryByUser(userId string) error {
+	return r.db.
+		Model(&models.Machine{}).
+		Where("user_id = ?", userId).
+		Update("country", "").Error
+}
+
+func (r *MachineRepository) Delete(id uint) error {
+	return r.db.
+		Where("id = ?", id).
+		Delete(models.Machine{}).Error
+}
+
+func (r *MachineRepository) InsertQuarantined(heartbeats []*models.QuarantinedHeartbeat) error {
+	if len(heartbeats) == 0 {
+		return nil
+	}
+	return r.db.Create(&heartbeats).Error
+}
+
+func (r *MachineRepository) GetQuarantinedByUserAndMachine(userId, machine string) ([]*models.QuarantinedHeartbeat, error) {
+	var heartbeats []*models.QuarantinedHeartbeat
+	if err := r.db.
+		Where("user_id = ?", userId).
+		Where("machine = ?", machine).
+		Order("id asc").
+		Find(&heartbeats).Error; err != nil {
+		return nil, err
+	}
+	return heartbeats, nil
+}
+
+func (r *MachineRepository) CountQuarantinedByUser(userId string) (map[string]int64, error) {
+	var results []struct {
+		Machine string
+		Count   int64
+	}
+	if err := r.db.
+		Model(&models.QuarantinedHeartbeat{}).
+		Select("machine, count(*) as count").
+		Where("user_id = ?", userId).
+		Group("machine").
+		Scan(&results).Error; err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int64, len(results))
+	for _, r := range results {
+		counts[r.Machine] = r.Count
+	}
+	return counts, nil
+}
+
+func (r *MachineRepository) CountQuarantinedByUserAndMachine(userId, machine string) (int64, error) {
+	var count int64
+	if err := r.db.
+		Model(&models.QuarantinedHeartbeat{}).
+		Where("user_id = ?", userId).
+		Where("machine = ?", machine).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *MachineRepository) DeleteQuarantinedByUserAndMachine(userId, machine string) error {
+	return r.db.
+		Where("user_id = ?", userId).
+		Where("machine = ?", machine).
+		Delete(models.QuarantinedHeartbeat{}).Error
+}
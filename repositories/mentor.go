@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+)
+
+type MentorRepository struct {
+	config *config.Config
+	db     *gorm.DB
+}
+
+func NewMentorRepository(db *gorm.DB) *MentorRepository {
+	return &MentorRepository{config: config.Get(), db: db}
+}
+
+func (r *MentorRepository) GetAll() ([]*models.Mentor, error) {
+	var mentors []*models.Mentor
+	if err := r.db.
+		Order("user_id asc").
+		Find(&mentors).Error; err != nil {
+		return nil, err
+	}
+	return mentors, nil
+}
+
+func (r *MentorRepository) GetByUser(userId string) ([]*models.Mentor, error) {
+	var mentors []*models.Mentor
+	if err := r.db.
+		Where(&models.Mentor{UserID: userId}).
+		Order("created_at asc").
+		Find(&mentors).Error; err != nil {
+		return nil, err
+	}
+	return mentors, nil
+}
+
+func (r *MentorRepository) GetByToken(token string) (*models.Mentor, error) {
+	if token == "" {
+		return nil, errors.New("invalid input")
+	}
+	mentor := &models.Mentor{}
+	if err := r.db.
+		Where(&models.Mentor{Token: token}).
+		First(mentor).Error; err != nil {
+		return nil, err
+	}
+	return mentor, nil
+}
+
+func (r *MentorRepository) Insert(mentor *models.Mentor) (*models.Mentor, error) {
+	if err := r.db.Create(mentor).Error; err != nil {
+		return nil, err
+	}
+	return mentor, nil
+}
+
+func (r *MentorRepository) DeleteByUserAndId(userId string, id uint) error {
+	return r.db.
+		Where("user_id = ?", userId).
+		Where("id = ?", id).
+		Delete(models.Mentor{}).Error
+}
+
+func (r *MentorRepository) DeleteByToken(token string) error {
+	if token == "" {
+		return errors.New("invalid input")
+	}
+	return r.db.
+		Where("token = ?", token).
+		Delete(models.Mentor{}).Error
+}
@@ -1,7 +1,11 @@
 package repositories
 
 import (
+	"time"
+
 	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/utils"
 	"gorm.io/gorm"
 )
 
@@ -41,3 +45,67 @@ func (srv *MetricsRepository) GetDatabaseSize() (size int64, err error) {
 	err = query.Scan(&size).Error
 	return size, err
 }
+
+// MeasureLatency runs a trivial query to determine the time it currently takes to get a response from the database
+func (srv *MetricsRepository) MeasureLatency() (time.Duration, error) {
+	var result int
+	t0 := time.Now()
+	err := srv.db.Raw("SELECT 1").Scan(&result).Error
+	return time.Since(t0), err
+}
+
+// GetStorageStatsByUser counts the rows stored per user in the biggest tables
+func (srv *MetricsRepository) GetStorageStatsByUser() ([]*models.UserStorageStats, error) {
+	statsMap := make(map[string]*models.UserStorageStats)
+	get := func(userId string) *models.UserStorageStats {
+		if _, ok := statsMap[userId]; !ok {
+			statsMap[userId] = &models.UserStorageStats{UserID: userId}
+		}
+		return statsMap[userId]
+	}
+
+	now := time.Now()
+
+	queries := []struct {
+		query *gorm.DB
+		apply func(*models.UserStorageStats, int64)
+	}{
+		{srv.db.Model(&models.Heartbeat{}), func(s *models.UserStorageStats, c int64) { s.Heartbeats = c }},
+		{srv.db.Model(&models.Heartbeat{}).Where("created_at >= ?", now.AddDate(0, 0, -7)), func(s *models.UserStorageStats, c int64) { s.HeartbeatsLast7d = c }},
+		{srv.db.Model(&models.Heartbeat{}).Where("created_at >= ?", now.AddDate(0, 0, -30)), func(s *models.UserStorageStats, c int64) { s.HeartbeatsLast30d = c }},
+		{srv.db.Model(&models.Summary{}), func(s *models.UserStorageStats, c int64) { s.Summaries = c }},
+		{srv.db.Model(&models.LeaderboardItem{}), func(s *models.UserStorageStats, c int64) { s.LeaderboardItems = c }},
+	}
+
+	for _, q := range queries {
+		var counts []*models.CountByUser
+		if err := q.query.
+			Select(utils.QuoteSql(srv.db, "user_id as %s, count(*) as %s", "user", "count")).
+			Group("user_id").
+			Find(&counts).Error; err != nil {
+			return nil, err
+		}
+		for _, c := range counts {
+			q.apply(get(c.User), c.Count)
+		}
+	}
+
+	var itemCounts []*models.CountByUser
+	if err := srv.db.
+		Table("summary_items").
+		Select(utils.QuoteSql(srv.db, "summaries.user_id as %s, count(*) as %s", "user", "count")).
+		Joins("inner join summaries on summaries.id = summary_items.summary_id").
+		Group("summaries.user_id").
+		Find(&itemCounts).Error; err != nil {
+		return nil, err
+	}
+	for _, c := range itemCounts {
+		get(c.User).SummaryItems = c.Count
+	}
+
+	stats := make([]*models.UserStorageStats, 0, len(statsMap))
+	for _, s := range statsMap {
+		stats = append(stats, s.Estimate())
+	}
+	return stats, nil
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PersonalRecordsRepository struct {
+	db *gorm.DB
+}
+
+func NewPersonalRecordsRepository(db *gorm.DB) *PersonalRecordsRepository {
+	return &PersonalRecordsRepository{db: db}
+}
+
+func (r *PersonalRecordsRepository) GetByUser(userId string) (*models.PersonalRecords, error) {
+	records := &models.PersonalRecords{}
+	if err := r.db.
+		Where(&models.PersonalRecords{UserID: userId}).
+		First(records).Error; err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+func (r *PersonalRecordsRepository) Upsert(records *models.PersonalRecords) error {
+	return r.db.
+		Clauses(clause.OnConflict{
+			UpdateAll: true,
+		}).
+		Create(records).Error
+}
+
+func (r *PersonalRecordsRepository) DeleteByUser(userId string) error {
+	return r.db.
+		Where("user_id = ?", userId).
+		Delete(&models.PersonalRecords{}).Error
+}
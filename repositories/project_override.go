@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ProjectOverrideRepository struct {
+	config *config.Config
+	db     *gorm.DB
+}
+
+func NewProjectOverrideRepository(db *gorm.DB) *ProjectOverrideRepository {
+	return &ProjectOverrideRepository{config: config.Get(), db: db}
+}
+
+func (r *ProjectOverrideRepository) GetByUser(userId string) ([]*models.ProjectOverride, error) {
+	if userId == "" {
+		return []*models.ProjectOverride{}, nil
+	}
+	var overrides []*models.ProjectOverride
+	if err := r.db.
+		Where(&models.ProjectOverride{UserID: userId}).
+		Order("path asc").
+		Find(&overrides).Error; err != nil {
+		return overrides, err
+	}
+	return overrides, nil
+}
+
+func (r *ProjectOverrideRepository) Upsert(override *models.ProjectOverride) (*models.ProjectOverride, error) {
+	if !override.IsValid() {
+		return nil, errors.New("invalid project override")
+	}
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "path"}},
+		DoUpdates: clause.AssignmentColumns([]string{"project"}),
+	}).Create(override)
+	if err := result.Error; err != nil {
+		return nil, err
+	}
+	return override, nil
+}
+
+func (r *ProjectOverrideRepository) DeleteByUserAndPath(userId, path string) error {
+	return r.db.
+		Where("user_id = ?", userId).
+		Where("path = ?", path).
+		Delete(models.ProjectOverride{}).Error
+}
@@ -0,0 +1,78 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+)
+
+// QueryConsoleRepository runs arbitrary statements of admins on a dedicated, possibly restricted connection
+type QueryConsoleRepository struct {
+	config *config.Config
+	db     *gorm.DB
+}
+
+func NewQueryConsoleRepository(db *gorm.DB) *QueryConsoleRepository {
+	return &QueryConsoleRepository{config: config.Get(), db: db}
+}
+
+// Query runs the statement inside a read-only transaction, which is always rolled back, and returns at most maxRows rows
+func (r *QueryConsoleRepository) Query(ctx context.Context, query string, maxRows int) (*models.QueryConsoleResult, error) {
+	tx := r.db.WithContext(ctx).Begin(&sql.TxOptions{ReadOnly: true})
+	if tx.Error != nil {
+		return nil, tx.Error
+	}
+	defer tx.Rollback()
+
+	if deadline, ok := ctx.Deadline(); ok && r.config.Db.IsPostgres() {
+		// cancel on the server as well, instead of only abandoning the connection
+		if err := tx.Exec(fmt.Sprintf("SET LOCAL statement_timeout = %d", time.Until(deadline).Milliseconds())).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := tx.Raw(query).Rows()
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.QueryConsoleResult{Columns: columns, Rows: make([][]interface{}, 0)}
+	for rows.Next() {
+		if len(result.Rows) >= maxRows {
+			result.Truncated = true
+			break
+		}
+
+		values := make([]interface{}, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				// drivers return text as bytes in many cases, which would otherwise be serialized as base64
+				values[i] = string(b)
+				if !utf8.Valid(b) {
+					values[i] = fmt.Sprintf("<%d bytes>", len(b))
+				}
+			}
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+)
+
+type RelayRuleRepository struct {
+	config *config.Config
+	db     *gorm.DB
+}
+
+func NewRelayRuleRepository(db *gorm.DB) *RelayRuleRepository {
+	return &RelayRuleRepository{config: config.Get(), db: db}
+}
+
+func (r *RelayRuleRepository) GetByUser(userId string) ([]*models.RelayRule, error) {
+	if userId == "" {
+		return []*models.RelayRule{}, nil
+	}
+	var rules []*models.RelayRule
+	if err := r.db.
+		Where(&models.RelayRule{UserID: userId}).
+		Order("id asc").
+		Find(&rules).Error; err != nil {
+		return rules, err
+	}
+	return rules, nil
+}
+
+func (r *RelayRuleRepository) Insert(rule *models.RelayRule) (*models.RelayRule, error) {
+	if !rule.IsValid() {
+		return nil, errors.New("invalid relay rule")
+	}
+	if err := r.db.Create(rule).Error; err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (r *RelayRuleRepository) DeleteByUserAndId(userId string, id uint) error {
+	return r.db.
+		Where("user_id = ?", userId).
+		Where("id = ?", id).
+		Delete(models.RelayRule{}).Error
+}
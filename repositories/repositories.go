@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"time"
 
 	"github.com/hackclub/hackatime/models"
@@ -18,10 +19,12 @@ type IAliasRepository interface {
 }
 
 type IHeartbeatRepository interface {
+	WithContext(context.Context) IHeartbeatRepository
 	InsertBatch([]*models.Heartbeat) error
 	GetAll() ([]*models.Heartbeat, error)
 	GetAllWithin(time.Time, time.Time, *models.User) ([]*models.Heartbeat, error)
 	GetAllWithinByFilters(time.Time, time.Time, *models.User, map[string][]string) ([]*models.Heartbeat, error)
+	CountActiveDaysByUser(*models.User, [][]time.Time, map[string][]string) (int64, error)
 	GetLatestByFilters(*models.User, map[string][]string) (*models.Heartbeat, error)
 	GetFirstByUsers() ([]*models.TimeByUser, error)
 	GetLastByUsers() ([]*models.TimeByUser, error)
@@ -35,6 +38,18 @@ type IHeartbeatRepository interface {
 	DeleteByUser(*models.User) error
 	DeleteByUserBefore(*models.User, time.Time) error
 	GetUserProjectStats(*models.User, time.Time, time.Time, int, int) ([]*models.ProjectStats, error)
+	GetUserAgentStats(*models.User) ([]*models.UserAgentStats, error)
+	GetMachineStats(*models.User) ([]*models.MachineStats, error)
+	GetDistinctValues(string) ([]string, error)
+	GetFirstByUsersWithValues(string, []string) ([]*models.TimeByUser, error)
+	ReplaceValues(string, []string, string) (int64, error)
+	GetProjectIntervalByUser(*models.User, string) (*models.Interval, error)
+	RenameProject(*models.User, string, string, time.Time, time.Time) (int64, error)
+	GetMachineIntervalByUser(*models.User, string) (*models.Interval, error)
+	CopyMachineToUser(*models.User, *models.User, string, time.Time, time.Time) (int64, error)
+	DeleteByUserAndMachineBefore(*models.User, string, time.Time) (int64, error)
+	GetAllByUserAfterId(*models.User, uint64, int) ([]*models.Heartbeat, error)
+	GetDeletionsByUserAfterId(*models.User, uint64, int) ([]*models.HeartbeatDeletion, error)
 }
 
 type IDiagnosticsRepository interface {
@@ -65,17 +80,135 @@ type IProjectLabelRepository interface {
 	Delete(uint) error
 }
 
+type IInviteCodeRepository interface {
+	GetAll() ([]*models.InviteCode, error)
+	GetByCode(string) (*models.InviteCode, error)
+	Insert(*models.InviteCode) (*models.InviteCode, error)
+	IncrementUses(string) error
+	Delete(string) error
+}
+
+type ICanonicalNameRepository interface {
+	GetAll() ([]*models.CanonicalName, error)
+	GetById(uint) (*models.CanonicalName, error)
+	Insert(*models.CanonicalName) (*models.CanonicalName, error)
+	Delete(uint) error
+}
+
+type IConcurrencyRepository interface {
+	GetWithin(time.Time, time.Time) ([]*models.ConcurrencySample, error)
+	Upsert(*models.ConcurrencySample) error
+	DeleteBefore(time.Time) error
+}
+
+type IUserPreferenceRepository interface {
+	GetByUserAndNamespace(string, string) ([]*models.UserPreference, error)
+	Upsert(*models.UserPreference) (*models.UserPreference, error)
+	DeleteByUserAndNamespaceAndKey(string, string, string) error
+	DeleteByUserAndNamespace(string, string) error
+}
+
+type IProjectMetadataRepository interface {
+	GetByUser(string) ([]*models.ProjectMetadata, error)
+	Upsert(*models.ProjectMetadata) (*models.ProjectMetadata, error)
+	DeleteByUserAndProject(string, string) error
+}
+
+type IProjectOverrideRepository interface {
+	GetByUser(string) ([]*models.ProjectOverride, error)
+	Upsert(*models.ProjectOverride) (*models.ProjectOverride, error)
+	DeleteByUserAndPath(string, string) error
+}
+
+type IRelayRuleRepository interface {
+	GetByUser(string) ([]*models.RelayRule, error)
+	Insert(*models.RelayRule) (*models.RelayRule, error)
+	DeleteByUserAndId(string, uint) error
+}
+
+type ITagRuleRepository interface {
+	GetByUser(string) ([]*models.TagRule, error)
+	Insert(*models.TagRule) (*models.TagRule, error)
+	DeleteByUserAndId(string, uint) error
+}
+
+type ITeamRepository interface {
+	GetAll() ([]*models.Team, error)
+	GetById(uint) (*models.Team, error)
+	Insert(*models.Team) (*models.Team, error)
+	Update(*models.Team) (*models.Team, error)
+	Delete(uint) error
+	InsertMember(*models.TeamMember) (*models.TeamMember, error)
+	DeleteMember(uint, string) error
+}
+
+type ICompareConsentRepository interface {
+	GetByUser(string) ([]*models.CompareConsent, error)
+	Exists(string, string) (bool, error)
+	Insert(*models.CompareConsent) (*models.CompareConsent, error)
+	Delete(string, string) error
+}
+
+type ITimeEntryRepository interface {
+	GetByUserAndId(string, uint) (*models.TimeEntry, error)
+	GetByUserAndExternalId(string, string) (*models.TimeEntry, error)
+	GetByUserWithin(string, time.Time, time.Time) ([]*models.TimeEntry, error)
+	Insert(*models.TimeEntry) (*models.TimeEntry, error)
+	Update(*models.TimeEntry) (*models.TimeEntry, error)
+	DeleteByUserAndId(string, uint) error
+	RenameProject(string, string, string) (int64, error)
+}
+
+type ITrackingPauseRepository interface {
+	GetByUser(string) ([]*models.TrackingPause, error)
+	Insert(*models.TrackingPause) (*models.TrackingPause, error)
+	Update(*models.TrackingPause) (*models.TrackingPause, error)
+	DeleteByUserAndId(string, uint) error
+}
+
+type ILanguageGoalRepository interface {
+	GetAll() ([]*models.LanguageGoal, error)
+	GetByUser(string) ([]*models.LanguageGoal, error)
+	Insert(*models.LanguageGoal) (*models.LanguageGoal, error)
+	UpdateLastNotified(*models.LanguageGoal) error
+	DeleteByUserAndId(string, uint) error
+}
+
+type IUserAvatarRepository interface {
+	GetByUser(string) (*models.UserAvatar, error)
+	Upsert(*models.UserAvatar) error
+	DeleteByUser(string) error
+}
+
+type IMachineRepository interface {
+	GetByUser(string) ([]*models.Machine, error)
+	GetByUserAndName(string, string) (*models.Machine, error)
+	Insert(*models.Machine) (*models.Machine, error)
+	Update(*models.Machine) (*models.Machine, error)
+	UpdateCountry(*models.Machine) (*models.Machine, error)
+	ClearCountryByUser(string) error
+	Delete(uint) error
+	InsertQuarantined([]*models.QuarantinedHeartbeat) error
+	GetQuarantinedByUserAndMachine(string, string) ([]*models.QuarantinedHeartbeat, error)
+	CountQuarantinedByUser(string) (map[string]int64, error)
+	CountQuarantinedByUserAndMachine(string, string) (int64, error)
+	DeleteQuarantinedByUserAndMachine(string, string) error
+}
+
 type ISummaryRepository interface {
+	WithContext(context.Context) ISummaryRepository
 	Insert(*models.Summary) error
 	GetAll() ([]*models.Summary, error)
 	GetByUserWithin(*models.User, time.Time, time.Time) ([]*models.Summary, error)
 	GetLastByUser() ([]*models.TimeByUser, error)
 	DeleteByUser(string) error
 	DeleteByUserBefore(string, time.Time) error
+	DeleteByUserWithin(string, time.Time, time.Time) error
 }
 
 type IUserRepository interface {
 	FindOne(user models.User) (*models.User, error)
+	GetByApiKeyPrefix(string) ([]*models.User, error)
 	GetByIds([]string) ([]*models.User, error)
 	GetAll() ([]*models.User, error)
 	GetMany([]string) ([]*models.User, error)
@@ -91,6 +224,37 @@ type IUserRepository interface {
 	Delete(*models.User) error
 }
 
+type IPersonalRecordsRepository interface {
+	GetByUser(string) (*models.PersonalRecords, error)
+	Upsert(*models.PersonalRecords) error
+	DeleteByUser(string) error
+}
+
+type ILegalConsentRepository interface {
+	CountByUserAndVersion(string, string) (int64, error)
+	Insert(*models.LegalConsent) error
+}
+
+type IYearReviewRepository interface {
+	GetByUserAndYear(string, int) (*models.YearReview, error)
+	GetByShareToken(string) (*models.YearReview, error)
+	Upsert(*models.YearReview) error
+	DeleteByUser(string) error
+	DeleteOrphaned() (int64, error)
+}
+
+type IUserExternalIdRepository interface {
+	GetByProviderAndExternalId(string, string) (*models.UserExternalId, error)
+	GetByUser(string) ([]*models.UserExternalId, error)
+	Upsert(*models.UserExternalId) error
+	DeleteByUserAndProvider(string, string) (int64, error)
+}
+
+type IActivityGraphRepository interface {
+	GetByUserAndYear(string, int) (*models.ActivityGraph, error)
+	Upsert(*models.ActivityGraph) error
+}
+
 type ILeaderboardRepository interface {
 	InsertBatch([]*models.LeaderboardItem) error
 	CountAllByUser(string) (int64, error)
@@ -99,4 +263,38 @@ type ILeaderboardRepository interface {
 	DeleteByUserAndInterval(string, *models.IntervalKey) error
 	GetAllAggregatedByInterval(*models.IntervalKey, *uint8, int, int) ([]*models.LeaderboardItemRanked, error)
 	GetAggregatedByUserAndInterval(string, *models.IntervalKey, *uint8, int, int) ([]*models.LeaderboardItemRanked, error)
+	DeleteByInterval(*models.IntervalKey) error
+}
+
+type ILeaderboardSeasonRepository interface {
+	GetAll() ([]*models.LeaderboardSeason, error)
+	GetByName(string) (*models.LeaderboardSeason, error)
+	GetStandings(uint, int, int) ([]*models.LeaderboardSeasonStanding, error)
+	Insert(*models.LeaderboardSeason, []*models.LeaderboardSeasonStanding) error
+}
+
+type IMetricsRepository interface {
+	GetDatabaseSize() (int64, error)
+	MeasureLatency() (time.Duration, error)
+	GetStorageStatsByUser() ([]*models.UserStorageStats, error)
+}
+
+type IQueryConsoleRepository interface {
+	Query(context.Context, string, int) (*models.QueryConsoleResult, error)
+}
+
+type ILeaderboardWebhookRepository interface {
+	GetAll() ([]*models.LeaderboardWebhook, error)
+	GetByUser(string) (*models.LeaderboardWebhook, error)
+	Upsert(*models.LeaderboardWebhook) error
+	DeleteByUser(string) error
+}
+
+type IMentorRepository interface {
+	GetAll() ([]*models.Mentor, error)
+	GetByUser(string) ([]*models.Mentor, error)
+	GetByToken(string) (*models.Mentor, error)
+	Insert(*models.Mentor) (*models.Mentor, error)
+	DeleteByUserAndId(string, uint) error
+	DeleteByToken(string) error
 }
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"time"
 
 	"github.com/duke-git/lancet/v2/slice"
@@ -18,6 +19,11 @@ func NewSummaryRepository(db *gorm.DB) *SummaryRepository {
 	return &SummaryRepository{db: db}
 }
 
+// WithContext returns a copy of the repository, whose queries are canceled once the given context is done
+func (r *SummaryRepository) WithContext(ctx context.Context) ISummaryRepository {
+	return &SummaryRepository{db: r.db.WithContext(ctx)}
+}
+
 func (r *SummaryRepository) GetAll() ([]*models.Summary, error) {
 	var summaries []*models.Summary
 	if err := r.db.
@@ -142,6 +148,17 @@ func (r *SummaryRepository) DeleteByUserBefore(userId string, t time.Time) error
 	return nil
 }
 
+func (r *SummaryRepository) DeleteByUserWithin(userId string, from, to time.Time) error {
+	if err := r.db.
+		Where("user_id = ?", userId).
+		Where("from_time >= ?", from.Local()).
+		Where("to_time <= ?", to.Local()).
+		Delete(models.Summary{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
 // inplace
 func (r *SummaryRepository) populateItems(summaries []*models.Summary, conditions []clause.Interface) error {
 	var items []*models.SummaryItem
@@ -0,0 +1,49 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+)
+
+type TagRuleRepository struct {
+	config *config.Config
+	db     *gorm.DB
+}
+
+func NewTagRuleRepository(db *gorm.DB) *TagRuleRepository {
+	return &TagRuleRepository{config: config.Get(), db: db}
+}
+
+func (r *TagRuleRepository) GetByUser(userId string) ([]*models.TagRule, error) {
+	if userId == "" {
+		return []*models.TagRule{}, nil
+	}
+	var rules []*models.TagRule
+	if err := r.db.
+		Where(&models.TagRule{UserID: userId}).
+		Order("id asc").
+		Find(&rules).Error; err != nil {
+		return rules, err
+	}
+	return rules, nil
+}
+
+func (r *TagRuleRepository) Insert(rule *models.TagRule) (*models.TagRule, error) {
+	if !rule.IsValid() {
+		return nil, errors.New("invalid tag rule")
+	}
+	if err := r.db.Create(rule).Error; err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (r *TagRuleRepository) DeleteByUserAndId(userId string, id uint) error {
+	return r.db.
+		Where("user_id = ?", userId).
+		Where("id = ?", id).
+		Delete(models.TagRule{}).Error
+}
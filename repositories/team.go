@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+)
+
+type TeamRepository struct {
+	config *config.Config
+	db     *gorm.DB
+}
+
+func NewTeamRepository(db *gorm.DB) *TeamRepository {
+	return &TeamRepository{config: config.Get(), db: db}
+}
+
+func (r *TeamRepository) GetAll() ([]*models.Team, error) {
+	var teams []*models.Team
+	if err := r.db.Preload("Members").Order("name asc").Find(&teams).Error; err != nil {
+		return nil, err
+	}
+	return teams, nil
+}
+
+func (r *TeamRepository) GetById(id uint) (*models.Team, error) {
+	team := &models.Team{}
+	if err := r.db.Preload("Members").Where(&models.Team{ID: id}).First(team).Error; err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+func (r *TeamRepository) Insert(team *models.Team) (*models.Team, error) {
+	if err := r.db.Omit("Members").Create(team).Error; err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+func (r *TeamRepository) Update(team *models.Team) (*models.Team, error) {
+	if err := r.db.Model(team).Select("name", "slack_webhook_url", "digest_template", "work_days", "work_start", "work_end").Updates(team).Error; err != nil {
+		return nil, err
+	}
+	return team, nil
+}
+
+func (r *TeamRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_id = ?", id).Delete(&models.TeamMember{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(&models.Team{}).Error
+	})
+}
+
+func (r *TeamRepository) InsertMember(member *models.TeamMember) (*models.TeamMember, error) {
+	if err := r.db.Create(member).Error; err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+func (r *TeamRepository) DeleteMember(teamId uint, userId string) error {
+	return r.db.
+		Where("team_id = ?", teamId).
+		Where("user_id = ?", userId).
+		Delete(&models.TeamMember{}).Error
+}
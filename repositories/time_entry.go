@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+)
+
+type TimeEntryRepository struct {
+	config *config.Config
+	db     *gorm.DB
+}
+
+func NewTimeEntryRepository(db *gorm.DB) *TimeEntryRepository {
+	return &TimeEntryRepository{config: config.Get(), db: db}
+}
+
+func (r *TimeEntryRepository) GetByUserAndId(userId string, id uint) (*models.TimeEntry, error) {
+	var entry models.TimeEntry
+	if err := r.db.
+		Where(&models.TimeEntry{UserID: userId, ID: id}).
+		First(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *TimeEntryRepository) GetByUserAndExternalId(userId, externalId string) (*models.TimeEntry, error) {
+	var entry models.TimeEntry
+	if err := r.db.
+		Where(&models.TimeEntry{UserID: userId, Type: models.TimeEntryTypeExternal, ExternalID: externalId}).
+		First(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetByUserWithin returns all of a user's entries, which overlap the given interval
+func (r *TimeEntryRepository) GetByUserWithin(userId string, from, to time.Time) ([]*models.TimeEntry, error) {
+	var entries []*models.TimeEntry
+	if err := r.db.
+		Where(&models.TimeEntry{UserID: userId}).
+		Where("from_time < ?", to.Local()).
+		Where("to_time > ?", from.Local()).
+		Order("from_time asc").
+		Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *TimeEntryRepository) Insert(entry *models.TimeEntry) (*models.TimeEntry, error) {
+	if !entry.IsValid() {
+		return nil, errors.New("invalid time entry")
+	}
+	if err := r.db.Create(entry).Error; err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (r *TimeEntryRepository) Update(entry *models.TimeEntry) (*models.TimeEntry, error) {
+	if !entry.IsValid() {
+		return nil, errors.New("invalid time entry")
+	}
+	if err := r.db.Model(entry).Select("project", "branch", "language", "category", "note", "from_time", "to_time").Updates(entry).Error; err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (r *TimeEntryRepository) DeleteByUserAndId(userId string, id uint) error {
+	return r.db.
+		Where("user_id = ?", userId).
+		Where("id = ?", id).
+		Delete(models.TimeEntry{}).Error
+}
+
+func (r *TimeEntryRepository) RenameProject(userId, oldProject, newProject string) (int64, error) {
+	result := r.db.
+		Model(&models.TimeEntry{}).
+		Where("user_id = ?", userId).
+		Where("project = ?", oldProject).
+		Update("project", newProject)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+)
+
+type TrackingPauseRepository struct {
+	config *config.Config
+	db     *gorm.DB
+}
+
+func NewTrackingPauseRepository(db *gorm.DB) *TrackingPauseRepository {
+	return &TrackingPauseRepository{config: config.Get(), db: db}
+}
+
+func (r *TrackingPauseRepository) GetByUser(userId string) ([]*models.TrackingPause, error) {
+	var pauses []*models.TrackingPause
+	if err := r.db.
+		Where(&models.TrackingPause{UserID: userId}).
+		Order("from_time asc").
+		Find(&pauses).Error; err != nil {
+		return nil, err
+	}
+	return pauses, nil
+}
+
+func (r *TrackingPauseRepository) Insert(pause *models.TrackingPause) (*models.TrackingPause, error) {
+	if !pause.IsValid() {
+		return nil, errors.New("invalid tracking pause")
+	}
+	if err := r.db.Create(pause).Error; err != nil {
+		return nil, err
+	}
+	return pause, nil
+}
+
+func (r *TrackingPauseRepository) Update(pause *models.TrackingPause) (*models.TrackingPause, error) {
+	if !pause.IsValid() {
+		return nil, errors.New("invalid tracking pause")
+	}
+	if err := r.db.Model(pause).Select("to_time", "note").Updates(pause).Error; err != nil {
+		return nil, err
+	}
+	return pause, nil
+}
+
+func (r *TrackingPauseRepository) DeleteByUserAndId(userId string, id uint) error {
+	return r.db.
+		Where("user_id = ?", userId).
+		Where("id = ?", id).
+		Delete(models.TrackingPause{}).Error
+}
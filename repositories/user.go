@@ -7,17 +7,19 @@ import (
 
 	"github.com/duke-git/lancet/v2/condition"
 
+	conf "github.com/hackclub/hackatime/config"
 	"github.com/hackclub/hackatime/models"
 	"github.com/hackclub/hackatime/utils"
 	"gorm.io/gorm"
 )
 
 type UserRepository struct {
-	db *gorm.DB
+	config *conf.Config
+	db     *gorm.DB
 }
 
 func NewUserRepository(db *gorm.DB) *UserRepository {
-	return &UserRepository{db: db}
+	return &UserRepository{config: conf.Get(), db: db}
 }
 
 func (r *UserRepository) FindOne(attributes models.User) (*models.User, error) {
@@ -28,6 +30,17 @@ func (r *UserRepository) FindOne(attributes models.User) (*models.User, error) {
 	return u, nil
 }
 
+// GetByApiKeyPrefix returns all users whose sealed api key starts with the given prefix, it's up to the caller to verify the actual key
+func (r *UserRepository) GetByApiKeyPrefix(prefix string) ([]*models.User, error) {
+	var users []*models.User
+	if err := r.db.
+		Where(&models.User{ApiKeyPrefix: prefix}).
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 func (r *UserRepository) GetByIds(userIds []string) ([]*models.User, error) {
 	var users []*models.User
 	if err := r.db.
@@ -118,7 +131,13 @@ func (r *UserRepository) InsertOrGet(user *models.User) (*models.User, bool, err
 		return u, false, nil
 	}
 
+	apiKey := user.ApiKey
+	if err := r.sealApiKey(user); err != nil {
+		return nil, false, err
+	}
+
 	result := r.db.Create(user)
+	user.ApiKey = apiKey
 	if err := result.Error; err != nil {
 		return nil, false, err
 	}
@@ -127,11 +146,20 @@ func (r *UserRepository) InsertOrGet(user *models.User) (*models.User, bool, err
 }
 
 func (r *UserRepository) Update(user *models.User) (*models.User, error) {
+	apiKey := user.ApiKey
+	if err := r.sealApiKey(user); err != nil {
+		return nil, err
+	}
+	defer func() { user.ApiKey = apiKey }()
+
 	updateMap := map[string]interface{}{
 		"name":                     user.Name,
-		"api_key":                  user.ApiKey,
+		"api_key":                  condition.TernaryOperator[bool, interface{}](user.ApiKey == "", nil, user.ApiKey),
+		"api_key_prefix":           user.ApiKeyPrefix,
+		"api_key_sealed":           user.ApiKeySealed,
 		"password":                 user.Password,
 		"email":                    user.Email,
+		"email_verified":           user.EmailVerified,
 		"last_logged_in_at":        user.LastLoggedInAt,
 		"share_data_max_days":      user.ShareDataMaxDays,
 		"share_editors":            user.ShareEditors,
@@ -153,6 +181,39 @@ func (r *UserRepository) Update(user *models.User) (*models.User, error) {
 		"invited_by":               user.InvitedBy,
 		"exclude_unknown_projects": user.ExcludeUnknownProjects,
 		"heartbeats_timeout_sec":   user.HeartbeatsTimeoutSec,
+		"require_machine_approval": user.RequireMachineApproval,
+		"unix_entity_separators":   user.UnixEntitySeparators,
+		"scrub_entity_home_dirs":   user.ScrubEntityHomeDirs,
+		"relative_entity_paths":    user.RelativeEntityPaths,
+		"hide_project_names":       user.HideProjectNames,
+		"archived_projects":        user.ArchivedProjects,
+		"clock_skew_events":        user.ClockSkewEvents,
+		"last_clock_skew_at":       user.LastClockSkewAt,
+		"simple_token":             user.SimpleToken,
+		"first_day_of_week":        user.FirstDayOfWeek,
+		"deactivated":              user.Deactivated,
+		"is_service_account":       user.IsServiceAccount,
+		"invite_pending":           user.InvitePending,
+		"suspended_at":             user.SuspendedAt,
+		"suspension_reason":        user.SuspensionReason,
+		"inactivity_alert_hours":   user.InactivityAlertHours,
+		"last_inactivity_alert_at": user.LastInactivityAlertAt,
+		"public_profile":           user.PublicProfile,
+		"profile_languages":        user.ProfileLanguages,
+		"profile_projects":         user.ProfileProjects,
+		"profile_activity":         user.ProfileActivity,
+		"bio":                      user.Bio,
+		"profile_location":         user.ProfileLocation,
+		"avatar_source":            user.AvatarSource,
+		"avatar_updated_at":        user.AvatarUpdatedAt,
+		"ingestion_blocklist":      user.IngestionBlocklist,
+		"scrapbook_enabled":        user.ScrapbookEnabled,
+		"scrapbook_template":       user.ScrapbookTemplate,
+		"last_scrapbook_post_at":   user.LastScrapbookPostAt,
+		"duration_rounding_min":    user.DurationRoundingMin,
+		"duration_format":          user.DurationFormat,
+		"locale":                   user.Locale,
+		"overlap_strategy":         user.OverlapStrategy,
 	}
 
 	result := r.db.Model(user).Updates(updateMap)
@@ -180,6 +241,24 @@ func (r *UserRepository) Delete(user *models.User) error {
 	return r.db.Delete(user).Error
 }
 
+// sealApiKey hashes or encrypts the user's api key, if configured, and blanks the plain one, which must be restored by the caller after writing
+func (r *UserRepository) sealApiKey(user *models.User) error {
+	store := r.config.Security.ApiKeyStore()
+	if store == nil || user.ApiKey == "" {
+		return nil
+	}
+	if user.ApiKeySealed == "" || !store.Verify(user.ApiKey, user.ApiKeySealed) {
+		sealed, err := store.Seal(user.ApiKey)
+		if err != nil {
+			return err
+		}
+		user.ApiKeyPrefix = utils.ApiKeyPrefix(user.ApiKey)
+		user.ApiKeySealed = sealed
+	}
+	user.ApiKey = ""
+	return nil
+}
+
 func (r *UserRepository) getByLoggedIn(t time.Time, after bool) ([]*models.User, error) {
 	var users []*models.User
 	comparator := condition.TernaryOperator[bool, string](after, ">=", "<=")
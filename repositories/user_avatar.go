@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserAvatarRepository struct {
+	db *gorm.DB
+}
+
+func NewUserAvatarRepository(db *gorm.DB) *UserAvatarRepository {
+	return &UserAvatarRepository{db: db}
+}
+
+func (r *UserAvatarRepository) GetByUser(userId string) (*models.UserAvatar, error) {
+	avatar := &models.UserAvatar{}
+	if err := r.db.
+		Where(&models.UserAvatar{UserID: userId}).
+		First(avatar).Error; err != nil {
+		return nil, err
+	}
+	return avatar, nil
+}
+
+func (r *UserAvatarRepository) Upsert(avatar *models.UserAvatar) error {
+	return r.db.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"data", "content_type", "updated_at"}),
+		}).
+		Create(avatar).Error
+}
+
+func (r *UserAvatarRepository) DeleteByUser(userId string) error {
+	return r.db.
+		Where("user_id = ?", userId).
+		Delete(models.UserAvatar{}).Error
+}
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserExternalIdRepository struct {
+	db *gorm.DB
+}
+
+func NewUserExternalIdRepository(db *gorm.DB) *UserExternalIdRepository {
+	return &UserExternalIdRepository{db: db}
+}
+
+func (r *UserExternalIdRepository) GetByProviderAndExternalId(provider, externalId string) (*models.UserExternalId, error) {
+	result := &models.UserExternalId{}
+	if err := r.db.
+		Where(&models.UserExternalId{Provider: provider, ExternalId: externalId}).
+		First(result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *UserExternalIdRepository) GetByUser(userId string) ([]*models.UserExternalId, error) {
+	var result []*models.UserExternalId
+	if err := r.db.
+		Where(&models.UserExternalId{UserID: userId}).
+		Order("provider asc").
+		Find(&result).Error; err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// Upsert replaces the user's previous id of the same provider
+func (r *UserExternalIdRepository) Upsert(externalId *models.UserExternalId) error {
+	return r.db.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "provider"}},
+			DoUpdates: clause.AssignmentColumns([]string{"external_id"}),
+		}).
+		Create(externalId).Error
+}
+
+func (r *UserExternalIdRepository) DeleteByUserAndProvider(userId, provider string) (int64, error) {
+	result := r.db.
+		Where("user_id = ?", userId).
+		Where("provider = ?", provider).
+		Delete(&models.UserExternalId{})
+	return result.RowsAffected, result.Error
+}
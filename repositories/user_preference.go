@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type UserPreferenceRepository struct {
+	config *config.Config
+	db     *gorm.DB
+}
+
+func NewUserPreferenceRepository(db *gorm.DB) *UserPreferenceRepository {
+	return &UserPreferenceRepository{config: config.Get(), db: db}
+}
+
+func (r *UserPreferenceRepository) GetByUserAndNamespace(userId, namespace string) ([]*models.UserPreference, error) {
+	var preferences []*models.UserPreference
+	if err := r.db.
+		Where("user_id = ?", userId).
+		Where("namespace = ?", namespace).
+		Order("pref_key asc").
+		Find(&preferences).Error; err != nil {
+		return preferences, err
+	}
+	return preferences, nil
+}
+
+func (r *UserPreferenceRepository) Upsert(preference *models.UserPreference) (*models.UserPreference, error) {
+	if !preference.IsValid() {
+		return nil, errors.New("invalid preference")
+	}
+	result := r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "namespace"}, {Name: "pref_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value"}),
+	}).Create(preference)
+	if err := result.Error; err != nil {
+		return nil, err
+	}
+	return preference, nil
+}
+
+func (r *UserPreferenceRepository) DeleteByUserAndNamespaceAndKey(userId, namespace, key string) error {
+	return r.db.
+		Where("user_id = ?", userId).
+		Where("namespace = ?", namespace).
+		Where("pref_key = ?", key).
+		Delete(models.UserPreference{}).Error
+}
+
+func (r *UserPreferenceRepository) DeleteByUserAndNamespace(userId, namespace string) error {
+	return r.db.
+		Where("user_id = ?", userId).
+		Where("namespace = ?", namespace).
+		Delete(models.UserPreference{}).Error
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type YearReviewRepository struct {
+	db *gorm.DB
+}
+
+func NewYearReviewRepository(db *gorm.DB) *YearReviewRepository {
+	return &YearReviewRepository{db: db}
+}
+
+func (r *YearReviewRepository) GetByUserAndYear(userId string, year int) (*models.YearReview, error) {
+	review := &models.YearReview{}
+	if err := r.db.
+		Where(&models.YearReview{UserID: userId, Year: year}).
+		First(review).Error; err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (r *YearReviewRepository) GetByShareToken(token string) (*models.YearReview, error) {
+	review := &models.YearReview{}
+	if err := r.db.
+		Where(&models.YearReview{ShareToken: token}).
+		First(review).Error; err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// Upsert replaces a previously generated review for the same user and year, but keeps its share token, so shared links remain valid
+func (r *YearReviewRepository) Upsert(review *models.YearReview) error {
+	return r.db.
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}, {Name: "year"}},
+			DoUpdates: clause.AssignmentColumns([]string{"stats", "created_at"}),
+		}).
+		Create(review).Error
+}
+
+func (r *YearReviewRepository) DeleteByUser(userId string) error {
+	return r.db.
+		Where("user_id = ?", userId).
+		Delete(&models.YearReview{}).Error
+}
+
+// DeleteOrphaned removes reviews of users which no longer exist and returns their number
+func (r *YearReviewRepository) DeleteOrphaned() (int64, error) {
+	result := r.db.
+		Where("user_id not in (?)", r.db.Model(&models.User{}).Select("id")).
+		Delete(&models.YearReview{})
+	return result.RowsAffected, result.Error
+}
@@ -65,7 +65,8 @@ func (h *ActivityApiHandler) GetActivityChart(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	if authorizedUser == nil || authorizedUser.ID != requestedUser.ID {
+	isProfileWidget := requestedUser.HasPublicProfile() && requestedUser.ProfileActivity
+	if (authorizedUser == nil || authorizedUser.ID != requestedUser.ID) && !isProfileWidget {
 		if _, userRange := helpers.ResolveMaximumRange(requestedUser.ShareDataMaxDays); userRange != models.IntervalPast12Months && userRange != models.IntervalAny { // TODO: build "hierarchy" of intervals to easily check if one is contained in another
 			w.WriteHeader(http.StatusForbidden)
 			return
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	routeutils "github.com/hackclub/hackatime/routes/utils"
+	"github.com/hackclub/hackatime/services"
+)
+
+type ActivityGraphApiHandler struct {
+	config            *conf.Config
+	userSrvc          services.IUserService
+	activityGraphSrvc services.IActivityGraphService
+}
+
+func NewActivityGraphApiHandler(userService services.IUserService, activityGraphService services.IActivityGraphService) *ActivityGraphApiHandler {
+	return &ActivityGraphApiHandler{
+		config:            conf.Get(),
+		userSrvc:          userService,
+		activityGraphSrvc: activityGraphService,
+	}
+}
+
+func (h *ActivityGraphApiHandler) RegisterRoutes(router chi.Router) {
+	router.Group(func(r chi.Router) {
+		r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+		r.Get("/users/{user}/activity_graph", h.Get)
+	})
+}
+
+// @Summary Retrieve a user's coding activity per day of a year, e.g. to render a contribution calendar
+// @Description Graphs are precomputed once a day, so the current day is not included. Each day has an intensity level from 0 to 4, relative to the busiest day of the year.
+// @ID get-activity-graph
+// @Tags summary
+// @Produce json
+// @Param user path string true "Username (or current)"
+// @Param year query int false "Year, defaults to the current one"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.ActivityGraph
+// @Router /users/{user}/activity_graph [get]
+func (h *ActivityGraphApiHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user, err := routeutils.CheckEffectiveUser(w, r, h.userSrvc, "current")
+	if err != nil {
+		return // response was already sent by util function
+	}
+
+	year := time.Now().In(user.TZ()).Year()
+	if yearParam := r.URL.Query().Get("year"); yearParam != "" {
+		if year, err = strconv.Atoi(yearParam); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid year"))
+			return
+		}
+	}
+
+	graph, err := h.activityGraphSrvc.GetByUser(user, year)
+	if errors.Is(err, services.ErrActivityGraphYearInvalid) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve activity graph", "userID", user.ID, "year", year, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, graph)
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type AnalyticsApiHandler struct {
+	config        *conf.Config
+	userSrvc      services.IUserService
+	analyticsSrvc services.IAnalyticsService
+}
+
+func NewAnalyticsApiHandler(userService services.IUserService, analyticsService services.IAnalyticsService) *AnalyticsApiHandler {
+	return &AnalyticsApiHandler{
+		config:        conf.Get(),
+		userSrvc:      userService,
+		analyticsSrvc: analyticsService,
+	}
+}
+
+func (h *AnalyticsApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Post("/query", h.Query)
+
+	router.Mount("/analytics", r)
+}
+
+// @Summary Evaluate a custom report over the authenticated user's coding activity
+// @Description Groups the user's durations by up to three dimensions (project, language, editor, operating_system, machine, branch, category) and, optionally, by day, week or month.
+// @Description Metrics are total_seconds, heartbeats, days, lines_added and lines_removed. Filters map dimensions to the values to include. The range must not exceed one year and is interpreted in the user's time zone.
+// @ID post-analytics-query
+// @Tags analytics
+// @Accept json
+// @Produce json
+// @Param query body models.AnalyticsQuery true "The report to evaluate"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.AnalyticsResult
+// @Router /analytics/query [post]
+func (h *AnalyticsApiHandler) Query(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	var query models.AnalyticsQuery
+	if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	result, err := h.analyticsSrvc.Query(user, &query)
+	if errors.Is(err, services.ErrAnalyticsQueryInvalid) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to evaluate analytics query", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, result)
+}
@@ -44,7 +44,7 @@ func (h *BadgeHandler) RegisterRoutes(router chi.Router) {
 
 func (h *BadgeHandler) Get(w http.ResponseWriter, r *http.Request) {
 	authorizedUser := middlewares.GetPrincipal(r)
-	user, err := h.userSrvc.GetUserById(chi.URLParam(r, "user"))
+	user, err := h.userSrvc.GetUserByRef(chi.URLParam(r, "user"))
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		return
@@ -79,7 +79,7 @@ func (h *BadgeHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	badgeData := v1.NewBadgeDataFrom(summary)
+	badgeData := v1.NewBadgeDataFrom(summary, user)
 	if customLabel := r.URL.Query().Get("label"); customLabel != "" {
 		badgeData.Label = customLabel
 	}
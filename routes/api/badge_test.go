@@ -49,7 +49,7 @@ func TestBadgeHandler_Get(t *testing.T) {
 	router.Mount("/api", apiRouter)
 
 	userServiceMock := new(mocks.UserServiceMock)
-	userServiceMock.On("GetUserById", "user1").Return(&user1, nil)
+	userServiceMock.On("GetUserByRef", "user1").Return(&user1, nil)
 
 	summaryServiceMock := new(mocks.SummaryServiceMock)
 	summaryServiceMock.On("Aliased", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time"), &user1, mock.Anything, mock.Anything).Return(&summary1, nil)
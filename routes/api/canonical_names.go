@@ -0,0 +1,178 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+	"gorm.io/gorm"
+)
+
+type canonicalizationVm struct {
+	Updated       int64 `json:"updated"`       // number of rewritten heartbeats
+	Resummarizing int   `json:"resummarizing"` // number of users whose summaries are being re-generated
+}
+
+type CanonicalNamesApiHandler struct {
+	config            *conf.Config
+	userSrvc          services.IUserService
+	canonicalNameSrvc services.ICanonicalNameService
+}
+
+func NewCanonicalNamesApiHandler(userService services.IUserService, canonicalNameService services.ICanonicalNameService) *CanonicalNamesApiHandler {
+	return &CanonicalNamesApiHandler{
+		config:            conf.Get(),
+		userSrvc:          userService,
+		canonicalNameSrvc: canonicalNameService,
+	}
+}
+
+func (h *CanonicalNamesApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.GetAll)
+	r.Post("/", h.Post)
+	r.Post("/apply", h.Apply)
+	r.Delete("/{id}", h.Delete)
+
+	router.Mount("/admin/canonical-names", r)
+}
+
+// @Summary Retrieve the mappings of editor and operating system name variants to canonical names (admin only)
+// @ID get-canonical-names
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.CanonicalName
+// @Router /admin/canonical-names [get]
+func (h *CanonicalNamesApiHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	mappings, err := h.canonicalNameSrvc.GetAll()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve canonical names", "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, mappings)
+}
+
+// @Summary Map a variant of an editor or operating system name to its canonical name, applied to all heartbeats received from now on (admin only)
+// @Description Variants are matched regardless of case, spaces and punctuation. Use the apply endpoint to also rewrite existing heartbeats.
+// @ID post-canonical-name
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param mapping body models.CanonicalName true "e.g. {\"type\": \"editor\", \"variant\": \"Code - Insiders\", \"canonical\": \"vscode\"}"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.CanonicalName
+// @Router /admin/canonical-names [post]
+func (h *CanonicalNamesApiHandler) Post(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	var mapping models.CanonicalName
+	if err := json.NewDecoder(r.Body).Decode(&mapping); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+	mapping = models.CanonicalName{Type: mapping.Type, Variant: mapping.Variant, Canonical: mapping.Canonical}
+
+	result, err := h.canonicalNameSrvc.Create(&mapping)
+	if errors.Is(err, services.ErrCanonicalNameInvalid) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if errors.Is(err, services.ErrCanonicalNameConflict) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to create canonical name", "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusCreated, result)
+}
+
+// @Summary Rewrite existing heartbeats according to the current mappings and re-generate the affected users' summaries (admin only)
+// @ID post-canonical-names-apply
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} canonicalizationVm
+// @Router /admin/canonical-names/apply [post]
+func (h *CanonicalNamesApiHandler) Apply(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	result, err := h.canonicalNameSrvc.Apply()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to apply canonical names", "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, &canonicalizationVm{Updated: result.Updated, Resummarizing: result.Resummarizing})
+}
+
+// @Summary Remove a mapping, heartbeats which were already rewritten keep their canonical name (admin only)
+// @ID delete-canonical-name
+// @Tags admin
+// @Param id path int true "Mapping ID"
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /admin/canonical-names/{id} [delete]
+func (h *CanonicalNamesApiHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	if err := h.canonicalNameSrvc.Delete(uint(id)); errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to delete canonical name", "id", id, "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CanonicalNamesApiHandler) checkAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if principal := middlewares.GetPrincipal(r); principal == nil || !principal.IsAdmin {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(conf.ErrUnauthorized))
+		return false
+	}
+	return true
+}
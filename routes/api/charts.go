@@ -0,0 +1,134 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+	"github.com/hackclub/hackatime/utils"
+)
+
+type ChartsApiHandler struct {
+	config    *conf.Config
+	userSrvc  services.IUserService
+	chartSrvc services.IChartService
+}
+
+func NewChartsApiHandler(userService services.IUserService, chartService services.IChartService) *ChartsApiHandler {
+	return &ChartsApiHandler{
+		config:    conf.Get(),
+		userSrvc:  userService,
+		chartSrvc: chartService,
+	}
+}
+
+func (h *ChartsApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).WithOptionalFor("/api/charts/").Handler)
+	r.Get("/{user}/weekly.png", h.GetWeekly)
+	r.Get("/{user}/languages.png", h.GetLanguages)
+
+	router.Mount("/charts", r)
+}
+
+// @Summary Render a bar chart of a user's coding time on each of the past seven days as png image
+// @Description Meant for embedding where no javascript widgets can run, e.g. in chat messages, mails or readmes. Available without authentication if the user shares at least seven days of data.
+// @ID get-chart-weekly
+// @Tags charts
+// @Produce png
+// @Param user path string true "Username"
+// @Param dark query bool false "Dark theme"
+// @Success 200 {file} file
+// @Router /charts/{user}/weekly.png [get]
+func (h *ChartsApiHandler) GetWeekly(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.checkAccess(w, r, models.IntervalPast7Days, false)
+	if !ok {
+		return
+	}
+
+	data, err := h.chartSrvc.RenderWeekly(user, isDarkTheme(r), utils.IsNoCache(r, time.Hour))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to render weekly chart", "userID", user.ID, "error", err)
+		return
+	}
+
+	respondPng(w, data)
+}
+
+// @Summary Render a donut chart of a user's top languages as png image
+// @Description Meant for embedding where no javascript widgets can run, e.g. in chat messages, mails or readmes. Available without authentication if the user shares their languages for the requested interval.
+// @ID get-chart-languages
+// @Tags charts
+// @Produce png
+// @Param user path string true "Username"
+// @Param interval query string false "Interval identifier, defaults to last_7_days" Enums(today, yesterday, week, month, year, 7_days, last_7_days, 30_days, last_30_days, 6_months, last_6_months, 12_months, last_12_months, last_year, any, all_time)
+// @Param dark query bool false "Dark theme"
+// @Success 200 {file} file
+// @Router /charts/{user}/languages.png [get]
+func (h *ChartsApiHandler) GetLanguages(w http.ResponseWriter, r *http.Request) {
+	interval := models.IntervalPast7Days
+	if q := r.URL.Query().Get("interval"); q != "" {
+		var err error
+		if interval, err = helpers.ParseInterval(q); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid interval"))
+			return
+		}
+	}
+
+	user, ok := h.checkAccess(w, r, interval, true)
+	if !ok {
+		return
+	}
+
+	data, err := h.chartSrvc.RenderLanguages(user, interval, isDarkTheme(r), utils.IsNoCache(r, time.Hour))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to render languages chart", "userID", user.ID, "error", err)
+		return
+	}
+
+	respondPng(w, data)
+}
+
+// checkAccess resolves the requested user and, unless it's the authenticated one, checks whether they share enough data for the given interval
+func (h *ChartsApiHandler) checkAccess(w http.ResponseWriter, r *http.Request, interval *models.IntervalKey, languages bool) (*models.User, bool) {
+	authorizedUser := middlewares.GetPrincipal(r)
+	user, err := h.userSrvc.GetUserByRef(chi.URLParam(r, "user"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return nil, false
+	}
+	if authorizedUser != nil && authorizedUser.ID == user.ID {
+		return user, true
+	}
+
+	_, from, to := helpers.ResolveIntervalTZ(interval, user.TZ())
+	// negative value means no limit
+	if (user.ShareDataMaxDays >= 0 && from.Before(to.AddDate(0, 0, -user.ShareDataMaxDays))) || (languages && !user.ShareLanguages) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(conf.ErrForbidden))
+		return nil, false
+	}
+	return user, true
+}
+
+func isDarkTheme(r *http.Request) bool {
+	return r.URL.Query().Has("dark") && r.URL.Query().Get("dark") != "false"
+}
+
+func respondPng(w http.ResponseWriter, data []byte) {
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "max-age=3600")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/services"
+	"gorm.io/gorm"
+)
+
+type CompareApiHandler struct {
+	config      *conf.Config
+	userSrvc    services.IUserService
+	compareSrvc services.ICompareService
+}
+
+func NewCompareApiHandler(userService services.IUserService, compareService services.ICompareService) *CompareApiHandler {
+	return &CompareApiHandler{
+		config:      conf.Get(),
+		userSrvc:    userService,
+		compareSrvc: compareService,
+	}
+}
+
+func (h *CompareApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/consents", h.GetConsents)
+	r.Put("/consents/{user}", h.PutConsent)
+	r.Delete("/consents/{user}", h.DeleteConsent)
+	r.Get("/{user}", h.Get)
+
+	router.Mount("/compare", r)
+}
+
+// @Summary Retrieve whom the authenticated user consented to compare with, who consented in turn and which of them mutually
+// @ID get-compare-consents
+// @Tags compare
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.CompareConsents
+// @Router /compare/consents [get]
+func (h *CompareApiHandler) GetConsents(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	consents, err := h.compareSrvc.GetConsents(user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve compare consents", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, consents)
+}
+
+// @Summary Consent to compare coding activity with another user, who has to consent as well before either of you can compare
+// @ID put-compare-consent
+// @Tags compare
+// @Param user path string true "The other user's id"
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /compare/consents/{user} [put]
+func (h *CompareApiHandler) PutConsent(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+	otherId := chi.URLParam(r, "user")
+
+	err := h.compareSrvc.Consent(user, otherId)
+	if errors.Is(err, services.ErrCompareSelf) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("user not found"))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to save compare consent", "userID", user.ID, "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Revoke the consent to compare with another user
+// @ID delete-compare-consent
+// @Tags compare
+// @Param user path string true "The other user's id"
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /compare/consents/{user} [delete]
+func (h *CompareApiHandler) DeleteConsent(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	if err := h.compareSrvc.Revoke(user, chi.URLParam(r, "user")); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to revoke compare consent", "userID", user.ID, "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Compare the authenticated user's summary with another user's side by side, given that both consented to each other
+// @Description Projects and machines are only included for users who share them publicly. The range is interpreted in the authenticated user's time zone.
+// @ID get-compare
+// @Tags compare
+// @Produce json
+// @Param user path string true "The other user's id"
+// @Param interval query string false "Interval identifier" Enums(today, yesterday, week, month, year, 7_days, last_7_days, 30_days, last_30_days, 6_months, last_6_months, 12_months, last_12_months, last_year, any, all_time, low_skies, high_seas, last_24h, last_90m)
+// @Param from query string false "Start date (e.g. '2021-02-07')"
+// @Param to query string false "End date (e.g. '2021-02-08')"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.UserComparison
+// @Router /compare/{user} [get]
+func (h *CompareApiHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	params, err := helpers.ParseSummaryParams(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	comparison, err := h.compareSrvc.Compare(user, chi.URLParam(r, "user"), params.From, params.To)
+	if errors.Is(err, services.ErrCompareSelf) || errors.Is(err, services.ErrCompareRangeInvalid) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if errors.Is(err, services.ErrCompareNoConsent) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to compare users", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, comparison)
+}
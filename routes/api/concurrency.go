@@ -0,0 +1,132 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+const (
+	defaultConcurrencyRange = 7 * 24 * time.Hour
+	maxConcurrencyRange     = 90 * 24 * time.Hour
+)
+
+type ConcurrencyApiHandler struct {
+	config          *conf.Config
+	userSrvc        services.IUserService
+	concurrencySrvc services.IConcurrencyService
+}
+
+func NewConcurrencyApiHandler(userService services.IUserService, concurrencyService services.IConcurrencyService) *ConcurrencyApiHandler {
+	return &ConcurrencyApiHandler{
+		config:          conf.Get(),
+		userSrvc:        userService,
+		concurrencySrvc: concurrencyService,
+	}
+}
+
+func (h *ConcurrencyApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.GetHeatmap)
+	r.Get("/history", h.GetHistory)
+
+	router.Mount("/admin/concurrency", r)
+}
+
+// @Summary Retrieve the maximum number of concurrently active users per weekday and hour, in the admin's timezone (admin only)
+// @Description Users count as active if they sent a heartbeat within the last two minutes. Defaults to the past 7 days, at most 90 days can be requested.
+// @ID get-admin-concurrency-heatmap
+// @Tags admin
+// @Produce json
+// @Param from query string false "Start date (e.g. '2021-02-07')"
+// @Param to query string false "End date (e.g. '2021-02-08')"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.ConcurrencyHeatmap
+// @Router /admin/concurrency [get]
+func (h *ConcurrencyApiHandler) GetHeatmap(w http.ResponseWriter, r *http.Request) {
+	user, from, to, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	samples, err := h.concurrencySrvc.GetWithin(from, to)
+	if err != nil {
+		h.respondError(w, r, err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, models.NewConcurrencyHeatmapFrom(samples, from, to, user.TZ()))
+}
+
+// @Summary Retrieve the per-minute history of concurrently active users (admin only)
+// @Description Defaults to the past 7 days, at most 90 days can be requested.
+// @ID get-admin-concurrency-history
+// @Tags admin
+// @Produce json
+// @Param from query string false "Start date (e.g. '2021-02-07')"
+// @Param to query string false "End date (e.g. '2021-02-08')"
+// @Security ApiKeyAuth
+// @Success 200 {array} models.ConcurrencySample
+// @Router /admin/concurrency/history [get]
+func (h *ConcurrencyApiHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	_, from, to, ok := h.parseRequest(w, r)
+	if !ok {
+		return
+	}
+
+	samples, err := h.concurrencySrvc.GetWithin(from, to)
+	if err != nil {
+		h.respondError(w, r, err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, samples)
+}
+
+func (h *ConcurrencyApiHandler) parseRequest(w http.ResponseWriter, r *http.Request) (user *models.User, from, to time.Time, ok bool) {
+	user = middlewares.GetPrincipal(r)
+	if user == nil || !user.IsAdmin {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(conf.ErrUnauthorized))
+		return nil, from, to, false
+	}
+
+	to, from = time.Now(), time.Now().Add(-defaultConcurrencyRange)
+	var err error
+	if q := r.URL.Query().Get("to"); q != "" {
+		if to, err = helpers.ParseDateTimeTZ(q, user.TZ()); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid 'to' parameter"))
+			return nil, from, to, false
+		}
+		from = to.Add(-defaultConcurrencyRange)
+	}
+	if q := r.URL.Query().Get("from"); q != "" {
+		if from, err = helpers.ParseDateTimeTZ(q, user.TZ()); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid 'from' parameter"))
+			return nil, from, to, false
+		}
+	}
+
+	if !to.After(from) || to.Sub(from) > maxConcurrencyRange {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid date range"))
+		return nil, from, to, false
+	}
+
+	return user, from, to, true
+}
+
+func (h *ConcurrencyApiHandler) respondError(w http.ResponseWriter, r *http.Request, err error) {
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write([]byte(conf.ErrInternalServerError))
+	conf.Log().Request(r).Error("failed to retrieve concurrency samples", "error", err)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+	"github.com/hackclub/hackatime/utils"
+)
+
+// DevApiHandler provides tooling for contributors and plugin developers, it is only available in dev mode
+type DevApiHandler struct {
+	config      *conf.Config
+	devDataSrvc services.IDevDataService
+}
+
+func NewDevApiHandler(devDataService services.IDevDataService) *DevApiHandler {
+	return &DevApiHandler{
+		config:      conf.Get(),
+		devDataSrvc: devDataService,
+	}
+}
+
+func (h *DevApiHandler) RegisterRoutes(router chi.Router) {
+	if !h.config.IsDev() {
+		return
+	}
+
+	r := chi.NewRouter()
+	r.Use(h.authenticate)
+	r.Post("/heartbeats/generate", h.PostGenerate)
+	router.Mount("/dev", r)
+}
+
+func (h *DevApiHandler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, err := utils.ExtractBearerAuth(r)
+		if err != nil || subtle.ConstantTimeCompare([]byte(key), []byte(h.config.Security.AdminToken)) != 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(conf.ErrUnauthorized))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// @Summary Generate synthetic heartbeats for development (dev mode only)
+// @Description Creates users dev_user_1 to dev_user_n (password equals username) and fills them with heartbeats of the past days
+// @ID post-dev-generate-heartbeats
+// @Tags misc
+// @Accept json
+// @Produce json
+// @Param params body models.DevDataParams false "Number of users, days, language weights and random seed"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.DevDataResult
+// @Router /dev/heartbeats/generate [post]
+func (h *DevApiHandler) PostGenerate(w http.ResponseWriter, r *http.Request) {
+	var params models.DevDataParams
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(conf.ErrBadRequest))
+			return
+		}
+	}
+
+	if err := params.WithDefaults().Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	result, err := h.devDataSrvc.Generate(&params)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to generate synthetic heartbeats", "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusCreated, result)
+}
@@ -0,0 +1,134 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/httprate"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type deviceCodeRequest struct {
+	ClientName string `json:"client_name"` // e.g. vscode-wakatime on build-server-1, displayed to the user for confirmation
+}
+
+type deviceTokenRequest struct {
+	DeviceCode string `json:"device_code"`
+}
+
+type DeviceApiHandler struct {
+	config         *conf.Config
+	userSrvc       services.IUserService
+	deviceAuthSrvc services.IDeviceAuthService
+}
+
+func NewDeviceApiHandler(userService services.IUserService, deviceAuthService services.IDeviceAuthService) *DeviceApiHandler {
+	return &DeviceApiHandler{
+		config:         conf.Get(),
+		userSrvc:       userService,
+		deviceAuthSrvc: deviceAuthService,
+	}
+}
+
+func (h *DeviceApiHandler) RegisterRoutes(router chi.Router) {
+	router.Group(func(r chi.Router) {
+		maxRate, window := h.config.Security.GetLoginMaxRate()
+		r.With(httprate.Limit(maxRate, window, httprate.WithKeyFuncs(middlewares.KeyByClientIp), httprate.WithLimitHandler(helpers.RespondRateLimited))).Post("/device/code", h.PostCode)
+		r.Post("/device/token", h.PostToken)
+	})
+}
+
+// @Summary Start the device flow to obtain an api key for a plugin on a headless machine
+// @Description The user then enters the returned user code at the verification uri, while the plugin polls /device/token at the given interval.
+// @ID post-device-code
+// @Tags device
+// @Accept json
+// @Produce json
+// @Param request body deviceCodeRequest false "Name of the requesting client"
+// @Success 200 {object} models.DeviceCodeResponse
+// @Router /device/code [post]
+func (h *DeviceApiHandler) PostCode(w http.ResponseWriter, r *http.Request) {
+	var req deviceCodeRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(conf.ErrBadRequest))
+			return
+		}
+	}
+	if len(req.ClientName) > 64 {
+		req.ClientName = req.ClientName[:64]
+	}
+
+	auth, err := h.deviceAuthSrvc.Start(req.ClientName)
+	if err != nil {
+		if errors.Is(err, services.ErrDeviceFlowDisabled) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to start device authorization", "error", err)
+		return
+	}
+
+	verificationUri := fmt.Sprintf("%s/device", h.config.Server.GetPublicUrl())
+	helpers.RespondJSON(w, r, http.StatusOK, &models.DeviceCodeResponse{
+		DeviceCode:              auth.DeviceCode,
+		UserCode:                auth.FormattedUserCode(),
+		VerificationUri:         verificationUri,
+		VerificationUriComplete: fmt.Sprintf("%s?user_code=%s", verificationUri, url.QueryEscape(auth.UserCode)),
+		ExpiresIn:               h.config.Security.DeviceCodeTTLMin * 60,
+		Interval:                int(auth.Interval.Seconds()),
+	})
+}
+
+// @Summary Poll for the api key after having started the device flow
+// @Description Responds with error authorization_pending until the user approved the request. Clients must wait for the interval between polls and increase it by five seconds upon slow_down.
+// @ID post-device-token
+// @Tags device
+// @Accept json
+// @Produce json
+// @Param request body deviceTokenRequest true "Device code as returned by /device/code"
+// @Success 200 {object} models.DeviceTokenResponse
+// @Failure 400 {object} models.DeviceTokenError
+// @Router /device/token [post]
+func (h *DeviceApiHandler) PostToken(w http.ResponseWriter, r *http.Request) {
+	var req deviceTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.DeviceCode == "" {
+		helpers.RespondJSON(w, r, http.StatusBadRequest, &models.DeviceTokenError{Error: "invalid_request"})
+		return
+	}
+
+	auth, err := h.deviceAuthSrvc.Poll(req.DeviceCode)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDeviceAuthorizationPending),
+			errors.Is(err, services.ErrDeviceSlowDown),
+			errors.Is(err, services.ErrDeviceAccessDenied),
+			errors.Is(err, services.ErrDeviceExpiredToken):
+			helpers.RespondJSON(w, r, http.StatusBadRequest, &models.DeviceTokenError{Error: err.Error()})
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(conf.ErrInternalServerError))
+			conf.Log().Request(r).Error("failed to poll device authorization", "error", err)
+		}
+		return
+	}
+
+	conf.Log().Request(r).Info("handed out api key via device flow", "userID", auth.UserID)
+	helpers.RespondJSON(w, r, http.StatusOK, &models.DeviceTokenResponse{
+		ApiKey:   auth.ApiKey,
+		Username: auth.UserID,
+		ApiUrl:   fmt.Sprintf("%s/api", h.config.Server.GetPublicUrl()),
+	})
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/duke-git/lancet/v2/condition"
 	"github.com/go-chi/chi/v5"
@@ -13,6 +14,7 @@ import (
 	routeutils "github.com/hackclub/hackatime/routes/utils"
 	"github.com/hackclub/hackatime/services"
 	"github.com/hackclub/hackatime/utils"
+	"github.com/muety/artifex/v2"
 
 	"github.com/hackclub/hackatime/models"
 )
@@ -22,14 +24,30 @@ type HeartbeatApiHandler struct {
 	userSrvc            services.IUserService
 	heartbeatSrvc       services.IHeartbeatService
 	languageMappingSrvc services.ILanguageMappingService
+	machineSrvc         services.IMachineService
+	loadSheddingSrvc    services.ILoadSheddingService
+	projectOverrideSrvc services.IProjectOverrideService
+	relayRuleSrvc       services.IRelayRuleService
+	geoSrvc             services.IGeoService
+	trackingPauseSrvc   services.ITrackingPauseService
+	tagRuleSrvc         services.ITagRuleService
+	queueWorkers        *artifex.Dispatcher
 }
 
-func NewHeartbeatApiHandler(userService services.IUserService, heartbeatService services.IHeartbeatService, languageMappingService services.ILanguageMappingService) *HeartbeatApiHandler {
+func NewHeartbeatApiHandler(userService services.IUserService, heartbeatService services.IHeartbeatService, languageMappingService services.ILanguageMappingService, machineService services.IMachineService, loadSheddingService services.ILoadSheddingService, projectOverrideService services.IProjectOverrideService, relayRuleService services.IRelayRuleService, geoService services.IGeoService, trackingPauseService services.ITrackingPauseService, tagRuleService services.ITagRuleService) *HeartbeatApiHandler {
 	return &HeartbeatApiHandler{
 		config:              conf.Get(),
 		userSrvc:            userService,
 		heartbeatSrvc:       heartbeatService,
 		languageMappingSrvc: languageMappingService,
+		machineSrvc:         machineService,
+		loadSheddingSrvc:    loadSheddingService,
+		projectOverrideSrvc: projectOverrideService,
+		relayRuleSrvc:       relayRuleService,
+		geoSrvc:             geoService,
+		trackingPauseSrvc:   trackingPauseService,
+		tagRuleSrvc:         tagRuleService,
+		queueWorkers:        conf.GetQueue(conf.QueueProcessing),
 	}
 }
 
@@ -38,21 +56,52 @@ type heartbeatResponseVm struct {
 }
 
 func (h *HeartbeatApiHandler) RegisterRoutes(router chi.Router) {
-	router.Group(func(r chi.Router) {
-		r.Use(
+	idempotency := middlewares.NewIdempotencyMiddleware()
+
+	postMiddlewares := func(maxBodyKb int64, relay bool) []func(http.Handler) http.Handler {
+		mws := []func(http.Handler) http.Handler{
+			// reject blocked clients before doing any work on their requests
+			middlewares.NewIpFilterMiddleware(),
+			// body limit goes next, to not have any other middleware read an oversized body
+			middlewares.NewBodyLimitMiddleware(maxBodyKb * 1024),
 			middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler,
-			customMiddleware.NewWakatimeRelayMiddleware().Handler,
-		)
-		// see https://github.com/kcoderhtml/hackatime/issues/203
+			// before relaying, so replays aren't forwarded to wakatime again either
+			idempotency,
+		}
+		if relay {
+			mws = append(mws, customMiddleware.NewWakatimeRelayMiddleware(h.relayRuleSrvc).Handler)
+		}
+		return mws
+	}
+
+	// see https://github.com/kcoderhtml/hackatime/issues/203
+	router.Group(func(r chi.Router) {
+		r.Use(postMiddlewares(h.config.App.HeartbeatMaxBodyKb, true)...)
 		r.Post("/heartbeat", h.Post)
-		r.Post("/heartbeats", h.Post)
 		r.Post("/users/{user}/heartbeats", h.Post)
-		r.Post("/users/{user}/heartbeats.bulk", h.Post)
 		r.Post("/v1/users/{user}/heartbeats", h.Post)
-		r.Post("/v1/users/{user}/heartbeats.bulk", h.Post)
 		r.Post("/compat/wakatime/v1/users/{user}/heartbeats", h.Post)
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Use(postMiddlewares(h.config.App.HeartbeatBulkMaxBodyKb, true)...)
+		r.Post("/heartbeats", h.Post)
+		r.Post("/users/{user}/heartbeats.bulk", h.Post)
+		r.Post("/v1/users/{user}/heartbeats.bulk", h.Post)
 		r.Post("/compat/wakatime/v1/users/{user}/heartbeats.bulk", h.Post)
 	})
+
+	// not relayed to wakatime, which doesn't know about this format
+	router.Group(func(r chi.Router) {
+		r.Use(postMiddlewares(h.config.App.HeartbeatBulkMaxBodyKb, false)...)
+		r.Post("/watcher/events", h.PostWatcherEvents)
+		r.Post("/users/{user}/watcher/events", h.PostWatcherEvents)
+	})
+
+	router.Group(func(r chi.Router) {
+		r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+		r.Get("/users/{user}/heartbeats/changes", h.GetChanges)
+	})
 }
 
 // @Summary Push a new heartbeat
@@ -73,19 +122,54 @@ func (h *HeartbeatApiHandler) Post(w http.ResponseWriter, r *http.Request) {
 	heartbeats, err = routeutils.ParseHeartbeats(r)
 	if err != nil {
 		conf.Log().Request(r).Error("error occurred", "error", err)
-		w.WriteHeader(http.StatusBadRequest)
-		w.Write([]byte(err.Error()))
+		helpers.RespondError(w, r, http.StatusBadRequest, models.ApiErrorInvalidInput, err.Error())
 		return
 	}
 
+	h.ingest(w, r, user, heartbeats, false)
+}
+
+// @Summary Push file modification events from a file watcher agent
+// @Description For editors without a plugin, a simple agent may watch the file system and report modified files instead. Every event is stored as a write heartbeat of category "file watching", without an editor.
+// @ID post-watcher-events
+// @Tags heartbeat
+// @Accept json
+// @Param events body models.WatcherEvents true "File modification events"
+// @Security ApiKeyAuth
+// @Success 201
+// @Router /watcher/events [post]
+func (h *HeartbeatApiHandler) PostWatcherEvents(w http.ResponseWriter, r *http.Request) {
+	user, err := routeutils.CheckEffectiveUser(w, r, h.userSrvc, "current")
+	if err != nil {
+		return // response was already sent by util function
+	}
+
+	events, err := routeutils.ParseWatcherEvents(r)
+	if err != nil {
+		helpers.RespondError(w, r, http.StatusBadRequest, models.ApiErrorInvalidInput, err.Error())
+		return
+	}
+
+	h.ingest(w, r, user, events.Heartbeats(), true)
+}
+
+// ingest validates, enriches and stores the given heartbeats of a user, editorless ones are never attributed to an editor
+func (h *HeartbeatApiHandler) ingest(w http.ResponseWriter, r *http.Request, user *models.User, heartbeats []*models.Heartbeat, editorless bool) {
+	var err error
+
 	userAgent := r.Header.Get("User-Agent")
 	opSys, editor, _ := utils.ParseUserAgent(userAgent)
 	machineName := r.Header.Get("X-Machine-Name")
+	maxFutureSkew := h.config.App.HeartbeatsMaxFutureSkew()
+	var numSkewed int
+
+	// blocklisted heartbeats are dropped silently, but still reported as created, so that clients won't re-send them
+	numHeartbeats := len(heartbeats)
+	kept := make([]*models.Heartbeat, 0, len(heartbeats))
 
 	for _, hb := range heartbeats {
 		if hb == nil {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write([]byte("invalid heartbeat object"))
+			helpers.RespondError(w, r, http.StatusBadRequest, models.ApiErrorInvalidInput, "invalid heartbeat object")
 			return
 		}
 
@@ -100,6 +184,13 @@ func (h *HeartbeatApiHandler) Post(w http.ResponseWriter, r *http.Request) {
 			machineName = hb.Machine
 		}
 
+		// before entity normalization, which might strip the parts of the path that overrides and project roots refer to
+		if err := h.projectOverrideSrvc.Resolve(user, hb); err != nil {
+			helpers.RespondError(w, r, http.StatusInternalServerError, models.ApiErrorInternal, conf.ErrInternalServerError)
+			conf.Log().Request(r).Error("failed to resolve heartbeat project", "userID", user.ID, "error", err)
+			return
+		}
+
 		if hb.Branch == "<<LAST_BRANCH>>" {
 			if latest, err := h.heartbeatSrvc.GetLatestByFilters(user, models.NewFiltersWith(models.SummaryProject, hb.Project)); latest != nil && err == nil {
 				hb.Branch = latest.Branch
@@ -112,38 +203,160 @@ func (h *HeartbeatApiHandler) Post(w http.ResponseWriter, r *http.Request) {
 		hb.UserID = user.ID
 		hb.Machine = machineName
 		hb.OperatingSystem = opSys
-		hb.Editor = editor
+		hb.Editor = condition.TernaryOperator[bool, string](editorless, "", editor)
 		hb.UserAgent = userAgent
+		hb.SanitizeSource()
+		hb.NormalizeEntity(user.UnixEntitySeparators, user.ScrubEntityHomeDirs, user.RelativeEntityPaths)
+		h.heartbeatSrvc.Normalize(hb) // before machine approval and hashing, so that both see the value which is eventually stored
+
+		// after normalization, so that rules match the values which are eventually stored
+		if err := h.tagRuleSrvc.Apply(user, hb); err != nil {
+			helpers.RespondError(w, r, http.StatusInternalServerError, models.ApiErrorInternal, conf.ErrInternalServerError)
+			conf.Log().Request(r).Error("failed to apply tag rules", "userID", user.ID, "error", err)
+			return
+		}
+
+		if h.heartbeatSrvc.IsBlocked(user, hb) {
+			continue
+		}
 
-		if !hb.Valid() || !hb.Timely(h.config.App.HeartbeatsMaxAge()) {
-			w.WriteHeader(http.StatusBadRequest)
-			w.Write([]byte("invalid heartbeat object"))
+		if hb.FromFuture(maxFutureSkew) {
+			numSkewed++
+			if h.config.App.HeartbeatClampFutureSkew {
+				hb.Time = models.CustomTime(time.Now())
+			}
+		}
+
+		if !hb.Valid() || !hb.Timely(h.config.App.HeartbeatsMaxAge(), maxFutureSkew) {
+			if numSkewed > 0 {
+				h.recordClockSkew(r, user, numSkewed)
+			}
+			helpers.RespondError(w, r, http.StatusBadRequest, models.ApiErrorInvalidInput, "invalid heartbeat object")
+			return
+		}
+
+		// heartbeats during a pause (e.g. a vacation) are dropped silently, too, as clients would otherwise re-send them once it's over
+		paused, err := h.trackingPauseSrvc.IsPaused(user, hb.Time.T())
+		if err != nil {
+			helpers.RespondError(w, r, http.StatusInternalServerError, models.ApiErrorInternal, conf.ErrInternalServerError)
+			conf.Log().Request(r).Error("failed to check tracking pauses", "userID", user.ID, "error", err)
 			return
 		}
+		if paused {
+			continue
+		}
 
 		hb.Hashed()
+		kept = append(kept, hb)
 	}
 
-	if err := h.heartbeatSrvc.InsertBatch(heartbeats); err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		w.Write([]byte(conf.ErrInternalServerError))
+	heartbeats = kept
+
+	if user.RequireMachineApproval {
+		heartbeats, err = h.quarantineUnapproved(user, heartbeats)
+		if err != nil {
+			helpers.RespondError(w, r, http.StatusInternalServerError, models.ApiErrorInternal, conf.ErrInternalServerError)
+			conf.Log().Request(r).Error("failed to quarantine heartbeats", "userID", user.ID, "error", err)
+			return
+		}
+	}
+
+	if h.loadSheddingSrvc.IsDegraded() {
+		// write heartbeats later, once the database has recovered
+		if err := h.loadSheddingSrvc.Spool(heartbeats); err != nil {
+			helpers.RespondErrorRetryAfter(w, r, http.StatusServiceUnavailable, models.ApiErrorOverloaded, err.Error(), h.loadSheddingSrvc.RetryAfter())
+			return
+		}
+	} else if err := h.heartbeatSrvc.InsertBatch(heartbeats); err != nil {
+		helpers.RespondError(w, r, http.StatusInternalServerError, models.ApiErrorInternal, conf.ErrInternalServerError)
 		conf.Log().Request(r).Error("failed to batch-insert heartbeats", "error", err)
 		return
 	}
 
-	if !user.HasData {
+	if numSkewed > 0 {
+		h.recordClockSkew(r, user, numSkewed)
+	}
+
+	if !user.HasData && len(heartbeats) > 0 {
 		user.HasData = true
-		if _, err := h.userSrvc.Update(user); err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			w.Write([]byte(conf.ErrInternalServerError))
-			conf.Log().Request(r).Error("failed to update user", "userID", user.ID, "error", err)
-			return
-		}
+		// heartbeats are already stored at this point, so failing the request would only make the client re-send them
+		h.markHasData(user)
 	}
 
+	// after quarantining, so that new machines are registered as pending approval first
+	h.trackLocation(r, user, kept)
+
 	defer func() {}()
 
-	helpers.RespondJSON(w, r, http.StatusCreated, constructSuccessResponse(len(heartbeats)))
+	helpers.RespondJSON(w, r, http.StatusCreated, constructSuccessResponse(numHeartbeats))
+}
+
+// markHasData persists the user's has_data flag in the background. only that column is written, because the principal might be
+// outdated by then, e.g. if the user was suspended in the meantime. if it fails, the flag is set again with the user's next heartbeats.
+func (h *HeartbeatApiHandler) markHasData(user *models.User) {
+	userId := user.ID
+	if err := h.queueWorkers.Dispatch(func() {
+		if _, err := h.userSrvc.UpdateField(&models.User{ID: userId}, "has_data", true); err != nil {
+			conf.Log().Error("failed to set 'has_data' flag for user", "userID", userId, "error", err)
+		}
+	}); err != nil {
+		conf.Log().Error("failed to dispatch user update", "userID", user.ID, "error", err)
+	}
+}
+
+// recordClockSkew keeps track of how often a user's clients sent heartbeats from the future, to be displayed in the settings
+func (h *HeartbeatApiHandler) recordClockSkew(r *http.Request, user *models.User, n int) {
+	now := models.CustomTime(time.Now())
+	user.ClockSkewEvents += n
+	user.LastClockSkewAt = &now
+	if _, err := h.userSrvc.Update(user); err != nil {
+		conf.Log().Request(r).Error("failed to record clock skew events", "userID", user.ID, "error", err)
+	}
+}
+
+// trackLocation stores the country of every machine the heartbeats were sent from, failing to do so doesn't fail the request
+func (h *HeartbeatApiHandler) trackLocation(r *http.Request, user *models.User, heartbeats []*models.Heartbeat) {
+	if !h.geoSrvc.Enabled() || !user.TrackLocation {
+		return
+	}
+
+	ip := middlewares.GetClientIp(r)
+	seen := make(map[string]bool)
+	for _, hb := range heartbeats {
+		if seen[hb.Machine] {
+			continue
+		}
+		seen[hb.Machine] = true
+		if err := h.geoSrvc.Track(user, hb.Machine, ip); err != nil {
+			conf.Log().Request(r).Error("failed to track machine location", "userID", user.ID, "error", err)
+		}
+	}
+}
+
+// quarantineUnapproved holds back heartbeats from machines not approved by the user and returns the remaining ones
+// quarantined heartbeats are still reported as created to the client, so that they won't be re-sent
+func (h *HeartbeatApiHandler) quarantineUnapproved(user *models.User, heartbeats []*models.Heartbeat) ([]*models.Heartbeat, error) {
+	approved := make([]*models.Heartbeat, 0, len(heartbeats))
+	quarantined := make([]*models.Heartbeat, 0)
+
+	for _, hb := range heartbeats {
+		ok, err := h.machineSrvc.IsApproved(user, hb.Machine)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			approved = append(approved, hb)
+		} else {
+			quarantined = append(quarantined, hb)
+		}
+	}
+
+	if len(quarantined) > 0 {
+		if err := h.machineSrvc.Quarantine(quarantined); err != nil {
+			return nil, err
+		}
+	}
+	return approved, nil
 }
 
 // construct weird response format (see https://github.com/wakatime/wakatime/blob/2e636d389bf5da4e998e05d5285a96ce2c181e3d/wakatime/api.py#L288)
@@ -207,6 +420,7 @@ func (h *HeartbeatApiHandler) postAlias3() {}
 // @Tags heartbeat
 // @Accept json
 // @Param heartbeat body []models.Heartbeat true "Multiple heartbeats"
+// @Param Idempotency-Key header string false "Replays of a request with the same key return the original response instead of inserting again"
 // @Security ApiKeyAuth
 // @Success 201
 // @Router /heartbeats [post]
@@ -217,6 +431,7 @@ func (h *HeartbeatApiHandler) postAlias4() {}
 // @Tags heartbeat
 // @Accept json
 // @Param heartbeat body []models.Heartbeat true "Multiple heartbeats"
+// @Param Idempotency-Key header string false "Replays of a request with the same key return the original response instead of inserting again"
 // @Param user path string true "Username (or current)"
 // @Security ApiKeyAuth
 // @Success 201
@@ -228,6 +443,7 @@ func (h *HeartbeatApiHandler) postAlias5() {}
 // @Tags heartbeat
 // @Accept json
 // @Param heartbeat body []models.Heartbeat true "Multiple heartbeats"
+// @Param Idempotency-Key header string false "Replays of a request with the same key return the original response instead of inserting again"
 // @Param user path string true "Username (or current)"
 // @Security ApiKeyAuth
 // @Success 201
@@ -239,6 +455,7 @@ func (h *HeartbeatApiHandler) postAlias6() {}
 // @Tags heartbeat
 // @Accept json
 // @Param heartbeat body []models.Heartbeat true "Multiple heartbeats"
+// @Param Idempotency-Key header string false "Replays of a request with the same key return the original response instead of inserting again"
 // @Param user path string true "Username (or current)"
 // @Security ApiKeyAuth
 // @Success 201
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/models"
+	wakatime "github.com/hackclub/hackatime/models/compat/wakatime/v1"
+	routeutils "github.com/hackclub/hackatime/routes/utils"
+)
+
+const (
+	heartbeatChangesDefaultLimit = 1000
+	heartbeatChangesMaxLimit     = 10000
+)
+
+type heartbeatChangesResponseVm struct {
+	Inserted []*wakatime.HeartbeatEntry  `json:"inserted"`
+	Deleted  []*models.HeartbeatDeletion `json:"deleted"`
+	Cursor   string                      `json:"cursor"`
+	HasMore  bool                        `json:"has_more"`
+}
+
+// @Summary Retrieve heartbeats inserted and deleted since a cursor, to keep an incremental mirror of a user's data
+// @Description Pass the cursor returned by the previous call to fetch the next batch of changes, omit it to start from scratch. Deletions should be applied before insertions, those with a machine only affect heartbeats from that machine.
+// @ID get-heartbeat-changes
+// @Tags heartbeat
+// @Produce json
+// @Param user path string true "Username (or current)"
+// @Param since query string false "Cursor returned by the previous request"
+// @Param limit query int false "Maximum number of insertions and deletions to return (default 1000, max 10000)"
+// @Security ApiKeyAuth
+// @Success 200 {object} heartbeatChangesResponseVm
+// @Router /users/{user}/heartbeats/changes [get]
+func (h *HeartbeatApiHandler) GetChanges(w http.ResponseWriter, r *http.Request) {
+	user, err := routeutils.CheckEffectiveUser(w, r, h.userSrvc, "current")
+	if err != nil {
+		return // response was already sent by util function
+	}
+
+	cursor, err := models.ParseHeartbeatChangesCursor(r.URL.Query().Get("since"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	limit := heartbeatChangesDefaultLimit
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		limit = min(l, heartbeatChangesMaxLimit)
+	}
+
+	changes, err := h.heartbeatSrvc.GetChangesSince(user, cursor, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve heartbeat changes", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, &heartbeatChangesResponseVm{
+		Inserted: wakatime.HeartbeatsToCompat(changes.Heartbeats, nil), // project names are only hidden in wakatime-compatible endpoints
+		Deleted:  changes.Deletions,
+		Cursor:   changes.Cursor.String(),
+		HasMore:  changes.HasMore,
+	})
+}
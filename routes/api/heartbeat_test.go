@@ -0,0 +1,66 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/hackclub/hackatime/services"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newHasDataTestHandler(userService services.IUserService) *HeartbeatApiHandler {
+	return &HeartbeatApiHandler{
+		config:       config.Get(),
+		userSrvc:     userService,
+		queueWorkers: config.GetQueue(config.QueueProcessing),
+	}
+}
+
+func TestHeartbeatApiHandler_MarkHasData(t *testing.T) {
+	config.Set(config.Empty())
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	assert.Nil(t, err)
+	sqlDb, _ := db.DB()
+	sqlDb.SetMaxOpenConns(1)
+	assert.Nil(t, db.AutoMigrate(&models.User{}))
+
+	userRepo := repositories.NewUserRepository(db)
+	userService := services.NewUserService(new(mocks.MailServiceMock), userRepo, nil)
+
+	_, created, err := userRepo.InsertOrGet(&models.User{ID: "user1", Password: "old-password"})
+	assert.Nil(t, err)
+	assert.True(t, created)
+
+	// principal of the heartbeat request
+	principal, err := userRepo.FindOne(models.User{ID: "user1"})
+	assert.Nil(t, err)
+	principal.HasData = true
+
+	// changed by another request before the flag is written
+	concurrent, _ := userRepo.FindOne(models.User{ID: "user1"})
+	suspendedAt := models.CustomTime(time.Now())
+	concurrent.SuspendedAt = &suspendedAt
+	concurrent.Password = "new-password"
+	_, err = userService.Update(concurrent)
+	assert.Nil(t, err)
+
+	sut := newHasDataTestHandler(userService)
+	sut.markHasData(principal)
+
+	assert.Eventually(t, func() bool {
+		u, err := userRepo.FindOne(models.User{ID: "user1"})
+		return err == nil && u.HasData
+	}, time.Second, 5*time.Millisecond)
+
+	persisted, _ := userRepo.FindOne(models.User{ID: "user1"})
+	assert.True(t, persisted.IsSuspended())
+	assert.Equal(t, "new-password", persisted.Password)
+}
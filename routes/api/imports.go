@@ -0,0 +1,124 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/services"
+)
+
+type ImportsApiHandler struct {
+	config     *conf.Config
+	userSrvc   services.IUserService
+	importSrvc services.IImportService
+}
+
+func NewImportsApiHandler(userService services.IUserService, importService services.IImportService) *ImportsApiHandler {
+	return &ImportsApiHandler{
+		config:     conf.Get(),
+		userSrvc:   userService,
+		importSrvc: importService,
+	}
+}
+
+func (h *ImportsApiHandler) RegisterRoutes(router chi.Router) {
+	if !h.config.App.ImportEnabled {
+		return
+	}
+
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Post("/", h.Post)
+	r.Get("/{id}", h.Get)
+	r.Post("/{id}/complete", h.Complete)
+
+	router.Mount("/imports", r)
+}
+
+// @Summary Start an import of a (possibly huge) wakatime data export by requesting a pre-signed url to upload it to
+// @Description Upload the json export (optionally gzip-compressed) to upload_url using upload_method, then complete the upload to have it processed in the background. For post (s3), send a multipart form with all upload_fields followed by the archive as field "file". With local object storage, the archive is put and may be uploaded in chunks by passing the current byte offset as offset query parameter. The url expires at upload_expires_at and archives larger than upload_max_bytes are rejected.
+// @ID post-import
+// @Tags import
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 202 {object} models.ImportJob
+// @Router /imports [post]
+func (h *ImportsApiHandler) Post(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	job, err := h.importSrvc.CreateUpload(user)
+	if errors.Is(err, services.ErrImportInvalid) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to create import upload", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusAccepted, job.Snapshot())
+}
+
+// @Summary Complete the upload of an import archive and start processing it, the upload url can't be used anymore afterwards
+// @ID post-import-complete
+// @Tags import
+// @Produce json
+// @Param id path string true "Import ID"
+// @Security ApiKeyAuth
+// @Success 202 {object} models.ImportJob
+// @Failure 413 {string} string "archive exceeds upload_max_bytes"
+// @Router /imports/{id}/complete [post]
+func (h *ImportsApiHandler) Complete(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	job, err := h.importSrvc.Complete(user, chi.URLParam(r, "id"))
+	if errors.Is(err, services.ErrImportNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return
+	}
+	if errors.Is(err, services.ErrImportInvalid) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if errors.Is(err, services.ErrImportTooLarge) {
+		w.WriteHeader(http.StatusRequestEntityTooLarge)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("upload missing or incomplete"))
+		conf.Log().Request(r).Warn("failed to complete import upload", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusAccepted, job.Snapshot())
+}
+
+// @Summary Retrieve the status and progress of an import
+// @ID get-import
+// @Tags import
+// @Produce json
+// @Param id path string true "Import ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.ImportJob
+// @Router /imports/{id} [get]
+func (h *ImportsApiHandler) Get(w http.ResponseWriter, r *http.Request) {
+	job, err := h.importSrvc.Get(middlewares.GetPrincipal(r), chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, job.Snapshot())
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type LanguageGoalApiHandler struct {
+	config           *conf.Config
+	userSrvc         services.IUserService
+	languageGoalSrvc services.ILanguageGoalService
+}
+
+func NewLanguageGoalApiHandler(userService services.IUserService, languageGoalService services.ILanguageGoalService) *LanguageGoalApiHandler {
+	return &LanguageGoalApiHandler{
+		config:           conf.Get(),
+		userSrvc:         userService,
+		languageGoalSrvc: languageGoalService,
+	}
+}
+
+func (h *LanguageGoalApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.GetAll)
+	r.Post("/", h.Post)
+	r.Delete("/{id}", h.Delete)
+
+	router.Mount("/goals/languages", r)
+}
+
+// @Summary Retrieve the authenticated user's language goals along with their progress in the current week
+// @ID get-language-goals
+// @Tags goals
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.LanguageGoalProgress
+// @Router /goals/languages [get]
+func (h *LanguageGoalApiHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	goals, err := h.languageGoalSrvc.GetByUser(user.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve language goals", "userID", user.ID, "error", err)
+		return
+	}
+
+	progress, err := h.languageGoalSrvc.GetProgress(user, goals)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to compute language goal progress", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, progress)
+}
+
+// @Summary Add a goal to spend at least a certain share of weekly coding time in a language
+// @ID post-language-goal
+// @Tags goals
+// @Accept json
+// @Produce json
+// @Param goal body models.LanguageGoal true "Language, minimum share in percent and optionally the only category to count, e.g. {\"language\": \"Rust\", \"min_percent\": 30, \"category\": \"coding\"}"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.LanguageGoal
+// @Router /goals/languages [post]
+func (h *LanguageGoalApiHandler) Post(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	var goal models.LanguageGoal
+	if err := json.NewDecoder(r.Body).Decode(&goal); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+	goal = models.LanguageGoal{UserID: user.ID, Language: goal.Language, MinPercent: goal.MinPercent, Category: strings.TrimSpace(goal.Category)}
+
+	if !goal.IsValid() {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid language goal"))
+		return
+	}
+
+	result, err := h.languageGoalSrvc.Create(&goal)
+	if errors.Is(err, services.ErrLanguageGoalLimit) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to create language goal", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusCreated, result)
+}
+
+// @Summary Remove one of the authenticated user's language goals
+// @ID delete-language-goal
+// @Tags goals
+// @Param id path int true "Goal ID"
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /goals/languages/{id} [delete]
+func (h *LanguageGoalApiHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	if err := h.languageGoalSrvc.Delete(user.ID, uint(id)); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to delete language goal", "userID", user.ID, "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
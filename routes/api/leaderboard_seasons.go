@@ -0,0 +1,107 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+	"github.com/hackclub/hackatime/utils"
+	"gorm.io/gorm"
+)
+
+type seasonStandingVm struct {
+	Rank        uint          `json:"rank"`
+	UserID      string        `json:"user_id"`
+	Username    string        `json:"username,omitempty"`
+	DisplayName string        `json:"display_name,omitempty"`
+	AvatarUrl   string        `json:"avatar_url,omitempty"`
+	Total       time.Duration `json:"total" swaggertype:"primitive,integer"`
+}
+
+type seasonStandingsVm struct {
+	Season    *models.LeaderboardSeason `json:"season"`
+	Standings []*seasonStandingVm       `json:"standings"`
+}
+
+type LeaderboardSeasonsApiHandler struct {
+	config          *conf.Config
+	leaderboardSrvc services.ILeaderboardService
+}
+
+func NewLeaderboardSeasonsApiHandler(leaderboardService services.ILeaderboardService) *LeaderboardSeasonsApiHandler {
+	return &LeaderboardSeasonsApiHandler{
+		config:          conf.Get(),
+		leaderboardSrvc: leaderboardService,
+	}
+}
+
+func (h *LeaderboardSeasonsApiHandler) RegisterRoutes(router chi.Router) {
+	if !h.config.App.LeaderboardEnabled {
+		return
+	}
+
+	r := chi.NewRouter()
+	r.Get("/", h.GetSeasons)
+	r.Get("/{season}", h.GetStandings)
+
+	router.Mount("/leaderboard/seasons", r)
+}
+
+// @Summary List archived leaderboard seasons, most recent first
+// @ID get-leaderboard-seasons
+// @Tags leaderboard
+// @Produce json
+// @Success 200 {array} models.LeaderboardSeason
+// @Router /leaderboard/seasons [get]
+func (h *LeaderboardSeasonsApiHandler) GetSeasons(w http.ResponseWriter, r *http.Request) {
+	seasons, err := h.leaderboardSrvc.GetSeasons()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve leaderboard seasons", "error", err)
+		return
+	}
+	helpers.RespondJSON(w, r, http.StatusOK, seasons)
+}
+
+// @Summary Retrieve the final standings of an archived leaderboard season
+// @ID get-leaderboard-season-standings
+// @Tags leaderboard
+// @Produce json
+// @Param season path string true "Season name, e.g. 2024-W03 or 2024-01"
+// @Param page query int false "Page number"
+// @Param page_size query int false "Page size (default 100)"
+// @Success 200 {object} seasonStandingsVm
+// @Router /leaderboard/seasons/{season} [get]
+func (h *LeaderboardSeasonsApiHandler) GetStandings(w http.ResponseWriter, r *http.Request) {
+	pageParams := utils.ParsePageParamsWithDefault(r, 1, 100)
+
+	season, standings, err := h.leaderboardSrvc.GetSeasonStandings(chi.URLParam(r, "season"), pageParams, true)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve leaderboard season standings", "error", err)
+		return
+	}
+
+	vm := &seasonStandingsVm{Season: season, Standings: make([]*seasonStandingVm, len(standings))}
+	for i, s := range standings {
+		vm.Standings[i] = &seasonStandingVm{Rank: s.Rank, UserID: s.UserID, Total: s.Total}
+		if s.User != nil {
+			vm.Standings[i].Username = s.User.Name
+			vm.Standings[i].DisplayName = s.User.DisplayName()
+			vm.Standings[i].AvatarUrl = s.User.AvatarURL(h.config.App.AvatarURLTemplate)
+		}
+	}
+	helpers.RespondJSON(w, r, http.StatusOK, vm)
+}
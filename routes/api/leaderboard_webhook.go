@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+	"gorm.io/gorm"
+)
+
+type LeaderboardWebhookApiHandler struct {
+	config                 *conf.Config
+	userSrvc               services.IUserService
+	leaderboardWebhookSrvc services.ILeaderboardWebhookService
+}
+
+func NewLeaderboardWebhookApiHandler(userService services.IUserService, leaderboardWebhookService services.ILeaderboardWebhookService) *LeaderboardWebhookApiHandler {
+	return &LeaderboardWebhookApiHandler{
+		config:                 conf.Get(),
+		userSrvc:               userService,
+		leaderboardWebhookSrvc: leaderboardWebhookService,
+	}
+}
+
+func (h *LeaderboardWebhookApiHandler) RegisterRoutes(router chi.Router) {
+	if !h.config.App.LeaderboardEnabled || !h.config.App.LeaderboardWebhooks {
+		return
+	}
+
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.Get)
+	r.Put("/", h.Put)
+	r.Delete("/", h.Delete)
+
+	router.Mount("/leaderboard/webhook", r)
+}
+
+// @Summary Retrieve the authenticated user's leaderboard webhook
+// @ID get-leaderboard-webhook
+// @Tags leaderboard
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.LeaderboardWebhook
+// @Router /leaderboard/webhook [get]
+func (h *LeaderboardWebhookApiHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	webhook, err := h.leaderboardWebhookSrvc.GetByUser(user.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve leaderboard webhook", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, webhook)
+}
+
+// @Summary Set up a webhook to be called when the authenticated user's rank on the live leaderboard changes by at least min_rank_change positions or, if notify_overtaken is set, when others overtake them
+// @Description Format json posts a models.LeaderboardRankEvent, signed with the secret (if any) in the X-Hackatime-Signature header, format slack posts a message to a slack incoming webhook. Only https urls are accepted.
+// @ID put-leaderboard-webhook
+// @Tags leaderboard
+// @Accept json
+// @Produce json
+// @Param webhook body models.LeaderboardWebhook true "Webhook to set up, replaces an existing one"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.LeaderboardWebhook
+// @Router /leaderboard/webhook [put]
+func (h *LeaderboardWebhookApiHandler) Put(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	var webhook models.LeaderboardWebhook
+	if err := json.NewDecoder(r.Body).Decode(&webhook); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+	webhook.UserID = user.ID
+
+	result, err := h.leaderboardWebhookSrvc.Set(&webhook)
+	if errors.Is(err, services.ErrLeaderboardWebhookInvalid) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to save leaderboard webhook", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, result)
+}
+
+// @Summary Remove the authenticated user's leaderboard webhook
+// @ID delete-leaderboard-webhook
+// @Tags leaderboard
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /leaderboard/webhook [delete]
+func (h *LeaderboardWebhookApiHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	if err := h.leaderboardWebhookSrvc.Delete(user.ID); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to delete leaderboard webhook", "userID", user.ID, "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
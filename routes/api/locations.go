@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/services"
+)
+
+type LocationsApiHandler struct {
+	config   *conf.Config
+	userSrvc services.IUserService
+	geoSrvc  services.IGeoService
+}
+
+func NewLocationsApiHandler(userService services.IUserService, geoService services.IGeoService) *LocationsApiHandler {
+	return &LocationsApiHandler{
+		config:   conf.Get(),
+		userSrvc: userService,
+		geoSrvc:  geoService,
+	}
+}
+
+func (h *LocationsApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.Get)
+
+	router.Mount("/locations", r)
+}
+
+// @Summary Retrieve the authenticated user's coding time by the countries their machines sent heartbeats from ("where I code from")
+// @Description Only available if the instance has a geoip database. Time from machines without a known country, e.g. because the user opted out of location tracking, is attributed to "unknown".
+// @ID get-locations
+// @Tags summary
+// @Produce json
+// @Param interval query string false "Interval identifier" Enums(today, yesterday, week, month, year, 7_days, last_7_days, 30_days, last_30_days, 6_months, last_6_months, 12_months, last_12_months, last_year, any, all_time, low_skies, high_seas, last_24h, last_90m)
+// @Param from query string false "Start date (e.g. '2021-02-07')"
+// @Param to query string false "End date (e.g. '2021-02-08')"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.LocationSummary
+// @Router /locations [get]
+func (h *LocationsApiHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	if !h.geoSrvc.Enabled() {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("location tracking is not enabled on this instance"))
+		return
+	}
+
+	params, err := helpers.ParseSummaryParams(r)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	locations, err := h.geoSrvc.Summarize(user, params.From, params.To)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to summarize locations", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, locations)
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+	"github.com/hackclub/hackatime/utils"
+)
+
+const (
+	defaultOverlapRange = 7 * 24 * time.Hour
+	maxOverlapRange     = 31 * 24 * time.Hour
+)
+
+type machineHeartbeatsRequest struct {
+	Machine string `json:"machine"`
+	ApiKey  string `json:"api_key,omitempty"` // of the user to reassign heartbeats to
+}
+
+type machineHeartbeatsVm struct {
+	Machine    string `json:"machine"`
+	Heartbeats int64  `json:"heartbeats"`
+}
+
+type MachinesApiHandler struct {
+	config          *conf.Config
+	userSrvc        services.IUserService
+	machineSrvc     services.IMachineService
+	aggregationSrvc services.IAggregationService
+	durationSrvc    services.IDurationService
+}
+
+func NewMachinesApiHandler(userService services.IUserService, machineService services.IMachineService, aggregationService services.IAggregationService, durationService services.IDurationService) *MachinesApiHandler {
+	return &MachinesApiHandler{
+		config:          conf.Get(),
+		userSrvc:        userService,
+		machineSrvc:     machineService,
+		aggregationSrvc: aggregationService,
+		durationSrvc:    durationService,
+	}
+}
+
+func (h *MachinesApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	// machine names are passed in the body, because they may contain arbitrary characters
+	r.Post("/delete", h.PostDelete)
+	r.Post("/reassign", h.PostReassign)
+	r.Get("/overlap", h.GetOverlap)
+
+	router.Mount("/machines", r)
+}
+
+// @Summary Delete all of the authenticated user's heartbeats from a machine
+// @Description Summaries of the affected days are re-generated in the background. Mirrors following the changes feed receive a deletion restricted to the machine.
+// @ID post-machine-delete
+// @Tags machines
+// @Accept json
+// @Produce json
+// @Param request body machineHeartbeatsRequest true "Machine name"
+// @Security ApiKeyAuth
+// @Success 200 {object} machineHeartbeatsVm
+// @Router /machines/delete [post]
+func (h *MachinesApiHandler) PostDelete(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	req, ok := h.decodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	interval, n, err := h.machineSrvc.DeleteHeartbeats(user, req.Machine)
+	if err != nil {
+		h.respondError(w, r, user, err)
+		return
+	}
+
+	h.resummarize(user, interval)
+	helpers.RespondJSON(w, r, http.StatusOK, &machineHeartbeatsVm{Machine: req.Machine, Heartbeats: n})
+}
+
+// @Summary Move all of the authenticated user's heartbeats from a machine to another user
+// @Description Meant for fixing machines set up with the wrong api key. Requires the api key of the user to move the heartbeats to. Summaries of both users are re-generated in the background.
+// @ID post-machine-reassign
+// @Tags machines
+// @Accept json
+// @Produce json
+// @Param request body machineHeartbeatsRequest true "Machine name and the other user's api key"
+// @Security ApiKeyAuth
+// @Success 200 {object} machineHeartbeatsVm
+// @Router /machines/reassign [post]
+func (h *MachinesApiHandler) PostReassign(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	req, ok := h.decodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	target, err := h.userSrvc.GetUserByKey(req.ApiKey)
+	if err != nil {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("invalid api key of target user"))
+		return
+	}
+
+	interval, n, err := h.machineSrvc.ReassignHeartbeats(user, target, req.Machine)
+	if err != nil {
+		h.respondError(w, r, user, err)
+		return
+	}
+
+	h.resummarize(user, interval)
+	h.resummarize(target, interval)
+	helpers.RespondJSON(w, r, http.StatusOK, &machineHeartbeatsVm{Machine: req.Machine, Heartbeats: n})
+}
+
+// @Summary Retrieve how much time the authenticated user's machines were active in parallel and how much of it was collapsed by the overlap strategy
+// @Description Durations are in nanoseconds. Defaults to the past 7 days, at most 31 days can be requested.
+// @ID get-machine-overlap
+// @Tags machines
+// @Produce json
+// @Param from query string false "Start date (e.g. '2021-02-07')"
+// @Param to query string false "End date (e.g. '2021-02-08')"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.DurationOverlap
+// @Router /machines/overlap [get]
+func (h *MachinesApiHandler) GetOverlap(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	to, from := time.Now(), time.Now().Add(-defaultOverlapRange)
+	var err error
+	if q := r.URL.Query().Get("to"); q != "" {
+		if to, err = helpers.ParseDateTimeTZ(q, user.TZ()); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid 'to' parameter"))
+			return
+		}
+		from = to.Add(-defaultOverlapRange)
+	}
+	if q := r.URL.Query().Get("from"); q != "" {
+		if from, err = helpers.ParseDateTimeTZ(q, user.TZ()); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid 'from' parameter"))
+			return
+		}
+	}
+	if !to.After(from) || to.Sub(from) > maxOverlapRange {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid date range"))
+		return
+	}
+
+	report, err := h.durationSrvc.GetOverlap(from, to, user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to compute machine overlap", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, report)
+}
+
+func (h *MachinesApiHandler) decodeRequest(w http.ResponseWriter, r *http.Request) (*machineHeartbeatsRequest, bool) {
+	var req machineHeartbeatsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || strings.TrimSpace(req.Machine) == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return nil, false
+	}
+	return &req, true
+}
+
+func (h *MachinesApiHandler) respondError(w http.ResponseWriter, r *http.Request, user *models.User, err error) {
+	switch {
+	case errors.Is(err, services.ErrMachineNoHeartbeats):
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(err.Error()))
+	case errors.Is(err, services.ErrMachineReassignInvalid):
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to update heartbeats of machine", "userID", user.ID, "error", err)
+	}
+}
+
+// resummarize re-generates the already materialized summaries of past days within the given interval
+func (h *MachinesApiHandler) resummarize(user *models.User, interval *models.Interval) {
+	if !interval.Start.Before(utils.BeginOfToday(time.Local)) {
+		return
+	}
+	if _, err := h.aggregationSrvc.Resummarize(user, interval.Start, interval.End); err != nil {
+		conf.Log().Warn("failed to re-generate summaries after changing heartbeats of machine, please retry using /api/admin/resummarize", "userID", user.ID, "error", err)
+	}
+}
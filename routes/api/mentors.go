@@ -0,0 +1,131 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type mentorRequest struct {
+	Email string `json:"email"`
+}
+
+type MentorsApiHandler struct {
+	config     *conf.Config
+	userSrvc   services.IUserService
+	mentorSrvc services.IMentorService
+}
+
+func NewMentorsApiHandler(userService services.IUserService, mentorService services.IMentorService) *MentorsApiHandler {
+	return &MentorsApiHandler{
+		config:     conf.Get(),
+		userSrvc:   userService,
+		mentorSrvc: mentorService,
+	}
+}
+
+func (h *MentorsApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.GetAll)
+	r.Post("/", h.Post)
+	r.Delete("/{id}", h.Delete)
+
+	router.Mount("/mentors", r)
+}
+
+// @Summary Retrieve the authenticated user's mentors, who receive a weekly summary of their coding time
+// @ID get-mentors
+// @Tags mentors
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.Mentor
+// @Router /mentors [get]
+func (h *MentorsApiHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	mentors, err := h.mentorSrvc.GetByUser(user.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve mentors", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, mentors)
+}
+
+// @Summary Add a mentor, e.g. a teacher or parent, who receives the weekly total and top project of the authenticated user via mail
+// @Description The mail includes no further details, like files or languages, and a link to unsubscribe. Requires a verified e-mail address, at most three mentors can be added.
+// @ID post-mentor
+// @Tags mentors
+// @Accept json
+// @Produce json
+// @Param mentor body mentorRequest true "E-mail address of the mentor"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.Mentor
+// @Router /mentors [post]
+func (h *MentorsApiHandler) Post(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	var req mentorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	mentor, err := h.mentorSrvc.Create(user, models.NewMentor(req.Email))
+	if errors.Is(err, services.ErrMentorInvalid) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to create mentor", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusCreated, mentor)
+}
+
+// @Summary Remove a mentor, who then won't receive any more weekly summaries
+// @ID delete-mentor
+// @Tags mentors
+// @Param id path int true "Mentor ID"
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /mentors/{id} [delete]
+func (h *MentorsApiHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	err = h.mentorSrvc.Delete(user, uint(id))
+	switch {
+	case errors.Is(err, services.ErrMentorNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+	case err != nil:
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to delete mentor", "userID", user.ID, "error", err)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
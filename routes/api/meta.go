@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+)
+
+const compatRoutePrefix = "/compat/wakatime/"
+
+type MetaApiHandler struct {
+	config          *conf.Config
+	routes          chi.Routes
+	compatEndpoints []string
+	walkOnce        sync.Once
+}
+
+func NewMetaApiHandler() *MetaApiHandler {
+	return &MetaApiHandler{config: conf.Get()}
+}
+
+func (h *MetaApiHandler) RegisterRoutes(router chi.Router) {
+	h.routes = router // walked upon the first request, once all other handlers registered their routes
+	router.Get("/meta/capabilities", h.GetCapabilities)
+}
+
+// @Summary Retrieve the instance's capabilities, i.e. supported wakatime-compatible endpoints, limits and enabled features
+// @Description Intended for plugin authors to adapt to the instance instead of hardcoding wakatime's behavior.
+// @ID get-capabilities
+// @Tags misc
+// @Produce json
+// @Success 200 {object} models.Capabilities
+// @Router /meta/capabilities [get]
+func (h *MetaApiHandler) GetCapabilities(w http.ResponseWriter, r *http.Request) {
+	h.walkOnce.Do(func() {
+		h.compatEndpoints = h.listCompatEndpoints()
+	})
+
+	apiUrl := h.config.Server.GetPublicUrl() + "/api"
+	capabilities := &models.Capabilities{
+		Version: h.config.Version,
+		ApiUrl:  apiUrl,
+		WakatimeCompat: &models.CapabilitiesCompat{
+			BaseUrl:   apiUrl,
+			Endpoints: h.compatEndpoints,
+		},
+		Limits: &models.CapabilitiesLimits{
+			HeartbeatMaxBodyBytes:     h.config.App.HeartbeatMaxBodyKb * 1024,
+			HeartbeatBulkMaxBodyBytes: h.config.App.HeartbeatBulkMaxBodyKb * 1024,
+			HeartbeatMaxAgeSec:        int64(h.config.App.HeartbeatsMaxAge().Seconds()),
+			HeartbeatMaxFutureSkewSec: int64(h.config.App.HeartbeatsMaxFutureSkew().Seconds()),
+			HeartbeatClampFutureSkew:  h.config.App.HeartbeatClampFutureSkew,
+			RequestTimeoutSec:         h.config.Server.TimeoutSec,
+		},
+		Features: map[string]bool{
+			"leaderboard":     h.config.App.LeaderboardEnabled,
+			"signup":          h.config.Security.GetRegistrationMode() != conf.RegistrationModeClosed,
+			"device_flow":     h.config.Security.DeviceFlow,
+			"imports":         h.config.App.ImportEnabled,
+			"exports":         h.config.Exports.Enabled,
+			"plugin_updates":  h.config.PluginUpdates.Enabled,
+			"load_shedding":   h.config.LoadShedding.Enabled,
+			"watcher_events":  true,
+			"idempotency_key": true,
+		},
+		Headers: &models.CapabilitiesHeaders{
+			IdempotencyKey: middlewares.HeaderIdempotencyKey,
+			MachineName:    "X-Machine-Name",
+			RequestId:      middlewares.HeaderRequestId,
+		},
+	}
+
+	w.Header().Set("Cache-Control", "max-age=3600")
+	helpers.RespondJSON(w, r, http.StatusOK, capabilities)
+}
+
+func (h *MetaApiHandler) listCompatEndpoints() []string {
+	endpoints := make([]string, 0)
+	if h.routes == nil {
+		return endpoints
+	}
+
+	seen := map[string]bool{}
+	chi.Walk(h.routes, func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		route = strings.TrimSuffix(route, "/")
+		if !strings.Contains(route, compatRoutePrefix) {
+			return nil
+		}
+		if endpoint := method + " " + route; !seen[endpoint] {
+			seen[endpoint] = true
+			endpoints = append(endpoints, endpoint)
+		}
+		return nil
+	})
+
+	sort.Strings(endpoints)
+	return endpoints
+}
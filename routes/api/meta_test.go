@@ -0,0 +1,49 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetaApiHandler_GetCapabilities(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Server.PublicUrl = "https://hackatime.example.org/"
+	cfg.App.HeartbeatBulkMaxBodyKb = 16
+	cfg.App.HeartbeatMaxAge = "24h"
+	cfg.Security.DeviceFlow = true
+	config.Set(cfg)
+
+	noop := func(w http.ResponseWriter, r *http.Request) {}
+
+	router := chi.NewRouter()
+	NewMetaApiHandler().RegisterRoutes(router)
+	// registered after the meta handler on purpose
+	router.Get("/compat/wakatime/v1/users/{user}/stats/{range}", noop)
+	router.Group(func(r chi.Router) {
+		r.Post("/compat/wakatime/v1/users/{user}/heartbeats.bulk", noop)
+		r.Post("/users/{user}/heartbeats.bulk", noop)
+	})
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/meta/capabilities", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var capabilities models.Capabilities
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&capabilities))
+	assert.Equal(t, "https://hackatime.example.org/api", capabilities.ApiUrl)
+	assert.Equal(t, []string{
+		"GET /compat/wakatime/v1/users/{user}/stats/{range}",
+		"POST /compat/wakatime/v1/users/{user}/heartbeats.bulk",
+	}, capabilities.WakatimeCompat.Endpoints)
+	assert.Equal(t, int64(16*1024), capabilities.Limits.HeartbeatBulkMaxBodyBytes)
+	assert.Equal(t, int64(86400), capabilities.Limits.HeartbeatMaxAgeSec)
+	assert.True(t, capabilities.Features["device_flow"])
+	assert.False(t, capabilities.Features["plugin_updates"])
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/alitto/pond"
+	"github.com/duke-git/lancet/v2/condition"
 	"github.com/go-chi/chi/v5"
 	conf "github.com/hackclub/hackatime/config"
 	"github.com/hackclub/hackatime/helpers"
@@ -43,6 +44,7 @@ const (
 	DescAdminUserTime        = "Total tracked activity in seconds (all time) (active users only)."
 	DescAdminTotalUsers      = "Total number of registered users."
 	DescAdminActiveUsers     = "Number of active users."
+	DescAdminUserDailyTime   = "Total tracked activity in seconds today, in the user's time zone (leaderboard participants only)."
 
 	DescJobQueueEnqueued      = "Number of jobs currently enqueued"
 	DescJobQueueTotalFinished = "Total number of processed jobs"
@@ -59,27 +61,38 @@ const (
 	DescNumGCTotal      = "Total cumulative number of GC cycles"
 	DescGoroutines      = "Total number of currently running goroutines"
 	DescDatabaseSize    = "Total database size in bytes"
+
+	DescLoadSheddingDegraded = "Whether the application currently runs in degraded mode due to high load"
+	DescLoadSheddingSpooled  = "Number of heartbeats currently held back to be written later"
+	DescLoadSheddingShed     = "Total number of requests (or heartbeats) affected by load shedding"
+	DescIngestionIpBlocked   = "Total number of heartbeat requests rejected by ip allow- or denylists"
+	DescIngestionTruncated   = "Total number of heartbeat fields truncated at ingestion for exceeding their max. length"
+	DescJanitorRemoved       = "Total number of stale items removed by the janitor since startup"
 )
 
 type MetricsHandler struct {
-	config          *conf.Config
-	userSrvc        services.IUserService
-	summarySrvc     services.ISummaryService
-	heartbeatSrvc   services.IHeartbeatService
-	leaderboardSrvc services.ILeaderboardService
-	keyValueSrvc    services.IKeyValueService
-	metricsRepo     *repositories.MetricsRepository
+	config           *conf.Config
+	userSrvc         services.IUserService
+	summarySrvc      services.ISummaryService
+	heartbeatSrvc    services.IHeartbeatService
+	leaderboardSrvc  services.ILeaderboardService
+	keyValueSrvc     services.IKeyValueService
+	metricsRepo      *repositories.MetricsRepository
+	loadSheddingSrvc services.ILoadSheddingService
+	janitorSrvc      services.IJanitorService
 }
 
-func NewMetricsHandler(userService services.IUserService, summaryService services.ISummaryService, heartbeatService services.IHeartbeatService, leaderboardService services.ILeaderboardService, keyValueService services.IKeyValueService, metricsRepo *repositories.MetricsRepository) *MetricsHandler {
+func NewMetricsHandler(userService services.IUserService, summaryService services.ISummaryService, heartbeatService services.IHeartbeatService, leaderboardService services.ILeaderboardService, keyValueService services.IKeyValueService, loadSheddingService services.ILoadSheddingService, janitorService services.IJanitorService, metricsRepo *repositories.MetricsRepository) *MetricsHandler {
 	return &MetricsHandler{
-		userSrvc:        userService,
-		summarySrvc:     summaryService,
-		heartbeatSrvc:   heartbeatService,
-		leaderboardSrvc: leaderboardService,
-		keyValueSrvc:    keyValueService,
-		metricsRepo:     metricsRepo,
-		config:          conf.Get(),
+		userSrvc:         userService,
+		summarySrvc:      summaryService,
+		heartbeatSrvc:    heartbeatService,
+		leaderboardSrvc:  leaderboardService,
+		keyValueSrvc:     keyValueService,
+		loadSheddingSrvc: loadSheddingService,
+		janitorSrvc:      janitorService,
+		metricsRepo:      metricsRepo,
+		config:           conf.Get(),
 	}
 }
 
@@ -107,17 +120,34 @@ func (h *MetricsHandler) Get(w http.ResponseWriter, r *http.Request) {
 
 	var metrics mm.Metrics
 
-	if userMetrics, err := h.getUserMetrics(reqUser); err != nil {
+	// user metrics are skipped while summaries can't be computed, so that load shedding metrics are still available
+	if userMetrics, err := h.getUserMetrics(reqUser); err != nil && !errors.Is(err, services.ErrDegraded) {
 		conf.Log().Request(r).Error("error occurred", "error", err)
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(conf.ErrInternalServerError))
 		return
-	} else {
+	} else if err == nil {
 		for _, m := range *userMetrics {
 			metrics = append(metrics, m)
 		}
 	}
 
+	for _, m := range h.getLoadSheddingMetrics() {
+		metrics = append(metrics, m)
+	}
+
+	for _, m := range h.getIpFilterMetrics() {
+		metrics = append(metrics, m)
+	}
+
+	for _, m := range h.getTruncationMetrics() {
+		metrics = append(metrics, m)
+	}
+
+	for _, m := range h.getJanitorMetrics() {
+		metrics = append(metrics, m)
+	}
+
 	if reqUser.IsAdmin {
 		if adminMetrics, err := h.getAdminMetrics(reqUser); err != nil {
 			conf.Log().Request(r).Error("error occurred", "error", err)
@@ -174,7 +204,7 @@ func (h *MetricsHandler) getUserMetrics(user *models.User) (*mm.Metrics, error)
 	metrics = append(metrics, &mm.GaugeMetric{
 		Name:   MetricsPrefix + "_cumulative_seconds_total",
 		Desc:   DescAllTime,
-		Value:  int64(v1.NewAllTimeFrom(summaryAllTime).Data.TotalSeconds),
+		Value:  int64(v1.NewAllTimeFrom(summaryAllTime, user).Data.TotalSeconds),
 		Labels: []mm.Label{},
 	})
 
@@ -380,6 +410,83 @@ func (h *MetricsHandler) getUserMetrics(user *models.User) (*mm.Metrics, error)
 	return &metrics, nil
 }
 
+func (h *MetricsHandler) getLoadSheddingMetrics() mm.Metrics {
+	var metrics mm.Metrics
+
+	metrics = append(metrics, &mm.GaugeMetric{
+		Name:   MetricsPrefix + "_load_shedding_degraded",
+		Desc:   DescLoadSheddingDegraded,
+		Value:  int64(condition.TernaryOperator(h.loadSheddingSrvc.IsDegraded(), 1, 0)),
+		Labels: []mm.Label{},
+	})
+
+	metrics = append(metrics, &mm.GaugeMetric{
+		Name:   MetricsPrefix + "_load_shedding_spooled_heartbeats",
+		Desc:   DescLoadSheddingSpooled,
+		Value:  int64(h.loadSheddingSrvc.CountSpooled()),
+		Labels: []mm.Label{},
+	})
+
+	for _, reason := range []string{services.ShedReasonRejected, services.ShedReasonUnavailable, services.ShedReasonSpooled} {
+		metrics = append(metrics, &mm.CounterMetric{
+			Name:   MetricsPrefix + "_load_shedding_shed_total",
+			Desc:   DescLoadSheddingShed,
+			Value:  h.loadSheddingSrvc.GetShedCounts()[reason],
+			Labels: []mm.Label{{Key: "reason", Value: reason}},
+		})
+	}
+
+	return metrics
+}
+
+func (h *MetricsHandler) getIpFilterMetrics() mm.Metrics {
+	var metrics mm.Metrics
+
+	counts := middlewares.GetIpBlockedCounts()
+	for _, reason := range []string{middlewares.IpBlockedReasonDenied, middlewares.IpBlockedReasonNotAllowed, middlewares.IpBlockedReasonUnknownOrigin} {
+		metrics = append(metrics, &mm.CounterMetric{
+			Name:   MetricsPrefix + "_ingestion_ip_blocked_total",
+			Desc:   DescIngestionIpBlocked,
+			Value:  counts[reason],
+			Labels: []mm.Label{{Key: "reason", Value: reason}},
+		})
+	}
+
+	return metrics
+}
+
+func (h *MetricsHandler) getTruncationMetrics() mm.Metrics {
+	var metrics mm.Metrics
+
+	counts := h.heartbeatSrvc.GetTruncatedCounts()
+	for _, field := range models.HeartbeatNormalizedFields() {
+		metrics = append(metrics, &mm.CounterMetric{
+			Name:   MetricsPrefix + "_ingestion_truncated_fields_total",
+			Desc:   DescIngestionTruncated,
+			Value:  counts[field],
+			Labels: []mm.Label{{Key: "field", Value: field}},
+		})
+	}
+
+	return metrics
+}
+
+func (h *MetricsHandler) getJanitorMetrics() mm.Metrics {
+	var metrics mm.Metrics
+
+	counts := h.janitorSrvc.GetRemovedCounts()
+	for _, kind := range services.JanitorKinds() {
+		metrics = append(metrics, &mm.CounterMetric{
+			Name:   MetricsPrefix + "_janitor_removed_total",
+			Desc:   DescJanitorRemoved,
+			Value:  counts[kind],
+			Labels: []mm.Label{{Key: "kind", Value: kind}},
+		})
+	}
+
+	return metrics
+}
+
 func (h *MetricsHandler) getAdminMetrics(user *models.User) (*mm.Metrics, error) {
 	var metrics mm.Metrics
 
@@ -483,5 +590,45 @@ func (h *MetricsHandler) getAdminMetrics(user *models.User) (*mm.Metrics, error)
 	wp.StopAndWait()
 	slog.Debug("finished retrieving total activity time by user", "duration", time.Since(t0))
 
+	if h.config.Security.ExposeUserDailyMetrics {
+		metrics = append(metrics, h.getUserDailyMetrics(activeUsers)...)
+		slog.Debug("finished retrieving daily activity time by user", "duration", time.Since(t0))
+	}
+
 	return &metrics, nil
 }
+
+// getUserDailyMetrics exposes today's total of every active user, who opted in to the public leaderboard, as one series per user,
+// so that dashboards can chart a whole group of users by scraping a single endpoint
+func (h *MetricsHandler) getUserDailyMetrics(activeUsers []*models.User) mm.Metrics {
+	var metrics mm.Metrics
+
+	wp := pond.New(utils.HalfCPUs(), 0)
+	lock := sync.Mutex{}
+
+	for _, u := range activeUsers {
+		if !u.PublicLeaderboard || u.IsServiceAccount {
+			continue
+		}
+		user := u
+		wp.Submit(func() {
+			from, to := helpers.MustResolveIntervalRawTZ("today", user.TZ())
+			summary, err := h.summarySrvc.Aliased(from, to, user, h.summarySrvc.Retrieve, nil, false)
+			if err != nil {
+				conf.Log().Error("failed to get daily time for user as part of metrics", "userID", user.ID, "error", err)
+				return
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			metrics = append(metrics, &mm.GaugeMetric{
+				Name:   MetricsPrefix + "_admin_user_today_seconds_total",
+				Desc:   DescAdminUserDailyTime,
+				Value:  int64(summary.TotalTime().Seconds()),
+				Labels: []mm.Label{{Key: "user", Value: user.ID}},
+			})
+		})
+	}
+
+	wp.StopAndWait()
+	return metrics
+}
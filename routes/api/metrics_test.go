@@ -0,0 +1,61 @@
+package api
+
+import (
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	mm "github.com/hackclub/hackatime/models/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMetricsHandler_GetUserDailyMetrics(t *testing.T) {
+	config.Set(config.Empty())
+
+	berlin := &models.User{ID: "berlin", Location: "Europe/Berlin", PublicLeaderboard: true}
+	tokyo := &models.User{ID: "tokyo", Location: "Asia/Tokyo", PublicLeaderboard: true}
+	private := &models.User{ID: "private", Location: "Europe/Berlin"}
+	service := &models.User{ID: "service", PublicLeaderboard: true, IsServiceAccount: true}
+	broken := &models.User{ID: "broken", PublicLeaderboard: true}
+
+	summaryOf := func(seconds time.Duration) *models.Summary {
+		return &models.Summary{Projects: models.SummaryItems{{Type: models.SummaryProject, Key: "wakapi", Total: seconds}}}
+	}
+
+	// every user's day starts at midnight in their own time zone
+	midnight := func(user *models.User) time.Time {
+		from, _ := helpers.MustResolveIntervalRawTZ("today", user.TZ())
+		return from
+	}
+	now := mock.AnythingOfType("time.Time")
+
+	summaryServiceMock := new(mocks.SummaryServiceMock)
+	summaryServiceMock.On("Aliased", midnight(berlin), now, berlin, mock.Anything, mock.Anything).Return(summaryOf(90), nil)
+	summaryServiceMock.On("Aliased", midnight(tokyo), now, tokyo, mock.Anything, mock.Anything).Return(summaryOf(3600), nil)
+	summaryServiceMock.On("Aliased", midnight(broken), now, broken, mock.Anything, mock.Anything).Return((*models.Summary)(nil), errors.New("failed"))
+
+	sut := &MetricsHandler{config: config.Get(), summarySrvc: summaryServiceMock}
+	metrics := sut.getUserDailyMetrics([]*models.User{berlin, tokyo, private, service, broken})
+
+	sort.Sort(metrics)
+	assert.Len(t, metrics, 2)
+	assert.Equal(t, &mm.GaugeMetric{
+		Name:   MetricsPrefix + "_admin_user_today_seconds_total",
+		Desc:   DescAdminUserDailyTime,
+		Value:  90,
+		Labels: []mm.Label{{Key: "user", Value: "berlin"}},
+	}, metrics[0])
+	assert.Equal(t, int64(3600), metrics[1].(*mm.GaugeMetric).Value)
+	assert.Equal(t, mm.Labels{{Key: "user", Value: "tokyo"}}, metrics[1].(*mm.GaugeMetric).Labels)
+
+	// users, who opted out of the leaderboard, and service accounts are never exposed
+	summaryServiceMock.AssertNotCalled(t, "Aliased", mock.Anything, mock.Anything, private, mock.Anything, mock.Anything)
+	summaryServiceMock.AssertNotCalled(t, "Aliased", mock.Anything, mock.Anything, service, mock.Anything, mock.Anything)
+	summaryServiceMock.AssertNumberOfCalls(t, "Aliased", 3)
+}
@@ -0,0 +1,180 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type NotificationTemplatesApiHandler struct {
+	config                   *conf.Config
+	userSrvc                 services.IUserService
+	notificationTemplateSrvc services.INotificationTemplateService
+}
+
+func NewNotificationTemplatesApiHandler(userService services.IUserService, notificationTemplateService services.INotificationTemplateService) *NotificationTemplatesApiHandler {
+	return &NotificationTemplatesApiHandler{
+		config:                   conf.Get(),
+		userSrvc:                 userService,
+		notificationTemplateSrvc: notificationTemplateService,
+	}
+}
+
+func (h *NotificationTemplatesApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.GetAll)
+	r.Put("/{kind}/{channel}", h.Put)
+	r.Delete("/{kind}/{channel}", h.Delete)
+	r.Post("/{kind}/{channel}/preview", h.PostPreview)
+
+	router.Mount("/admin/notification-templates", r)
+}
+
+// @Summary List the custom notification templates in use on this instance (admin only)
+// @ID get-notification-templates
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.NotificationTemplate
+// @Router /admin/notification-templates [get]
+func (h *NotificationTemplatesApiHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	templates, err := h.notificationTemplateSrvc.GetAll()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve notification templates", "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, templates)
+}
+
+// @Summary Replace the built-in content of a kind of notification on a channel with a custom go template (admin only)
+// @Description Kinds and their channels are inactivity_alert and language_goals_report (mail, telegram) as well as season_winners (slack). Mail templates are html/template and may set a subject, others are text/template. Templates are validated by rendering them with placeholder data.
+// @ID put-notification-template
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param kind path string true "Kind of notification"
+// @Param channel path string true "Channel"
+// @Param template body models.NotificationTemplate true "Only subject and template are considered"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.NotificationTemplate
+// @Router /admin/notification-templates/{kind}/{channel} [put]
+func (h *NotificationTemplatesApiHandler) Put(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	t, ok := h.decodeTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.notificationTemplateSrvc.Set(t); err != nil {
+		h.respondError(w, r, err, "failed to save notification template")
+		return
+	}
+
+	conf.Log().Request(r).Info("changed notification template", "kind", t.Kind, "channel", t.Channel, "admin", middlewares.GetPrincipal(r).ID)
+	helpers.RespondJSON(w, r, http.StatusOK, t)
+}
+
+// @Summary Discard a custom notification template, so that the built-in content is used again (admin only)
+// @ID delete-notification-template
+// @Tags admin
+// @Param kind path string true "Kind of notification"
+// @Param channel path string true "Channel"
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /admin/notification-templates/{kind}/{channel} [delete]
+func (h *NotificationTemplatesApiHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	if err := h.notificationTemplateSrvc.Reset(chi.URLParam(r, "kind"), chi.URLParam(r, "channel")); err != nil {
+		h.respondError(w, r, err, "failed to reset notification template")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Render a notification template with placeholder data without saving it (admin only)
+// @ID post-notification-template-preview
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param kind path string true "Kind of notification"
+// @Param channel path string true "Channel"
+// @Param template body models.NotificationTemplate true "Only subject and template are considered"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.RenderedNotification
+// @Router /admin/notification-templates/{kind}/{channel}/preview [post]
+func (h *NotificationTemplatesApiHandler) PostPreview(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	t, ok := h.decodeTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	rendered, err := h.notificationTemplateSrvc.Preview(t)
+	if err != nil {
+		h.respondError(w, r, err, "failed to preview notification template")
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, rendered)
+}
+
+func (h *NotificationTemplatesApiHandler) decodeTemplate(w http.ResponseWriter, r *http.Request) (*models.NotificationTemplate, bool) {
+	var t models.NotificationTemplate
+	if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return nil, false
+	}
+	t.Kind, t.Channel = chi.URLParam(r, "kind"), chi.URLParam(r, "channel")
+	return &t, true
+}
+
+func (h *NotificationTemplatesApiHandler) respondError(w http.ResponseWriter, r *http.Request, err error, msg string) {
+	if errors.Is(err, services.ErrNotificationTemplateUnsupported) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if errors.Is(err, services.ErrNotificationTemplateInvalid) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error())) // includes the parse or execution error, to help fixing the template
+		return
+	}
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write([]byte(conf.ErrInternalServerError))
+	conf.Log().Request(r).Error(msg, "error", err)
+}
+
+func (h *NotificationTemplatesApiHandler) checkAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if principal := middlewares.GetPrincipal(r); principal == nil || !principal.IsAdmin {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(conf.ErrUnauthorized))
+		return false
+	}
+	return true
+}
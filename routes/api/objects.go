@@ -0,0 +1,125 @@
+package api
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/services"
+	"github.com/hackclub/hackatime/services/objects"
+)
+
+type ObjectsHandler struct {
+	config     *conf.Config
+	objectSrvc services.IObjectStorageService
+}
+
+func NewObjectsHandler(objectStorageService services.IObjectStorageService) *ObjectsHandler {
+	return &ObjectsHandler{
+		config:     conf.Get(),
+		objectSrvc: objectStorageService,
+	}
+}
+
+func (h *ObjectsHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Get("/*", h.Get)
+	r.Put("/*", h.Put)
+	router.Mount("/objects", r)
+}
+
+// @Summary Download a stored artifact using a signed link
+// @ID get-object
+// @Tags misc
+// @Param expires query string true "Expiry timestamp of the link"
+// @Param signature query string true "Signature of the link"
+// @Success 200
+// @Router /objects/{key} [get]
+func (h *ObjectsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "*")
+
+	data, err := h.objectSrvc.Retrieve(key, r.URL.Query().Get("expires"), r.URL.Query().Get("signature"))
+	if err != nil {
+		if errors.Is(err, objects.ErrInvalidSignature) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		if errors.Is(err, objects.ErrNotFound) || errors.Is(err, objects.ErrInvalidKey) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(conf.ErrNotFound))
+			return
+		}
+		conf.Log().Request(r).Error("failed to retrieve object", "key", key, "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		return
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": path.Base(key)}))
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// @Summary Upload (a chunk of) an import archive using a signed upload link
+// @Description Chunks are appended at the given offset, which has to match the size uploaded so far, as returned in the Upload-Offset header. Only used with local object storage, s3 upload links point to the storage directly.
+// @ID put-object
+// @Tags misc
+// @Param expires query string true "Expiry timestamp of the link"
+// @Param signature query string true "Signature of the link"
+// @Param offset query int false "Byte offset of the chunk, defaults to 0"
+// @Success 204
+// @Router /objects/{key} [put]
+func (h *ObjectsHandler) Put(w http.ResponseWriter, r *http.Request) {
+	key := chi.URLParam(r, "*")
+
+	var offset int64
+	if o := r.URL.Query().Get("offset"); o != "" {
+		var err error
+		if offset, err = strconv.ParseInt(o, 10, 64); err != nil || offset < 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid offset"))
+			return
+		}
+	}
+
+	maxSize := int64(h.config.App.ImportMaxUploadMb) * 1024 * 1024
+	size, err := h.objectSrvc.Upload(key, r.URL.Query().Get("expires"), r.URL.Query().Get("signature"), offset, r.Body, maxSize)
+	if err == nil || errors.Is(err, objects.ErrUploadOffset) {
+		w.Header().Set("Upload-Offset", strconv.FormatInt(size, 10))
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, objects.ErrInvalidSignature):
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(err.Error()))
+		case errors.Is(err, objects.ErrUploadOffset), errors.Is(err, objects.ErrUploadCompleted):
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(err.Error()))
+		case errors.Is(err, objects.ErrUploadTooLarge):
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			w.Write([]byte(err.Error()))
+		case errors.Is(err, objects.ErrNotFound), errors.Is(err, objects.ErrInvalidKey):
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(conf.ErrNotFound))
+		default:
+			conf.Log().Request(r).Error("failed to upload object", "key", key, "error", err)
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(conf.ErrInternalServerError))
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
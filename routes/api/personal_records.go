@@ -0,0 +1,113 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	routeutils "github.com/hackclub/hackatime/routes/utils"
+	"github.com/hackclub/hackatime/services"
+)
+
+type recordVm struct {
+	Total time.Duration      `json:"total" swaggertype:"primitive,integer"`
+	Time  *models.CustomTime `json:"time" swaggertype:"primitive,number"` // start of the day, week or session, respectively
+}
+
+type hourRecordVm struct {
+	Hour  int           `json:"hour"` // 0 - 23, in the user's time zone
+	Total time.Duration `json:"total" swaggertype:"primitive,integer"`
+}
+
+type personalRecordsVm struct {
+	BestDay            *recordVm                `json:"best_day"`
+	BestWeek           *recordVm                `json:"best_week"`
+	LongestSession     *recordVm                `json:"longest_session"`
+	MostProductiveHour *hourRecordVm            `json:"most_productive_hour"`
+	DailyPercentiles   map[string]time.Duration `json:"daily_percentiles" swaggertype:"object"` // daily coding time on active days, accurate to 15 minutes
+	UpdatedUntil       models.CustomTime        `json:"updated_until" swaggertype:"primitive,number"`
+}
+
+type PersonalRecordsApiHandler struct {
+	config              *conf.Config
+	userSrvc            services.IUserService
+	personalRecordsSrvc services.IPersonalRecordsService
+}
+
+func NewPersonalRecordsApiHandler(userService services.IUserService, personalRecordsService services.IPersonalRecordsService) *PersonalRecordsApiHandler {
+	return &PersonalRecordsApiHandler{
+		config:              conf.Get(),
+		userSrvc:            userService,
+		personalRecordsSrvc: personalRecordsService,
+	}
+}
+
+func (h *PersonalRecordsApiHandler) RegisterRoutes(router chi.Router) {
+	router.Group(func(r chi.Router) {
+		r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+		r.Get("/users/{user}/records", h.Get)
+	})
+}
+
+// @Summary Retrieve a user's personal records
+// @Description Records are updated in the background and only include completed days. If they haven't been computed yet, 202 is returned and the request should be retried later.
+// @ID get-personal-records
+// @Tags records
+// @Produce json
+// @Param user path string true "Username (or current)"
+// @Security ApiKeyAuth
+// @Success 200 {object} personalRecordsVm
+// @Success 202
+// @Router /users/{user}/records [get]
+func (h *PersonalRecordsApiHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user, err := routeutils.CheckEffectiveUser(w, r, h.userSrvc, "current")
+	if err != nil {
+		return // response was already sent by util function
+	}
+
+	records, err := h.personalRecordsSrvc.GetByUser(user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve personal records", "userID", user.ID, "error", err)
+		return
+	}
+	if records == nil {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("personal records are being computed, please try again later"))
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, newPersonalRecordsVm(records))
+}
+
+func newPersonalRecordsVm(records *models.PersonalRecords) *personalRecordsVm {
+	vm := &personalRecordsVm{
+		DailyPercentiles: map[string]time.Duration{
+			"p50": records.DailyPercentile(50),
+			"p75": records.DailyPercentile(75),
+			"p90": records.DailyPercentile(90),
+			"p99": records.DailyPercentile(99),
+		},
+		UpdatedUntil: records.ProcessedUntil,
+	}
+
+	if records.BestDay != nil {
+		vm.BestDay = &recordVm{Total: records.BestDayTotal, Time: records.BestDay}
+	}
+	if records.BestWeek != nil {
+		vm.BestWeek = &recordVm{Total: records.BestWeekTotal, Time: records.BestWeek}
+	}
+	if records.LongestSessionStart != nil {
+		vm.LongestSession = &recordVm{Total: records.LongestSession, Time: records.LongestSessionStart}
+	}
+	if hour, total := records.MostProductiveHour(); hour >= 0 {
+		vm.MostProductiveHour = &hourRecordVm{Hour: hour, Total: total}
+	}
+
+	return vm
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/services"
+)
+
+type PluginReleasesHandler struct {
+	config            *conf.Config
+	pluginReleaseSrvc services.IPluginReleaseService
+}
+
+func NewPluginReleasesHandler(pluginReleaseService services.IPluginReleaseService) *PluginReleasesHandler {
+	return &PluginReleasesHandler{
+		config:            conf.Get(),
+		pluginReleaseSrvc: pluginReleaseService,
+	}
+}
+
+func (h *PluginReleasesHandler) RegisterRoutes(router chi.Router) {
+	if !h.config.PluginUpdates.Enabled {
+		return
+	}
+
+	// mimics github's release api, so that plugins only need their base url to be changed
+	r := chi.NewRouter()
+	r.Get("/latest", h.GetLatest)
+	r.Get("/latest/download/{asset}", h.GetLatestAsset)
+	r.Get("/download/{tag}/{asset}", h.GetAsset)
+
+	router.Mount("/plugins/releases", r)
+}
+
+// @Summary Retrieve the latest wakatime-cli release in github's format, for plugins to check for updates
+// @ID get-plugin-release-latest
+// @Tags misc
+// @Produce json
+// @Success 200 {object} models.PluginRelease
+// @Router /plugins/releases/latest [get]
+func (h *PluginReleasesHandler) GetLatest(w http.ResponseWriter, r *http.Request) {
+	release, err := h.pluginReleaseSrvc.GetLatest()
+	if err != nil {
+		h.respondError(w, r, err)
+		return
+	}
+	helpers.RespondJSON(w, r, http.StatusOK, release)
+}
+
+// @Summary Download an asset of the latest wakatime-cli release
+// @ID get-plugin-release-latest-asset
+// @Tags misc
+// @Param asset path string true "Asset name, e.g. wakatime-cli-linux-amd64.zip"
+// @Success 200
+// @Router /plugins/releases/latest/download/{asset} [get]
+func (h *PluginReleasesHandler) GetLatestAsset(w http.ResponseWriter, r *http.Request) {
+	release, err := h.pluginReleaseSrvc.GetLatest()
+	if err != nil {
+		h.respondError(w, r, err)
+		return
+	}
+	h.serveAsset(w, r, release.TagName, chi.URLParam(r, "asset"))
+}
+
+// @Summary Download an asset of a wakatime-cli release
+// @ID get-plugin-release-asset
+// @Tags misc
+// @Param tag path string true "Release tag, e.g. v1.102.1"
+// @Param asset path string true "Asset name, e.g. wakatime-cli-linux-amd64.zip"
+// @Success 200
+// @Router /plugins/releases/download/{tag}/{asset} [get]
+func (h *PluginReleasesHandler) GetAsset(w http.ResponseWriter, r *http.Request) {
+	h.serveAsset(w, r, chi.URLParam(r, "tag"), chi.URLParam(r, "asset"))
+}
+
+func (h *PluginReleasesHandler) serveAsset(w http.ResponseWriter, r *http.Request, tag, name string) {
+	f, err := h.pluginReleaseSrvc.OpenAsset(tag, name)
+	if err != nil {
+		h.respondError(w, r, err)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		h.respondError(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Cache-Control", "public, max-age=86400") // assets of a release never change
+	http.ServeContent(w, r, name, info.ModTime(), f)
+}
+
+func (h *PluginReleasesHandler) respondError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, services.ErrPluginReleaseNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return
+	}
+	conf.Log().Request(r).Error("failed to serve plugin release", "error", err)
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write([]byte(conf.ErrInternalServerError))
+}
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/services"
+)
+
+type ProfileApiHandler struct {
+	config      *conf.Config
+	userSrvc    services.IUserService
+	profileSrvc services.IProfileService
+}
+
+func NewProfileApiHandler(userService services.IUserService, profileService services.IProfileService) *ProfileApiHandler {
+	return &ProfileApiHandler{
+		config:      conf.Get(),
+		userSrvc:    userService,
+		profileSrvc: profileService,
+	}
+}
+
+func (h *ProfileApiHandler) RegisterRoutes(router chi.Router) {
+	router.Get("/@{username}", h.Get)
+}
+
+// @Summary Retrieve a user's public profile
+// @Description Only available if the user enabled their public profile. Widgets the user chose to hide are left out.
+// @ID get-profile
+// @Tags users
+// @Produce json
+// @Param username path string true "Username"
+// @Success 200 {object} models.Profile
+// @Router /@{username} [get]
+func (h *ProfileApiHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user, err := h.userSrvc.GetUserById(chi.URLParam(r, "username"))
+	if err != nil || !user.HasPublicProfile() {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return
+	}
+
+	profile, err := h.profileSrvc.GetByUser(user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to load profile", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, profile)
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestProfileApiHandler_Get(t *testing.T) {
+	config.Set(config.Empty())
+
+	public := &models.User{ID: "public", PublicProfile: true, ProfileLanguages: true, ProfileProjects: false, ProfileActivity: true}
+	private := &models.User{ID: "private", ProfileLanguages: true}
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserById", "public").Return(public, nil)
+	userServiceMock.On("GetUserById", "private").Return(private, nil)
+
+	summary := models.NewEmptySummary()
+	summary.Languages = models.SummaryItems{{Type: models.SummaryLanguage, Key: "go", Total: 3600}, {Type: models.SummaryLanguage, Key: "rust", Total: 1200}}
+	summary.Projects = models.SummaryItems{{Type: models.SummaryProject, Key: "secret", Total: 4800}}
+
+	summaryServiceMock := new(mocks.SummaryServiceMock)
+	summaryServiceMock.On("Aliased", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time"), public, mock.Anything, mock.Anything).Return(summary, nil)
+
+	router := chi.NewRouter()
+	NewProfileApiHandler(userServiceMock, services.NewProfileService(summaryServiceMock)).RegisterRoutes(router)
+
+	t.Run("when requesting a public profile", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/@public", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+
+		var profile models.Profile
+		assert.Nil(t, json.NewDecoder(rec.Body).Decode(&profile))
+		assert.Equal(t, "public", profile.Username)
+		assert.Len(t, profile.Languages, 2)
+		assert.Equal(t, "go", profile.Languages[0].Key)
+		assert.InDelta(t, 75.0, profile.Languages[0].Percent, 0.01)
+		assert.Empty(t, profile.Projects) // hidden by user
+		assert.NotEmpty(t, profile.ActivityChartUrl)
+	})
+
+	t.Run("when requesting a private profile", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/@private", nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type ProjectMetadataApiHandler struct {
+	config              *conf.Config
+	userSrvc            services.IUserService
+	projectMetadataSrvc services.IProjectMetadataService
+}
+
+func NewProjectMetadataApiHandler(userService services.IUserService, projectMetadataService services.IProjectMetadataService) *ProjectMetadataApiHandler {
+	return &ProjectMetadataApiHandler{
+		config:              conf.Get(),
+		userSrvc:            userService,
+		projectMetadataSrvc: projectMetadataService,
+	}
+}
+
+func (h *ProjectMetadataApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.GetAll)
+	// project names may contain slashes
+	r.Put("/*", h.Put)
+	r.Delete("/*", h.Delete)
+
+	router.Mount("/projects/metadata", r)
+}
+
+// @Summary Retrieve the authenticated user's project metadata
+// @ID get-project-metadata
+// @Tags projects
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.ProjectMetadata
+// @Router /projects/metadata [get]
+func (h *ProjectMetadataApiHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	metadata, err := h.projectMetadataSrvc.GetByUser(user.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve project metadata", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, metadata)
+}
+
+// @Summary Set color, icon and description of one of the authenticated user's projects
+// @ID put-project-metadata
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param project path string true "Project name"
+// @Param metadata body models.ProjectMetadata true "Project metadata, color must be a hex code like #2f855a"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.ProjectMetadata
+// @Router /projects/metadata/{project} [put]
+func (h *ProjectMetadataApiHandler) Put(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	var metadata models.ProjectMetadata
+	if err := json.NewDecoder(r.Body).Decode(&metadata); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+	metadata.ID = 0
+	metadata.UserID = user.ID
+	metadata.ProjectKey = chi.URLParam(r, "*")
+
+	if !metadata.IsValid() {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid project metadata"))
+		return
+	}
+
+	result, err := h.projectMetadataSrvc.Upsert(&metadata)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to save project metadata", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, result)
+}
+
+// @Summary Remove the metadata of one of the authenticated user's projects
+// @ID delete-project-metadata
+// @Tags projects
+// @Param project path string true "Project name"
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /projects/metadata/{project} [delete]
+func (h *ProjectMetadataApiHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	if err := h.projectMetadataSrvc.Delete(user.ID, chi.URLParam(r, "*")); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to delete project metadata", "userID", user.ID, "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
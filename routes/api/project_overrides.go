@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type ProjectOverrideApiHandler struct {
+	config              *conf.Config
+	userSrvc            services.IUserService
+	projectOverrideSrvc services.IProjectOverrideService
+}
+
+func NewProjectOverrideApiHandler(userService services.IUserService, projectOverrideService services.IProjectOverrideService) *ProjectOverrideApiHandler {
+	return &ProjectOverrideApiHandler{
+		config:              conf.Get(),
+		userSrvc:            userService,
+		projectOverrideSrvc: projectOverrideService,
+	}
+}
+
+func (h *ProjectOverrideApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.GetAll)
+	r.Post("/", h.Post)
+	r.Delete("/", h.Delete)
+
+	router.Mount("/projects/overrides", r)
+}
+
+// @Summary Retrieve the authenticated user's project overrides
+// @ID get-project-overrides
+// @Tags projects
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.ProjectOverride
+// @Router /projects/overrides [get]
+func (h *ProjectOverrideApiHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	overrides, err := h.projectOverrideSrvc.GetByUser(user.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve project overrides", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, overrides)
+}
+
+// @Summary Assign all future heartbeats of files below a directory to a fixed project
+// @ID post-project-override
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param override body models.ProjectOverride true "Absolute directory path and project name"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.ProjectOverride
+// @Router /projects/overrides [post]
+func (h *ProjectOverrideApiHandler) Post(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	var override models.ProjectOverride
+	if err := json.NewDecoder(r.Body).Decode(&override); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+	override.ID = 0
+	override.UserID = user.ID
+	override.Path = models.NormalizeProjectOverridePath(override.Path)
+
+	if !override.IsValid() {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid project override"))
+		return
+	}
+
+	result, err := h.projectOverrideSrvc.Upsert(&override)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to save project override", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, result)
+}
+
+// @Summary Remove one of the authenticated user's project overrides
+// @ID delete-project-override
+// @Tags projects
+// @Param path query string true "Directory path of the override"
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /projects/overrides [delete]
+func (h *ProjectOverrideApiHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	if err := h.projectOverrideSrvc.Delete(user.ID, path); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to delete project override", "userID", user.ID, "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type projectRenameRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type projectRenameJobVm struct {
+	*models.ProjectRenameJob
+	Progress float64 `json:"progress"`
+}
+
+type ProjectRenameApiHandler struct {
+	config            *conf.Config
+	userSrvc          services.IUserService
+	projectRenameSrvc services.IProjectRenameService
+}
+
+func NewProjectRenameApiHandler(userService services.IUserService, projectRenameService services.IProjectRenameService) *ProjectRenameApiHandler {
+	return &ProjectRenameApiHandler{
+		config:            conf.Get(),
+		userSrvc:          userService,
+		projectRenameSrvc: projectRenameService,
+	}
+}
+
+func (h *ProjectRenameApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Post("/", h.Post)
+	r.Get("/{id}", h.Get)
+
+	router.Mount("/projects/rename", r)
+}
+
+// @Summary Rename one of the authenticated user's projects across all historical heartbeats, time entries and summaries
+// @Description Runs in the background, use the returned job id to track its progress. The new name may also be an existing project, in which case both are merged.
+// @ID post-project-rename
+// @Tags projects
+// @Accept json
+// @Produce json
+// @Param rename body projectRenameRequest true "Old and new project name"
+// @Security ApiKeyAuth
+// @Success 202 {object} projectRenameJobVm
+// @Router /projects/rename [post]
+func (h *ProjectRenameApiHandler) Post(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	var req projectRenameRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	job, err := h.projectRenameSrvc.Rename(user, req.From, req.To)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrProjectRenameInvalid):
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+		case errors.Is(err, services.ErrProjectRenameNotFound):
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(err.Error()))
+		case errors.Is(err, services.ErrProjectRenameRunning):
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(err.Error()))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(conf.ErrInternalServerError))
+			conf.Log().Request(r).Error("failed to start project rename", "userID", user.ID, "error", err)
+		}
+		return
+	}
+
+	conf.Log().Request(r).Info("started renaming project", "userID", user.ID, "from", req.From, "to", req.To)
+	helpers.RespondJSON(w, r, http.StatusAccepted, newProjectRenameJobVm(job))
+}
+
+// @Summary Retrieve the progress of a project rename job
+// @ID get-project-rename
+// @Tags projects
+// @Produce json
+// @Param id path string true "Job ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} projectRenameJobVm
+// @Router /projects/rename/{id} [get]
+func (h *ProjectRenameApiHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	job, ok := h.projectRenameSrvc.GetJob(chi.URLParam(r, "id"))
+	if !ok || (job.UserID != user.ID && !user.IsAdmin) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, newProjectRenameJobVm(job))
+}
+
+func newProjectRenameJobVm(job *models.ProjectRenameJob) *projectRenameJobVm {
+	return &projectRenameJobVm{
+		ProjectRenameJob: job.Snapshot(),
+		Progress:         job.Progress(),
+	}
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type QueryConsoleApiHandler struct {
+	config           *conf.Config
+	userSrvc         services.IUserService
+	queryConsoleSrvc services.IQueryConsoleService
+}
+
+func NewQueryConsoleApiHandler(userService services.IUserService, queryConsoleService services.IQueryConsoleService) *QueryConsoleApiHandler {
+	return &QueryConsoleApiHandler{
+		config:           conf.Get(),
+		userSrvc:         userService,
+		queryConsoleSrvc: queryConsoleService,
+	}
+}
+
+func (h *QueryConsoleApiHandler) RegisterRoutes(router chi.Router) {
+	if !h.config.QueryConsole.Enabled {
+		return
+	}
+
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Post("/", h.Post)
+
+	router.Mount("/admin/query", r)
+}
+
+// @Summary Run a read-only query against the database for debugging (admin only)
+// @Description Only single select, with, explain and show statements are allowed. They run inside a read-only transaction, on a restricted connection (required for mysql and postgres), and are subject to the row and time limits of the query console. On sqlite, statements may neither select all columns nor reference credential columns.
+// @ID post-admin-query
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param query body models.QueryConsoleRequest true "Statement to run"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.QueryConsoleResult
+// @Failure 403 {object} models.ApiError
+// @Router /admin/query [post]
+func (h *QueryConsoleApiHandler) Post(w http.ResponseWriter, r *http.Request) {
+	principal := middlewares.GetPrincipal(r)
+	if principal == nil || !principal.IsAdmin {
+		helpers.RespondError(w, r, http.StatusForbidden, models.ApiErrorForbidden, conf.ErrForbidden)
+		return
+	}
+
+	var req models.QueryConsoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	// every statement is logged for auditing, regardless of whether it succeeds
+	conf.Log().Request(r).Info("running query console statement", "admin", principal.ID, "query", req.Query)
+
+	result, err := h.queryConsoleSrvc.Run(req.Query)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrQueryConsoleStatement), errors.Is(err, services.ErrQueryConsoleSensitive), errors.Is(err, services.ErrQueryConsoleFailed):
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error())) // includes the database's error message, to help fixing the query
+		case errors.Is(err, services.ErrQueryConsoleTimeout):
+			w.WriteHeader(http.StatusRequestTimeout)
+			w.Write([]byte(err.Error()))
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(conf.ErrInternalServerError))
+			conf.Log().Request(r).Error("failed to run query console statement", "error", err)
+		}
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, result)
+}
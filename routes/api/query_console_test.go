@@ -0,0 +1,66 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryConsoleApiHandler_Post(t *testing.T) {
+	cfg := config.Empty()
+	cfg.QueryConsole.Enabled = true
+	cfg.QueryConsole.MaxRows = 10
+	cfg.QueryConsole.TimeoutSec = 1
+	config.Set(cfg)
+
+	admin := &models.User{ID: "admin", ApiKey: "admin-api-key", IsAdmin: true}
+	user := &models.User{ID: "user", ApiKey: "user-api-key"}
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserByKey", admin.ApiKey).Return(admin, nil)
+	userServiceMock.On("GetUserByKey", user.ApiKey).Return(user, nil)
+
+	router := chi.NewRouter()
+	router.Use(middlewares.NewPrincipalMiddleware())
+	NewQueryConsoleApiHandler(userServiceMock, services.NewQueryConsoleService(nil)).RegisterRoutes(router)
+
+	post := func(u *models.User, query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/admin/query", strings.NewReader(`{"query": "`+query+`"}`))
+		req.Header.Add("Authorization", "Bearer "+base64.StdEncoding.EncodeToString([]byte(u.ApiKey)))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("when not an admin", func(t *testing.T) {
+		rec := post(user, "SELECT 1")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+
+		var apiErr models.ApiError
+		assert.Nil(t, json.NewDecoder(rec.Body).Decode(&apiErr))
+		assert.Equal(t, models.ApiErrorForbidden, apiErr.Code)
+	})
+
+	t.Run("when reading credentials without a restricted role", func(t *testing.T) {
+		rec := post(admin, "SELECT password FROM users")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, services.ErrQueryConsoleSensitive.Error(), rec.Body.String())
+	})
+
+	t.Run("when modifying data", func(t *testing.T) {
+		rec := post(admin, "WITH t AS (SELECT 1) DELETE FROM users")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, services.ErrQueryConsoleStatement.Error(), rec.Body.String())
+	})
+}
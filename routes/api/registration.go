@@ -0,0 +1,216 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type inviteCodeRequest struct {
+	MaxUses       int `json:"max_uses"`        // 0 for unlimited
+	ValidForHours int `json:"valid_for_hours"` // 0 for never expiring
+}
+
+type RegistrationApiHandler struct {
+	config           *conf.Config
+	userSrvc         services.IUserService
+	registrationSrvc services.IRegistrationService
+}
+
+func NewRegistrationApiHandler(userService services.IUserService, registrationService services.IRegistrationService) *RegistrationApiHandler {
+	return &RegistrationApiHandler{
+		config:           conf.Get(),
+		userSrvc:         userService,
+		registrationSrvc: registrationService,
+	}
+}
+
+func (h *RegistrationApiHandler) RegisterRoutes(router chi.Router) {
+	policyRouter := chi.NewRouter()
+	policyRouter.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	policyRouter.Get("/", h.GetPolicy)
+	policyRouter.Put("/", h.PutPolicy)
+	policyRouter.Delete("/", h.DeletePolicy)
+
+	inviteCodesRouter := chi.NewRouter()
+	inviteCodesRouter.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	inviteCodesRouter.Get("/", h.GetInviteCodes)
+	inviteCodesRouter.Post("/", h.PostInviteCode)
+	inviteCodesRouter.Delete("/{code}", h.DeleteInviteCode)
+
+	router.Mount("/admin/registration", policyRouter)
+	router.Mount("/admin/invite-codes", inviteCodesRouter)
+}
+
+// @Summary Retrieve who may currently sign up on this instance (admin only)
+// @ID get-registration-policy
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.RegistrationPolicy
+// @Router /admin/registration [get]
+func (h *RegistrationApiHandler) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+	helpers.RespondJSON(w, r, http.StatusOK, h.registrationSrvc.GetPolicy())
+}
+
+// @Summary Change who may sign up on this instance at runtime, overriding the configured registration mode (admin only)
+// @Description Mode is one of open, invite, domain or closed. Domain mode requires at least one domain, e.g. school.edu, which includes its subdomains.
+// @ID put-registration-policy
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param policy body models.RegistrationPolicy true "e.g. {\"mode\": \"domain\", \"domains\": [\"school.edu\"]}"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.RegistrationPolicy
+// @Router /admin/registration [put]
+func (h *RegistrationApiHandler) PutPolicy(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	var policy models.RegistrationPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	if err := h.registrationSrvc.SetPolicy(&policy); err != nil {
+		if errors.Is(err, services.ErrRegistrationPolicy) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to save registration policy", "error", err)
+		return
+	}
+
+	conf.Log().Request(r).Info("changed registration policy", "mode", policy.Mode, "admin", middlewares.GetPrincipal(r).ID)
+	helpers.RespondJSON(w, r, http.StatusOK, h.registrationSrvc.GetPolicy())
+}
+
+// @Summary Discard the registration policy set at runtime, so that the configured one applies again (admin only)
+// @ID delete-registration-policy
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.RegistrationPolicy
+// @Router /admin/registration [delete]
+func (h *RegistrationApiHandler) DeletePolicy(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	if err := h.registrationSrvc.ResetPolicy(); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to reset registration policy", "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, h.registrationSrvc.GetPolicy())
+}
+
+// @Summary List all invite codes, including used up and expired ones (admin only)
+// @ID get-invite-codes
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.InviteCode
+// @Router /admin/invite-codes [get]
+func (h *RegistrationApiHandler) GetInviteCodes(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	codes, err := h.registrationSrvc.GetInviteCodes()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve invite codes", "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, codes)
+}
+
+// @Summary Create an invite code, which can be used a limited number of times and expires after a while (admin only)
+// @ID post-invite-code
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param limits body inviteCodeRequest true "e.g. {\"max_uses\": 30, \"valid_for_hours\": 168}, zero for no limit"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.InviteCode
+// @Router /admin/invite-codes [post]
+func (h *RegistrationApiHandler) PostInviteCode(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	var req inviteCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	code, err := h.registrationSrvc.CreateInviteCode(middlewares.GetPrincipal(r).ID, req.MaxUses, time.Duration(req.ValidForHours)*time.Hour)
+	if errors.Is(err, services.ErrInviteCodeParameters) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to create invite code", "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusCreated, code)
+}
+
+// @Summary Revoke an invite code (admin only)
+// @ID delete-invite-code
+// @Tags admin
+// @Param code path string true "Invite code"
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /admin/invite-codes/{code} [delete]
+func (h *RegistrationApiHandler) DeleteInviteCode(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	if err := h.registrationSrvc.DeleteInviteCode(chi.URLParam(r, "code")); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to delete invite code", "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *RegistrationApiHandler) checkAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if principal := middlewares.GetPrincipal(r); principal == nil || !principal.IsAdmin {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(conf.ErrUnauthorized))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type RelayRulesApiHandler struct {
+	config        *conf.Config
+	userSrvc      services.IUserService
+	relayRuleSrvc services.IRelayRuleService
+}
+
+func NewRelayRulesApiHandler(userService services.IUserService, relayRuleService services.IRelayRuleService) *RelayRulesApiHandler {
+	return &RelayRulesApiHandler{
+		config:        conf.Get(),
+		userSrvc:      userService,
+		relayRuleSrvc: relayRuleService,
+	}
+}
+
+func (h *RelayRulesApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.GetAll)
+	r.Post("/", h.Post)
+	r.Delete("/{id}", h.Delete)
+
+	router.Mount("/relay/rules", r)
+}
+
+// @Summary Retrieve the rules applied to the authenticated user's heartbeats before relaying them to wakatime
+// @ID get-relay-rules
+// @Tags relay
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.RelayRule
+// @Router /relay/rules [get]
+func (h *RelayRulesApiHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	rules, err := h.relayRuleSrvc.GetByUser(user.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve relay rules", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, rules)
+}
+
+// @Summary Add a rule to transform heartbeats before relaying them to wakatime, without affecting the ones stored on this instance
+// @Description Type is either hide_entity, which replaces file names by HIDDEN.<ext>, or rename_project, which replaces the project name by the given value. Rules apply to the given project only, or to all projects if left blank.
+// @ID post-relay-rule
+// @Tags relay
+// @Accept json
+// @Produce json
+// @Param rule body models.RelayRule true "e.g. {\"type\": \"rename_project\", \"project\": \"secret-client\", \"value\": \"freelance\"}"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.RelayRule
+// @Router /relay/rules [post]
+func (h *RelayRulesApiHandler) Post(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	var rule models.RelayRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+	rule.ID = 0
+	rule.UserID = user.ID
+
+	if !rule.IsValid() {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid relay rule"))
+		return
+	}
+
+	result, err := h.relayRuleSrvc.Create(&rule)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to save relay rule", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusCreated, result)
+}
+
+// @Summary Remove one of the authenticated user's relay rules
+// @ID delete-relay-rule
+// @Tags relay
+// @Param id path int true "Rule id"
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /relay/rules/{id} [delete]
+func (h *RelayRulesApiHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	if err := h.relayRuleSrvc.Delete(user.ID, uint(id)); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to delete relay rule", "userID", user.ID, "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
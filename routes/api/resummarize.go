@@ -0,0 +1,127 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type resummarizeJobVm struct {
+	*models.ResummarizeJob
+	Progress float64 `json:"progress"`
+}
+
+type ResummarizeApiHandler struct {
+	config          *conf.Config
+	userSrvc        services.IUserService
+	aggregationSrvc services.IAggregationService
+}
+
+func NewResummarizeApiHandler(userService services.IUserService, aggregationService services.IAggregationService) *ResummarizeApiHandler {
+	return &ResummarizeApiHandler{
+		config:          conf.Get(),
+		userSrvc:        userService,
+		aggregationSrvc: aggregationService,
+	}
+}
+
+func (h *ResummarizeApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Post("/", h.Post)
+	r.Get("/{id}", h.Get)
+
+	router.Mount("/admin/resummarize", r)
+}
+
+// @Summary Re-generate a user's summaries within a date range, e.g. after language mappings or aliases were changed (admin only)
+// @ID post-resummarize
+// @Tags admin
+// @Produce json
+// @Param user query string true "Username"
+// @Param from query string true "Start date (e.g. '2021-02-07')"
+// @Param to query string false "End date (exclusive, e.g. '2021-02-08'), defaults to today"
+// @Security ApiKeyAuth
+// @Success 202 {object} resummarizeJobVm
+// @Router /admin/resummarize [post]
+func (h *ResummarizeApiHandler) Post(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	user, err := h.userSrvc.GetUserByRef(r.URL.Query().Get("user"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return
+	}
+
+	from, err := helpers.ParseDateTimeTZ(r.URL.Query().Get("from"), time.Local)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid 'from' parameter"))
+		return
+	}
+	to := time.Now()
+	if toParam := r.URL.Query().Get("to"); toParam != "" {
+		if to, err = helpers.ParseDateTimeTZ(toParam, time.Local); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("invalid 'to' parameter"))
+			return
+		}
+	}
+
+	job, err := h.aggregationSrvc.Resummarize(user, from, to)
+	if err != nil {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusAccepted, newResummarizeJobVm(job))
+}
+
+// @Summary Retrieve the progress of a summary re-generation job (admin only)
+// @ID get-resummarize
+// @Tags admin
+// @Produce json
+// @Param id path string true "Job ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} resummarizeJobVm
+// @Router /admin/resummarize/{id} [get]
+func (h *ResummarizeApiHandler) Get(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	job, ok := h.aggregationSrvc.GetResummarizeJob(chi.URLParam(r, "id"))
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, newResummarizeJobVm(job))
+}
+
+func (h *ResummarizeApiHandler) checkAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if principal := middlewares.GetPrincipal(r); principal == nil || !principal.IsAdmin {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(conf.ErrUnauthorized))
+		return false
+	}
+	return true
+}
+
+func newResummarizeJobVm(job *models.ResummarizeJob) *resummarizeJobVm {
+	return &resummarizeJobVm{
+		ResummarizeJob: job.Snapshot(),
+		Progress:       job.Progress(),
+	}
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newResummarizeTestRouter(aggregationService *mocks.AggregationServiceMock) (chi.Router, *models.User, *models.User) {
+	admin := &models.User{ID: "admin", ApiKey: "admin-api-key", IsAdmin: true}
+	user := &models.User{ID: "user", ApiKey: "user-api-key"}
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserByKey", admin.ApiKey).Return(admin, nil)
+	userServiceMock.On("GetUserByKey", user.ApiKey).Return(user, nil)
+	userServiceMock.On("GetUserByRef", user.ID).Return(user, nil)
+	userServiceMock.On("GetUserByRef", mock.Anything).Return((*models.User)(nil), errors.New("not found"))
+
+	router := chi.NewRouter()
+	router.Use(middlewares.NewPrincipalMiddleware())
+	NewResummarizeApiHandler(userServiceMock, aggregationService).RegisterRoutes(router)
+	return router, admin, user
+}
+
+func resummarizeRequest(router chi.Router, method, target string, user *models.User) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	req.Header.Add("Authorization", "Bearer "+base64.StdEncoding.EncodeToString([]byte(user.ApiKey)))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestResummarizeApiHandler_AdminOnly(t *testing.T) {
+	config.Set(config.Empty())
+
+	aggregationService := new(mocks.AggregationServiceMock)
+	router, _, user := newResummarizeTestRouter(aggregationService)
+
+	rec := resummarizeRequest(router, http.MethodPost, "/admin/resummarize?user=user&from=2026-10-01", user)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	rec = resummarizeRequest(router, http.MethodGet, "/admin/resummarize/some-job", user)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	aggregationService.AssertNotCalled(t, "Resummarize", mock.Anything, mock.Anything, mock.Anything)
+	aggregationService.AssertNotCalled(t, "GetResummarizeJob", mock.Anything)
+}
+
+func TestResummarizeApiHandler_Post(t *testing.T) {
+	config.Set(config.Empty())
+
+	from := time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local)
+	to := time.Date(2026, 10, 8, 0, 0, 0, 0, time.Local)
+	job := &models.ResummarizeJob{ID: "some-job", UserID: "user", From: from, To: to, Status: models.ResummarizeStatusRunning, DaysTotal: 7, DaysDone: 1, DaysFailed: 1}
+
+	aggregationService := new(mocks.AggregationServiceMock)
+	aggregationService.On("Resummarize", mock.Anything, from, to).Return(job, nil).Once()
+	aggregationService.On("Resummarize", mock.Anything, from, mock.Anything).Return(nil, errors.New("aggregation already in progress")).Once()
+	router, admin, _ := newResummarizeTestRouter(aggregationService)
+
+	rec := resummarizeRequest(router, http.MethodPost, "/admin/resummarize?user=user&from=2026-10-01&to=2026-10-08", admin)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	var vm resummarizeJobVm
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&vm))
+	assert.Equal(t, "some-job", vm.ID)
+	assert.Equal(t, 7, vm.DaysTotal)
+	assert.InDelta(t, 2.0/7.0, vm.Progress, 0.001)
+	assert.Equal(t, "user", aggregationService.Calls[0].Arguments.Get(0).(*models.User).ID)
+
+	// to defaults to now
+	rec = resummarizeRequest(router, http.MethodPost, "/admin/resummarize?user=user&from=2026-10-01", admin)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.WithinDuration(t, time.Now(), aggregationService.Calls[1].Arguments.Get(2).(time.Time), time.Minute)
+
+	rec = resummarizeRequest(router, http.MethodPost, "/admin/resummarize?user=unknown&from=2026-10-01", admin)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = resummarizeRequest(router, http.MethodPost, "/admin/resummarize?user=user&from=yesterday", admin)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = resummarizeRequest(router, http.MethodPost, "/admin/resummarize?user=user&from=2026-10-01&to=tomorrow", admin)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	aggregationService.AssertNumberOfCalls(t, "Resummarize", 2)
+}
+
+func TestResummarizeApiHandler_Get(t *testing.T) {
+	config.Set(config.Empty())
+
+	finishedAt := time.Now()
+	job := &models.ResummarizeJob{ID: "some-job", UserID: "user", Status: models.ResummarizeStatusFinished, DaysTotal: 4, DaysDone: 4, FinishedAt: &finishedAt}
+
+	aggregationService := new(mocks.AggregationServiceMock)
+	aggregationService.On("GetResummarizeJob", "some-job").Return(job, true)
+	aggregationService.On("GetResummarizeJob", mock.Anything).Return(nil, false)
+	router, admin, _ := newResummarizeTestRouter(aggregationService)
+
+	rec := resummarizeRequest(router, http.MethodGet, "/admin/resummarize/some-job", admin)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var vm resummarizeJobVm
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&vm))
+	assert.Equal(t, models.ResummarizeStatusFinished, vm.Status)
+	assert.Equal(t, 1.0, vm.Progress)
+	assert.NotNil(t, vm.FinishedAt)
+
+	rec = resummarizeRequest(router, http.MethodGet, "/admin/resummarize/unknown", admin)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,428 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gofrs/uuid/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+const scimContentType = "application/scim+json"
+
+var (
+	scimFilterPattern       = regexp.MustCompile(`(?i)^(userName|emails\.value|emails|displayName) eq "(.*)"$`)
+	scimMemberFilterPattern = regexp.MustCompile(`(?i)^members\[value eq "(.*)"\]$`)
+)
+
+// ScimHandler implements a subset of scim 2.0 for user provisioning from identity providers like okta or azure ad
+// hackatime doesn't have groups, so the only group exposed is a virtual "admins" group, whose members are granted admin rights
+type ScimHandler struct {
+	config   *conf.Config
+	userSrvc services.IUserService
+}
+
+func NewScimHandler(userService services.IUserService) *ScimHandler {
+	return &ScimHandler{
+		config:   conf.Get(),
+		userSrvc: userService,
+	}
+}
+
+func (h *ScimHandler) RegisterRoutes(router chi.Router) {
+	if h.config.Security.ScimToken == "" {
+		return
+	}
+
+	r := chi.NewRouter()
+	r.Use(h.authenticate)
+	r.Get("/ServiceProviderConfig", h.GetServiceProviderConfig)
+	r.Get("/Users", h.GetUsers)
+	r.Post("/Users", h.PostUser)
+	r.Get("/Users/{id}", h.GetUser)
+	r.Put("/Users/{id}", h.PutUser)
+	r.Patch("/Users/{id}", h.PatchUser)
+	r.Delete("/Users/{id}", h.DeleteUser)
+	r.Get("/Groups", h.GetGroups)
+	r.Get("/Groups/{id}", h.GetGroup)
+	r.Patch("/Groups/{id}", h.PatchGroup)
+
+	router.Mount("/scim/v2", r)
+}
+
+func (h *ScimHandler) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(h.config.Security.ScimToken)) != 1 {
+			h.respondError(w, http.StatusUnauthorized, "", "invalid token")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *ScimHandler) GetServiceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, http.StatusOK, map[string]interface{}{
+		"schemas":        []string{models.ScimSchemaSpConfig},
+		"patch":          map[string]bool{"supported": true},
+		"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]interface{}{"supported": true, "maxResults": 200},
+		"changePassword": map[string]bool{"supported": false},
+		"sort":           map[string]bool{"supported": false},
+		"etag":           map[string]bool{"supported": false},
+		"authenticationSchemes": []map[string]string{
+			{"type": "oauthbearertoken", "name": "OAuth Bearer Token", "description": "Authentication using the scim_token configured for this instance"},
+		},
+	})
+}
+
+func (h *ScimHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.userSrvc.GetAll()
+	if err != nil {
+		conf.Log().Request(r).Error("failed to fetch users for scim", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "", conf.ErrInternalServerError)
+		return
+	}
+
+	if filter := r.URL.Query().Get("filter"); filter != "" {
+		match := scimFilterPattern.FindStringSubmatch(filter)
+		if match == nil {
+			h.respondError(w, http.StatusBadRequest, "invalidFilter", "unsupported filter")
+			return
+		}
+		filtered := make([]*models.User, 0)
+		for _, u := range users {
+			switch strings.ToLower(match[1]) {
+			case "username":
+				if strings.EqualFold(u.ID, match[2]) {
+					filtered = append(filtered, u)
+				}
+			case "emails", "emails.value":
+				if u.Email != "" && strings.EqualFold(u.Email, match[2]) {
+					filtered = append(filtered, u)
+				}
+			case "displayname":
+				if u.Name == match[2] {
+					filtered = append(filtered, u)
+				}
+			}
+		}
+		users = filtered
+	}
+
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	startIndex, count := 1, 100
+	if v, err := strconv.Atoi(r.URL.Query().Get("startIndex")); err == nil && v > 0 {
+		startIndex = v
+	}
+	if v, err := strconv.Atoi(r.URL.Query().Get("count")); err == nil && v >= 0 {
+		count = min(v, 200)
+	}
+
+	resources := make([]*models.ScimUser, 0, count)
+	for i := startIndex - 1; i < len(users) && len(resources) < count; i++ {
+		resources = append(resources, models.NewScimUserFrom(users[i], h.baseUrl()))
+	}
+
+	h.respond(w, http.StatusOK, &models.ScimListResponse{
+		Schemas:      []string{models.ScimSchemaListResponse},
+		TotalResults: len(users),
+		StartIndex:   startIndex,
+		ItemsPerPage: len(resources),
+		Resources:    resources,
+	})
+}
+
+func (h *ScimHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.userSrvc.GetUserById(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, http.StatusNotFound, "", "user not found")
+		return
+	}
+	h.respond(w, http.StatusOK, models.NewScimUserFrom(user, h.baseUrl()))
+}
+
+func (h *ScimHandler) PostUser(w http.ResponseWriter, r *http.Request) {
+	var payload models.ScimUser
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalidSyntax", "invalid user resource")
+		return
+	}
+
+	if !models.ValidateUsername(payload.UserName) {
+		h.respondError(w, http.StatusBadRequest, "invalidValue", "invalid user name")
+		return
+	}
+	if email := payload.PrimaryEmail(); email != "" && !models.ValidateEmail(email) {
+		h.respondError(w, http.StatusBadRequest, "invalidValue", "invalid e-mail address")
+		return
+	}
+
+	// users log in via password reset or trusted header auth, the initial password is never revealed
+	signup := &models.Signup{
+		Username: payload.UserName,
+		Email:    payload.PrimaryEmail(),
+		Password: uuid.Must(uuid.NewV4()).String(),
+	}
+
+	user, created, err := h.userSrvc.CreateOrGet(signup, false)
+	if err != nil {
+		conf.Log().Request(r).Error("failed to create user via scim", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "", conf.ErrInternalServerError)
+		return
+	}
+	if !created {
+		h.respondError(w, http.StatusConflict, "uniqueness", "user already exists")
+		return
+	}
+
+	payload.ApplyTo(user)
+	user.EmailVerified = user.Email != "" // addresses managed by the identity provider are trusted
+	if _, err := h.userSrvc.Update(user); err != nil {
+		conf.Log().Request(r).Error("failed to update user via scim", "userID", user.ID, "error", err)
+		h.respondError(w, http.StatusInternalServerError, "", conf.ErrInternalServerError)
+		return
+	}
+
+	conf.Log().Request(r).Info("provisioned user via scim", "userID", user.ID)
+	h.respond(w, http.StatusCreated, models.NewScimUserFrom(user, h.baseUrl()))
+}
+
+func (h *ScimHandler) PutUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.userSrvc.GetUserById(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, http.StatusNotFound, "", "user not found")
+		return
+	}
+
+	var payload models.ScimUser
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalidSyntax", "invalid user resource")
+		return
+	}
+	if payload.UserName != "" && payload.UserName != user.ID {
+		h.respondError(w, http.StatusBadRequest, "mutability", "user name can't be changed")
+		return
+	}
+
+	payload.ApplyTo(user)
+	h.updateUser(w, r, user)
+}
+
+func (h *ScimHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.userSrvc.GetUserById(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, http.StatusNotFound, "", "user not found")
+		return
+	}
+
+	var payload models.ScimPatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalidSyntax", "invalid patch request")
+		return
+	}
+	if err := payload.ApplyTo(user); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalidValue", err.Error())
+		return
+	}
+
+	h.updateUser(w, r, user)
+}
+
+func (h *ScimHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.userSrvc.GetUserById(chi.URLParam(r, "id"))
+	if err != nil {
+		h.respondError(w, http.StatusNotFound, "", "user not found")
+		return
+	}
+
+	if err := h.userSrvc.Delete(user); err != nil {
+		conf.Log().Request(r).Error("failed to delete user via scim", "userID", user.ID, "error", err)
+		h.respondError(w, http.StatusInternalServerError, "", conf.ErrInternalServerError)
+		return
+	}
+
+	conf.Log().Request(r).Info("deleted user via scim", "userID", user.ID)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ScimHandler) GetGroups(w http.ResponseWriter, r *http.Request) {
+	group, err := h.loadAdminsGroup()
+	if err != nil {
+		conf.Log().Request(r).Error("failed to fetch users for scim", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "", conf.ErrInternalServerError)
+		return
+	}
+
+	groups := []*models.ScimGroup{group}
+	if filter := r.URL.Query().Get("filter"); filter != "" && !strings.EqualFold(filter, fmt.Sprintf(`displayName eq "%s"`, models.ScimAdminsGroupId)) {
+		groups = []*models.ScimGroup{}
+	}
+
+	h.respond(w, http.StatusOK, &models.ScimListResponse{
+		Schemas:      []string{models.ScimSchemaListResponse},
+		TotalResults: len(groups),
+		StartIndex:   1,
+		ItemsPerPage: len(groups),
+		Resources:    groups,
+	})
+}
+
+func (h *ScimHandler) GetGroup(w http.ResponseWriter, r *http.Request) {
+	if chi.URLParam(r, "id") != models.ScimAdminsGroupId {
+		h.respondError(w, http.StatusNotFound, "", "group not found")
+		return
+	}
+
+	group, err := h.loadAdminsGroup()
+	if err != nil {
+		conf.Log().Request(r).Error("failed to fetch users for scim", "error", err)
+		h.respondError(w, http.StatusInternalServerError, "", conf.ErrInternalServerError)
+		return
+	}
+	h.respond(w, http.StatusOK, group)
+}
+
+func (h *ScimHandler) PatchGroup(w http.ResponseWriter, r *http.Request) {
+	if chi.URLParam(r, "id") != models.ScimAdminsGroupId {
+		h.respondError(w, http.StatusNotFound, "", "group not found")
+		return
+	}
+
+	var payload models.ScimPatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		h.respondError(w, http.StatusBadRequest, "invalidSyntax", "invalid patch request")
+		return
+	}
+
+	for _, op := range payload.Operations {
+		memberIds := scimMemberIds(op.Value)
+		if match := scimMemberFilterPattern.FindStringSubmatch(op.Path); match != nil {
+			memberIds = append(memberIds, match[1])
+		}
+
+		switch strings.ToLower(op.Op) {
+		case "add":
+			h.setAdmin(r, memberIds, true)
+		case "remove":
+			h.setAdmin(r, memberIds, false)
+		case "replace":
+			group, err := h.loadAdminsGroup()
+			if err != nil {
+				h.respondError(w, http.StatusInternalServerError, "", conf.ErrInternalServerError)
+				return
+			}
+			currentIds := make([]string, len(group.Members))
+			for i, m := range group.Members {
+				currentIds[i] = m.Value
+			}
+			h.setAdmin(r, currentIds, false)
+			h.setAdmin(r, memberIds, true)
+		default:
+			h.respondError(w, http.StatusBadRequest, "invalidValue", fmt.Sprintf("unsupported patch operation '%s'", op.Op))
+			return
+		}
+	}
+
+	h.GetGroup(w, r)
+}
+
+func (h *ScimHandler) updateUser(w http.ResponseWriter, r *http.Request, user *models.User) {
+	if user.Email != "" && !models.ValidateEmail(user.Email) {
+		h.respondError(w, http.StatusBadRequest, "invalidValue", "invalid e-mail address")
+		return
+	}
+	user.EmailVerified = user.Email != ""
+
+	if _, err := h.userSrvc.Update(user); err != nil {
+		conf.Log().Request(r).Error("failed to update user via scim", "userID", user.ID, "error", err)
+		h.respondError(w, http.StatusInternalServerError, "", conf.ErrInternalServerError)
+		return
+	}
+
+	h.respond(w, http.StatusOK, models.NewScimUserFrom(user, h.baseUrl()))
+}
+
+func (h *ScimHandler) setAdmin(r *http.Request, userIds []string, isAdmin bool) {
+	for _, id := range userIds {
+		user, err := h.userSrvc.GetUserById(id)
+		if err != nil || user.IsAdmin == isAdmin {
+			continue
+		}
+		user.IsAdmin = isAdmin
+		if _, err := h.userSrvc.Update(user); err != nil {
+			conf.Log().Request(r).Error("failed to update admin status via scim", "userID", user.ID, "error", err)
+		}
+	}
+}
+
+func (h *ScimHandler) loadAdminsGroup() (*models.ScimGroup, error) {
+	users, err := h.userSrvc.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]*models.ScimMember, 0)
+	for _, u := range users {
+		if u.IsAdmin {
+			members = append(members, &models.ScimMember{Value: u.ID, Display: u.ID})
+		}
+	}
+
+	return &models.ScimGroup{
+		Schemas:     []string{models.ScimSchemaGroup},
+		Id:          models.ScimAdminsGroupId,
+		DisplayName: models.ScimAdminsGroupId,
+		Members:     members,
+		Meta: &models.ScimMeta{
+			ResourceType: "Group",
+			Location:     fmt.Sprintf("%s/Groups/%s", h.baseUrl(), models.ScimAdminsGroupId),
+		},
+	}, nil
+}
+
+func (h *ScimHandler) baseUrl() string {
+	return fmt.Sprintf("%s/api/scim/v2", h.config.Server.GetPublicUrl())
+}
+
+func (h *ScimHandler) respond(w http.ResponseWriter, status int, object interface{}) {
+	w.Header().Set("Content-Type", scimContentType)
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(object); err != nil {
+		conf.Log().Error("error while writing json response", "error", err)
+	}
+}
+
+func (h *ScimHandler) respondError(w http.ResponseWriter, status int, scimType, detail string) {
+	h.respond(w, status, models.NewScimError(status, scimType, detail))
+}
+
+// scimMemberIds extracts user ids from a group patch value, i.e. [{"value": "<id>"}, ...]
+func scimMemberIds(value interface{}) []string {
+	ids := make([]string, 0)
+	if m, ok := value.(map[string]interface{}); ok {
+		value = m["members"]
+	}
+	if list, ok := value.([]interface{}); ok {
+		for _, item := range list {
+			if m, ok := item.(map[string]interface{}); ok {
+				if id, ok := m["value"].(string); ok {
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	return ids
+}
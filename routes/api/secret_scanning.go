@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+const secretScanningMaxBodyBytes = 1024 * 1024
+
+type SecretScanningHandler struct {
+	config             *conf.Config
+	secretScanningSrvc services.ISecretScanningService
+}
+
+func NewSecretScanningHandler(secretScanningService services.ISecretScanningService) *SecretScanningHandler {
+	return &SecretScanningHandler{
+		config:             conf.Get(),
+		secretScanningSrvc: secretScanningService,
+	}
+}
+
+func (h *SecretScanningHandler) RegisterRoutes(router chi.Router) {
+	if !h.config.Security.SecretScanning {
+		return
+	}
+	router.
+		With(middlewares.NewBodyLimitMiddleware(secretScanningMaxBodyBytes)).
+		Post("/secret_scanning/github", h.PostGithub)
+}
+
+// @Summary Report leaked api keys (github secret scanning partner program)
+// @Description Leaked api keys are revoked immediately and their owners are notified via e-mail
+// @ID post-secret-scanning-github
+// @Tags secret_scanning
+// @Accept json
+// @Produce json
+// @Param alerts body []models.SecretScanningAlert true "List of potentially leaked tokens"
+// @Success 200 {array} models.SecretScanningFeedback
+// @Router /secret_scanning/github [post]
+func (h *SecretScanningHandler) PostGithub(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	keyId := r.Header.Get("Github-Public-Key-Identifier")
+	signature := r.Header.Get("Github-Public-Key-Signature")
+	if err := h.secretScanningSrvc.VerifySignature(payload, keyId, signature); err != nil {
+		conf.Log().Request(r).Warn("failed to verify secret scanning alert signature", "error", err)
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(conf.ErrUnauthorized))
+		return
+	}
+
+	var alerts []*models.SecretScanningAlert
+	if err := json.Unmarshal(payload, &alerts); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, h.secretScanningSrvc.RevokeLeaked(alerts))
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/duke-git/lancet/v2/slice"
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type serviceAccountVm struct {
+	Username  string            `json:"username"`
+	Name      string            `json:"name"`
+	ApiKey    string            `json:"api_key,omitempty"` // only included upon creation
+	CreatedAt models.CustomTime `json:"created_at" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+}
+
+type serviceAccountRequest struct {
+	Username string `json:"username"`
+	Name     string `json:"name"`
+}
+
+type ServiceAccountsApiHandler struct {
+	config   *conf.Config
+	userSrvc services.IUserService
+}
+
+func NewServiceAccountsApiHandler(userService services.IUserService) *ServiceAccountsApiHandler {
+	return &ServiceAccountsApiHandler{
+		config:   conf.Get(),
+		userSrvc: userService,
+	}
+}
+
+func (h *ServiceAccountsApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.GetAll)
+	r.Post("/", h.Post)
+	r.Delete("/{username}", h.Delete)
+
+	router.Mount("/admin/service-accounts", r)
+}
+
+// @Summary List all service accounts (admin only)
+// @ID get-service-accounts
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} serviceAccountVm
+// @Router /admin/service-accounts [get]
+func (h *ServiceAccountsApiHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	users, err := h.userSrvc.GetAll()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve users", "error", err)
+		return
+	}
+
+	accounts := slice.Map[*models.User, *serviceAccountVm](
+		slice.Filter[*models.User](users, func(i int, u *models.User) bool { return u.IsServiceAccount }),
+		func(i int, u *models.User) *serviceAccountVm {
+			return &serviceAccountVm{Username: u.ID, Name: u.Name, CreatedAt: u.CreatedAt}
+		},
+	)
+
+	helpers.RespondJSON(w, r, http.StatusOK, accounts)
+}
+
+// @Summary Create a service account for integrations, which can only authenticate via its api key (admin only)
+// @ID post-service-account
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param account body serviceAccountRequest true "Username and display name"
+// @Security ApiKeyAuth
+// @Success 201 {object} serviceAccountVm
+// @Router /admin/service-accounts [post]
+func (h *ServiceAccountsApiHandler) Post(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	var req serviceAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !models.ValidateUsername(req.Username) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid username"))
+		return
+	}
+
+	user, created, err := h.userSrvc.CreateServiceAccount(req.Username, req.Name)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to create service account", "username", req.Username, "error", err)
+		return
+	}
+	if !created {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte("user already exists"))
+		return
+	}
+
+	conf.Log().Request(r).Info("created service account", "username", user.ID, "admin", middlewares.GetPrincipal(r).ID)
+	helpers.RespondJSON(w, r, http.StatusCreated, &serviceAccountVm{Username: user.ID, Name: user.Name, ApiKey: user.ApiKey, CreatedAt: user.CreatedAt})
+}
+
+// @Summary Delete a service account and all of its data (admin only)
+// @ID delete-service-account
+// @Tags admin
+// @Param username path string true "Username of the service account"
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /admin/service-accounts/{username} [delete]
+func (h *ServiceAccountsApiHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	user, err := h.userSrvc.GetUserById(chi.URLParam(r, "username"))
+	if err != nil || !user.IsServiceAccount {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return
+	}
+
+	if err := h.userSrvc.Delete(user); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to delete service account", "username", user.ID, "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *ServiceAccountsApiHandler) checkAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if user := middlewares.GetPrincipal(r); user == nil || !user.IsAdmin {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(conf.ErrUnauthorized))
+		return false
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type SettingsApiHandler struct {
+	config   *conf.Config
+	userSrvc services.IUserService
+}
+
+func NewSettingsApiHandler(userService services.IUserService) *SettingsApiHandler {
+	return &SettingsApiHandler{
+		config:   conf.Get(),
+		userSrvc: userService,
+	}
+}
+
+func (h *SettingsApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.Get)
+	r.Put("/", h.Update)
+	r.Patch("/", h.Update)
+
+	router.Mount("/settings", r)
+}
+
+// @Summary Retrieve the authenticated user's settings
+// @ID get-settings
+// @Tags settings
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.UserSettings
+// @Router /settings [get]
+func (h *SettingsApiHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+	helpers.RespondJSON(w, r, http.StatusOK, models.NewUserSettingsFrom(user))
+}
+
+// @Summary Update the authenticated user's settings
+// @Description Only fields present in the request body are updated. Changes to the heartbeats timeout or unknown project exclusion only apply to newly computed summaries.
+// @ID update-settings
+// @Tags settings
+// @Accept json
+// @Produce json
+// @Param settings body models.UserSettingsUpdate true "Settings to update"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.UserSettings
+// @Router /settings [patch]
+func (h *SettingsApiHandler) Update(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	var payload models.UserSettingsUpdate
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	if err := payload.Apply(user); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	if _, err := h.userSrvc.Update(user); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to update user settings", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, models.NewUserSettingsFrom(user))
+}
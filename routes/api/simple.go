@@ -0,0 +1,91 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/models"
+	routeutils "github.com/hackclub/hackatime/routes/utils"
+	"github.com/hackclub/hackatime/services"
+	"github.com/hackclub/hackatime/utils"
+)
+
+type SimpleApiHandler struct {
+	config      *conf.Config
+	userSrvc    services.IUserService
+	summarySrvc services.ISummaryService
+}
+
+type simpleTodayViewModel struct {
+	TotalSeconds int64  `json:"total_seconds"`
+	Text         string `json:"text"`
+}
+
+func NewSimpleApiHandler(userService services.IUserService, summaryService services.ISummaryService) *SimpleApiHandler {
+	return &SimpleApiHandler{
+		config:      conf.Get(),
+		userSrvc:    userService,
+		summarySrvc: summaryService,
+	}
+}
+
+// no auth middleware here, since the token in the url path is the only credential
+// this is intended for no-code tools (like apple shortcuts), that can't easily set custom headers
+func (h *SimpleApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Get("/{token}/today", h.GetToday)
+	router.Mount("/simple", r)
+}
+
+// @Summary Retrieve today's total coding time, authenticated by the user's simple token
+// @ID get-simple-today
+// @Tags summary
+// @Produce plain
+// @Produce json
+// @Param token path string true "The user's simple token (see settings)"
+// @Param format query string false "Response format" Enums(text, json)
+// @Success 200 {object} simpleTodayViewModel
+// @Router /simple/{token}/today [get]
+func (h *SimpleApiHandler) GetToday(w http.ResponseWriter, r *http.Request) {
+	user, err := h.userSrvc.GetUserBySimpleToken(chi.URLParam(r, "token"))
+	if err != nil || user.Deactivated || user.IsServiceAccount || user.InvitePending {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(conf.ErrUnauthorized))
+		return
+	}
+	if user.IsSuspended() {
+		// same as the authentication middleware, to tell why the token doesn't work anymore
+		helpers.RespondError(w, r, http.StatusForbidden, models.ApiErrorSuspended, user.SuspensionMessage())
+		return
+	}
+
+	summary, err, status := routeutils.LoadUserSummaryByParams(h.summarySrvc, &models.SummaryParams{
+		From: utils.BeginOfToday(user.TZ()),
+		To:   time.Now(),
+		User: user,
+	})
+	if err != nil {
+		w.WriteHeader(status)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to load summary", "userID", user.ID, "error", err)
+		return
+	}
+
+	total := summary.TotalTime().Round(time.Minute)
+	text := helpers.FmtUserDuration(total, user)
+
+	if r.URL.Query().Get("format") == "json" || r.Header.Get("Accept") == "application/json" {
+		helpers.RespondJSON(w, r, http.StatusOK, &simpleTodayViewModel{
+			TotalSeconds: int64(total.Seconds()),
+			Text:         text,
+		})
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(text))
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSimpleApiHandler_GetToday(t *testing.T) {
+	config.Set(config.Empty())
+
+	router := chi.NewRouter()
+	apiRouter := chi.NewRouter()
+	apiRouter.Use(middlewares.NewPrincipalMiddleware())
+	router.Mount("/api", apiRouter)
+
+	suspendedAt := models.CustomTime(time.Now())
+	activeUser := &models.User{ID: "active"}
+	suspendedUser := &models.User{ID: "suspended", SuspendedAt: &suspendedAt, SuspensionReason: "spam"}
+	deactivatedUser := &models.User{ID: "deactivated", Deactivated: true}
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserBySimpleToken", "active-token").Return(activeUser, nil)
+	userServiceMock.On("GetUserBySimpleToken", "suspended-token").Return(suspendedUser, nil)
+	userServiceMock.On("GetUserBySimpleToken", "deactivated-token").Return(deactivatedUser, nil)
+	userServiceMock.On("GetUserBySimpleToken", "unknown-token").Return((*models.User)(nil), errors.New("record not found"))
+
+	summaryServiceMock := new(mocks.SummaryServiceMock)
+	summaryServiceMock.On("Aliased", mock.AnythingOfType("time.Time"), mock.AnythingOfType("time.Time"), activeUser, mock.Anything, mock.Anything).Return(&models.Summary{
+		Projects: []*models.SummaryItem{{Type: models.SummaryProject, Key: "wakapi", Total: 90 * time.Minute / time.Second}},
+	}, nil)
+
+	sut := NewSimpleApiHandler(userServiceMock, summaryServiceMock)
+	sut.RegisterRoutes(apiRouter)
+
+	t.Run("when requesting with a valid token", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/simple/active-token/today?format=json", nil))
+
+		var vm simpleTodayViewModel
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Nil(t, json.NewDecoder(rec.Body).Decode(&vm))
+		assert.Equal(t, int64(90*60), vm.TotalSeconds)
+		assert.NotEmpty(t, vm.Text)
+	})
+
+	t.Run("when requesting with an unknown token", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/simple/unknown-token/today", nil))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("when requesting with the token of a suspended user", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/simple/suspended-token/today", nil))
+
+		var apiErr models.ApiError
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.Nil(t, json.NewDecoder(rec.Body).Decode(&apiErr))
+		assert.Equal(t, models.ApiErrorSuspended, apiErr.Code)
+	})
+
+	t.Run("when requesting with the token of a deactivated user", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/simple/deactivated-token/today", nil))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	summaryServiceMock.AssertNumberOfCalls(t, "Aliased", 1)
+}
@@ -0,0 +1,95 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/hackclub/hackatime/services"
+)
+
+const defaultStorageTopN = 10
+
+type StorageApiHandler struct {
+	config      *conf.Config
+	userSrvc    services.IUserService
+	metricsRepo repositories.IMetricsRepository
+}
+
+func NewStorageApiHandler(userService services.IUserService, metricsRepo repositories.IMetricsRepository) *StorageApiHandler {
+	return &StorageApiHandler{
+		config:      conf.Get(),
+		userSrvc:    userService,
+		metricsRepo: metricsRepo,
+	}
+}
+
+func (h *StorageApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.Get)
+
+	router.Mount("/admin/storage", r)
+}
+
+// @Summary Retrieve storage usage per user (admin only)
+// @ID get-admin-storage
+// @Tags admin
+// @Produce json
+// @Param top query int false "Number of top consumers to list (default 10)"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.StorageStatsViewModel
+// @Failure 403 {object} models.ApiError
+// @Router /admin/storage [get]
+func (h *StorageApiHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+	if user == nil || !user.IsAdmin {
+		helpers.RespondError(w, r, http.StatusForbidden, models.ApiErrorForbidden, conf.ErrForbidden)
+		return
+	}
+
+	topN := defaultStorageTopN
+	if top, err := strconv.Atoi(r.URL.Query().Get("top")); err == nil && top > 0 {
+		topN = top
+	}
+
+	stats, err := h.metricsRepo.GetStorageStatsByUser()
+	if err != nil {
+		conf.Log().Request(r).Error("failed to retrieve storage stats", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		return
+	}
+
+	dbSize, err := h.metricsRepo.GetDatabaseSize()
+	if err != nil {
+		conf.Log().Request(r).Error("failed to retrieve database size", "error", err)
+	}
+
+	vm := &models.StorageStatsViewModel{
+		DatabaseBytes: dbSize,
+		TotalUsers:    len(stats),
+	}
+	for _, s := range stats {
+		vm.TotalEstimatedBytes += s.EstimatedBytes
+		vm.TotalHeartbeats += s.Heartbeats
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].EstimatedBytes > stats[j].EstimatedBytes
+	})
+	vm.TopConsumers = append([]*models.UserStorageStats{}, stats[:min(topN, len(stats))]...)
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].GrowthRate7d() > stats[j].GrowthRate7d()
+	})
+	vm.TopGrowing = append([]*models.UserStorageStats{}, stats[:min(topN, len(stats))]...)
+
+	helpers.RespondJSON(w, r, http.StatusOK, vm)
+}
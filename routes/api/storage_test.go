@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func newStorageTestRouter(metricsRepo *mocks.MetricsRepositoryMock) (chi.Router, *models.User, *models.User) {
+	admin := &models.User{ID: "admin", ApiKey: "admin-api-key", IsAdmin: true}
+	user := &models.User{ID: "user", ApiKey: "user-api-key"}
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserByKey", admin.ApiKey).Return(admin, nil)
+	userServiceMock.On("GetUserByKey", user.ApiKey).Return(user, nil)
+
+	router := chi.NewRouter()
+	router.Use(middlewares.NewPrincipalMiddleware())
+	NewStorageApiHandler(userServiceMock, metricsRepo).RegisterRoutes(router)
+	return router, admin, user
+}
+
+func storageRequest(router chi.Router, target string, user *models.User) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	req.Header.Add("Authorization", "Bearer "+base64.StdEncoding.EncodeToString([]byte(user.ApiKey)))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestStorageApiHandler_Get_AdminOnly(t *testing.T) {
+	config.Set(config.Empty())
+
+	metricsRepo := new(mocks.MetricsRepositoryMock)
+	router, _, user := newStorageTestRouter(metricsRepo)
+
+	rec := storageRequest(router, "/admin/storage", user)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	var apiErr models.ApiError
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&apiErr))
+	assert.Equal(t, models.ApiErrorForbidden, apiErr.Code)
+	metricsRepo.AssertNotCalled(t, "GetStorageStatsByUser")
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/storage", nil))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestStorageApiHandler_Get(t *testing.T) {
+	config.Set(config.Empty())
+
+	metricsRepo := new(mocks.MetricsRepositoryMock)
+	metricsRepo.On("GetDatabaseSize").Return(int64(4096), nil)
+	metricsRepo.On("GetStorageStatsByUser").Return([]*models.UserStorageStats{
+		{UserID: "small", Heartbeats: 10, HeartbeatsLast7d: 7, EstimatedBytes: 100},
+		{UserID: "big", Heartbeats: 1000, HeartbeatsLast7d: 14, EstimatedBytes: 10000},
+		{UserID: "growing", Heartbeats: 500, HeartbeatsLast7d: 490, EstimatedBytes: 5000},
+	}, nil)
+	router, admin, _ := newStorageTestRouter(metricsRepo)
+
+	rec := storageRequest(router, "/admin/storage?top=2", admin)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var vm models.StorageStatsViewModel
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&vm))
+	assert.Equal(t, int64(4096), vm.DatabaseBytes)
+	assert.Equal(t, 3, vm.TotalUsers)
+	assert.Equal(t, int64(1510), vm.TotalHeartbeats)
+	assert.Equal(t, int64(15100), vm.TotalEstimatedBytes)
+	assert.Len(t, vm.TopConsumers, 2)
+	assert.Equal(t, "big", vm.TopConsumers[0].UserID)
+	assert.Equal(t, "growing", vm.TopConsumers[1].UserID)
+	assert.Len(t, vm.TopGrowing, 2)
+	assert.Equal(t, "growing", vm.TopGrowing[0].UserID)
+	assert.Equal(t, "big", vm.TopGrowing[1].UserID)
+}
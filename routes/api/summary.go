@@ -9,26 +9,43 @@ import (
 
 	conf "github.com/hackclub/hackatime/config"
 	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
 	"github.com/hackclub/hackatime/services"
 )
 
+// summaryVm additionally carries the metadata of all projects contained in the summary
+type summaryVm struct {
+	*models.Summary
+	ProjectMetadata map[string]*models.ProjectMetadata `json:"project_metadata"`
+}
+
+type compactSummaryVm struct {
+	*models.CompactSummary
+	ProjectMetadata map[string]*models.ProjectMetadata `json:"project_metadata"`
+}
+
 type SummaryApiHandler struct {
-	config      *conf.Config
-	userSrvc    services.IUserService
-	summarySrvc services.ISummaryService
+	config              *conf.Config
+	userSrvc            services.IUserService
+	summarySrvc         services.ISummaryService
+	projectMetadataSrvc services.IProjectMetadataService
 }
 
-func NewSummaryApiHandler(userService services.IUserService, summaryService services.ISummaryService) *SummaryApiHandler {
+func NewSummaryApiHandler(userService services.IUserService, summaryService services.ISummaryService, projectMetadataService services.IProjectMetadataService) *SummaryApiHandler {
 	return &SummaryApiHandler{
-		summarySrvc: summaryService,
-		userSrvc:    userService,
-		config:      conf.Get(),
+		summarySrvc:         summaryService,
+		userSrvc:            userService,
+		projectMetadataSrvc: projectMetadataService,
+		config:              conf.Get(),
 	}
 }
 
 func (h *SummaryApiHandler) RegisterRoutes(router chi.Router) {
 	r := chi.NewRouter()
-	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Use(
+		middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler,
+		middlewares.NewTimeoutMiddleware(h.config.Server.RequestTimeouts.GetSummary()),
+	)
 	r.Get("/", h.Get)
 
 	router.Mount("/summary", r)
@@ -38,7 +55,8 @@ func (h *SummaryApiHandler) RegisterRoutes(router chi.Router) {
 // @ID get-summary
 // @Tags summary
 // @Produce json
-// @Param interval query string false "Interval identifier" Enums(today, yesterday, week, month, year, 7_days, last_7_days, 30_days, last_30_days, 6_months, last_6_months, 12_months, last_12_months, last_year, any, all_time, low_skies, high_seas)
+// @Description Besides the predefined intervals, sliding windows ending right now can be requested as last_<n>m or last_<n>h (e.g. last_90m, last_24h, up to a week), which are always computed from raw heartbeats.
+// @Param interval query string false "Interval identifier" Enums(today, yesterday, week, month, year, 7_days, last_7_days, 30_days, last_30_days, 6_months, last_6_months, 12_months, last_12_months, last_year, any, all_time, low_skies, high_seas, last_24h, last_90m)
 // @Param from query string false "Start date (e.g. '2021-02-07')"
 // @Param to query string false "End date (e.g. '2021-02-08')"
 // @Param recompute query bool false "Whether to recompute the summary from raw heartbeat or use cache"
@@ -48,17 +66,58 @@ func (h *SummaryApiHandler) RegisterRoutes(router chi.Router) {
 // @Param operating_system query string false "OS to filter by"
 // @Param machine query string false "Machine to filter by"
 // @Param label query string false "Project label to filter by"
+// @Param category query string false "Category to filter by (e.g. coding, code reviewing, debugging)"
+// @Param source query string false "Comma-separated heartbeat sources to filter by (plugin, import, backfill, synthesized)"
+// @Param group_by query string false "Comma-separated types to only return the items of, e.g. category to get the time split by coding, reviewing, debugging, etc." Enums(project, language, editor, operating_system, machine, label, branch, entity, category)
+// @Param include_archived query bool false "Whether to include archived projects"
+// @Param compact query bool false "Whether to only return rounded totals and the top 5 items per type (e.g. for mobile widgets)"
 // @Param user query string false "The user to filter by if using Bearer authentication and the admin token"
 // @Security ApiKeyAuth
-// @Success 200 {object} models.Summary
+// @Success 200 {object} summaryVm
 // @Router /summary [get]
 func (h *SummaryApiHandler) Get(w http.ResponseWriter, r *http.Request) {
+	groupBy, err := helpers.ParseSummaryGroupBy(r)
+	if err != nil {
+		helpers.RespondError(w, r, http.StatusBadRequest, models.ApiErrorInvalidInput, err.Error())
+		return
+	}
+
 	summary, err, status := routeutils.LoadUserSummary(h.summarySrvc, r)
 	if err != nil {
-		w.WriteHeader(status)
-		w.Write([]byte(err.Error()))
+		helpers.RespondError(w, r, status, models.ApiErrorCodeFor(status), err.Error())
+		return
+	}
+
+	if len(groupBy) > 0 {
+		grouped := *summary // summary might be shared with the cache, so don't modify it in place
+		summary = grouped.KeepOnly(groupBy)
+	}
+
+	metadata, err := h.getProjectMetadata(summary)
+	if err != nil {
+		helpers.RespondError(w, r, http.StatusInternalServerError, models.ApiErrorInternal, conf.ErrInternalServerError)
+		conf.Log().Request(r).Error("failed to retrieve project metadata", "userID", summary.UserID, "error", err)
+		return
+	}
+
+	if compact := r.URL.Query().Get("compact"); compact != "" && compact != "false" {
+		helpers.RespondJSON(w, r, http.StatusOK, &compactSummaryVm{CompactSummary: models.NewCompactSummary(summary), ProjectMetadata: metadata})
 		return
 	}
 
-	helpers.RespondJSON(w, r, http.StatusOK, summary)
+	helpers.RespondJSON(w, r, http.StatusOK, &summaryVm{Summary: summary, ProjectMetadata: metadata})
+}
+
+func (h *SummaryApiHandler) getProjectMetadata(summary *models.Summary) (map[string]*models.ProjectMetadata, error) {
+	userMetadata, err := h.projectMetadataSrvc.GetByUserMapped(summary.UserID)
+	if err != nil {
+		return nil, err
+	}
+	metadata := make(map[string]*models.ProjectMetadata)
+	for _, p := range summary.Projects {
+		if m, ok := userMetadata[p.Key]; ok {
+			metadata[p.Key] = m
+		}
+	}
+	return metadata, nil
 }
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	routeutils "github.com/hackclub/hackatime/routes/utils"
+	"github.com/hackclub/hackatime/services"
+)
+
+type SummaryPdfApiHandler struct {
+	config     *conf.Config
+	userSrvc   services.IUserService
+	exportSrvc services.IExportService
+}
+
+func NewSummaryPdfApiHandler(userService services.IUserService, exportService services.IExportService) *SummaryPdfApiHandler {
+	return &SummaryPdfApiHandler{
+		config:     conf.Get(),
+		userSrvc:   userService,
+		exportSrvc: exportService,
+	}
+}
+
+func (h *SummaryPdfApiHandler) RegisterRoutes(router chi.Router) {
+	router.Group(func(r chi.Router) {
+		r.Use(
+			middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler,
+			middlewares.NewTimeoutMiddleware(h.config.Server.RequestTimeouts.GetSummary()),
+		)
+		r.Get("/users/{user}/summary.pdf", h.Get)
+	})
+}
+
+// @Summary Retrieve a printable pdf report of a user's summary, e.g. to attach it to an invoice
+// @ID get-summary-pdf
+// @Tags summary
+// @Produce application/pdf
+// @Param user path string true "Username (or current)"
+// @Param range query string false "Interval identifier, defaults to last_month" Enums(today, yesterday, week, month, last_month, year, 7_days, last_7_days, 30_days, last_30_days, 6_months, last_6_months, 12_months, last_12_months, last_year)
+// @Param from query string false "Start date (e.g. '2021-02-07'), instead of range"
+// @Param to query string false "End date (e.g. '2021-02-08'), instead of range"
+// @Security ApiKeyAuth
+// @Success 200 {file} file
+// @Router /users/{user}/summary.pdf [get]
+func (h *SummaryPdfApiHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user, err := routeutils.CheckEffectiveUser(w, r, h.userSrvc, "current")
+	if err != nil {
+		return // response was already sent by util function
+	}
+
+	from, to, err := h.parseRange(r, user)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	data, err := h.exportSrvc.GeneratePdf(user, from, to)
+	if errors.Is(err, services.ErrPdfExportRangeInvalid) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to generate pdf summary", "userID", user.ID, "error", err)
+		return
+	}
+
+	filename := fmt.Sprintf("summary_%s_%s_%s.pdf", user.ID, from.Format(conf.SimpleDateFormat), to.Add(-time.Nanosecond).Format(conf.SimpleDateFormat))
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (h *SummaryPdfApiHandler) parseRange(r *http.Request, user *models.User) (from, to time.Time, err error) {
+	q := r.URL.Query()
+	if q.Has("from") || q.Has("to") {
+		if from, err = helpers.ParseDateTimeTZ(q.Get("from"), user.TZ()); err != nil {
+			return from, to, errors.New("missing or invalid 'from' parameter")
+		}
+		if to, err = helpers.ParseDateTimeTZ(q.Get("to"), user.TZ()); err != nil {
+			return from, to, errors.New("missing or invalid 'to' parameter")
+		}
+		return from, to, nil
+	}
+
+	interval := (*models.IntervalLastMonth)[0]
+	if q.Has("range") {
+		interval = q.Get("range")
+	}
+	if err, from, to = helpers.ResolveIntervalRawTZWeekStart(interval, user.TZ(), user.WeekStart()); err != nil {
+		return from, to, errors.New("invalid 'range' parameter")
+	}
+	return from, to, nil
+}
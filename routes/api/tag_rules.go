@@ -0,0 +1,125 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type TagRulesApiHandler struct {
+	config      *conf.Config
+	userSrvc    services.IUserService
+	tagRuleSrvc services.ITagRuleService
+}
+
+func NewTagRulesApiHandler(userService services.IUserService, tagRuleService services.ITagRuleService) *TagRulesApiHandler {
+	return &TagRulesApiHandler{
+		config:      conf.Get(),
+		userSrvc:    userService,
+		tagRuleSrvc: tagRuleService,
+	}
+}
+
+func (h *TagRulesApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.GetAll)
+	r.Post("/", h.Post)
+	r.Delete("/{id}", h.Delete)
+
+	router.Mount("/tags/rules", r)
+}
+
+// @Summary Retrieve the rules by which the authenticated user's incoming heartbeats are tagged
+// @ID get-tag-rules
+// @Tags tags
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.TagRule
+// @Router /tags/rules [get]
+func (h *TagRulesApiHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	rules, err := h.tagRuleSrvc.GetByUser(user.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve tag rules", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, rules)
+}
+
+// @Summary Add a rule to tag all incoming heartbeats whose field equals the given value
+// @Description Field is one of project, branch, language, category, machine or entity, where the value of the latter is a path, which all files below are matched by. Rules only apply to heartbeats received after their creation.
+// @ID post-tag-rule
+// @Tags tags
+// @Accept json
+// @Produce json
+// @Param rule body models.TagRule true "e.g. {\"field\": \"project\", \"value\": \"shop-frontend\", \"tag\": \"client-a\"}"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.TagRule
+// @Router /tags/rules [post]
+func (h *TagRulesApiHandler) Post(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	var rule models.TagRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+	rule.ID = 0
+	rule.UserID = user.ID
+
+	if !models.NormalizeTagRule(&rule).IsValid() {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid tag rule"))
+		return
+	}
+
+	result, err := h.tagRuleSrvc.Create(&rule)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to save tag rule", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusCreated, result)
+}
+
+// @Summary Remove one of the authenticated user's tag rules
+// @ID delete-tag-rule
+// @Tags tags
+// @Param id path int true "Rule id"
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /tags/rules/{id} [delete]
+func (h *TagRulesApiHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 32)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	if err := h.tagRuleSrvc.Delete(user.ID, uint(id)); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to delete tag rule", "userID", user.ID, "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,357 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+	"gorm.io/gorm"
+)
+
+type teamRequest struct {
+	Name            string `json:"name"`
+	SlackWebhookUrl string `json:"slack_webhook_url"`
+	DigestTemplate  string `json:"digest_template"`
+	WorkDays        string `json:"work_days"`  // comma-separated week days, defaults to monday through friday
+	WorkStart       string `json:"work_start"` // hh:mm, defaults to 09:00
+	WorkEnd         string `json:"work_end"`   // hh:mm, defaults to 17:00
+}
+
+type teamDigestVm struct {
+	Text string `json:"text"`
+}
+
+type TeamsApiHandler struct {
+	config   *conf.Config
+	userSrvc services.IUserService
+	teamSrvc services.ITeamService
+}
+
+func NewTeamsApiHandler(userService services.IUserService, teamService services.ITeamService) *TeamsApiHandler {
+	return &TeamsApiHandler{
+		config:   conf.Get(),
+		userSrvc: userService,
+		teamSrvc: teamService,
+	}
+}
+
+func (h *TeamsApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.GetAll)
+	r.Post("/", h.Post)
+	r.Put("/{id}", h.Put)
+	r.Delete("/{id}", h.Delete)
+	r.Put("/{id}/members/{user}", h.PutMember)
+	r.Delete("/{id}/members/{user}", h.DeleteMember)
+	r.Get("/{id}/digest", h.GetDigest)
+	r.Post("/{id}/digest", h.PostDigest)
+
+	router.Mount("/admin/teams", r)
+}
+
+// @Summary Retrieve all teams along with their members (admin only)
+// @ID get-teams
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.Team
+// @Router /admin/teams [get]
+func (h *TeamsApiHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	teams, err := h.teamSrvc.GetAll()
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve teams", "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, teams)
+}
+
+// @Summary Create a team, whose weekly digest is posted to the given slack channel (admin only)
+// @Description The digest template is a go text/template, see models.TeamDigest for the available fields and models.DefaultTeamDigestTemplate for an example. The default template is used if blank. Work days and hours define which time counts as work hours in the digest, in each member's own time zone.
+// @ID post-team
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param team body teamRequest true "Team"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.Team
+// @Router /admin/teams [post]
+func (h *TeamsApiHandler) Post(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	var req teamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	team, err := h.teamSrvc.Create(&models.Team{
+		Name:            req.Name,
+		SlackWebhookUrl: req.SlackWebhookUrl,
+		DigestTemplate:  req.DigestTemplate,
+		WorkDays:        req.WorkDays,
+		WorkStart:       req.WorkStart,
+		WorkEnd:         req.WorkEnd,
+	})
+	if !h.handleSaveError(w, r, err) {
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusCreated, team)
+}
+
+// @Summary Update a team's name, slack channel or digest template (admin only)
+// @ID put-team
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Team ID"
+// @Param team body teamRequest true "Team"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.Team
+// @Router /admin/teams/{id} [put]
+func (h *TeamsApiHandler) Put(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	team, ok := h.loadTeam(w, r)
+	if !ok {
+		return
+	}
+
+	var req teamRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+	team.Name, team.SlackWebhookUrl, team.DigestTemplate = req.Name, req.SlackWebhookUrl, req.DigestTemplate
+	team.WorkDays, team.WorkStart, team.WorkEnd = req.WorkDays, req.WorkStart, req.WorkEnd
+
+	team, err := h.teamSrvc.Update(team)
+	if !h.handleSaveError(w, r, err) {
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, team)
+}
+
+// @Summary Delete a team (admin only)
+// @ID delete-team
+// @Tags admin
+// @Param id path int true "Team ID"
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /admin/teams/{id} [delete]
+func (h *TeamsApiHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	team, ok := h.loadTeam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.teamSrvc.Delete(team.ID); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to delete team", "teamID", team.ID, "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Add a user to a team (admin only)
+// @ID put-team-member
+// @Tags admin
+// @Produce json
+// @Param id path int true "Team ID"
+// @Param user path string true "User ID"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.TeamMember
+// @Router /admin/teams/{id}/members/{user} [put]
+func (h *TeamsApiHandler) PutMember(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	team, ok := h.loadTeam(w, r)
+	if !ok {
+		return
+	}
+
+	member, err := h.teamSrvc.AddMember(team, chi.URLParam(r, "user"))
+	if errors.Is(err, services.ErrTeamMemberConflict) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte("user not found"))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to add team member", "teamID", team.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusCreated, member)
+}
+
+// @Summary Remove a user from a team (admin only)
+// @ID delete-team-member
+// @Tags admin
+// @Param id path int true "Team ID"
+// @Param user path string true "User ID"
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /admin/teams/{id}/members/{user} [delete]
+func (h *TeamsApiHandler) DeleteMember(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	team, ok := h.loadTeam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.teamSrvc.RemoveMember(team, chi.URLParam(r, "user")); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to remove team member", "teamID", team.ID, "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Preview last week's digest of a team as rendered by its template, without posting it (admin only)
+// @ID get-team-digest
+// @Tags admin
+// @Produce json
+// @Param id path int true "Team ID"
+// @Security ApiKeyAuth
+// @Success 200 {object} teamDigestVm
+// @Router /admin/teams/{id}/digest [get]
+func (h *TeamsApiHandler) GetDigest(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	team, ok := h.loadTeam(w, r)
+	if !ok {
+		return
+	}
+
+	text, err := h.teamSrvc.RenderDigest(team)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to render team digest", "teamID", team.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, &teamDigestVm{Text: text})
+}
+
+// @Summary Post last week's digest of a team to its slack channel right away (admin only)
+// @ID post-team-digest
+// @Tags admin
+// @Param id path int true "Team ID"
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /admin/teams/{id}/digest [post]
+func (h *TeamsApiHandler) PostDigest(w http.ResponseWriter, r *http.Request) {
+	if !h.checkAdmin(w, r) {
+		return
+	}
+
+	team, ok := h.loadTeam(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.teamSrvc.PostDigest(team); errors.Is(err, services.ErrTeamNoWebhook) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("failed to post digest"))
+		conf.Log().Request(r).Error("failed to post team digest", "teamID", team.ID, "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TeamsApiHandler) loadTeam(w http.ResponseWriter, r *http.Request) (*models.Team, bool) {
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return nil, false
+	}
+
+	team, err := h.teamSrvc.GetById(uint(id))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return nil, false
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve team", "id", id, "error", err)
+		return nil, false
+	}
+	return team, true
+}
+
+func (h *TeamsApiHandler) handleSaveError(w http.ResponseWriter, r *http.Request, err error) bool {
+	if errors.Is(err, services.ErrTeamInvalid) || errors.Is(err, services.ErrTeamTemplateInvalid) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return false
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to save team", "error", err)
+		return false
+	}
+	return true
+}
+
+func (h *TeamsApiHandler) checkAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if principal := middlewares.GetPrincipal(r); principal == nil || !principal.IsAdmin {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(conf.ErrUnauthorized))
+		return false
+	}
+	return true
+}
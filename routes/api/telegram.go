@@ -0,0 +1,114 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type TelegramApiHandler struct {
+	config       *conf.Config
+	userSrvc     services.IUserService
+	telegramSrvc services.ITelegramService
+}
+
+func NewTelegramApiHandler(userService services.IUserService, telegramService services.ITelegramService) *TelegramApiHandler {
+	return &TelegramApiHandler{
+		config:       conf.Get(),
+		userSrvc:     userService,
+		telegramSrvc: telegramService,
+	}
+}
+
+func (h *TelegramApiHandler) RegisterRoutes(router chi.Router) {
+	if !h.config.Telegram.Enabled() {
+		return
+	}
+
+	r := chi.NewRouter()
+	r.Post("/webhook", h.PostWebhook)
+	r.Group(func(r chi.Router) {
+		r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+		r.Post("/link", h.PostLink)
+		r.Delete("/link", h.DeleteLink)
+	})
+
+	router.Mount("/telegram", r)
+}
+
+// PostWebhook receives updates from telegram, authenticated by the secret token the webhook was registered with
+func (h *TelegramApiHandler) PostWebhook(w http.ResponseWriter, r *http.Request) {
+	token := r.Header.Get("X-Telegram-Bot-Api-Secret-Token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.config.Telegram.GetWebhookSecret())) != 1 {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(conf.ErrUnauthorized))
+		return
+	}
+
+	var update models.TelegramUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	// telegram retries failed deliveries, so failures to reply are only logged
+	if err := h.telegramSrvc.HandleUpdate(&update); err != nil {
+		conf.Log().Request(r).Error("failed to handle telegram update", "updateID", update.UpdateId, "error", err)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// @Summary Create a code to link a telegram chat to the authenticated user
+// @Description Send the code to the bot as "/start <code>" from the chat to link, or open the returned url. Codes expire after 15 minutes. Linked chats receive goal and inactivity alerts and can query the user's coding time.
+// @ID post-telegram-link
+// @Tags telegram
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 201 {object} models.TelegramLink
+// @Router /telegram/link [post]
+func (h *TelegramApiHandler) PostLink(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	link, err := h.telegramSrvc.CreateLink(user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to create telegram link", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusCreated, link)
+}
+
+// @Summary Unlink the authenticated user's telegram chat
+// @ID delete-telegram-link
+// @Tags telegram
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /telegram/link [delete]
+func (h *TelegramApiHandler) DeleteLink(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	deleted, err := h.telegramSrvc.Unlink(user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to unlink telegram chat", "userID", user.ID, "error", err)
+		return
+	}
+	if !deleted {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+	"github.com/hackclub/hackatime/utils"
+)
+
+type TimeEntriesApiHandler struct {
+	config          *conf.Config
+	userSrvc        services.IUserService
+	timeEntrySrvc   services.ITimeEntryService
+	aggregationSrvc services.IAggregationService
+}
+
+func NewTimeEntriesApiHandler(userService services.IUserService, timeEntryService services.ITimeEntryService, aggregationService services.IAggregationService) *TimeEntriesApiHandler {
+	return &TimeEntriesApiHandler{
+		config:          conf.Get(),
+		userSrvc:        userService,
+		timeEntrySrvc:   timeEntryService,
+		aggregationSrvc: aggregationService,
+	}
+}
+
+func (h *TimeEntriesApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.GetAll)
+	r.Post("/", h.Post)
+	r.Delete("/{id}", h.Delete)
+
+	router.Mount("/time-entries", r)
+}
+
+// @Summary Retrieve the authenticated user's manual time entries and annotations within a date range
+// @ID get-time-entries
+// @Tags time entries
+// @Produce json
+// @Param from query string true "Start date (e.g. '2021-02-07')"
+// @Param to query string true "End date (e.g. '2021-02-08')"
+// @Security ApiKeyAuth
+// @Success 200 {array} models.TimeEntry
+// @Router /time-entries [get]
+func (h *TimeEntriesApiHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	from, err := helpers.ParseDateTimeTZ(r.URL.Query().Get("from"), user.TZ())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("missing or invalid 'from' parameter"))
+		return
+	}
+	to, err := helpers.ParseDateTimeTZ(r.URL.Query().Get("to"), user.TZ())
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("missing or invalid 'to' parameter"))
+		return
+	}
+
+	entries, err := h.timeEntrySrvc.GetByUserWithin(user.ID, from, to)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve time entries", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, entries)
+}
+
+// @Summary Add time not captured by any plugin (type manual), e.g. pair programming, or only annotate a period of time (type annotation)
+// @Description Manual time is merged into summaries under the "manual" source, so it can be filtered out using the source parameter. Times are unix timestamps, like for heartbeats.
+// @ID post-time-entry
+// @Tags time entries
+// @Accept json
+// @Produce json
+// @Param entry body models.TimeEntry true "e.g. {\"type\": \"manual\", \"project\": \"wakapi\", \"category\": \"coding\", \"note\": \"pair programming\", \"from\": 1707296400, \"to\": 1707303600}"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.TimeEntry
+// @Router /time-entries [post]
+func (h *TimeEntriesApiHandler) Post(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	var entry models.TimeEntry
+	if err := json.NewDecoder(r.Body).Decode(&entry); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+	entry = models.TimeEntry{
+		UserID:   user.ID,
+		Type:     entry.Type,
+		Project:  strings.TrimSpace(entry.Project),
+		Language: strings.TrimSpace(entry.Language),
+		Category: strings.TrimSpace(entry.Category),
+		Note:     strings.TrimSpace(entry.Note),
+		FromTime: entry.FromTime,
+		ToTime:   entry.ToTime,
+	}
+
+	if !entry.IsValid() || entry.FromTime.T().After(time.Now()) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid time entry"))
+		return
+	}
+
+	result, err := h.timeEntrySrvc.Create(&entry)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to create time entry", "userID", user.ID, "error", err)
+		return
+	}
+
+	h.resummarize(user, result)
+	helpers.RespondJSON(w, r, http.StatusCreated, result)
+}
+
+// @Summary Remove one of the authenticated user's time entries
+// @ID delete-time-entry
+// @Tags time entries
+// @Param id path int true "Entry ID"
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /time-entries/{id} [delete]
+func (h *TimeEntriesApiHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	entry, err := h.timeEntrySrvc.GetByUserAndId(user.ID, uint(id))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return
+	}
+
+	if err := h.timeEntrySrvc.Delete(entry); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to delete time entry", "userID", user.ID, "error", err)
+		return
+	}
+
+	h.resummarize(user, entry)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// resummarize re-generates the already materialized summaries of past days the entry falls into
+func (h *TimeEntriesApiHandler) resummarize(user *models.User, entry *models.TimeEntry) {
+	if !entry.CountsTowardsSummaries() || !entry.FromTime.T().Before(utils.BeginOfToday(time.Local)) {
+		return
+	}
+	if _, err := h.aggregationSrvc.Resummarize(user, entry.FromTime.T(), entry.ToTime.T()); err != nil {
+		conf.Log().Warn("failed to re-generate summaries after time entry change", "userID", user.ID, "error", err)
+	}
+}
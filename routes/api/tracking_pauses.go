@@ -0,0 +1,146 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type trackingPauseRequest struct {
+	From string `json:"from"` // first day of the pause, e.g. '2024-07-01'
+	To   string `json:"to"`   // last day of the pause, inclusive
+	Note string `json:"note"`
+}
+
+type TrackingPausesApiHandler struct {
+	config            *conf.Config
+	userSrvc          services.IUserService
+	trackingPauseSrvc services.ITrackingPauseService
+}
+
+func NewTrackingPausesApiHandler(userService services.IUserService, trackingPauseService services.ITrackingPauseService) *TrackingPausesApiHandler {
+	return &TrackingPausesApiHandler{
+		config:            conf.Get(),
+		userSrvc:          userService,
+		trackingPauseSrvc: trackingPauseService,
+	}
+}
+
+func (h *TrackingPausesApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.GetAll)
+	r.Post("/", h.Post)
+	r.Delete("/{id}", h.Delete)
+
+	router.Mount("/pauses", r)
+}
+
+// @Summary Retrieve the authenticated user's tracking pauses (vacations), past and upcoming
+// @ID get-tracking-pauses
+// @Tags pauses
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} models.TrackingPause
+// @Router /pauses [get]
+func (h *TrackingPausesApiHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	pauses, err := h.trackingPauseSrvc.GetByUser(user.ID)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve tracking pauses", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, pauses)
+}
+
+// @Summary Pause tracking for a range of days, e.g. a vacation
+// @Description Heartbeats sent for any time within the pause are discarded, though still reported as created. Days within the pause don't break the streak. Days are interpreted in the user's time zone, the pause must not start in the past and must not overlap another one.
+// @ID post-tracking-pause
+// @Tags pauses
+// @Accept json
+// @Produce json
+// @Param pause body trackingPauseRequest true "First and last day of the pause"
+// @Security ApiKeyAuth
+// @Success 201 {object} models.TrackingPause
+// @Router /pauses [post]
+func (h *TrackingPausesApiHandler) Post(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	var req trackingPauseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	from, errFrom := time.ParseInLocation(conf.SimpleDateFormat, req.From, user.TZ())
+	to, errTo := time.ParseInLocation(conf.SimpleDateFormat, req.To, user.TZ())
+	if errFrom != nil || errTo != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("missing or invalid 'from' or 'to' date"))
+		return
+	}
+
+	pause, err := h.trackingPauseSrvc.Create(user, models.NewTrackingPause(from, to, req.Note))
+	if errors.Is(err, services.ErrTrackingPauseInvalid) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to create tracking pause", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusCreated, pause)
+}
+
+// @Summary Resume tracking, i.e. end an active pause right away or cancel an upcoming one
+// @Description Past pauses can't be removed.
+// @ID delete-tracking-pause
+// @Tags pauses
+// @Param id path int true "Pause ID"
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /pauses/{id} [delete]
+func (h *TrackingPausesApiHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	id, err := strconv.ParseUint(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	err = h.trackingPauseSrvc.End(user, uint(id))
+	switch {
+	case errors.Is(err, services.ErrTrackingPauseNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+	case errors.Is(err, services.ErrTrackingPauseInvalid):
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+	case err != nil:
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to end tracking pause", "userID", user.ID, "error", err)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type userExternalIdRequest struct {
+	ExternalId string `json:"external_id"`
+}
+
+type UserExternalIdsApiHandler struct {
+	config   *conf.Config
+	userSrvc services.IUserService
+}
+
+func NewUserExternalIdsApiHandler(userService services.IUserService) *UserExternalIdsApiHandler {
+	return &UserExternalIdsApiHandler{
+		config:   conf.Get(),
+		userSrvc: userService,
+	}
+}
+
+func (h *UserExternalIdsApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.GetAll)
+	r.Put("/{provider}", h.Put)
+	r.Delete("/{provider}", h.Delete)
+
+	router.Mount("/admin/users/{username}/external-ids", r)
+}
+
+// @Summary List a user's external ids (admin only)
+// @ID get-user-external-ids
+// @Tags admin
+// @Produce json
+// @Param username path string true "Username or external id"
+// @Security ApiKeyAuth
+// @Success 200 {array} models.UserExternalId
+// @Router /admin/users/{username}/external-ids [get]
+func (h *UserExternalIdsApiHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.loadUser(w, r)
+	if !ok {
+		return
+	}
+
+	externalIds, err := h.userSrvc.GetExternalIds(user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve external ids", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, externalIds)
+}
+
+// @Summary Assign an external id of the given provider to a user, after which "{provider}:{external_id}" can be used in place of their username (admin only)
+// @ID put-user-external-id
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param username path string true "Username or external id"
+// @Param provider path string true "Provider, e.g. slack (lowercase letters, digits, dashes and underscores)"
+// @Param external_id body userExternalIdRequest true "External id"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.UserExternalId
+// @Router /admin/users/{username}/external-ids/{provider} [put]
+func (h *UserExternalIdsApiHandler) Put(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.loadUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req userExternalIdRequest
+	provider := chi.URLParam(r, "provider")
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || !models.ValidateExternalIdProvider(provider) || !models.ValidateExternalId(req.ExternalId) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid provider or external id"))
+		return
+	}
+
+	externalId, err := h.userSrvc.SetExternalId(user, provider, req.ExternalId)
+	if errors.Is(err, services.ErrExternalIdTaken) {
+		w.WriteHeader(http.StatusConflict)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to set external id", "userID", user.ID, "provider", provider, "error", err)
+		return
+	}
+
+	conf.Log().Request(r).Info("set external id", "userID", user.ID, "provider", provider, "admin", middlewares.GetPrincipal(r).ID)
+	helpers.RespondJSON(w, r, http.StatusOK, externalId)
+}
+
+// @Summary Remove a user's external id of the given provider (admin only)
+// @ID delete-user-external-id
+// @Tags admin
+// @Param username path string true "Username or external id"
+// @Param provider path string true "Provider"
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /admin/users/{username}/external-ids/{provider} [delete]
+func (h *UserExternalIdsApiHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.loadUser(w, r)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.userSrvc.DeleteExternalId(user, chi.URLParam(r, "provider"))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to delete external id", "userID", user.ID, "error", err)
+		return
+	}
+	if !deleted {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserExternalIdsApiHandler) loadUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	if principal := middlewares.GetPrincipal(r); principal == nil || !principal.IsAdmin {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(conf.ErrUnauthorized))
+		return nil, false
+	}
+
+	user, err := h.userSrvc.GetUserByRef(chi.URLParam(r, "username"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return nil, false
+	}
+	return user, true
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+const (
+	userInviteStatusCreated = "created"
+	userInviteStatusExists  = "exists"
+	userInviteStatusInvalid = "invalid"
+	userInviteStatusFailed  = "failed"
+)
+
+type userInviteVm struct {
+	Username   string `json:"username"`
+	Email      string `json:"email,omitempty"`
+	Status     string `json:"status"`
+	InviteLink string `json:"invite_link,omitempty"`
+	ApiKey     string `json:"api_key,omitempty"` // only works once the invite was accepted
+}
+
+type UserInvitesApiHandler struct {
+	config   *conf.Config
+	userSrvc services.IUserService
+}
+
+func NewUserInvitesApiHandler(userService services.IUserService) *UserInvitesApiHandler {
+	return &UserInvitesApiHandler{
+		config:   conf.Get(),
+		userSrvc: userService,
+	}
+}
+
+func (h *UserInvitesApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(
+		middlewares.NewBodyLimitMiddleware(1024*1024),
+		middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler,
+	)
+	r.Post("/", h.Post)
+
+	router.Mount("/admin/invites", r)
+}
+
+// @Summary Bulk-create invited users from csv, returning an invite link and a pre-issued api key for each of them (admin only)
+// @Description The csv needs a header row with a "username" and / or an "email" column. Pre-issued api keys start working once the user set a password via their invite link.
+// @ID post-user-invites
+// @Tags admin
+// @Accept text/csv
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} userInviteVm
+// @Router /admin/invites [post]
+func (h *UserInvitesApiHandler) Post(w http.ResponseWriter, r *http.Request) {
+	admin := middlewares.GetPrincipal(r)
+	if admin == nil || !admin.IsAdmin {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(conf.ErrUnauthorized))
+		return
+	}
+
+	invites, err := models.ParseInviteCsv(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	results := make([]*userInviteVm, 0, len(invites))
+	for _, invite := range invites {
+		results = append(results, h.invite(r, invite, admin))
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, results)
+}
+
+func (h *UserInvitesApiHandler) invite(r *http.Request, invite *models.Invite, admin *models.User) *userInviteVm {
+	result := &userInviteVm{Username: invite.Username, Email: invite.Email}
+
+	if !models.ValidateUsername(invite.Username) || !models.ValidateEmail(invite.Email) {
+		result.Status = userInviteStatusInvalid
+		return result
+	}
+	if invite.Email != "" {
+		if existing, err := h.userSrvc.GetUserByEmail(invite.Email); err == nil && existing != nil {
+			result.Status = userInviteStatusExists
+			return result
+		}
+	}
+
+	user, created, err := h.userSrvc.CreateInvited(invite, admin.ID)
+	if err != nil {
+		conf.Log().Request(r).Error("failed to create invited user", "username", invite.Username, "error", err)
+		result.Status = userInviteStatusFailed
+		return result
+	}
+	if !created {
+		result.Status = userInviteStatusExists
+		return result
+	}
+
+	conf.Log().Request(r).Info("created invited user", "username", user.ID, "admin", admin.ID)
+	result.Status = userInviteStatusCreated
+	result.InviteLink = fmt.Sprintf("%s/set-password?token=%s", h.config.Server.GetPublicUrl(), user.ResetToken)
+	result.ApiKey = user.ApiKey
+	return result
+}
@@ -0,0 +1,215 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type UserPreferencesApiHandler struct {
+	config             *conf.Config
+	userSrvc           services.IUserService
+	userPreferenceSrvc services.IUserPreferenceService
+}
+
+func NewUserPreferencesApiHandler(userService services.IUserService, userPreferenceService services.IUserPreferenceService) *UserPreferencesApiHandler {
+	return &UserPreferencesApiHandler{
+		config:             conf.Get(),
+		userSrvc:           userService,
+		userPreferenceSrvc: userPreferenceService,
+	}
+}
+
+func (h *UserPreferencesApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/{namespace}", h.GetAll)
+	r.Delete("/{namespace}", h.DeleteAll)
+	r.Get("/{namespace}/{key}", h.Get)
+	r.Put("/{namespace}/{key}", h.Put)
+	r.Delete("/{namespace}/{key}", h.Delete)
+
+	router.Mount("/preferences", r)
+}
+
+// @Summary Retrieve all of the authenticated user's preferences within a client's namespace
+// @Description Namespaces (e.g. vscode, web or cli) consist of up to 32 lowercase letters, digits, dashes and underscores. The response maps keys to their json values.
+// @ID get-preferences
+// @Tags preferences
+// @Produce json
+// @Param namespace path string true "Client namespace, e.g. vscode"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.UserPreferences
+// @Router /preferences/{namespace} [get]
+func (h *UserPreferencesApiHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+	namespace, ok := h.parseNamespace(w, r)
+	if !ok {
+		return
+	}
+
+	preferences, err := h.userPreferenceSrvc.GetByUserAndNamespace(user.ID, namespace)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve preferences", "userID", user.ID, "namespace", namespace, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, preferences)
+}
+
+// @Summary Retrieve a single preference's json value
+// @ID get-preference
+// @Tags preferences
+// @Produce json
+// @Param namespace path string true "Client namespace, e.g. vscode"
+// @Param key path string true "Preference key"
+// @Security ApiKeyAuth
+// @Success 200 {object} object
+// @Router /preferences/{namespace}/{key} [get]
+func (h *UserPreferencesApiHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+	namespace, ok := h.parseNamespace(w, r)
+	if !ok {
+		return
+	}
+
+	preferences, err := h.userPreferenceSrvc.GetByUserAndNamespace(user.ID, namespace)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve preferences", "userID", user.ID, "namespace", namespace, "error", err)
+		return
+	}
+
+	value, ok := preferences[chi.URLParam(r, "key")]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, value)
+}
+
+// @Summary Set a single preference to an arbitrary json value
+// @Description Values must not exceed 4 kb and a namespace holds at most 100 preferences.
+// @ID put-preference
+// @Tags preferences
+// @Accept json
+// @Produce json
+// @Param namespace path string true "Client namespace, e.g. vscode"
+// @Param key path string true "Preference key"
+// @Param value body object true "Any json value, e.g. {\"chart\": \"bar\", \"pinned\": [\"wakapi\"]}"
+// @Security ApiKeyAuth
+// @Success 200 {object} object
+// @Router /preferences/{namespace}/{key} [put]
+func (h *UserPreferencesApiHandler) Put(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+	namespace, ok := h.parseNamespace(w, r)
+	if !ok {
+		return
+	}
+
+	value, err := io.ReadAll(io.LimitReader(r.Body, models.MaxPreferenceValueLength+1))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	preference := &models.UserPreference{
+		UserID:    user.ID,
+		Namespace: namespace,
+		Key:       chi.URLParam(r, "key"),
+		Value:     string(value),
+	}
+	if !preference.IsValid() {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid preference, value must be valid json of at most 4 kb"))
+		return
+	}
+
+	result, err := h.userPreferenceSrvc.Set(preference)
+	if errors.Is(err, services.ErrTooManyPreferences) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to save preference", "userID", user.ID, "namespace", namespace, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, json.RawMessage(result.Value))
+}
+
+// @Summary Remove a single preference
+// @ID delete-preference
+// @Tags preferences
+// @Param namespace path string true "Client namespace, e.g. vscode"
+// @Param key path string true "Preference key"
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /preferences/{namespace}/{key} [delete]
+func (h *UserPreferencesApiHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+	namespace, ok := h.parseNamespace(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.userPreferenceSrvc.Delete(user.ID, namespace, chi.URLParam(r, "key")); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to delete preference", "userID", user.ID, "namespace", namespace, "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// @Summary Remove all of the authenticated user's preferences within a client's namespace
+// @ID delete-preferences
+// @Tags preferences
+// @Param namespace path string true "Client namespace, e.g. vscode"
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /preferences/{namespace} [delete]
+func (h *UserPreferencesApiHandler) DeleteAll(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+	namespace, ok := h.parseNamespace(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.userPreferenceSrvc.DeleteNamespace(user.ID, namespace); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to delete preferences", "userID", user.ID, "namespace", namespace, "error", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserPreferencesApiHandler) parseNamespace(w http.ResponseWriter, r *http.Request) (string, bool) {
+	namespace := chi.URLParam(r, "namespace")
+	if !models.IsValidPreferenceNamespace(namespace) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid namespace"))
+		return "", false
+	}
+	return namespace, true
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type UserProfileApiHandler struct {
+	config         *conf.Config
+	userSrvc       services.IUserService
+	userAvatarSrvc services.IUserAvatarService
+}
+
+func NewUserProfileApiHandler(userService services.IUserService, userAvatarService services.IUserAvatarService) *UserProfileApiHandler {
+	return &UserProfileApiHandler{
+		config:         conf.Get(),
+		userSrvc:       userService,
+		userAvatarSrvc: userAvatarService,
+	}
+}
+
+func (h *UserProfileApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.Get)
+	r.Put("/", h.Update)
+	r.Patch("/", h.Update)
+	r.Put("/avatar", h.PutAvatar)
+	r.Delete("/avatar", h.DeleteAvatar)
+
+	router.Mount("/users/current/profile", r)
+	router.Get("/avatar/uploads/{user}", h.GetAvatar)
+}
+
+// @Summary Retrieve the authenticated user's profile
+// @ID get-user-profile
+// @Tags users
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.UserProfile
+// @Router /users/current/profile [get]
+func (h *UserProfileApiHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+	helpers.RespondJSON(w, r, http.StatusOK, models.NewUserProfileFrom(user, h.avatarUrl(user)))
+}
+
+// @Summary Update the authenticated user's profile
+// @Description Only fields present in the request body are updated. An empty display name falls back to the username.
+// @ID update-user-profile
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param profile body models.UserProfileUpdate true "Profile fields to update"
+// @Security ApiKeyAuth
+// @Success 200 {object} models.UserProfile
+// @Router /users/current/profile [patch]
+func (h *UserProfileApiHandler) Update(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	var payload models.UserProfileUpdate
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	if err := payload.Apply(user); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	if _, err := h.userSrvc.Update(user); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to update user profile", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, models.NewUserProfileFrom(user, h.avatarUrl(user)))
+}
+
+// @Summary Upload an avatar for the authenticated user
+// @Description Expects the raw image as request body, either png, jpeg, gif or webp of at most 256 kb. The uploaded avatar becomes the active one.
+// @ID put-user-avatar
+// @Tags users
+// @Accept image/png
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} models.UserProfile
+// @Router /users/current/profile/avatar [put]
+func (h *UserProfileApiHandler) PutAvatar(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+
+	data, err := io.ReadAll(io.LimitReader(r.Body, models.MaxAvatarBytes+1))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	if err := h.userAvatarSrvc.Upload(user, data); err != nil {
+		if errors.Is(err, services.ErrAvatarInvalid) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to upload avatar", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, models.NewUserProfileFrom(user, h.avatarUrl(user)))
+}
+
+// @Summary Delete the authenticated user's uploaded avatar
+// @ID delete-user-avatar
+// @Tags users
+// @Security ApiKeyAuth
+// @Success 204
+// @Router /users/current/profile/avatar [delete]
+func (h *UserProfileApiHandler) DeleteAvatar(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+	if err := h.userAvatarSrvc.Delete(user); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to delete avatar", "userID", user.ID, "error", err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *UserProfileApiHandler) GetAvatar(w http.ResponseWriter, r *http.Request) {
+	avatar, err := h.userAvatarSrvc.GetByUser(chi.URLParam(r, "user"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return
+	}
+
+	w.Header().Set("Content-Type", avatar.ContentType)
+	w.Header().Set("Cache-Control", "max-age=2592000") // urls change upon every upload
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	w.Write(avatar.Data)
+}
+
+func (h *UserProfileApiHandler) avatarUrl(user *models.User) string {
+	avatarUrl := user.AvatarURL(h.config.App.AvatarURLTemplate)
+	if avatarUrl != "" && !strings.HasPrefix(avatarUrl, "http") {
+		avatarUrl = fmt.Sprintf("%s%s/%s", h.config.Server.GetPublicUrl(), h.config.Server.BasePath, avatarUrl)
+	}
+	return avatarUrl
+}
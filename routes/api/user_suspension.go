@@ -0,0 +1,155 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+)
+
+type userSuspensionVm struct {
+	Username    string             `json:"username"`
+	Suspended   bool               `json:"suspended"`
+	SuspendedAt *models.CustomTime `json:"suspended_at" swaggertype:"string" format:"date" example:"2006-01-02 15:04:05.000"`
+	Reason      string             `json:"reason"`
+}
+
+type userSuspensionRequest struct {
+	Reason string `json:"reason"`
+}
+
+type UserSuspensionApiHandler struct {
+	config   *conf.Config
+	userSrvc services.IUserService
+}
+
+func NewUserSuspensionApiHandler(userService services.IUserService) *UserSuspensionApiHandler {
+	return &UserSuspensionApiHandler{
+		config:   conf.Get(),
+		userSrvc: userService,
+	}
+}
+
+func (h *UserSuspensionApiHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+	r.Get("/", h.Get)
+	r.Put("/", h.Put)
+	r.Delete("/", h.Delete)
+
+	router.Mount("/admin/users/{username}/suspension", r)
+}
+
+// @Summary Retrieve whether a user is suspended (admin only)
+// @ID get-user-suspension
+// @Tags admin
+// @Produce json
+// @Param username path string true "Username"
+// @Security ApiKeyAuth
+// @Success 200 {object} userSuspensionVm
+// @Router /admin/users/{username}/suspension [get]
+func (h *UserSuspensionApiHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.loadUser(w, r)
+	if !ok {
+		return
+	}
+	helpers.RespondJSON(w, r, http.StatusOK, newUserSuspensionVm(user))
+}
+
+// @Summary Suspend a user, which blocks logins and heartbeats, but keeps all data (admin only)
+// @ID put-user-suspension
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param username path string true "Username"
+// @Param suspension body userSuspensionRequest false "Reason, which is shown to the user"
+// @Security ApiKeyAuth
+// @Success 200 {object} userSuspensionVm
+// @Router /admin/users/{username}/suspension [put]
+func (h *UserSuspensionApiHandler) Put(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.loadUser(w, r)
+	if !ok {
+		return
+	}
+
+	admin := middlewares.GetPrincipal(r)
+	if user.ID == admin.ID {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("you can't suspend yourself"))
+		return
+	}
+
+	var req userSuspensionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(conf.ErrBadRequest))
+			return
+		}
+	}
+
+	updated, err := h.userSrvc.Suspend(user, req.Reason, admin)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to suspend user", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, newUserSuspensionVm(updated))
+}
+
+// @Summary Lift a user's suspension (admin only)
+// @ID delete-user-suspension
+// @Tags admin
+// @Produce json
+// @Param username path string true "Username"
+// @Security ApiKeyAuth
+// @Success 200 {object} userSuspensionVm
+// @Router /admin/users/{username}/suspension [delete]
+func (h *UserSuspensionApiHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user, ok := h.loadUser(w, r)
+	if !ok {
+		return
+	}
+
+	updated, err := h.userSrvc.Unsuspend(user, middlewares.GetPrincipal(r))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to unsuspend user", "userID", user.ID, "error", err)
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, newUserSuspensionVm(updated))
+}
+
+func (h *UserSuspensionApiHandler) loadUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	if principal := middlewares.GetPrincipal(r); principal == nil || !principal.IsAdmin {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(conf.ErrUnauthorized))
+		return nil, false
+	}
+
+	user, err := h.userSrvc.GetUserByRef(chi.URLParam(r, "username"))
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return nil, false
+	}
+	return user, true
+}
+
+func newUserSuspensionVm(user *models.User) *userSuspensionVm {
+	return &userSuspensionVm{
+		Username:    user.ID,
+		Suspended:   user.IsSuspended(),
+		SuspendedAt: user.SuspendedAt,
+		Reason:      user.SuspensionReason,
+	}
+}
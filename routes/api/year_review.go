@@ -0,0 +1,158 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	routeutils "github.com/hackclub/hackatime/routes/utils"
+	"github.com/hackclub/hackatime/services"
+	"gorm.io/gorm"
+)
+
+type yearReviewVm struct {
+	*models.YearReview
+	ShareUrl string `json:"share_url"`
+	ImageUrl string `json:"image_url"`
+}
+
+type YearReviewApiHandler struct {
+	config         *conf.Config
+	userSrvc       services.IUserService
+	yearReviewSrvc services.IYearReviewService
+}
+
+func NewYearReviewApiHandler(userService services.IUserService, yearReviewService services.IYearReviewService) *YearReviewApiHandler {
+	return &YearReviewApiHandler{
+		config:         conf.Get(),
+		userSrvc:       userService,
+		yearReviewSrvc: yearReviewService,
+	}
+}
+
+func (h *YearReviewApiHandler) RegisterRoutes(router chi.Router) {
+	router.Group(func(r chi.Router) {
+		r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+		r.Get("/users/{user}/year-review/{year}", h.Get)
+	})
+	router.Get("/year-review/shared/{token}", h.GetShared)
+	router.Get("/year-review/shared/{token}/image", h.GetSharedImage)
+}
+
+// @Summary Retrieve a user's year in review
+// @Description Reviews of past years are generated every January. If one hasn't been generated yet, 202 is returned and the request should be retried later.
+// @ID get-year-review
+// @Tags records
+// @Produce json
+// @Param user path string true "Username (or current)"
+// @Param year path int true "Year, e.g. 2024"
+// @Security ApiKeyAuth
+// @Success 200 {object} yearReviewVm
+// @Success 202
+// @Router /users/{user}/year-review/{year} [get]
+func (h *YearReviewApiHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user, err := routeutils.CheckEffectiveUser(w, r, h.userSrvc, "current")
+	if err != nil {
+		return // response was already sent by util function
+	}
+
+	year, err := strconv.Atoi(chi.URLParam(r, "year"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid year"))
+		return
+	}
+
+	review, err := h.yearReviewSrvc.GetByUser(user, year)
+	if errors.Is(err, services.ErrYearNotCompleted) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve year review", "userID", user.ID, "year", year, "error", err)
+		return
+	}
+	if review == nil {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("year review is being generated, please try again later"))
+		return
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, h.newYearReviewVm(review))
+}
+
+// @Summary Retrieve a shared year in review
+// @ID get-shared-year-review
+// @Tags records
+// @Produce json
+// @Param token path string true "Share token"
+// @Success 200 {object} yearReviewVm
+// @Router /year-review/shared/{token} [get]
+func (h *YearReviewApiHandler) GetShared(w http.ResponseWriter, r *http.Request) {
+	review, ok := h.loadShared(w, r)
+	if !ok {
+		return
+	}
+	helpers.RespondJSON(w, r, http.StatusOK, h.newYearReviewVm(review))
+}
+
+// @Summary Retrieve a shared year in review as an image
+// @ID get-shared-year-review-image
+// @Tags records
+// @Produce png
+// @Param token path string true "Share token"
+// @Success 200 {file} file
+// @Router /year-review/shared/{token}/image [get]
+func (h *YearReviewApiHandler) GetSharedImage(w http.ResponseWriter, r *http.Request) {
+	review, ok := h.loadShared(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := h.yearReviewSrvc.RenderImage(review)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to render year review image", "year", review.Year, "error", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+func (h *YearReviewApiHandler) loadShared(w http.ResponseWriter, r *http.Request) (*models.YearReview, bool) {
+	review, err := h.yearReviewSrvc.GetByShareToken(chi.URLParam(r, "token"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(conf.ErrNotFound))
+		return nil, false
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve shared year review", "error", err)
+		return nil, false
+	}
+	return review, true
+}
+
+func (h *YearReviewApiHandler) newYearReviewVm(review *models.YearReview) *yearReviewVm {
+	shareUrl := fmt.Sprintf("%s/api/year-review/shared/%s", h.config.Server.GetPublicUrl(), review.ShareToken)
+	return &yearReviewVm{
+		YearReview: review,
+		ShareUrl:   shareUrl,
+		ImageUrl:   shareUrl + "/image",
+	}
+}
@@ -49,7 +49,7 @@ func (h *BadgeHandler) RegisterRoutes(router chi.Router) {
 // @Success 200 {object} v1.BadgeData
 // @Router /compat/shields/v1/{user}/{interval}/{filter} [get]
 func (h *BadgeHandler) Get(w http.ResponseWriter, r *http.Request) {
-	user, err := h.userSrvc.GetUserById(chi.URLParam(r, "user"))
+	user, err := h.userSrvc.GetUserByRef(chi.URLParam(r, "user"))
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		return
@@ -83,7 +83,7 @@ func (h *BadgeHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	vm := v1.NewBadgeDataFrom(summary)
+	vm := v1.NewBadgeDataFrom(summary, user)
 	h.cache.SetDefault(cacheKey, vm)
 	helpers.RespondJSON(w, r, http.StatusOK, vm)
 }
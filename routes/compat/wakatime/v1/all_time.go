@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"context"
 	"net/http"
 	"time"
 
@@ -31,7 +32,10 @@ func NewAllTimeHandler(userService services.IUserService, summaryService service
 
 func (h *AllTimeHandler) RegisterRoutes(router chi.Router) {
 	router.Group(func(r chi.Router) {
-		r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+		r.Use(
+			middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler,
+			middlewares.NewTimeoutMiddleware(h.config.Server.RequestTimeouts.GetSummary()),
+		)
 		r.Get("/compat/wakatime/v1/users/{user}/all_time_since_today", h.Get)
 	})
 }
@@ -51,18 +55,20 @@ func (h *AllTimeHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return // response was already sent by util function
 	}
 
-	summary, err, status := h.loadUserSummary(user, helpers.ParseSummaryFilters(r).WithSelectFilteredOnly())
+	summary, err, status := h.loadUserSummary(r.Context(), user, helpers.ParseSummaryFilters(r).WithSelectFilteredOnly())
 	if err != nil {
 		w.WriteHeader(status)
 		w.Write([]byte(err.Error()))
 		return
 	}
 
-	vm := v1.NewAllTimeFrom(summary)
+	vm := v1.NewAllTimeFrom(summary, user)
 	helpers.RespondJSON(w, r, http.StatusOK, vm)
 }
 
-func (h *AllTimeHandler) loadUserSummary(user *models.User, filters *models.Filters) (*models.Summary, error, int) {
+func (h *AllTimeHandler) loadUserSummary(ctx context.Context, user *models.User, filters *models.Filters) (*models.Summary, error, int) {
+	summarySrvc := services.SummaryServiceWithContext(h.summarySrvc, ctx)
+
 	summaryParams := &models.SummaryParams{
 		From:      time.Time{},
 		To:        time.Now(),
@@ -70,12 +76,12 @@ func (h *AllTimeHandler) loadUserSummary(user *models.User, filters *models.Filt
 		Recompute: false,
 	}
 
-	var retrieveSummary types.SummaryRetriever = h.summarySrvc.Retrieve
+	var retrieveSummary types.SummaryRetriever = summarySrvc.Retrieve
 	if summaryParams.Recompute {
-		retrieveSummary = h.summarySrvc.Summarize
+		retrieveSummary = summarySrvc.Summarize
 	}
 
-	summary, err := h.summarySrvc.Aliased(
+	summary, err := summarySrvc.Aliased(
 		summaryParams.From,
 		summaryParams.To,
 		summaryParams.User,
@@ -84,7 +90,7 @@ func (h *AllTimeHandler) loadUserSummary(user *models.User, filters *models.Filt
 		summaryParams.Recompute,
 	)
 	if err != nil {
-		return nil, err, http.StatusInternalServerError
+		return nil, err, routeutils.SummaryErrorStatus(err)
 	}
 
 	return summary, nil, http.StatusOK
@@ -0,0 +1,152 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	wakatime "github.com/hackclub/hackatime/models/compat/wakatime/v1"
+	routeutils "github.com/hackclub/hackatime/routes/utils"
+	"github.com/hackclub/hackatime/services"
+	"github.com/hackclub/hackatime/utils"
+)
+
+type ExternalDurationsHandler struct {
+	userSrvc        services.IUserService
+	timeEntrySrvc   services.ITimeEntryService
+	aggregationSrvc services.IAggregationService
+}
+
+func NewExternalDurationsHandler(userService services.IUserService, timeEntryService services.ITimeEntryService, aggregationService services.IAggregationService) *ExternalDurationsHandler {
+	return &ExternalDurationsHandler{
+		userSrvc:        userService,
+		timeEntrySrvc:   timeEntryService,
+		aggregationSrvc: aggregationService,
+	}
+}
+
+func (h *ExternalDurationsHandler) RegisterRoutes(router chi.Router) {
+	router.Group(func(r chi.Router) {
+		r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+		r.Get("/users/{user}/external_durations", h.Get)
+		r.Get("/v1/users/{user}/external_durations", h.Get)
+		r.Get("/compat/wakatime/v1/users/{user}/external_durations", h.Get)
+		r.Post("/users/{user}/external_durations", h.Post)
+		r.Post("/v1/users/{user}/external_durations", h.Post)
+		r.Post("/compat/wakatime/v1/users/{user}/external_durations", h.Post)
+	})
+}
+
+// @Summary Get the external durations of a user for the specified date
+// @ID get-external-durations
+// @Tags wakatime
+// @Produce json
+// @Param date query string true "Date"
+// @Param project query string false "Only durations of this project"
+// @Param user path string true "Username (or current)"
+// @Security ApiKeyAuth
+// @Success 200 {object} v1.ExternalDurationsViewModel
+// @Failure 400 {string} string "bad date"
+// @Router /compat/wakatime/v1/users/{user}/external_durations [get]
+func (h *ExternalDurationsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user, err := routeutils.CheckEffectiveUser(w, r, h.userSrvc, "current")
+	if err != nil {
+		return // response was already sent by util function
+	}
+
+	date, err := time.Parse(conf.SimpleDateFormat, r.URL.Query().Get("date"))
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("bad date"))
+		return
+	}
+
+	timezone := user.TZ()
+	rangeFrom, rangeTo := datetime.BeginOfDay(date.In(timezone)), datetime.EndOfDay(date.In(timezone))
+
+	entries, err := h.timeEntrySrvc.GetByUserWithin(user.ID, rangeFrom, rangeTo)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to retrieve external durations", "userID", user.ID, "error", err)
+		return
+	}
+
+	project := r.URL.Query().Get("project")
+	data := make([]*wakatime.ExternalDurationEntry, 0, len(entries))
+	for _, e := range entries {
+		if e.Type != models.TimeEntryTypeExternal || (project != "" && user.DisplayedProjectName(e.Project) != project) {
+			continue
+		}
+		data = append(data, wakatime.NewExternalDurationEntry(e, user))
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, &wakatime.ExternalDurationsViewModel{
+		Data:     data,
+		Start:    rangeFrom.UTC().Format(time.RFC3339),
+		End:      rangeTo.UTC().Format(time.RFC3339),
+		Timezone: timezone.String(),
+	})
+}
+
+// @Summary Push a block of time measured by a third-party integration, e.g. a meeting tracker or design tool
+// @Description External durations are merged into summaries under their category ("external", if none given) and the "external" source. Pushing a duration with an already known external_id replaces the previous one.
+// @ID post-external-duration
+// @Tags wakatime
+// @Accept json
+// @Produce json
+// @Param user path string true "Username (or current)"
+// @Param duration body v1.ExternalDurationEntry true "e.g. {\"external_id\": \"meeting-42\", \"entity\": \"Sprint planning\", \"type\": \"app\", \"category\": \"meeting\", \"start_time\": 1707296400, \"end_time\": 1707300000}"
+// @Security ApiKeyAuth
+// @Success 201 {object} v1.ExternalDurationViewModel
+// @Router /compat/wakatime/v1/users/{user}/external_durations [post]
+func (h *ExternalDurationsHandler) Post(w http.ResponseWriter, r *http.Request) {
+	user, err := routeutils.CheckEffectiveUser(w, r, h.userSrvc, "current")
+	if err != nil {
+		return // response was already sent by util function
+	}
+
+	var payload wakatime.ExternalDurationEntry
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(conf.ErrBadRequest))
+		return
+	}
+
+	entry := payload.ToTimeEntry(user.ID)
+	if !entry.IsValid() || entry.Note == "" || entry.FromTime.T().After(time.Now()) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid external duration"))
+		return
+	}
+
+	result, previous, err := h.timeEntrySrvc.PutExternal(entry)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to store external duration", "userID", user.ID, "error", err)
+		return
+	}
+
+	if previous != nil {
+		h.resummarize(user, previous)
+	}
+	h.resummarize(user, result)
+	helpers.RespondJSON(w, r, http.StatusCreated, &wakatime.ExternalDurationViewModel{Data: wakatime.NewExternalDurationEntry(result, user)})
+}
+
+// resummarize re-generates the already materialized summaries of past days the entry falls into
+func (h *ExternalDurationsHandler) resummarize(user *models.User, entry *models.TimeEntry) {
+	if !entry.FromTime.T().Before(utils.BeginOfToday(time.Local)) {
+		return
+	}
+	if _, err := h.aggregationSrvc.Resummarize(user, entry.FromTime.T(), entry.ToTime.T()); err != nil {
+		conf.Log().Warn("failed to re-generate summaries after external duration change", "userID", user.ID, "error", err)
+	}
+}
@@ -77,7 +77,7 @@ func (h *HeartbeatHandler) Get(w http.ResponseWriter, r *http.Request) {
 	}
 
 	res := HeartbeatsResult{
-		Data:     wakatime.HeartbeatsToCompat(heartbeats),
+		Data:     wakatime.HeartbeatsToCompat(heartbeats, user),
 		Start:    rangeFrom.UTC().Format(time.RFC3339),
 		End:      rangeTo.UTC().Format(time.RFC3339),
 		Timezone: timezone.String(),
@@ -0,0 +1,56 @@
+package v1
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHeartbeatHandler_Get_HideProjectNames(t *testing.T) {
+	config.Set(config.Empty())
+
+	router := chi.NewRouter()
+	apiRouter := chi.NewRouter()
+	apiRouter.Use(middlewares.NewPrincipalMiddleware())
+	router.Mount("/api", apiRouter)
+
+	user := *basicUser
+	user.HideProjectNames = true
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserById", "BasicUser").Return(&user, nil)
+	userServiceMock.On("GetUserByKey", "basic-user-api-key").Return(&user, nil)
+
+	heartbeatServiceMock := new(mocks.HeartbeatServiceMock)
+	heartbeatServiceMock.On("GetAllWithin", mock.Anything, mock.Anything, &user).Return([]*models.Heartbeat{
+		{UserID: user.ID, Project: "wakapi", Entity: "/home/user/wakapi/main.go", Branch: "main", Language: "Go", Time: models.CustomTime(time.Now())},
+	}, nil)
+
+	NewHeartbeatHandler(userServiceMock, heartbeatServiceMock).RegisterRoutes(apiRouter)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/compat/wakatime/v1/users/current/heartbeats?date=2024-01-01", nil)
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", base64.StdEncoding.EncodeToString([]byte(user.ApiKey))))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var res HeartbeatsResult
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&res))
+	assert.Len(t, res.Data, 1)
+	assert.Equal(t, user.AnonymizedProjectName("wakapi"), res.Data[0].Project)
+	assert.Empty(t, res.Data[0].Entity)
+	assert.Empty(t, res.Data[0].Branch)
+	assert.Equal(t, "Go", res.Data[0].Language)
+}
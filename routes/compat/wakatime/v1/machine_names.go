@@ -0,0 +1,68 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hackclub/hackatime/helpers"
+
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/middlewares"
+	v1 "github.com/hackclub/hackatime/models/compat/wakatime/v1"
+	routeutils "github.com/hackclub/hackatime/routes/utils"
+	"github.com/hackclub/hackatime/services"
+)
+
+type MachineNamesHandler struct {
+	config        *conf.Config
+	userSrvc      services.IUserService
+	heartbeatSrvc services.IHeartbeatService
+}
+
+func NewMachineNamesHandler(userService services.IUserService, heartbeatService services.IHeartbeatService) *MachineNamesHandler {
+	return &MachineNamesHandler{
+		userSrvc:      userService,
+		heartbeatSrvc: heartbeatService,
+		config:        conf.Get(),
+	}
+}
+
+func (h *MachineNamesHandler) RegisterRoutes(router chi.Router) {
+	router.Group(func(r chi.Router) {
+		r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+		r.Get("/compat/wakatime/v1/users/{user}/machine_names", h.Get)
+		r.Get("/v1/users/{user}/machine_names", h.Get)
+	})
+}
+
+// @Summary Retrieve the machines the user has sent heartbeats from
+// @Description Mimics https://wakatime.com/developers#machine_names
+// @ID get-wakatime-machine-names
+// @Tags wakatime
+// @Produce json
+// @Param user path string true "User ID to fetch data for (or 'current')"
+// @Security ApiKeyAuth
+// @Success 200 {object} v1.MachineViewModel
+// @Router /compat/wakatime/v1/users/{user}/machine_names [get]
+func (h *MachineNamesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user, err := routeutils.CheckEffectiveUser(w, r, h.userSrvc, "current")
+	if err != nil {
+		return // response was already sent by util function
+	}
+
+	results, err := h.heartbeatSrvc.GetMachineStats(user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("error occurred", "error", err)
+		return
+	}
+
+	machines := make([]*v1.MachineEntry, len(results))
+	for i, m := range results {
+		machines[i] = v1.NewMachineEntry(m)
+	}
+
+	vm := &v1.MachineViewModel{Data: machines, TotalPages: 1}
+	helpers.RespondJSON(w, r, http.StatusOK, vm)
+}
@@ -0,0 +1,56 @@
+package v1
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	v1 "github.com/hackclub/hackatime/models/compat/wakatime/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMachineNamesHandler_Get(t *testing.T) {
+	config.Set(config.Empty())
+
+	router := chi.NewRouter()
+	apiRouter := chi.NewRouter()
+	apiRouter.Use(middlewares.NewPrincipalMiddleware())
+	router.Mount("/api", apiRouter)
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserByKey", "basic-user-api-key").Return(basicUser, nil)
+
+	t0 := time.Date(2022, 2, 2, 22, 22, 22, 0, time.UTC)
+	heartbeatServiceMock := new(mocks.HeartbeatServiceMock)
+	heartbeatServiceMock.On("GetMachineStats", basicUser).Return([]*models.MachineStats{
+		{Machine: "laptop", First: models.CustomTime(t0), Last: models.CustomTime(t0.Add(time.Hour))},
+	}, nil)
+
+	NewMachineNamesHandler(userServiceMock, heartbeatServiceMock).RegisterRoutes(apiRouter)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/compat/wakatime/v1/users/{user}/machine_names", nil)
+	req = withUrlParam(req, "user", "current")
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", base64.StdEncoding.EncodeToString([]byte(basicUser.ApiKey))))
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var vm v1.MachineViewModel
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&vm))
+	assert.Equal(t, 1, vm.TotalPages)
+	assert.Len(t, vm.Data, 1)
+	assert.Equal(t, "laptop", vm.Data[0].Id) // must match machine_name_id of heartbeats
+	assert.Equal(t, "laptop", vm.Data[0].Value)
+	assert.True(t, vm.Data[0].CreatedAt.Equal(t0))
+	assert.True(t, vm.Data[0].LastSeen.Equal(t0.Add(time.Hour)))
+}
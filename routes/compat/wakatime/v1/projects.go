@@ -1,13 +1,18 @@
 package v1
 
 import (
+	"errors"
 	"net/http"
 	"net/url"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/hackclub/hackatime/models"
 
+	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/duke-git/lancet/v2/slice"
 	"github.com/go-chi/chi/v5"
 	"github.com/hackclub/hackatime/helpers"
 
@@ -19,17 +24,30 @@ import (
 	"github.com/hackclub/hackatime/utils"
 )
 
+const (
+	projectSortLastActive = "last_active"
+	projectSortName       = "name"
+	projectSortTotal      = "total"
+	projectSortCreated    = "created"
+)
+
+var projectSortKeys = []string{projectSortLastActive, projectSortName, projectSortTotal, projectSortCreated}
+
 type ProjectsHandler struct {
-	config        *conf.Config
-	userSrvc      services.IUserService
-	heartbeatSrvc services.IHeartbeatService
+	config              *conf.Config
+	userSrvc            services.IUserService
+	heartbeatSrvc       services.IHeartbeatService
+	summarySrvc         services.ISummaryService
+	projectMetadataSrvc services.IProjectMetadataService
 }
 
-func NewProjectsHandler(userService services.IUserService, heartbeatsService services.IHeartbeatService) *ProjectsHandler {
+func NewProjectsHandler(userService services.IUserService, heartbeatsService services.IHeartbeatService, summaryService services.ISummaryService, projectMetadataService services.IProjectMetadataService) *ProjectsHandler {
 	return &ProjectsHandler{
-		userSrvc:      userService,
-		heartbeatSrvc: heartbeatsService,
-		config:        conf.Get(),
+		userSrvc:            userService,
+		heartbeatSrvc:       heartbeatsService,
+		summarySrvc:         summaryService,
+		projectMetadataSrvc: projectMetadataService,
+		config:              conf.Get(),
 	}
 }
 
@@ -48,6 +66,14 @@ func (h *ProjectsHandler) RegisterRoutes(router chi.Router) {
 // @Produce json
 // @Param user path string true "User ID to fetch data for (or 'current')"
 // @Param q query string false "Query to filter projects by"
+// @Param include_archived query bool false "Whether to include archived projects"
+// @Param language query string false "Only include projects whose most used language is the given one"
+// @Param sort query string false "Attribute to sort projects by" Enums(last_active, name, total, created)
+// @Param order query string false "Sort order, defaults to descending, except for name" Enums(asc, desc)
+// @Param include_totals query bool false "Whether to include each project's total coding time within the given range (implied by sort=total or a range)"
+// @Param range query string false "Range interval identifier to compute totals for, defaults to all time" Enums(today, yesterday, week, month, year, 7_days, last_7_days, 30_days, last_30_days, 6_months, last_6_months, 12_months, last_12_months, last_year, any, all_time)
+// @Param start query string false "Start date to compute totals from (e.g. '2021-02-07')"
+// @Param end query string false "End date to compute totals until (inclusive, e.g. '2021-02-08')"
 // @Security ApiKeyAuth
 // @Success 200 {object} v1.ProjectsViewModel
 // @Router /compat/wakatime/v1/users/{user}/projects [get]
@@ -57,7 +83,24 @@ func (h *ProjectsHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return // response was already sent by util function
 	}
 
-	projects, err := h.loadProjects(user, r.URL.Query().Get("q"), false)
+	query := r.URL.Query()
+
+	sortBy := query.Get("sort")
+	if sortBy == "" {
+		sortBy = projectSortLastActive
+	}
+	if !slice.Contain(projectSortKeys, sortBy) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid 'sort' parameter"))
+		return
+	}
+	if order := query.Get("order"); order != "" && order != "asc" && order != "desc" {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("invalid 'order' parameter"))
+		return
+	}
+
+	projects, err := h.loadProjects(user, query.Get("q"), false)
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte("something went wrong"))
@@ -65,6 +108,35 @@ func (h *ProjectsHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if includeArchived := query.Get("include_archived"); includeArchived == "" || includeArchived == "false" {
+		projects = slice.Filter[*v1.Project](projects, func(i int, p *v1.Project) bool {
+			return !user.IsProjectArchived(p.Name)
+		})
+	}
+
+	if language := query.Get("language"); language != "" {
+		projects = slice.Filter[*v1.Project](projects, func(i int, p *v1.Project) bool {
+			return strings.EqualFold(p.TopLanguage, language)
+		})
+	}
+
+	if includeTotals, _ := strconv.ParseBool(query.Get("include_totals")); includeTotals || sortBy == projectSortTotal || query.Has("range") || query.Has("start") {
+		from, to, err := h.parseTotalsRange(user, query)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		if err := h.addTotals(user, projects, from, to); err != nil {
+			w.WriteHeader(routeutils.SummaryErrorStatus(err))
+			w.Write([]byte(conf.ErrInternalServerError))
+			conf.Log().Request(r).Error("failed to compute project totals", "userID", user.ID, "error", err)
+			return
+		}
+	}
+
+	sortProjects(projects, sortBy, query.Get("order"))
+
 	vm := &v1.ProjectsViewModel{Data: projects}
 	helpers.RespondJSON(w, r, http.StatusOK, vm)
 }
@@ -109,19 +181,110 @@ func (h *ProjectsHandler) loadProjects(user *models.User, q string, exact bool)
 		return nil, err
 	}
 
+	metadata, err := h.projectMetadataSrvc.GetByUserMapped(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
 	projects := make([]*v1.Project, 0, len(results))
 	for _, p := range results {
-		if (exact && p.Project == q) || (!exact && strings.HasPrefix(p.Project, q)) {
-			projects = append(projects, &v1.Project{
-				ID:                           p.Project,
-				Name:                         p.Project,
+		name := user.DisplayedProjectName(p.Project) // anonymized projects are also looked up by their anonymized name
+		if (exact && name == q) || (!exact && strings.HasPrefix(name, q)) {
+			project := &v1.Project{
+				ID:                           name,
+				Name:                         name,
 				LastHeartbeatAt:              p.Last.T(),
 				HumanReadableLastHeartbeatAt: helpers.FormatDateTimeHuman(p.Last.T()),
-				UrlencodedName:               url.QueryEscape(p.Project),
+				UrlencodedName:               url.QueryEscape(name),
 				CreatedAt:                    p.First.T(),
-			})
+				TopLanguage:                  p.TopLanguage,
+			}
+			if m, ok := metadata[p.Project]; ok {
+				project.Color, project.Icon, project.Description = m.Color, m.Icon, m.Description
+			}
+			if user.HideProjectNames {
+				project.Description = "" // likely to give away the project's name
+			}
+			projects = append(projects, project)
 		}
 	}
 
 	return projects, nil
 }
+
+func (h *ProjectsHandler) parseTotalsRange(user *models.User, query url.Values) (time.Time, time.Time, error) {
+	if rangeParam := query.Get("range"); rangeParam != "" {
+		err, from, to := helpers.ResolveIntervalRawTZWeekStart(rangeParam, user.TZ(), user.WeekStart())
+		if err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid 'range' parameter")
+		}
+		return from, to, nil
+	}
+
+	if query.Get("start") == "" {
+		err, from, to := helpers.ResolveIntervalTZ(models.IntervalAny, user.TZ())
+		return from, to, err
+	}
+
+	from, err := helpers.ParseDateTimeTZ(query.Get("start"), user.TZ())
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid 'start' parameter")
+	}
+	to := time.Now().In(user.TZ())
+	if query.Get("end") != "" {
+		if to, err = helpers.ParseDateTimeTZ(query.Get("end"), user.TZ()); err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid 'end' parameter")
+		}
+		to = datetime.EndOfDay(to) // end date is inclusive, just like for summaries
+	}
+	if !to.After(from) {
+		return time.Time{}, time.Time{}, errors.New("'end' date must be after 'start' date")
+	}
+	return from, to, nil
+}
+
+// addTotals sets each project's total coding time within the given range, based on the raw (non-aliased) summary, as project names are raw as well
+func (h *ProjectsHandler) addTotals(user *models.User, projects []*v1.Project, from, to time.Time) error {
+	summary, err := h.summarySrvc.Retrieve(from, to, user, nil)
+	if err != nil {
+		return err
+	}
+	totals := make(map[string]time.Duration, len(summary.Projects))
+	for _, item := range summary.Projects {
+		totals[user.DisplayedProjectName(item.Key)] += item.TotalFixed()
+	}
+	for _, p := range projects {
+		total := totals[p.Name]
+		seconds := total.Seconds()
+		p.TotalSeconds = &seconds
+		p.HumanReadableTotal = helpers.FmtUserDuration(total, user)
+	}
+	return nil
+}
+
+func sortProjects(projects []*v1.Project, sortBy, order string) {
+	asc := order == "asc" || (order == "" && sortBy == projectSortName)
+	sort.SliceStable(projects, func(i, j int) bool {
+		a, b := projects[i], projects[j]
+		if !asc {
+			a, b = b, a
+		}
+		switch sortBy {
+		case projectSortName:
+			return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+		case projectSortTotal:
+			return projectTotal(a) < projectTotal(b)
+		case projectSortCreated:
+			return a.CreatedAt.Before(b.CreatedAt)
+		default:
+			return a.LastHeartbeatAt.Before(b.LastHeartbeatAt)
+		}
+	})
+}
+
+func projectTotal(p *v1.Project) float64 {
+	if p.TotalSeconds == nil {
+		return 0
+	}
+	return *p.TotalSeconds
+}
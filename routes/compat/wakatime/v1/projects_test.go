@@ -0,0 +1,157 @@
+package v1
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	v1 "github.com/hackclub/hackatime/models/compat/wakatime/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestProjectsHandler_Get_SortAndFilter(t *testing.T) {
+	config.Set(config.Empty())
+
+	router := chi.NewRouter()
+	apiRouter := chi.NewRouter()
+	apiRouter.Use(middlewares.NewPrincipalMiddleware())
+	router.Mount("/api", apiRouter)
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserById", "BasicUser").Return(basicUser, nil)
+	userServiceMock.On("GetUserByKey", "basic-user-api-key").Return(basicUser, nil)
+
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	heartbeatServiceMock := new(mocks.HeartbeatServiceMock)
+	heartbeatServiceMock.On("GetUserProjectStats", basicUser, mock.Anything, mock.Anything, mock.Anything, false).Return([]*models.ProjectStats{
+		{Project: "wakapi", TopLanguage: "Go", First: models.CustomTime(t0), Last: models.CustomTime(t0.Add(72 * time.Hour))},
+		{Project: "anchr", TopLanguage: "JavaScript", First: models.CustomTime(t0.Add(24 * time.Hour)), Last: models.CustomTime(t0.Add(48 * time.Hour))},
+		{Project: "fritz", TopLanguage: "go", First: models.CustomTime(t0.Add(48 * time.Hour)), Last: models.CustomTime(t0.Add(24 * time.Hour))},
+	}, nil)
+
+	summaryServiceMock := new(mocks.SummaryServiceMock)
+	summaryServiceMock.On("Retrieve", mock.Anything, mock.Anything, basicUser, (*models.Filters)(nil)).Return(&models.Summary{
+		Projects: []*models.SummaryItem{
+			{Type: models.SummaryProject, Key: "wakapi", Total: 600},
+			{Type: models.SummaryProject, Key: "fritz", Total: 1200},
+		},
+	}, nil)
+
+	projectMetadataServiceMock := new(mocks.ProjectMetadataServiceMock)
+	projectMetadataServiceMock.On("GetByUserMapped", basicUser.ID).Return(map[string]*models.ProjectMetadata{}, nil)
+
+	NewProjectsHandler(userServiceMock, heartbeatServiceMock, summaryServiceMock, projectMetadataServiceMock).RegisterRoutes(apiRouter)
+
+	request := func(query string) (int, []*v1.Project) {
+		req := httptest.NewRequest(http.MethodGet, "/api/compat/wakatime/v1/users/current/projects?"+query, nil)
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", base64.StdEncoding.EncodeToString([]byte(basicUser.ApiKey))))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		var vm v1.ProjectsViewModel
+		json.NewDecoder(rec.Body).Decode(&vm)
+		return rec.Code, vm.Data
+	}
+
+	names := func(projects []*v1.Project) []string {
+		result := make([]string, len(projects))
+		for i, p := range projects {
+			result[i] = p.Name
+		}
+		return result
+	}
+
+	code, projects := request("")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"wakapi", "anchr", "fritz"}, names(projects))
+	assert.Nil(t, projects[0].TotalSeconds)
+
+	_, projects = request("sort=name")
+	assert.Equal(t, []string{"anchr", "fritz", "wakapi"}, names(projects))
+
+	_, projects = request("sort=created&order=asc")
+	assert.Equal(t, []string{"wakapi", "anchr", "fritz"}, names(projects))
+
+	_, projects = request("language=Go")
+	assert.Equal(t, []string{"wakapi", "fritz"}, names(projects))
+
+	_, projects = request("sort=total")
+	assert.Equal(t, []string{"fritz", "wakapi", "anchr"}, names(projects))
+	assert.Equal(t, 1200.0, *projects[0].TotalSeconds)
+	assert.Equal(t, 0.0, *projects[2].TotalSeconds)
+
+	_, projects = request("range=last_7_days&language=go&order=asc")
+	assert.Equal(t, []string{"fritz", "wakapi"}, names(projects))
+	assert.NotNil(t, projects[0].TotalSeconds)
+
+	code, _ = request("sort=foo")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = request("start=2024-02-01&end=2024-01-01")
+	assert.Equal(t, http.StatusBadRequest, code)
+}
+
+func TestProjectsHandler_Get_HideProjectNames(t *testing.T) {
+	config.Set(config.Empty())
+
+	router := chi.NewRouter()
+	apiRouter := chi.NewRouter()
+	apiRouter.Use(middlewares.NewPrincipalMiddleware())
+	router.Mount("/api", apiRouter)
+
+	user := *basicUser
+	user.HideProjectNames = true
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserById", "BasicUser").Return(&user, nil)
+	userServiceMock.On("GetUserByKey", "basic-user-api-key").Return(&user, nil)
+
+	heartbeatServiceMock := new(mocks.HeartbeatServiceMock)
+	heartbeatServiceMock.On("GetUserProjectStats", &user, mock.Anything, mock.Anything, mock.Anything, false).Return([]*models.ProjectStats{
+		{Project: "wakapi", TopLanguage: "Go"},
+	}, nil)
+
+	summaryServiceMock := new(mocks.SummaryServiceMock)
+	summaryServiceMock.On("Retrieve", mock.Anything, mock.Anything, &user, (*models.Filters)(nil)).Return(&models.Summary{
+		Projects: []*models.SummaryItem{{Type: models.SummaryProject, Key: "wakapi", Total: 600}},
+	}, nil)
+
+	projectMetadataServiceMock := new(mocks.ProjectMetadataServiceMock)
+	projectMetadataServiceMock.On("GetByUserMapped", user.ID).Return(map[string]*models.ProjectMetadata{
+		"wakapi": {Color: "#00ff00", Description: "the wakapi server"},
+	}, nil)
+
+	NewProjectsHandler(userServiceMock, heartbeatServiceMock, summaryServiceMock, projectMetadataServiceMock).RegisterRoutes(apiRouter)
+
+	request := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/compat/wakatime/v1/users/current/projects"+path, nil)
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", base64.StdEncoding.EncodeToString([]byte(user.ApiKey))))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	anonymized := user.AnonymizedProjectName("wakapi")
+	assert.Regexp(t, "^project-[0-9a-f]{8}$", anonymized)
+
+	var vm v1.ProjectsViewModel
+	json.NewDecoder(request("?include_totals=true").Body).Decode(&vm)
+	assert.Len(t, vm.Data, 1)
+	assert.Equal(t, anonymized, vm.Data[0].Name)
+	assert.Equal(t, anonymized, vm.Data[0].ID)
+	assert.Equal(t, 600.0, *vm.Data[0].TotalSeconds)
+	assert.Equal(t, "#00ff00", vm.Data[0].Color)
+	assert.Empty(t, vm.Data[0].Description)
+
+	assert.Equal(t, http.StatusOK, request("/"+anonymized).Code)
+	assert.Equal(t, http.StatusNotFound, request("/wakapi").Code)
+}
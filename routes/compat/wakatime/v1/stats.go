@@ -1,6 +1,8 @@
 package v1
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"time"
 
@@ -11,20 +13,23 @@ import (
 	"github.com/hackclub/hackatime/middlewares"
 	"github.com/hackclub/hackatime/models"
 	v1 "github.com/hackclub/hackatime/models/compat/wakatime/v1"
+	routeutils "github.com/hackclub/hackatime/routes/utils"
 	"github.com/hackclub/hackatime/services"
 )
 
 type StatsHandler struct {
-	config      *conf.Config
-	userSrvc    services.IUserService
-	summarySrvc services.ISummaryService
+	config        *conf.Config
+	userSrvc      services.IUserService
+	summarySrvc   services.ISummaryService
+	heartbeatSrvc services.IHeartbeatService
 }
 
-func NewStatsHandler(userService services.IUserService, summaryService services.ISummaryService) *StatsHandler {
+func NewStatsHandler(userService services.IUserService, summaryService services.ISummaryService, heartbeatService services.IHeartbeatService) *StatsHandler {
 	return &StatsHandler{
-		userSrvc:    userService,
-		summarySrvc: summaryService,
-		config:      conf.Get(),
+		userSrvc:      userService,
+		summarySrvc:   summaryService,
+		heartbeatSrvc: heartbeatService,
+		config:        conf.Get(),
 	}
 }
 
@@ -32,6 +37,7 @@ func (h *StatsHandler) RegisterRoutes(router chi.Router) {
 	router.Group(func(r chi.Router) {
 		r.Use(
 			middlewares.NewAuthenticateMiddleware(h.userSrvc).WithOptionalFor("/").Handler,
+			middlewares.NewTimeoutMiddleware(h.config.Server.RequestTimeouts.GetStats()),
 		)
 		r.Get("/v1/users/{user}/stats/{range}", h.Get)
 		r.Get("/compat/wakatime/v1/users/{user}/stats/{range}", h.Get)
@@ -57,6 +63,7 @@ func (h *StatsHandler) RegisterRoutes(router chi.Router) {
 // @Param operating_system query string false "OS to filter by"
 // @Param machine query string false "Machine to filter by"
 // @Param label query string false "Project label to filter by"
+// @Param source query string false "Comma-separated heartbeat sources to filter by (plugin, import, backfill, synthesized)"
 // @Security ApiKeyAuth
 // @Success 200 {object} v1.StatsViewModel
 // @Router /compat/wakatime/v1/users/{user}/stats/{range} [get]
@@ -70,7 +77,7 @@ func (h *StatsHandler) Get(w http.ResponseWriter, r *http.Request) {
 		userParam = authorizedUser.ID
 	}
 
-	requestedUser, err := h.userSrvc.GetUserById(userParam)
+	requestedUser, err := h.userSrvc.GetUserByRef(userParam)
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
 		w.Write([]byte("user not found"))
@@ -102,14 +109,26 @@ func (h *StatsHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	summary, err, status := h.loadUserSummary(requestedUser, rangeFrom, rangeTo, helpers.ParseSummaryFilters(r))
+	filters := helpers.ParseSummaryFilters(r)
+	if err := resolveProjectFilter(filters, requestedUser, h.heartbeatSrvc); errors.Is(err, errProjectNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(err.Error()))
+		return
+	} else if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("failed to resolve project filter", "userID", requestedUser.ID, "error", err)
+		return
+	}
+
+	summary, err, status := h.loadUserSummary(r.Context(), requestedUser, rangeFrom, rangeTo, filters)
 	if err != nil {
 		w.WriteHeader(status)
 		w.Write([]byte(err.Error()))
 		return
 	}
 
-	stats := v1.NewStatsFrom(summary, &models.Filters{})
+	stats := v1.NewStatsFrom(summary, &models.Filters{}, requestedUser)
 	stats.Data.Range = rangeParam
 	stats.Data.HumanReadableRange = helpers.MustParseInterval(rangeParam).GetHumanReadable()
 	stats.Data.IsCodingActivityVisible = requestedUser.ShareDataMaxDays != 0
@@ -137,7 +156,9 @@ func (h *StatsHandler) Get(w http.ResponseWriter, r *http.Request) {
 	helpers.RespondJSON(w, r, http.StatusOK, stats)
 }
 
-func (h *StatsHandler) loadUserSummary(user *models.User, start, end time.Time, filters *models.Filters) (*models.Summary, error, int) {
+func (h *StatsHandler) loadUserSummary(ctx context.Context, user *models.User, start, end time.Time, filters *models.Filters) (*models.Summary, error, int) {
+	summarySrvc := services.SummaryServiceWithContext(h.summarySrvc, ctx)
+
 	overallParams := &models.SummaryParams{
 		From:      start,
 		To:        end,
@@ -145,9 +166,9 @@ func (h *StatsHandler) loadUserSummary(user *models.User, start, end time.Time,
 		Recompute: false,
 	}
 
-	summary, err := h.summarySrvc.Aliased(overallParams.From, overallParams.To, user, h.summarySrvc.Retrieve, filters, false)
+	summary, err := summarySrvc.Aliased(overallParams.From, overallParams.To, user, summarySrvc.Retrieve, filters, false)
 	if err != nil {
-		return nil, err, http.StatusInternalServerError
+		return nil, err, routeutils.SummaryErrorStatus(err)
 	}
 
 	return summary, nil, http.StatusOK
@@ -1,7 +1,14 @@
 package v1
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -14,17 +21,28 @@ import (
 	v1 "github.com/hackclub/hackatime/models/compat/wakatime/v1"
 	routeutils "github.com/hackclub/hackatime/routes/utils"
 	"github.com/hackclub/hackatime/services"
+	"github.com/patrickmn/go-cache"
 )
 
+const statusBarDeltaRetention = 15 * time.Minute
+
 type StatusBarViewModel struct {
 	CachedAt time.Time        `json:"cached_at"`
 	Data     v1.SummariesData `json:"data"`
 }
 
+// StatusBarDeltaViewModel only contains those parts of the statusbar data, which changed compared to the version referenced by If-None-Match
+type StatusBarDeltaViewModel struct {
+	CachedAt time.Time                  `json:"cached_at"`
+	Delta    bool                       `json:"delta"`
+	Data     map[string]json.RawMessage `json:"data" swaggertype:"object"`
+}
+
 type StatusBarHandler struct {
 	config      *conf.Config
 	userSrvc    services.IUserService
 	summarySrvc services.ISummaryService
+	cache       *cache.Cache // previously served statusbar data by etag, to compute deltas against
 }
 
 func NewStatusBarHandler(userService services.IUserService, summaryService services.ISummaryService) *StatusBarHandler {
@@ -32,12 +50,16 @@ func NewStatusBarHandler(userService services.IUserService, summaryService servi
 		userSrvc:    userService,
 		summarySrvc: summaryService,
 		config:      conf.Get(),
+		cache:       cache.New(statusBarDeltaRetention, statusBarDeltaRetention),
 	}
 }
 
 func (h *StatusBarHandler) RegisterRoutes(router chi.Router) {
 	router.Group(func(r chi.Router) {
-		r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+		r.Use(
+			middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler,
+			middlewares.NewTimeoutMiddleware(h.config.Server.RequestTimeouts.GetSummary()),
+		)
 		r.Get("/users/{user}/statusbar/{range}", h.Get)
 		r.Get("/v1/users/{user}/statusbar/{range}", h.Get)
 		r.Get("/compat/wakatime/v1/users/{user}/statusbar/{range}", h.Get)
@@ -46,12 +68,17 @@ func (h *StatusBarHandler) RegisterRoutes(router chi.Router) {
 
 // @Summary Retrieve summary for statusbar
 // @Description Mimics https://wakatime.com/api/v1/users/current/statusbar/today. Have no official documentation
+// @Description Responses carry an ETag. If it is sent back via If-None-Match and nothing has changed since, 304 is returned without computing the summary again.
+// @Description With delta=true, only the parts of the data that changed compared to the version referenced by If-None-Match are returned.
 // @ID statusbar
 // @Tags wakatime
 // @Produce json
 // @Param user path string true "User ID to fetch data for (or 'current')"
+// @Param If-None-Match header string false "ETag of a previous response"
+// @Param delta query bool false "Whether to only return changed parts of the data"
 // @Security ApiKeyAuth
 // @Success 200 {object} StatusBarViewModel
+// @Success 304
 // @Router /users/{user}/statusbar/today [get]
 func (h *StatusBarHandler) Get(w http.ResponseWriter, r *http.Request) {
 	user, err := routeutils.CheckEffectiveUser(w, r, h.userSrvc, "current")
@@ -71,20 +98,98 @@ func (h *StatusBarHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	summary, status, err := h.loadUserSummary(user, rangeFrom, rangeTo)
+	// polling clients will mostly ask for the same data again, so skip computing the summary, unless the user's data has changed since
+	etag := h.getETag(user, rangeParam, rangeFrom)
+	previousEtag := strings.TrimSpace(r.Header.Get("If-None-Match"))
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "private, no-cache")
+
+	if etagMatches(previousEtag, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	summary, status, err := h.loadUserSummary(r.Context(), user, rangeFrom, rangeTo)
 	if err != nil {
+		w.Header().Del("ETag")
 		w.WriteHeader(status)
 		w.Write([]byte(err.Error()))
 		return
 	}
-	summariesView := v1.NewSummariesFrom([]*models.Summary{summary})
-	helpers.RespondJSON(w, r, http.StatusOK, StatusBarViewModel{
+	summariesView := v1.NewSummariesFrom([]*models.Summary{summary}, user)
+	vm := StatusBarViewModel{
 		CachedAt: time.Now(),
 		Data:     *summariesView.Data[0],
-	})
+	}
+
+	parts, err := splitStatusBarData(&vm.Data)
+	if err != nil {
+		conf.Log().Request(r).Error("failed to serialize statusbar data", "error", err)
+		helpers.RespondJSON(w, r, http.StatusOK, vm)
+		return
+	}
+	h.cache.SetDefault(etag, parts)
+
+	if delta, _ := strconv.ParseBool(r.URL.Query().Get("delta")); delta && previousEtag != "" {
+		if previous, ok := h.cache.Get(previousEtag); ok {
+			helpers.RespondJSON(w, r, http.StatusOK, StatusBarDeltaViewModel{
+				CachedAt: vm.CachedAt,
+				Delta:    true,
+				Data:     diffStatusBarData(previous.(map[string]json.RawMessage), parts),
+			})
+			return
+		}
+	}
+
+	helpers.RespondJSON(w, r, http.StatusOK, vm)
 }
 
-func (h *StatusBarHandler) loadUserSummary(user *models.User, start, end time.Time) (*models.Summary, int, error) {
+func (h *StatusBarHandler) getETag(user *models.User, rangeParam string, from time.Time) string {
+	// display preferences are part of the etag, as they affect the response's text fields and project names
+	hash := sha1.Sum([]byte(fmt.Sprintf("%s|%s|%d|%d|%d|%s|%t", user.ID, rangeParam, from.Unix(), h.summarySrvc.GetVersion(user.ID), user.DurationRoundingMin, user.DurationFormat, user.HideProjectNames)))
+	return fmt.Sprintf("W/\"%x\"", hash[:10])
+}
+
+// etagMatches performs a weak comparison of the given If-None-Match header against the current etag
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// splitStatusBarData serializes every top-level field of the data separately, so it can be compared field by field later on
+func splitStatusBarData(data *v1.SummariesData) (map[string]json.RawMessage, error) {
+	serialized, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var parts map[string]json.RawMessage
+	if err := json.Unmarshal(serialized, &parts); err != nil {
+		return nil, err
+	}
+	return parts, nil
+}
+
+func diffStatusBarData(previous, current map[string]json.RawMessage) map[string]json.RawMessage {
+	changed := make(map[string]json.RawMessage)
+	for k, v := range current {
+		if p, ok := previous[k]; !ok || !bytes.Equal(p, v) {
+			changed[k] = v
+		}
+	}
+	return changed
+}
+
+func (h *StatusBarHandler) loadUserSummary(ctx context.Context, user *models.User, start, end time.Time) (*models.Summary, int, error) {
+	summarySrvc := services.SummaryServiceWithContext(h.summarySrvc, ctx)
+
 	summaryParams := &models.SummaryParams{
 		From:      start,
 		To:        end,
@@ -92,14 +197,14 @@ func (h *StatusBarHandler) loadUserSummary(user *models.User, start, end time.Ti
 		Recompute: false,
 	}
 
-	var retrieveSummary types.SummaryRetriever = h.summarySrvc.Retrieve
+	var retrieveSummary types.SummaryRetriever = summarySrvc.Retrieve
 	if summaryParams.Recompute {
-		retrieveSummary = h.summarySrvc.Summarize
+		retrieveSummary = summarySrvc.Summarize
 	}
 
-	summary, err := h.summarySrvc.Aliased(summaryParams.From, summaryParams.To, summaryParams.User, retrieveSummary, nil, summaryParams.Recompute)
+	summary, err := summarySrvc.Aliased(summaryParams.From, summaryParams.To, summaryParams.User, retrieveSummary, nil, summaryParams.Recompute)
 	if err != nil {
-		return nil, http.StatusInternalServerError, err
+		return nil, routeutils.SummaryErrorStatus(err), err
 	}
 
 	return summary, http.StatusOK, nil
@@ -0,0 +1,89 @@
+package v1
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStatusBarHandler_Get_ETag(t *testing.T) {
+	config.Set(config.Empty())
+
+	router := chi.NewRouter()
+	apiRouter := chi.NewRouter()
+	apiRouter.Use(middlewares.NewPrincipalMiddleware())
+	router.Mount("/api", apiRouter)
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserById", "BasicUser").Return(basicUser, nil)
+	userServiceMock.On("GetUserByKey", "basic-user-api-key").Return(basicUser, nil)
+
+	summary := func(projectTime time.Duration) *models.Summary {
+		return &models.Summary{
+			User:      basicUser,
+			UserID:    basicUser.ID,
+			FromTime:  models.CustomTime(time.Now().Add(-1 * time.Hour)),
+			ToTime:    models.CustomTime(time.Now()),
+			Projects:  []*models.SummaryItem{{Type: models.SummaryProject, Key: "wakapi", Total: projectTime / time.Second}},
+			Languages: []*models.SummaryItem{{Type: models.SummaryLanguage, Key: "Go", Total: 1800}},
+		}
+	}
+
+	summaryServiceMock := new(mocks.SummaryServiceMock)
+	summaryServiceMock.On("GetVersion", basicUser.ID).Return(int64(1)).Times(3)
+	summaryServiceMock.On("GetVersion", basicUser.ID).Return(int64(2))
+	summaryServiceMock.On("Aliased", mock.Anything, mock.Anything, basicUser, mock.Anything, mock.Anything).Return(summary(30*time.Minute), nil).Once()
+	summaryServiceMock.On("Aliased", mock.Anything, mock.Anything, basicUser, mock.Anything, mock.Anything).Return(summary(45*time.Minute), nil).Once()
+
+	NewStatusBarHandler(userServiceMock, summaryServiceMock).RegisterRoutes(apiRouter)
+
+	request := func(ifNoneMatch string, delta bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/api/users/current/statusbar/today?delta=%v", delta), nil)
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", base64.StdEncoding.EncodeToString([]byte(basicUser.ApiKey))))
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// initial request
+	rec := request("", false)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	// unchanged
+	rec = request(etag, false)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	rec = request(fmt.Sprintf(`"foo", %s`, etag), true)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+
+	// changed, delta against previous response
+	rec = request(etag, true)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+
+	var result StatusBarDeltaViewModel
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&result))
+	assert.True(t, result.Delta)
+	assert.Contains(t, result.Data, "projects")
+	assert.Contains(t, result.Data, "grand_total")
+	assert.NotContains(t, result.Data, "languages")
+
+	summaryServiceMock.AssertNumberOfCalls(t, "Aliased", 2)
+}
@@ -19,28 +19,35 @@ import (
 	"github.com/hackclub/hackatime/utils"
 )
 
+var errProjectNotFound = errors.New("project not found")
+
 type SummariesHandler struct {
-	config      *conf.Config
-	userSrvc    services.IUserService
-	summarySrvc services.ISummaryService
+	config        *conf.Config
+	userSrvc      services.IUserService
+	summarySrvc   services.ISummaryService
+	heartbeatSrvc services.IHeartbeatService
 }
 
-func NewSummariesHandler(userService services.IUserService, summaryService services.ISummaryService) *SummariesHandler {
+func NewSummariesHandler(userService services.IUserService, summaryService services.ISummaryService, heartbeatService services.IHeartbeatService) *SummariesHandler {
 	return &SummariesHandler{
-		userSrvc:    userService,
-		summarySrvc: summaryService,
-		config:      conf.Get(),
+		userSrvc:      userService,
+		summarySrvc:   summaryService,
+		heartbeatSrvc: heartbeatService,
+		config:        conf.Get(),
 	}
 }
 
 func (h *SummariesHandler) RegisterRoutes(router chi.Router) {
 	router.Group(func(r chi.Router) {
-		r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+		r.Use(
+			middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler,
+			middlewares.NewTimeoutMiddleware(h.config.Server.RequestTimeouts.GetSummary()),
+		)
 		r.Get("/compat/wakatime/v1/users/{user}/summaries", h.Get)
 	})
 }
 
-// TODO: Support parameters: project, branches, timeout, writes_only
+// TODO: Support parameters: timeout, writes_only
 // See https://wakatime.com/developers#summaries.
 // Timezone can be specified via an offset suffix (e.g. +02:00) in date strings.
 // Requires https://github.com/muety/wakapi/issues/108.
@@ -54,12 +61,14 @@ func (h *SummariesHandler) RegisterRoutes(router chi.Router) {
 // @Param range query string false "Range interval identifier" Enums(today, yesterday, week, month, year, 7_days, last_7_days, 30_days, last_30_days, 6_months, last_6_months, 12_months, last_12_months, last_year, any, all_time)
 // @Param start query string false "Start date (e.g. '2021-02-07')"
 // @Param end query string false "End date (e.g. '2021-02-08')"
-// @Param project query string false "Project to filter by"
+// @Param project query string false "Project to filter by, also includes the project's branches and entities in the response"
+// @Param branches query string false "Comma-separated branches to filter by"
 // @Param language query string false "Language to filter by"
 // @Param editor query string false "Editor to filter by"
 // @Param operating_system query string false "OS to filter by"
 // @Param machine query string false "Machine to filter by"
 // @Param label query string false "Project label to filter by"
+// @Param source query string false "Comma-separated heartbeat sources to filter by (plugin, import, backfill, synthesized)"
 // @Security ApiKeyAuth
 // @Success 200 {object} v1.SummariesViewModel
 // @Router /compat/wakatime/v1/users/{user}/summaries [get]
@@ -76,7 +85,7 @@ func (h *SummariesHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	vm := v1.NewSummariesFrom(summaries)
+	vm := v1.NewSummariesFrom(summaries, user)
 	helpers.RespondJSON(w, r, http.StatusOK, vm)
 }
 
@@ -138,11 +147,17 @@ func (h *SummariesHandler) loadUserSummaries(r *http.Request, user *models.User)
 
 	// filtering
 	filters := helpers.ParseSummaryFilters(r)
+	if err := resolveProjectFilter(filters, user, h.heartbeatSrvc); errors.Is(err, errProjectNotFound) {
+		return nil, err, http.StatusNotFound
+	} else if err != nil {
+		return nil, errors.New(conf.ErrInternalServerError), http.StatusInternalServerError
+	}
 
+	summarySrvc := services.SummaryServiceWithContext(h.summarySrvc, r.Context())
 	for i, interval := range intervals {
-		summary, err := h.summarySrvc.Aliased(interval[0], interval[1], user, h.summarySrvc.Retrieve, filters, end.After(time.Now()))
+		summary, err := summarySrvc.Aliased(interval[0], interval[1], user, summarySrvc.Retrieve, filters, end.After(time.Now()))
 		if err != nil {
-			return nil, err, http.StatusInternalServerError
+			return nil, err, routeutils.SummaryErrorStatus(err)
 		}
 		// wakatime returns requested instead of actual summary range
 		summary.FromTime = models.CustomTime(interval[0])
@@ -152,3 +167,24 @@ func (h *SummariesHandler) loadUserSummaries(r *http.Request, user *models.User)
 
 	return summaries, nil, http.StatusOK
 }
+
+// resolveProjectFilter maps anonymized project names in the filters back to the actual ones, if the user opted to hide project names
+func resolveProjectFilter(filters *models.Filters, user *models.User, heartbeatSrvc services.IHeartbeatService) error {
+	if !user.HideProjectNames || !filters.Project.Exists() {
+		return nil
+	}
+
+	projects, err := heartbeatSrvc.GetEntitySetByUser(models.SummaryProject, user.ID)
+	if err != nil {
+		return err
+	}
+
+	for i, p := range filters.Project {
+		resolved, ok := user.ResolveProjectName(p, projects)
+		if !ok {
+			return errProjectNotFound
+		}
+		filters.Project[i] = resolved
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package v1
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	v1 "github.com/hackclub/hackatime/models/compat/wakatime/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSummariesHandler_Get_HideProjectNames(t *testing.T) {
+	config.Set(config.Empty())
+
+	router := chi.NewRouter()
+	apiRouter := chi.NewRouter()
+	apiRouter.Use(middlewares.NewPrincipalMiddleware())
+	router.Mount("/api", apiRouter)
+
+	user := *basicUser
+	user.HideProjectNames = true
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserById", "BasicUser").Return(&user, nil)
+	userServiceMock.On("GetUserByKey", "basic-user-api-key").Return(&user, nil)
+
+	heartbeatServiceMock := new(mocks.HeartbeatServiceMock)
+	heartbeatServiceMock.On("GetEntitySetByUser", models.SummaryProject, user.ID).Return([]string{"wakapi", "secret"}, nil)
+
+	summaryServiceMock := new(mocks.SummaryServiceMock)
+	summaryServiceMock.On("Aliased", mock.Anything, mock.Anything, &user, mock.Anything, mock.MatchedBy(func(f *models.Filters) bool {
+		return len(f.Project) == 1 && f.Project[0] == "wakapi"
+	})).Return(&models.Summary{
+		Projects: []*models.SummaryItem{{Type: models.SummaryProject, Key: "wakapi", Total: 600}},
+		Branches: []*models.SummaryItem{{Type: models.SummaryBranch, Key: "feature/wakapi-login", Total: 600}},
+		Entities: []*models.SummaryItem{{Type: models.SummaryEntity, Key: "/home/user/wakapi/main.go", Total: 600}},
+	}, nil)
+
+	NewSummariesHandler(userServiceMock, summaryServiceMock, heartbeatServiceMock).RegisterRoutes(apiRouter)
+
+	request := func(project string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/compat/wakatime/v1/users/current/summaries?start=2024-01-01&end=2024-01-01&project="+project, nil)
+		req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", base64.StdEncoding.EncodeToString([]byte(user.ApiKey))))
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// anonymized name is mapped back to the actual project
+	rec := request(user.AnonymizedProjectName("wakapi"))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var vm v1.SummariesViewModel
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&vm))
+	assert.Len(t, vm.Data, 1)
+	assert.Equal(t, user.AnonymizedProjectName("wakapi"), vm.Data[0].Projects[0].Name)
+	assert.NotNil(t, vm.Data[0].Branches)
+	assert.Empty(t, vm.Data[0].Branches)
+	assert.NotNil(t, vm.Data[0].Entities)
+	assert.Empty(t, vm.Data[0].Entities)
+
+	// actual names can't be used to probe for projects
+	assert.Equal(t, http.StatusNotFound, request("wakapi").Code)
+	summaryServiceMock.AssertNumberOfCalls(t, "Aliased", 1)
+}
@@ -0,0 +1,68 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hackclub/hackatime/helpers"
+
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/middlewares"
+	v1 "github.com/hackclub/hackatime/models/compat/wakatime/v1"
+	routeutils "github.com/hackclub/hackatime/routes/utils"
+	"github.com/hackclub/hackatime/services"
+)
+
+type UserAgentsHandler struct {
+	config        *conf.Config
+	userSrvc      services.IUserService
+	heartbeatSrvc services.IHeartbeatService
+}
+
+func NewUserAgentsHandler(userService services.IUserService, heartbeatService services.IHeartbeatService) *UserAgentsHandler {
+	return &UserAgentsHandler{
+		userSrvc:      userService,
+		heartbeatSrvc: heartbeatService,
+		config:        conf.Get(),
+	}
+}
+
+func (h *UserAgentsHandler) RegisterRoutes(router chi.Router) {
+	router.Group(func(r chi.Router) {
+		r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).Handler)
+		r.Get("/compat/wakatime/v1/users/{user}/user_agents", h.Get)
+		r.Get("/v1/users/{user}/user_agents", h.Get)
+	})
+}
+
+// @Summary Retrieve the plugins and clients the user has sent heartbeats from
+// @Description Mimics https://wakatime.com/developers#user_agents
+// @ID get-wakatime-user-agents
+// @Tags wakatime
+// @Produce json
+// @Param user path string true "User ID to fetch data for (or 'current')"
+// @Security ApiKeyAuth
+// @Success 200 {object} v1.UserAgentsViewModel
+// @Router /compat/wakatime/v1/users/{user}/user_agents [get]
+func (h *UserAgentsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	user, err := routeutils.CheckEffectiveUser(w, r, h.userSrvc, "current")
+	if err != nil {
+		return // response was already sent by util function
+	}
+
+	results, err := h.heartbeatSrvc.GetUserAgentStats(user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(conf.ErrInternalServerError))
+		conf.Log().Request(r).Error("error occurred", "error", err)
+		return
+	}
+
+	userAgents := make([]*v1.UserAgentEntry, len(results))
+	for i, ua := range results {
+		userAgents[i] = v1.NewUserAgentEntry(ua)
+	}
+
+	vm := &v1.UserAgentsViewModel{Data: userAgents, TotalPages: 1}
+	helpers.RespondJSON(w, r, http.StatusOK, vm)
+}
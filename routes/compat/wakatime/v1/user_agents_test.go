@@ -0,0 +1,66 @@
+package v1
+
+import (
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserAgentsHandler_Get(t *testing.T) {
+	config.Set(config.Empty())
+
+	router := chi.NewRouter()
+	apiRouter := chi.NewRouter()
+	apiRouter.Use(middlewares.NewPrincipalMiddleware())
+	router.Mount("/api", apiRouter)
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserByKey", "basic-user-api-key").Return(basicUser, nil)
+
+	const userAgent = "wakatime/v1.90.0 (linux-6.8.0-x86_64) go1.22.1 vscode/1.89.1 vscode-wakatime/24.5.0"
+	t0 := time.Date(2022, 2, 2, 22, 22, 22, 0, time.UTC)
+	heartbeatServiceMock := new(mocks.HeartbeatServiceMock)
+	heartbeatServiceMock.On("GetUserAgentStats", basicUser).Return([]*models.UserAgentStats{
+		{UserAgent: userAgent, Editor: "vscode", OperatingSystem: "Linux", First: models.CustomTime(t0), Last: models.CustomTime(t0.Add(time.Hour))},
+	}, nil)
+
+	NewUserAgentsHandler(userServiceMock, heartbeatServiceMock).RegisterRoutes(apiRouter)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/api/compat/wakatime/v1/users/{user}/user_agents", nil)
+	req = withUrlParam(req, "user", "current")
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", base64.StdEncoding.EncodeToString([]byte(basicUser.ApiKey))))
+	router.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// decoded generically to check the exact field names of wakatime's api
+	var vm struct {
+		Data       []map[string]interface{} `json:"data"`
+		TotalPages int                      `json:"total_pages"`
+	}
+	assert.Nil(t, json.NewDecoder(rec.Body).Decode(&vm))
+	assert.Equal(t, 1, vm.TotalPages)
+	assert.Len(t, vm.Data, 1)
+
+	entry := vm.Data[0]
+	assert.Equal(t, fmt.Sprintf("%x", md5.Sum([]byte(userAgent))), entry["id"])
+	assert.Equal(t, userAgent, entry["value"])
+	assert.Equal(t, "vscode", entry["editor"])
+	assert.Equal(t, "Linux", entry["os"])
+	assert.Equal(t, "2022-02-02T22:22:22Z", entry["first_seen_at"])
+	assert.Equal(t, "2022-02-02T23:22:22Z", entry["last_seen_at"])
+	assert.Equal(t, "2022-02-02T22:22:22Z", entry["created_at"])
+}
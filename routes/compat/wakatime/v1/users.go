@@ -51,7 +51,7 @@ func (h *UsersHandler) Get(w http.ResponseWriter, r *http.Request) {
 
 	user := v1.NewFromUser(wakapiUser)
 	if hb, err := h.heartbeatSrvc.GetLatestByUser(wakapiUser); err == nil {
-		user = user.WithLatestHeartbeat(hb)
+		user = user.WithLatestHeartbeat(hb, wakapiUser)
 	} else {
 		conf.Log().Request(r).Error("error occurred", "error", err)
 	}
@@ -2,6 +2,7 @@ package v1
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
@@ -49,6 +50,8 @@ func TestUsersHandler_Get(t *testing.T) {
 	userServiceMock.On("GetUserById", "AdminUser").Return(adminUser, nil)
 	userServiceMock.On("GetUserByKey", "admin-user-api-key").Return(adminUser, nil)
 	userServiceMock.On("GetUserById", "BasicUser").Return(basicUser, nil)
+	userServiceMock.On("GetUserByRef", "AdminUser").Return(adminUser, nil)
+	userServiceMock.On("GetUserByRef", "BasicUser").Return(basicUser, nil)
 	userServiceMock.On("GetUserByKey", "basic-user-api-key").Return(basicUser, nil)
 
 	heartbeatServiceMock := new(mocks.HeartbeatServiceMock)
@@ -105,13 +108,13 @@ func TestUsersHandler_Get(t *testing.T) {
 			res := rec.Result()
 			defer res.Body.Close()
 
-			data, err := io.ReadAll(res.Body)
-			if err != nil {
+			var apiErr models.ApiError
+			if err := json.NewDecoder(res.Body).Decode(&apiErr); err != nil {
 				t.Errorf("unextected error. Error: %s", err)
 			}
 
-			if string(data) != "401 unauthorized" {
-				t.Errorf("invalid response received. Expected: '401 unauthorized' Received: %s", string(data))
+			if res.StatusCode != http.StatusUnauthorized || apiErr.Error != "401 unauthorized" || apiErr.Code != models.ApiErrorUnauthorized {
+				t.Errorf("invalid response received. Expected: '401 unauthorized' Received: %+v", apiErr)
 			}
 		})
 
@@ -140,3 +143,34 @@ func TestUsersHandler_Get(t *testing.T) {
 		})
 	})
 }
+
+func TestUsersHandler_Get_HideProjectNames(t *testing.T) {
+	config.Set(config.Empty())
+
+	router := chi.NewRouter()
+	apiRouter := chi.NewRouter()
+	apiRouter.Use(middlewares.NewPrincipalMiddleware())
+	router.Mount("/api", apiRouter)
+
+	user := *basicUser
+	user.HideProjectNames = true
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserById", "BasicUser").Return(&user, nil)
+	userServiceMock.On("GetUserByKey", "basic-user-api-key").Return(&user, nil)
+
+	heartbeatServiceMock := new(mocks.HeartbeatServiceMock)
+	heartbeatServiceMock.On("GetLatestByUser", &user).Return(&models.Heartbeat{Project: "wakapi", Time: models.CustomTime(time.Now())}, nil)
+
+	NewUsersHandler(userServiceMock, heartbeatServiceMock).RegisterRoutes(apiRouter)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/compat/wakatime/v1/users/current", nil)
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", base64.StdEncoding.EncodeToString([]byte(user.ApiKey))))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	data, _ := io.ReadAll(rec.Body)
+	if !strings.Contains(string(data), fmt.Sprintf("\"last_project\":\"%s\"", user.AnonymizedProjectName("wakapi"))) {
+		t.Errorf("invalid response received. Expected anonymized last project Received: %s", string(data))
+	}
+}
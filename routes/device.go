@@ -0,0 +1,122 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/models/view"
+	routeutils "github.com/hackclub/hackatime/routes/utils"
+	"github.com/hackclub/hackatime/services"
+)
+
+// DeviceHandler serves the page on which users enter the code displayed by a plugin on a headless machine to grant it their api key
+type DeviceHandler struct {
+	config         *conf.Config
+	userService    services.IUserService
+	deviceAuthSrvc services.IDeviceAuthService
+}
+
+func NewDeviceHandler(userService services.IUserService, deviceAuthService services.IDeviceAuthService) *DeviceHandler {
+	return &DeviceHandler{
+		config:         conf.Get(),
+		userService:    userService,
+		deviceAuthSrvc: deviceAuthService,
+	}
+}
+
+func (h *DeviceHandler) RegisterRoutes(router chi.Router) {
+	r := chi.NewRouter()
+	r.Use(
+		middlewares.NewAuthenticateMiddleware(h.userService).
+			WithRedirectTarget(defaultErrorRedirectTarget()).
+			WithRedirectErrorMessage("unauthorized").Handler,
+	)
+	r.Get("/", h.GetIndex)
+	r.Post("/", h.PostIndex)
+
+	router.Mount("/device", r)
+}
+
+func (h *DeviceHandler) GetIndex(w http.ResponseWriter, r *http.Request) {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+	if err := templates[conf.DeviceTemplate].Execute(w, h.buildViewModel(r, w)); err != nil {
+		conf.Log().Request(r).Error("failed to get device page", "error", err)
+	}
+}
+
+func (h *DeviceHandler) PostIndex(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+	redirectTarget := fmt.Sprintf("%s/device", h.config.Server.BasePath)
+
+	if err := r.ParseForm(); err != nil {
+		routeutils.SetError(r, w, "missing form values")
+		http.Redirect(w, r, redirectTarget, http.StatusFound)
+		return
+	}
+
+	userCode := r.PostForm.Get("user_code")
+	retryTarget := fmt.Sprintf("%s?user_code=%s", redirectTarget, url.QueryEscape(userCode))
+
+	var err error
+	switch r.PostForm.Get("action") {
+	case "approve":
+		err = h.deviceAuthSrvc.Approve(userCode, user, r.PostForm.Get("confirm_rotation") == "true")
+	case "deny":
+		err = h.deviceAuthSrvc.Deny(userCode)
+	default:
+		// only looking up the code, so the user can confirm the requesting client first
+		http.Redirect(w, r, retryTarget, http.StatusFound)
+		return
+	}
+
+	if err != nil {
+		if errors.Is(err, services.ErrDeviceUserCodeInvalid) || errors.Is(err, services.ErrDeviceRotationUnconfirmed) {
+			routeutils.SetError(r, w, err.Error())
+		} else {
+			conf.Log().Request(r).Error("failed to process device authorization", "userID", user.ID, "error", err)
+			routeutils.SetError(r, w, "internal server error")
+		}
+		http.Redirect(w, r, retryTarget, http.StatusFound)
+		return
+	}
+
+	if r.PostForm.Get("action") == "approve" {
+		routeutils.SetSuccess(r, w, "device connected successfully, you may close this page now")
+	} else {
+		routeutils.SetSuccess(r, w, "device request denied")
+	}
+	http.Redirect(w, r, redirectTarget, http.StatusFound)
+}
+
+func (h *DeviceHandler) buildViewModel(r *http.Request, w http.ResponseWriter) *view.DeviceViewModel {
+	user := middlewares.GetPrincipal(r)
+	vm := &view.DeviceViewModel{
+		SharedLoggedInViewModel: view.SharedLoggedInViewModel{
+			SharedViewModel: view.NewSharedViewModel(h.config, nil),
+			User:            user,
+		},
+		UserCode: models.NormalizeDeviceUserCode(r.URL.Query().Get("user_code")),
+	}
+
+	if !h.config.Security.DeviceFlow {
+		vm.SetError(services.ErrDeviceFlowDisabled.Error())
+		return routeutils.WithSessionMessages(vm, r, w)
+	}
+
+	if vm.UserCode != "" {
+		if auth, err := h.deviceAuthSrvc.GetByUserCode(vm.UserCode); err == nil {
+			vm.Authorization = auth
+		} else {
+			vm.SetError(err.Error())
+		}
+	}
+	return routeutils.WithSessionMessages(vm, r, w)
+}
@@ -0,0 +1,95 @@
+package routes
+
+import (
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+	"github.com/hackclub/hackatime/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDeviceHandler_Approve_HashedApiKey(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Env = "dev"
+	cfg.Security.DeviceFlow = true
+	cfg.Security.DeviceCodeTTLMin = 15
+	cfg.Security.ApiKeyStorage = utils.ApiKeyStorageHashed
+	config.Set(cfg)
+
+	if cwd, _ := os.Getwd(); strings.HasSuffix(cwd, "routes") {
+		os.Chdir("..")
+	}
+
+	const apiKey = "a1b2c3d4-0000-4000-8000-000000000000"
+	user := &models.User{ID: "user1"} // plain key can't be recovered with hashed storage
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserByKey", apiKey).Return(user, nil)
+	userServiceMock.On("ResetApiKey", user).Return(user, nil)
+
+	deviceAuthService := services.NewDeviceAuthService(userServiceMock)
+	auth, err := deviceAuthService.Start("vscode")
+	assert.Nil(t, err)
+
+	router := chi.NewRouter()
+	router.Use(middlewares.NewPrincipalMiddleware())
+	NewDeviceHandler(userServiceMock, deviceAuthService).RegisterRoutes(router)
+
+	authenticate := func(req *http.Request) *http.Request {
+		req.Header.Set("Authorization", "Bearer "+base64.StdEncoding.EncodeToString([]byte(apiKey)))
+		return req
+	}
+	approve := func(form url.Values) *http.Response {
+		req := authenticate(httptest.NewRequest(http.MethodPost, "/device", strings.NewReader(form.Encode())))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Result()
+	}
+
+	t.Run("when viewing the request", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, authenticate(httptest.NewRequest(http.MethodGet, "/device?user_code="+auth.UserCode, nil)))
+		res := rec.Result()
+		defer res.Body.Close()
+
+		data, _ := io.ReadAll(res.Body)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Contains(t, string(data), `name="confirm_rotation"`)
+		assert.Contains(t, string(data), "other machines and plugins will be disconnected")
+	})
+
+	t.Run("when approving without confirming the rotation", func(t *testing.T) {
+		res := approve(url.Values{"user_code": {auth.UserCode}, "action": {"approve"}})
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Contains(t, res.Header.Get("Location"), "user_code="+auth.UserCode)
+		userServiceMock.AssertNotCalled(t, "ResetApiKey", mock.Anything)
+
+		_, err := deviceAuthService.Poll(auth.DeviceCode)
+		assert.ErrorIs(t, err, services.ErrDeviceAuthorizationPending)
+	})
+
+	t.Run("when approving with confirmation", func(t *testing.T) {
+		res := approve(url.Values{"user_code": {auth.UserCode}, "action": {"approve"}, "confirm_rotation": {"true"}})
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "/device", res.Header.Get("Location"))
+		userServiceMock.AssertNumberOfCalls(t, "ResetApiKey", 1)
+
+		approved, err := deviceAuthService.Poll(auth.DeviceCode)
+		assert.Nil(t, err)
+		assert.Equal(t, user.ID, approved.UserID)
+	})
+}
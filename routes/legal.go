@@ -0,0 +1,65 @@
+package routes
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models/view"
+	"github.com/hackclub/hackatime/services"
+)
+
+type LegalHandler struct {
+	config    *conf.Config
+	legalSrvc services.ILegalService
+}
+
+func NewLegalHandler(legalService services.ILegalService) *LegalHandler {
+	return &LegalHandler{
+		config:    conf.Get(),
+		legalSrvc: legalService,
+	}
+}
+
+func (h *LegalHandler) RegisterRoutes(router chi.Router) {
+	router.Get("/terms", h.GetTerms)
+	router.Get("/privacy", h.GetPrivacy)
+}
+
+func (h *LegalHandler) GetTerms(w http.ResponseWriter, r *http.Request) {
+	h.serveDocument(w, r, services.LegalDocumentTerms, "Terms of Service")
+}
+
+func (h *LegalHandler) GetPrivacy(w http.ResponseWriter, r *http.Request) {
+	h.serveDocument(w, r, services.LegalDocumentPrivacy, "Privacy Policy")
+}
+
+func (h *LegalHandler) serveDocument(w http.ResponseWriter, r *http.Request, name, title string) {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	text, err := h.legalSrvc.GetDocument(name)
+	if errors.Is(err, services.ErrLegalDocumentNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		templates[conf.LegalTemplate].Execute(w, h.buildViewModel(title).WithError("this document is not available on this instance"))
+		return
+	}
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		conf.Log().Request(r).Error("failed to read legal document", "name", name, "error", err)
+		templates[conf.LegalTemplate].Execute(w, h.buildViewModel(title).WithError("failed to load content"))
+		return
+	}
+
+	templates[conf.LegalTemplate].Execute(w, h.buildViewModel(title).WithHtmlText(text))
+}
+
+func (h *LegalHandler) buildViewModel(title string) *view.LegalViewModel {
+	return &view.LegalViewModel{
+		SharedViewModel: view.NewSharedViewModel(h.config, nil),
+		Title:           title,
+		Version:         h.config.Legal.Version,
+	}
+}
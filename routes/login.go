@@ -6,12 +6,11 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/dchest/captcha"
 	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/httprate"
 	conf "github.com/hackclub/hackatime/config"
 	"github.com/hackclub/hackatime/middlewares"
 	"github.com/hackclub/hackatime/models"
@@ -22,36 +21,43 @@ import (
 )
 
 type LoginHandler struct {
-	config       *conf.Config
-	userSrvc     services.IUserService
-	mailSrvc     services.IMailService
-	keyValueSrvc services.IKeyValueService
+	config           *conf.Config
+	userSrvc         services.IUserService
+	mailSrvc         services.IMailService
+	verifySrvc       services.IEmailVerificationService
+	throttleSrvc     services.ILoginThrottleService
+	legalSrvc        services.ILegalService
+	registrationSrvc services.IRegistrationService
 }
 
-func NewLoginHandler(userService services.IUserService, mailService services.IMailService, keyValueService services.IKeyValueService) *LoginHandler {
+func NewLoginHandler(userService services.IUserService, mailService services.IMailService, emailVerificationService services.IEmailVerificationService, loginThrottleService services.ILoginThrottleService, legalService services.ILegalService, registrationService services.IRegistrationService) *LoginHandler {
 	return &LoginHandler{
-		config:       conf.Get(),
-		userSrvc:     userService,
-		mailSrvc:     mailService,
-		keyValueSrvc: keyValueService,
+		config:           conf.Get(),
+		userSrvc:         userService,
+		mailSrvc:         mailService,
+		verifySrvc:       emailVerificationService,
+		throttleSrvc:     loginThrottleService,
+		legalSrvc:        legalService,
+		registrationSrvc: registrationService,
 	}
 }
 
 func (h *LoginHandler) RegisterRoutes(router chi.Router) {
 	router.Get("/login", h.GetIndex)
 	router.
-		With(httprate.LimitByRealIP(h.config.Security.GetLoginMaxRate())).
+		With(middlewares.LimitByClientIp(h.config.Security.GetLoginMaxRate())).
 		Post("/login", h.PostLogin)
 	router.Get("/signup", h.GetSignup)
 	router.
-		With(httprate.LimitByRealIP(h.config.Security.GetSignupMaxRate())).
+		With(middlewares.LimitByClientIp(h.config.Security.GetSignupMaxRate())).
 		Post("/signup", h.PostSignup)
 	router.Get("/set-password", h.GetSetPassword)
 	router.Post("/set-password", h.PostSetPassword)
 	router.Get("/reset-password", h.GetResetPassword)
 	router.
-		With(httprate.LimitByRealIP(h.config.Security.GetPasswordResetMaxRate())).
+		With(middlewares.LimitByClientIp(h.config.Security.GetPasswordResetMaxRate())).
 		Post("/reset-password", h.PostResetPassword)
+	router.Get("/verify-email", h.GetVerifyEmail)
 
 	authMiddleware := middlewares.NewAuthenticateMiddleware(h.userSrvc).
 		WithRedirectTarget(defaultErrorRedirectTarget()).
@@ -74,7 +80,7 @@ func (h *LoginHandler) GetIndex(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	templates[conf.LoginTemplate].Execute(w, h.buildViewModel(r, w, false))
+	templates[conf.LoginTemplate].Execute(w, h.buildViewModel(r, w, h.throttleSrvc.RequiresCaptcha(clientIp(r), "")))
 }
 
 func (h *LoginHandler) PostLogin(w http.ResponseWriter, r *http.Request) {
@@ -99,23 +105,79 @@ func (h *LoginHandler) PostLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	attempt := &models.LoginAttempt{Username: login.Username, Ip: clientIp(r)}
+	if h.rejectLockedOut(w, r, attempt.Ip, "") {
+		return
+	}
+
 	user, err := h.userSrvc.GetUserById(login.Username)
 	if err != nil {
 		// try getting the user by email
 		err = nil
 		user, err = h.userSrvc.GetUserByEmail(login.Username)
 		if err != nil {
+			h.throttleSrvc.RegisterFailure(attempt)
 			w.WriteHeader(http.StatusNotFound)
-			templates[conf.LoginTemplate].Execute(w, h.buildViewModel(r, w, false).WithError("user not found"))
+			templates[conf.LoginTemplate].Execute(w, h.buildViewModel(r, w, h.throttleSrvc.RequiresCaptcha(attempt.Ip, attempt.Username)).WithError("user not found"))
 			return
 		}
 	}
+	attempt.UserID = user.ID
+
+	if h.rejectLockedOut(w, r, attempt.Ip, attempt.UserID) {
+		return
+	}
 
-	if !utils.ComparePassword(user.Password, login.Password, h.config.Security.PasswordSalt) {
+	// captcha is only checked once, but not counted as a failed attempt, as the client might not have been aware of it
+	if h.throttleSrvc.RequiresCaptcha(attempt.Ip, attempt.UserID) && !models.ValidateCaptcha(login.CaptchaId, login.Captcha) {
 		w.WriteHeader(http.StatusUnauthorized)
-		templates[conf.LoginTemplate].Execute(w, h.buildViewModel(r, w, false).WithError("invalid credentials"))
+		templates[conf.LoginTemplate].Execute(w, h.buildViewModel(r, w, true).WithError("please solve the captcha"))
+		return
+	}
+
+	// service accounts don't have a password and may only use their api key
+	if user.IsServiceAccount || !utils.ComparePassword(user.Password, login.Password, h.config.Security.PasswordSalt) {
+		if lockout := h.throttleSrvc.RegisterFailure(attempt); lockout > 0 {
+			h.respondLockedOut(w, r, lockout)
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		templates[conf.LoginTemplate].Execute(w, h.buildViewModel(r, w, h.throttleSrvc.RequiresCaptcha(attempt.Ip, attempt.UserID)).WithError("invalid credentials"))
+		return
+	}
+
+	if user.Deactivated {
+		w.WriteHeader(http.StatusForbidden)
+		templates[conf.LoginTemplate].Execute(w, h.buildViewModel(r, w, false).WithError("account deactivated"))
+		return
+	}
+
+	if user.IsSuspended() {
+		w.WriteHeader(http.StatusForbidden)
+		templates[conf.LoginTemplate].Execute(w, h.buildViewModel(r, w, false).WithError(user.SuspensionMessage()))
+		return
+	}
+
+	// users have to (re-)accept the terms upon their first login after they were updated
+	requiresConsent, err := h.legalSrvc.RequiresConsent(user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		conf.Log().Request(r).Error("failed to check legal consent", "userID", user.ID, "error", err)
+		templates[conf.LoginTemplate].Execute(w, h.buildViewModel(r, w, false).WithError("internal server error"))
 		return
 	}
+	if requiresConsent && !login.AcceptLegal {
+		templates[conf.LoginTemplate].Execute(w, h.buildViewModel(r, w, false).WithConsentRequired(login.Username).WithError("please accept the updated terms to continue"))
+		return
+	}
+	if requiresConsent {
+		if err := h.legalSrvc.Accept(user, attempt.Ip); err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			conf.Log().Request(r).Error("failed to record legal consent", "userID", user.ID, "error", err)
+			templates[conf.LoginTemplate].Execute(w, h.buildViewModel(r, w, false).WithError("internal server error"))
+			return
+		}
+	}
 
 	encoded, err := h.config.Security.SecureCookie.Encode(models.AuthCookieKey, user.ID)
 	if err != nil {
@@ -125,6 +187,17 @@ func (h *LoginHandler) PostLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// transparently upgrade hashes created with an outdated algorithm or outdated parameters, as this is the only time we know the plain password
+	if hasher := h.config.Security.PasswordHasher(); hasher.NeedsRehash(user.Password) {
+		if hash, err := hasher.Hash(login.Password, h.config.Security.PasswordSalt); err == nil {
+			user.Password = hash
+		} else {
+			conf.Log().Request(r).Error("failed to rehash password", "userID", user.ID, "error", err)
+		}
+	}
+
+	h.throttleSrvc.RegisterSuccess(attempt)
+
 	user.LastLoggedInAt = models.CustomTime(time.Now())
 	h.userSrvc.Update(user)
 
@@ -176,10 +249,15 @@ func (h *LoginHandler) PostSignup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !h.config.IsDev() && !adminTokenSignup && !h.config.Security.AllowSignup && (!h.config.Security.InviteCodes || signup.InviteCode == "") {
-		w.WriteHeader(http.StatusForbidden)
-		templates[conf.SignupTemplate].Execute(w, h.buildViewModel(r, w, h.config.Security.SignupCaptcha).WithError("registration is disabled on this server"))
-		return
+	var inviteCode *models.InviteCode
+	if !h.config.IsDev() && !adminTokenSignup {
+		code, err := h.registrationSrvc.CheckSignup(&signup)
+		if err != nil {
+			w.WriteHeader(http.StatusForbidden)
+			templates[conf.SignupTemplate].Execute(w, h.buildViewModel(r, w, h.config.Security.SignupCaptcha).WithError(err.Error()))
+			return
+		}
+		inviteCode = code
 	}
 
 	if cookie, err := r.Cookie(models.AuthCookieKey); err == nil && cookie.Value != "" {
@@ -187,28 +265,9 @@ func (h *LoginHandler) PostSignup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var invitedBy string
-	var invitedDate time.Time
-	var inviteCodeKey = fmt.Sprintf("%s_%s", conf.KeyInviteCode, signup.InviteCode)
-
-	if kv, _ := h.keyValueSrvc.GetString(inviteCodeKey); kv != nil && kv.Value != "" {
-		if parts := strings.Split(kv.Value, ","); len(parts) == 2 {
-			invitedBy = parts[0]
-			invitedDate, _ = time.Parse(time.RFC3339, parts[1])
-		}
-
-		if err := h.keyValueSrvc.DeleteString(inviteCodeKey); err != nil {
-			conf.Log().Error("failed to revoke invite code", "inviteCodeKey", inviteCodeKey, "error", err)
-		}
+	if inviteCode != nil {
+		signup.InvitedBy = inviteCode.CreatedBy
 	}
-
-	if signup.InviteCode != "" && time.Since(invitedDate) > 24*time.Hour {
-		w.WriteHeader(http.StatusForbidden)
-		templates[conf.SignupTemplate].Execute(w, h.buildViewModel(r, w, h.config.Security.SignupCaptcha).WithError("invite code invalid or expired"))
-		return
-	}
-
-	signup.InvitedBy = invitedBy
 	validity, validityErr := signup.IsValid()
 	if !validity {
 		w.WriteHeader(http.StatusBadRequest)
@@ -226,10 +285,24 @@ func (h *LoginHandler) PostSignup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.config.Legal.RequiresConsent() && !adminTokenSignup && !signup.AcceptLegal {
+		w.WriteHeader(http.StatusBadRequest)
+		templates[conf.SignupTemplate].Execute(w, h.buildViewModel(r, w, h.config.Security.SignupCaptcha).WithError("please accept the terms of service and privacy policy"))
+		return
+	}
+
 	if signup.Name == "" {
 		signup.Name = signup.Username
 	}
 
+	if inviteCode != nil {
+		if err := h.registrationSrvc.RedeemInviteCode(inviteCode); err != nil {
+			w.WriteHeader(http.StatusForbidden)
+			templates[conf.SignupTemplate].Execute(w, h.buildViewModel(r, w, h.config.Security.SignupCaptcha).WithError(err.Error()))
+			return
+		}
+	}
+
 	numUsers, _ := h.userSrvc.Count()
 
 	user, created, err := h.userSrvc.CreateOrGet(&signup, numUsers == 0)
@@ -250,6 +323,16 @@ func (h *LoginHandler) PostSignup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if created && !adminTokenSignup {
+		if err := h.legalSrvc.Accept(user, clientIp(r)); err != nil {
+			// the account exists nevertheless, consent will be asked for again upon login
+			w.WriteHeader(http.StatusInternalServerError)
+			conf.Log().Request(r).Error("failed to record legal consent", "userID", user.ID, "error", err)
+			templates[conf.SignupTemplate].Execute(w, h.buildViewModel(r, w, h.config.Security.SignupCaptcha).WithError("account created, but failed to record your consent to the terms, please log in to accept them again"))
+			return
+		}
+	}
+
 	if created && h.config.Mail.WelcomeEnabled {
 		if err := h.mailSrvc.SendWelcome(user); err != nil {
 			conf.Log().Request(r).Error("failed to send welcome mail", "userID", user.ID, "error", err)
@@ -258,6 +341,16 @@ func (h *LoginHandler) PostSignup(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if created && user.Email != "" && h.config.Mail.Enabled {
+		go func(user *models.User) {
+			if err := h.verifySrvc.SendVerification(user); err != nil {
+				conf.Log().Request(r).Error("failed to send e-mail verification mail", "userID", user.ID, "error", err)
+			} else {
+				slog.Info("sent e-mail verification mail", "userID", user.ID)
+			}
+		}(user)
+	}
+
 	// Check if submitted with admin token in authorization header
 	if adminTokenSignup {
 		// Return JSON response with created and api key values
@@ -355,7 +448,8 @@ func (h *LoginHandler) PostSetPassword(w http.ResponseWriter, r *http.Request) {
 
 	user.Password = setRequest.Password
 	user.ResetToken = ""
-	if hash, err := utils.HashPassword(user.Password, h.config.Security.PasswordSalt); err != nil {
+	user.InvitePending = false // setting a password via the invite link activates bulk-invited users
+	if hash, err := h.config.Security.PasswordHasher().Hash(user.Password, h.config.Security.PasswordSalt); err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		conf.Log().Request(r).Error("failed to set new password", "error", err)
 		templates[conf.SetPasswordTemplate].Execute(w, h.buildViewModel(r, w, false).WithError("failed to set new password"))
@@ -422,19 +516,60 @@ func (h *LoginHandler) PostResetPassword(w http.ResponseWriter, r *http.Request)
 	http.Redirect(w, r, h.config.Server.BasePath, http.StatusFound)
 }
 
+func (h *LoginHandler) GetVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	user, err := h.verifySrvc.Verify(r.URL.Query().Get("token"))
+	if err != nil {
+		conf.Log().Request(r).Warn("e-mail verification failed", "error", err)
+		routeutils.SetError(r, w, "invalid or expired verification link")
+		http.Redirect(w, r, h.config.Server.BasePath, http.StatusFound)
+		return
+	}
+
+	slog.Info("verified e-mail address", "userID", user.ID)
+	routeutils.SetSuccess(r, w, "e-mail address verified successfully")
+	http.Redirect(w, r, h.config.Server.BasePath, http.StatusFound)
+}
+
+func (h *LoginHandler) rejectLockedOut(w http.ResponseWriter, r *http.Request, ip, userId string) bool {
+	if lockout := h.throttleSrvc.GetLockout(ip, userId); lockout > 0 {
+		h.respondLockedOut(w, r, lockout)
+		return true
+	}
+	return false
+}
+
+func (h *LoginHandler) respondLockedOut(w http.ResponseWriter, r *http.Request, lockout time.Duration) {
+	lockout = lockout.Round(time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(int(lockout.Seconds())))
+	w.WriteHeader(http.StatusTooManyRequests)
+	templates[conf.LoginTemplate].Execute(w, h.buildViewModel(r, w, false).WithError(fmt.Sprintf("too many failed login attempts, please try again in %s", lockout)))
+}
+
 func (h *LoginHandler) buildViewModel(r *http.Request, w http.ResponseWriter, withCaptcha bool) *view.LoginViewModel {
 	numUsers, _ := h.userSrvc.Count()
+	policy := h.registrationSrvc.GetPolicy()
 
 	vm := &view.LoginViewModel{
 		SharedViewModel: view.NewSharedViewModel(h.config, nil),
 		TotalUsers:      int(numUsers),
-		AllowSignup:     h.config.IsDev() || h.config.Security.AllowSignup,
+		AllowSignup:     h.config.IsDev() || policy.AllowsSignupForm(),
 		InviteCode:      r.URL.Query().Get("invite"),
+		LegalVersion:    h.config.Legal.Version,
 	}
 
+	if policy.Mode == conf.RegistrationModeDomain {
+		vm.SignupDomains = policy.Domains
+	}
 	if withCaptcha {
 		vm.CaptchaId = captcha.New()
 	}
 
 	return routeutils.WithSessionMessages(vm, r, w)
 }
+
+func clientIp(r *http.Request) string {
+	if ip := middlewares.GetClientIp(r); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
@@ -0,0 +1,168 @@
+package routes
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/securecookie"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/middlewares"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services"
+	"github.com/hackclub/hackatime/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func setupLegalTest(version string) *config.Config {
+	cfg := config.Empty()
+	cfg.Env = "dev"
+	cfg.Legal.Version = version
+	cfg.Security.PasswordHashAlgorithm = utils.PasswordHashBcrypt
+	cfg.Security.BcryptCost = bcrypt.MinCost
+	cfg.Security.SignupMaxRate = "100/1s"
+	cfg.Security.LoginMaxRate = "100/1s"
+	cfg.Security.PasswordResetMaxRate = "100/1s"
+	cfg.Security.SecureCookie = securecookie.New(securecookie.GenerateRandomKey(32), securecookie.GenerateRandomKey(32))
+	config.Set(cfg)
+
+	if cwd, _ := os.Getwd(); strings.HasSuffix(cwd, "routes") {
+		os.Chdir("..")
+	}
+	return cfg
+}
+
+func newLegalTestRouter(userService services.IUserService, legalService services.ILegalService) chi.Router {
+	router := chi.NewRouter()
+	router.Use(middlewares.NewPrincipalMiddleware())
+	keyValueServiceMock := new(mocks.KeyValueServiceMock)
+	keyValueServiceMock.On("GetString", config.KeyRegistrationPolicy).Return(&models.KeyStringValue{Key: config.KeyRegistrationPolicy}, nil)
+	registrationService := services.NewRegistrationService(keyValueServiceMock, nil)
+
+	NewLoginHandler(userService, nil, nil, services.NewLoginThrottleService(), legalService, registrationService).RegisterRoutes(router)
+	return router
+}
+
+func postForm(router chi.Router, path string, form url.Values) *http.Response {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec.Result()
+}
+
+func TestLoginHandler_PostSignup_Legal(t *testing.T) {
+	setupLegalTest("2026-01")
+
+	signup := url.Values{
+		"username":        {"john"},
+		"password":        {"secret-password"},
+		"password_repeat": {"secret-password"},
+	}
+	user := &models.User{ID: "john"}
+
+	t.Run("when accepting the terms", func(t *testing.T) {
+		userServiceMock := new(mocks.UserServiceMock)
+		userServiceMock.On("Count").Return(1, nil)
+		userServiceMock.On("CreateOrGet", mock.Anything, false).Return(user, true, nil)
+
+		legalRepo := new(mocks.LegalConsentRepositoryMock)
+		legalRepo.On("Insert", mock.Anything).Return(nil)
+
+		form := url.Values{"accept_legal": {"true"}}
+		for k, v := range signup {
+			form[k] = v
+		}
+		res := postForm(newLegalTestRouter(userServiceMock, services.NewLegalService(legalRepo)), "/signup", form)
+
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		legalRepo.AssertNumberOfCalls(t, "Insert", 1)
+		consent := legalRepo.Calls[0].Arguments.Get(0).(*models.LegalConsent)
+		assert.Equal(t, "john", consent.UserID)
+		assert.Equal(t, "2026-01", consent.Version)
+	})
+
+	t.Run("when not accepting the terms", func(t *testing.T) {
+		userServiceMock := new(mocks.UserServiceMock)
+		userServiceMock.On("Count").Return(1, nil)
+		legalRepo := new(mocks.LegalConsentRepositoryMock)
+
+		res := postForm(newLegalTestRouter(userServiceMock, services.NewLegalService(legalRepo)), "/signup", signup)
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		userServiceMock.AssertNotCalled(t, "CreateOrGet", mock.Anything, mock.Anything)
+		legalRepo.AssertNotCalled(t, "Insert", mock.Anything)
+	})
+
+	t.Run("when consent fails to be recorded", func(t *testing.T) {
+		userServiceMock := new(mocks.UserServiceMock)
+		userServiceMock.On("Count").Return(1, nil)
+		userServiceMock.On("CreateOrGet", mock.Anything, false).Return(user, true, nil)
+
+		legalRepo := new(mocks.LegalConsentRepositoryMock)
+		legalRepo.On("Insert", mock.Anything).Return(errors.New("database is locked"))
+
+		form := url.Values{"accept_legal": {"true"}}
+		for k, v := range signup {
+			form[k] = v
+		}
+		res := postForm(newLegalTestRouter(userServiceMock, services.NewLegalService(legalRepo)), "/signup", form)
+		defer res.Body.Close()
+
+		data, _ := io.ReadAll(res.Body)
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+		assert.Contains(t, string(data), "failed to record your consent")
+	})
+}
+
+func TestLoginHandler_PostLogin_LegalVersionBump(t *testing.T) {
+	cfg := setupLegalTest("2026-06")
+
+	hash, err := cfg.Security.PasswordHasher().Hash("secret-password", cfg.Security.PasswordSalt)
+	assert.Nil(t, err)
+	user := &models.User{ID: "john", Password: hash}
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserById", user.ID).Return(user, nil)
+	userServiceMock.On("Update", user).Return(user, nil)
+	userServiceMock.On("Count").Return(1, nil)
+
+	// accepted the previous version only
+	legalRepo := new(mocks.LegalConsentRepositoryMock)
+	legalRepo.On("CountByUserAndVersion", user.ID, "2026-06").Return(int64(0), nil)
+	legalRepo.On("Insert", mock.Anything).Return(nil)
+
+	router := newLegalTestRouter(userServiceMock, services.NewLegalService(legalRepo))
+	login := url.Values{"username": {"john"}, "password": {"secret-password"}}
+
+	t.Run("when logging in without accepting the updated terms", func(t *testing.T) {
+		res := postForm(router, "/login", login)
+		defer res.Body.Close()
+
+		data, _ := io.ReadAll(res.Body)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Contains(t, string(data), `name="accept_legal"`)
+		assert.Contains(t, string(data), "version 2026-06")
+		assert.Empty(t, res.Cookies())
+		legalRepo.AssertNotCalled(t, "Insert", mock.Anything)
+	})
+
+	t.Run("when re-accepting the updated terms", func(t *testing.T) {
+		login.Set("accept_legal", "true")
+		res := postForm(router, "/login", login)
+
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.NotEmpty(t, res.Cookies())
+		legalRepo.AssertNumberOfCalls(t, "Insert", 1)
+		assert.Equal(t, "2026-06", legalRepo.Calls[len(legalRepo.Calls)-1].Arguments.Get(0).(*models.LegalConsent).Version)
+	})
+}
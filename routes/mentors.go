@@ -0,0 +1,80 @@
+package routes
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models/view"
+	routeutils "github.com/hackclub/hackatime/routes/utils"
+	"github.com/hackclub/hackatime/services"
+)
+
+// MentorsHandler serves the page on which mentors, who don't have an account, unsubscribe from a user's weekly summaries.
+// Unsubscribing requires submitting a form, so that link scanners of mail providers don't trigger it.
+type MentorsHandler struct {
+	config     *conf.Config
+	userSrvc   services.IUserService
+	mentorSrvc services.IMentorService
+}
+
+func NewMentorsHandler(userService services.IUserService, mentorService services.IMentorService) *MentorsHandler {
+	return &MentorsHandler{
+		config:     conf.Get(),
+		userSrvc:   userService,
+		mentorSrvc: mentorService,
+	}
+}
+
+func (h *MentorsHandler) RegisterRoutes(router chi.Router) {
+	router.Get("/mentors/unsubscribe", h.GetUnsubscribe)
+	router.Post("/mentors/unsubscribe", h.PostUnsubscribe)
+}
+
+func (h *MentorsHandler) GetUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	vm := h.buildViewModel(r, w, r.URL.Query().Get("token"))
+	if mentor, err := h.mentorSrvc.GetByToken(vm.Token); err == nil {
+		if user, err := h.userSrvc.GetUserById(mentor.UserID); err == nil {
+			vm.UserName = user.DisplayName()
+		}
+	}
+	templates[conf.MentorTemplate].Execute(w, vm)
+}
+
+func (h *MentorsHandler) PostUnsubscribe(w http.ResponseWriter, r *http.Request) {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	vm := h.buildViewModel(r, w, r.PostFormValue("token"))
+	mentor, err := h.mentorSrvc.Unsubscribe(vm.Token)
+	if errors.Is(err, services.ErrMentorNotFound) {
+		w.WriteHeader(http.StatusNotFound)
+		templates[conf.MentorTemplate].Execute(w, vm)
+		return
+	}
+	if err != nil {
+		conf.Log().Request(r).Error("failed to unsubscribe mentor", "error", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		templates[conf.MentorTemplate].Execute(w, vm.WithError("internal server error"))
+		return
+	}
+
+	slog.Info("mentor unsubscribed", "userID", mentor.UserID, "mentorID", mentor.ID)
+	vm.Unsubscribed = true
+	templates[conf.MentorTemplate].Execute(w, vm.WithSuccess("unsubscribed successfully"))
+}
+
+func (h *MentorsHandler) buildViewModel(r *http.Request, w http.ResponseWriter, token string) *view.MentorUnsubscribeViewModel {
+	vm := &view.MentorUnsubscribeViewModel{
+		SharedViewModel: view.NewSharedViewModel(h.config, nil),
+		Token:           token,
+	}
+	return routeutils.WithSessionMessages(vm, r, w)
+}
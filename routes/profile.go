@@ -0,0 +1,62 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models/view"
+	"github.com/hackclub/hackatime/services"
+)
+
+type ProfileHandler struct {
+	config      *conf.Config
+	userSrvc    services.IUserService
+	profileSrvc services.IProfileService
+}
+
+func NewProfileHandler(userService services.IUserService, profileService services.IProfileService) *ProfileHandler {
+	return &ProfileHandler{
+		config:      conf.Get(),
+		userSrvc:    userService,
+		profileSrvc: profileService,
+	}
+}
+
+func (h *ProfileHandler) RegisterRoutes(router chi.Router) {
+	router.Get("/@{username}", h.GetProfile)
+}
+
+func (h *ProfileHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user, err := h.userSrvc.GetUserById(chi.URLParam(r, "username"))
+	if err != nil || !user.HasPublicProfile() {
+		w.WriteHeader(http.StatusNotFound)
+		templates[conf.ProfileTemplate].Execute(w, h.buildViewModel().WithError("this profile does not exist or is private"))
+		return
+	}
+
+	profile, err := h.profileSrvc.GetByUser(user)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		conf.Log().Request(r).Error("failed to load profile", "userID", user.ID, "error", err)
+		templates[conf.ProfileTemplate].Execute(w, h.buildViewModel().WithError("failed to load profile"))
+		return
+	}
+
+	vm := h.buildViewModel()
+	vm.Profile = profile
+	if h.config.App.AvatarURLTemplate != "" {
+		vm.AvatarURL = user.AvatarURL(h.config.App.AvatarURLTemplate)
+	}
+	templates[conf.ProfileTemplate].Execute(w, vm)
+}
+
+func (h *ProfileHandler) buildViewModel() *view.ProfileViewModel {
+	return &view.ProfileViewModel{
+		SharedViewModel: view.NewSharedViewModel(h.config, nil),
+	}
+}
@@ -1,9 +1,11 @@
 package routes
 
 import (
+	"fmt"
 	"net/http"
 	"time"
 
+	"github.com/duke-git/lancet/v2/slice"
 	"github.com/go-chi/chi/v5"
 	conf "github.com/hackclub/hackatime/config"
 	"github.com/hackclub/hackatime/middlewares"
@@ -36,6 +38,7 @@ func (h *ProjectsHandler) RegisterRoutes(router chi.Router) {
 			WithRedirectErrorMessage("unauthorized").Handler,
 	)
 	r.Get("/", h.GetIndex)
+	r.Post("/", h.PostIndex)
 
 	router.Mount("/projects", r)
 }
@@ -49,6 +52,40 @@ func (h *ProjectsHandler) GetIndex(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h *ProjectsHandler) PostIndex(w http.ResponseWriter, r *http.Request) {
+	user := middlewares.GetPrincipal(r)
+	redirectTarget := fmt.Sprintf("%s/projects", h.config.Server.BasePath)
+
+	if err := r.ParseForm(); err != nil {
+		routeutils.SetError(r, w, "missing form values")
+		http.Redirect(w, r, redirectTarget, http.StatusFound)
+		return
+	}
+
+	project := r.PostForm.Get("project")
+	switch r.PostForm.Get("action") {
+	case "archive_project":
+		user.ArchiveProject(project)
+	case "unarchive_project":
+		user.UnarchiveProject(project)
+		redirectTarget += "?include_archived=true"
+	default:
+		routeutils.SetError(r, w, "unknown action requests")
+		http.Redirect(w, r, redirectTarget, http.StatusFound)
+		return
+	}
+
+	if _, err := h.userService.Update(user); err != nil {
+		conf.Log().Request(r).Error("failed to update archived projects", "userID", user.ID, "error", err)
+		routeutils.SetError(r, w, "internal server error")
+		http.Redirect(w, r, redirectTarget, http.StatusFound)
+		return
+	}
+
+	routeutils.SetSuccess(r, w, "project updated successfully")
+	http.Redirect(w, r, redirectTarget, http.StatusFound)
+}
+
 func (h *ProjectsHandler) buildViewModel(r *http.Request, w http.ResponseWriter) *view.ProjectsViewModel {
 	user := middlewares.GetPrincipal(r)
 	if user == nil { // this should actually never occur, because of auth middleware
@@ -76,14 +113,22 @@ func (h *ProjectsHandler) buildViewModel(r *http.Request, w http.ResponseWriter)
 		}
 	}
 
+	includeArchived := r.URL.Query().Get("include_archived") != "" && r.URL.Query().Get("include_archived") != "false"
+	if !includeArchived {
+		projects = slice.Filter[*models.ProjectStats](projects, func(i int, p *models.ProjectStats) bool {
+			return !user.IsProjectArchived(p.Project)
+		})
+	}
+
 	vm := &view.ProjectsViewModel{
 		SharedLoggedInViewModel: view.SharedLoggedInViewModel{
 			SharedViewModel: view.NewSharedViewModel(h.config, nil),
 			User:            user,
 			ApiKey:          user.ApiKey,
 		},
-		Projects:   projects,
-		PageParams: pageParams,
+		Projects:        projects,
+		PageParams:      pageParams,
+		IncludeArchived: includeArchived,
 	}
 	return routeutils.WithSessionMessages(vm, r, w)
 }
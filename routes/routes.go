@@ -3,9 +3,11 @@ package routes
 import (
 	"html/template"
 	"strings"
+	"time"
 
 	"github.com/duke-git/lancet/v2/strutil"
 	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/i18n"
 
 	"github.com/duke-git/lancet/v2/datetime"
 	"github.com/hackclub/hackatime/config"
@@ -28,6 +30,8 @@ func DefaultTemplateFuncs() template.FuncMap {
 		"simpledate":     helpers.FormatDate,
 		"simpledatetime": helpers.FormatDateTime,
 		"duration":       helpers.FmtWakatimeDuration,
+		"userDuration":   userDuration,
+		"t":              i18n.T,
 		"floordate":      datetime.BeginOfDay,
 		"ceildate":       utils.CeilDate,
 		"title":          strings.Title,
@@ -61,6 +65,12 @@ func DefaultTemplateFuncs() template.FuncMap {
 		"cssSafe": func(s string) template.CSS {
 			return template.CSS(s)
 		},
+		"hasTerms": func() bool {
+			return config.Get().Legal.TermsFile != ""
+		},
+		"hasPrivacyPolicy": func() bool {
+			return config.Get().Legal.PrivacyFile != ""
+		},
 		"avatarUrlTemplate": func() string {
 			return config.Get().App.AvatarURLTemplate
 		},
@@ -118,3 +128,8 @@ func defaultErrorRedirectTarget() string {
 func add(i, j int) int {
 	return i + j
 }
+
+// userDuration formats a duration according to the user's display preferences, used as {{ .Total | userDuration .User }}
+func userDuration(user *models.User, d time.Duration) string {
+	return helpers.FmtUserDuration(d, user)
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"net/http"
 	"sort"
@@ -11,11 +12,11 @@ import (
 
 	"github.com/duke-git/lancet/v2/condition"
 	"github.com/go-chi/chi/v5"
-	"github.com/gofrs/uuid/v5"
 
 	"log/slog"
 
 	datastructure "github.com/duke-git/lancet/v2/datastructure/set"
+	"github.com/duke-git/lancet/v2/slice"
 	"github.com/gorilla/schema"
 	conf "github.com/hackclub/hackatime/config"
 	"github.com/hackclub/hackatime/middlewares"
@@ -29,19 +30,28 @@ import (
 
 const criticalError = "a critical error has occurred, sorry"
 
+const emailVerificationBackoff = 5 * time.Minute
+
 type SettingsHandler struct {
-	config              *conf.Config
-	userSrvc            services.IUserService
-	summarySrvc         services.ISummaryService
-	heartbeatSrvc       services.IHeartbeatService
-	aliasSrvc           services.IAliasService
-	aggregationSrvc     services.IAggregationService
-	languageMappingSrvc services.ILanguageMappingService
-	projectLabelSrvc    services.IProjectLabelService
-	keyValueSrvc        services.IKeyValueService
-	mailSrvc            services.IMailService
-	httpClient          *http.Client
-	aggregationLocks    map[string]bool
+	config                *conf.Config
+	userSrvc              services.IUserService
+	summarySrvc           services.ISummaryService
+	heartbeatSrvc         services.IHeartbeatService
+	aliasSrvc             services.IAliasService
+	aggregationSrvc       services.IAggregationService
+	languageMappingSrvc   services.ILanguageMappingService
+	projectLabelSrvc      services.IProjectLabelService
+	keyValueSrvc          services.IKeyValueService
+	registrationSrvc      services.IRegistrationService
+	mailSrvc              services.IMailService
+	machineSrvc           services.IMachineService
+	exportSrvc            services.IExportService
+	emailVerificationSrvc services.IEmailVerificationService
+	trackingPauseSrvc     services.ITrackingPauseService
+	telegramSrvc          services.ITelegramService
+	mentorSrvc            services.IMentorService
+	httpClient            *http.Client
+	aggregationLocks      map[string]bool
 }
 
 type action func(w http.ResponseWriter, r *http.Request) actionResult
@@ -53,7 +63,10 @@ type actionResult struct {
 	values  *map[string]interface{}
 }
 
-const valueInviteCode = "invite_code"
+const (
+	valueInviteCode   = "invite_code"
+	valueTelegramLink = "telegram_link"
+)
 
 var credentialsDecoder = schema.NewDecoder()
 
@@ -67,20 +80,34 @@ func NewSettingsHandler(
 	projectLabelService services.IProjectLabelService,
 	keyValueService services.IKeyValueService,
 	mailService services.IMailService,
+	machineService services.IMachineService,
+	exportService services.IExportService,
+	emailVerificationService services.IEmailVerificationService,
+	registrationService services.IRegistrationService,
+	trackingPauseService services.ITrackingPauseService,
+	telegramService services.ITelegramService,
+	mentorService services.IMentorService,
 ) *SettingsHandler {
 	return &SettingsHandler{
-		config:              conf.Get(),
-		summarySrvc:         summaryService,
-		aliasSrvc:           aliasService,
-		aggregationSrvc:     aggregationService,
-		languageMappingSrvc: languageMappingService,
-		projectLabelSrvc:    projectLabelService,
-		userSrvc:            userService,
-		heartbeatSrvc:       heartbeatService,
-		keyValueSrvc:        keyValueService,
-		mailSrvc:            mailService,
-		httpClient:          &http.Client{Timeout: 10 * time.Second},
-		aggregationLocks:    make(map[string]bool),
+		config:                conf.Get(),
+		summarySrvc:           summaryService,
+		aliasSrvc:             aliasService,
+		aggregationSrvc:       aggregationService,
+		languageMappingSrvc:   languageMappingService,
+		projectLabelSrvc:      projectLabelService,
+		userSrvc:              userService,
+		heartbeatSrvc:         heartbeatService,
+		keyValueSrvc:          keyValueService,
+		mailSrvc:              mailService,
+		machineSrvc:           machineService,
+		exportSrvc:            exportService,
+		emailVerificationSrvc: emailVerificationService,
+		registrationSrvc:      registrationService,
+		trackingPauseSrvc:     trackingPauseService,
+		telegramSrvc:          telegramService,
+		mentorSrvc:            mentorService,
+		httpClient:            &http.Client{Timeout: 10 * time.Second},
+		aggregationLocks:      make(map[string]bool),
 	}
 }
 
@@ -154,6 +181,10 @@ func (h *SettingsHandler) dispatchAction(action string) action {
 		return h.actionUpdateUser
 	case "reset_apikey":
 		return h.actionResetApiKey
+	case "reset_simple_token":
+		return h.actionResetSimpleToken
+	case "delete_simple_token":
+		return h.actionDeleteSimpleToken
 	case "delete_alias":
 		return h.actionDeleteAlias
 	case "add_alias":
@@ -170,10 +201,18 @@ func (h *SettingsHandler) dispatchAction(action string) action {
 		return h.actionUpdateSharing
 	case "update_leaderboard":
 		return h.actionUpdateLeaderboard
+	case "update_profile":
+		return h.actionUpdateProfile
 	case "toggle_wakatime":
 		return h.actionSetWakatimeApiKey
 	case "import_wakatime":
 		return h.actionImportWakatime
+	case "export_wakatime":
+		return h.actionExportWakatime
+	case "export_html":
+		return h.actionExportHtml
+	case "resend_email_verification":
+		return h.actionResendEmailVerification
 	case "regenerate_summaries":
 		return h.actionRegenerateSummaries
 	case "clear_data":
@@ -186,6 +225,30 @@ func (h *SettingsHandler) dispatchAction(action string) action {
 		return h.actionUpdateExcludeUnknownProjects
 	case "update_heartbeats_timeout":
 		return h.actionUpdateHeartbeatsTimeout
+	case "update_entity_normalization":
+		return h.actionUpdateEntityNormalization
+	case "reset_clock_skew":
+		return h.actionResetClockSkew
+	case "update_machine_approval":
+		return h.actionUpdateMachineApproval
+	case "update_location_tracking":
+		return h.actionUpdateLocationTracking
+	case "approve_machine":
+		return h.actionApproveMachine
+	case "reject_machine":
+		return h.actionRejectMachine
+	case "pause_tracking":
+		return h.actionPauseTracking
+	case "end_tracking_pause":
+		return h.actionEndTrackingPause
+	case "link_telegram":
+		return h.actionLinkTelegram
+	case "unlink_telegram":
+		return h.actionUnlinkTelegram
+	case "add_mentor":
+		return h.actionAddMentor
+	case "delete_mentor":
+		return h.actionDeleteMentor
 	}
 	return nil
 }
@@ -213,6 +276,11 @@ func (h *SettingsHandler) actionUpdateUser(w http.ResponseWriter, r *http.Reques
 		return actionResult{http.StatusBadRequest, "", "cannot unset email while subscription is active", nil}
 	}
 
+	emailChanged := !strings.EqualFold(user.Email, payload.Email)
+	if emailChanged {
+		user.EmailVerified = false
+	}
+
 	user.Name = payload.Name
 	user.Email = payload.Email
 	user.Location = payload.Location
@@ -223,6 +291,11 @@ func (h *SettingsHandler) actionUpdateUser(w http.ResponseWriter, r *http.Reques
 		return actionResult{http.StatusInternalServerError, "", conf.ErrInternalServerError, nil}
 	}
 
+	if emailChanged && user.Email != "" && h.config.Mail.Enabled {
+		go h.sendEmailVerification(r, user)
+		return actionResult{http.StatusOK, "user updated successfully, please check your inbox to verify your new e-mail address", "", nil}
+	}
+
 	return actionResult{http.StatusOK, "user updated successfully", "", nil}
 }
 
@@ -250,7 +323,7 @@ func (h *SettingsHandler) actionChangePassword(w http.ResponseWriter, r *http.Re
 	}
 
 	user.Password = credentials.PasswordNew
-	if hash, err := utils.HashPassword(user.Password, h.config.Security.PasswordSalt); err != nil {
+	if hash, err := h.config.Security.PasswordHasher().Hash(user.Password, h.config.Security.PasswordSalt); err != nil {
 		return actionResult{http.StatusInternalServerError, "", conf.ErrInternalServerError, nil}
 	} else {
 		user.Password = hash
@@ -287,6 +360,35 @@ func (h *SettingsHandler) actionResetApiKey(w http.ResponseWriter, r *http.Reque
 	return actionResult{http.StatusOK, msg, "", nil}
 }
 
+func (h *SettingsHandler) actionResetSimpleToken(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+	if _, err := h.userSrvc.ResetSimpleToken(user); err != nil {
+		return actionResult{http.StatusInternalServerError, "", conf.ErrInternalServerError, nil}
+	}
+
+	return actionResult{http.StatusOK, "simple token generated", "", nil}
+}
+
+func (h *SettingsHandler) actionDeleteSimpleToken(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+	defer h.userSrvc.FlushUserCache(user.ID)
+
+	user.SimpleToken = ""
+	if _, err := h.userSrvc.Update(user); err != nil {
+		return actionResult{http.StatusInternalServerError, "", conf.ErrInternalServerError, nil}
+	}
+
+	return actionResult{http.StatusOK, "simple token revoked", "", nil}
+}
+
 func (h *SettingsHandler) actionUpdateLeaderboard(w http.ResponseWriter, r *http.Request) actionResult {
 	if h.config.IsDev() {
 		loadTemplates()
@@ -307,6 +409,29 @@ func (h *SettingsHandler) actionUpdateLeaderboard(w http.ResponseWriter, r *http
 	return actionResult{http.StatusOK, "settings updated", "", nil}
 }
 
+func (h *SettingsHandler) actionUpdateProfile(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	var err error
+	user := middlewares.GetPrincipal(r)
+	defer h.userSrvc.FlushUserCache(user.ID)
+
+	user.PublicProfile, err = strconv.ParseBool(r.PostFormValue("enable_profile"))
+	user.ProfileLanguages, err = strconv.ParseBool(r.PostFormValue("profile_languages"))
+	user.ProfileProjects, err = strconv.ParseBool(r.PostFormValue("profile_projects"))
+	user.ProfileActivity, err = strconv.ParseBool(r.PostFormValue("profile_activity"))
+
+	if err != nil {
+		return actionResult{http.StatusBadRequest, "", "invalid input", nil}
+	}
+	if _, err := h.userSrvc.Update(user); err != nil {
+		return actionResult{http.StatusInternalServerError, "", "internal sever error", nil}
+	}
+	return actionResult{http.StatusOK, "settings updated", "", nil}
+}
+
 func (h *SettingsHandler) actionUpdateExcludeUnknownProjects(w http.ResponseWriter, r *http.Request) actionResult {
 	if h.config.IsDev() {
 		loadTemplates()
@@ -355,6 +480,13 @@ func (h *SettingsHandler) actionUpdateHeartbeatsTimeout(w http.ResponseWriter, r
 	}
 	user.HeartbeatsTimeoutSec = int(val)
 
+	if strategy := r.PostFormValue("overlap_strategy"); strategy != "" {
+		if !slice.Contain(models.OverlapStrategies, strategy) {
+			return actionResult{http.StatusBadRequest, "", "invalid input", nil}
+		}
+		user.OverlapStrategy = strategy
+	}
+
 	if _, err := h.userSrvc.Update(user); err != nil {
 		return actionResult{http.StatusInternalServerError, "", "internal sever error", nil}
 	}
@@ -362,6 +494,189 @@ func (h *SettingsHandler) actionUpdateHeartbeatsTimeout(w http.ResponseWriter, r
 	return actionResult{http.StatusOK, "Done. To apply this change to already existing data, please regenerate your summaries.", "", nil}
 }
 
+func (h *SettingsHandler) actionUpdateEntityNormalization(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	var err error
+	user := middlewares.GetPrincipal(r)
+	defer h.userSrvc.FlushUserCache(user.ID)
+
+	user.UnixEntitySeparators, err = strconv.ParseBool(r.PostFormValue("unix_entity_separators"))
+	user.ScrubEntityHomeDirs, err = strconv.ParseBool(r.PostFormValue("scrub_entity_home_dirs"))
+	user.RelativeEntityPaths, err = strconv.ParseBool(r.PostFormValue("relative_entity_paths"))
+
+	if err != nil {
+		return actionResult{http.StatusBadRequest, "", "invalid input", nil}
+	}
+
+	if _, err := h.userSrvc.Update(user); err != nil {
+		return actionResult{http.StatusInternalServerError, "", "internal sever error", nil}
+	}
+
+	return actionResult{http.StatusOK, "settings updated, changes only apply to newly received heartbeats", "", nil}
+}
+
+func (h *SettingsHandler) actionResetClockSkew(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+	defer h.userSrvc.FlushUserCache(user.ID)
+
+	user.ClockSkewEvents = 0
+	user.LastClockSkewAt = nil
+
+	if _, err := h.userSrvc.Update(user); err != nil {
+		return actionResult{http.StatusInternalServerError, "", "internal sever error", nil}
+	}
+
+	return actionResult{http.StatusOK, "clock skew counter reset", "", nil}
+}
+
+func (h *SettingsHandler) actionUpdateMachineApproval(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	var err error
+	user := middlewares.GetPrincipal(r)
+	defer h.userSrvc.FlushUserCache(user.ID)
+
+	user.RequireMachineApproval, err = strconv.ParseBool(r.PostFormValue("require_machine_approval"))
+	if err != nil {
+		return actionResult{http.StatusBadRequest, "", "invalid input", nil}
+	}
+
+	if user.RequireMachineApproval {
+		// machines seen before are trusted implicitly
+		knownMachines, err := h.heartbeatSrvc.GetEntitySetByUser(models.SummaryMachine, user.ID)
+		if err != nil {
+			return actionResult{http.StatusInternalServerError, "", "internal sever error", nil}
+		}
+		if err := h.machineSrvc.ApproveAll(user, knownMachines); err != nil {
+			conf.Log().Request(r).Error("failed to approve known machines", "userID", user.ID, "error", err)
+			return actionResult{http.StatusInternalServerError, "", "internal sever error", nil}
+		}
+	}
+
+	if _, err := h.userSrvc.Update(user); err != nil {
+		return actionResult{http.StatusInternalServerError, "", "internal sever error", nil}
+	}
+
+	return actionResult{http.StatusOK, "settings updated", "", nil}
+}
+
+func (h *SettingsHandler) actionUpdateLocationTracking(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	var err error
+	user := middlewares.GetPrincipal(r)
+	defer h.userSrvc.FlushUserCache(user.ID)
+
+	user.TrackLocation, err = strconv.ParseBool(r.PostFormValue("track_location"))
+	if err != nil {
+		return actionResult{http.StatusBadRequest, "", "invalid input", nil}
+	}
+
+	if !user.TrackLocation {
+		if err := h.machineSrvc.ClearCountries(user); err != nil {
+			conf.Log().Request(r).Error("failed to clear machine locations", "userID", user.ID, "error", err)
+			return actionResult{http.StatusInternalServerError, "", "internal sever error", nil}
+		}
+	}
+
+	if _, err := h.userSrvc.Update(user); err != nil {
+		return actionResult{http.StatusInternalServerError, "", "internal sever error", nil}
+	}
+
+	return actionResult{http.StatusOK, "settings updated", "", nil}
+}
+
+func (h *SettingsHandler) actionPauseTracking(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+
+	from, errFrom := time.ParseInLocation(conf.SimpleDateFormat, r.PostFormValue("pause_from"), user.TZ())
+	to, errTo := time.ParseInLocation(conf.SimpleDateFormat, r.PostFormValue("pause_to"), user.TZ())
+	if errFrom != nil || errTo != nil {
+		return actionResult{http.StatusBadRequest, "", "invalid input", nil}
+	}
+
+	if _, err := h.trackingPauseSrvc.Create(user, models.NewTrackingPause(from, to, r.PostFormValue("pause_note"))); err != nil {
+		if errors.Is(err, services.ErrTrackingPauseInvalid) {
+			return actionResult{http.StatusBadRequest, "", err.Error(), nil}
+		}
+		conf.Log().Request(r).Error("failed to create tracking pause", "userID", user.ID, "error", err)
+		return actionResult{http.StatusInternalServerError, "", "internal sever error", nil}
+	}
+
+	return actionResult{http.StatusOK, "tracking paused", "", nil}
+}
+
+func (h *SettingsHandler) actionEndTrackingPause(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+
+	id, err := strconv.ParseUint(r.PostFormValue("pause_id"), 10, 64)
+	if err != nil {
+		return actionResult{http.StatusBadRequest, "", "invalid input", nil}
+	}
+
+	if err := h.trackingPauseSrvc.End(user, uint(id)); err != nil {
+		if errors.Is(err, services.ErrTrackingPauseInvalid) || errors.Is(err, services.ErrTrackingPauseNotFound) {
+			return actionResult{http.StatusBadRequest, "", err.Error(), nil}
+		}
+		conf.Log().Request(r).Error("failed to end tracking pause", "userID", user.ID, "error", err)
+		return actionResult{http.StatusInternalServerError, "", "internal sever error", nil}
+	}
+
+	return actionResult{http.StatusOK, "tracking resumed", "", nil}
+}
+
+func (h *SettingsHandler) actionApproveMachine(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+	machineName := r.PostFormValue("machine_name")
+
+	released, err := h.machineSrvc.Approve(user, machineName)
+	if err != nil {
+		conf.Log().Request(r).Error("failed to approve machine", "userID", user.ID, "error", err)
+		return actionResult{http.StatusInternalServerError, "", "could not approve machine", nil}
+	}
+
+	return actionResult{http.StatusOK, fmt.Sprintf("machine approved, %d quarantined heartbeats were released", released), "", nil}
+}
+
+func (h *SettingsHandler) actionRejectMachine(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+	machineName := r.PostFormValue("machine_name")
+
+	if err := h.machineSrvc.Reject(user, machineName); err != nil {
+		conf.Log().Request(r).Error("failed to reject machine", "userID", user.ID, "error", err)
+		return actionResult{http.StatusInternalServerError, "", "could not reject machine", nil}
+	}
+
+	return actionResult{http.StatusOK, "machine rejected, its heartbeats will be dropped from now on, consider resetting your api key", "", nil}
+}
+
 func (h *SettingsHandler) actionUpdateSharing(w http.ResponseWriter, r *http.Request) actionResult {
 	if h.config.IsDev() {
 		loadTemplates()
@@ -378,6 +693,7 @@ func (h *SettingsHandler) actionUpdateSharing(w http.ResponseWriter, r *http.Req
 	user.ShareOSs, err = strconv.ParseBool(r.PostFormValue("share_oss"))
 	user.ShareMachines, err = strconv.ParseBool(r.PostFormValue("share_machines"))
 	user.ShareLabels, err = strconv.ParseBool(r.PostFormValue("share_labels"))
+	user.HideProjectNames, err = strconv.ParseBool(r.PostFormValue("hide_project_names"))
 	user.ShareDataMaxDays, err = strconv.Atoi(r.PostFormValue("max_days"))
 
 	if err != nil {
@@ -669,7 +985,7 @@ func (h *SettingsHandler) actionImportWakatime(w http.ResponseWriter, r *http.Re
 			}
 		}
 
-		if user.Email != "" {
+		if user.HasTrustedEmail() {
 			if err := h.mailSrvc.SendImportNotification(user, time.Now().Sub(start), int(countAfter-countBefore)); err != nil {
 				conf.Log().Request(r).Error("failed to send import notification mail", "userID", user.ID, "error", err)
 			} else {
@@ -686,6 +1002,156 @@ func (h *SettingsHandler) actionImportWakatime(w http.ResponseWriter, r *http.Re
 	return actionResult{http.StatusAccepted, "Import started. This will take several minutes. Please check back later.", "", nil}
 }
 
+func (h *SettingsHandler) actionExportWakatime(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+	if !user.HasTrustedEmail() {
+		return actionResult{http.StatusBadRequest, "", "you need to set (and verify) an e-mail address to receive the export", nil}
+	}
+
+	kvKeyLastExport := fmt.Sprintf("%s_%s", conf.KeyLastExport, user.ID)
+
+	// exports are just as expensive as imports, so they're subject to the same backoff
+	if !h.config.IsDev() {
+		lastExport, _ := time.Parse(time.RFC822, h.keyValueSrvc.MustGetString(kvKeyLastExport).Value)
+		if time.Now().Sub(lastExport) < time.Duration(h.config.App.ImportBackoffMin)*time.Minute {
+			return actionResult{
+				http.StatusTooManyRequests,
+				"",
+				fmt.Sprintf("Too many data exports - you are only allowed to request an export every %d minutes.", h.config.App.ImportBackoffMin),
+				nil,
+			}
+		}
+	}
+
+	go func(user *models.User) {
+		link, err := h.exportSrvc.RunWakatimeExport(user)
+		if err != nil {
+			conf.Log().Request(r).Error("wakatime export for user failed", "userID", user.ID, "error", err)
+			return
+		}
+
+		if err := h.mailSrvc.SendExportNotification(user, link); err != nil {
+			conf.Log().Request(r).Error("failed to send export notification mail", "userID", user.ID, "error", err)
+		} else {
+			slog.Info("sent export notification mail", "userID", user.ID)
+		}
+	}(user)
+
+	h.keyValueSrvc.PutString(&models.KeyStringValue{
+		Key:   kvKeyLastExport,
+		Value: time.Now().Format(time.RFC822),
+	})
+
+	return actionResult{http.StatusAccepted, "Export started. You will receive an e-mail with a download link once it's ready.", "", nil}
+}
+
+func (h *SettingsHandler) actionExportHtml(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+	if !user.HasTrustedEmail() {
+		return actionResult{http.StatusBadRequest, "", "you need to set (and verify) an e-mail address to receive the export", nil}
+	}
+
+	from, errFrom := time.ParseInLocation(conf.SimpleDateFormat, r.PostFormValue("from"), user.TZ())
+	to, errTo := time.ParseInLocation(conf.SimpleDateFormat, r.PostFormValue("to"), user.TZ())
+	if errFrom != nil || errTo != nil || to.Before(from) {
+		return actionResult{http.StatusBadRequest, "", "invalid export range", nil}
+	}
+	to = to.AddDate(0, 0, 1) // include the last day
+
+	kvKeyLastExport := fmt.Sprintf("%s_%s", conf.KeyLastExport, user.ID)
+
+	// shares its backoff with the wakatime export
+	if !h.config.IsDev() {
+		lastExport, _ := time.Parse(time.RFC822, h.keyValueSrvc.MustGetString(kvKeyLastExport).Value)
+		if time.Now().Sub(lastExport) < time.Duration(h.config.App.ImportBackoffMin)*time.Minute {
+			return actionResult{
+				http.StatusTooManyRequests,
+				"",
+				fmt.Sprintf("Too many data exports - you are only allowed to request an export every %d minutes.", h.config.App.ImportBackoffMin),
+				nil,
+			}
+		}
+	}
+
+	go func(user *models.User) {
+		link, err := h.exportSrvc.RunHtmlExport(user, from, to)
+		if err != nil {
+			conf.Log().Request(r).Error("html export for user failed", "userID", user.ID, "error", err)
+			return
+		}
+
+		if err := h.mailSrvc.SendExportNotification(user, link); err != nil {
+			conf.Log().Request(r).Error("failed to send export notification mail", "userID", user.ID, "error", err)
+		} else {
+			slog.Info("sent export notification mail", "userID", user.ID)
+		}
+	}(user)
+
+	h.keyValueSrvc.PutString(&models.KeyStringValue{
+		Key:   kvKeyLastExport,
+		Value: time.Now().Format(time.RFC822),
+	})
+
+	return actionResult{http.StatusAccepted, "Export started. You will receive an e-mail with a download link once it's ready.", "", nil}
+}
+
+func (h *SettingsHandler) actionResendEmailVerification(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	if !h.config.Mail.Enabled {
+		return actionResult{http.StatusNotImplemented, "", "mailing is disabled on this server", nil}
+	}
+
+	user := middlewares.GetPrincipal(r)
+	if user.Email == "" {
+		return actionResult{http.StatusBadRequest, "", "you need to set an e-mail address first", nil}
+	}
+	if user.EmailVerified {
+		return actionResult{http.StatusBadRequest, "", "your e-mail address is already verified", nil}
+	}
+
+	kvKeyLastVerification := fmt.Sprintf("%s_%s", conf.KeyLastEmailVerification, user.ID)
+
+	if !h.config.IsDev() {
+		lastVerification, _ := time.Parse(time.RFC822, h.keyValueSrvc.MustGetString(kvKeyLastVerification).Value)
+		if time.Now().Sub(lastVerification) < emailVerificationBackoff {
+			return actionResult{
+				http.StatusTooManyRequests,
+				"",
+				fmt.Sprintf("Too many requests - you can only request a new verification mail every %d minutes.", int(emailVerificationBackoff.Minutes())),
+				nil,
+			}
+		}
+	}
+
+	go h.sendEmailVerification(r, user)
+
+	h.keyValueSrvc.PutString(&models.KeyStringValue{
+		Key:   kvKeyLastVerification,
+		Value: time.Now().Format(time.RFC822),
+	})
+
+	return actionResult{http.StatusAccepted, "Verification mail sent, please check your inbox.", "", nil}
+}
+
+func (h *SettingsHandler) sendEmailVerification(r *http.Request, user *models.User) {
+	if err := h.emailVerificationSrvc.SendVerification(user); err != nil {
+		conf.Log().Request(r).Error("failed to send e-mail verification mail", "userID", user.ID, "error", err)
+	} else {
+		slog.Info("sent e-mail verification mail", "userID", user.ID)
+	}
+}
+
 func (h *SettingsHandler) actionRegenerateSummaries(w http.ResponseWriter, r *http.Request) actionResult {
 	if h.config.IsDev() {
 		loadTemplates()
@@ -759,12 +1225,10 @@ func (h *SettingsHandler) actionGenerateInvite(w http.ResponseWriter, r *http.Re
 	}
 
 	user := middlewares.GetPrincipal(r)
-	inviteCode := uuid.Must(uuid.NewV4()).String()[0:8]
 
-	if err := h.keyValueSrvc.PutString(&models.KeyStringValue{
-		Key:   fmt.Sprintf("%s_%s", conf.KeyInviteCode, inviteCode),
-		Value: fmt.Sprintf("%s,%s", user.ID, time.Now().Format(time.RFC3339)),
-	}); err != nil {
+	// invite codes generated by users are single-use and valid for a day, admins can create others via the api
+	inviteCode, err := h.registrationSrvc.CreateInviteCode(user.ID, 1, 24*time.Hour)
+	if err != nil {
 		return actionResult{http.StatusInternalServerError, "", "failed to generate invite code", nil}
 	}
 
@@ -773,11 +1237,88 @@ func (h *SettingsHandler) actionGenerateInvite(w http.ResponseWriter, r *http.Re
 		"Successfully generated new invite code (see below)",
 		"",
 		&map[string]interface{}{
-			valueInviteCode: inviteCode,
+			valueInviteCode: inviteCode.Code,
 		},
 	}
 }
 
+func (h *SettingsHandler) actionLinkTelegram(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+
+	link, err := h.telegramSrvc.CreateLink(user)
+	if err != nil {
+		return actionResult{http.StatusInternalServerError, "", "failed to create telegram link code", nil}
+	}
+
+	return actionResult{
+		http.StatusOK,
+		"Successfully created a link code, send it to the bot within 15 minutes (see below)",
+		"",
+		&map[string]interface{}{
+			valueTelegramLink: link,
+		},
+	}
+}
+
+func (h *SettingsHandler) actionUnlinkTelegram(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+
+	if _, err := h.telegramSrvc.Unlink(user); err != nil {
+		return actionResult{http.StatusInternalServerError, "", "failed to unlink telegram chat", nil}
+	}
+
+	return actionResult{http.StatusOK, "telegram chat unlinked", "", nil}
+}
+
+func (h *SettingsHandler) actionAddMentor(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+
+	if _, err := h.mentorSrvc.Create(user, models.NewMentor(r.PostFormValue("mentor_email"))); err != nil {
+		if errors.Is(err, services.ErrMentorInvalid) {
+			return actionResult{http.StatusBadRequest, "", err.Error(), nil}
+		}
+		conf.Log().Request(r).Error("failed to add mentor", "userID", user.ID, "error", err)
+		return actionResult{http.StatusInternalServerError, "", "internal sever error", nil}
+	}
+
+	return actionResult{http.StatusOK, "mentor added", "", nil}
+}
+
+func (h *SettingsHandler) actionDeleteMentor(w http.ResponseWriter, r *http.Request) actionResult {
+	if h.config.IsDev() {
+		loadTemplates()
+	}
+
+	user := middlewares.GetPrincipal(r)
+
+	id, err := strconv.ParseUint(r.PostFormValue("mentor_id"), 10, 64)
+	if err != nil {
+		return actionResult{http.StatusBadRequest, "", "invalid input", nil}
+	}
+
+	if err := h.mentorSrvc.Delete(user, uint(id)); err != nil {
+		if errors.Is(err, services.ErrMentorNotFound) {
+			return actionResult{http.StatusBadRequest, "", err.Error(), nil}
+		}
+		conf.Log().Request(r).Error("failed to delete mentor", "userID", user.ID, "error", err)
+		return actionResult{http.StatusInternalServerError, "", "internal sever error", nil}
+	}
+
+	return actionResult{http.StatusOK, "mentor removed", "", nil}
+}
+
 func (h *SettingsHandler) validateWakatimeKey(apiKey string, baseUrl string) bool {
 	if baseUrl == "" {
 		baseUrl = conf.WakatimeApiUrl
@@ -905,6 +1446,25 @@ func (h *SettingsHandler) buildViewModel(r *http.Request, w http.ResponseWriter,
 		}
 	}
 
+	// machines
+	machines, err := h.machineSrvc.GetByUser(user.ID)
+	if err != nil {
+		conf.Log().Request(r).Error("error while fetching machines", "error", err)
+	}
+	quarantinedCounts, err := h.machineSrvc.CountQuarantinedByUser(user.ID)
+	if err != nil {
+		conf.Log().Request(r).Error("error while counting quarantined heartbeats", "error", err)
+	}
+
+	// tracking pauses, only those not over yet
+	pauses, err := h.trackingPauseSrvc.GetByUser(user.ID)
+	if err != nil {
+		conf.Log().Request(r).Error("error while fetching tracking pauses", "error", err)
+	}
+	pauses = slice.Filter[*models.TrackingPause](pauses, func(i int, p *models.TrackingPause) bool {
+		return p.ToTime.T().After(time.Now())
+	})
+
 	// subscriptions
 	var subscriptionPrice string
 	if h.config.Subscriptions.Enabled {
@@ -922,6 +1482,26 @@ func (h *SettingsHandler) buildViewModel(r *http.Request, w http.ResponseWriter,
 	inviteCode := getVal[string](args, valueInviteCode, "")
 	inviteLink := condition.TernaryOperator[bool, string](inviteCode == "", "", fmt.Sprintf("%s/signup?invite=%s", h.config.Server.GetPublicUrl(), inviteCode))
 
+	// telegram
+	var telegramLinked bool
+	if h.config.Telegram.Enabled() {
+		externalIds, err := h.userSrvc.GetExternalIds(user)
+		if err != nil {
+			conf.Log().Request(r).Error("error while fetching external ids", "error", err)
+		}
+		telegramLinked = slice.ContainBy[*models.UserExternalId](externalIds, func(id *models.UserExternalId) bool {
+			return id.Provider == models.ExternalIdProviderTelegram
+		})
+	}
+
+	// mentors
+	var mentors []*models.Mentor
+	if h.config.Mail.Enabled {
+		if mentors, err = h.mentorSrvc.GetByUser(user.ID); err != nil {
+			conf.Log().Request(r).Error("error while fetching mentors", "error", err)
+		}
+	}
+
 	vm := &view.SettingsViewModel{
 		SharedLoggedInViewModel: view.SharedLoggedInViewModel{
 			SharedViewModel: view.NewSharedViewModel(h.config, nil),
@@ -932,11 +1512,20 @@ func (h *SettingsHandler) buildViewModel(r *http.Request, w http.ResponseWriter,
 		Aliases:             combinedAliases,
 		Labels:              combinedLabels,
 		Projects:            projects,
+		Machines:            machines,
+		QuarantinedCounts:   quarantinedCounts,
 		UserFirstData:       firstData,
 		SubscriptionPrice:   subscriptionPrice,
 		SupportContact:      h.config.App.SupportContact,
 		DataRetentionMonths: h.config.App.DataRetentionMonths,
 		InviteLink:          inviteLink,
+		GeoEnabled:          h.config.App.GeoIpDb != "",
+		TrackingPauses:      pauses,
+		TelegramEnabled:     h.config.Telegram.Enabled(),
+		TelegramLinked:      telegramLinked,
+		TelegramLink:        getVal[*models.TelegramLink](args, valueTelegramLink, nil),
+		MentorsEnabled:      h.config.Mail.Enabled,
+		Mentors:             mentors,
 	}
 	return routeutils.WithSessionMessages(vm, r, w)
 }
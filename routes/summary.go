@@ -36,6 +36,7 @@ func (h *SummaryHandler) RegisterRoutes(router chi.Router) {
 	r.Use(middlewares.NewAuthenticateMiddleware(h.userSrvc).
 		WithRedirectTarget(defaultErrorRedirectTarget()).
 		WithRedirectErrorMessage("unauthorized").Handler,
+		middlewares.NewTimeoutMiddleware(h.config.Server.RequestTimeouts.GetSummary()),
 	)
 	r.Get("/", h.GetIndex)
 
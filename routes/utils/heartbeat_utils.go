@@ -3,6 +3,7 @@ package utils
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 
@@ -34,6 +35,11 @@ func tryParseBulk(r *http.Request) ([]*models.Heartbeat, error) {
 	if err := dec.Decode(&heartbeats); err != nil {
 		return nil, err
 	}
+	for _, h := range heartbeats {
+		if h == nil {
+			return nil, errors.New("invalid heartbeat")
+		}
+	}
 
 	return heartbeats, nil
 }
@@ -52,3 +58,14 @@ func tryParseSingle(r *http.Request) ([]*models.Heartbeat, error) {
 
 	return []*models.Heartbeat{&heartbeat}, nil
 }
+
+func ParseWatcherEvents(r *http.Request) (*models.WatcherEvents, error) {
+	var events models.WatcherEvents
+	if err := json.NewDecoder(r.Body).Decode(&events); err != nil {
+		return nil, err
+	}
+	if len(events.Events) == 0 {
+		return nil, errors.New("no events given")
+	}
+	return &events, nil
+}
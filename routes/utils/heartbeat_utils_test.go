@@ -0,0 +1,65 @@
+package utils
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseHeartbeats(t *testing.T) {
+	single := `{"entity": "/home/bob/dev/wakapi/main.go", "type": "file", "project": "wakapi", "language": "Go", "time": 1709542815.123}`
+	bulk := `[` + single + `, ` + single + `]`
+
+	heartbeats, err := ParseHeartbeats(newHeartbeatsRequest(single))
+	assert.Nil(t, err)
+	assert.Len(t, heartbeats, 1)
+	assert.Equal(t, "wakapi", heartbeats[0].Project)
+
+	heartbeats, err = ParseHeartbeats(newHeartbeatsRequest(bulk))
+	assert.Nil(t, err)
+	assert.Len(t, heartbeats, 2)
+
+	_, err = ParseHeartbeats(newHeartbeatsRequest(`[null]`))
+	assert.NotNil(t, err)
+	_, err = ParseHeartbeats(newHeartbeatsRequest(`{"time": "foo"}`))
+	assert.NotNil(t, err)
+}
+
+func FuzzParseHeartbeats(f *testing.F) {
+	f.Add(`{"entity": "/home/bob/dev/wakapi/main.go", "type": "file", "project": "wakapi", "language": "Go", "time": 1709542815.123}`)
+	f.Add(`[{"entity": "main.go", "time": 1709542815}, {"entity": "https://wakapi.dev", "type": "domain", "time": "1709542816.5"}]`)
+	f.Add(`[{"entity": "main.go", "lines": 12, "line_additions": 3, "line_deletions": 1, "is_write": true, "time": 1}]`)
+	f.Add(`[]`)
+	f.Add(`null`)
+	f.Add(`[null]`)
+	f.Add(`{}`)
+	f.Add(`{"time": 1e400}`)
+	f.Add(`[{"lines": -1}]`)
+
+	f.Fuzz(func(t *testing.T, body string) {
+		r := newHeartbeatsRequest(body)
+		heartbeats, err := ParseHeartbeats(r)
+
+		if err == nil {
+			for _, h := range heartbeats {
+				if h == nil {
+					t.Fatalf("got nil heartbeat for body %q", body)
+				}
+			}
+		}
+
+		// the body must remain readable for subsequent handlers, e.g. the relay middleware
+		rest, _ := io.ReadAll(r.Body)
+		if !bytes.Equal(rest, []byte(body)) {
+			t.Fatalf("request body was altered from %q to %q", body, rest)
+		}
+	})
+}
+
+func newHeartbeatsRequest(body string) *http.Request {
+	return httptest.NewRequest(http.MethodPost, "/api/heartbeats", bytes.NewBufferString(body))
+}
@@ -35,6 +35,11 @@ func GetEffectiveProjectsList(user *models.User, heartbeatSrvc services.IHeartbe
 		projects.Add(a.Key)
 	}
 
+	// hide archived projects from pickers
+	for _, p := range user.ArchivedProjects {
+		projects.Delete(p)
+	}
+
 	sorted := projects.Values()
 	sort.Strings(sorted)
 	return sorted, nil
@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -15,7 +16,7 @@ func LoadUserSummary(ss services.ISummaryService, r *http.Request) (*models.Summ
 	if err != nil {
 		return nil, err, http.StatusBadRequest
 	}
-	return LoadUserSummaryByParams(ss, summaryParams)
+	return LoadUserSummaryByParams(services.SummaryServiceWithContext(ss, r.Context()), summaryParams)
 }
 
 func LoadUserSummaryByParams(ss services.ISummaryService, params *models.SummaryParams) (*models.Summary, error, int) {
@@ -33,15 +34,34 @@ func LoadUserSummaryByParams(ss services.ISummaryService, params *models.Summary
 		params.Recompute,
 	)
 	if err != nil {
-		return nil, err, http.StatusInternalServerError
+		return nil, err, SummaryErrorStatus(err)
 	}
 
+	if !params.IncludeArchived && !params.HasFilters() {
+		summary = summary.WithoutProjects(params.User.ArchivedProjects)
+	}
+
+	// the summary might be shared via the cache, so never modify it in place
+	copied := *summary
+	summary = &copied
 	summary.FromTime = models.CustomTime(summary.FromTime.T().In(params.User.TZ()))
 	summary.ToTime = models.CustomTime(summary.ToTime.T().In(params.User.TZ()))
 
 	return summary, nil, http.StatusOK
 }
 
+// SummaryErrorStatus maps errors from retrieving a summary to a response status, i.e. 503 while summaries can only be served from cache
+// and 504 if the summary couldn't be computed before the route's deadline
+func SummaryErrorStatus(err error) int {
+	if errors.Is(err, services.ErrDegraded) {
+		return http.StatusServiceUnavailable
+	}
+	if errors.Is(err, services.ErrSummaryTimeout) {
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
 func FilterColors(all map[string]string, haystack models.SummaryItems) map[string]string {
 	subset := make(map[string]string)
 	for _, item := range haystack {
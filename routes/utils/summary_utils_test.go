@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLoadUserSummaryByParams_ArchivedProjects(t *testing.T) {
+	user := &models.User{ID: "user1", Location: "Europe/Berlin", ArchivedProjects: models.StringList{"dead-project"}}
+	from, to := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	// same instance on every call, like a summary served from cache
+	cached := &models.Summary{
+		FromTime: models.CustomTime(from),
+		ToTime:   models.CustomTime(to),
+		Projects: models.SummaryItems{
+			{Type: models.SummaryProject, Key: "wakapi", Total: 50 * time.Second},
+			{Type: models.SummaryProject, Key: "dead-project", Total: 20 * time.Second},
+		},
+	}
+
+	summaryService := new(mocks.SummaryServiceMock)
+	summaryService.On("Aliased", from, to, user, mock.Anything, mock.Anything).Return(cached, nil)
+
+	summary, err, status := LoadUserSummaryByParams(summaryService, &models.SummaryParams{From: from, To: to, User: user})
+	assert.Nil(t, err)
+	assert.Equal(t, 200, status)
+	assert.Len(t, summary.Projects, 2)
+	assert.Equal(t, models.ArchivedSummaryKey, summary.Projects[1].Key)
+
+	summary, err, _ = LoadUserSummaryByParams(summaryService, &models.SummaryParams{From: from, To: to, User: user, IncludeArchived: true})
+	assert.Nil(t, err)
+	assert.Len(t, summary.Projects, 2)
+	assert.Equal(t, "dead-project", summary.Projects[1].Key)
+
+	// cached summary was never modified
+	assert.Len(t, cached.Projects, 2)
+	assert.Equal(t, "dead-project", cached.Projects[1].Key)
+	assert.Equal(t, time.UTC, cached.FromTime.T().Location())
+}
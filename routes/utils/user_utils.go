@@ -6,18 +6,18 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	conf "github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
 	"github.com/hackclub/hackatime/middlewares"
 	"github.com/hackclub/hackatime/models"
 	"github.com/hackclub/hackatime/services"
 )
 
-// CheckEffectiveUser extracts the requested user from a URL (like '/users/{user}'), compares it with the currently authorized user and writes an HTTP error if they differ.
+// CheckEffectiveUser extracts the requested user (by username or external id) from a URL (like '/users/{user}'), compares it with the currently authorized user and writes an HTTP error if they differ.
 // Fallback can be used to manually set a value for '{user}' if none is present.
 func CheckEffectiveUser(w http.ResponseWriter, r *http.Request, userService services.IUserService, fallback string) (*models.User, error) {
 	respondError := func(code int, text string) (*models.User, error) {
 		err := errors.New(conf.ErrUnauthorized)
-		w.WriteHeader(http.StatusUnauthorized)
-		w.Write([]byte(err.Error()))
+		helpers.RespondError(w, r, http.StatusUnauthorized, models.ApiErrorUnauthorized, err.Error())
 		return nil, err
 	}
 
@@ -33,14 +33,18 @@ func CheckEffectiveUser(w http.ResponseWriter, r *http.Request, userService serv
 		return authorizedUser, nil
 	}
 
-	if authorizedUser.ID != userParam && !authorizedUser.IsAdmin {
+	// external ids can only be compared to the authorized user after resolving them
+	if _, _, isExternal := models.ParseExternalUserRef(userParam); !isExternal && authorizedUser.ID != userParam && !authorizedUser.IsAdmin {
 		return respondError(http.StatusUnauthorized, conf.ErrUnauthorized)
 	}
 
-	requestedUser, err := userService.GetUserById(userParam)
+	requestedUser, err := userService.GetUserByRef(userParam)
 	if err != nil {
 		return respondError(http.StatusNotFound, "user not found")
 	}
+	if authorizedUser.ID != requestedUser.ID && !authorizedUser.IsAdmin {
+		return respondError(http.StatusUnauthorized, conf.ErrUnauthorized)
+	}
 
 	return requestedUser, nil
 }
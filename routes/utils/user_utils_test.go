@@ -20,7 +20,7 @@ func TestCheckEffectiveUser_Current(t *testing.T) {
 	user, err := CheckEffectiveUser(w, r, userServiceMock, "current")
 	assert.Nil(t, err)
 	assert.Equal(t, "user1", user.ID)
-	userServiceMock.AssertNumberOfCalls(t, "GetUserById", 0)
+	userServiceMock.AssertNumberOfCalls(t, "GetUserByRef", 0)
 }
 
 func TestCheckEffectiveUser_Other(t *testing.T) {
@@ -29,8 +29,8 @@ func TestCheckEffectiveUser_Other(t *testing.T) {
 	user, err := CheckEffectiveUser(w, r, userServiceMock, "current")
 	assert.Nil(t, err)
 	assert.Equal(t, "user2", user.ID)
-	userServiceMock.AssertCalled(t, "GetUserById", "user2")
-	userServiceMock.AssertNumberOfCalls(t, "GetUserById", 1)
+	userServiceMock.AssertCalled(t, "GetUserByRef", "user2")
+	userServiceMock.AssertNumberOfCalls(t, "GetUserByRef", 1)
 }
 
 func TestCheckEffectiveUser_FallbackUnauthorized(t *testing.T) {
@@ -39,7 +39,7 @@ func TestCheckEffectiveUser_FallbackUnauthorized(t *testing.T) {
 	user, err := CheckEffectiveUser(w, r, userServiceMock, "current")
 	assert.NotNil(t, err)
 	assert.Nil(t, user)
-	userServiceMock.AssertNumberOfCalls(t, "GetUserById", 0)
+	userServiceMock.AssertNumberOfCalls(t, "GetUserByRef", 0)
 }
 
 func TestCheckEffectiveUser_FallbackEmpty(t *testing.T) {
@@ -48,7 +48,7 @@ func TestCheckEffectiveUser_FallbackEmpty(t *testing.T) {
 	user, err := CheckEffectiveUser(w, r, userServiceMock, "current")
 	assert.Nil(t, err)
 	assert.Equal(t, "user1", user.ID)
-	userServiceMock.AssertNumberOfCalls(t, "GetUserById", 0)
+	userServiceMock.AssertNumberOfCalls(t, "GetUserByRef", 0)
 }
 
 func TestCheckEffectiveUser_FallbackUnauthenticated(t *testing.T) {
@@ -57,7 +57,7 @@ func TestCheckEffectiveUser_FallbackUnauthenticated(t *testing.T) {
 	user, err := CheckEffectiveUser(w, r, userServiceMock, "current")
 	assert.NotNil(t, err)
 	assert.Nil(t, user)
-	userServiceMock.AssertNumberOfCalls(t, "GetUserById", 0)
+	userServiceMock.AssertNumberOfCalls(t, "GetUserByRef", 0)
 }
 
 func mockUserAwareRequest(requestedUser, authorizedUser string) (*http.Request, http.ResponseWriter, *mocks.UserServiceMock) {
@@ -76,9 +76,9 @@ func mockUserAwareRequest(requestedUser, authorizedUser string) (*http.Request,
 	r = r.WithContext(context.WithValue(r.Context(), "principal", &testPrincipal))
 
 	userServiceMock := new(mocks.UserServiceMock)
-	userServiceMock.On("GetUserById", "user1").Return(&models.User{ID: "user1"}, nil)
-	userServiceMock.On("GetUserById", "user2").Return(&models.User{ID: "user2"}, nil)
-	userServiceMock.On("GetUserById", "admin").Return(&models.User{ID: "admin"}, nil)
+	userServiceMock.On("GetUserByRef", "user1").Return(&models.User{ID: "user1"}, nil)
+	userServiceMock.On("GetUserByRef", "user2").Return(&models.User{ID: "user2"}, nil)
+	userServiceMock.On("GetUserByRef", "admin").Return(&models.User{ID: "admin"}, nil)
 
 	return r, httptest.NewRecorder(), userServiceMock
 }
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/muety/artifex/v2"
+	"gorm.io/gorm"
+)
+
+var ErrActivityGraphYearInvalid = errors.New("activity graphs are only available for the current and past years")
+
+// ActivityGraphService precomputes per-day activity of the current year for every user once a day, so that contribution calendars
+// can be served without aggregating a whole year of summaries upon every request. Graphs of past years are generated once on demand.
+type ActivityGraphService struct {
+	config       *config.Config
+	repository   repositories.IActivityGraphRepository
+	summarySrvc  ISummaryService
+	userSrvc     IUserService
+	queueDefault *artifex.Dispatcher
+	queueWorkers *artifex.Dispatcher
+}
+
+func NewActivityGraphService(activityGraphRepo repositories.IActivityGraphRepository, summaryService ISummaryService, userService IUserService) *ActivityGraphService {
+	return &ActivityGraphService{
+		config:       config.Get(),
+		repository:   activityGraphRepo,
+		summarySrvc:  summaryService,
+		userSrvc:     userService,
+		queueDefault: config.GetDefaultQueue(),
+		queueWorkers: config.GetQueue(config.QueueProcessing),
+	}
+}
+
+func (srv *ActivityGraphService) Schedule() {
+	slog.Info("scheduling activity graph generation")
+
+	_, err := srv.queueDefault.DispatchCron(func() {
+		users, err := srv.userSrvc.GetAll()
+		if err != nil {
+			config.Log().Error("failed to fetch users for activity graph generation", "error", err)
+			return
+		}
+
+		slog.Info("generating activity graphs", "userCount", len(users))
+		for _, u := range users {
+			if !u.HasData {
+				continue
+			}
+			user := u
+			// the year yesterday belonged to, so that the final day of a year is included, too
+			year := time.Now().In(user.TZ()).AddDate(0, 0, -1).Year()
+			if err := srv.queueWorkers.Dispatch(func() {
+				if _, err := srv.Generate(user, year); err != nil {
+					config.Log().Error("failed to generate activity graph", "userID", user.ID, "year", year, "error", err)
+				}
+			}); err != nil {
+				config.Log().Error("failed to dispatch activity graph generation", "userID", user.ID, "year", year, "error", err)
+			}
+		}
+	}, srv.config.App.GetActivityGraphCron())
+
+	if err != nil {
+		config.Log().Error("failed to schedule activity graph generation", "error", err)
+	}
+}
+
+// GetByUser returns the user's precomputed activity graph of the given year and generates it first, if it doesn't exist yet
+func (srv *ActivityGraphService) GetByUser(user *models.User, year int) (*models.ActivityGraph, error) {
+	if !srv.isValidYear(user, year) {
+		return nil, ErrActivityGraphYearInvalid
+	}
+
+	graph, err := srv.repository.GetByUserAndYear(user.ID, year)
+	if err == nil {
+		return graph, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	return srv.Generate(user, year)
+}
+
+// Generate computes the user's activity graph of the given year up until (excluding) today and persists it
+func (srv *ActivityGraphService) Generate(user *models.User, year int) (*models.ActivityGraph, error) {
+	if !srv.isValidYear(user, year) {
+		return nil, ErrActivityGraphYearInvalid
+	}
+
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, user.TZ())
+	to := from.AddDate(1, 0, 0)
+	if today := datetime.BeginOfDay(time.Now().In(user.TZ())); today.Before(to) {
+		to = today
+	}
+
+	var dates []time.Time
+	var totals []time.Duration
+	if to.After(from) {
+		summaries, err := retrieveDailySummaries(srv.summarySrvc, user, from, to)
+		if err != nil {
+			return nil, err
+		}
+		for i, d := 0, from; d.Before(to); i, d = i+1, d.AddDate(0, 0, 1) {
+			var total time.Duration
+			if i < len(summaries) && summaries[i] != nil {
+				total = summaries[i].TotalTime()
+			}
+			dates = append(dates, d)
+			totals = append(totals, total)
+		}
+	}
+
+	graph := &models.ActivityGraph{
+		UserID:    user.ID,
+		Year:      year,
+		Days:      models.NewActivityGraphDays(dates, totals),
+		UpdatedAt: models.CustomTime(time.Now()),
+	}
+	if err := srv.repository.Upsert(graph); err != nil {
+		return nil, err
+	}
+	return graph, nil
+}
+
+func (srv *ActivityGraphService) isValidYear(user *models.User, year int) bool {
+	return year > 0 && year <= time.Now().In(user.TZ()).Year()
+}
@@ -7,10 +7,14 @@ import (
 	"time"
 
 	datastructure "github.com/duke-git/lancet/v2/datastructure/set"
+	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/gofrs/uuid/v5"
 	"github.com/hackclub/hackatime/config"
 	"github.com/muety/artifex/v2"
+	"github.com/patrickmn/go-cache"
 
 	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/utils"
 )
 
 const (
@@ -19,12 +23,15 @@ const (
 
 var aggregationLock = sync.Mutex{}
 
+var ErrAggregationInProgress = errors.New("aggregation already in progress for at least of the request users")
+
 type AggregationService struct {
 	config           *config.Config
 	userService      IUserService
 	summaryService   ISummaryService
 	heartbeatService IHeartbeatService
 	inProgress       datastructure.Set[string]
+	resummarizeJobs  *cache.Cache
 	queueDefault     *artifex.Dispatcher
 	queueWorkers     *artifex.Dispatcher
 }
@@ -36,6 +43,7 @@ func NewAggregationService(userService IUserService, summaryService ISummaryServ
 		summaryService:   summaryService,
 		heartbeatService: heartbeatService,
 		inProgress:       datastructure.New[string](),
+		resummarizeJobs:  cache.New(24*time.Hour, 1*time.Hour),
 		queueDefault:     config.GetDefaultQueue(),
 		queueWorkers:     config.GetQueue(config.QueueProcessing),
 	}
@@ -148,6 +156,87 @@ func (srv *AggregationService) process(job AggregationJob) {
 	}
 }
 
+// Resummarize re-materializes the user's daily summaries between from and to (rounded to full days), e.g. after language
+// mappings or project aliases were changed. Days are processed one after another in the background, pausing for the
+// configured throttle in between, and the returned job can be used to track progress.
+func (srv *AggregationService) Resummarize(user *models.User, from, to time.Time) (*models.ResummarizeJob, error) {
+	from, to = datetime.BeginOfDay(from.Local()), utils.CeilDate(to.Local())
+	if today := utils.BeginOfToday(time.Local); to.After(today) {
+		to = today // today's summary is not materialized, yet
+	}
+	if !from.Before(to) {
+		return nil, errors.New("invalid date range")
+	}
+
+	userIds := datastructure.New(user.ID)
+	if err := srv.lockUsers(userIds); err != nil {
+		return nil, err
+	}
+
+	days := utils.SplitRangeByDays(from, to)
+	job := &models.ResummarizeJob{
+		ID:        uuid.Must(uuid.NewV4()).String(),
+		UserID:    user.ID,
+		From:      from,
+		To:        to,
+		Status:    models.ResummarizeStatusRunning,
+		DaysTotal: len(days),
+		StartedAt: time.Now(),
+	}
+	srv.resummarizeJobs.SetDefault(job.ID, job)
+
+	go func() {
+		defer srv.unlockUsers(userIds)
+		job.Finish(srv.resummarize(job, user, days))
+	}()
+
+	return job, nil
+}
+
+func (srv *AggregationService) GetResummarizeJob(id string) (*models.ResummarizeJob, bool) {
+	if job, ok := srv.resummarizeJobs.Get(id); ok {
+		return job.(*models.ResummarizeJob), true
+	}
+	return nil, false
+}
+
+func (srv *AggregationService) resummarize(job *models.ResummarizeJob, user *models.User, days [][]time.Time) error {
+	slog.Info("re-generating summaries", "userID", user.ID, "from", job.From, "to", job.To, "days", len(days))
+
+	throttle := time.Duration(srv.config.App.ResummarizeThrottleMs) * time.Millisecond
+
+	for i, day := range days {
+		if i > 0 && throttle > 0 {
+			time.Sleep(throttle)
+		}
+
+		summary, err := srv.summaryService.Summarize(day[0], day[1], user, nil)
+		if err != nil {
+			config.Log().Error("failed to re-generate summary", "from", day[0], "to", day[1], "userID", user.ID, "error", err)
+			job.Advance(true)
+			continue
+		}
+
+		// only replace the old summary once the new one was computed successfully
+		if err := srv.summaryService.DeleteByUserWithin(user.ID, day[0], day[1]); err != nil {
+			return err
+		}
+		if err := srv.summaryService.Insert(summary); err != nil {
+			config.Log().Error("failed to save summary", "userID", user.ID, "fromTime", summary.FromTime, "toTime", summary.ToTime, "error", err)
+			job.Advance(true)
+			continue
+		}
+		job.Advance(false)
+
+		if (i+1)%30 == 0 {
+			slog.Info("re-generating summaries", "userID", user.ID, "progress", job.Progress())
+		}
+	}
+
+	slog.Info("finished re-generating summaries", "userID", user.ID, "from", job.From, "to", job.To)
+	return nil
+}
+
 func generateUserJobs(user *models.User, from time.Time, jobs chan<- *AggregationJob) {
 	var to time.Time
 
@@ -181,7 +270,7 @@ func (srv *AggregationService) lockUsers(userIds datastructure.Set[string]) erro
 	defer aggregationLock.Unlock()
 	for uid := range userIds {
 		if srv.inProgress.Contain(uid) {
-			return errors.New("aggregation already in progress for at least of the request users")
+			return ErrAggregationInProgress
 		}
 	}
 	srv.inProgress = srv.inProgress.Union(userIds)
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupResummarizeTest(throttleMs int) *models.User {
+	cfg := config.Empty()
+	cfg.App.ResummarizeThrottleMs = throttleMs
+	config.Set(cfg)
+	return &models.User{ID: TestUserId}
+}
+
+func waitForResummarizeJob(t *testing.T, job *models.ResummarizeJob) *models.ResummarizeJob {
+	assert.Eventually(t, func() bool { return !job.IsRunning() }, time.Second, 5*time.Millisecond)
+	return job.Snapshot()
+}
+
+func TestAggregationService_Resummarize(t *testing.T) {
+	user := setupResummarizeTest(0)
+
+	today := utils.BeginOfToday(time.Local)
+	days := utils.SplitRangeByDays(today.AddDate(0, 0, -3), today)
+	summaryOf := func(day []time.Time) *models.Summary {
+		return &models.Summary{UserID: user.ID, FromTime: models.CustomTime(day[0]), ToTime: models.CustomTime(day[1])}
+	}
+
+	summaryService := new(mocks.SummaryServiceMock)
+	summaryService.On("Summarize", days[0][0], days[0][1], user, mock.Anything).Return(summaryOf(days[0]), nil)
+	summaryService.On("Summarize", days[1][0], days[1][1], user, mock.Anything).Return((*models.Summary)(nil), errors.New("failed"))
+	summaryService.On("Summarize", days[2][0], days[2][1], user, mock.Anything).Return(summaryOf(days[2]), nil)
+	summaryService.On("DeleteByUserWithin", user.ID, mock.Anything, mock.Anything).Return(nil)
+	summaryService.On("Insert", mock.Anything).Return(nil)
+
+	sut := NewAggregationService(nil, summaryService, nil)
+
+	// today's summary is not materialized, yet, so the range is capped
+	job, err := sut.Resummarize(user, today.AddDate(0, 0, -3).Add(2*time.Hour), time.Now())
+	assert.Nil(t, err)
+	assert.Equal(t, days[0][0], job.From)
+	assert.Equal(t, today, job.To)
+
+	result := waitForResummarizeJob(t, job)
+	assert.Equal(t, models.ResummarizeStatusFinished, result.Status)
+	assert.Equal(t, 3, result.DaysTotal)
+	assert.Equal(t, 2, result.DaysDone)
+	assert.Equal(t, 1, result.DaysFailed)
+	assert.NotNil(t, result.FinishedAt)
+	assert.Equal(t, 1.0, job.Progress())
+
+	// the old summary of the failed day is kept
+	summaryService.AssertCalled(t, "DeleteByUserWithin", user.ID, days[0][0], days[0][1])
+	summaryService.AssertNotCalled(t, "DeleteByUserWithin", user.ID, days[1][0], days[1][1])
+	summaryService.AssertCalled(t, "DeleteByUserWithin", user.ID, days[2][0], days[2][1])
+	summaryService.AssertNumberOfCalls(t, "Insert", 2)
+
+	fetched, ok := sut.GetResummarizeJob(job.ID)
+	assert.True(t, ok)
+	assert.Same(t, job, fetched)
+	_, ok = sut.GetResummarizeJob("unknown")
+	assert.False(t, ok)
+}
+
+func TestAggregationService_Resummarize_Failed(t *testing.T) {
+	user := setupResummarizeTest(0)
+	today := utils.BeginOfToday(time.Local)
+
+	summaryService := new(mocks.SummaryServiceMock)
+	summaryService.On("Summarize", mock.Anything, mock.Anything, user, mock.Anything).Return(&models.Summary{UserID: user.ID}, nil)
+	summaryService.On("DeleteByUserWithin", user.ID, mock.Anything, mock.Anything).Return(errors.New("database is locked"))
+
+	sut := NewAggregationService(nil, summaryService, nil)
+
+	job, err := sut.Resummarize(user, today.AddDate(0, 0, -2), today)
+	assert.Nil(t, err)
+
+	result := waitForResummarizeJob(t, job)
+	assert.Equal(t, models.ResummarizeStatusFailed, result.Status)
+	assert.Equal(t, "database is locked", result.Error)
+	assert.Zero(t, result.DaysDone)
+	summaryService.AssertNumberOfCalls(t, "Summarize", 1) // aborted after the first day
+	summaryService.AssertNotCalled(t, "Insert", mock.Anything)
+
+	// user is unlocked again
+	_, err = sut.Resummarize(user, today.AddDate(0, 0, -1), today)
+	assert.Nil(t, err)
+}
+
+func TestAggregationService_Resummarize_Throttled(t *testing.T) {
+	user := setupResummarizeTest(20)
+	today := utils.BeginOfToday(time.Local)
+
+	summaryService := new(mocks.SummaryServiceMock)
+	summaryService.On("Summarize", mock.Anything, mock.Anything, user, mock.Anything).Return(&models.Summary{UserID: user.ID}, nil)
+	summaryService.On("DeleteByUserWithin", user.ID, mock.Anything, mock.Anything).Return(nil)
+	summaryService.On("Insert", mock.Anything).Return(nil)
+
+	sut := NewAggregationService(nil, summaryService, nil)
+
+	job, err := sut.Resummarize(user, today.AddDate(0, 0, -3), today)
+	assert.Nil(t, err)
+
+	// pauses between, but not before the first day
+	result := waitForResummarizeJob(t, job)
+	assert.Equal(t, 3, result.DaysDone)
+	assert.GreaterOrEqual(t, result.FinishedAt.Sub(result.StartedAt), 2*20*time.Millisecond)
+}
+
+func TestAggregationService_Resummarize_Invalid(t *testing.T) {
+	user := setupResummarizeTest(0)
+	today := utils.BeginOfToday(time.Local)
+
+	release := make(chan time.Time)
+	summaryService := new(mocks.SummaryServiceMock)
+	summaryService.On("Summarize", mock.Anything, mock.Anything, user, mock.Anything).WaitUntil(release).Return(&models.Summary{UserID: user.ID}, nil)
+	summaryService.On("DeleteByUserWithin", user.ID, mock.Anything, mock.Anything).Return(nil)
+	summaryService.On("Insert", mock.Anything).Return(nil)
+
+	sut := NewAggregationService(nil, summaryService, nil)
+
+	// nothing to re-generate from today on
+	_, err := sut.Resummarize(user, today, time.Now())
+	assert.Error(t, err)
+	_, err = sut.Resummarize(user, today.AddDate(0, 0, -1), today.AddDate(0, 0, -2))
+	assert.Error(t, err)
+
+	job, err := sut.Resummarize(user, today.AddDate(0, 0, -1), today)
+	assert.Nil(t, err)
+
+	// at most one job per user at a time
+	_, err = sut.Resummarize(user, today.AddDate(0, 0, -1), today)
+	assert.ErrorIs(t, err, ErrAggregationInProgress)
+
+	close(release)
+	assert.Equal(t, models.ResummarizeStatusFinished, waitForResummarizeJob(t, job).Status)
+}
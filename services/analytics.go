@@ -0,0 +1,268 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/duke-git/lancet/v2/slice"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/models"
+)
+
+const (
+	analyticsMaxRange           = 366 * 24 * time.Hour
+	analyticsMaxDimensions      = 3
+	analyticsMaxFilterValues    = 50
+	analyticsDefaultLimit       = 100
+	analyticsMaxLimit           = 1000
+	analyticsDimensionSeparator = "\x00"
+	analyticsFilterTag          = "tag"
+)
+
+var ErrAnalyticsQueryInvalid = errors.New("invalid analytics query")
+
+// AnalyticsService evaluates custom report queries against a user's durations.
+// Queries are validated against a fixed set of dimensions, metrics and filters, so they never reach the database in any other form than the usual, parameterized heartbeat queries.
+type AnalyticsService struct {
+	config          *config.Config
+	durationService IDurationService
+}
+
+func NewAnalyticsService(durationService IDurationService) *AnalyticsService {
+	return &AnalyticsService{
+		config:          config.Get(),
+		durationService: durationService,
+	}
+}
+
+func (srv *AnalyticsService) Query(user *models.User, query *models.AnalyticsQuery) (*models.AnalyticsResult, error) {
+	from, err := helpers.ParseDateTimeTZ(query.From, user.TZ())
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing or invalid 'from'", ErrAnalyticsQueryInvalid)
+	}
+	to, err := helpers.ParseDateTimeTZ(query.To, user.TZ())
+	if err != nil {
+		return nil, fmt.Errorf("%w: missing or invalid 'to'", ErrAnalyticsQueryInvalid)
+	}
+	if !to.After(from) || to.Sub(from) > analyticsMaxRange {
+		return nil, fmt.Errorf("%w: range must be positive and must not exceed one year", ErrAnalyticsQueryInvalid)
+	}
+
+	dimensions, err := parseAnalyticsDimensions(query.Dimensions)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := parseAnalyticsMetrics(query.Metrics)
+	if err != nil {
+		return nil, err
+	}
+	filters, err := parseAnalyticsFilters(query.Filters)
+	if err != nil {
+		return nil, err
+	}
+
+	grain := query.Grain
+	if grain == "" {
+		grain = models.AnalyticsGrainNone
+	}
+	if !slice.Contain([]string{models.AnalyticsGrainNone, models.AnalyticsGrainDay, models.AnalyticsGrainWeek, models.AnalyticsGrainMonth}, grain) {
+		return nil, fmt.Errorf("%w: unknown grain '%s'", ErrAnalyticsQueryInvalid, grain)
+	}
+
+	limit := query.Limit
+	if limit == 0 {
+		limit = analyticsDefaultLimit
+	}
+	if limit < 0 || limit > analyticsMaxLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrAnalyticsQueryInvalid, analyticsMaxLimit)
+	}
+
+	durations, err := srv.durationService.Get(from, to, user, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := aggregateAnalyticsRows(durations, dimensions, grain, user)
+	sortAnalyticsRows(rows, dimensions, metrics[0])
+
+	result := &models.AnalyticsResult{
+		From:       from,
+		To:         to,
+		Grain:      grain,
+		Dimensions: slice.Map[uint8, string](dimensions, func(i int, t uint8) string { return models.GetEntityColumn(t) }),
+		Metrics:    metrics,
+		Rows:       make([]*models.AnalyticsRow, 0, len(rows)),
+	}
+	if len(rows) > limit {
+		rows, result.Truncated = rows[:limit], true
+	}
+	for _, r := range rows {
+		result.Rows = append(result.Rows, r.toRow(metrics))
+	}
+	return result, nil
+}
+
+type analyticsAccumulator struct {
+	period       *time.Time
+	keys         []string
+	total        time.Duration
+	heartbeats   int64
+	days         map[string]bool
+	linesAdded   int64
+	linesRemoved int64
+	dimensions   []uint8
+}
+
+func (a *analyticsAccumulator) metric(name string) int64 {
+	switch name {
+	case models.AnalyticsMetricTotalSeconds:
+		return int64(a.total.Seconds())
+	case models.AnalyticsMetricHeartbeats:
+		return a.heartbeats
+	case models.AnalyticsMetricDays:
+		return int64(len(a.days))
+	case models.AnalyticsMetricLinesAdded:
+		return a.linesAdded
+	case models.AnalyticsMetricLinesRemoved:
+		return a.linesRemoved
+	}
+	return 0
+}
+
+func (a *analyticsAccumulator) toRow(metrics []string) *models.AnalyticsRow {
+	row := &models.AnalyticsRow{Period: a.period, Dimensions: map[string]string{}, Metrics: map[string]int64{}}
+	for i, t := range a.dimensions {
+		row.Dimensions[models.GetEntityColumn(t)] = a.keys[i]
+	}
+	for _, m := range metrics {
+		row.Metrics[m] = a.metric(m)
+	}
+	return row
+}
+
+func aggregateAnalyticsRows(durations models.Durations, dimensions []uint8, grain string, user *models.User) []*analyticsAccumulator {
+	rows := make([]*analyticsAccumulator, 0)
+	index := make(map[string]*analyticsAccumulator)
+
+	for _, d := range durations {
+		t := d.Time.T().In(user.TZ())
+
+		var period *time.Time
+		switch grain {
+		case models.AnalyticsGrainDay:
+			p := datetime.BeginOfDay(t)
+			period = &p
+		case models.AnalyticsGrainWeek:
+			p := datetime.BeginOfWeek(t, user.WeekStart())
+			period = &p
+		case models.AnalyticsGrainMonth:
+			p := datetime.BeginOfMonth(t)
+			period = &p
+		}
+
+		keys := slice.Map[uint8, string](dimensions, func(i int, t uint8) string { return d.GetKey(t) })
+		id := strings.Join(keys, analyticsDimensionSeparator)
+		if period != nil {
+			id = period.Format(time.RFC3339) + analyticsDimensionSeparator + id
+		}
+
+		acc, ok := index[id]
+		if !ok {
+			acc = &analyticsAccumulator{period: period, keys: keys, days: map[string]bool{}, dimensions: dimensions}
+			index[id] = acc
+			rows = append(rows, acc)
+		}
+		acc.total += d.Duration
+		acc.heartbeats += int64(d.NumHeartbeats)
+		acc.days[t.Format(config.SimpleDateFormat)] = true
+		acc.linesAdded += d.EditStats.LinesAdded
+		acc.linesRemoved += d.EditStats.LinesRemoved
+	}
+
+	return rows
+}
+
+// sortAnalyticsRows orders rows chronologically and, within the same period, by the first requested metric descending
+func sortAnalyticsRows(rows []*analyticsAccumulator, dimensions []uint8, metric string) {
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].period != nil && !rows[i].period.Equal(*rows[j].period) {
+			return rows[i].period.Before(*rows[j].period)
+		}
+		if mi, mj := rows[i].metric(metric), rows[j].metric(metric); mi != mj {
+			return mi > mj
+		}
+		for k := range dimensions {
+			if rows[i].keys[k] != rows[j].keys[k] {
+				return rows[i].keys[k] < rows[j].keys[k]
+			}
+		}
+		return false
+	})
+}
+
+func parseAnalyticsDimensions(names []string) ([]uint8, error) {
+	if len(names) > analyticsMaxDimensions {
+		return nil, fmt.Errorf("%w: at most %d dimensions are allowed", ErrAnalyticsQueryInvalid, analyticsMaxDimensions)
+	}
+	dimensions := make([]uint8, 0, len(names))
+	for _, name := range names {
+		t, err := parseAnalyticsDimension(name)
+		if err != nil {
+			return nil, err
+		}
+		if slice.Contain(dimensions, t) {
+			return nil, fmt.Errorf("%w: duplicate dimension '%s'", ErrAnalyticsQueryInvalid, name)
+		}
+		dimensions = append(dimensions, t)
+	}
+	return dimensions, nil
+}
+
+func parseAnalyticsDimension(name string) (uint8, error) {
+	t, ok := models.ParseSummaryType(name)
+	if !ok || !slice.Contain(models.AnalyticsDimensions(), t) {
+		return 0, fmt.Errorf("%w: unknown dimension '%s'", ErrAnalyticsQueryInvalid, name)
+	}
+	return t, nil
+}
+
+func parseAnalyticsMetrics(names []string) ([]string, error) {
+	if len(names) == 0 {
+		return []string{models.AnalyticsMetricTotalSeconds}, nil
+	}
+	metrics := make([]string, 0, len(names))
+	for _, name := range names {
+		if !slice.Contain(models.AnalyticsMetrics(), name) {
+			return nil, fmt.Errorf("%w: unknown metric '%s'", ErrAnalyticsQueryInvalid, name)
+		}
+		if slice.Contain(metrics, name) {
+			return nil, fmt.Errorf("%w: duplicate metric '%s'", ErrAnalyticsQueryInvalid, name)
+		}
+		metrics = append(metrics, name)
+	}
+	return metrics, nil
+}
+
+func parseAnalyticsFilters(filterMap map[string][]string) (*models.Filters, error) {
+	filters := &models.Filters{}
+	for name, values := range filterMap {
+		if len(values) == 0 || len(values) > analyticsMaxFilterValues {
+			return nil, fmt.Errorf("%w: filter '%s' must have between 1 and %d values", ErrAnalyticsQueryInvalid, name, analyticsMaxFilterValues)
+		}
+		if name == analyticsFilterTag { // tags can be filtered by, but not grouped by, as a duration may carry several of them
+			filters.WithTags(values)
+			continue
+		}
+		t, err := parseAnalyticsDimension(name)
+		if err != nil {
+			return nil, err
+		}
+		filters.WithMultiple(t, values)
+	}
+	return filters, nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAnalyticsService_Query(t *testing.T) {
+	config.Set(config.Empty())
+
+	user := &models.User{ID: TestUserId, Location: "UTC"}
+	day1 := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+
+	durationService := new(mocks.DurationServiceMock)
+	durationService.On("Get", mock.Anything, mock.Anything, user, mock.Anything).Return(models.Durations{
+		{Time: models.CustomTime(day1), Duration: 2 * time.Hour, Project: TestProject1, Language: TestLanguageGo, NumHeartbeats: 10, EditStats: models.EditStats{LinesAdded: 5}},
+		{Time: models.CustomTime(day1.Add(3 * time.Hour)), Duration: 1 * time.Hour, Project: TestProject2, Language: TestLanguageGo, NumHeartbeats: 4},
+		{Time: models.CustomTime(day2), Duration: 30 * time.Minute, Project: TestProject1, Language: TestLanguageJava, NumHeartbeats: 2, EditStats: models.EditStats{LinesAdded: 1}},
+	}, nil)
+
+	sut := NewAnalyticsService(durationService)
+
+	result, err := sut.Query(user, &models.AnalyticsQuery{
+		From:       "2024-03-01",
+		To:         "2024-04-01",
+		Dimensions: []string{"project"},
+		Metrics:    []string{"total_seconds", "days", "lines_added"},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"project"}, result.Dimensions)
+	assert.Len(t, result.Rows, 2)
+	assert.Nil(t, result.Rows[0].Period)
+	assert.Equal(t, TestProject1, result.Rows[0].Dimensions["project"])
+	assert.Equal(t, int64(9000), result.Rows[0].Metrics["total_seconds"])
+	assert.Equal(t, int64(2), result.Rows[0].Metrics["days"])
+	assert.Equal(t, int64(6), result.Rows[0].Metrics["lines_added"])
+	assert.Equal(t, TestProject2, result.Rows[1].Dimensions["project"])
+	assert.False(t, result.Truncated)
+
+	result, err = sut.Query(user, &models.AnalyticsQuery{
+		From:       "2024-03-01",
+		To:         "2024-04-01",
+		Dimensions: []string{"language"},
+		Grain:      "day",
+	})
+	assert.Nil(t, err)
+	assert.Len(t, result.Rows, 2)
+	assert.Equal(t, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), *result.Rows[0].Period)
+	assert.Equal(t, TestLanguageGo, result.Rows[0].Dimensions["language"])
+	assert.Equal(t, int64(10800), result.Rows[0].Metrics["total_seconds"])
+	assert.Equal(t, time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), *result.Rows[1].Period)
+	assert.Equal(t, TestLanguageJava, result.Rows[1].Dimensions["language"])
+
+	result, err = sut.Query(user, &models.AnalyticsQuery{
+		From:       "2024-03-01",
+		To:         "2024-04-01",
+		Dimensions: []string{"project", "language"},
+		Limit:      2,
+	})
+	assert.Nil(t, err)
+	assert.Len(t, result.Rows, 2)
+	assert.True(t, result.Truncated)
+}
+
+func TestAnalyticsService_Query_Invalid(t *testing.T) {
+	config.Set(config.Empty())
+
+	user := &models.User{ID: TestUserId, Location: "UTC"}
+	sut := NewAnalyticsService(new(mocks.DurationServiceMock))
+
+	queries := []*models.AnalyticsQuery{
+		{From: "2024-03-01"},
+		{From: "2024-03-01", To: "2024-02-01"},
+		{From: "2023-01-01", To: "2024-06-01"},
+		{From: "2024-03-01", To: "2024-04-01", Dimensions: []string{"label"}},
+		{From: "2024-03-01", To: "2024-04-01", Dimensions: []string{"project", "project"}},
+		{From: "2024-03-01", To: "2024-04-01", Dimensions: []string{"project", "language", "editor", "machine"}},
+		{From: "2024-03-01", To: "2024-04-01", Metrics: []string{"user_id; drop table users"}},
+		{From: "2024-03-01", To: "2024-04-01", Filters: map[string][]string{"api_key": {"foo"}}},
+		{From: "2024-03-01", To: "2024-04-01", Filters: map[string][]string{"project": {}}},
+		{From: "2024-03-01", To: "2024-04-01", Grain: "hour"},
+		{From: "2024-03-01", To: "2024-04-01", Limit: 5000},
+	}
+
+	for _, q := range queries {
+		_, err := sut.Query(user, q)
+		assert.True(t, errors.Is(err, ErrAnalyticsQueryInvalid), q)
+	}
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/leandro-lugaresi/hub"
+	"github.com/patrickmn/go-cache"
+)
+
+const canonicalNamesCacheKey = "canonical_names"
+
+var (
+	ErrCanonicalNameInvalid  = errors.New("invalid mapping")
+	ErrCanonicalNameConflict = errors.New("variant is already mapped to a different name or the name is itself mapped to another one")
+)
+
+type CanonicalNameService struct {
+	config          *config.Config
+	cache           *cache.Cache
+	eventBus        *hub.Hub
+	repository      repositories.ICanonicalNameRepository
+	heartbeatRepo   repositories.IHeartbeatRepository
+	userSrvc        IUserService
+	aggregationSrvc IAggregationService
+}
+
+func NewCanonicalNameService(canonicalNameRepo repositories.ICanonicalNameRepository, heartbeatRepo repositories.IHeartbeatRepository, userService IUserService) *CanonicalNameService {
+	return &CanonicalNameService{
+		config:        config.Get(),
+		cache:         cache.New(1*time.Hour, 1*time.Hour),
+		eventBus:      config.EventBus(),
+		repository:    canonicalNameRepo,
+		heartbeatRepo: heartbeatRepo,
+		userSrvc:      userService,
+	}
+}
+
+// SetAggregationService is required for Apply to re-generate summaries. It can't be passed upon construction, because the
+// aggregation service depends on the heartbeat service, which in turn depends on this one.
+func (srv *CanonicalNameService) SetAggregationService(aggregationService IAggregationService) {
+	srv.aggregationSrvc = aggregationService
+}
+
+func (srv *CanonicalNameService) GetAll() ([]*models.CanonicalName, error) {
+	return srv.repository.GetAll()
+}
+
+func (srv *CanonicalNameService) Create(mapping *models.CanonicalName) (*models.CanonicalName, error) {
+	mapping.Variant = strings.TrimSpace(mapping.Variant)
+	mapping.Canonical = strings.TrimSpace(mapping.Canonical)
+	if !mapping.IsValid() {
+		return nil, ErrCanonicalNameInvalid
+	}
+
+	names, err := srv.getNames()
+	if err != nil {
+		return nil, err
+	}
+	if existing, ok := names[mapping.Type][models.CanonicalNameKey(mapping.Variant)]; ok && existing != mapping.Canonical {
+		return nil, ErrCanonicalNameConflict
+	}
+	if existing, ok := names[mapping.Type][models.CanonicalNameKey(mapping.Canonical)]; ok && existing != mapping.Canonical {
+		return nil, ErrCanonicalNameConflict // no chains of mappings
+	}
+
+	result, err := srv.repository.Insert(mapping)
+	if err != nil {
+		return nil, err
+	}
+	srv.cache.Delete(canonicalNamesCacheKey)
+	return result, nil
+}
+
+func (srv *CanonicalNameService) Delete(id uint) error {
+	if _, err := srv.repository.GetById(id); err != nil {
+		return err
+	}
+	if err := srv.repository.Delete(id); err != nil {
+		return err
+	}
+	srv.cache.Delete(canonicalNamesCacheKey)
+	return nil
+}
+
+// Canonicalize replaces the heartbeat's editor and operating system by their canonical names upon ingestion
+func (srv *CanonicalNameService) Canonicalize(heartbeat *models.Heartbeat) {
+	names, err := srv.getNames()
+	if err != nil {
+		config.Log().Error("failed to load canonical names, storing heartbeat as is", "error", err)
+		return
+	}
+	names.Apply(heartbeat)
+}
+
+// Apply rewrites all existing heartbeats according to the current mappings, e.g. after a mapping was added, and re-generates the
+// affected users' summaries from their first rewritten heartbeat on in the background.
+func (srv *CanonicalNameService) Apply() (*models.CanonicalizationResult, error) {
+	names, err := srv.getNames()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.CanonicalizationResult{AffectedSince: map[string]models.CustomTime{}}
+
+	for _, nameType := range []string{models.CanonicalNameTypeEditor, models.CanonicalNameTypeOS} {
+		column := (&models.CanonicalName{Type: nameType}).Column()
+		values, err := srv.heartbeatRepo.GetDistinctValues(column)
+		if err != nil {
+			return nil, err
+		}
+
+		variants := map[string][]string{} // canonical name -> stored variants
+		for _, v := range values {
+			if canonical := names.Resolve(nameType, v); canonical != v {
+				variants[canonical] = append(variants[canonical], v)
+			}
+		}
+
+		for canonical, vs := range variants {
+			firstByUsers, err := srv.heartbeatRepo.GetFirstByUsersWithValues(column, vs)
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range firstByUsers {
+				if since, ok := result.AffectedSince[f.User]; !ok || f.Time.T().Before(since.T()) {
+					result.AffectedSince[f.User] = f.Time
+				}
+			}
+
+			n, err := srv.heartbeatRepo.ReplaceValues(column, vs, canonical)
+			if err != nil {
+				return nil, err
+			}
+			result.Updated += n
+			slog.Info("canonicalized heartbeats", "type", nameType, "variants", vs, "canonical", canonical, "count", n)
+		}
+	}
+
+	for userId, since := range result.AffectedSince {
+		srv.notifyHeartbeatUpdate(userId)
+
+		user, err := srv.userSrvc.GetUserById(userId)
+		if err != nil {
+			config.Log().Warn("failed to get user to re-generate summaries for", "userID", userId, "error", err)
+			continue
+		}
+		if _, err := srv.aggregationSrvc.Resummarize(user, since.T(), time.Now()); err != nil {
+			config.Log().Warn("failed to re-generate summaries after canonicalization", "userID", userId, "error", err)
+			continue
+		}
+		result.Resummarizing++
+	}
+
+	return result, nil
+}
+
+func (srv *CanonicalNameService) notifyHeartbeatUpdate(userId string) {
+	srv.eventBus.Publish(hub.Message{
+		Name:   config.EventHeartbeatUpdate,
+		Fields: map[string]interface{}{config.FieldUserId: userId},
+	})
+}
+
+func (srv *CanonicalNameService) getNames() (models.CanonicalNames, error) {
+	if names, found := srv.cache.Get(canonicalNamesCacheKey); found {
+		return names.(models.CanonicalNames), nil
+	}
+	mappings, err := srv.repository.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	names := models.NewCanonicalNamesFrom(mappings)
+	srv.cache.SetDefault(canonicalNamesCacheKey, names)
+	return names, nil
+}
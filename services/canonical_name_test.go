@@ -0,0 +1,123 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/hackclub/hackatime/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestCanonicalNameService_Create(t *testing.T) {
+	config.Set(config.Empty())
+
+	existing := []*models.CanonicalName{
+		{ID: 1, Type: models.CanonicalNameTypeEditor, Variant: "Code - Insiders", Canonical: "vscode"},
+	}
+
+	repoMock := new(mocks.CanonicalNameRepositoryMock)
+	repoMock.On("GetAll").Return(existing, nil)
+	repoMock.On("Insert", mock.Anything).Return(&models.CanonicalName{ID: 2}, nil)
+
+	sut := NewCanonicalNameService(repoMock, nil, nil)
+
+	_, err := sut.Create(&models.CanonicalName{Type: "browser", Variant: "chrome", Canonical: "Chrome"})
+	assert.ErrorIs(t, err, ErrCanonicalNameInvalid)
+	_, err = sut.Create(&models.CanonicalName{Type: models.CanonicalNameTypeEditor, Variant: " ", Canonical: "vscode"})
+	assert.ErrorIs(t, err, ErrCanonicalNameInvalid)
+
+	_, err = sut.Create(&models.CanonicalName{Type: models.CanonicalNameTypeEditor, Variant: "code insiders", Canonical: "VS Code"})
+	assert.ErrorIs(t, err, ErrCanonicalNameConflict)
+	_, err = sut.Create(&models.CanonicalName{Type: models.CanonicalNameTypeEditor, Variant: "codium", Canonical: "code-insiders"})
+	assert.ErrorIs(t, err, ErrCanonicalNameConflict)
+
+	_, err = sut.Create(&models.CanonicalName{Type: models.CanonicalNameTypeEditor, Variant: " codium ", Canonical: "vscode"})
+	assert.Nil(t, err)
+	repoMock.AssertCalled(t, "Insert", &models.CanonicalName{Type: models.CanonicalNameTypeEditor, Variant: "codium", Canonical: "vscode"})
+
+	hb := &models.Heartbeat{Editor: "Code-Insiders"}
+	sut.Canonicalize(hb)
+	assert.Equal(t, "vscode", hb.Editor)
+}
+
+func TestCanonicalNameService_Apply(t *testing.T) {
+	config.Set(config.Empty())
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	assert.Nil(t, err)
+	sqlDb, _ := db.DB()
+	sqlDb.SetMaxOpenConns(1)
+	assert.Nil(t, db.AutoMigrate(&models.User{}, &models.Heartbeat{}, &models.Summary{}, &models.SummaryItem{}, &models.CanonicalName{}, &models.Alias{}, &models.ProjectLabel{}, &models.TimeEntry{}, &models.LanguageMapping{}))
+
+	user := &models.User{ID: TestUserId}
+	assert.Nil(t, db.Create(user).Error)
+
+	yesterday := utils.BeginOfToday(time.Local).AddDate(0, 0, -1)
+	for i, editor := range []string{"Code - Insiders", "Code - Insiders", "vscode"} {
+		assert.Nil(t, db.Create(&models.Heartbeat{
+			User:     user,
+			UserID:   user.ID,
+			Entity:   "main.go",
+			Type:     "file",
+			Project:  "wakapi",
+			Language: "Go",
+			Editor:   editor,
+			Time:     models.CustomTime(yesterday.Add(10*time.Hour + time.Duration(i)*time.Minute)),
+			Hash:     fmt.Sprintf("hash-%d", i),
+		}).Error)
+	}
+
+	heartbeatRepo := repositories.NewHeartbeatRepository(db)
+	canonicalNameRepo := repositories.NewCanonicalNameRepository(db)
+	summaryRepo := repositories.NewSummaryRepository(db)
+
+	userService := new(mocks.UserServiceMock)
+	userService.On("GetUserById", user.ID).Return(user, nil)
+
+	sut := NewCanonicalNameService(canonicalNameRepo, heartbeatRepo, userService)
+	heartbeatService := NewHeartbeatService(heartbeatRepo, NewLanguageMappingService(repositories.NewLanguageMappingRepository(db)), sut)
+	durationService := NewDurationService(heartbeatService, NewTimeEntryService(repositories.NewTimeEntryRepository(db)))
+	summaryService := NewSummaryService(summaryRepo, heartbeatService, durationService, NewAliasService(repositories.NewAliasRepository(db)), NewProjectLabelService(repositories.NewProjectLabelRepository(db)))
+	sut.SetAggregationService(NewAggregationService(userService, summaryService, heartbeatService))
+
+	updates := config.EventBus().Subscribe(1, config.EventHeartbeatUpdate)
+	defer config.EventBus().Unsubscribe(updates)
+
+	_, err = sut.Create(&models.CanonicalName{Type: models.CanonicalNameTypeEditor, Variant: "code - insiders", Canonical: "vscode"})
+	assert.Nil(t, err)
+
+	result, err := sut.Apply()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), result.Updated)
+	assert.Equal(t, 1, result.Resummarizing)
+
+	select {
+	case m := <-updates.Receiver:
+		assert.Equal(t, user.ID, m.Fields[config.FieldUserId])
+	case <-time.After(time.Second):
+		t.Fatal("heartbeat update was not published")
+	}
+
+	var editors []string
+	assert.Eventually(t, func() bool {
+		summaries, err := summaryRepo.GetByUserWithin(user, yesterday, yesterday.AddDate(0, 0, 1))
+		if err != nil || len(summaries) != 1 {
+			return false
+		}
+		editors = nil
+		for _, item := range summaries[0].Editors {
+			editors = append(editors, item.Key)
+		}
+		return true
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"vscode"}, editors)
+}
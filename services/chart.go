@@ -0,0 +1,291 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/duke-git/lancet/v2/condition"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/utils"
+	"github.com/patrickmn/go-cache"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	chartImageWidth      = 400
+	chartImageHeight     = 200
+	chartImageScale      = 2
+	chartBackgroundLight = "#FFFFFF"
+	chartMaxLanguages    = 5 // all further languages are merged into "Other"
+	chartMaxLabelLength  = 18
+)
+
+// fallback colors for languages without a color in the config
+var chartPalette = []string{"#047857", "#2563EB", "#D97706", "#DB2777", "#7C3AED", "#6B7280"}
+
+// ChartService renders common charts as png images, e.g. for embedding in chat messages, mails or readmes, where no javascript widgets can run
+type ChartService struct {
+	config         *config.Config
+	cache          *cache.Cache
+	summaryService ISummaryService
+}
+
+func NewChartService(summaryService ISummaryService) *ChartService {
+	return &ChartService{
+		config:         config.Get(),
+		cache:          cache.New(time.Hour, time.Hour),
+		summaryService: summaryService,
+	}
+}
+
+// RenderWeekly draws a bar chart of the user's coding time on each of the past seven days, including today
+func (srv *ChartService) RenderWeekly(user *models.User, darkTheme, skipCache bool) ([]byte, error) {
+	cacheKey := fmt.Sprintf("weekly_%s_%v", user.ID, darkTheme)
+	if result, found := srv.cache.Get(cacheKey); found && !skipCache {
+		return result.([]byte), nil
+	}
+
+	// whole days, including today
+	to := time.Now().In(user.TZ())
+	from := utils.BeginOfToday(user.TZ()).AddDate(0, 0, -6)
+	days, err := retrieveDailySummaries(srv.summaryService, user, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := renderWeeklyChart(days, darkTheme)
+	if err == nil {
+		srv.cache.SetDefault(cacheKey, data)
+	}
+	return data, err
+}
+
+// RenderLanguages draws a donut chart of the user's top languages within the given interval
+func (srv *ChartService) RenderLanguages(user *models.User, interval *models.IntervalKey, darkTheme, skipCache bool) ([]byte, error) {
+	cacheKey := fmt.Sprintf("languages_%s_%s_%v", user.ID, (*interval)[0], darkTheme)
+	if result, found := srv.cache.Get(cacheKey); found && !skipCache {
+		return result.([]byte), nil
+	}
+
+	err, from, to := helpers.ResolveIntervalTZ(interval, user.TZ())
+	if err != nil {
+		return nil, err
+	}
+	summary, err := srv.summaryService.Aliased(from, to, user, srv.summaryService.Retrieve, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := renderLanguagesChart(summary.Languages, interval.GetHumanReadable(), srv.config.App.GetLanguageColors(), darkTheme)
+	if err == nil {
+		srv.cache.SetDefault(cacheKey, data)
+	}
+	return data, err
+}
+
+type chartCanvas struct {
+	img        *image.RGBA
+	foreground color.RGBA
+	accent     color.RGBA
+	muted      color.RGBA
+}
+
+func newChartCanvas(darkTheme bool) *chartCanvas {
+	c := &chartCanvas{
+		img:        image.NewRGBA(image.Rect(0, 0, chartImageWidth, chartImageHeight)),
+		foreground: utils.HexToRGBA(condition.TernaryOperator[bool, string](darkTheme, textDark, textLight)),
+		accent:     utils.HexToRGBA(condition.TernaryOperator[bool, string](darkTheme, colorMaxDark, colorMaxLight)),
+		muted:      utils.HexToRGBA(condition.TernaryOperator[bool, string](darkTheme, "#374151", colorMinLight)),
+	}
+	background := utils.HexToRGBA(condition.TernaryOperator[bool, string](darkTheme, colorMinDark, chartBackgroundLight))
+	draw.Draw(c.img, c.img.Bounds(), &image.Uniform{C: background}, image.Point{}, draw.Src)
+	return c
+}
+
+func (c *chartCanvas) fill(rect image.Rectangle, col color.Color) {
+	draw.Draw(c.img, rect, &image.Uniform{C: col}, image.Point{}, draw.Src)
+}
+
+func (c *chartCanvas) text(x, y int, text string, col color.Color) {
+	d := &font.Drawer{Dst: c.img, Src: image.NewUniform(col), Face: basicfont.Face7x13, Dot: fixed.P(x, y)}
+	d.DrawString(text)
+}
+
+// textCentered writes the text horizontally centered around x
+func (c *chartCanvas) textCentered(x, y int, text string, col color.Color) {
+	c.text(x-font.MeasureString(basicfont.Face7x13, text).Round()/2, y, text, col)
+}
+
+// encode upscales the image, because the basic font is tiny, and encodes it as png
+func (c *chartCanvas) encode() ([]byte, error) {
+	scaled := image.NewRGBA(image.Rect(0, 0, chartImageWidth*chartImageScale, chartImageHeight*chartImageScale))
+	xdraw.NearestNeighbor.Scale(scaled, scaled.Bounds(), c.img, c.img.Bounds(), draw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, scaled); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func renderWeeklyChart(days []*models.Summary, darkTheme bool) ([]byte, error) {
+	const (
+		paddingX  = 16
+		barsTop   = 48
+		barsBase  = 170
+		barsRatio = 0.6 // share of a bar's slot filled by the bar itself
+	)
+
+	c := newChartCanvas(darkTheme)
+
+	var total, maxTotal time.Duration
+	for _, d := range days {
+		if d == nil {
+			continue
+		}
+		total += d.TotalTime()
+		maxTotal = max(maxTotal, d.TotalTime())
+	}
+	c.text(paddingX, 22, fmt.Sprintf("Last 7 days: %s", helpers.FmtWakatimeDuration(total)), c.accent)
+
+	slotWidth := (chartImageWidth - 2*paddingX) / max(len(days), 1)
+	barWidth := int(float64(slotWidth) * barsRatio)
+	for i, d := range days {
+		if d == nil {
+			continue
+		}
+		x := paddingX + i*slotWidth + (slotWidth-barWidth)/2
+		center := x + barWidth/2
+		height := 1
+		if maxTotal > 0 {
+			height = max(int(float64(barsBase-barsTop)*float64(d.TotalTime())/float64(maxTotal)), 1)
+		}
+
+		c.fill(image.Rect(x, barsBase-height, x+barWidth, barsBase), condition.TernaryOperator[bool, color.Color](d.TotalTime() > 0, c.accent, c.muted))
+		if d.TotalTime() > 0 {
+			c.textCentered(center, barsBase-height-4, fmtChartDuration(d.TotalTime()), c.foreground)
+		}
+		c.textCentered(center, barsBase+16, d.FromTime.T().Weekday().String()[:3], c.foreground)
+	}
+
+	return c.encode()
+}
+
+type chartSegment struct {
+	label    string
+	share    float64
+	color    color.RGBA
+	fromFrac float64 // start of the segment on the circle, as a fraction of the full circle
+}
+
+func renderLanguagesChart(languages models.SummaryItems, intervalLabel string, colors map[string]string, darkTheme bool) ([]byte, error) {
+	const (
+		centerX, centerY = 100, 115
+		outerRadius      = 70
+		innerRadius      = 42
+		legendX          = 200
+	)
+
+	c := newChartCanvas(darkTheme)
+	c.text(16, 22, fmt.Sprintf("Languages, %s", strings.ToLower(intervalLabel)), c.accent)
+
+	segments := languageChartSegments(languages, colors)
+	if len(segments) == 0 {
+		segments = []*chartSegment{{share: 1, color: c.muted}}
+		c.text(legendX, centerY, "No data", c.foreground)
+	}
+
+	// segments go clockwise, starting at twelve o'clock
+	for y := centerY - outerRadius; y <= centerY+outerRadius; y++ {
+		for x := centerX - outerRadius; x <= centerX+outerRadius; x++ {
+			dx, dy := float64(x-centerX), float64(y-centerY)
+			if r := math.Hypot(dx, dy); r < innerRadius || r > outerRadius {
+				continue
+			}
+			frac := math.Atan2(dx, -dy) / (2 * math.Pi)
+			if frac < 0 {
+				frac += 1
+			}
+			s := segments[len(segments)-1]
+			for _, candidate := range segments {
+				if frac < candidate.fromFrac+candidate.share {
+					s = candidate
+					break
+				}
+			}
+			c.img.Set(x, y, s.color)
+		}
+	}
+
+	for i, s := range segments {
+		if s.label == "" {
+			continue
+		}
+		y := 56 + i*20
+		c.fill(image.Rect(legendX, y-10, legendX+10, y), s.color)
+		label := s.label
+		if runes := []rune(label); len(runes) > chartMaxLabelLength {
+			label = string(runes[:chartMaxLabelLength-3]) + "..." // basic font only covers ascii
+		}
+		c.text(legendX+16, y, fmt.Sprintf("%s (%.1f%%)", label, s.share*100), c.foreground)
+	}
+
+	return c.encode()
+}
+
+// languageChartSegments returns the shares of the top languages, merging all others into a single one
+func languageChartSegments(languages models.SummaryItems, colors map[string]string) []*chartSegment {
+	items := make(models.SummaryItems, 0, len(languages))
+	var total time.Duration
+	for _, item := range languages {
+		if item.TotalFixed() > 0 {
+			items = append(items, item)
+			total += item.TotalFixed()
+		}
+	}
+	if total == 0 {
+		return []*chartSegment{}
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].TotalFixed() > items[j].TotalFixed()
+	})
+
+	segments := make([]*chartSegment, 0, chartMaxLanguages+1)
+	var frac float64
+	for i, item := range items {
+		if i == chartMaxLanguages {
+			segments = append(segments, &chartSegment{label: "Other", share: 1 - frac, color: utils.HexToRGBA(chartPalette[len(chartPalette)-1]), fromFrac: frac})
+			break
+		}
+		hex, ok := colors[strings.ToLower(item.Key)]
+		if !ok {
+			hex = chartPalette[i%(len(chartPalette)-1)]
+		}
+		share := float64(item.TotalFixed()) / float64(total)
+		segments = append(segments, &chartSegment{label: item.Key, share: share, color: utils.HexToRGBA(hex), fromFrac: frac})
+		frac += share
+	}
+	return segments
+}
+
+// fmtChartDuration formats a duration short enough to fit above a bar, e.g. "2h 5m"
+func fmtChartDuration(d time.Duration) string {
+	d = d.Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", d/time.Minute)
+	}
+	return fmt.Sprintf("%dh %dm", d/time.Hour, (d%time.Hour)/time.Minute)
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderWeeklyChart(t *testing.T) {
+	config.Set(config.Empty())
+
+	from := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	days := make([]*models.Summary, 7)
+	for i := range days {
+		days[i] = &models.Summary{
+			FromTime: models.CustomTime(from.AddDate(0, 0, i)),
+			ToTime:   models.CustomTime(from.AddDate(0, 0, i+1)),
+			Projects: []*models.SummaryItem{{Type: models.SummaryProject, Key: TestProject1, Total: time.Duration(i) * time.Hour / time.Second}},
+		}
+	}
+	days[3] = nil // failed to retrieve
+
+	for _, dark := range []bool{false, true} {
+		data, err := renderWeeklyChart(days, dark)
+		assert.Nil(t, err)
+		img, err := png.Decode(bytes.NewReader(data))
+		assert.Nil(t, err)
+		assert.Equal(t, chartImageWidth*chartImageScale, img.Bounds().Dx())
+		assert.Equal(t, chartImageHeight*chartImageScale, img.Bounds().Dy())
+	}
+}
+
+func TestRenderLanguagesChart(t *testing.T) {
+	config.Set(config.Empty())
+
+	languages := models.SummaryItems{
+		{Type: models.SummaryLanguage, Key: TestLanguageGo, Total: 3600},
+		{Type: models.SummaryLanguage, Key: TestLanguageJava, Total: 1800},
+	}
+
+	for _, items := range []models.SummaryItems{languages, {}} {
+		data, err := renderLanguagesChart(items, models.IntervalPast7Days.GetHumanReadable(), map[string]string{"go": "#00ADD8"}, false)
+		assert.Nil(t, err)
+		_, err = png.Decode(bytes.NewReader(data))
+		assert.Nil(t, err)
+	}
+}
+
+func TestLanguageChartSegments(t *testing.T) {
+	languages := models.SummaryItems{
+		{Key: "Python", Total: 100},
+		{Key: "Go", Total: 400},
+		{Key: "Java", Total: 100},
+		{Key: "Rust", Total: 100},
+		{Key: "C", Total: 100},
+		{Key: "Zig", Total: 100},
+		{Key: "Haskell", Total: 100},
+		{Key: "Cobol", Total: 0},
+	}
+
+	segments := languageChartSegments(languages, map[string]string{"go": "#00ADD8"})
+	assert.Len(t, segments, chartMaxLanguages+1)
+	assert.Equal(t, "Go", segments[0].label)
+	assert.Equal(t, 0.4, segments[0].share)
+	assert.Equal(t, uint8(0xAD), segments[0].color.G)
+	assert.Equal(t, 0.4, segments[1].fromFrac)
+	assert.Equal(t, "Other", segments[chartMaxLanguages].label)
+	assert.InDelta(t, 0.2, segments[chartMaxLanguages].share, 1e-9)
+
+	assert.Empty(t, languageChartSegments(models.SummaryItems{}, nil))
+	assert.Equal(t, "2h 5m", fmtChartDuration(2*time.Hour+5*time.Minute))
+	assert.Equal(t, "45m", fmtChartDuration(45*time.Minute))
+}
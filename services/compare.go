@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+)
+
+const compareMaxRange = 366 * 24 * time.Hour
+
+var (
+	ErrCompareSelf         = errors.New("can not compare with yourself")
+	ErrCompareNoConsent    = errors.New("both users need to consent to compare with each other")
+	ErrCompareRangeInvalid = errors.New("comparison range must not exceed one year")
+)
+
+// CompareService lets two users compare their summaries side by side, as long as both of them consented to it
+type CompareService struct {
+	config         *config.Config
+	repository     repositories.ICompareConsentRepository
+	userService    IUserService
+	summaryService ISummaryService
+}
+
+func NewCompareService(compareConsentRepo repositories.ICompareConsentRepository, userService IUserService, summaryService ISummaryService) *CompareService {
+	return &CompareService{
+		config:         config.Get(),
+		repository:     compareConsentRepo,
+		userService:    userService,
+		summaryService: summaryService,
+	}
+}
+
+func (srv *CompareService) GetConsents(user *models.User) (*models.CompareConsents, error) {
+	consents, err := srv.repository.GetByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.CompareConsents{Given: []string{}, Received: []string{}, Mutual: []string{}}
+	received := map[string]bool{}
+	for _, c := range consents {
+		if c.OtherID == user.ID {
+			result.Received = append(result.Received, c.UserID)
+			received[c.UserID] = true
+		}
+	}
+	for _, c := range consents {
+		if c.UserID == user.ID {
+			result.Given = append(result.Given, c.OtherID)
+			if received[c.OtherID] {
+				result.Mutual = append(result.Mutual, c.OtherID)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (srv *CompareService) Consent(user *models.User, otherId string) error {
+	if otherId == user.ID {
+		return ErrCompareSelf
+	}
+	if _, err := srv.userService.GetUserById(otherId); err != nil {
+		return err
+	}
+	if exists, err := srv.repository.Exists(user.ID, otherId); err != nil || exists {
+		return err
+	}
+	_, err := srv.repository.Insert(&models.CompareConsent{UserID: user.ID, OtherID: otherId})
+	return err
+}
+
+func (srv *CompareService) Revoke(user *models.User, otherId string) error {
+	return srv.repository.Delete(user.ID, otherId)
+}
+
+func (srv *CompareService) IsMutual(userId, otherId string) (bool, error) {
+	given, err := srv.repository.Exists(userId, otherId)
+	if err != nil || !given {
+		return false, err
+	}
+	return srv.repository.Exists(otherId, userId)
+}
+
+// Compare retrieves both users' summaries over the same range, given that both of them consented to the comparison
+func (srv *CompareService) Compare(user *models.User, otherId string, from, to time.Time) (*models.UserComparison, error) {
+	if otherId == user.ID {
+		return nil, ErrCompareSelf
+	}
+	if !to.After(from) || to.Sub(from) > compareMaxRange {
+		return nil, ErrCompareRangeInvalid
+	}
+
+	mutual, err := srv.IsMutual(user.ID, otherId)
+	if err != nil {
+		return nil, err
+	}
+	if !mutual {
+		return nil, ErrCompareNoConsent
+	}
+
+	other, err := srv.userService.GetUserById(otherId)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &models.UserComparison{From: from, To: to}
+	for _, u := range []*models.User{user, other} {
+		summary, err := srv.summaryService.Aliased(from, to, u, srv.summaryService.Retrieve, nil, false)
+		if err != nil {
+			return nil, err
+		}
+		comparison.Users = append(comparison.Users, models.NewComparedUser(u, summary))
+	}
+	return comparison, nil
+}
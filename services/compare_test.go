@@ -0,0 +1,76 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCompareService_GetConsents(t *testing.T) {
+	config.Set(config.Empty())
+
+	user := &models.User{ID: "user1"}
+	repo := new(mocks.CompareConsentRepositoryMock)
+	repo.On("GetByUser", user.ID).Return([]*models.CompareConsent{
+		{UserID: "user1", OtherID: "user2"},
+		{UserID: "user3", OtherID: "user1"},
+		{UserID: "user2", OtherID: "user1"},
+		{UserID: "user1", OtherID: "user4"},
+	}, nil)
+
+	sut := NewCompareService(repo, nil, nil)
+
+	consents, err := sut.GetConsents(user)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"user2", "user4"}, consents.Given)
+	assert.Equal(t, []string{"user3", "user2"}, consents.Received)
+	assert.Equal(t, []string{"user2"}, consents.Mutual)
+}
+
+func TestCompareService_Compare(t *testing.T) {
+	config.Set(config.Empty())
+
+	user1 := &models.User{ID: "user1", ShareProjects: true}
+	user2 := &models.User{ID: "user2"}
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 7)
+
+	summary := &models.Summary{
+		Projects:  models.SummaryItems{{Type: models.SummaryProject, Key: TestProject1, Total: 3600}},
+		Languages: models.SummaryItems{{Type: models.SummaryLanguage, Key: TestLanguageGo, Total: 3600}},
+	}
+
+	repo := new(mocks.CompareConsentRepositoryMock)
+	repo.On("Exists", "user1", "user2").Return(true, nil)
+	repo.On("Exists", "user2", "user1").Return(false, nil).Once()
+	userService := new(mocks.UserServiceMock)
+	userService.On("GetUserById", user2.ID).Return(user2, nil)
+	summaryService := new(mocks.SummaryServiceMock)
+	summaryService.On("Aliased", from, to, mock.Anything, mock.Anything, mock.Anything).Return(summary, nil)
+
+	sut := NewCompareService(repo, userService, summaryService)
+
+	_, err := sut.Compare(user1, user1.ID, from, to)
+	assert.ErrorIs(t, err, ErrCompareSelf)
+	_, err = sut.Compare(user1, user2.ID, from, from.AddDate(2, 0, 0))
+	assert.ErrorIs(t, err, ErrCompareRangeInvalid)
+	_, err = sut.Compare(user1, user2.ID, from, to)
+	assert.ErrorIs(t, err, ErrCompareNoConsent)
+
+	repo.On("Exists", "user2", "user1").Return(true, nil)
+
+	comparison, err := sut.Compare(user1, user2.ID, from, to)
+	assert.Nil(t, err)
+	assert.Len(t, comparison.Users, 2)
+	assert.Equal(t, "user1", comparison.Users[0].UserID)
+	assert.Equal(t, int64(3600), comparison.Users[0].Summary.Total)
+	assert.Len(t, comparison.Users[0].Summary.Projects, 1)
+	assert.Equal(t, "user2", comparison.Users[1].UserID)
+	assert.Empty(t, comparison.Users[1].Summary.Projects) // not shared
+	assert.Len(t, comparison.Users[1].Summary.Languages, 1)
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/leandro-lugaresi/hub"
+)
+
+// ConcurrencyService keeps track of when users last sent heartbeats and records the number of concurrently active users once per minute
+type ConcurrencyService struct {
+	config       *config.Config
+	eventBus     *hub.Hub
+	repository   repositories.IConcurrencyRepository
+	lastSeen     map[string]time.Time
+	lastSeenLock sync.Mutex
+}
+
+func NewConcurrencyService(concurrencyRepo repositories.IConcurrencyRepository) *ConcurrencyService {
+	return &ConcurrencyService{
+		config:     config.Get(),
+		eventBus:   config.EventBus(),
+		repository: concurrencyRepo,
+		lastSeen:   map[string]time.Time{},
+	}
+}
+
+func (srv *ConcurrencyService) Schedule() {
+	slog.Info("sampling concurrently active users", "retentionDays", srv.config.App.ConcurrencyRetentionDays)
+
+	sub := srv.eventBus.Subscribe(0, config.EventHeartbeatCreate)
+	go func(sub *hub.Subscription) {
+		for m := range sub.Receiver {
+			heartbeat := m.Fields[config.FieldPayload].(*models.Heartbeat)
+			srv.Track(heartbeat.UserID, heartbeat.Time.T())
+		}
+	}(&sub)
+
+	// not using the job queues here, because samples must be taken at regular intervals, regardless of load
+	time.Sleep(time.Until(time.Now().Truncate(time.Minute).Add(time.Minute)))
+	ticker := time.NewTicker(time.Minute)
+	for t := range ticker.C {
+		srv.sample(t)
+	}
+}
+
+// Track notes a user's activity at the given time, heartbeats sent with a delay (e.g. imports or offline heartbeats) only count if still within the heartbeat timeout
+func (srv *ConcurrencyService) Track(userId string, t time.Time) {
+	if time.Since(t) > models.DefaultHeartbeatsTimeout {
+		return
+	}
+
+	srv.lastSeenLock.Lock()
+	defer srv.lastSeenLock.Unlock()
+	if t.After(srv.lastSeen[userId]) {
+		srv.lastSeen[userId] = t
+	}
+}
+
+// CountActive returns the number of users who were active within the heartbeat timeout before the given time and forgets about all others
+func (srv *ConcurrencyService) CountActive(now time.Time) int {
+	srv.lastSeenLock.Lock()
+	defer srv.lastSeenLock.Unlock()
+
+	for userId, t := range srv.lastSeen {
+		if now.Sub(t) > models.DefaultHeartbeatsTimeout {
+			delete(srv.lastSeen, userId)
+		}
+	}
+	return len(srv.lastSeen)
+}
+
+func (srv *ConcurrencyService) GetWithin(from, to time.Time) ([]*models.ConcurrencySample, error) {
+	return srv.repository.GetWithin(from, to)
+}
+
+func (srv *ConcurrencyService) sample(now time.Time) {
+	sample := &models.ConcurrencySample{
+		Time:        models.CustomTime(now.Truncate(time.Minute)),
+		ActiveUsers: srv.CountActive(now),
+	}
+	if err := srv.repository.Upsert(sample); err != nil {
+		config.Log().Error("failed to save concurrency sample", "error", err)
+	}
+
+	if now.Minute() == 0 && srv.config.App.ConcurrencyRetentionDays > 0 {
+		if err := srv.repository.DeleteBefore(now.AddDate(0, 0, -srv.config.App.ConcurrencyRetentionDays)); err != nil {
+			config.Log().Error("failed to delete old concurrency samples", "error", err)
+		}
+	}
+}
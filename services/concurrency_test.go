@@ -0,0 +1,25 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyService_CountActive(t *testing.T) {
+	config.Set(config.Empty())
+
+	now := time.Now()
+	sut := NewConcurrencyService(nil)
+
+	sut.Track("user1", now.Add(-30*time.Second))
+	sut.Track("user1", now.Add(-90*time.Second)) // older heartbeat must not move last activity back
+	sut.Track("user2", now.Add(-90*time.Second))
+	sut.Track("user3", now.Add(-time.Hour)) // e.g. from an import
+	assert.Equal(t, 2, sut.CountActive(now))
+
+	assert.Equal(t, 1, sut.CountActive(now.Add(time.Minute)))
+	assert.Equal(t, 0, sut.CountActive(now.Add(5*time.Minute)))
+}
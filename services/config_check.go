@@ -0,0 +1,152 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// ConfigCheckService validates the configuration against the environment the application runs in, i.e. whether configured
+// databases, mail servers and upstream apis are actually reachable with the given settings
+type ConfigCheckService struct {
+	config      *config.Config
+	httpClient  *http.Client
+	mailService IMailService
+}
+
+func NewConfigCheckService(mailService IMailService) *ConfigCheckService {
+	return &ConfigCheckService{
+		config:      config.Get(),
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		mailService: mailService,
+	}
+}
+
+// RunAll performs all checks, checks of disabled features are reported as skipped
+func (srv *ConfigCheckService) RunAll() []*models.ConfigCheck {
+	return []*models.ConfigCheck{
+		srv.checkDatabase(),
+		srv.checkMail(),
+		srv.checkRelay(),
+		srv.checkPluginUpdates(),
+	}
+}
+
+// LogAll performs all checks and logs their results, without aborting on failures
+func (srv *ConfigCheckService) LogAll() {
+	for _, c := range srv.RunAll() {
+		switch c.Status {
+		case models.ConfigCheckFailed:
+			slog.Error("config check failed", "check", c.Name, "message", c.Message, "hint", c.Hint)
+		case models.ConfigCheckWarning:
+			slog.Warn("config check passed with warnings", "check", c.Name, "message", c.Message, "hint", c.Hint)
+		case models.ConfigCheckSkipped:
+			slog.Debug("config check skipped", "check", c.Name, "message", c.Message)
+		default:
+			slog.Info("config check passed", "check", c.Name, "message", c.Message)
+		}
+	}
+}
+
+func (srv *ConfigCheckService) checkDatabase() *models.ConfigCheck {
+	check := &models.ConfigCheck{Name: "database"}
+
+	db, err := gorm.Open(srv.config.Db.GetDialector(), &gorm.Config{Logger: logger.Discard}, config.GetWakapiDBOpts(&srv.config.Db))
+	if err != nil {
+		return failedCheck(check, err, "check db.dialect, db.host, db.port, db.user, db.password and db.name")
+	}
+	sqlDb, err := db.DB()
+	if err != nil {
+		return failedCheck(check, err, "check db.dialect and the connection parameters")
+	}
+	defer sqlDb.Close()
+
+	if err := sqlDb.Ping(); err != nil {
+		return failedCheck(check, err, "make sure the database server is running and reachable from this host")
+	}
+
+	check.Status = models.ConfigCheckOk
+	check.Message = fmt.Sprintf("connected to %s database", srv.config.Db.Dialect)
+	return check
+}
+
+func (srv *ConfigCheckService) checkMail() *models.ConfigCheck {
+	check := &models.ConfigCheck{Name: "mail"}
+
+	if !srv.config.Mail.Enabled || srv.config.Mail.Provider != config.MailProviderSmtp {
+		check.Status = models.ConfigCheckSkipped
+		check.Message = "smtp mail sending is disabled"
+		return check
+	}
+	if srv.config.Mail.Sender == "" {
+		return failedCheck(check, fmt.Errorf("no sender address configured"), "set mail.sender")
+	}
+
+	if err := srv.mailService.Check(); err != nil {
+		return failedCheck(check, err, "check mail.smtp.host, mail.smtp.port, mail.smtp.tls and the credentials")
+	}
+
+	check.Status = models.ConfigCheckOk
+	check.Message = fmt.Sprintf("authenticated at %s", srv.config.Mail.Smtp.ConnStr())
+	return check
+}
+
+func (srv *ConfigCheckService) checkRelay() *models.ConfigCheck {
+	check := &models.ConfigCheck{Name: "relay"}
+
+	// any response counts, the api root itself requires authentication
+	if err := srv.checkReachable(config.WakatimeApiUrl); err != nil {
+		failedCheck(check, err, "heartbeats can't be relayed to wakatime, allow outgoing connections or ignore this if none of your users relay heartbeats")
+		check.Status = models.ConfigCheckWarning
+		return check
+	}
+
+	check.Status = models.ConfigCheckOk
+	check.Message = fmt.Sprintf("reached %s", config.WakatimeApiUrl)
+	return check
+}
+
+func (srv *ConfigCheckService) checkPluginUpdates() *models.ConfigCheck {
+	check := &models.ConfigCheck{Name: "plugin_updates"}
+
+	upstream := srv.config.PluginUpdates.UpstreamUrl
+	if !srv.config.PluginUpdates.Enabled || upstream == "" {
+		check.Status = models.ConfigCheckSkipped
+		check.Message = "no upstream to mirror plugin releases from configured"
+		return check
+	}
+
+	if err := srv.checkReachable(strings.TrimSuffix(upstream, "/") + "/releases/latest"); err != nil {
+		return failedCheck(check, err, "check plugin_updates.upstream_url or leave it blank to only serve local releases")
+	}
+
+	check.Status = models.ConfigCheckOk
+	check.Message = fmt.Sprintf("reached %s", upstream)
+	return check
+}
+
+func (srv *ConfigCheckService) checkReachable(url string) error {
+	res, err := srv.httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode >= 500 {
+		return fmt.Errorf("got response status %d", res.StatusCode)
+	}
+	return nil
+}
+
+func failedCheck(check *models.ConfigCheck, err error, hint string) *models.ConfigCheck {
+	check.Status = models.ConfigCheckFailed
+	check.Message = err.Error()
+	check.Hint = hint
+	return check
+}
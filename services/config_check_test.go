@@ -0,0 +1,95 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigCheckService_CheckDatabase(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Db.Dialect = config.SQLDialectSqlite
+	cfg.Db.Name = filepath.Join(t.TempDir(), "wakapi.db")
+	config.Set(cfg)
+
+	check := NewConfigCheckService(nil).checkDatabase()
+	assert.Equal(t, models.ConfigCheckOk, check.Status)
+	assert.False(t, check.Failed())
+
+	cfg = config.Empty()
+	cfg.Db.Dialect = config.SQLDialectPostgres
+	cfg.Db.Host = "127.0.0.1"
+	cfg.Db.Port = 1 // nothing listening
+	cfg.Db.User = "wakapi"
+	cfg.Db.Name = "wakapi"
+	config.Set(cfg)
+
+	check = NewConfigCheckService(nil).checkDatabase()
+	assert.True(t, check.Failed())
+	assert.NotEmpty(t, check.Message)
+	assert.NotEmpty(t, check.Hint)
+}
+
+func TestConfigCheckService_CheckMail(t *testing.T) {
+	cfg := config.Empty()
+	config.Set(cfg)
+
+	mailService := new(mocks.MailServiceMock)
+	check := NewConfigCheckService(mailService).checkMail()
+	assert.Equal(t, models.ConfigCheckSkipped, check.Status)
+
+	cfg.Mail.Enabled = true
+	cfg.Mail.Provider = config.MailProviderSmtp
+	check = NewConfigCheckService(mailService).checkMail()
+	assert.True(t, check.Failed()) // no sender
+	assert.Equal(t, "set mail.sender", check.Hint)
+
+	cfg.Mail.Sender = "noreply@example.org"
+	mailService.On("Check").Return(nil).Once()
+	check = NewConfigCheckService(mailService).checkMail()
+	assert.Equal(t, models.ConfigCheckOk, check.Status)
+
+	mailService.On("Check").Return(errors.New("535 authentication failed")).Once()
+	check = NewConfigCheckService(mailService).checkMail()
+	assert.True(t, check.Failed())
+	assert.Equal(t, "535 authentication failed", check.Message)
+}
+
+func TestConfigCheckService_CheckPluginUpdates(t *testing.T) {
+	var status int
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/releases/latest", r.URL.Path)
+		w.WriteHeader(status)
+	}))
+	defer upstream.Close()
+
+	cfg := config.Empty()
+	config.Set(cfg)
+
+	check := NewConfigCheckService(nil).checkPluginUpdates()
+	assert.Equal(t, models.ConfigCheckSkipped, check.Status)
+
+	cfg.PluginUpdates.Enabled = true
+	cfg.PluginUpdates.UpstreamUrl = upstream.URL + "/"
+
+	// any response but a server error counts as reachable
+	status = http.StatusNotFound
+	check = NewConfigCheckService(nil).checkPluginUpdates()
+	assert.Equal(t, models.ConfigCheckOk, check.Status)
+
+	status = http.StatusBadGateway
+	check = NewConfigCheckService(nil).checkPluginUpdates()
+	assert.True(t, check.Failed())
+	assert.Equal(t, "got response status 502", check.Message)
+
+	upstream.Close()
+	check = NewConfigCheckService(nil).checkPluginUpdates()
+	assert.True(t, check.Failed())
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/duke-git/lancet/v2/condition"
+	datastructure "github.com/duke-git/lancet/v2/datastructure/set"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+)
+
+const devDataUserPrefix = "dev_user_"
+
+var devDataExtensions = map[string]string{
+	"Go":         ".go",
+	"TypeScript": ".ts",
+	"JavaScript": ".js",
+	"Python":     ".py",
+	"Rust":       ".rs",
+	"Java":       ".java",
+	"C":          ".c",
+	"C++":        ".cpp",
+	"Markdown":   ".md",
+	"HTML":       ".html",
+	"CSS":        ".css",
+	"YAML":       ".yml",
+}
+
+var devDataEditors = []struct{ editor, plugin string }{
+	{"vscode", "vscode/1.85.0 vscode-wakatime/24.4.0"},
+	{"neovim", "neovim/0.9.5 vim-wakatime/11.1.1"},
+	{"intellij", "IntelliJ/2023.3 IntelliJ-wakatime/14.3.1"},
+}
+
+var devDataOperatingSystems = []string{"linux", "darwin", "windows"}
+
+var devDataProjects = []string{"hackatime", "dotfiles", "website", "game-jam", "homework", "side-project"}
+
+// DevDataService generates synthetic, yet realistically looking, heartbeats to exercise summaries and leaderboards in development
+type DevDataService struct {
+	config          *config.Config
+	userSrvc        IUserService
+	heartbeatSrvc   IHeartbeatService
+	aggregationSrvc IAggregationService
+}
+
+func NewDevDataService(userService IUserService, heartbeatService IHeartbeatService, aggregationService IAggregationService) *DevDataService {
+	return &DevDataService{
+		config:          config.Get(),
+		userSrvc:        userService,
+		heartbeatSrvc:   heartbeatService,
+		aggregationSrvc: aggregationService,
+	}
+}
+
+func (srv *DevDataService) Generate(params *models.DevDataParams) (*models.DevDataResult, error) {
+	if !srv.config.IsDev() {
+		return nil, fmt.Errorf("generating synthetic data is only allowed in dev mode")
+	}
+
+	seed := params.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	rnd := rand.New(rand.NewSource(seed))
+
+	result := &models.DevDataResult{Users: make([]string, 0, params.Users)}
+	userIds := datastructure.New[string]()
+
+	for i := 1; i <= params.Users; i++ {
+		userId := fmt.Sprintf("%s%d", devDataUserPrefix, i)
+		user, _, err := srv.userSrvc.CreateOrGet(&models.Signup{
+			Username: userId,
+			Email:    fmt.Sprintf("%s@example.org", userId),
+			Password: userId,
+		}, false)
+		if err != nil {
+			return nil, err
+		}
+
+		heartbeats := generateDevHeartbeats(user, params.Days, params.Languages, time.Now(), rnd)
+		if err := srv.heartbeatSrvc.InsertBatch(heartbeats); err != nil {
+			return nil, err
+		}
+
+		if !user.HasData && len(heartbeats) > 0 {
+			user.HasData = true
+			if _, err := srv.userSrvc.Update(user); err != nil {
+				return nil, err
+			}
+		}
+
+		result.Users = append(result.Users, user.ID)
+		result.Heartbeats += len(heartbeats)
+		userIds.Add(user.ID)
+	}
+
+	slog.Info("generated synthetic heartbeats", "users", len(result.Users), "heartbeats", result.Heartbeats, "seed", seed)
+
+	if err := srv.aggregationSrvc.AggregateSummaries(userIds); err != nil {
+		config.Log().Error("failed to aggregate summaries for synthetic data", "error", err)
+	}
+
+	return result, nil
+}
+
+// generateDevHeartbeats simulates a few coding sessions on most days within the past days, each consisting of heartbeats every couple of minutes
+func generateDevHeartbeats(user *models.User, days int, languageWeights map[string]float64, now time.Time, rnd *rand.Rand) []*models.Heartbeat {
+	languages, cumulativeWeights := cumulateWeights(languageWeights)
+	pickLanguage := func() string {
+		if len(languages) == 0 {
+			return "Go"
+		}
+		n := rnd.Float64() * cumulativeWeights[len(cumulativeWeights)-1]
+		return languages[sort.SearchFloat64s(cumulativeWeights, n)]
+	}
+
+	// every user sticks to one editor and os and has a handful of projects
+	editor := devDataEditors[rnd.Intn(len(devDataEditors))]
+	opSys := devDataOperatingSystems[rnd.Intn(len(devDataOperatingSystems))]
+	projects := make([]string, 2+rnd.Intn(3))
+	for i, p := range rnd.Perm(len(devDataProjects))[:len(projects)] {
+		projects[i] = devDataProjects[p]
+	}
+	userAgent := fmt.Sprintf("wakatime/v1.90.0 (%s-6.5.0) go1.21.5 %s", opSys, editor.plugin)
+	machine := fmt.Sprintf("%s-%s", user.ID, opSys)
+
+	heartbeats := make([]*models.Heartbeat, 0)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, user.TZ())
+
+	for d := days - 1; d >= 0; d-- {
+		day := today.AddDate(0, 0, -d)
+		if rnd.Float64() < 0.2 { // take a day off every now and then
+			continue
+		}
+
+		sessions := 1 + rnd.Intn(3)
+		for s := 0; s < sessions; s++ {
+			t := day.Add(time.Duration(8+rnd.Intn(13))*time.Hour + time.Duration(rnd.Intn(60))*time.Minute)
+			end := t.Add(time.Duration(20+rnd.Intn(130)) * time.Minute)
+			project := projects[rnd.Intn(len(projects))]
+			branch := condition.TernaryOperator(rnd.Float64() < 0.7, "main", fmt.Sprintf("feature/%d", rnd.Intn(100)))
+			language := pickLanguage()
+
+			for ; t.Before(end) && t.Before(now); t = t.Add(time.Duration(30+rnd.Intn(150)) * time.Second) {
+				if rnd.Float64() < 0.1 { // switch files every once in a while
+					language = pickLanguage()
+				}
+				hb := &models.Heartbeat{
+					User:            user,
+					UserID:          user.ID,
+					Entity:          fmt.Sprintf("/home/dev/%s/src/file%d%s", project, rnd.Intn(20), devDataExtension(language)),
+					Type:            "file",
+					Category:        "coding",
+					Project:         project,
+					Branch:          branch,
+					Language:        language,
+					IsWrite:         rnd.Float64() < 0.3,
+					Editor:          editor.editor,
+					OperatingSystem: opSys,
+					Machine:         machine,
+					UserAgent:       userAgent,
+					Time:            models.CustomTime(t),
+					Source:          models.HeartbeatSourceSynthesized,
+				}
+				heartbeats = append(heartbeats, hb.Hashed())
+			}
+		}
+	}
+
+	return heartbeats
+}
+
+func cumulateWeights(weights map[string]float64) ([]string, []float64) {
+	keys := make([]string, 0, len(weights))
+	for k, w := range weights {
+		if w > 0 {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys) // for deterministic results given the same seed
+
+	cumulative := make([]float64, len(keys))
+	var sum float64
+	for i, k := range keys {
+		sum += weights[k]
+		cumulative[i] = sum
+	}
+	return keys, cumulative
+}
+
+func devDataExtension(language string) string {
+	if ext, ok := devDataExtensions[language]; ok {
+		return ext
+	}
+	return "." + strings.ToLower(strings.ReplaceAll(language, " ", ""))
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateDevHeartbeats(t *testing.T) {
+	user := &models.User{ID: "dev_user_1"}
+	now := time.Date(2024, 5, 20, 23, 0, 0, 0, time.Local)
+	languages := map[string]float64{"Go": 1, "Python": 1, "Rust": 0}
+
+	heartbeats := generateDevHeartbeats(user, 14, languages, now, rand.New(rand.NewSource(42)))
+	assert.NotEmpty(t, heartbeats)
+
+	seen := map[string]bool{}
+	for _, hb := range heartbeats {
+		assert.True(t, hb.Valid())
+		assert.False(t, hb.Time.T().After(now))
+		assert.True(t, hb.Time.T().After(now.AddDate(0, 0, -14)))
+		assert.NotEmpty(t, hb.Hash)
+		assert.NotEqual(t, "Rust", hb.Language)
+		seen[hb.Language] = true
+	}
+	assert.Len(t, seen, 2)
+
+	// same seed, same data
+	again := generateDevHeartbeats(user, 14, languages, now, rand.New(rand.NewSource(42)))
+	assert.Len(t, again, len(heartbeats))
+	assert.Equal(t, heartbeats[0].Hash, again[0].Hash)
+}
+
+func TestDevDataParams_Validate(t *testing.T) {
+	params := (&models.DevDataParams{}).WithDefaults()
+	assert.Nil(t, params.Validate())
+	assert.Equal(t, 3, params.Users)
+
+	assert.NotNil(t, (&models.DevDataParams{Users: 1000, Days: 1}).Validate())
+	assert.NotNil(t, (&models.DevDataParams{Users: 1, Days: 1, Languages: map[string]float64{"Go": -1}}).Validate())
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/patrickmn/go-cache"
+)
+
+const (
+	devicePollInterval = 5 * time.Second
+	deviceSlowDownStep = 5 * time.Second
+)
+
+var (
+	ErrDeviceFlowDisabled         = errors.New("device authorization is disabled on this instance")
+	ErrDeviceAuthorizationPending = errors.New("authorization_pending")
+	ErrDeviceSlowDown             = errors.New("slow_down")
+	ErrDeviceAccessDenied         = errors.New("access_denied")
+	ErrDeviceExpiredToken         = errors.New("expired_token")
+	ErrDeviceUserCodeInvalid      = errors.New("invalid or expired code")
+	ErrDeviceRotationUnconfirmed  = errors.New("approving creates a new api key, which disconnects your other machines, please confirm this first")
+)
+
+// DeviceAuthService lets plugins on headless machines obtain the user's api key by having the user enter a short code on the website.
+// pending authorizations are only kept in memory, as they expire within minutes anyway.
+type DeviceAuthService struct {
+	config      *config.Config
+	cache       *cache.Cache
+	lock        sync.Mutex
+	userService IUserService
+}
+
+func NewDeviceAuthService(userService IUserService) *DeviceAuthService {
+	return &DeviceAuthService{
+		config:      config.Get(),
+		cache:       cache.New(cache.NoExpiration, 10*time.Minute),
+		userService: userService,
+	}
+}
+
+// Start creates a new pending authorization for the given client
+func (srv *DeviceAuthService) Start(clientName string) (*models.DeviceAuthorization, error) {
+	if !srv.config.Security.DeviceFlow {
+		return nil, ErrDeviceFlowDisabled
+	}
+
+	deviceCode := make([]byte, 32)
+	if _, err := rand.Read(deviceCode); err != nil {
+		return nil, err
+	}
+	userCode, err := srv.generateUserCode()
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := time.Duration(srv.config.Security.DeviceCodeTTLMin) * time.Minute
+	auth := &models.DeviceAuthorization{
+		DeviceCode: hex.EncodeToString(deviceCode),
+		UserCode:   userCode,
+		ClientName: clientName,
+		ExpiresAt:  time.Now().Add(ttl),
+		Interval:   devicePollInterval,
+	}
+
+	srv.cache.Set(srv.deviceCacheKey(auth.DeviceCode), auth, ttl)
+	srv.cache.Set(srv.userCacheKey(auth.UserCode), auth, ttl)
+	return auth, nil
+}
+
+// GetByUserCode looks up a pending authorization by the code entered by the user
+func (srv *DeviceAuthService) GetByUserCode(userCode string) (*models.DeviceAuthorization, error) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	return srv.getPendingByUserCode(userCode)
+}
+
+// Approve hands out the user's api key to the device, which requested the given user code, upon its next poll.
+// If api keys are stored as hashes, the plain key can't be recovered, so a new one is created instead, which is only kept in memory until redeemed.
+// As this disconnects all other machines using the old key, the user has to explicitly confirm the rotation in that case.
+func (srv *DeviceAuthService) Approve(userCode string, user *models.User, confirmRotation bool) error {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	auth, err := srv.getPendingByUserCode(userCode)
+	if err != nil {
+		return err
+	}
+
+	if user.ApiKey == "" {
+		if !confirmRotation {
+			return ErrDeviceRotationUnconfirmed
+		}
+		if _, err := srv.userService.ResetApiKey(user); err != nil {
+			return err
+		}
+		slog.Info("rotated api key for device authorization", "userID", user.ID)
+	}
+
+	auth.UserID, auth.ApiKey = user.ID, user.ApiKey
+	slog.Info("approved device authorization", "userID", user.ID, "client", auth.ClientName)
+	return nil
+}
+
+func (srv *DeviceAuthService) Deny(userCode string) error {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	auth, err := srv.getPendingByUserCode(userCode)
+	if err != nil {
+		return err
+	}
+	auth.Denied = true
+	return nil
+}
+
+// Poll is called by the device repeatedly until the user approved or denied the request and returns the approved authorization, including the api key.
+// an approved authorization can only be redeemed once.
+func (srv *DeviceAuthService) Poll(deviceCode string) (*models.DeviceAuthorization, error) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	item, found := srv.cache.Get(srv.deviceCacheKey(deviceCode))
+	if !found {
+		return nil, ErrDeviceExpiredToken
+	}
+	auth := item.(*models.DeviceAuthorization)
+	if auth.IsExpired() {
+		return nil, ErrDeviceExpiredToken
+	}
+
+	defer func() { auth.LastPolledAt = time.Now() }()
+
+	if auth.Denied {
+		srv.remove(auth)
+		return nil, ErrDeviceAccessDenied
+	}
+	if auth.IsApproved() {
+		srv.remove(auth)
+		return auth, nil
+	}
+	if time.Since(auth.LastPolledAt) < auth.Interval {
+		auth.Interval += deviceSlowDownStep
+		return nil, ErrDeviceSlowDown
+	}
+	return nil, ErrDeviceAuthorizationPending
+}
+
+func (srv *DeviceAuthService) getPendingByUserCode(userCode string) (*models.DeviceAuthorization, error) {
+	item, found := srv.cache.Get(srv.userCacheKey(models.NormalizeDeviceUserCode(userCode)))
+	if !found {
+		return nil, ErrDeviceUserCodeInvalid
+	}
+	auth := item.(*models.DeviceAuthorization)
+	if auth.IsExpired() || auth.IsApproved() || auth.Denied {
+		return nil, ErrDeviceUserCodeInvalid
+	}
+	return auth, nil
+}
+
+func (srv *DeviceAuthService) remove(auth *models.DeviceAuthorization) {
+	srv.cache.Delete(srv.deviceCacheKey(auth.DeviceCode))
+	srv.cache.Delete(srv.userCacheKey(auth.UserCode))
+}
+
+func (srv *DeviceAuthService) generateUserCode() (string, error) {
+	for {
+		code := make([]byte, models.DeviceUserCodeLength)
+		for i := range code {
+			n, err := rand.Int(rand.Reader, big.NewInt(int64(len(models.DeviceUserCodeCharset))))
+			if err != nil {
+				return "", err
+			}
+			code[i] = models.DeviceUserCodeCharset[n.Int64()]
+		}
+		if _, taken := srv.cache.Get(srv.userCacheKey(string(code))); !taken {
+			return string(code), nil
+		}
+	}
+}
+
+func (srv *DeviceAuthService) deviceCacheKey(deviceCode string) string {
+	return "device_" + deviceCode
+}
+
+func (srv *DeviceAuthService) userCacheKey(userCode string) string {
+	return "user_" + userCode
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDeviceAuthService_Approve(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Security.DeviceFlow = true
+	cfg.Security.DeviceCodeTTLMin = 15
+	config.Set(cfg)
+
+	sut := NewDeviceAuthService(new(mocks.UserServiceMock))
+	user := &models.User{ID: "user1", ApiKey: "fc4e6e4c-5ef6-4b6a-9c4b-1f1a6a6c0b2e"}
+
+	auth, err := sut.Start("vscode")
+	assert.Nil(t, err)
+	assert.Len(t, auth.UserCode, models.DeviceUserCodeLength)
+	assert.Len(t, auth.FormattedUserCode(), models.DeviceUserCodeLength+1)
+
+	_, err = sut.Poll(auth.DeviceCode)
+	assert.ErrorIs(t, err, ErrDeviceAuthorizationPending)
+	_, err = sut.Poll(auth.DeviceCode)
+	assert.ErrorIs(t, err, ErrDeviceSlowDown)
+	assert.Equal(t, devicePollInterval+deviceSlowDownStep, auth.Interval)
+
+	// user codes are matched regardless of case and separators
+	found, err := sut.GetByUserCode(" " + auth.FormattedUserCode()[:4] + "-" + auth.UserCode[4:] + " ")
+	assert.Nil(t, err)
+	assert.Equal(t, "vscode", found.ClientName)
+
+	assert.Nil(t, sut.Approve(auth.FormattedUserCode(), user, false))
+	assert.ErrorIs(t, sut.Approve(auth.UserCode, user, false), ErrDeviceUserCodeInvalid)
+
+	approved, err := sut.Poll(auth.DeviceCode)
+	assert.Nil(t, err)
+	assert.Equal(t, "user1", approved.UserID)
+	assert.Equal(t, user.ApiKey, approved.ApiKey)
+
+	// can only be redeemed once
+	_, err = sut.Poll(auth.DeviceCode)
+	assert.ErrorIs(t, err, ErrDeviceExpiredToken)
+}
+
+func TestDeviceAuthService_Deny(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Security.DeviceFlow = true
+	cfg.Security.DeviceCodeTTLMin = 15
+	config.Set(cfg)
+
+	sut := NewDeviceAuthService(new(mocks.UserServiceMock))
+
+	auth, err := sut.Start("")
+	assert.Nil(t, err)
+	assert.Nil(t, sut.Deny(auth.UserCode))
+	assert.ErrorIs(t, sut.Deny(auth.UserCode), ErrDeviceUserCodeInvalid)
+
+	_, err = sut.Poll(auth.DeviceCode)
+	assert.ErrorIs(t, err, ErrDeviceAccessDenied)
+	_, err = sut.Poll(auth.DeviceCode)
+	assert.ErrorIs(t, err, ErrDeviceExpiredToken)
+}
+
+func TestDeviceAuthService_Expired(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Security.DeviceFlow = true
+	cfg.Security.DeviceCodeTTLMin = 15
+	config.Set(cfg)
+
+	sut := NewDeviceAuthService(new(mocks.UserServiceMock))
+
+	auth, err := sut.Start("")
+	assert.Nil(t, err)
+	auth.ExpiresAt = time.Now().Add(-time.Second)
+
+	_, err = sut.GetByUserCode(auth.UserCode)
+	assert.ErrorIs(t, err, ErrDeviceUserCodeInvalid)
+	_, err = sut.Poll(auth.DeviceCode)
+	assert.ErrorIs(t, err, ErrDeviceExpiredToken)
+	_, err = sut.Poll("unknown")
+	assert.ErrorIs(t, err, ErrDeviceExpiredToken)
+}
+
+func TestDeviceAuthService_HashedApiKey(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Security.DeviceFlow = true
+	cfg.Security.DeviceCodeTTLMin = 15
+	cfg.Security.ApiKeyStorage = utils.ApiKeyStorageHashed
+	config.Set(cfg)
+
+	// with hashed storage, the plain api key is not available after loading the user
+	user := &models.User{ID: "user1"}
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("ResetApiKey", user).Run(func(args mock.Arguments) {
+		args.Get(0).(*models.User).ApiKey = "a1b2c3d4-0000-4000-8000-000000000001"
+	}).Return(user, nil)
+
+	sut := NewDeviceAuthService(userServiceMock)
+
+	auth, err := sut.Start("")
+	assert.Nil(t, err)
+	// rotating the key disconnects all other machines, so it needs to be confirmed
+	assert.ErrorIs(t, sut.Approve(auth.UserCode, user, false), ErrDeviceRotationUnconfirmed)
+	userServiceMock.AssertNotCalled(t, "ResetApiKey", user)
+	_, err = sut.Poll(auth.DeviceCode)
+	assert.ErrorIs(t, err, ErrDeviceAuthorizationPending)
+
+	assert.Nil(t, sut.Approve(auth.UserCode, user, true))
+	userServiceMock.AssertNumberOfCalls(t, "ResetApiKey", 1)
+
+	approved, err := sut.Poll(auth.DeviceCode)
+	assert.Nil(t, err)
+	assert.Equal(t, "user1", approved.UserID)
+	assert.Equal(t, "a1b2c3d4-0000-4000-8000-000000000001", approved.ApiKey)
+
+	// plain key is handed out only once
+	_, err = sut.Poll(auth.DeviceCode)
+	assert.ErrorIs(t, err, ErrDeviceExpiredToken)
+}
+
+func TestDeviceAuthService_Disabled(t *testing.T) {
+	config.Set(config.Empty())
+
+	_, err := NewDeviceAuthService(new(mocks.UserServiceMock)).Start("")
+	assert.ErrorIs(t, err, ErrDeviceFlowDisabled)
+}
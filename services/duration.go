@@ -1,35 +1,195 @@
 package services
 
 import (
+	"context"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/duke-git/lancet/v2/datetime"
 	"github.com/duke-git/lancet/v2/mathutil"
 	"github.com/hackclub/hackatime/config"
 	"github.com/hackclub/hackatime/models"
+	"github.com/leandro-lugaresi/hub"
+	"github.com/patrickmn/go-cache"
 )
 
+// heartbeats of a day are kept for at most this long, which also bounds the staleness caused by changes that aren't announced as events (e.g. language mappings)
+const durationCacheTTL = 1 * time.Hour
+
 type DurationService struct {
 	config           *config.Config
+	eventBus         *hub.Hub
+	cache            *cache.Cache
 	heartbeatService IHeartbeatService
+	timeEntryService ITimeEntryService
 }
 
-func NewDurationService(heartbeatService IHeartbeatService) *DurationService {
+func NewDurationService(heartbeatService IHeartbeatService, timeEntryService ITimeEntryService) *DurationService {
 	srv := &DurationService{
 		config:           config.Get(),
+		eventBus:         config.EventBus(),
+		cache:            cache.New(durationCacheTTL, durationCacheTTL),
 		heartbeatService: heartbeatService,
+		timeEntryService: timeEntryService,
 	}
+
+	sub1 := srv.eventBus.Subscribe(0, config.EventHeartbeatCreate, config.EventHeartbeatUpdate, config.EventUserUpdate)
+	go func(sub *hub.Subscription) {
+		for m := range sub.Receiver {
+			switch m.Name {
+			case config.EventHeartbeatCreate:
+				heartbeat := m.Fields[config.FieldPayload].(*models.Heartbeat)
+				if days, ok := srv.cache.Get(heartbeat.UserID); ok {
+					days.(*durationCacheUser).invalidateSince(heartbeat.Time.T())
+				}
+			case config.EventHeartbeatUpdate:
+				if userId := m.Fields[config.FieldUserId].(string); userId != "" {
+					srv.cache.Delete(userId)
+				} else {
+					srv.cache.Flush()
+				}
+			case config.EventUserUpdate:
+				srv.cache.Delete(m.Fields[config.FieldPayload].(*models.User).ID)
+			}
+		}
+	}(&sub1)
+
 	return srv
 }
 
+// WithContext returns a copy of the service sharing the same cache, whose database queries are canceled once the given context is done
+func (srv *DurationService) WithContext(ctx context.Context) IDurationService {
+	scoped := *srv
+	if s, ok := srv.heartbeatService.(contextScoped[IHeartbeatService]); ok {
+		scoped.heartbeatService = s.WithContext(ctx)
+	}
+	return &scoped
+}
+
 func (srv *DurationService) Get(from, to time.Time, user *models.User, filters *models.Filters) (models.Durations, error) {
 	heartbeatsTimeout := user.HeartbeatsTimeout()
 
+	rawDurations, numHeartbeats, err := srv.getRawDurations(from, to, user)
+	if err != nil {
+		return nil, err
+	}
+
+	durations := make(models.Durations, 0, len(rawDurations))
+
+	for _, rd := range rawDurations {
+		// raw durations might be cached, so never modify them
+		d := *rd
+
+		// even when filters are applied, we'll still have to compute the whole summary first and then filter out non-matching durations
+		// if we fetched only matching heartbeats in the first place, there will be false positive gaps (see DefaultHeartbeatsTimeout)
+		// in case the user worked on different projects in parallel
+		// see https://github.com/muety/wakapi/issues/535
+		if filters != nil && !filters.MatchDuration(&d) {
+			continue
+		}
+
+		if user.ExcludeUnknownProjects && d.Project == "" {
+			continue
+		}
+
+		// will only happen if two heartbeats with different hashes (e.g. different project) have the same timestamp
+		// that, in turn, will most likely only happen for mysql, where `time` column's precision was set to second for a while
+		// assume that two non-identical heartbeats with identical time are sub-second apart from each other, so round up to expectancy value
+		// also see https://github.com/muety/wakapi/issues/340
+		if d.Duration == 0 {
+			d.Duration = 500 * time.Millisecond
+		}
+		durations = append(durations, &d)
+	}
+
+	if numHeartbeats == 1 && len(durations) == 1 {
+		durations[0].Duration = heartbeatsTimeout
+	}
+
+	// merge manually entered time
+	manualDurations, err := srv.getManualDurations(from, to, user, filters)
+	if err != nil {
+		return nil, err
+	}
+	durations = append(durations, manualDurations...)
+
+	return durations.Sorted(), nil
+}
+
+// getRawDurations returns the unfiltered durations within the given range and the number of heartbeats they were computed from.
+// Ranges within a single day, as requested by the statusbar or when generating daily summaries, are computed from a per-day cache of heartbeats,
+// which is incrementally refreshed as new heartbeats come in, instead of re-fetching the entire day from the database on every request.
+func (srv *DurationService) getRawDurations(from, to time.Time, user *models.User) (models.Durations, int, error) {
+	if !isSingleDay(from, to, user) {
+		heartbeats, err := srv.heartbeatService.GetAllWithin(from, to, user)
+		if err != nil {
+			return nil, 0, err
+		}
+		return computeDurationsWith(heartbeats, user.HeartbeatsTimeout(), user.OverlapResolution()), len(heartbeats), nil
+	}
+
+	return srv.getCachedDay(user.ID, from).durations(srv.heartbeatService, user, to)
+}
+
+// GetOverlap compares the time each machine was active within the given range with the time counted for it after resolving overlaps using the user's strategy
+func (srv *DurationService) GetOverlap(from, to time.Time, user *models.User) (*models.DurationOverlap, error) {
 	heartbeats, err := srv.heartbeatService.GetAllWithin(from, to, user)
 	if err != nil {
 		return nil, err
 	}
 
+	timeout, strategy := user.HeartbeatsTimeout(), user.OverlapResolution()
+	active := computeMachineDurations(heartbeats, timeout)
+	counted := computeDurationsWith(heartbeats, timeout, strategy)
+
+	machines := make(map[string]*models.MachineOverlap)
+	getMachine := func(d *models.Duration) *models.MachineOverlap {
+		key := d.GetKey(models.SummaryMachine)
+		if _, ok := machines[key]; !ok {
+			machines[key] = &models.MachineOverlap{Machine: key}
+		}
+		return machines[key]
+	}
+
+	var totalActive, totalUnion time.Duration
+	for _, d := range active {
+		getMachine(d).Active += d.Duration
+		totalActive += d.Duration
+	}
+	for _, d := range collapseOverlaps(active) {
+		totalUnion += d.Duration
+	}
+
+	report := &models.DurationOverlap{From: from, To: to, Strategy: strategy, Machines: make([]*models.MachineOverlap, 0, len(machines))}
+	for _, d := range counted {
+		getMachine(d).Counted += d.Duration
+		report.Total += d.Duration
+	}
+	report.Overlapping = totalActive - totalUnion
+	report.Collapsed = totalActive - report.Total
+
+	for _, m := range machines {
+		report.Machines = append(report.Machines, m)
+	}
+	sort.Slice(report.Machines, func(i, j int) bool {
+		return report.Machines[i].Machine < report.Machines[j].Machine
+	})
+	return report, nil
+}
+
+func (srv *DurationService) getCachedDay(userId string, from time.Time) *durationCacheDay {
+	for {
+		if days, ok := srv.cache.Get(userId); ok {
+			return days.(*durationCacheUser).get(from)
+		}
+		// might race with a concurrent request for the same user, in which case the other one's entry is used
+		srv.cache.Add(userId, &durationCacheUser{days: map[int64]*durationCacheDay{}}, cache.DefaultExpiration)
+	}
+}
+
+// computeDurations aggregates the given, chronologically ordered heartbeats to durations
+func computeDurations(heartbeats []*models.Heartbeat, heartbeatsTimeout time.Duration) models.Durations {
 	// Aggregation
 	// the below logic is approximately equivalent to the SQL query at scripts/aggregate_durations_mysql.sql
 	// a postgres-compatible script was contributed by @cwilby and is available at scripts/aggregate_durations_postgres.sql
@@ -80,41 +240,256 @@ func (srv *DurationService) Get(from, to time.Time, user *models.User, filters *
 			latest = d1
 		} else {
 			latest.NumHeartbeats++
+			latest.EditStats = latest.EditStats.Add(d1.EditStats)
 		}
 
 		count++
 	}
 
 	durations := make(models.Durations, 0)
-
 	for _, list := range mapping {
-		for _, d := range list {
-			// even when filters are applied, we'll still have to compute the whole summary first and then filter out non-matching durations
-			// if we fetched only matching heartbeats in the first place, there will be false positive gaps (see DefaultHeartbeatsTimeout)
-			// in case the user worked on different projects in parallel
-			// see https://github.com/muety/wakapi/issues/535
-			if filters != nil && !filters.MatchDuration(d) {
-				continue
-			}
+		durations = append(durations, list...)
+	}
+	return durations
+}
 
-			if user.ExcludeUnknownProjects && d.Project == "" {
-				continue
+// computeDurationsWith aggregates the given, chronologically ordered heartbeats to durations and resolves time, during which several machines were active, using the given strategy
+func computeDurationsWith(heartbeats []*models.Heartbeat, heartbeatsTimeout time.Duration, strategy string) models.Durations {
+	switch strategy {
+	case models.OverlapStrategySum:
+		return computeMachineDurations(heartbeats, heartbeatsTimeout)
+	case models.OverlapStrategyMax:
+		return collapseOverlaps(computeMachineDurations(heartbeats, heartbeatsTimeout))
+	default:
+		// a single timeline across all machines, i.e. a heartbeat from another machine ends the current duration
+		return computeDurations(heartbeats, heartbeatsTimeout)
+	}
+}
+
+// computeMachineDurations aggregates every machine's heartbeats on its own, so durations of different machines may overlap
+func computeMachineDurations(heartbeats []*models.Heartbeat, heartbeatsTimeout time.Duration) models.Durations {
+	machines := make([]string, 0)
+	byMachine := make(map[string][]*models.Heartbeat)
+	for _, h := range heartbeats {
+		if _, ok := byMachine[h.Machine]; !ok {
+			machines = append(machines, h.Machine)
+		}
+		byMachine[h.Machine] = append(byMachine[h.Machine], h)
+	}
+
+	durations := make(models.Durations, 0)
+	for _, m := range machines {
+		durations = append(durations, computeDurations(byMachine[m], heartbeatsTimeout)...)
+	}
+	return durations
+}
+
+// collapseOverlaps counts time, which is covered by several durations, only once for the longest of them.
+// Shorter durations are cut to the parts not covered by a longer one, which might split them up, or dropped entirely.
+func collapseOverlaps(durations models.Durations) models.Durations {
+	sorted := make(models.Durations, len(durations))
+	copy(sorted, durations)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Duration > sorted[j].Duration
+	})
+
+	claimed := make([][2]time.Time, 0, len(sorted)) // disjoint and ordered by start
+	result := make(models.Durations, 0, len(sorted))
+
+	for _, d := range sorted {
+		start, end := d.Time.T(), d.Time.T().Add(d.Duration)
+		if d.Duration <= 0 {
+			result = append(result, d)
+			continue
+		}
+
+		// first claimed interval ending after this duration's start
+		i := sort.Search(len(claimed), func(k int) bool {
+			return claimed[k][1].After(start)
+		})
+
+		cursor, first := start, true
+		j := i
+		for ; j < len(claimed) && claimed[j][0].Before(end); j++ {
+			if claimed[j][0].After(cursor) {
+				result = append(result, cutDuration(d, cursor, claimed[j][0], first))
+				first = false
 			}
+			if claimed[j][1].After(cursor) {
+				cursor = claimed[j][1]
+			}
+		}
+		if end.After(cursor) {
+			result = append(result, cutDuration(d, cursor, end, first))
+		}
 
-			// will only happen if two heartbeats with different hashes (e.g. different project) have the same timestamp
-			// that, in turn, will most likely only happen for mysql, where `time` column's precision was set to second for a while
-			// assume that two non-identical heartbeats with identical time are sub-second apart from each other, so round up to expectancy value
-			// also see https://github.com/muety/wakapi/issues/340
-			if d.Duration == 0 {
-				d.Duration = 500 * time.Millisecond
+		// merge this duration's interval with all claimed ones it overlaps
+		merged := [2]time.Time{start, end}
+		if i < j {
+			if claimed[i][0].Before(merged[0]) {
+				merged[0] = claimed[i][0]
+			}
+			if claimed[j-1][1].After(merged[1]) {
+				merged[1] = claimed[j-1][1]
 			}
-			durations = append(durations, d)
 		}
+		claimed = append(claimed[:i], append([][2]time.Time{merged}, claimed[j:]...)...)
 	}
 
-	if len(heartbeats) == 1 && len(durations) == 1 {
-		durations[0].Duration = heartbeatsTimeout
+	return result
+}
+
+// cutDuration copies the given duration restricted to [from, to), heartbeats and edit stats are only kept for the first part
+func cutDuration(d *models.Duration, from, to time.Time, first bool) *models.Duration {
+	part := *d
+	part.Time = models.CustomTime(from)
+	part.Duration = to.Sub(from)
+	if !first {
+		part.NumHeartbeats = 0
+		part.EditStats = models.EditStats{}
 	}
+	return &part
+}
 
-	return durations.Sorted(), nil
+func (srv *DurationService) getManualDurations(from, to time.Time, user *models.User, filters *models.Filters) (models.Durations, error) {
+	durations := make(models.Durations, 0)
+	if srv.timeEntryService == nil {
+		return durations, nil
+	}
+
+	entries, err := srv.timeEntryService.GetByUserWithin(user.ID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, e := range entries {
+		d := e.Duration(from, to)
+		if d == nil || (filters != nil && !filters.MatchDuration(d)) || (user.ExcludeUnknownProjects && d.Project == "") {
+			continue
+		}
+		durations = append(durations, d)
+	}
+	return durations, nil
+}
+
+// isSingleDay checks whether the given range starts at the beginning of a day in the user's time zone and doesn't extend beyond that day
+func isSingleDay(from, to time.Time, user *models.User) bool {
+	from = from.In(user.TZ())
+	return from.Equal(datetime.BeginOfDay(from)) && to.After(from) && !to.After(from.AddDate(0, 0, 1))
+}
+
+// durationCacheUser holds the cached days of a single user
+type durationCacheUser struct {
+	lock sync.Mutex
+	days map[int64]*durationCacheDay
+}
+
+func (c *durationCacheUser) get(from time.Time) *durationCacheDay {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	day, ok := c.days[from.Unix()]
+	if !ok {
+		day = &durationCacheDay{from: from, end: from.AddDate(0, 0, 1), loadedTo: from}
+		c.days[from.Unix()] = day
+	}
+	return day
+}
+
+func (c *durationCacheUser) invalidateSince(t time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, day := range c.days {
+		day.invalidateSince(t)
+	}
+}
+
+// durationCacheDay holds the heartbeats of a day, which were fetched from the database so far.
+// Heartbeats only ever get appended to a day (even though clients might send them with delay), so only the part after the earliest new heartbeat needs to be re-fetched.
+// Once the day is over and all its heartbeats were fetched, it is served from memory entirely.
+type durationCacheDay struct {
+	from       time.Time
+	end        time.Time
+	lock       sync.Mutex
+	heartbeats []*models.Heartbeat // chronologically ordered, all within [from, loadedTo)
+	loadedTo   time.Time
+	stale      *time.Time // earliest time of any heartbeat created since the last refresh
+	staleLock  sync.Mutex
+	// most recently computed durations
+	result         models.Durations
+	resultTo       time.Time
+	resultTimeout  time.Duration
+	resultStrategy string
+	resultCount    int
+}
+
+func (d *durationCacheDay) invalidateSince(t time.Time) {
+	if t.Before(d.from) || !t.Before(d.end) {
+		return
+	}
+	d.staleLock.Lock()
+	defer d.staleLock.Unlock()
+	if d.stale == nil || t.Before(*d.stale) {
+		d.stale = &t
+	}
+}
+
+func (d *durationCacheDay) takeStale() *time.Time {
+	d.staleLock.Lock()
+	defer d.staleLock.Unlock()
+	stale := d.stale
+	d.stale = nil
+	return stale
+}
+
+func (d *durationCacheDay) durations(heartbeatService IHeartbeatService, user *models.User, to time.Time) (models.Durations, int, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if err := d.refresh(heartbeatService, user, to); err != nil {
+		return nil, 0, err
+	}
+
+	timeout, strategy := user.HeartbeatsTimeout(), user.OverlapResolution()
+	if d.result != nil && d.resultTo.Equal(to) && d.resultTimeout == timeout && d.resultStrategy == strategy {
+		return d.result, d.resultCount, nil
+	}
+
+	n := d.indexOf(to)
+	d.result, d.resultTo, d.resultTimeout, d.resultStrategy, d.resultCount = computeDurationsWith(d.heartbeats[:n], timeout, strategy), to, timeout, strategy, n
+	return d.result, n, nil
+}
+
+// refresh fetches all heartbeats that came in since the last refresh and the ones up until the requested time, if not yet loaded
+func (d *durationCacheDay) refresh(heartbeatService IHeartbeatService, user *models.User, to time.Time) error {
+	since, until := d.loadedTo, d.loadedTo
+	if stale := d.takeStale(); stale != nil && stale.Before(since) {
+		since = *stale
+	}
+	if to.After(until) {
+		until = to
+	}
+	if !since.Before(until) {
+		return nil
+	}
+
+	heartbeats, err := heartbeatService.GetAllWithin(since, until, user)
+	if err != nil {
+		if since.Before(d.loadedTo) {
+			d.invalidateSince(since) // retry next time
+		}
+		return err
+	}
+
+	n := d.indexOf(since)
+	d.heartbeats = append(d.heartbeats[:n:n], heartbeats...)
+	d.loadedTo = until
+	d.result = nil
+	return nil
+}
+
+// indexOf returns the index of the first heartbeat at or after the given time
+func (d *durationCacheDay) indexOf(t time.Time) int {
+	return sort.Search(len(d.heartbeats), func(i int) bool {
+		return !d.heartbeats[i].Time.T().Before(t)
+	})
 }
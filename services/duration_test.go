@@ -135,7 +135,7 @@ func TestDurationServiceTestSuite(t *testing.T) {
 
 func (suite *DurationServiceTestSuite) TestDurationService_Get() {
 	// https://anchr.io/i/F0HEK.jpg
-	sut := NewDurationService(suite.HeartbeatService)
+	sut := NewDurationService(suite.HeartbeatService, nil)
 
 	var (
 		from      time.Time
@@ -184,7 +184,7 @@ func (suite *DurationServiceTestSuite) TestDurationService_Get() {
 }
 
 func (suite *DurationServiceTestSuite) TestDurationService_Get_Filtered() {
-	sut := NewDurationService(suite.HeartbeatService)
+	sut := NewDurationService(suite.HeartbeatService, nil)
 
 	var (
 		from      time.Time
@@ -205,7 +205,7 @@ func (suite *DurationServiceTestSuite) TestDurationService_Get_Filtered() {
 }
 
 func (suite *DurationServiceTestSuite) TestDurationService_Get_CustomTimeout() {
-	sut := NewDurationService(suite.HeartbeatService)
+	sut := NewDurationService(suite.HeartbeatService, nil)
 
 	var (
 		from      time.Time
@@ -255,6 +255,167 @@ func (suite *DurationServiceTestSuite) TestDurationService_Get_CustomTimeout() {
 	assert.Equal(suite.T(), 3, durations[1].NumHeartbeats)
 }
 
+func (suite *DurationServiceTestSuite) TestDurationService_Get_Cached() {
+	sut := NewDurationService(suite.HeartbeatService, nil)
+	user := &models.User{ID: TestUserId, Location: "UTC"}
+
+	var (
+		from      = suite.TestStartTime // beginning of a day
+		to1       = suite.TestStartTime.Add(1 * time.Minute)
+		to2       = suite.TestStartTime.Add(1 * time.Hour)
+		durations models.Durations
+		err       error
+	)
+
+	suite.HeartbeatService.On("GetAllWithin", from, to1, user).Return(filterHeartbeats(from, to1, suite.TestHeartbeats), nil)
+	suite.HeartbeatService.On("GetAllWithin", to1, to2, user).Return(filterHeartbeats(to1, to2, suite.TestHeartbeats), nil)
+
+	/* Test 1 */
+	durations, err = sut.Get(from, to1, user, nil)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), durations, 1)
+	suite.HeartbeatService.AssertNumberOfCalls(suite.T(), "GetAllWithin", 1)
+
+	/* Test 2 */
+	// only heartbeats after the previously requested range are fetched
+	durations, err = sut.Get(from, to2, user, nil)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), durations, 3)
+	assert.Equal(suite.T(), 150*time.Second, durations[0].Duration)
+	suite.HeartbeatService.AssertNumberOfCalls(suite.T(), "GetAllWithin", 2)
+
+	/* Test 3 */
+	durations, err = sut.Get(from, to2, user, nil)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), durations, 3)
+	suite.HeartbeatService.AssertNumberOfCalls(suite.T(), "GetAllWithin", 2)
+
+	/* Test 4 */
+	// a (delayed) heartbeat came in, so everything after it is re-fetched
+	since := suite.TestStartTime.Add(3 * time.Minute)
+	suite.HeartbeatService.On("GetAllWithin", since, to2, user).Return(filterHeartbeats(since, to2, suite.TestHeartbeats), nil)
+	sut.getCachedDay(user.ID, from).invalidateSince(since)
+
+	durations, err = sut.Get(from, to2, user, nil)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), durations, 3)
+	assert.Equal(suite.T(), 150*time.Second, durations[0].Duration)
+	assert.Equal(suite.T(), 15*time.Second, durations[2].Duration)
+	assert.Equal(suite.T(), 3, durations[2].NumHeartbeats)
+	suite.HeartbeatService.AssertNumberOfCalls(suite.T(), "GetAllWithin", 3)
+}
+
+func (suite *DurationServiceTestSuite) TestDurationService_Get_OverlapStrategy() {
+	sut := NewDurationService(suite.HeartbeatService, nil)
+
+	defer func() {
+		suite.TestUser.OverlapStrategy = "" // revert to defaults
+	}()
+
+	newHeartbeat := func(machine, project string, offsetSec int) *models.Heartbeat {
+		return &models.Heartbeat{
+			ID:       rand.Uint64(),
+			UserID:   TestUserId,
+			Project:  project,
+			Language: TestLanguageGo,
+			Machine:  machine,
+			Time:     models.CustomTime(suite.TestStartTime.Add(time.Duration(offsetSec) * time.Second)),
+		}
+	}
+
+	// machine 1 active from 0:00 to 2:00, machine 2 from 1:40 to 2:40
+	heartbeats := []*models.Heartbeat{
+		newHeartbeat(TestMachine1, TestProject1, 0),
+		newHeartbeat(TestMachine1, TestProject1, 30),
+		newHeartbeat(TestMachine1, TestProject1, 60),
+		newHeartbeat(TestMachine1, TestProject1, 90),
+		newHeartbeat(TestMachine2, TestProject2, 100),
+		newHeartbeat(TestMachine1, TestProject1, 120),
+		newHeartbeat(TestMachine2, TestProject2, 130),
+		newHeartbeat(TestMachine2, TestProject2, 160),
+	}
+
+	from, to := suite.TestStartTime.Add(-1*time.Hour), suite.TestStartTime.Add(1*time.Hour)
+	suite.HeartbeatService.On("GetAllWithin", from, to, suite.TestUser).Return(heartbeats, nil)
+
+	total := func(durations models.Durations) (sum time.Duration) {
+		for _, d := range durations {
+			sum += d.Duration
+		}
+		return sum
+	}
+
+	/* Test 1 */
+	durations, err := sut.Get(from, to, suite.TestUser, nil)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 160*time.Second, total(durations))
+	assert.Len(suite.T(), durations, 4)
+
+	/* Test 2 */
+	suite.TestUser.OverlapStrategy = models.OverlapStrategySum
+	durations, err = sut.Get(from, to, suite.TestUser, nil)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 180*time.Second, total(durations))
+	assert.Len(suite.T(), durations, 2)
+
+	/* Test 3 */
+	suite.TestUser.OverlapStrategy = models.OverlapStrategyMax
+	durations, err = sut.Get(from, to, suite.TestUser, nil)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), 160*time.Second, total(durations))
+	assert.Len(suite.T(), durations, 2)
+	assert.Equal(suite.T(), 120*time.Second, durations[0].Duration)
+	assert.Equal(suite.T(), 40*time.Second, durations[1].Duration) // cut to the part after machine 1 stopped
+	assert.Equal(suite.T(), suite.TestStartTime.Add(120*time.Second), durations[1].Time.T())
+	assert.Equal(suite.T(), TestMachine2, durations[1].Machine)
+
+	/* Test 4 */
+	suite.TestUser.OverlapStrategy = ""
+	report, err := sut.GetOverlap(from, to, suite.TestUser)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), models.OverlapStrategyMostRecent, report.Strategy)
+	assert.Equal(suite.T(), 160*time.Second, report.Total)
+	assert.Equal(suite.T(), 20*time.Second, report.Overlapping)
+	assert.Equal(suite.T(), 20*time.Second, report.Collapsed)
+	assert.Len(suite.T(), report.Machines, 2)
+	assert.Equal(suite.T(), &models.MachineOverlap{Machine: TestMachine1, Active: 120 * time.Second, Counted: 110 * time.Second}, report.Machines[0])
+	assert.Equal(suite.T(), &models.MachineOverlap{Machine: TestMachine2, Active: 60 * time.Second, Counted: 50 * time.Second}, report.Machines[1])
+}
+
+func TestCollapseOverlaps(t *testing.T) {
+	start := time.Unix(0, MinUnixTime1)
+	newDuration := func(machine string, fromSec, toSec int) *models.Duration {
+		return &models.Duration{Machine: machine, Time: models.CustomTime(start.Add(time.Duration(fromSec) * time.Second)), Duration: time.Duration(toSec-fromSec) * time.Second, NumHeartbeats: 2}
+	}
+
+	result := collapseOverlaps(models.Durations{
+		newDuration(TestMachine1, 0, 60),
+		newDuration(TestMachine1, 120, 180),
+		newDuration(TestMachine2, 30, 150), // longest one
+		newDuration(TestMachine2, 200, 210),
+		newDuration(TestMachine1, 10, 300), // spans all others
+	}).Sorted()
+
+	assert.Len(t, result, 2)
+	assert.Equal(t, 10*time.Second, result[0].Duration) // 0:00 - 0:10
+	assert.Equal(t, 290*time.Second, result[1].Duration)
+	assert.Equal(t, 4, result.TotalNumHeartbeats())
+
+	result = collapseOverlaps(models.Durations{
+		newDuration(TestMachine1, 0, 60),
+		newDuration(TestMachine1, 120, 180),
+		newDuration(TestMachine2, 30, 150), // longest one
+		newDuration(TestMachine2, 200, 210),
+	}).Sorted()
+
+	assert.Len(t, result, 4)
+	assert.Equal(t, 30*time.Second, result[0].Duration)  // 0:00 - 0:30
+	assert.Equal(t, 120*time.Second, result[1].Duration) // 0:30 - 2:30
+	assert.Equal(t, 30*time.Second, result[2].Duration)  // 2:30 - 3:00
+	assert.Equal(t, 10*time.Second, result[3].Duration)  // 3:20 - 3:30
+	assert.Equal(t, start.Add(150*time.Second), result[2].Time.T())
+}
+
 func filterHeartbeats(from, to time.Time, heartbeats []*models.Heartbeat) []*models.Heartbeat {
 	filtered := make([]*models.Heartbeat, 0, len(heartbeats))
 	for _, h := range heartbeats {
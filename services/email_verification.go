@@ -0,0 +1,137 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+)
+
+var ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+
+// EmailVerificationService issues signed links, which users receive via mail to confirm they own their e-mail address.
+// Tokens are bound to the address they were issued for and thus become invalid once it is changed.
+type EmailVerificationService struct {
+	config          *config.Config
+	userService     IUserService
+	mailService     IMailService
+	keyValueService IKeyValueService
+	secret          []byte
+	secretLock      sync.Mutex
+}
+
+func NewEmailVerificationService(userService IUserService, mailService IMailService, keyValueService IKeyValueService) *EmailVerificationService {
+	return &EmailVerificationService{
+		config:          config.Get(),
+		userService:     userService,
+		mailService:     mailService,
+		keyValueService: keyValueService,
+	}
+}
+
+func (srv *EmailVerificationService) SendVerification(user *models.User) error {
+	if user.Email == "" {
+		return errors.New("user has no e-mail address")
+	}
+
+	token, err := srv.GenerateToken(user, time.Now().Add(config.EmailVerificationTokenTtl))
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/verify-email?token=%s", srv.config.Server.GetPublicUrl(), token)
+	return srv.mailService.SendEmailVerification(user, link)
+}
+
+// GenerateToken creates a token of the form <base64 user id>.<expiry unix timestamp>.<signature>
+func (srv *EmailVerificationService) GenerateToken(user *models.User, expires time.Time) (string, error) {
+	signature, err := srv.sign(user.ID, user.Email, expires.Unix())
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s.%d.%s", base64.RawURLEncoding.EncodeToString([]byte(user.ID)), expires.Unix(), signature), nil
+}
+
+// Verify checks the given token and, if valid, marks the user's current e-mail address as verified
+func (srv *EmailVerificationService) Verify(token string) (*models.User, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	userId, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	user, err := srv.userService.GetUserById(string(userId))
+	if err != nil || user.Email == "" {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	signature, err := srv.sign(user.ID, user.Email, expires)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal([]byte(signature), []byte(parts[2])) {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	if user.EmailVerified {
+		return user, nil
+	}
+	user.EmailVerified = true
+	return srv.userService.Update(user)
+}
+
+func (srv *EmailVerificationService) sign(userId, email string, expires int64) (string, error) {
+	secret, err := srv.getSecret()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(fmt.Sprintf("%s|%s|%d", userId, strings.ToLower(email), expires)))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// getSecret returns the instance-wide signing key, which is generated once and persisted, so links keep working across restarts
+func (srv *EmailVerificationService) getSecret() ([]byte, error) {
+	srv.secretLock.Lock()
+	defer srv.secretLock.Unlock()
+
+	if srv.secret != nil {
+		return srv.secret, nil
+	}
+
+	if kv, err := srv.keyValueService.GetString(config.KeyEmailVerificationSecret); err == nil && kv.Value != "" {
+		if secret, err := hex.DecodeString(kv.Value); err == nil {
+			srv.secret = secret
+			return srv.secret, nil
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	if err := srv.keyValueService.PutString(&models.KeyStringValue{Key: config.KeyEmailVerificationSecret, Value: hex.EncodeToString(secret)}); err != nil {
+		return nil, err
+	}
+	srv.secret = secret
+	return srv.secret, nil
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestEmailVerificationService_Verify(t *testing.T) {
+	config.Set(config.Empty())
+
+	user := &models.User{ID: "user1", Email: "user1@example.org"}
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserById", user.ID).Return(user, nil)
+	userServiceMock.On("Update", user).Return(user, nil)
+
+	keyValueServiceMock := new(mocks.KeyValueServiceMock)
+	keyValueServiceMock.On("GetString", config.KeyEmailVerificationSecret).Return(&models.KeyStringValue{}, nil)
+	keyValueServiceMock.On("PutString", mock.Anything).Return(nil)
+
+	sut := NewEmailVerificationService(userServiceMock, nil, keyValueServiceMock)
+
+	token, err := sut.GenerateToken(user, time.Now().Add(time.Hour))
+	assert.Nil(t, err)
+
+	expiredToken, _ := sut.GenerateToken(user, time.Now().Add(-1*time.Minute))
+	_, err = sut.Verify(expiredToken)
+	assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+
+	_, err = sut.Verify(token + "0")
+	assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+
+	_, err = sut.Verify("garbage")
+	assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+
+	// tokens are bound to the address they were issued for
+	user.Email = "other@example.org"
+	_, err = sut.Verify(token)
+	assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+	assert.False(t, user.EmailVerified)
+
+	user.Email = "user1@example.org"
+	result, err := sut.Verify(token)
+	assert.Nil(t, err)
+	assert.True(t, result.EmailVerified)
+
+	// secret is generated once and kept
+	keyValueServiceMock.AssertNumberOfCalls(t, "PutString", 1)
+}
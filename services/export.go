@@ -0,0 +1,155 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services/exports"
+	"github.com/hackclub/hackatime/utils"
+	"github.com/muety/artifex/v2"
+)
+
+// past time range to cover in the export
+const exportRange = 7 * 24 * time.Hour
+
+var exportSummaryTypes = []uint8{models.SummaryProject, models.SummaryLanguage, models.SummaryEditor, models.SummaryOS, models.SummaryMachine, models.SummaryCategory}
+
+type ExportService struct {
+	config           *config.Config
+	summaryService   ISummaryService
+	heartbeatService IHeartbeatService
+	userService      IUserService
+	objectSrvc       IObjectStorageService
+	targets          []exports.ExportTarget
+	queueDefault     *artifex.Dispatcher
+	queueWorkers     *artifex.Dispatcher
+}
+
+func NewExportService(summaryService ISummaryService, heartbeatService IHeartbeatService, userService IUserService, objectStorageService IObjectStorageService) *ExportService {
+	conf := config.Get()
+	return &ExportService{
+		config:           conf,
+		summaryService:   summaryService,
+		heartbeatService: heartbeatService,
+		userService:      userService,
+		objectSrvc:       objectStorageService,
+		targets:          exports.GetTargets(conf),
+		queueDefault:     config.GetDefaultQueue(),
+		queueWorkers:     config.GetQueue(config.QueueReports),
+	}
+}
+
+func (srv *ExportService) Schedule() {
+	if !srv.config.Exports.Enabled {
+		return
+	}
+	if len(srv.targets) == 0 {
+		slog.Info("exports are enabled, but no export target is configured, only keeping archives in object storage")
+	}
+
+	slog.Info("scheduling csv exports", "targets", len(srv.targets))
+
+	_, err := srv.queueDefault.DispatchCron(func() {
+		if err := srv.queueWorkers.Dispatch(func() {
+			if err := srv.RunExport(exportRange); err != nil {
+				config.Log().Error("failed to run csv export", "error", err)
+			}
+		}); err != nil {
+			config.Log().Error("failed to dispatch csv export job", "error", err)
+		}
+	}, srv.config.Exports.GetTimeCron())
+
+	if err != nil {
+		config.Log().Error("failed to schedule csv exports", "error", err)
+	}
+}
+
+// RunExport generates a csv export for all selected users, covering the given past time range, and pushes it to all configured targets
+func (srv *ExportService) RunExport(duration time.Duration) error {
+	users, err := srv.getUsers()
+	if err != nil {
+		return err
+	}
+
+	to := datetime.BeginOfDay(time.Now())
+	from := to.Add(-1 * duration)
+
+	slog.Info("generating csv export", "userCount", len(users), "from", from, "to", to)
+
+	data, err := srv.GenerateCsv(users, from, to)
+	if err != nil {
+		return err
+	}
+
+	filename := fmt.Sprintf("hackatime_%s_%s.csv", from.Format(config.SimpleDateFormat), to.Format(config.SimpleDateFormat))
+
+	var lastErr error
+	if srv.objectSrvc != nil {
+		if link, err := srv.objectSrvc.Store("exports/"+filename, data, "text/csv"); err != nil {
+			config.Log().Error("failed to store csv export", "error", err)
+			lastErr = err
+		} else {
+			slog.Info("stored csv export", "filename", filename, "link", link)
+		}
+	}
+
+	for _, t := range srv.targets {
+		if err := t.Upload(filename, data); err != nil {
+			config.Log().Error("failed to upload csv export", "target", t.Name(), "error", err)
+			lastErr = err
+			continue
+		}
+		slog.Info("uploaded csv export", "target", t.Name(), "filename", filename)
+	}
+	return lastErr
+}
+
+// GenerateCsv produces one row per user, day and summary item within the given range
+func (srv *ExportService) GenerateCsv(users []*models.User, from, to time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+
+	if err := w.Write([]string{"user_id", "date", "type", "key", "total_seconds"}); err != nil {
+		return nil, err
+	}
+
+	for _, u := range users {
+		// align day boundaries with the user's time zone
+		userFrom := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, u.TZ())
+		userTo := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, u.TZ())
+
+		for _, interval := range utils.SplitRangeByDays(userFrom, userTo) {
+			summary, err := srv.summaryService.Aliased(interval[0], interval[1], u, srv.summaryService.Retrieve, nil, false)
+			if err != nil {
+				config.Log().Error("failed to retrieve summary for csv export", "userID", u.ID, "from", interval[0], "error", err)
+				return nil, err
+			}
+
+			date := interval[0].Format(config.SimpleDateFormat)
+			for _, t := range exportSummaryTypes {
+				for _, item := range *summary.GetByType(t) {
+					if err := w.Write([]string{u.ID, date, models.GetEntityColumn(t), item.Key, strconv.FormatInt(int64(item.TotalFixed().Seconds()), 10)}); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+	}
+
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+func (srv *ExportService) getUsers() ([]*models.User, error) {
+	if userIds := srv.config.Exports.GetUserIds(); len(userIds) > 0 {
+		return srv.userService.GetMany(userIds)
+	}
+	return srv.userService.GetAll()
+}
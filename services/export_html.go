@@ -0,0 +1,220 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	svg "github.com/ajstarks/svgo/float"
+	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/models"
+	exportviews "github.com/hackclub/hackatime/views/export"
+)
+
+const (
+	htmlExportTemplate    = "dashboard.tpl.html"
+	htmlExportTopN        = 10
+	htmlExportMaxDays     = 366
+	htmlExportBarColor    = "#48bb78"
+	htmlExportTextColor   = "#e2e8f0"
+	htmlExportRowHeight   = 24
+	htmlExportLabelWidth  = 140
+	htmlExportBarWidth    = 220
+	htmlExportDayWidth    = 14
+	htmlExportDailyHeight = 120
+)
+
+var ErrHtmlExportRangeInvalid = fmt.Errorf("export range must span between one and %d days", htmlExportMaxDays)
+
+var htmlExportCharts = []struct {
+	summaryType uint8
+	title       string
+}{
+	{models.SummaryProject, "Projects"},
+	{models.SummaryLanguage, "Languages"},
+	{models.SummaryEditor, "Editors"},
+	{models.SummaryOS, "Operating Systems"},
+	{models.SummaryMachine, "Machines"},
+	{models.SummaryCategory, "Categories"},
+}
+
+type htmlExportChart struct {
+	Title string
+	Svg   template.HTML
+}
+
+type htmlExportViewModel struct {
+	Username    string
+	PublicUrl   string
+	From        time.Time
+	To          time.Time
+	GeneratedAt time.Time
+	Total       time.Duration
+	Daily       template.HTML
+	Charts      []*htmlExportChart
+}
+
+// RunHtmlExport renders the user's dashboard for the given range into a static, self-contained html bundle and returns a download link for it
+func (srv *ExportService) RunHtmlExport(user *models.User, from, to time.Time) (string, error) {
+	if srv.objectSrvc == nil {
+		return "", errors.New("object storage is not available")
+	}
+
+	slog.Info("generating html export", "userID", user.ID, "from", from, "to", to)
+
+	data, err := srv.GenerateHtmlArchive(user, from, to)
+	if err != nil {
+		return "", err
+	}
+
+	filename := fmt.Sprintf("exports/%s/dashboard_%s_%s.zip", user.ID, from.Format(config.SimpleDateFormat), to.Format(config.SimpleDateFormat))
+	return srv.objectSrvc.Store(filename, data, "application/zip")
+}
+
+// GenerateHtmlArchive produces a zip archive with an index.html, which neither loads scripts, styles nor images from anywhere,
+// so that it can be archived or shared without the instance being reachable. Charts are inlined as svg.
+func (srv *ExportService) GenerateHtmlArchive(user *models.User, from, to time.Time) ([]byte, error) {
+	from = datetime.BeginOfDay(from.In(user.TZ()))
+	to = datetime.BeginOfDay(to.In(user.TZ()))
+	if !to.After(from) || to.Sub(from) > htmlExportMaxDays*24*time.Hour {
+		return nil, ErrHtmlExportRangeInvalid
+	}
+
+	summary, err := srv.summaryService.Aliased(from, to, user, srv.summaryService.Retrieve, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	dailySummaries, err := retrieveDailySummaries(srv.summaryService, user, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	vm := &htmlExportViewModel{
+		Username:    user.ID,
+		PublicUrl:   srv.config.Server.GetPublicUrl(),
+		From:        from,
+		To:          to.AddDate(0, 0, -1), // last day, inclusive
+		GeneratedAt: time.Now().In(user.TZ()),
+		Total:       summary.TotalTime(),
+		Daily:       renderHtmlExportDaily(dailySummaries),
+	}
+	for _, c := range htmlExportCharts {
+		if items := *summary.GetByType(c.summaryType); len(items) > 0 {
+			vm.Charts = append(vm.Charts, &htmlExportChart{Title: c.title, Svg: renderHtmlExportBars(items)})
+		}
+	}
+
+	tpl, err := template.New(htmlExportTemplate).Funcs(template.FuncMap{
+		"date":     helpers.FormatDateHuman,
+		"datetime": helpers.FormatDateTimeHuman,
+		"duration": helpers.FmtWakatimeDuration,
+	}).ParseFS(exportviews.TemplateFiles, htmlExportTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	f, err := zw.Create("index.html")
+	if err != nil {
+		return nil, err
+	}
+	if err := tpl.Execute(f, vm); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderHtmlExportBars draws a horizontal bar chart of the top items
+func renderHtmlExportBars(items models.SummaryItems) template.HTML {
+	sorted := make(models.SummaryItems, len(items))
+	copy(sorted, items)
+	sort.Sort(sort.Reverse(sorted))
+	if len(sorted) > htmlExportTopN {
+		sorted = sorted[:htmlExportTopN]
+	}
+
+	var max time.Duration
+	for _, item := range sorted {
+		if item.TotalFixed() > max {
+			max = item.TotalFixed()
+		}
+	}
+
+	w, h := float64(htmlExportLabelWidth+htmlExportBarWidth+80), float64(len(sorted)*htmlExportRowHeight)
+	buf := new(bytes.Buffer)
+	canvas := svg.New(buf)
+	canvas.Start(w, h)
+	for i, item := range sorted {
+		y := float64(i * htmlExportRowHeight)
+		barWidth := 0.0
+		if max > 0 {
+			barWidth = float64(item.TotalFixed()) / float64(max) * htmlExportBarWidth
+		}
+		canvas.Text(0, y+16, htmlExportLabel(item.Key, 20), fmt.Sprintf("fill: %s; font-size: 12px; font-family: sans-serif", htmlExportTextColor))
+		canvas.Rect(htmlExportLabelWidth, y+4, barWidth, htmlExportRowHeight-8, fmt.Sprintf("fill: %s", htmlExportBarColor))
+		canvas.Text(htmlExportLabelWidth+barWidth+6, y+16, helpers.FmtWakatimeDuration(item.TotalFixed()), fmt.Sprintf("fill: %s; font-size: 12px; font-family: sans-serif", htmlExportTextColor))
+	}
+	canvas.End()
+	return inlineSvg(buf)
+}
+
+// renderHtmlExportDaily draws one vertical bar per day
+func renderHtmlExportDaily(summaries []*models.Summary) template.HTML {
+	if len(summaries) == 0 {
+		return ""
+	}
+
+	var max time.Duration
+	for _, s := range summaries {
+		if s != nil && s.TotalTime() > max {
+			max = s.TotalTime()
+		}
+	}
+
+	w, h := float64(len(summaries)*htmlExportDayWidth), float64(htmlExportDailyHeight)
+	buf := new(bytes.Buffer)
+	canvas := svg.New(buf)
+	canvas.Start(w, h)
+	for i, s := range summaries {
+		if s == nil || max == 0 {
+			continue
+		}
+		total := s.TotalTime()
+		barHeight := float64(total) / float64(max) * h
+		canvas.Group()
+		canvas.Title(fmt.Sprintf("%s on %s", helpers.FmtWakatimeDuration(total), helpers.FormatDateHuman(s.FromTime.T())))
+		canvas.Rect(float64(i*htmlExportDayWidth)+1, h-barHeight, htmlExportDayWidth-2, barHeight, fmt.Sprintf("fill: %s", htmlExportBarColor))
+		canvas.Gend()
+	}
+	canvas.End()
+	return inlineSvg(buf)
+}
+
+// inlineSvg strips the xml declaration, which is not allowed within html documents
+func inlineSvg(buf *bytes.Buffer) template.HTML {
+	data := buf.String()
+	if i := strings.Index(data, "<svg"); i > 0 {
+		data = data[i:]
+	}
+	return template.HTML(data)
+}
+
+func htmlExportLabel(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
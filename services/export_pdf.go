@@ -0,0 +1,228 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+
+	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/go-pdf/fpdf"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/models"
+)
+
+const (
+	pdfExportMaxDays       = 366
+	pdfExportTopN          = 10
+	pdfExportMargin        = 15.0 // mm
+	pdfExportRowHeight     = 6.5
+	pdfExportDailyHeight   = 40.0
+	pdfExportLabelWidth    = 60.0
+	pdfExportDurationWidth = 28.0
+	pdfExportPercentWidth  = 16.0
+)
+
+var ErrPdfExportRangeInvalid = fmt.Errorf("export range must span between one and %d days", pdfExportMaxDays)
+
+var (
+	pdfExportBarColor   = [3]int{72, 187, 120} // same green as the html export
+	pdfExportTextColor  = [3]int{26, 32, 44}
+	pdfExportMutedColor = [3]int{113, 128, 150}
+	pdfExportGridColor  = [3]int{226, 232, 240}
+)
+
+// GeneratePdf renders a printable report of the user's summary for the given range, including a daily chart and the top items per type,
+// e.g. to be attached to an invoice
+func (srv *ExportService) GeneratePdf(user *models.User, from, to time.Time) ([]byte, error) {
+	from = datetime.BeginOfDay(from.In(user.TZ()))
+	to = to.In(user.TZ())
+	if !to.After(from) || to.Sub(from) > pdfExportMaxDays*24*time.Hour {
+		return nil, ErrPdfExportRangeInvalid
+	}
+
+	slog.Info("generating pdf export", "userID", user.ID, "from", from, "to", to)
+
+	summary, err := srv.summaryService.Aliased(from, to, user, srv.summaryService.Retrieve, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	dailySummaries, err := retrieveDailySummaries(srv.summaryService, user, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("") // core fonts only support cp1252
+	generatedAt := time.Now().In(user.TZ())
+
+	pdf.SetTitle(fmt.Sprintf("Coding activity of %s", user.ID), true)
+	pdf.SetAuthor(srv.config.Server.GetPublicUrl(), true)
+	pdf.SetCreator("Hackatime", true)
+	pdf.SetCreationDate(generatedAt)
+	pdf.SetMargins(pdfExportMargin, pdfExportMargin, pdfExportMargin)
+	pdf.SetAutoPageBreak(true, pdfExportMargin)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-pdfExportMargin + 5)
+		pdf.SetFont("Helvetica", "", 8)
+		setPdfTextColor(pdf, pdfExportMutedColor)
+		pdf.CellFormat(0, 4, tr(fmt.Sprintf("Generated by %s on %s", srv.config.Server.GetPublicUrl(), helpers.FormatDateTimeHuman(generatedAt))), "", 0, "L", false, 0, "")
+		pdf.SetX(pdfExportMargin)
+		pdf.CellFormat(0, 4, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AddPage()
+
+	// header
+	lastDay := to.Add(-time.Nanosecond) // inclusive
+	pdf.SetFont("Helvetica", "B", 20)
+	setPdfTextColor(pdf, pdfExportTextColor)
+	pdf.CellFormat(0, 10, "Coding Activity Report", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 11)
+	setPdfTextColor(pdf, pdfExportMutedColor)
+	pdf.CellFormat(0, 6, tr(fmt.Sprintf("%s  |  %s - %s", user.ID, helpers.FormatDateHuman(from), helpers.FormatDateHuman(lastDay))), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	// key figures
+	var activeDays int
+	for _, s := range dailySummaries {
+		if s != nil && s.TotalTime() > 0 {
+			activeDays++
+		}
+	}
+	total := summary.TotalTime()
+	figures := [][2]string{
+		{"Total", helpers.FmtUserDuration(total, user)},
+		{"Daily Average", helpers.FmtUserDuration(total/time.Duration(max(len(dailySummaries), 1)), user)},
+		{"Active Days", fmt.Sprintf("%d of %d", activeDays, len(dailySummaries))},
+	}
+	pageWidth, _ := pdf.GetPageSize()
+	contentWidth := pageWidth - 2*pdfExportMargin
+	figureWidth := contentWidth / float64(len(figures))
+	pdf.SetFont("Helvetica", "", 9)
+	for _, f := range figures {
+		pdf.CellFormat(figureWidth, 5, f[0], "", 0, "L", false, 0, "")
+	}
+	pdf.Ln(5)
+	pdf.SetFont("Helvetica", "B", 14)
+	setPdfTextColor(pdf, pdfExportTextColor)
+	for _, f := range figures {
+		pdf.CellFormat(figureWidth, 8, f[1], "", 0, "L", false, 0, "")
+	}
+	pdf.Ln(14)
+
+	// daily chart
+	if len(dailySummaries) > 1 {
+		renderPdfSectionTitle(pdf, "Daily Activity")
+		renderPdfDaily(pdf, dailySummaries, contentWidth)
+		pdf.Ln(8)
+	}
+
+	// top items per type
+	for _, c := range htmlExportCharts {
+		items := *summary.GetByType(c.summaryType)
+		if len(items) == 0 {
+			continue
+		}
+		sorted := make(models.SummaryItems, len(items))
+		copy(sorted, items)
+		sort.Sort(sort.Reverse(sorted))
+		if len(sorted) > pdfExportTopN {
+			sorted = sorted[:pdfExportTopN]
+		}
+
+		_, pageHeight := pdf.GetPageSize()
+		if pdf.GetY()+float64(len(sorted)+2)*pdfExportRowHeight > pageHeight-pdfExportMargin {
+			pdf.AddPage()
+		}
+		renderPdfSectionTitle(pdf, c.title)
+		renderPdfTable(pdf, sorted, total, contentWidth, user, tr)
+		pdf.Ln(6)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func renderPdfSectionTitle(pdf *fpdf.Fpdf, title string) {
+	pdf.SetFont("Helvetica", "B", 12)
+	setPdfTextColor(pdf, pdfExportTextColor)
+	pdf.CellFormat(0, 8, title, "", 1, "L", false, 0, "")
+}
+
+// renderPdfDaily draws one vertical bar per day
+func renderPdfDaily(pdf *fpdf.Fpdf, summaries []*models.Summary, width float64) {
+	var max time.Duration
+	for _, s := range summaries {
+		if s != nil && s.TotalTime() > max {
+			max = s.TotalTime()
+		}
+	}
+
+	x0, y0 := pdf.GetX(), pdf.GetY()
+	dayWidth := width / float64(len(summaries))
+
+	pdf.SetDrawColor(pdfExportGridColor[0], pdfExportGridColor[1], pdfExportGridColor[2])
+	pdf.SetLineWidth(0.2)
+	pdf.Line(x0, y0+pdfExportDailyHeight, x0+width, y0+pdfExportDailyHeight)
+
+	pdf.SetFillColor(pdfExportBarColor[0], pdfExportBarColor[1], pdfExportBarColor[2])
+	for i, s := range summaries {
+		if s == nil || max == 0 {
+			continue
+		}
+		barHeight := float64(s.TotalTime()) / float64(max) * pdfExportDailyHeight
+		pdf.Rect(x0+float64(i)*dayWidth+dayWidth*0.15, y0+pdfExportDailyHeight-barHeight, dayWidth*0.7, barHeight, "F")
+	}
+
+	// label first and last day
+	pdf.SetXY(x0, y0+pdfExportDailyHeight+1)
+	pdf.SetFont("Helvetica", "", 8)
+	setPdfTextColor(pdf, pdfExportMutedColor)
+	if first, last := summaries[0], summaries[len(summaries)-1]; first != nil && last != nil {
+		pdf.CellFormat(width/2, 4, helpers.FormatDateHuman(first.FromTime.T()), "", 0, "L", false, 0, "")
+		pdf.CellFormat(width/2, 4, helpers.FormatDateHuman(last.FromTime.T()), "", 1, "R", false, 0, "")
+	} else {
+		pdf.Ln(4)
+	}
+}
+
+// renderPdfTable lists the given items along with their share of the total time and a horizontal bar
+func renderPdfTable(pdf *fpdf.Fpdf, items models.SummaryItems, total time.Duration, width float64, user *models.User, tr func(string) string) {
+	var max time.Duration
+	for _, item := range items {
+		if item.TotalFixed() > max {
+			max = item.TotalFixed()
+		}
+	}
+	barWidth := width - pdfExportLabelWidth - pdfExportDurationWidth - pdfExportPercentWidth
+
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.SetFillColor(pdfExportBarColor[0], pdfExportBarColor[1], pdfExportBarColor[2])
+	pdf.SetDrawColor(pdfExportGridColor[0], pdfExportGridColor[1], pdfExportGridColor[2])
+	for _, item := range items {
+		x, y := pdf.GetX(), pdf.GetY()
+		setPdfTextColor(pdf, pdfExportTextColor)
+		pdf.CellFormat(pdfExportLabelWidth, pdfExportRowHeight, tr(htmlExportLabel(item.Key, 36)), "B", 0, "L", false, 0, "")
+		pdf.CellFormat(barWidth, pdfExportRowHeight, "", "B", 0, "L", false, 0, "")
+		pdf.CellFormat(pdfExportDurationWidth, pdfExportRowHeight, helpers.FmtUserDuration(item.TotalFixed(), user), "B", 0, "R", false, 0, "")
+		setPdfTextColor(pdf, pdfExportMutedColor)
+		var percent float64
+		if total > 0 {
+			percent = float64(item.TotalFixed()) / float64(total) * 100
+		}
+		pdf.CellFormat(pdfExportPercentWidth, pdfExportRowHeight, fmt.Sprintf("%.1f %%", percent), "B", 1, "R", false, 0, "")
+
+		if max > 0 {
+			pdf.Rect(x+pdfExportLabelWidth, y+1.75, float64(item.TotalFixed())/float64(max)*(barWidth-4), pdfExportRowHeight-3.5, "F")
+		}
+	}
+}
+
+func setPdfTextColor(pdf *fpdf.Fpdf, c [3]int) {
+	pdf.SetTextColor(c[0], c[1], c[2])
+}
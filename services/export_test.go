@@ -0,0 +1,158 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	wakatime "github.com/hackclub/hackatime/models/compat/wakatime/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type ExportServiceTestSuite struct {
+	suite.Suite
+	TestUser         *models.User
+	UserService      *mocks.UserServiceMock
+	SummaryService   *mocks.SummaryServiceMock
+	HeartbeatService *mocks.HeartbeatServiceMock
+}
+
+func (suite *ExportServiceTestSuite) SetupSuite() {
+	suite.TestUser = &models.User{ID: TestUserId, Location: "UTC"}
+	config.Set(config.Empty())
+}
+
+func (suite *ExportServiceTestSuite) BeforeTest(suiteName, testName string) {
+	suite.UserService = new(mocks.UserServiceMock)
+	suite.SummaryService = new(mocks.SummaryServiceMock)
+	suite.HeartbeatService = new(mocks.HeartbeatServiceMock)
+}
+
+func TestExportServiceTestSuite(t *testing.T) {
+	suite.Run(t, new(ExportServiceTestSuite))
+}
+
+func (suite *ExportServiceTestSuite) TestExportService_GenerateCsv() {
+	sut := NewExportService(suite.SummaryService, suite.HeartbeatService, suite.UserService, nil)
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 2)
+
+	summary := models.NewEmptySummary()
+	summary.Projects = models.SummaryItems{{Type: models.SummaryProject, Key: TestProject1, Total: 120}}
+	summary.Languages = models.SummaryItems{{Type: models.SummaryLanguage, Key: TestLanguageGo, Total: 120}}
+
+	suite.SummaryService.On("Aliased", mock.Anything, mock.Anything, suite.TestUser, mock.Anything, mock.Anything).Return(summary, nil)
+
+	result, err := sut.GenerateCsv([]*models.User{suite.TestUser}, from, to)
+	lines := strings.Split(strings.TrimSpace(string(result)), "\n")
+
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), lines, 5)
+	assert.Equal(suite.T(), "user_id,date,type,key,total_seconds", lines[0])
+	assert.Equal(suite.T(), TestUserId+",2024-01-01,project,"+TestProject1+",120", lines[1])
+	assert.Equal(suite.T(), TestUserId+",2024-01-02,language,"+TestLanguageGo+",120", lines[4])
+	suite.SummaryService.AssertNumberOfCalls(suite.T(), "Aliased", 2)
+}
+
+func (suite *ExportServiceTestSuite) TestExportService_GenerateWakatimeArchive() {
+	sut := NewExportService(suite.SummaryService, suite.HeartbeatService, suite.UserService, nil)
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 3)
+
+	heartbeats := []*models.Heartbeat{
+		{ID: 1, UserID: TestUserId, Project: TestProject1, Language: TestLanguageGo, Time: models.CustomTime(from.Add(1 * time.Hour))},
+		{ID: 2, UserID: TestUserId, Project: TestProject1, Language: TestLanguageGo, Time: models.CustomTime(from.Add(2 * time.Hour))},
+		{ID: 3, UserID: TestUserId, Project: TestProject1, Language: TestLanguageGo, Time: models.CustomTime(from.Add(50 * time.Hour))},
+	}
+	suite.HeartbeatService.On("GetAllWithin", mock.Anything, mock.Anything, suite.TestUser).Return(heartbeats, nil)
+
+	result, err := sut.GenerateWakatimeArchive(suite.TestUser, from, to)
+	assert.Nil(suite.T(), err)
+
+	zr, err := zip.NewReader(bytes.NewReader(result), int64(len(result)))
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), zr.File, 2) // no file for days without heartbeats
+	assert.Equal(suite.T(), "heartbeats_2024-01-01.json", zr.File[0].Name)
+	assert.Equal(suite.T(), "heartbeats_2024-01-03.json", zr.File[1].Name)
+
+	f, _ := zr.File[0].Open()
+	defer f.Close()
+	var day wakatime.JsonExportViewModel
+	assert.Nil(suite.T(), json.NewDecoder(f).Decode(&day))
+	assert.Equal(suite.T(), from.Unix(), day.Range.Start)
+	assert.Len(suite.T(), day.Days, 1)
+	assert.Equal(suite.T(), "2024-01-01", day.Days[0].Date)
+	assert.Len(suite.T(), day.Days[0].Heartbeats, 2)
+	assert.Equal(suite.T(), TestProject1, day.Days[0].Heartbeats[0].Project)
+}
+
+func (suite *ExportServiceTestSuite) TestExportService_GenerateHtmlArchive() {
+	sut := NewExportService(suite.SummaryService, suite.HeartbeatService, suite.UserService, nil)
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 2)
+
+	summary := models.NewEmptySummary()
+	summary.Projects = models.SummaryItems{{Type: models.SummaryProject, Key: "<script>", Total: 120}}
+	summary.Languages = models.SummaryItems{{Type: models.SummaryLanguage, Key: TestLanguageGo, Total: 120}}
+
+	suite.SummaryService.On("Aliased", from, to, suite.TestUser, mock.Anything, mock.Anything, mock.Anything).Return(summary, nil)
+	suite.SummaryService.On("Retrieve", mock.Anything, mock.Anything, suite.TestUser, mock.Anything).Return(summary, nil)
+
+	result, err := sut.GenerateHtmlArchive(suite.TestUser, from, to)
+	assert.Nil(suite.T(), err)
+
+	zr, err := zip.NewReader(bytes.NewReader(result), int64(len(result)))
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), zr.File, 1)
+	assert.Equal(suite.T(), "index.html", zr.File[0].Name)
+
+	f, _ := zr.File[0].Open()
+	defer f.Close()
+	var html strings.Builder
+	io.Copy(&html, f)
+	assert.Contains(suite.T(), html.String(), "<svg")
+	assert.Contains(suite.T(), html.String(), TestLanguageGo)
+	assert.Contains(suite.T(), html.String(), "&lt;script&gt;")
+	assert.NotContains(suite.T(), html.String(), "<?xml")
+	assert.NotContains(suite.T(), html.String(), "<script")
+	suite.SummaryService.AssertNumberOfCalls(suite.T(), "Retrieve", 2)
+
+	_, err = sut.GenerateHtmlArchive(suite.TestUser, to, from)
+	assert.ErrorIs(suite.T(), err, ErrHtmlExportRangeInvalid)
+}
+
+func (suite *ExportServiceTestSuite) TestExportService_GeneratePdf() {
+	sut := NewExportService(suite.SummaryService, suite.HeartbeatService, suite.UserService, nil)
+
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 0, 2)
+
+	summary := models.NewEmptySummary()
+	summary.Projects = models.SummaryItems{{Type: models.SummaryProject, Key: "wakapi – ünicode", Total: 120}}
+	summary.Languages = models.SummaryItems{{Type: models.SummaryLanguage, Key: TestLanguageGo, Total: 120}}
+
+	suite.SummaryService.On("Aliased", from, to, suite.TestUser, mock.Anything, mock.Anything, mock.Anything).Return(summary, nil)
+	suite.SummaryService.On("Retrieve", mock.Anything, mock.Anything, suite.TestUser, mock.Anything).Return(summary, nil)
+
+	result, err := sut.GeneratePdf(suite.TestUser, from, to)
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), bytes.HasPrefix(result, []byte("%PDF-")))
+	assert.Contains(suite.T(), string(result), "%%EOF")
+
+	_, err = sut.GeneratePdf(suite.TestUser, to, from)
+	assert.ErrorIs(suite.T(), err, ErrPdfExportRangeInvalid)
+	_, err = sut.GeneratePdf(suite.TestUser, from, from.AddDate(2, 0, 0))
+	assert.ErrorIs(suite.T(), err, ErrPdfExportRangeInvalid)
+}
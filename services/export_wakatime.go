@@ -0,0 +1,109 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	wakatime "github.com/hackclub/hackatime/models/compat/wakatime/v1"
+	"github.com/hackclub/hackatime/utils"
+)
+
+const wakatimeExportChunkDays = 30 // number of days of heartbeats to load at once
+
+// RunWakatimeExport generates an archive of all the user's heartbeats in the format of wakatime's data export and returns a download link for it
+func (srv *ExportService) RunWakatimeExport(user *models.User) (string, error) {
+	if srv.objectSrvc == nil {
+		return "", errors.New("object storage is not available")
+	}
+
+	from, err := srv.getFirstHeartbeatTime(user)
+	if err != nil {
+		return "", err
+	}
+	to := time.Now()
+
+	slog.Info("generating wakatime export", "userID", user.ID, "from", from)
+
+	data, err := srv.GenerateWakatimeArchive(user, from, to)
+	if err != nil {
+		return "", err
+	}
+
+	filename := fmt.Sprintf("exports/%s/wakatime_%s.zip", user.ID, to.Format(config.SimpleDateFormat))
+	return srv.objectSrvc.Store(filename, data, "application/zip")
+}
+
+// GenerateWakatimeArchive produces a zip archive with one json file per day, each of which is structured like wakatime's data export
+// and can thus be imported on its own
+func (srv *ExportService) GenerateWakatimeArchive(user *models.User, from, to time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	from = datetime.BeginOfDay(from.In(user.TZ()))
+	to = to.In(user.TZ())
+
+	for chunkFrom := from; chunkFrom.Before(to); chunkFrom = chunkFrom.AddDate(0, 0, wakatimeExportChunkDays) {
+		chunkTo := chunkFrom.AddDate(0, 0, wakatimeExportChunkDays)
+		if chunkTo.After(to) {
+			chunkTo = to
+		}
+
+		heartbeats, err := srv.heartbeatService.GetAllWithin(chunkFrom, chunkTo, user)
+		if err != nil {
+			return nil, err
+		}
+
+		byDay := make(map[string][]*models.Heartbeat)
+		for _, h := range heartbeats {
+			date := h.Time.T().In(user.TZ()).Format(config.SimpleDateFormat)
+			byDay[date] = append(byDay[date], h)
+		}
+
+		for _, interval := range utils.SplitRangeByDays(chunkFrom, chunkTo) {
+			date := interval[0].Format(config.SimpleDateFormat)
+			dayHeartbeats, ok := byDay[date]
+			if !ok {
+				continue
+			}
+
+			day := &wakatime.JsonExportViewModel{
+				Range: &wakatime.JsonExportRange{Start: interval[0].Unix(), End: interval[1].Unix()},
+				Days:  []*wakatime.JsonExportDay{{Date: date, Heartbeats: wakatime.HeartbeatsToCompat(dayHeartbeats, nil)}}, // the user's own archive, so project names are never hidden,
+			}
+
+			f, err := zw.Create(fmt.Sprintf("heartbeats_%s.json", date))
+			if err != nil {
+				return nil, err
+			}
+			if err := json.NewEncoder(f).Encode(day); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (srv *ExportService) getFirstHeartbeatTime(user *models.User) (time.Time, error) {
+	firstHeartbeats, err := srv.heartbeatService.GetFirstByUsers()
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, entry := range firstHeartbeats {
+		if entry.User == user.ID && !entry.Time.T().IsZero() {
+			return entry.Time.T(), nil
+		}
+	}
+	return time.Now(), nil
+}
@@ -0,0 +1,25 @@
+package exports
+
+import (
+	"github.com/hackclub/hackatime/config"
+)
+
+type ExportTarget interface {
+	Name() string
+	Upload(string, []byte) error
+}
+
+// GetTargets returns all export targets, which are configured
+func GetTargets(c *config.Config) []ExportTarget {
+	targets := make([]ExportTarget, 0)
+	if c.Exports.Webhook.Url != "" {
+		targets = append(targets, NewWebhookTarget(c.Exports.Webhook))
+	}
+	if c.Exports.S3.Bucket != "" {
+		targets = append(targets, NewS3Target(c.Exports.S3))
+	}
+	if c.Exports.Sftp.Host != "" {
+		targets = append(targets, NewSftpTarget(c.Exports.Sftp))
+	}
+	return targets
+}
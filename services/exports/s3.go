@@ -0,0 +1,61 @@
+package exports
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/utils"
+)
+
+// S3Target uploads files to an s3-compatible object storage using path-style urls and aws signature v4
+// see https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+type S3Target struct {
+	config     config.ExportS3Config
+	httpClient *http.Client
+}
+
+func NewS3Target(config config.ExportS3Config) *S3Target {
+	if config.Endpoint == "" {
+		config.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", config.Region)
+	}
+	return &S3Target{
+		config:     config,
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (t *S3Target) Name() string {
+	return "s3"
+}
+
+func (t *S3Target) Upload(name string, data []byte) error {
+	objectUrl, err := url.Parse(fmt.Sprintf("%s/%s/%s%s", strings.TrimSuffix(t.config.Endpoint, "/"), t.config.Bucket, t.config.Prefix, name))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, objectUrl.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/csv")
+	utils.SignAwsV4(req, data, utils.AwsCredentials{Region: t.config.Region, AccessKey: t.config.AccessKey, SecretKey: t.config.SecretKey}, time.Now().UTC())
+
+	res, err := t.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		body, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("s3 responded with status %d: %s", res.StatusCode, string(body))
+	}
+	return nil
+}
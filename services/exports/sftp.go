@@ -0,0 +1,233 @@
+package exports
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// minimal subset of the sftp protocol (version 3), just enough to write a single file
+// see https://datatracker.ietf.org/doc/html/draft-ietf-secsh-filexfer-02
+const (
+	sftpFxpInit    = 1
+	sftpFxpVersion = 2
+	sftpFxpOpen    = 3
+	sftpFxpClose   = 4
+	sftpFxpWrite   = 6
+	sftpFxpStatus  = 101
+	sftpFxpHandle  = 102
+
+	sftpFxfWrite = 0x00000002
+	sftpFxfCreat = 0x00000008
+	sftpFxfTrunc = 0x00000010
+
+	sftpFxOk = 0
+
+	sftpMaxChunkSize  = 32 * 1024
+	sftpMaxPacketSize = 256 * 1024 // responses to the requests sent here are tiny, anything larger is considered malformed
+)
+
+var (
+	errSftpHostKeyMissing   = errors.New("sftp host key required, unless verification is skipped explicitly")
+	errSftpMalformedPacket  = errors.New("malformed sftp packet")
+	errSftpUnexpectedPacket = errors.New("unexpected sftp response")
+)
+
+type SftpTarget struct {
+	config config.ExportSftpConfig
+}
+
+func NewSftpTarget(config config.ExportSftpConfig) *SftpTarget {
+	return &SftpTarget{config: config}
+}
+
+func (t *SftpTarget) Name() string {
+	return "sftp"
+}
+
+func (t *SftpTarget) Upload(name string, data []byte) error {
+	hostKeyCallback, err := t.hostKeyCallback()
+	if err != nil {
+		return err
+	}
+
+	client, err := ssh.Dial("tcp", fmt.Sprintf("%s:%d", t.config.Host, t.config.Port), &ssh.ClientConfig{
+		User:            t.config.Username,
+		Auth:            []ssh.AuthMethod{ssh.Password(t.config.Password)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         30 * time.Second,
+	})
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	w, err := session.StdinPipe()
+	if err != nil {
+		return err
+	}
+	r, err := session.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := session.RequestSubsystem("sftp"); err != nil {
+		return err
+	}
+
+	conn := &sftpConn{r: r, w: w}
+	return conn.writeFile(path.Join(t.config.Path, name), data)
+}
+
+func (t *SftpTarget) hostKeyCallback() (ssh.HostKeyCallback, error) {
+	if t.config.HostKey == "" {
+		if !t.config.InsecureSkipHostKey {
+			return nil, errSftpHostKeyMissing
+		}
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	hostKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(t.config.HostKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse sftp host key: %v", err)
+	}
+	return ssh.FixedHostKey(hostKey), nil
+}
+
+type sftpConn struct {
+	r      io.Reader
+	w      io.Writer
+	nextId uint32
+}
+
+func (c *sftpConn) writeFile(filePath string, data []byte) error {
+	if err := c.send(sftpFxpInit, binary.BigEndian.AppendUint32(nil, 3)); err != nil {
+		return err
+	}
+	if t, _, err := c.recv(); err != nil {
+		return err
+	} else if t != sftpFxpVersion {
+		return errSftpUnexpectedPacket
+	}
+
+	// open
+	payload := c.appendId(nil)
+	payload = appendString(payload, []byte(filePath))
+	payload = binary.BigEndian.AppendUint32(payload, sftpFxfWrite|sftpFxfCreat|sftpFxfTrunc)
+	payload = binary.BigEndian.AppendUint32(payload, 0) // no attributes
+	if err := c.send(sftpFxpOpen, payload); err != nil {
+		return err
+	}
+	t, res, err := c.recv()
+	if err != nil {
+		return err
+	}
+	if t != sftpFxpHandle {
+		return parseStatus(res)
+	}
+	if len(res) < 4 {
+		return errSftpMalformedPacket
+	}
+	handle, err := readString(res[4:]) // skip request id
+	if err != nil {
+		return err
+	}
+
+	// write
+	for offset := 0; offset < len(data); offset += sftpMaxChunkSize {
+		chunk := data[offset:min(offset+sftpMaxChunkSize, len(data))]
+		payload := c.appendId(nil)
+		payload = appendString(payload, handle)
+		payload = binary.BigEndian.AppendUint64(payload, uint64(offset))
+		payload = appendString(payload, chunk)
+		if err := c.send(sftpFxpWrite, payload); err != nil {
+			return err
+		}
+		if err := c.expectOk(); err != nil {
+			return err
+		}
+	}
+
+	// close
+	if err := c.send(sftpFxpClose, appendString(c.appendId(nil), handle)); err != nil {
+		return err
+	}
+	return c.expectOk()
+}
+
+func (c *sftpConn) appendId(b []byte) []byte {
+	c.nextId++
+	return binary.BigEndian.AppendUint32(b, c.nextId)
+}
+
+func (c *sftpConn) send(packetType byte, payload []byte) error {
+	packet := binary.BigEndian.AppendUint32(nil, uint32(len(payload)+1))
+	packet = append(packet, packetType)
+	packet = append(packet, payload...)
+	_, err := c.w.Write(packet)
+	return err
+}
+
+func (c *sftpConn) recv() (byte, []byte, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(c.r, header); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if length < 1 || length > sftpMaxPacketSize {
+		return 0, nil, errSftpMalformedPacket
+	}
+	body := make([]byte, length-1)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, err
+	}
+	return header[4], body, nil
+}
+
+func (c *sftpConn) expectOk() error {
+	t, res, err := c.recv()
+	if err != nil {
+		return err
+	}
+	if t != sftpFxpStatus {
+		return errSftpUnexpectedPacket
+	}
+	return parseStatus(res)
+}
+
+func parseStatus(res []byte) error {
+	if len(res) < 8 {
+		return errSftpMalformedPacket
+	}
+	if code := binary.BigEndian.Uint32(res[4:8]); code != sftpFxOk {
+		return fmt.Errorf("sftp request failed with status %d", code)
+	}
+	return nil
+}
+
+// readString reads a length-prefixed string from the beginning of b
+func readString(b []byte) ([]byte, error) {
+	if len(b) < 4 {
+		return nil, errSftpMalformedPacket
+	}
+	n := binary.BigEndian.Uint32(b[:4])
+	if uint64(n) > uint64(len(b)-4) {
+		return nil, errSftpMalformedPacket
+	}
+	return b[4 : 4+n], nil
+}
+
+func appendString(b []byte, s []byte) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
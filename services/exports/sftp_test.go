@@ -0,0 +1,89 @@
+package exports
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func sftpPacket(packetType byte, payload []byte) []byte {
+	packet := binary.BigEndian.AppendUint32(nil, uint32(len(payload)+1))
+	packet = append(packet, packetType)
+	return append(packet, payload...)
+}
+
+func sftpStatus(id, code uint32) []byte {
+	payload := binary.BigEndian.AppendUint32(nil, id)
+	return sftpPacket(sftpFxpStatus, binary.BigEndian.AppendUint32(payload, code))
+}
+
+func TestSftpConn_WriteFile(t *testing.T) {
+	var server bytes.Buffer
+	server.Write(sftpPacket(sftpFxpVersion, binary.BigEndian.AppendUint32(nil, 3)))
+	server.Write(sftpPacket(sftpFxpHandle, appendString(binary.BigEndian.AppendUint32(nil, 1), []byte("h1"))))
+	server.Write(sftpStatus(2, sftpFxOk)) // first chunk
+	server.Write(sftpStatus(3, sftpFxOk)) // second chunk
+	server.Write(sftpStatus(4, sftpFxOk)) // close
+
+	var sent bytes.Buffer
+	sut := &sftpConn{r: &server, w: &sent}
+
+	assert.Nil(t, sut.writeFile("exports/out.json", make([]byte, sftpMaxChunkSize+1)))
+	assert.Equal(t, uint32(4), sut.nextId)
+	assert.Zero(t, server.Len())
+}
+
+func TestSftpConn_WriteFile_Malformed(t *testing.T) {
+	version := sftpPacket(sftpFxpVersion, binary.BigEndian.AppendUint32(nil, 3))
+
+	tests := map[string][]byte{
+		"zero length":      {0, 0, 0, 0, sftpFxpVersion},
+		"oversized length": {0xff, 0xff, 0xff, 0xff, sftpFxpVersion},
+		"truncated body":   {0, 0, 0, 10, sftpFxpVersion, 0, 0},
+		"unexpected type":  sftpPacket(sftpFxpStatus, nil),
+		"short handle":     append(bytes.Clone(version), sftpPacket(sftpFxpHandle, []byte{0, 0, 0, 1, 0, 0})...),
+		"handle overflow":  append(bytes.Clone(version), sftpPacket(sftpFxpHandle, []byte{0, 0, 0, 1, 0xff, 0xff, 0xff, 0xff, 'h'})...),
+		"short status":     append(bytes.Clone(version), sftpPacket(sftpFxpStatus, []byte{0, 0, 0, 1})...),
+		"failed open":      append(bytes.Clone(version), sftpStatus(1, 3)...),
+	}
+
+	for name, response := range tests {
+		t.Run(name, func(t *testing.T) {
+			sut := &sftpConn{r: bytes.NewReader(response), w: io.Discard}
+			assert.Error(t, sut.writeFile("out.json", []byte("data")))
+		})
+	}
+}
+
+func TestSftpTarget_HostKeyCallback(t *testing.T) {
+	_, err := NewSftpTarget(config.ExportSftpConfig{Host: "localhost"}).hostKeyCallback()
+	assert.ErrorIs(t, err, errSftpHostKeyMissing)
+
+	callback, err := NewSftpTarget(config.ExportSftpConfig{Host: "localhost", InsecureSkipHostKey: true}).hostKeyCallback()
+	assert.Nil(t, err)
+	assert.NotNil(t, callback)
+
+	_, err = NewSftpTarget(config.ExportSftpConfig{Host: "localhost", HostKey: "invalid"}).hostKeyCallback()
+	assert.Error(t, err)
+
+	callback, err = NewSftpTarget(config.ExportSftpConfig{Host: "localhost", HostKey: "ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIOMqqnkVzrm0SdG6UOoqKLsabgH5C9okWi0dh2l9GKJl"}).hostKeyCallback()
+	assert.Nil(t, err)
+	assert.NotNil(t, callback)
+}
+
+// FuzzSftpConn_WriteFile feeds arbitrary server responses to the client, which must never panic or allocate beyond the max. packet size
+func FuzzSftpConn_WriteFile(f *testing.F) {
+	f.Add(sftpPacket(sftpFxpVersion, binary.BigEndian.AppendUint32(nil, 3)))
+	f.Add(append(sftpPacket(sftpFxpVersion, nil), sftpPacket(sftpFxpHandle, []byte{0, 0, 0, 1, 0, 0, 0, 2, 'h', '1'})...))
+	f.Add([]byte{0, 0, 0, 0, 0})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, sftpFxpHandle})
+
+	f.Fuzz(func(t *testing.T, response []byte) {
+		sut := &sftpConn{r: bytes.NewReader(response), w: io.Discard}
+		_ = sut.writeFile("out.json", []byte("data"))
+	})
+}
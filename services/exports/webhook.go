@@ -0,0 +1,57 @@
+package exports
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+)
+
+const signatureHeader = "X-Hackatime-Signature"
+
+type WebhookTarget struct {
+	config     config.ExportWebhookConfig
+	httpClient *http.Client
+}
+
+func NewWebhookTarget(config config.ExportWebhookConfig) *WebhookTarget {
+	return &WebhookTarget{
+		config:     config,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (t *WebhookTarget) Name() string {
+	return "webhook"
+}
+
+func (t *WebhookTarget) Upload(name string, data []byte) error {
+	req, err := http.NewRequest(http.MethodPost, t.config.Url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/csv")
+	req.Header.Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", name))
+
+	if t.config.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(t.config.Secret))
+		mac.Write(data)
+		req.Header.Set(signatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	res, err := t.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+	return nil
+}
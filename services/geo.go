@@ -0,0 +1,163 @@
+package services
+
+import (
+	"bytes"
+	"encoding/csv"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+)
+
+type geoIpRange struct {
+	start   net.IP // 16-byte representation, also for ipv4 addresses
+	end     net.IP
+	country string
+}
+
+// GeoService resolves the coarse location (country) of client ip addresses from a local geoip database, so no ip address is ever sent to a third party.
+// Only the country is stored per machine, never the ip address itself.
+type GeoService struct {
+	config         *config.Config
+	ranges         []*geoIpRange
+	machineService IMachineService
+	summaryService ISummaryService
+}
+
+func NewGeoService(machineService IMachineService, summaryService ISummaryService) *GeoService {
+	srv := &GeoService{
+		config:         config.Get(),
+		ranges:         []*geoIpRange{},
+		machineService: machineService,
+		summaryService: summaryService,
+	}
+
+	if path := srv.config.App.GeoIpDb; path != "" {
+		ranges, err := loadGeoIpDb(path)
+		if err != nil {
+			config.Log().Error("failed to load geoip database, geo features are disabled", "path", path, "error", err)
+			return srv
+		}
+		srv.ranges = ranges
+		slog.Info("loaded geoip database", "ranges", len(ranges))
+	}
+
+	return srv
+}
+
+func (srv *GeoService) Enabled() bool {
+	return len(srv.ranges) > 0
+}
+
+// Country returns the iso 3166-1 alpha-2 code of the country the given ip address is located in, or an empty string, if unknown
+func (srv *GeoService) Country(ip net.IP) string {
+	if ip = ip.To16(); ip == nil {
+		return ""
+	}
+	i := sort.Search(len(srv.ranges), func(i int) bool {
+		return bytes.Compare(srv.ranges[i].start, ip) > 0
+	}) - 1
+	if i < 0 || bytes.Compare(ip, srv.ranges[i].end) > 0 {
+		return ""
+	}
+	return srv.ranges[i].country
+}
+
+// Track stores the country of the given ip address for the user's machine, unless the user opted out of it
+func (srv *GeoService) Track(user *models.User, machineName string, ip net.IP) error {
+	if !srv.Enabled() || !user.TrackLocation || machineName == "" {
+		return nil
+	}
+	country := srv.Country(ip)
+	if country == "" {
+		return nil
+	}
+	return srv.machineService.SetCountry(user, machineName, country)
+}
+
+// Summarize breaks down the user's coding time within the given range by the countries of the machines it was tracked on
+func (srv *GeoService) Summarize(user *models.User, from, to time.Time) (*models.LocationSummary, error) {
+	machines, err := srv.machineService.GetByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	countries := make(map[string]string, len(machines))
+	for _, m := range machines {
+		countries[m.Name] = m.Country
+	}
+
+	// raw machine names are needed to look up their countries, so aliases are not resolved
+	summary, err := srv.summaryService.Retrieve(from, to, user, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]time.Duration)
+	for _, item := range summary.Machines {
+		country := countries[item.Key]
+		if country == "" {
+			country = models.UnknownSummaryKey
+		}
+		totals[country] += item.TotalFixed()
+	}
+
+	result := &models.LocationSummary{From: from, To: to, Countries: make([]*models.LocationSummaryItem, 0, len(totals))}
+	for country, total := range totals {
+		result.Countries = append(result.Countries, &models.LocationSummaryItem{Country: country, TotalSeconds: int64(total.Seconds())})
+	}
+	sort.Slice(result.Countries, func(i, j int) bool {
+		if result.Countries[i].TotalSeconds != result.Countries[j].TotalSeconds {
+			return result.Countries[i].TotalSeconds > result.Countries[j].TotalSeconds
+		}
+		return result.Countries[i].Country < result.Countries[j].Country
+	})
+	return result, nil
+}
+
+// loadGeoIpDb reads ip ranges from a csv file with the columns start_ip,end_ip,country_code (further columns are ignored), as provided by e.g. db-ip.com
+func loadGeoIpDb(path string) ([]*geoIpRange, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return parseGeoIpDb(file)
+}
+
+func parseGeoIpDb(r io.Reader) ([]*geoIpRange, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	ranges := make([]*geoIpRange, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) < 3 {
+			continue
+		}
+
+		start, end := net.ParseIP(strings.TrimSpace(record[0])).To16(), net.ParseIP(strings.TrimSpace(record[1])).To16()
+		country := strings.ToUpper(strings.TrimSpace(record[2]))
+		if start == nil || end == nil || len(country) != 2 || bytes.Compare(start, end) > 0 {
+			continue // e.g. header line
+		}
+		ranges = append(ranges, &geoIpRange{start: start, end: end, country: country})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool {
+		return bytes.Compare(ranges[i].start, ranges[j].start) < 0
+	})
+	return ranges, nil
+}
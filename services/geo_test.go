@@ -0,0 +1,35 @@
+package services
+
+import (
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeoService_Country(t *testing.T) {
+	db := strings.Join([]string{
+		"start_ip,end_ip,country",
+		"1.0.0.0,1.0.0.255,au",
+		"5.1.0.0,5.1.255.255,DE",
+		"2001:db8::,2001:db8:ffff:ffff:ffff:ffff:ffff:ffff,NL",
+		"9.9.9.9,8.8.8.8,US", // invalid range
+		"garbage",
+	}, "\n")
+
+	ranges, err := parseGeoIpDb(strings.NewReader(db))
+	assert.Nil(t, err)
+	assert.Len(t, ranges, 3)
+
+	sut := &GeoService{ranges: ranges}
+	assert.True(t, sut.Enabled())
+	assert.Equal(t, "AU", sut.Country(net.ParseIP("1.0.0.1")))
+	assert.Equal(t, "DE", sut.Country(net.ParseIP("5.1.255.255")))
+	assert.Equal(t, "DE", sut.Country(net.ParseIP("5.1.0.0").To4()))
+	assert.Equal(t, "NL", sut.Country(net.ParseIP("2001:db8::1")))
+	assert.Equal(t, "", sut.Country(net.ParseIP("5.2.0.0")))
+	assert.Equal(t, "", sut.Country(net.ParseIP("0.0.0.1")))
+	assert.Equal(t, "", sut.Country(net.ParseIP("2001:db9::1")))
+	assert.Equal(t, "", sut.Country(nil))
+}
@@ -1,14 +1,19 @@
 package services
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"math"
+	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	datastructure "github.com/duke-git/lancet/v2/datastructure/set"
 	"github.com/duke-git/lancet/v2/maputil"
+	"github.com/duke-git/lancet/v2/slice"
 	"github.com/hackclub/hackatime/config"
 	"github.com/hackclub/hackatime/repositories"
 	"github.com/hackclub/hackatime/utils"
@@ -24,17 +29,29 @@ type HeartbeatService struct {
 	eventBus            *hub.Hub
 	repository          repositories.IHeartbeatRepository
 	languageMappingSrvc ILanguageMappingService
+	canonicalNameSrvc   ICanonicalNameService
 	entityCacheLock     *sync.RWMutex
+	truncatedCounts     map[string]*atomic.Int64
+	globalBlocklist     []*regexp.Regexp
 }
 
-func NewHeartbeatService(heartbeatRepo repositories.IHeartbeatRepository, languageMappingService ILanguageMappingService) *HeartbeatService {
+func NewHeartbeatService(heartbeatRepo repositories.IHeartbeatRepository, languageMappingService ILanguageMappingService, canonicalNameService ICanonicalNameService) *HeartbeatService {
 	srv := &HeartbeatService{
 		config:              config.Get(),
 		cache:               cache.New(24*time.Hour, 24*time.Hour),
 		eventBus:            config.EventBus(),
 		repository:          heartbeatRepo,
 		languageMappingSrvc: languageMappingService,
+		canonicalNameSrvc:   canonicalNameService,
 		entityCacheLock:     &sync.RWMutex{},
+		truncatedCounts:     make(map[string]*atomic.Int64),
+	}
+
+	// patterns were already validated when loading the config
+	srv.globalBlocklist, _ = models.CompileBlocklist(srv.config.App.IngestionBlocklist)
+
+	for _, field := range models.HeartbeatNormalizedFields() {
+		srv.truncatedCounts[field] = &atomic.Int64{}
 	}
 
 	// using event hub is an unnecessary indirection here, however, we might
@@ -54,6 +71,7 @@ func NewHeartbeatService(heartbeatRepo repositories.IHeartbeatRepository, langua
 }
 
 func (srv *HeartbeatService) Insert(heartbeat *models.Heartbeat) error {
+	srv.Normalize(heartbeat)
 	go srv.updateEntityUserCacheByHeartbeat(heartbeat)
 	return srv.repository.InsertBatch([]*models.Heartbeat{heartbeat})
 }
@@ -69,7 +87,7 @@ func (srv *HeartbeatService) InsertBatch(heartbeats []*models.Heartbeat) error {
 	filteredHeartbeats := make([]*models.Heartbeat, 0, len(heartbeats))
 	for _, hb := range heartbeats {
 		if !hashes.Contain(hb.Hash) {
-			hb = hb.Sanitize()
+			hb = srv.Normalize(hb.Sanitize())
 			filteredHeartbeats = append(filteredHeartbeats, hb)
 			hashes.Add(hb.Hash)
 		}
@@ -83,6 +101,46 @@ func (srv *HeartbeatService) InsertBatch(heartbeats []*models.Heartbeat) error {
 	return err
 }
 
+// WithContext returns a copy of the service, whose database queries are canceled once the given context is done
+func (srv *HeartbeatService) WithContext(ctx context.Context) IHeartbeatService {
+	scoped := *srv
+	scoped.repository = srv.repository.WithContext(ctx)
+	return &scoped
+}
+
+// Normalize maps editor and operating system to their canonical names and truncates overly long fields instead of having the database
+// reject the heartbeat (no-op for heartbeats, which were already normalized)
+func (srv *HeartbeatService) Normalize(heartbeat *models.Heartbeat) *models.Heartbeat {
+	if srv.canonicalNameSrvc != nil {
+		srv.canonicalNameSrvc.Canonicalize(heartbeat)
+	}
+	for _, field := range heartbeat.NormalizeFields() {
+		srv.truncatedCounts[field].Add(1)
+		slog.Debug("truncated heartbeat field", "field", field, "userID", heartbeat.UserID)
+	}
+	return heartbeat
+}
+
+// IsBlocked reports whether the heartbeat's project or entity matches the instance-wide or the user's ingestion blocklist
+func (srv *HeartbeatService) IsBlocked(user *models.User, heartbeat *models.Heartbeat) bool {
+	if heartbeat.MatchesBlocklist(srv.globalBlocklist) {
+		return true
+	}
+	if len(user.IngestionBlocklist) == 0 {
+		return false
+	}
+	return heartbeat.MatchesBlocklist(srv.getUserBlocklist(user))
+}
+
+// GetTruncatedCounts returns the number of heartbeat fields truncated at ingestion so far, by field name
+func (srv *HeartbeatService) GetTruncatedCounts() map[string]int64 {
+	counts := make(map[string]int64, len(srv.truncatedCounts))
+	for field, count := range srv.truncatedCounts {
+		counts[field] = count.Load()
+	}
+	return counts
+}
+
 func (srv *HeartbeatService) Count(approximate bool) (int64, error) {
 	result, ok := srv.cache.Get(srv.countTotalCacheKey())
 	if ok {
@@ -108,6 +166,14 @@ func (srv *HeartbeatService) CountByUser(user *models.User) (int64, error) {
 	return count, err
 }
 
+// CountActiveDaysByUser counts the days, each given as [start, end), on which the user has any heartbeats matching the filters
+func (srv *HeartbeatService) CountActiveDaysByUser(user *models.User, days [][]time.Time, filters *models.Filters) (int64, error) {
+	if filters == nil {
+		filters = &models.Filters{}
+	}
+	return srv.repository.CountActiveDaysByUser(user, days, srv.filtersToColumnMap(filters))
+}
+
 func (srv *HeartbeatService) CountByUsers(users []*models.User) ([]*models.CountByUser, error) {
 	missingUsers := make([]*models.User, 0, len(users))
 	userCounts := make([]*models.CountByUser, 0, len(users))
@@ -149,6 +215,12 @@ func (srv *HeartbeatService) GetAllWithinByFilters(from, to time.Time, user *mod
 	if err != nil {
 		return nil, err
 	}
+	if filters.Tag.Exists() {
+		// tags are stored as a single column, so they're matched in memory
+		heartbeats = slice.Filter(heartbeats, func(i int, h *models.Heartbeat) bool {
+			return filters.Tag.MatchAnyTag(h.Tags)
+		})
+	}
 	return srv.augmented(heartbeats, user.ID)
 }
 
@@ -194,19 +266,48 @@ func (srv *HeartbeatService) GetEntitySetByUser(entityType uint8, userId string)
 
 func (srv *HeartbeatService) DeleteBefore(t time.Time) error {
 	go srv.cache.Flush()
+	defer srv.notifyUpdate("")
 	return srv.repository.DeleteBefore(t)
 }
 
 func (srv *HeartbeatService) DeleteByUser(user *models.User) error {
 	go srv.cache.Flush()
+	defer srv.notifyUpdate(user.ID)
 	return srv.repository.DeleteByUser(user)
 }
 
 func (srv *HeartbeatService) DeleteByUserBefore(user *models.User, t time.Time) error {
 	go srv.cache.Flush()
+	defer srv.notifyUpdate(user.ID)
 	return srv.repository.DeleteByUserBefore(user, t)
 }
 
+func (srv *HeartbeatService) GetProjectIntervalByUser(user *models.User, project string) (*models.Interval, error) {
+	return srv.repository.GetProjectIntervalByUser(user, project)
+}
+
+func (srv *HeartbeatService) RenameProject(user *models.User, oldProject, newProject string, from, to time.Time) (int64, error) {
+	go srv.cache.Flush()
+	defer srv.notifyUpdate(user.ID)
+	return srv.repository.RenameProject(user, oldProject, newProject, from, to)
+}
+
+func (srv *HeartbeatService) GetMachineIntervalByUser(user *models.User, machine string) (*models.Interval, error) {
+	return srv.repository.GetMachineIntervalByUser(user, machine)
+}
+
+func (srv *HeartbeatService) CopyMachineToUser(user, target *models.User, machine string, from, to time.Time) (int64, error) {
+	go srv.cache.Flush()
+	defer srv.notifyUpdate(target.ID)
+	return srv.repository.CopyMachineToUser(user, target, machine, from, to)
+}
+
+func (srv *HeartbeatService) DeleteByUserAndMachineBefore(user *models.User, machine string, t time.Time) (int64, error) {
+	go srv.cache.Flush()
+	defer srv.notifyUpdate(user.ID)
+	return srv.repository.DeleteByUserAndMachineBefore(user, machine, t)
+}
+
 func (srv *HeartbeatService) GetUserProjectStats(user *models.User, from, to time.Time, pageParams *utils.PageParams, skipCache bool) ([]*models.ProjectStats, error) {
 	// for projects page, call this like: GetUserProjectStats(&models.User{ID: "n1try"}, time.Time{}, utils.BeginOfToday(time.Local), false)
 
@@ -241,6 +342,33 @@ func (srv *HeartbeatService) GetUserProjectStats(user *models.User, from, to tim
 	return results, err
 }
 
+func (srv *HeartbeatService) GetUserAgentStats(user *models.User) ([]*models.UserAgentStats, error) {
+	return srv.repository.GetUserAgentStats(user)
+}
+
+func (srv *HeartbeatService) GetMachineStats(user *models.User) ([]*models.MachineStats, error) {
+	return srv.repository.GetMachineStats(user)
+}
+
+// GetChangesSince returns up to limit heartbeats inserted and deletions performed after the given cursor
+func (srv *HeartbeatService) GetChangesSince(user *models.User, cursor *models.HeartbeatChangesCursor, limit int) (*models.HeartbeatChanges, error) {
+	heartbeats, err := srv.repository.GetAllByUserAfterId(user, cursor.HeartbeatId, limit)
+	if err != nil {
+		return nil, err
+	}
+	deletions, err := srv.repository.GetDeletionsByUserAfterId(user, cursor.DeletionId, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.HeartbeatChanges{
+		Heartbeats: heartbeats,
+		Deletions:  deletions,
+		Cursor:     cursor.Advance(heartbeats, deletions),
+		HasMore:    len(heartbeats) == limit || len(deletions) == limit,
+	}, nil
+}
+
 func (srv *HeartbeatService) augmented(heartbeats []*models.Heartbeat, userId string) ([]*models.Heartbeat, error) {
 	languageMapping, err := srv.languageMappingSrvc.ResolveByUser(userId)
 	if err != nil {
@@ -299,6 +427,13 @@ func (srv *HeartbeatService) notifyBatch(heartbeats []*models.Heartbeat) {
 	}
 }
 
+func (srv *HeartbeatService) notifyUpdate(userId string) {
+	srv.eventBus.Publish(hub.Message{
+		Name:   config.EventHeartbeatUpdate,
+		Fields: map[string]interface{}{config.FieldUserId: userId},
+	})
+}
+
 func (srv *HeartbeatService) countByUserCacheKey(userId string) string {
 	return fmt.Sprintf("%s--hearbeat-count", userId)
 }
@@ -319,6 +454,9 @@ func (srv *HeartbeatService) filtersToColumnMap(filters *models.Filters) map[str
 			columnMap[models.GetEntityColumn(t)] = *f
 		}
 	}
+	if filters.Source.Exists() {
+		columnMap["source"] = filters.Source
+	}
 	return columnMap
 }
 
@@ -345,3 +483,17 @@ func (srv *HeartbeatService) checkInvalidateProjectStatsCache(newHeartbeat *mode
 		go srv.populateUniqueUserProjects(newHeartbeat.UserID)
 	}
 }
+
+func (srv *HeartbeatService) getUserBlocklist(user *models.User) []*regexp.Regexp {
+	cacheKey := fmt.Sprintf("blocklist_%s_%s", user.ID, strings.Join(user.IngestionBlocklist, "\x00"))
+	if result, found := srv.cache.Get(cacheKey); found {
+		return result.([]*regexp.Regexp)
+	}
+	patterns, err := models.CompileBlocklist(user.IngestionBlocklist)
+	if err != nil {
+		config.Log().Error("failed to compile user's ingestion blocklist", "userID", user.ID, "error", err)
+		return nil
+	}
+	srv.cache.Set(cacheKey, patterns, 1*time.Hour)
+	return patterns
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	datastructure "github.com/duke-git/lancet/v2/datastructure/set"
+	"github.com/gofrs/uuid/v5"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services/imports"
+	"github.com/patrickmn/go-cache"
+)
+
+var (
+	ErrImportInvalid  = errors.New("invalid import")
+	ErrImportNotFound = errors.New("import not found")
+	ErrImportTooLarge = errors.New("uploaded archive exceeds the maximum size")
+)
+
+// ImportService imports archives, which are too large to be sent to the api in a single request. The user uploads the archive
+// to a pre-signed, size-limited target first (a post policy for s3 or chunked to the local object storage), then it is processed
+// in the background. Jobs are only kept in memory, archives of jobs lost upon a restart are removed by the expired objects cleanup.
+type ImportService struct {
+	config          *config.Config
+	heartbeatSrvc   IHeartbeatService
+	userSrvc        IUserService
+	summarySrvc     ISummaryService
+	aggregationSrvc IAggregationService
+	objectSrvc      IObjectStorageService
+	mailSrvc        IMailService
+	jobs            *cache.Cache
+}
+
+func NewImportService(heartbeatService IHeartbeatService, userService IUserService, summaryService ISummaryService, aggregationService IAggregationService, objectStorageService IObjectStorageService, mailService IMailService) *ImportService {
+	srv := &ImportService{
+		config:          config.Get(),
+		heartbeatSrvc:   heartbeatService,
+		userSrvc:        userService,
+		summarySrvc:     summaryService,
+		aggregationSrvc: aggregationService,
+		objectSrvc:      objectStorageService,
+		mailSrvc:        mailService,
+		jobs:            cache.New(24*time.Hour, 1*time.Hour),
+	}
+	srv.jobs.OnEvicted(srv.onJobEvicted)
+	return srv
+}
+
+// CreateUpload issues a pre-signed url to upload a wakatime data export to, at most one import per user may be active at a time
+func (srv *ImportService) CreateUpload(user *models.User) (*models.ImportJob, error) {
+	for _, item := range srv.jobs.Items() {
+		if job := item.Object.(*models.ImportJob); job.UserID == user.ID && job.IsActive() {
+			return nil, fmt.Errorf("%w: another import is still in progress", ErrImportInvalid)
+		}
+	}
+
+	expiry := srv.config.Objects.GetLinkExpiry()
+	job := &models.ImportJob{
+		ID:              uuid.Must(uuid.NewV4()).String(),
+		UserID:          user.ID,
+		Status:          models.ImportStatusAwaitingUpload,
+		UploadExpiresAt: time.Now().Add(expiry),
+		UploadMaxBytes:  int64(srv.config.App.ImportMaxUploadMb) * 1024 * 1024,
+		CreatedAt:       time.Now(),
+	}
+	job.ObjectKey = fmt.Sprintf("imports/%s.json", job.ID)
+
+	target, err := srv.objectSrvc.SignedUploadUrl(job.ObjectKey, expiry, job.UploadMaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	job.UploadUrl, job.UploadMethod, job.UploadFields = target.Url, target.Method, target.Fields
+
+	srv.jobs.SetDefault(job.ID, job)
+	return job, nil
+}
+
+// Complete seals the upload, so that its url can't be used anymore, and starts processing the archive in the background
+func (srv *ImportService) Complete(user *models.User, id string) (*models.ImportJob, error) {
+	job, err := srv.Get(user, id)
+	if err != nil {
+		return nil, err
+	}
+	if !job.IsAwaitingUpload() {
+		return nil, fmt.Errorf("%w: import is not awaiting an upload (anymore)", ErrImportInvalid)
+	}
+
+	if err := srv.objectSrvc.CompleteUpload(job.ObjectKey); err != nil {
+		return nil, err
+	}
+	job.Start()
+
+	// s3 already enforces the maximum size through the upload's policy, check again to not depend on the storage's behavior
+	size, err := srv.objectSrvc.Size(job.ObjectKey)
+	if err == nil && size > job.UploadMaxBytes {
+		err = fmt.Errorf("%w (%d bytes)", ErrImportTooLarge, size)
+	}
+	if err != nil {
+		job.Finish(0, err)
+		srv.deleteUpload(job)
+		return nil, err
+	}
+
+	go func(user *models.User) {
+		job.Finish(srv.process(job, user))
+	}(user)
+
+	return job, nil
+}
+
+func (srv *ImportService) Get(user *models.User, id string) (*models.ImportJob, error) {
+	if item, ok := srv.jobs.Get(id); ok {
+		if job := item.(*models.ImportJob); job.UserID == user.ID {
+			return job, nil
+		}
+	}
+	return nil, ErrImportNotFound
+}
+
+// process streams the uploaded archive into the database and returns the number of heartbeats, which weren't present before
+func (srv *ImportService) process(job *models.ImportJob, user *models.User) (int, error) {
+	start := time.Now()
+	slog.Info("processing uploaded import", "userID", user.ID, "jobID", job.ID)
+
+	defer srv.deleteUpload(job)
+
+	reader, err := srv.objectSrvc.Open(job.ObjectKey)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	countBefore, _ := srv.heartbeatSrvc.CountByUser(user)
+
+	importer := imports.NewWakatimeArchiveImporter(reader)
+	stream, err := importer.ImportAll(user)
+	if err != nil {
+		return 0, err
+	}
+
+	batch := make([]*models.Heartbeat, 0, srv.config.App.ImportBatchSize)
+	insert := func(batch []*models.Heartbeat) {
+		if err := srv.heartbeatSrvc.InsertBatch(batch); err != nil {
+			slog.Warn("failed to insert imported heartbeat, already existing?", "error", err)
+		}
+		job.Advance(len(batch))
+	}
+
+	for hb := range stream {
+		batch = append(batch, hb)
+		if len(batch) == srv.config.App.ImportBatchSize {
+			insert(batch)
+			batch = make([]*models.Heartbeat, 0, srv.config.App.ImportBatchSize)
+		}
+	}
+	if len(batch) > 0 {
+		insert(batch)
+	}
+
+	countAfter, _ := srv.heartbeatSrvc.CountByUser(user)
+	added := int(countAfter - countBefore)
+	slog.Info("imported heartbeats from uploaded archive", "userID", user.ID, "jobID", job.ID, "importedCount", added)
+
+	if err := importer.Err(); err != nil {
+		// heartbeats decoded so far are kept, summaries are re-generated once the user imports the fixed archive
+		return added, err
+	}
+
+	if err := srv.summarySrvc.DeleteByUser(user.ID); err != nil {
+		return added, err
+	}
+	if err := srv.aggregationSrvc.AggregateSummaries(datastructure.New(user.ID)); err != nil {
+		return added, err
+	}
+
+	if !user.HasData {
+		user.HasData = true
+		if _, err := srv.userSrvc.Update(user); err != nil {
+			config.Log().Error("failed to set 'has_data' flag for user", "userID", user.ID, "error", err)
+		}
+	}
+
+	if user.HasTrustedEmail() {
+		if err := srv.mailSrvc.SendImportNotification(user, time.Since(start), added); err != nil {
+			config.Log().Error("failed to send import notification mail", "userID", user.ID, "error", err)
+		}
+	}
+
+	return added, nil
+}
+
+func (srv *ImportService) deleteUpload(job *models.ImportJob) {
+	if err := srv.objectSrvc.Delete(job.ObjectKey); err != nil {
+		config.Log().Warn("failed to delete uploaded import", "key", job.ObjectKey, "error", err)
+	}
+}
+
+// onJobEvicted removes whatever was uploaded for jobs, which were never completed, instead of waiting for the expired objects cleanup
+func (srv *ImportService) onJobEvicted(_ string, item interface{}) {
+	if job := item.(*models.ImportJob); !job.IsStarted() {
+		srv.deleteUpload(job)
+	}
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services/objects"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestImportService_CreateUpload(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Objects.LinkExpiry = "1h"
+	cfg.App.ImportMaxUploadMb = 2
+	config.Set(cfg)
+
+	user := &models.User{ID: TestUserId}
+	target := &objects.UploadTarget{Url: "https://s3.example.org/hackatime", Method: http.MethodPost, Fields: map[string]string{"policy": "foo"}}
+
+	objectService := new(mocks.ObjectStorageServiceMock)
+	objectService.On("SignedUploadUrl", mock.Anything, time.Hour, int64(2*1024*1024)).Return(target, nil)
+
+	sut := NewImportService(nil, nil, nil, nil, objectService, nil)
+
+	job, err := sut.CreateUpload(user)
+	assert.Nil(t, err)
+	assert.Equal(t, target.Url, job.UploadUrl)
+	assert.Equal(t, http.MethodPost, job.UploadMethod)
+	assert.Equal(t, target.Fields, job.UploadFields)
+	assert.Equal(t, int64(2*1024*1024), job.UploadMaxBytes)
+
+	_, err = sut.CreateUpload(user)
+	assert.ErrorIs(t, err, ErrImportInvalid)
+}
+
+func TestImportService_Complete_TooLarge(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Objects.LinkExpiry = "1h"
+	cfg.App.ImportMaxUploadMb = 1
+	config.Set(cfg)
+
+	user := &models.User{ID: TestUserId}
+
+	objectService := new(mocks.ObjectStorageServiceMock)
+	objectService.On("SignedUploadUrl", mock.Anything, mock.Anything, mock.Anything).Return(&objects.UploadTarget{Method: http.MethodPut}, nil)
+	objectService.On("CompleteUpload", mock.Anything).Return(nil)
+	objectService.On("Size", mock.Anything).Return(int64(1024*1024+1), nil)
+	objectService.On("Delete", mock.Anything).Return(nil)
+
+	sut := NewImportService(nil, nil, nil, nil, objectService, nil)
+
+	job, err := sut.CreateUpload(user)
+	assert.Nil(t, err)
+
+	_, err = sut.Complete(user, job.ID)
+	assert.ErrorIs(t, err, ErrImportTooLarge)
+	assert.Equal(t, models.ImportStatusFailed, job.Snapshot().Status)
+	assert.Empty(t, job.Snapshot().UploadUrl)
+	objectService.AssertCalled(t, "Delete", job.ObjectKey)
+
+	_, err = sut.Complete(user, job.ID)
+	assert.ErrorIs(t, err, ErrImportInvalid)
+}
+
+func TestImportService_DeletesAbandonedUploads(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Objects.LinkExpiry = "1h"
+	config.Set(cfg)
+
+	user := &models.User{ID: TestUserId}
+
+	objectService := new(mocks.ObjectStorageServiceMock)
+	objectService.On("SignedUploadUrl", mock.Anything, mock.Anything, mock.Anything).Return(&objects.UploadTarget{Method: http.MethodPut}, nil)
+	objectService.On("Delete", mock.Anything).Return(nil)
+
+	sut := NewImportService(nil, nil, nil, nil, objectService, nil)
+
+	job, err := sut.CreateUpload(user)
+	assert.Nil(t, err)
+
+	sut.jobs.Delete(job.ID)
+	objectService.AssertCalled(t, "Delete", job.ObjectKey)
+}
@@ -0,0 +1,128 @@
+package imports
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	wakatime "github.com/hackclub/hackatime/models/compat/wakatime/v1"
+)
+
+// WakatimeArchiveImporter reads heartbeats from an uploaded wakatime data export (json, optionally gzip-compressed).
+// The archive is decoded day by day, so that even huge ones never need to fit into memory.
+type WakatimeArchiveImporter struct {
+	reader io.Reader
+	err    error
+}
+
+func NewWakatimeArchiveImporter(reader io.Reader) *WakatimeArchiveImporter {
+	return &WakatimeArchiveImporter{reader: reader}
+}
+
+func (w *WakatimeArchiveImporter) Import(user *models.User, minFrom time.Time, maxTo time.Time) (<-chan *models.Heartbeat, error) {
+	slog.Info("running wakatime archive import for user", "userID", user.ID)
+
+	reader, err := decompress(w.reader)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(reader)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, err
+	}
+
+	out := make(chan *models.Heartbeat)
+
+	go func() {
+		defer close(out)
+
+		userAgents := map[string]*wakatime.UserAgentEntry{}
+		machineNames := map[string]*wakatime.MachineEntry{}
+
+		w.err = streamDays(decoder, func(day *wakatime.JsonExportDay) {
+			for _, h := range day.Heartbeats {
+				hb := mapHeartbeat(h, userAgents, machineNames, user)
+				if hb.Time.T().Before(minFrom) || hb.Time.T().After(maxTo) {
+					continue
+				}
+				out <- hb
+			}
+		})
+		if w.err != nil {
+			config.Log().Error("failed to decode wakatime archive", "userID", user.ID, "error", w.err)
+		}
+	}()
+
+	return out, nil
+}
+
+func (w *WakatimeArchiveImporter) ImportAll(user *models.User) (<-chan *models.Heartbeat, error) {
+	return w.Import(user, config.BeginningOfWakatime(), time.Now())
+}
+
+// Err returns the error, which stopped decoding the archive, if any, once the heartbeats channel was closed
+func (w *WakatimeArchiveImporter) Err() error {
+	return w.err
+}
+
+func decompress(reader io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(reader)
+	magic, err := buffered.Peek(2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read archive: %w", err)
+	}
+	if magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(buffered)
+	}
+	return buffered, nil
+}
+
+// streamDays calls the given function for every entry of the export's days array and skips all other fields
+func streamDays(decoder *json.Decoder, f func(day *wakatime.JsonExportDay)) error {
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		if key != "days" {
+			var skipped json.RawMessage
+			if err := decoder.Decode(&skipped); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := expectDelim(decoder, '['); err != nil {
+			return err
+		}
+		for decoder.More() {
+			var day wakatime.JsonExportDay
+			if err := decoder.Decode(&day); err != nil {
+				return err
+			}
+			f(&day)
+		}
+		if err := expectDelim(decoder, ']'); err != nil {
+			return err
+		}
+	}
+	return expectDelim(decoder, '}')
+}
+
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("invalid archive, expected '%s', got '%v'", delim, token)
+	}
+	return nil
+}
@@ -135,6 +135,7 @@ func mapHeartbeat(
 		UserAgent:       ua.Value,
 		Time:            models.CustomTime(time.Unix(0, int64(entry.Time*1e9))),
 		Origin:          OriginWakatime,
+		Source:          models.HeartbeatSourceImport,
 		OriginId:        entry.Id,
 		CreatedAt:       models.CustomTime(entry.CreatedAt),
 	}).Hashed()
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/duke-git/lancet/v2/slice"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/muety/artifex/v2"
+	"gorm.io/gorm"
+)
+
+const checkInactivityEvery = 1 * time.Hour
+
+type inactivityWebhookPayload struct {
+	UserID         string    `json:"user_id"`
+	LastHeartbeat  time.Time `json:"last_heartbeat"`
+	ThresholdHours int       `json:"threshold_hours"`
+}
+
+// InactivityAlertService notifies users whose editor plugins silently stopped sending heartbeats, i.e. who haven't been active
+// for more than their configured number of hours, only counting time on work days. Users are alerted once per period of inactivity.
+type InactivityAlertService struct {
+	config           *config.Config
+	userService      IUserService
+	heartbeatService IHeartbeatService
+	mailService      IMailService
+	telegramService  ITelegramService
+	queueDefault     *artifex.Dispatcher
+	queueMails       *artifex.Dispatcher
+}
+
+func NewInactivityAlertService(userService IUserService, heartbeatService IHeartbeatService, mailService IMailService, telegramService ITelegramService) *InactivityAlertService {
+	return &InactivityAlertService{
+		config:           config.Get(),
+		userService:      userService,
+		heartbeatService: heartbeatService,
+		mailService:      mailService,
+		telegramService:  telegramService,
+		queueDefault:     config.GetDefaultQueue(),
+		queueMails:       config.GetQueue(config.QueueMails),
+	}
+}
+
+func (srv *InactivityAlertService) Schedule() {
+	if !srv.config.Inactivity.Enabled {
+		return
+	}
+
+	slog.Info("scheduling inactivity alerts")
+	if _, err := srv.queueDefault.DispatchEvery(srv.CheckAll, checkInactivityEvery); err != nil {
+		config.Log().Error("failed to schedule inactivity alert jobs", "error", err)
+	}
+}
+
+func (srv *InactivityAlertService) CheckAll() {
+	users, err := srv.userService.GetAll()
+	if err != nil {
+		config.Log().Error("failed to fetch users for inactivity alerts", "error", err)
+		return
+	}
+
+	users = slice.Filter[*models.User](users, func(i int, u *models.User) bool {
+		return u.InactivityAlertHours > 0 && !u.IsServiceAccount && !u.Deactivated && !u.IsSuspended()
+	})
+
+	now := time.Now()
+	for _, u := range users {
+		user := u
+		if err := srv.queueMails.Dispatch(func() {
+			if _, err := srv.Check(user, now); err != nil {
+				config.Log().Error("failed to check user for inactivity", "userID", user.ID, "error", err)
+			}
+		}); err != nil {
+			config.Log().Error("failed to dispatch inactivity check for user", "userID", user.ID, "error", err)
+		}
+	}
+}
+
+// Check alerts the given user if they've been inactive for too long and reports whether the alert was sent
+func (srv *InactivityAlertService) Check(user *models.User, now time.Time) (bool, error) {
+	if user.InactivityAlertHours <= 0 {
+		return false, nil
+	}
+
+	latest, err := srv.heartbeatService.GetLatestByUser(user)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil // never tracked anything, so nothing can have stopped working
+	}
+	if err != nil {
+		return false, err
+	}
+
+	lastHeartbeat := latest.Time.T()
+	if user.LastInactivityAlertAt != nil && user.LastInactivityAlertAt.T().After(lastHeartbeat) {
+		return false, nil // already alerted since the user was last active
+	}
+
+	threshold := time.Duration(user.InactivityAlertHours) * time.Hour
+	if inactiveWorkTime(lastHeartbeat, now, user.TZ(), srv.config.Inactivity.GetWorkDays()) < threshold {
+		return false, nil
+	}
+
+	slog.Info("user is inactive, sending alert", "userID", user.ID, "lastHeartbeat", lastHeartbeat)
+
+	if user.HasTrustedEmail() {
+		if err := srv.mailService.SendInactivityAlert(user, lastHeartbeat); err != nil {
+			config.Log().Error("failed to send inactivity alert mail", "userID", user.ID, "error", err)
+		}
+	}
+	if _, err := srv.telegramService.SendInactivityAlert(user, lastHeartbeat); err != nil {
+		config.Log().Error("failed to send inactivity alert to telegram", "userID", user.ID, "error", err)
+	}
+	if url := srv.config.Inactivity.WebhookUrl; url != "" {
+		if err := sendInactivityWebhook(url, srv.config.Inactivity.WebhookSecret, user, lastHeartbeat); err != nil {
+			config.Log().Error("failed to send inactivity alert webhook", "userID", user.ID, "error", err)
+		}
+	}
+
+	alertedAt := models.CustomTime(now)
+	user.LastInactivityAlertAt = &alertedAt
+	if _, err := srv.userService.UpdateField(user, "last_inactivity_alert_at", alertedAt); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+func sendInactivityWebhook(url, secret string, user *models.User, lastHeartbeat time.Time) error {
+	data, err := json.Marshal(&inactivityWebhookPayload{
+		UserID:         user.ID,
+		LastHeartbeat:  lastHeartbeat,
+		ThresholdHours: user.InactivityAlertHours,
+	})
+	if err != nil {
+		return err
+	}
+	return postWebhookJson(url, data, signedWebhookHeaders(secret, data))
+}
+
+// inactiveWorkTime sums up the time between from and to that falls on one of the given work days in the given time zone
+func inactiveWorkTime(from, to time.Time, tz *time.Location, workDays []time.Weekday) time.Duration {
+	var total time.Duration
+	for cur := from.In(tz); cur.Before(to); {
+		next := time.Date(cur.Year(), cur.Month(), cur.Day()+1, 0, 0, 0, 0, tz)
+		if next.After(to) {
+			next = to.In(tz)
+		}
+		if slices.Contains(workDays, cur.Weekday()) {
+			total += next.Sub(cur)
+		}
+		cur = next
+	}
+	return total
+}
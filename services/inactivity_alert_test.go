@@ -0,0 +1,63 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestInactiveWorkTime(t *testing.T) {
+	tz, _ := time.LoadLocation("Europe/Berlin")
+	workDays := []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday}
+
+	friday := time.Date(2024, 1, 5, 18, 0, 0, 0, tz)
+	monday := time.Date(2024, 1, 8, 10, 0, 0, 0, tz)
+
+	assert.Equal(t, 16*time.Hour, inactiveWorkTime(friday, monday, tz, workDays)) // weekend doesn't count
+	assert.Equal(t, 4*time.Hour, inactiveWorkTime(friday.Add(-4*time.Hour), friday, tz, workDays))
+	assert.Zero(t, inactiveWorkTime(friday.Add(12*time.Hour), friday.Add(36*time.Hour), tz, workDays))
+	assert.Zero(t, inactiveWorkTime(monday, friday, tz, workDays))
+}
+
+func TestInactivityAlertService_Check(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Inactivity.WorkDays = "monday,tuesday,wednesday,thursday,friday"
+	config.Set(cfg)
+
+	now := time.Date(2024, 1, 9, 12, 0, 0, 0, time.UTC) // tuesday
+	active := &models.User{ID: "active", InactivityAlertHours: 8}
+	inactive := &models.User{ID: "inactive", InactivityAlertHours: 8}
+	alertedAt := models.CustomTime(now.Add(-1 * time.Hour))
+	alerted := &models.User{ID: "alerted", InactivityAlertHours: 8, LastInactivityAlertAt: &alertedAt}
+	untracked := &models.User{ID: "untracked", InactivityAlertHours: 8}
+
+	heartbeatServiceMock := new(mocks.HeartbeatServiceMock)
+	heartbeatServiceMock.On("GetLatestByUser", active).Return(&models.Heartbeat{Time: models.CustomTime(now.Add(-2 * time.Hour))}, nil)
+	heartbeatServiceMock.On("GetLatestByUser", inactive).Return(&models.Heartbeat{Time: models.CustomTime(now.Add(-24 * time.Hour))}, nil)
+	heartbeatServiceMock.On("GetLatestByUser", alerted).Return(&models.Heartbeat{Time: models.CustomTime(now.Add(-24 * time.Hour))}, nil)
+	heartbeatServiceMock.On("GetLatestByUser", untracked).Return((*models.Heartbeat)(nil), gorm.ErrRecordNotFound)
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("UpdateField", inactive, "last_inactivity_alert_at", models.CustomTime(now)).Return(inactive, nil)
+
+	sut := NewInactivityAlertService(userServiceMock, heartbeatServiceMock, nil, NewTelegramService(userServiceMock, nil, nil))
+
+	for _, u := range []*models.User{active, alerted, untracked} {
+		sent, err := sut.Check(u, now)
+		assert.Nil(t, err)
+		assert.False(t, sent, u.ID)
+	}
+
+	sent, err := sut.Check(inactive, now)
+	assert.Nil(t, err)
+	assert.True(t, sent)
+	assert.Equal(t, now, inactive.LastInactivityAlertAt.T())
+	userServiceMock.AssertNumberOfCalls(t, "UpdateField", 1)
+	userServiceMock.AssertNotCalled(t, "Update", mock.Anything)
+}
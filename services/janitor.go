@@ -0,0 +1,192 @@
+package services
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/muety/artifex/v2"
+	"go.uber.org/atomic"
+)
+
+const (
+	JanitorKindKeyValues   = "key_values"
+	JanitorKindInviteCodes = "invite_codes"
+	JanitorKindShareLinks  = "share_links"
+)
+
+// min. age of a rate limiting timestamp before it's considered stale, in addition to the longest configured backoff
+const janitorThrottleKeyMinAge = 7 * 24 * time.Hour
+
+func JanitorKinds() []string {
+	return []string{JanitorKindKeyValues, JanitorKindInviteCodes, JanitorKindShareLinks}
+}
+
+// JanitorService periodically removes state that would otherwise pile up forever: per-user key-values of deleted users or outdated rate limiting timestamps,
+// used up or expired invite codes and share links of deleted users.
+// Login sessions and device authorizations are never persisted (signed cookies and in-memory with expiry), so there's nothing to clean up for them.
+type JanitorService struct {
+	config           *config.Config
+	userSrvc         IUserService
+	keyValueSrvc     IKeyValueService
+	registrationSrvc IRegistrationService
+	yearReviewRepo   repositories.IYearReviewRepository
+	queueDefault     *artifex.Dispatcher
+	removedCounts    map[string]*atomic.Int64
+}
+
+func NewJanitorService(userService IUserService, keyValueService IKeyValueService, registrationService IRegistrationService, yearReviewRepo repositories.IYearReviewRepository) *JanitorService {
+	removedCounts := make(map[string]*atomic.Int64)
+	for _, kind := range JanitorKinds() {
+		removedCounts[kind] = atomic.NewInt64(0)
+	}
+
+	return &JanitorService{
+		config:           config.Get(),
+		userSrvc:         userService,
+		keyValueSrvc:     keyValueService,
+		registrationSrvc: registrationService,
+		yearReviewRepo:   yearReviewRepo,
+		queueDefault:     config.GetDefaultQueue(),
+		removedCounts:    removedCounts,
+	}
+}
+
+func (srv *JanitorService) Schedule() {
+	if srv.config.App.JanitorTime == "" {
+		return
+	}
+
+	slog.Info("scheduling janitor")
+
+	if _, err := srv.queueDefault.DispatchCron(srv.Run, srv.config.App.JanitorTime); err != nil {
+		config.Log().Error("failed to dispatch janitor job", "error", err)
+	}
+}
+
+// Run performs all cleanups once, failed ones are logged and don't affect the others
+func (srv *JanitorService) Run() {
+	if err := srv.CleanKeyValues(); err != nil {
+		config.Log().Error("janitor failed to clean up key-values", "error", err)
+	}
+	if err := srv.CleanInviteCodes(); err != nil {
+		config.Log().Error("janitor failed to clean up invite codes", "error", err)
+	}
+	if err := srv.CleanShareLinks(); err != nil {
+		config.Log().Error("janitor failed to clean up share links", "error", err)
+	}
+}
+
+// CleanKeyValues removes per-user key-values of users which no longer exist and rate limiting timestamps, which are too old to limit anything
+func (srv *JanitorService) CleanKeyValues() error {
+	users, err := srv.userSrvc.GetAll()
+	if err != nil {
+		return err
+	}
+	userIds := make(map[string]bool, len(users))
+	for _, u := range users {
+		userIds[u.ID] = true
+	}
+
+	throttleKeys := map[string]bool{config.KeyLastImport: true, config.KeyLastImportSuccess: true, config.KeyLastExport: true, config.KeyLastEmailVerification: true}
+	maxThrottleAge := max(janitorThrottleKeyMinAge, time.Duration(srv.config.App.ImportMaxRate)*time.Hour, time.Duration(srv.config.App.ImportBackoffMin)*time.Minute)
+
+	stale := make([]string, 0)
+	for _, prefix := range []string{config.KeyLastImport, config.KeyLastImportSuccess, config.KeyLastExport, config.KeyLastEmailVerification, config.KeyFirstHeartbeat, config.KeySubscriptionNotificationSent} {
+		kvs, err := srv.keyValueSrvc.GetByPrefix(prefix + "_")
+		if err != nil {
+			return err
+		}
+		for _, kv := range kvs {
+			userId, ok := strings.CutPrefix(kv.Key, prefix+"_")
+			if !ok { // underscores are wildcards in like-queries
+				continue
+			}
+			if !userIds[userId] {
+				stale = append(stale, kv.Key)
+				continue
+			}
+			if throttleKeys[prefix] {
+				if t, err := time.Parse(time.RFC822, kv.Value); err != nil || time.Since(t) > maxThrottleAge {
+					stale = append(stale, kv.Key)
+				}
+			}
+		}
+	}
+
+	return srv.remove(JanitorKindKeyValues, stale, srv.keyValueSrvc.DeleteString)
+}
+
+// CleanInviteCodes removes invite codes, which have been used up or expired for longer than the configured retention period
+func (srv *JanitorService) CleanInviteCodes() error {
+	if srv.config.App.InviteRetentionDays < 0 {
+		return nil
+	}
+
+	codes, err := srv.registrationSrvc.GetInviteCodes()
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().AddDate(0, 0, -srv.config.App.InviteRetentionDays)
+	stale := make([]string, 0)
+	for _, c := range codes {
+		expired := c.ExpiresAt != nil && c.ExpiresAt.T().Before(cutoff)
+		usedUp := c.MaxUses > 0 && c.Uses >= c.MaxUses && c.CreatedAt.T().Before(cutoff)
+		if expired || usedUp {
+			stale = append(stale, c.Code)
+		}
+	}
+
+	return srv.remove(JanitorKindInviteCodes, stale, srv.registrationSrvc.DeleteInviteCode)
+}
+
+// CleanShareLinks removes year reviews (and thus their share links) of users which no longer exist, in case the database didn't cascade their deletion
+func (srv *JanitorService) CleanShareLinks() error {
+	if srv.config.App.DataCleanupDryRun {
+		return nil
+	}
+	n, err := srv.yearReviewRepo.DeleteOrphaned()
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		slog.Info("janitor removed orphaned share links", "count", n)
+	}
+	srv.removedCounts[JanitorKindShareLinks].Add(n)
+	return nil
+}
+
+// GetRemovedCounts returns the number of items removed by the janitor since startup, by kind
+func (srv *JanitorService) GetRemovedCounts() map[string]int64 {
+	counts := make(map[string]int64, len(srv.removedCounts))
+	for kind, count := range srv.removedCounts {
+		counts[kind] = count.Load()
+	}
+	return counts
+}
+
+func (srv *JanitorService) remove(kind string, keys []string, delete func(string) error) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if srv.config.App.DataCleanupDryRun {
+		slog.Info("janitor skipping removal for dry run", "kind", kind, "count", len(keys))
+		return nil
+	}
+
+	var removed int64
+	var lastErr error
+	for _, key := range keys {
+		if err := delete(key); err != nil {
+			lastErr = err
+			continue
+		}
+		removed++
+	}
+	srv.removedCounts[kind].Add(removed)
+	slog.Info("janitor removed stale items", "kind", kind, "count", removed)
+	return lastErr
+}
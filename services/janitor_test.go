@@ -0,0 +1,79 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestJanitorService_CleanKeyValues(t *testing.T) {
+	cfg := config.Empty()
+	cfg.App.ImportMaxRate = 24
+	config.Set(cfg)
+
+	recent := time.Now().Add(-time.Hour).Format(time.RFC822)
+	old := time.Now().AddDate(0, 0, -30).Format(time.RFC822)
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetAll").Return([]*models.User{{ID: "alice"}}, nil)
+
+	keyValueServiceMock := new(mocks.KeyValueServiceMock)
+	keyValueServiceMock.On("GetByPrefix", config.KeyLastImport+"_").Return([]*models.KeyStringValue{
+		{Key: config.KeyLastImport + "_alice", Value: recent},
+		{Key: config.KeyLastImport + "_bob", Value: recent},
+	}, nil)
+	keyValueServiceMock.On("GetByPrefix", config.KeyLastExport+"_").Return([]*models.KeyStringValue{
+		{Key: config.KeyLastExport + "_alice", Value: old},
+		{Key: "last_exportXalice", Value: old}, // matched by like-query wildcard
+	}, nil)
+	keyValueServiceMock.On("GetByPrefix", config.KeyFirstHeartbeat+"_").Return([]*models.KeyStringValue{
+		{Key: config.KeyFirstHeartbeat + "_alice", Value: old},
+	}, nil)
+	keyValueServiceMock.On("GetByPrefix", mock.Anything).Return([]*models.KeyStringValue{}, nil)
+	keyValueServiceMock.On("DeleteString", mock.Anything).Return(nil)
+
+	sut := NewJanitorService(userServiceMock, keyValueServiceMock, nil, nil)
+
+	assert.Nil(t, sut.CleanKeyValues())
+	keyValueServiceMock.AssertCalled(t, "DeleteString", config.KeyLastImport+"_bob")
+	keyValueServiceMock.AssertCalled(t, "DeleteString", config.KeyLastExport+"_alice")
+	keyValueServiceMock.AssertNumberOfCalls(t, "DeleteString", 2)
+	assert.Equal(t, int64(2), sut.GetRemovedCounts()[JanitorKindKeyValues])
+}
+
+func TestJanitorService_CleanInviteCodes(t *testing.T) {
+	cfg := config.Empty()
+	cfg.App.InviteRetentionDays = 30
+	config.Set(cfg)
+
+	longAgo := models.CustomTime(time.Now().AddDate(0, 0, -60))
+	recently := models.CustomTime(time.Now().AddDate(0, 0, -1))
+
+	inviteCodeRepoMock := new(mocks.InviteCodeRepositoryMock)
+	inviteCodeRepoMock.On("GetAll").Return([]*models.InviteCode{
+		{Code: "unused", MaxUses: 1, CreatedAt: longAgo},
+		{Code: "unlimited", MaxUses: 0, Uses: 5, CreatedAt: longAgo},
+		{Code: "usedup", MaxUses: 1, Uses: 1, CreatedAt: longAgo},
+		{Code: "usedup-recent", MaxUses: 1, Uses: 1, CreatedAt: recently},
+		{Code: "expired", MaxUses: 1, CreatedAt: longAgo, ExpiresAt: &longAgo},
+		{Code: "expired-recent", MaxUses: 1, CreatedAt: longAgo, ExpiresAt: &recently},
+	}, nil)
+	inviteCodeRepoMock.On("Delete", mock.Anything).Return(nil)
+
+	sut := NewJanitorService(nil, nil, NewRegistrationService(new(mocks.KeyValueServiceMock), inviteCodeRepoMock), nil)
+
+	assert.Nil(t, sut.CleanInviteCodes())
+	inviteCodeRepoMock.AssertCalled(t, "Delete", "usedup")
+	inviteCodeRepoMock.AssertCalled(t, "Delete", "expired")
+	inviteCodeRepoMock.AssertNumberOfCalls(t, "Delete", 2)
+
+	cfg.App.DataCleanupDryRun = true
+	assert.Nil(t, sut.CleanInviteCodes())
+	inviteCodeRepoMock.AssertNumberOfCalls(t, "Delete", 2)
+	assert.Equal(t, int64(2), sut.GetRemovedCounts()[JanitorKindInviteCodes])
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/hackclub/hackatime/utils"
+	"github.com/muety/artifex/v2"
+)
+
+const (
+	languageGoalsMaxPerUser = 10
+	checkLanguageGoalsEvery = 1 * time.Hour
+)
+
+var ErrLanguageGoalLimit = errors.New("maximum number of language goals reached")
+
+// LanguageGoalService evaluates learning goals like "at least 30 % of weekly coding time in rust" and tells users how they did
+// once each of their weeks (in their own time zone and with their preferred first day of the week) has closed
+type LanguageGoalService struct {
+	config          *config.Config
+	repository      repositories.ILanguageGoalRepository
+	summaryService  ISummaryService
+	userService     IUserService
+	mailService     IMailService
+	telegramService ITelegramService
+	queueDefault    *artifex.Dispatcher
+	queueMails      *artifex.Dispatcher
+}
+
+func NewLanguageGoalService(languageGoalRepository repositories.ILanguageGoalRepository, summaryService ISummaryService, userService IUserService, mailService IMailService, telegramService ITelegramService) *LanguageGoalService {
+	return &LanguageGoalService{
+		config:          config.Get(),
+		repository:      languageGoalRepository,
+		summaryService:  summaryService,
+		userService:     userService,
+		mailService:     mailService,
+		telegramService: telegramService,
+		queueDefault:    config.GetDefaultQueue(),
+		queueMails:      config.GetQueue(config.QueueMails),
+	}
+}
+
+func (srv *LanguageGoalService) Schedule() {
+	slog.Info("scheduling language goal notifications")
+	if _, err := srv.queueDefault.DispatchEvery(srv.NotifyAll, checkLanguageGoalsEvery); err != nil {
+		config.Log().Error("failed to schedule language goal notification jobs", "error", err)
+	}
+}
+
+func (srv *LanguageGoalService) GetByUser(userId string) ([]*models.LanguageGoal, error) {
+	return srv.repository.GetByUser(userId)
+}
+
+func (srv *LanguageGoalService) Create(goal *models.LanguageGoal) (*models.LanguageGoal, error) {
+	existing, err := srv.repository.GetByUser(goal.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= languageGoalsMaxPerUser {
+		return nil, ErrLanguageGoalLimit
+	}
+	// the week the goal was created in is already half over, so don't report on it
+	now := models.CustomTime(time.Now())
+	goal.LastNotifiedAt = &now
+	return srv.repository.Insert(goal)
+}
+
+func (srv *LanguageGoalService) Delete(userId string, id uint) error {
+	return srv.repository.DeleteByUserAndId(userId, id)
+}
+
+// GetProgress evaluates the given goals against the user's current, still running week
+func (srv *LanguageGoalService) GetProgress(user *models.User, goals []*models.LanguageGoal) ([]*models.LanguageGoalProgress, error) {
+	from := utils.BeginOfThisWeekFrom(user.TZ(), user.WeekStart())
+	return srv.getProgress(user, goals, from, time.Now().In(user.TZ()))
+}
+
+func (srv *LanguageGoalService) NotifyAll() {
+	goals, err := srv.repository.GetAll()
+	if err != nil {
+		config.Log().Error("failed to fetch language goals for notifications", "error", err)
+		return
+	}
+
+	goalsByUser := make(map[string][]*models.LanguageGoal)
+	for _, g := range goals {
+		goalsByUser[g.UserID] = append(goalsByUser[g.UserID], g)
+	}
+
+	now := time.Now()
+	for userId, userGoals := range goalsByUser {
+		userId, userGoals := userId, userGoals
+		if err := srv.queueMails.Dispatch(func() {
+			if _, err := srv.Notify(userId, userGoals, now); err != nil {
+				config.Log().Error("failed to send language goal notification", "userID", userId, "error", err)
+			}
+		}); err != nil {
+			config.Log().Error("failed to dispatch language goal notification for user", "userID", userId, "error", err)
+		}
+	}
+}
+
+// Notify sends the user the outcome of their goals for the most recently closed week, unless already done, and reports whether a notification was sent
+func (srv *LanguageGoalService) Notify(userId string, goals []*models.LanguageGoal, now time.Time) (bool, error) {
+	user, err := srv.userService.GetUserById(userId)
+	if err != nil {
+		return false, err
+	}
+
+	to := datetime.BeginOfWeek(now.In(user.TZ()), user.WeekStart())
+	from := to.AddDate(0, 0, -7)
+
+	var due []*models.LanguageGoal
+	for _, g := range goals {
+		if g.LastNotifiedAt == nil || g.LastNotifiedAt.T().Before(to) {
+			due = append(due, g)
+		}
+	}
+	if len(due) == 0 {
+		return false, nil
+	}
+
+	progress, err := srv.getProgress(user, due, from, to)
+	if err != nil {
+		return false, err
+	}
+
+	var sent bool
+	if user.HasTrustedEmail() && !user.Deactivated && !user.IsSuspended() {
+		if err := srv.mailService.SendLanguageGoalsReport(user, progress); err != nil {
+			config.Log().Error("failed to send language goals mail", "userID", user.ID, "error", err)
+		} else {
+			sent = true
+		}
+	}
+	if !user.Deactivated && !user.IsSuspended() {
+		if ok, err := srv.telegramService.SendLanguageGoalsReport(user, progress); err != nil {
+			config.Log().Error("failed to send language goals to telegram", "userID", user.ID, "error", err)
+		} else if ok {
+			sent = true
+		}
+	}
+
+	notifiedAt := models.CustomTime(now)
+	for _, g := range due {
+		g.LastNotifiedAt = &notifiedAt
+		if err := srv.repository.UpdateLastNotified(g); err != nil {
+			return sent, err
+		}
+	}
+	return sent, nil
+}
+
+func (srv *LanguageGoalService) getProgress(user *models.User, goals []*models.LanguageGoal, from, to time.Time) ([]*models.LanguageGoalProgress, error) {
+	summaries := make(map[string]*models.Summary) // by category
+
+	progress := make([]*models.LanguageGoalProgress, 0, len(goals))
+	for _, g := range goals {
+		summary, ok := summaries[g.Category]
+		if !ok {
+			var filters *models.Filters
+			if g.Category != "" {
+				filters = (&models.Filters{}).With(models.SummaryCategory, g.Category)
+			}
+
+			var err error
+			if summary, err = srv.summaryService.Aliased(from, to, user, srv.summaryService.Retrieve, filters, false); err != nil {
+				return nil, err
+			}
+			summaries[g.Category] = summary
+		}
+		progress = append(progress, models.NewLanguageGoalProgress(g, summary))
+	}
+	return progress, nil
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/duke-git/lancet/v2/slice"
 	"github.com/hackclub/hackatime/config"
 	"github.com/hackclub/hackatime/helpers"
 	"github.com/hackclub/hackatime/models"
@@ -19,30 +20,40 @@ import (
 )
 
 type LeaderboardService struct {
-	config         *config.Config
-	cache          *cache.Cache
-	eventBus       *hub.Hub
-	repository     repositories.ILeaderboardRepository
-	summaryService ISummaryService
-	userService    IUserService
-	queueDefault   *artifex.Dispatcher
-	queueWorkers   *artifex.Dispatcher
-	defaultScope   *models.IntervalKey
+	config                      *config.Config
+	cache                       *cache.Cache
+	eventBus                    *hub.Hub
+	repository                  repositories.ILeaderboardRepository
+	seasonRepo                  repositories.ILeaderboardSeasonRepository
+	summaryService              ISummaryService
+	heartbeatService            IHeartbeatService
+	userService                 IUserService
+	notificationTemplateService INotificationTemplateService
+	queueDefault                *artifex.Dispatcher
+	queueWorkers                *artifex.Dispatcher
+	defaultScope                *models.IntervalKey
 }
 
-func NewLeaderboardService(leaderboardRepo repositories.ILeaderboardRepository, summaryService ISummaryService, userService IUserService) *LeaderboardService {
+func NewLeaderboardService(leaderboardRepo repositories.ILeaderboardRepository, seasonRepo repositories.ILeaderboardSeasonRepository, summaryService ISummaryService, heartbeatService IHeartbeatService, userService IUserService, notificationTemplateService INotificationTemplateService) *LeaderboardService {
 	srv := &LeaderboardService{
-		config:         config.Get(),
-		cache:          cache.New(6*time.Hour, 6*time.Hour),
-		eventBus:       config.EventBus(),
-		repository:     leaderboardRepo,
-		summaryService: summaryService,
-		userService:    userService,
-		queueDefault:   config.GetDefaultQueue(),
-		queueWorkers:   config.GetQueue(config.QueueProcessing),
+		config:                      config.Get(),
+		cache:                       cache.New(6*time.Hour, 6*time.Hour),
+		eventBus:                    config.EventBus(),
+		repository:                  leaderboardRepo,
+		seasonRepo:                  seasonRepo,
+		summaryService:              summaryService,
+		heartbeatService:            heartbeatService,
+		userService:                 userService,
+		notificationTemplateService: notificationTemplateService,
+		queueDefault:                config.GetDefaultQueue(),
+		queueWorkers:                config.GetQueue(config.QueueProcessing),
 	}
 
-	scope, err := helpers.ParseInterval(srv.config.App.LeaderboardScope)
+	scopeKey := srv.config.App.LeaderboardScope
+	if srv.config.Seasons.Enabled() {
+		scopeKey = srv.config.Seasons.GetScope() // live leaderboard is reset with every new season
+	}
+	scope, err := helpers.ParseInterval(scopeKey)
 	if err != nil {
 		config.Log().Fatal(err.Error())
 	}
@@ -84,22 +95,35 @@ func (srv *LeaderboardService) Schedule() {
 	slog.Info("scheduling leaderboard generation")
 
 	generate := func() {
-		var users []*models.User
-		var err error
+		users, err := srv.getLeaderboardUsers()
+		if err != nil {
+			config.Log().Error("failed to get users for leaderboard generation", "error", err)
+			return
+		}
+		if !srv.config.App.LeaderboardWebhooks {
+			srv.ComputeLeaderboard(users, srv.defaultScope, []uint8{models.SummaryLanguage})
+			return
+		}
 
-		if srv.config.App.IgnoreUserLeaderboardPreference {
-			users, err = srv.userService.GetAll()
-			config.Log().Info("generating leaderboard for all users", "userCount", len(users))
-		} else {
-			users, err = srv.userService.GetAllByLeaderboard(true)
-			config.Log().Info("generating leaderboard for some users", "userCount", len(users))
+		// compare ranks before and after to notify users about changes
+		ranksBefore, err := srv.getRanks(srv.defaultScope)
+		if err != nil {
+			config.Log().Error("failed to get leaderboard ranks before generation", "error", err)
+		}
+		srv.ComputeLeaderboard(users, srv.defaultScope, []uint8{models.SummaryLanguage})
+		if ranksBefore == nil {
+			return
 		}
 
+		ranksAfter, err := srv.getRanks(srv.defaultScope)
 		if err != nil {
-			config.Log().Error("failed to get users for leaderboard generation", "error", err)
+			config.Log().Error("failed to get leaderboard ranks after generation", "error", err)
 			return
 		}
-		srv.ComputeLeaderboard(users, srv.defaultScope, []uint8{models.SummaryLanguage})
+		srv.eventBus.Publish(hub.Message{
+			Name:   config.EventLeaderboardUpdate,
+			Fields: map[string]interface{}{config.FieldPayload: &models.LeaderboardRankChanges{Interval: (*srv.defaultScope)[0], Before: ranksBefore, After: ranksAfter}},
+		})
 	}
 
 	for _, cronExp := range srv.config.App.GetLeaderboardGenerationTimeCron() {
@@ -107,6 +131,19 @@ func (srv *LeaderboardService) Schedule() {
 			config.Log().Error("failed to schedule leaderboard generation", "cronExpression", cronExp, "error", err)
 		}
 	}
+
+	if srv.config.Seasons.Enabled() {
+		slog.Info("scheduling leaderboard seasons", "period", srv.config.Seasons.Period)
+
+		closeSeason := func() {
+			if _, err := srv.CloseSeason(); err != nil {
+				config.Log().Error("failed to close leaderboard season", "error", err)
+			}
+		}
+		if _, err := srv.queueDefault.DispatchCron(closeSeason, srv.config.Seasons.GetCron()); err != nil {
+			config.Log().Error("failed to schedule leaderboard season closing", "error", err)
+		}
+	}
 }
 
 func (srv *LeaderboardService) ComputeLeaderboard(users []*models.User, interval *models.IntervalKey, by []uint8) error {
@@ -118,6 +155,11 @@ func (srv *LeaderboardService) ComputeLeaderboard(users []*models.User, interval
 			continue
 		}
 
+		if eligible, reason := srv.IsEligible(user, interval); !eligible {
+			slog.Debug("excluding user from leaderboard", "userID", user.ID, "reason", reason)
+			continue
+		}
+
 		item, err := srv.GenerateByUser(user, interval)
 		if err != nil {
 			config.Log().Error("failed to generate general leaderboard for user", "userID", user.ID, "error", err)
@@ -152,6 +194,33 @@ func (srv *LeaderboardService) ComputeLeaderboard(users []*models.User, interval
 	return nil
 }
 
+func (srv *LeaderboardService) getLeaderboardUsers() ([]*models.User, error) {
+	var users []*models.User
+	var err error
+
+	if srv.config.App.IgnoreUserLeaderboardPreference {
+		users, err = srv.userService.GetAll()
+		config.Log().Info("generating leaderboard for all users", "userCount", len(users))
+	} else {
+		users, err = srv.userService.GetAllByLeaderboard(true)
+		config.Log().Info("generating leaderboard for some users", "userCount", len(users))
+	}
+	return users, err
+}
+
+// getRanks returns the rank of every user on the general (not aggregated) leaderboard of the given interval
+func (srv *LeaderboardService) getRanks(interval *models.IntervalKey) (map[string]int, error) {
+	items, err := srv.repository.GetAllAggregatedByInterval(interval, nil, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	ranks := make(map[string]int, len(items))
+	for _, item := range items {
+		ranks[item.UserID] = int(item.Rank)
+	}
+	return ranks, nil
+}
+
 func (srv *LeaderboardService) ExistsAnyByUser(userId string) (bool, error) {
 	count, err := srv.repository.CountAllByUser(userId)
 	return count > 0, err
@@ -236,18 +305,23 @@ func (srv *LeaderboardService) GetAggregatedByIntervalAndUser(interval *models.I
 }
 
 func (srv *LeaderboardService) GenerateByUser(user *models.User, interval *models.IntervalKey) (*models.LeaderboardItem, error) {
-	err, from, to := helpers.ResolveIntervalTZ(interval, user.TZ())
+	err, from, to := srv.resolveInterval(interval, user)
 	if err != nil {
 		return nil, err
 	}
 
-	summary, err := srv.summaryService.Aliased(from, to, user, srv.summaryService.Retrieve, nil, false)
+	summary, err := srv.summaryService.Aliased(from, to, user, srv.summaryService.Retrieve, srv.getSummaryFilters(), false)
 	if err != nil {
 		return nil, err
 	}
 
 	// exclude unknown language (will also exclude browsing time by chrome-wakatime plugin)
 	total := summary.TotalTime() - summary.TotalTimeByKey(models.SummaryLanguage, models.UnknownSummaryKey)
+	for _, l := range summary.Languages {
+		if l.Key != models.UnknownSummaryKey && srv.isExcludedLanguage(l.Key) {
+			total -= l.TotalFixed()
+		}
+	}
 	return &models.LeaderboardItem{
 		User:     user,
 		UserID:   user.ID,
@@ -257,12 +331,12 @@ func (srv *LeaderboardService) GenerateByUser(user *models.User, interval *model
 }
 
 func (srv *LeaderboardService) GenerateAggregatedByUser(user *models.User, interval *models.IntervalKey, by uint8) ([]*models.LeaderboardItem, error) {
-	err, from, to := helpers.ResolveIntervalTZ(interval, user.TZ())
+	err, from, to := srv.resolveInterval(interval, user)
 	if err != nil {
 		return nil, err
 	}
 
-	summary, err := srv.summaryService.Aliased(from, to, user, srv.summaryService.Retrieve, nil, false)
+	summary, err := srv.summaryService.Aliased(from, to, user, srv.summaryService.Retrieve, srv.getSummaryFilters(), false)
 	if err != nil {
 		return nil, err
 	}
@@ -275,6 +349,9 @@ func (srv *LeaderboardService) GenerateAggregatedByUser(user *models.User, inter
 		if item.Key == models.UnknownSummaryKey {
 			continue
 		}
+		if by == models.SummaryLanguage && srv.isExcludedLanguage(item.Key) {
+			continue
+		}
 
 		items = append(items, &models.LeaderboardItem{
 			User:     user,
@@ -289,6 +366,105 @@ func (srv *LeaderboardService) GenerateAggregatedByUser(user *models.User, inter
 	return items, nil
 }
 
+// IsEligible evaluates the admin-configured leaderboard eligibility rules for the given user and returns the reason if not eligible
+func (srv *LeaderboardService) IsEligible(user *models.User, interval *models.IntervalKey) (bool, string) {
+	if user.IsServiceAccount {
+		return false, "service account"
+	}
+
+	if user.IsSuspended() {
+		return false, "suspended"
+	}
+
+	if user.Deactivated {
+		return false, "deactivated"
+	}
+
+	if minAge := srv.config.App.LeaderboardMinAccountAgeDays; minAge > 0 && user.CreatedAt.T().After(time.Now().AddDate(0, 0, -minAge)) {
+		return false, "account too young"
+	}
+
+	if srv.config.App.LeaderboardRequireEmail && !user.HasTrustedEmail() {
+		return false, "no verified e-mail address"
+	}
+
+	if minDays := srv.config.App.LeaderboardMinActiveDays; minDays > 0 {
+		err, from, to := srv.resolveInterval(interval, user)
+		if err != nil {
+			return false, err.Error()
+		}
+
+		activeDays, err := srv.heartbeatService.CountActiveDaysByUser(user, utils.SplitRangeByDays(from, to), srv.getSummaryFilters())
+		if err != nil {
+			return false, err.Error()
+		}
+		if activeDays < int64(minDays) {
+			return false, "too few active days"
+		}
+	}
+
+	return true, ""
+}
+
+// resolveInterval resolves the given leaderboard interval in the user's time zone and, if backfilled time is to be excluded,
+// cuts off everything before the start of the current season
+func (srv *LeaderboardService) resolveInterval(interval *models.IntervalKey, user *models.User) (error, time.Time, time.Time) {
+	err, from, to := helpers.ResolveIntervalTZ(interval, user.TZ())
+	if err != nil || !srv.config.App.LeaderboardExcludeBackfilled || !srv.config.Seasons.Enabled() {
+		return err, from, to
+	}
+
+	// seasons are closed in server-local time, see CloseSeason
+	err, seasonStart, _ := helpers.ResolveIntervalRawTZ(srv.config.Seasons.GetScope(), time.Local)
+	if err != nil {
+		return err, from, to
+	}
+	if from.Before(seasonStart) {
+		from = seasonStart
+	}
+	if to.Before(from) {
+		to = from
+	}
+	return nil, from, to
+}
+
+// getSummaryFilters restricts leaderboard totals to the configured heartbeat sources and categories, e.g. to not rank imported history or code reviews
+func (srv *LeaderboardService) getSummaryFilters() *models.Filters {
+	var filters *models.Filters
+	if categories := srv.config.App.GetLeaderboardCategories(); len(categories) > 0 {
+		filters = (&models.Filters{}).WithMultiple(models.SummaryCategory, categories)
+	}
+
+	sources := srv.config.App.GetLeaderboardSources()
+	if srv.config.App.LeaderboardExcludeBackfilled || srv.config.App.LeaderboardExcludeManual {
+		if len(sources) == 0 {
+			sources = models.HeartbeatSources()
+		}
+		sources = slice.Filter[string](sources, func(i int, s string) bool {
+			if srv.config.App.LeaderboardExcludeManual && (s == models.HeartbeatSourceManual || s == models.HeartbeatSourceExternal) {
+				return false
+			}
+			return !srv.config.App.LeaderboardExcludeBackfilled || (s != models.HeartbeatSourceImport && s != models.HeartbeatSourceBackfill)
+		})
+	}
+	if len(sources) > 0 {
+		if filters == nil {
+			filters = &models.Filters{}
+		}
+		filters = filters.WithSources(sources)
+	}
+	return filters
+}
+
+func (srv *LeaderboardService) isExcludedLanguage(language string) bool {
+	for _, l := range srv.config.App.GetLeaderboardExcludedLanguages() {
+		if strings.ToLower(language) == l {
+			return true
+		}
+	}
+	return false
+}
+
 func (srv *LeaderboardService) getHash(interval *models.IntervalKey, by *uint8, user string, pageParams *utils.PageParams) string {
 	k := strings.Join(*interval, "__") + "__" + user
 	if by != nil && !reflect.ValueOf(by).IsNil() {
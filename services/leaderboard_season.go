@@ -0,0 +1,246 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/utils"
+	"gorm.io/gorm"
+)
+
+const webhookSignatureHeader = "X-Hackatime-Signature"
+
+var webhookHttpClient = &http.Client{Timeout: 30 * time.Second}
+
+type seasonWinnerPayload struct {
+	Rank         uint   `json:"rank"`
+	UserID       string `json:"user_id"`
+	TotalSeconds int64  `json:"total_seconds"`
+}
+
+type seasonWebhookPayload struct {
+	Season  string                 `json:"season"`
+	Period  string                 `json:"period"`
+	From    time.Time              `json:"from"`
+	To      time.Time              `json:"to"`
+	Winners []*seasonWinnerPayload `json:"winners"`
+}
+
+// CloseSeason archives the final standings of the season that just ended, resets the live leaderboard and announces the winners
+// closing a season twice is a no-op, so it's safe to call it again, e.g. after a failed attempt
+func (srv *LeaderboardService) CloseSeason() (*models.LeaderboardSeason, error) {
+	interval := models.IntervalLastWeek
+	if srv.config.Seasons.Period == config.SeasonPeriodMonthly {
+		interval = models.IntervalLastMonth
+	}
+
+	err, from, to := helpers.ResolveIntervalTZ(interval, time.Local)
+	if err != nil {
+		return nil, err
+	}
+
+	name := models.SeasonName(srv.config.Seasons.Period, from)
+	if existing, err := srv.seasonRepo.GetByName(name); err == nil {
+		slog.Info("leaderboard season already closed", "season", name)
+		return existing, nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	slog.Info("closing leaderboard season", "season", name)
+
+	users, err := srv.getLeaderboardUsers()
+	if err != nil {
+		return nil, err
+	}
+
+	// compute the finished season's leaderboard just like a regular one and archive it afterward
+	if err := srv.ComputeLeaderboard(users, interval, []uint8{}); err != nil {
+		return nil, err
+	}
+	items, err := srv.repository.GetAllAggregatedByInterval(interval, nil, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	season := &models.LeaderboardSeason{
+		Name:     name,
+		Period:   srv.config.Seasons.Period,
+		FromTime: models.CustomTime(from),
+		ToTime:   models.CustomTime(to),
+	}
+	standings := make([]*models.LeaderboardSeasonStanding, 0, len(items))
+	for _, item := range items {
+		if item.Total <= 0 {
+			continue
+		}
+		standings = append(standings, &models.LeaderboardSeasonStanding{
+			UserID: item.UserID,
+			Rank:   item.Rank,
+			Total:  item.Total,
+		})
+	}
+
+	if err := srv.seasonRepo.Insert(season, standings); err != nil {
+		return nil, err
+	}
+	if err := srv.repository.DeleteByInterval(interval); err != nil {
+		config.Log().Error("failed to clean up temporary season leaderboard", "season", name, "error", err)
+	}
+
+	// start over with the new season right away instead of waiting for the next scheduled generation
+	srv.ComputeLeaderboard(users, srv.defaultScope, []uint8{models.SummaryLanguage})
+
+	srv.notifySeasonWinners(season, standings)
+
+	return season, nil
+}
+
+func (srv *LeaderboardService) GetSeasons() ([]*models.LeaderboardSeason, error) {
+	return srv.seasonRepo.GetAll()
+}
+
+func (srv *LeaderboardService) GetSeasonStandings(name string, pageParams *utils.PageParams, resolveUsers bool) (*models.LeaderboardSeason, []*models.LeaderboardSeasonStanding, error) {
+	season, err := srv.seasonRepo.GetByName(name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	standings, err := srv.seasonRepo.GetStandings(season.ID, pageParams.Limit(), pageParams.Offset())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resolveUsers {
+		userIds := make([]string, len(standings))
+		for i, s := range standings {
+			userIds[i] = s.UserID
+		}
+		users, err := srv.userService.GetManyMapped(userIds)
+		if err != nil {
+			config.Log().Error("failed to resolve users for season standings", "error", err)
+		} else {
+			for _, s := range standings {
+				s.User = users[s.UserID]
+			}
+		}
+	}
+
+	return season, standings, nil
+}
+
+func (srv *LeaderboardService) notifySeasonWinners(season *models.LeaderboardSeason, standings []*models.LeaderboardSeasonStanding) {
+	winners := make([]*models.LeaderboardSeasonStanding, 0, srv.config.Seasons.NumWinners)
+	for _, s := range standings {
+		if int(s.Rank) > srv.config.Seasons.NumWinners {
+			break
+		}
+		winners = append(winners, s)
+	}
+
+	if url := srv.config.Seasons.WebhookUrl; url != "" {
+		if err := sendSeasonWebhook(url, srv.config.Seasons.WebhookSecret, season, winners); err != nil {
+			config.Log().Error("failed to send season webhook", "season", season.Name, "error", err)
+		}
+	}
+
+	if url := srv.config.Seasons.SlackWebhookUrl; url != "" {
+		if err := sendSeasonSlackMessage(url, srv.seasonSlackText(season, winners)); err != nil {
+			config.Log().Error("failed to post season winners to slack", "season", season.Name, "error", err)
+		}
+	}
+}
+
+func sendSeasonWebhook(url, secret string, season *models.LeaderboardSeason, winners []*models.LeaderboardSeasonStanding) error {
+	payload := &seasonWebhookPayload{
+		Season:  season.Name,
+		Period:  season.Period,
+		From:    season.FromTime.T(),
+		To:      season.ToTime.T(),
+		Winners: make([]*seasonWinnerPayload, len(winners)),
+	}
+	for i, w := range winners {
+		payload.Winners[i] = &seasonWinnerPayload{Rank: w.Rank, UserID: w.UserID, TotalSeconds: int64(w.Total.Seconds())}
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return postWebhookJson(url, data, signedWebhookHeaders(secret, data))
+}
+
+// seasonSlackText renders the operator's template for season winners, if any, and falls back to the built-in text otherwise
+func (srv *LeaderboardService) seasonSlackText(season *models.LeaderboardSeason, winners []*models.LeaderboardSeasonStanding) string {
+	custom, err := srv.notificationTemplateService.Render(models.NotificationKindSeasonWinners, models.NotificationChannelSlack, models.NewSeasonWinnersNotification(season, winners))
+	if err != nil {
+		config.Log().Error("failed to render custom notification template, using built-in one", "kind", models.NotificationKindSeasonWinners, "error", err)
+	}
+	if custom != nil && custom.Body != "" {
+		return custom.Body
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf(":trophy: Leaderboard season *%s* is over!", season.Name))
+	if len(winners) == 0 {
+		sb.WriteString(" Nobody coded this time.")
+	}
+	for _, w := range winners {
+		sb.WriteString(fmt.Sprintf("\n%d. *%s* – %s", w.Rank, w.UserID, helpers.FmtWakatimeDuration(w.Total)))
+	}
+	return sb.String()
+}
+
+func sendSeasonSlackMessage(url, text string) error {
+	data, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	return postWebhookJson(url, data, nil)
+}
+
+// signedWebhookHeaders returns the hmac-sha256 signature header for the given payload, if a secret is set
+func signedWebhookHeaders(secret string, data []byte) map[string]string {
+	headers := map[string]string{}
+	if secret != "" {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(data)
+		headers[webhookSignatureHeader] = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	return headers
+}
+
+func postWebhookJson(url string, data []byte, headers map[string]string) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := webhookHttpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", res.StatusCode)
+	}
+	return nil
+}
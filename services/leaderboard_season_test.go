@@ -0,0 +1,87 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeasonName(t *testing.T) {
+	from := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, "2024-W03", models.SeasonName(config.SeasonPeriodWeekly, from))
+	assert.Equal(t, "2024-01", models.SeasonName(config.SeasonPeriodMonthly, from))
+}
+
+func TestSendSeasonWebhook(t *testing.T) {
+	var body []byte
+	var signature string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		signature = r.Header.Get(webhookSignatureHeader)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	season := &models.LeaderboardSeason{
+		Name:     "2024-W03",
+		Period:   config.SeasonPeriodWeekly,
+		FromTime: models.CustomTime(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)),
+		ToTime:   models.CustomTime(time.Date(2024, 1, 22, 0, 0, 0, 0, time.UTC)),
+	}
+	winners := []*models.LeaderboardSeasonStanding{
+		{UserID: "alice", Rank: 1, Total: 10 * time.Hour},
+		{UserID: "bob", Rank: 2, Total: 90 * time.Minute},
+	}
+
+	assert.Nil(t, sendSeasonWebhook(server.URL, "secret", season, winners))
+
+	var payload seasonWebhookPayload
+	assert.Nil(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, "2024-W03", payload.Season)
+	assert.Len(t, payload.Winners, 2)
+	assert.Equal(t, "alice", payload.Winners[0].UserID)
+	assert.Equal(t, int64(36000), payload.Winners[0].TotalSeconds)
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write(body)
+	assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), signature)
+}
+
+func TestLeaderboardService_ExcludeBackfilled(t *testing.T) {
+	cfg := config.Empty()
+	cfg.App.LeaderboardExcludeBackfilled = true
+	cfg.Seasons.Period = config.SeasonPeriodMonthly
+	sut := &LeaderboardService{config: cfg}
+	user := &models.User{ID: "alice", Location: "UTC"}
+
+	assert.Equal(t, models.OrFilter{models.HeartbeatSourcePlugin, models.HeartbeatSourceSynthesized, models.HeartbeatSourceManual, models.HeartbeatSourceExternal}, sut.getSummaryFilters().Source)
+
+	cfg.App.LeaderboardExcludeManual = true
+	assert.Equal(t, models.OrFilter{models.HeartbeatSourcePlugin, models.HeartbeatSourceSynthesized}, sut.getSummaryFilters().Source)
+	cfg.App.LeaderboardExcludeManual = false
+
+	err, from, _ := sut.resolveInterval(models.IntervalPast12Months, user)
+	assert.Nil(t, err)
+	_, seasonStart, _ := helpers.ResolveIntervalRawTZ("month", time.Local)
+	assert.True(t, from.Equal(seasonStart))
+
+	cfg.App.LeaderboardSources = "plugin,import"
+	assert.Equal(t, models.OrFilter{models.HeartbeatSourcePlugin}, sut.getSummaryFilters().Source)
+
+	cfg.App.LeaderboardExcludeBackfilled = false
+	cfg.App.LeaderboardSources = ""
+	assert.Nil(t, sut.getSummaryFilters())
+	_, from, _ = sut.resolveInterval(models.IntervalPast12Months, user)
+	assert.True(t, from.Before(seasonStart))
+}
@@ -0,0 +1,68 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLeaderboardService_IsEligible(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Security.RequireEmailVerification = true
+	cfg.App.LeaderboardMinAccountAgeDays = 7
+	cfg.App.LeaderboardRequireEmail = true
+	cfg.App.LeaderboardMinActiveDays = 3
+	config.Set(cfg)
+
+	suspendedAt := models.CustomTime(time.Now())
+	createdAt := models.CustomTime(time.Now().AddDate(0, 0, -30))
+	recentlyCreatedAt := models.CustomTime(time.Now().AddDate(0, 0, -2))
+
+	verified := func(u *models.User) *models.User {
+		u.CreatedAt, u.Email, u.EmailVerified = createdAt, "user@example.org", true
+		return u
+	}
+
+	tests := []struct {
+		name       string
+		user       *models.User
+		activeDays int64
+		eligible   bool
+		reason     string
+	}{
+		{"regular user", verified(&models.User{ID: TestUserId}), 5, true, ""},
+		{"service account", verified(&models.User{ID: TestUserId, IsServiceAccount: true}), 5, false, "service account"},
+		{"suspended", verified(&models.User{ID: TestUserId, SuspendedAt: &suspendedAt}), 5, false, "suspended"},
+		{"deactivated", verified(&models.User{ID: TestUserId, Deactivated: true}), 5, false, "deactivated"},
+		{"account too young", &models.User{ID: TestUserId, CreatedAt: recentlyCreatedAt, Email: "user@example.org", EmailVerified: true}, 5, false, "account too young"},
+		{"no e-mail address", &models.User{ID: TestUserId, CreatedAt: createdAt}, 5, false, "no verified e-mail address"},
+		{"unverified e-mail address", &models.User{ID: TestUserId, CreatedAt: createdAt, Email: "user@example.org"}, 5, false, "no verified e-mail address"},
+		{"too few active days", verified(&models.User{ID: TestUserId}), 2, false, "too few active days"},
+		{"just enough active days", verified(&models.User{ID: TestUserId}), 3, true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			heartbeatService := new(mocks.HeartbeatServiceMock)
+			heartbeatService.On("CountActiveDaysByUser", tt.user, mock.Anything, mock.Anything).Return(tt.activeDays, nil)
+
+			sut := &LeaderboardService{config: config.Get(), heartbeatService: heartbeatService}
+
+			eligible, reason := sut.IsEligible(tt.user, models.IntervalPast7Days)
+			assert.Equal(t, tt.eligible, eligible)
+			assert.Equal(t, tt.reason, reason)
+
+			if tt.eligible {
+				// one query for the whole interval, one bucket per day
+				heartbeatService.AssertNumberOfCalls(t, "CountActiveDaysByUser", 1)
+				days := heartbeatService.Calls[0].Arguments.Get(1).([][]time.Time)
+				assert.GreaterOrEqual(t, len(days), 7)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/leandro-lugaresi/hub"
+	"github.com/muety/artifex/v2"
+)
+
+var ErrLeaderboardWebhookInvalid = errors.New("invalid leaderboard webhook")
+
+// LeaderboardWebhookService notifies users via their webhooks about changes of their rank, whenever the live leaderboard was re-generated
+type LeaderboardWebhookService struct {
+	config     *config.Config
+	eventBus   *hub.Hub
+	queue      *artifex.Dispatcher
+	repository repositories.ILeaderboardWebhookRepository
+}
+
+func NewLeaderboardWebhookService(leaderboardWebhookRepository repositories.ILeaderboardWebhookRepository) *LeaderboardWebhookService {
+	srv := &LeaderboardWebhookService{
+		config:     config.Get(),
+		eventBus:   config.EventBus(),
+		queue:      config.GetDefaultQueue(),
+		repository: leaderboardWebhookRepository,
+	}
+
+	onLeaderboardUpdate := srv.eventBus.Subscribe(0, config.EventLeaderboardUpdate)
+	go func(sub *hub.Subscription) {
+		for m := range sub.Receiver {
+			changes := m.Fields[config.FieldPayload].(*models.LeaderboardRankChanges)
+			if err := srv.queue.Dispatch(func() {
+				srv.Notify(changes)
+			}); err != nil {
+				config.Log().Error("failed to dispatch leaderboard webhooks", "error", err)
+			}
+		}
+	}(&onLeaderboardUpdate)
+
+	return srv
+}
+
+func (srv *LeaderboardWebhookService) GetByUser(userId string) (*models.LeaderboardWebhook, error) {
+	return srv.repository.GetByUser(userId)
+}
+
+func (srv *LeaderboardWebhookService) Set(webhook *models.LeaderboardWebhook) (*models.LeaderboardWebhook, error) {
+	webhook.Url = strings.TrimSpace(webhook.Url)
+	if webhook.Format == "" {
+		webhook.Format = models.LeaderboardWebhookFormatJson
+	}
+	if !webhook.IsValid() {
+		return nil, fmt.Errorf("%w: https url, format json or slack and at least one kind of event required", ErrLeaderboardWebhookInvalid)
+	}
+	if err := srv.repository.Upsert(webhook); err != nil {
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (srv *LeaderboardWebhookService) Delete(userId string) error {
+	return srv.repository.DeleteByUser(userId)
+}
+
+// Notify calls every webhook with the events it is interested in, one request per event
+func (srv *LeaderboardWebhookService) Notify(changes *models.LeaderboardRankChanges) {
+	webhooks, err := srv.repository.GetAll()
+	if err != nil {
+		config.Log().Error("failed to get leaderboard webhooks", "error", err)
+		return
+	}
+
+	for _, w := range webhooks {
+		for _, event := range changes.EventsFor(w) {
+			slog.Info("calling leaderboard webhook", "userID", w.UserID, "event", event.Event, "oldRank", event.OldRank, "newRank", event.NewRank)
+			if err := sendLeaderboardWebhook(w, event); err != nil {
+				config.Log().Warn("failed to call leaderboard webhook", "userID", w.UserID, "event", event.Event, "error", err)
+			}
+		}
+	}
+}
+
+func sendLeaderboardWebhook(w *models.LeaderboardWebhook, event *models.LeaderboardRankEvent) error {
+	if w.Format == models.LeaderboardWebhookFormatSlack {
+		return sendSeasonSlackMessage(w.Url, leaderboardSlackText(event))
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	return postWebhookJson(w.Url, data, signedWebhookHeaders(w.Secret, data))
+}
+
+func leaderboardSlackText(event *models.LeaderboardRankEvent) string {
+	if event.Event == models.LeaderboardEventOvertaken {
+		return fmt.Sprintf(":racing_car: *%s* was overtaken on the leaderboard by %s and is now ranked #%d (was #%d).", event.UserID, strings.Join(event.OvertakenBy, ", "), event.NewRank, event.OldRank)
+	}
+	if event.NewRank < event.OldRank {
+		return fmt.Sprintf(":chart_with_upwards_trend: *%s* climbed %d positions on the leaderboard and is now ranked #%d.", event.UserID, event.OldRank-event.NewRank, event.NewRank)
+	}
+	return fmt.Sprintf(":chart_with_downwards_trend: *%s* dropped %d positions on the leaderboard and is now ranked #%d.", event.UserID, event.NewRank-event.OldRank, event.NewRank)
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/patrickmn/go-cache"
+)
+
+const (
+	LegalDocumentTerms   = "terms"
+	LegalDocumentPrivacy = "privacy"
+)
+
+var ErrLegalDocumentNotFound = errors.New("no such legal document configured")
+
+// LegalService keeps track of which version of the instance's terms of service and privacy policy users have accepted
+type LegalService struct {
+	config     *config.Config
+	cache      *cache.Cache
+	repository repositories.ILegalConsentRepository
+}
+
+func NewLegalService(legalConsentRepo repositories.ILegalConsentRepository) *LegalService {
+	return &LegalService{
+		config:     config.Get(),
+		cache:      cache.New(1*time.Hour, 1*time.Hour),
+		repository: legalConsentRepo,
+	}
+}
+
+// RequiresConsent returns whether the user still has to accept the current version of the terms
+func (srv *LegalService) RequiresConsent(user *models.User) (bool, error) {
+	if !srv.config.Legal.RequiresConsent() || user.IsServiceAccount {
+		return false, nil
+	}
+
+	cacheKey := srv.cacheKey(user.ID)
+	if _, ok := srv.cache.Get(cacheKey); ok {
+		return false, nil
+	}
+
+	count, err := srv.repository.CountByUserAndVersion(user.ID, srv.config.Legal.Version)
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		srv.cache.SetDefault(cacheKey, true)
+	}
+	return count == 0, nil
+}
+
+func (srv *LegalService) Accept(user *models.User, ip string) error {
+	if !srv.config.Legal.RequiresConsent() {
+		return nil
+	}
+
+	if err := srv.repository.Insert(&models.LegalConsent{
+		UserID:     user.ID,
+		Version:    srv.config.Legal.Version,
+		Ip:         ip,
+		AcceptedAt: models.CustomTime(time.Now()),
+	}); err != nil {
+		return err
+	}
+
+	srv.cache.SetDefault(srv.cacheKey(user.ID), true)
+	config.Log().Info("user accepted legal terms", "userID", user.ID, "version", srv.config.Legal.Version)
+	return nil
+}
+
+// GetDocument returns the (html) content of the given legal document, re-read from disk every time, so that it can be updated without a restart
+func (srv *LegalService) GetDocument(name string) (string, error) {
+	var path string
+	switch name {
+	case LegalDocumentTerms:
+		path = srv.config.Legal.TermsFile
+	case LegalDocumentPrivacy:
+		path = srv.config.Legal.PrivacyFile
+	}
+	if path == "" {
+		return "", ErrLegalDocumentNotFound
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (srv *LegalService) cacheKey(userId string) string {
+	return fmt.Sprintf("%s_%s", userId, srv.config.Legal.Version)
+}
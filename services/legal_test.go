@@ -0,0 +1,118 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLegalService_Accept(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Legal.Version = "2026-01"
+	config.Set(cfg)
+
+	user := &models.User{ID: TestUserId}
+
+	repo := new(mocks.LegalConsentRepositoryMock)
+	repo.On("CountByUserAndVersion", user.ID, "2026-01").Return(int64(0), nil).Once()
+	repo.On("Insert", mock.Anything).Return(nil)
+
+	sut := NewLegalService(repo)
+
+	requiresConsent, err := sut.RequiresConsent(user)
+	assert.Nil(t, err)
+	assert.True(t, requiresConsent)
+
+	assert.Nil(t, sut.Accept(user, "127.0.0.1"))
+	consent := repo.Calls[len(repo.Calls)-1].Arguments.Get(0).(*models.LegalConsent)
+	assert.Equal(t, user.ID, consent.UserID)
+	assert.Equal(t, "2026-01", consent.Version)
+	assert.Equal(t, "127.0.0.1", consent.Ip)
+
+	// served from cache afterward
+	requiresConsent, err = sut.RequiresConsent(user)
+	assert.Nil(t, err)
+	assert.False(t, requiresConsent)
+	repo.AssertNumberOfCalls(t, "CountByUserAndVersion", 1)
+}
+
+func TestLegalService_Accept_VersionBump(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Legal.Version = "2026-01"
+	config.Set(cfg)
+
+	user := &models.User{ID: TestUserId}
+
+	repo := new(mocks.LegalConsentRepositoryMock)
+	repo.On("CountByUserAndVersion", user.ID, "2026-01").Return(int64(1), nil)
+	repo.On("CountByUserAndVersion", user.ID, "2026-06").Return(int64(0), nil).Once()
+	repo.On("Insert", mock.Anything).Return(nil)
+
+	sut := NewLegalService(repo)
+
+	requiresConsent, err := sut.RequiresConsent(user)
+	assert.Nil(t, err)
+	assert.False(t, requiresConsent)
+
+	// updated terms have to be accepted again
+	cfg.Legal.Version = "2026-06"
+	requiresConsent, err = sut.RequiresConsent(user)
+	assert.Nil(t, err)
+	assert.True(t, requiresConsent)
+
+	assert.Nil(t, sut.Accept(user, "127.0.0.1"))
+	assert.Equal(t, "2026-06", repo.Calls[len(repo.Calls)-1].Arguments.Get(0).(*models.LegalConsent).Version)
+
+	requiresConsent, err = sut.RequiresConsent(user)
+	assert.Nil(t, err)
+	assert.False(t, requiresConsent)
+}
+
+func TestLegalService_Accept_Failed(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Legal.Version = "2026-01"
+	config.Set(cfg)
+
+	user := &models.User{ID: TestUserId}
+
+	repo := new(mocks.LegalConsentRepositoryMock)
+	repo.On("CountByUserAndVersion", user.ID, "2026-01").Return(int64(0), nil)
+	repo.On("Insert", mock.Anything).Return(errors.New("database is locked"))
+
+	sut := NewLegalService(repo)
+
+	assert.Error(t, sut.Accept(user, "127.0.0.1"))
+
+	// still blocked until consent was actually recorded
+	requiresConsent, err := sut.RequiresConsent(user)
+	assert.Nil(t, err)
+	assert.True(t, requiresConsent)
+}
+
+func TestLegalService_RequiresConsent_Disabled(t *testing.T) {
+	config.Set(config.Empty())
+
+	repo := new(mocks.LegalConsentRepositoryMock)
+	sut := NewLegalService(repo)
+
+	requiresConsent, err := sut.RequiresConsent(&models.User{ID: TestUserId})
+	assert.Nil(t, err)
+	assert.False(t, requiresConsent)
+	assert.Nil(t, sut.Accept(&models.User{ID: TestUserId}, "127.0.0.1"))
+
+	cfg := config.Empty()
+	cfg.Legal.Version = "2026-01"
+	config.Set(cfg)
+
+	requiresConsent, err = NewLegalService(repo).RequiresConsent(&models.User{ID: "service", IsServiceAccount: true})
+	assert.Nil(t, err)
+	assert.False(t, requiresConsent)
+
+	repo.AssertNotCalled(t, "CountByUserAndVersion", mock.Anything, mock.Anything)
+	repo.AssertNotCalled(t, "Insert", mock.Anything)
+}
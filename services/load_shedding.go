@@ -0,0 +1,153 @@
+package services
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/leandro-lugaresi/hub"
+)
+
+const (
+	ShedReasonRejected    = "rejected"    // expensive endpoint refused right away
+	ShedReasonUnavailable = "unavailable" // request failed downstream, e.g. because a summary wasn't cached
+	ShedReasonSpooled     = "spooled"     // heartbeats held back to be written later
+)
+
+const spoolFlushBatchSize = 1000
+
+var ErrDegraded = errors.New("service temporarily degraded, please try again later")
+
+// LoadSheddingService monitors database latency and job queue depth and switches the application into a degraded mode, while any of them exceeds its threshold
+type LoadSheddingService struct {
+	config           *config.Config
+	eventBus         *hub.Hub
+	heartbeatSrvc    IHeartbeatService
+	measureLatency   func() (time.Duration, error)
+	countPendingJobs func() int
+	degraded         atomic.Bool
+	spool            []*models.Heartbeat
+	spoolLock        sync.Mutex
+	shedCounts       map[string]int64
+	shedCountsLock   sync.RWMutex
+}
+
+func NewLoadSheddingService(heartbeatService IHeartbeatService, metricsRepo *repositories.MetricsRepository) *LoadSheddingService {
+	return &LoadSheddingService{
+		config:           config.Get(),
+		eventBus:         config.EventBus(),
+		heartbeatSrvc:    heartbeatService,
+		measureLatency:   metricsRepo.MeasureLatency,
+		countPendingJobs: config.CountPendingJobs,
+		spool:            make([]*models.Heartbeat, 0),
+		shedCounts:       map[string]int64{},
+	}
+}
+
+func (srv *LoadSheddingService) Schedule() {
+	if !srv.config.LoadShedding.Enabled {
+		return
+	}
+
+	slog.Info("monitoring load for load shedding", "maxDbLatencyMs", srv.config.LoadShedding.MaxDbLatencyMs, "maxQueueDepth", srv.config.LoadShedding.MaxQueueDepth)
+
+	// not using the job queues here, because the check must not get stuck behind piled up jobs
+	ticker := time.NewTicker(time.Duration(srv.config.LoadShedding.CheckInterval) * time.Second)
+	for range ticker.C {
+		srv.Evaluate()
+	}
+}
+
+// Evaluate checks the current load and enters or leaves degraded mode accordingly, spooled heartbeats are flushed once the pressure is gone
+func (srv *LoadSheddingService) Evaluate() {
+	latency, err := srv.measureLatency()
+	pending := srv.countPendingJobs()
+	overloaded := err != nil ||
+		latency > time.Duration(srv.config.LoadShedding.MaxDbLatencyMs)*time.Millisecond ||
+		pending > srv.config.LoadShedding.MaxQueueDepth
+
+	if wasDegraded := srv.degraded.Swap(overloaded); wasDegraded != overloaded {
+		if overloaded {
+			slog.Warn("entering degraded mode due to high load", "dbLatency", latency, "pendingJobs", pending, "error", err)
+			srv.eventBus.Publish(hub.Message{Name: config.EventLoadSheddingStart})
+		} else {
+			slog.Info("leaving degraded mode", "dbLatency", latency, "pendingJobs", pending)
+			srv.eventBus.Publish(hub.Message{Name: config.EventLoadSheddingStop})
+		}
+	}
+
+	if !overloaded {
+		if err := srv.FlushSpool(); err != nil {
+			config.Log().Error("failed to flush spooled heartbeats", "error", err)
+		}
+	}
+}
+
+func (srv *LoadSheddingService) IsDegraded() bool {
+	return srv.degraded.Load()
+}
+
+func (srv *LoadSheddingService) RetryAfter() time.Duration {
+	return time.Duration(srv.config.LoadShedding.RetryAfterSec) * time.Second
+}
+
+// Spool holds back heartbeats to be inserted once the load has decreased, fails if the spool is full
+func (srv *LoadSheddingService) Spool(heartbeats []*models.Heartbeat) error {
+	srv.spoolLock.Lock()
+	defer srv.spoolLock.Unlock()
+
+	if len(srv.spool)+len(heartbeats) > srv.config.LoadShedding.MaxSpoolSize {
+		return ErrDegraded
+	}
+	srv.spool = append(srv.spool, heartbeats...)
+	srv.RecordShed(ShedReasonSpooled, len(heartbeats))
+	return nil
+}
+
+func (srv *LoadSheddingService) FlushSpool() error {
+	srv.spoolLock.Lock()
+	defer srv.spoolLock.Unlock()
+
+	if len(srv.spool) == 0 {
+		return nil
+	}
+
+	slog.Info("flushing spooled heartbeats", "count", len(srv.spool))
+
+	for len(srv.spool) > 0 {
+		n := min(len(srv.spool), spoolFlushBatchSize)
+		if err := srv.heartbeatSrvc.InsertBatch(srv.spool[:n]); err != nil {
+			return err // remaining heartbeats stay in the spool and are retried with the next check
+		}
+		srv.spool = srv.spool[n:]
+	}
+	return nil
+}
+
+func (srv *LoadSheddingService) CountSpooled() int {
+	srv.spoolLock.Lock()
+	defer srv.spoolLock.Unlock()
+	return len(srv.spool)
+}
+
+func (srv *LoadSheddingService) RecordShed(reason string, n int) {
+	srv.shedCountsLock.Lock()
+	defer srv.shedCountsLock.Unlock()
+	srv.shedCounts[reason] += int64(n)
+}
+
+func (srv *LoadSheddingService) GetShedCounts() map[string]int64 {
+	srv.shedCountsLock.RLock()
+	defer srv.shedCountsLock.RUnlock()
+
+	counts := make(map[string]int64, len(srv.shedCounts))
+	for k, v := range srv.shedCounts {
+		counts[k] = v
+	}
+	return counts
+}
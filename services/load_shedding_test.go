@@ -0,0 +1,59 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLoadSheddingService_Evaluate(t *testing.T) {
+	cfg := config.Empty()
+	cfg.LoadShedding.MaxDbLatencyMs = 100
+	cfg.LoadShedding.MaxQueueDepth = 10
+	cfg.LoadShedding.MaxSpoolSize = 3
+	config.Set(cfg)
+
+	heartbeatService := new(mocks.HeartbeatServiceMock)
+	heartbeatService.On("InsertBatch", mock.Anything).Return(nil)
+
+	var latency time.Duration
+	var latencyErr error
+	var pending int
+
+	sut := NewLoadSheddingService(heartbeatService, nil)
+	sut.measureLatency = func() (time.Duration, error) { return latency, latencyErr }
+	sut.countPendingJobs = func() int { return pending }
+
+	sut.Evaluate()
+	assert.False(t, sut.IsDegraded())
+
+	latency = 500 * time.Millisecond
+	sut.Evaluate()
+	assert.True(t, sut.IsDegraded())
+
+	assert.Nil(t, sut.Spool([]*models.Heartbeat{{}, {}}))
+	assert.ErrorIs(t, sut.Spool([]*models.Heartbeat{{}, {}}), ErrDegraded)
+	assert.Equal(t, 2, sut.CountSpooled())
+	assert.Equal(t, int64(2), sut.GetShedCounts()[ShedReasonSpooled])
+
+	latency, pending = 0, 20
+	sut.Evaluate()
+	assert.True(t, sut.IsDegraded())
+
+	pending, latencyErr = 0, errors.New("connection refused")
+	sut.Evaluate()
+	assert.True(t, sut.IsDegraded())
+	heartbeatService.AssertNotCalled(t, "InsertBatch", mock.Anything)
+
+	latencyErr = nil
+	sut.Evaluate()
+	assert.False(t, sut.IsDegraded())
+	assert.Equal(t, 0, sut.CountSpooled())
+	heartbeatService.AssertNumberOfCalls(t, "InsertBatch", 1)
+}
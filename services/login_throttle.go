@@ -0,0 +1,152 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/leandro-lugaresi/hub"
+	"github.com/patrickmn/go-cache"
+)
+
+const maxLoginLockoutExponent = 20
+
+type loginFailures struct {
+	count       int
+	lockedUntil time.Time
+}
+
+// LoginThrottleService counts failed logins per client ip and per account and locks out either of them for exponentially growing durations,
+// independent of the request rate limit, so credential stuffing gets expensive even when spread over time
+type LoginThrottleService struct {
+	config   *config.Config
+	eventBus *hub.Hub
+	cache    *cache.Cache
+	lock     sync.Mutex
+}
+
+func NewLoginThrottleService() *LoginThrottleService {
+	return &LoginThrottleService{
+		config:   config.Get(),
+		eventBus: config.EventBus(),
+		cache:    cache.New(cache.NoExpiration, 10*time.Minute),
+	}
+}
+
+// GetLockout returns for how much longer logins from the given ip or to the given account are rejected
+func (srv *LoginThrottleService) GetLockout(ip, account string) time.Duration {
+	var remaining time.Duration
+	for _, f := range srv.getFailures(ip, account) {
+		if d := time.Until(f.lockedUntil); d > remaining {
+			remaining = d
+		}
+	}
+	return remaining
+}
+
+// RequiresCaptcha tells whether a captcha has to be solved before the next login attempt from the given ip or to the given account is checked
+func (srv *LoginThrottleService) RequiresCaptcha(ip, account string) bool {
+	threshold := srv.config.Security.LoginCaptchaThreshold
+	if threshold <= 0 {
+		return false
+	}
+	for _, f := range srv.getFailures(ip, account) {
+		if f.count >= threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// RegisterFailure counts a failed attempt and returns the resulting lockout duration, if any
+func (srv *LoginThrottleService) RegisterFailure(attempt *models.LoginAttempt) time.Duration {
+	srv.lock.Lock()
+	var lockout time.Duration
+	for _, key := range srv.keys(attempt.Ip, srv.accountOf(attempt)) {
+		f := &loginFailures{}
+		if cached, ok := srv.cache.Get(key); ok {
+			f = cached.(*loginFailures)
+		}
+		f.count++
+		if d := srv.lockoutDuration(f.count); d > 0 {
+			f.lockedUntil = time.Now().Add(d)
+			lockout = max(lockout, d)
+		}
+		// forget about failures after a while of inactivity
+		srv.cache.Set(key, f, srv.lockoutDuration(f.count)+srv.config.Security.GetLoginLockoutMax())
+	}
+	srv.lock.Unlock()
+
+	attempt.LockedFor = lockout
+	slog.Warn("failed login attempt", "audit", true, "username", attempt.Username, "userID", attempt.UserID, "ip", attempt.Ip)
+	srv.publish(config.EventLoginFailure, attempt)
+	if lockout > 0 {
+		slog.Warn("locked out login", "audit", true, "username", attempt.Username, "userID", attempt.UserID, "ip", attempt.Ip, "duration", lockout)
+		srv.publish(config.EventLoginLockout, attempt)
+	}
+	return lockout
+}
+
+// RegisterSuccess resets the account's failure count, failures from the client's ip are kept, as an attacker might own a valid account as well
+func (srv *LoginThrottleService) RegisterSuccess(attempt *models.LoginAttempt) {
+	attempt.Success = true
+	srv.cache.Delete(srv.accountKey(srv.accountOf(attempt)))
+	slog.Info("successful login", "audit", true, "userID", attempt.UserID, "ip", attempt.Ip)
+	srv.publish(config.EventLoginSuccess, attempt)
+}
+
+func (srv *LoginThrottleService) lockoutDuration(failures int) time.Duration {
+	threshold := srv.config.Security.LoginLockoutThreshold
+	if threshold <= 0 || failures < threshold {
+		return 0
+	}
+	d := srv.config.Security.GetLoginLockoutBase() << min(failures-threshold, maxLoginLockoutExponent)
+	return min(d, srv.config.Security.GetLoginLockoutMax())
+}
+
+func (srv *LoginThrottleService) getFailures(ip, account string) []loginFailures {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	failures := make([]loginFailures, 0, 2)
+	for _, key := range srv.keys(ip, account) {
+		if cached, ok := srv.cache.Get(key); ok {
+			failures = append(failures, *cached.(*loginFailures))
+		}
+	}
+	return failures
+}
+
+func (srv *LoginThrottleService) keys(ip, account string) []string {
+	keys := make([]string, 0, 2)
+	if ip != "" {
+		keys = append(keys, fmt.Sprintf("ip:%s", ip))
+	}
+	if account != "" {
+		keys = append(keys, srv.accountKey(account))
+	}
+	return keys
+}
+
+func (srv *LoginThrottleService) accountKey(account string) string {
+	return fmt.Sprintf("account:%s", strings.ToLower(account))
+}
+
+// accountOf prefers the actual user id, so a user can't be attacked under both their id and their e-mail address
+func (srv *LoginThrottleService) accountOf(attempt *models.LoginAttempt) string {
+	if attempt.UserID != "" {
+		return attempt.UserID
+	}
+	return attempt.Username
+}
+
+func (srv *LoginThrottleService) publish(event string, attempt *models.LoginAttempt) {
+	srv.eventBus.Publish(hub.Message{
+		Name:   event,
+		Fields: map[string]interface{}{config.FieldPayload: attempt},
+	})
+}
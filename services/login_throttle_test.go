@@ -0,0 +1,58 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginThrottleService_Lockout(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Security.LoginLockoutThreshold = 3
+	cfg.Security.LoginLockoutBase = "1m"
+	cfg.Security.LoginLockoutMax = "3m"
+	cfg.Security.LoginCaptchaThreshold = 2
+	config.Set(cfg)
+
+	sut := NewLoginThrottleService()
+	attempt := func() *models.LoginAttempt {
+		return &models.LoginAttempt{Username: "user1@example.org", UserID: "user1", Ip: "192.168.178.10"}
+	}
+
+	assert.Zero(t, sut.RegisterFailure(attempt()))
+	assert.False(t, sut.RequiresCaptcha("192.168.178.10", "user1"))
+	assert.Zero(t, sut.RegisterFailure(attempt()))
+	assert.True(t, sut.RequiresCaptcha("192.168.178.10", "user1"))
+	assert.True(t, sut.RequiresCaptcha("192.168.178.10", ""))
+	assert.True(t, sut.RequiresCaptcha("", "USER1"))
+	assert.False(t, sut.RequiresCaptcha("192.168.178.11", "user2"))
+	assert.Zero(t, sut.GetLockout("192.168.178.10", "user1"))
+
+	// exponential lockout, capped at the maximum
+	assert.Equal(t, 1*time.Minute, sut.RegisterFailure(attempt()))
+	assert.Equal(t, 2*time.Minute, sut.RegisterFailure(attempt()))
+	assert.Equal(t, 3*time.Minute, sut.RegisterFailure(attempt()))
+	assert.Equal(t, 3*time.Minute, sut.RegisterFailure(attempt()))
+
+	assert.InDelta(t, (3 * time.Minute).Seconds(), sut.GetLockout("192.168.178.10", "").Seconds(), 1)
+	assert.InDelta(t, (3 * time.Minute).Seconds(), sut.GetLockout("192.168.178.99", "user1").Seconds(), 1)
+	assert.Zero(t, sut.GetLockout("192.168.178.99", "user2"))
+
+	// success only resets the account
+	sut.RegisterSuccess(attempt())
+	assert.Zero(t, sut.GetLockout("192.168.178.99", "user1"))
+	assert.NotZero(t, sut.GetLockout("192.168.178.10", "user1"))
+}
+
+func TestLoginThrottleService_Disabled(t *testing.T) {
+	config.Set(config.Empty())
+
+	sut := NewLoginThrottleService()
+	for i := 0; i < 10; i++ {
+		assert.Zero(t, sut.RegisterFailure(&models.LoginAttempt{Username: "user1", Ip: "192.168.178.10"}))
+	}
+	assert.False(t, sut.RequiresCaptcha("192.168.178.10", "user1"))
+}
@@ -0,0 +1,345 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/patrickmn/go-cache"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrMachineNoHeartbeats    = errors.New("no heartbeats from this machine")
+	ErrMachineReassignInvalid = errors.New("heartbeats can only be reassigned to another user")
+)
+
+type MachineService struct {
+	config           *config.Config
+	cache            *cache.Cache
+	repository       repositories.IMachineRepository
+	heartbeatService IHeartbeatService
+	mailService      IMailService
+}
+
+func NewMachineService(machineRepository repositories.IMachineRepository, heartbeatService IHeartbeatService, mailService IMailService) *MachineService {
+	return &MachineService{
+		config:           config.Get(),
+		cache:            cache.New(1*time.Hour, 1*time.Hour),
+		repository:       machineRepository,
+		heartbeatService: heartbeatService,
+		mailService:      mailService,
+	}
+}
+
+func (srv *MachineService) GetByUser(userId string) ([]*models.Machine, error) {
+	return srv.repository.GetByUser(userId)
+}
+
+func (srv *MachineService) CountQuarantinedByUser(userId string) (map[string]int64, error) {
+	return srv.repository.CountQuarantinedByUser(userId)
+}
+
+// IsApproved checks whether the given machine was approved by the user, and registers it as pending (and notifies the user) if it is seen for the first time
+func (srv *MachineService) IsApproved(user *models.User, machineName string) (bool, error) {
+	cacheKey := srv.getHash(user.ID, machineName)
+	if cached, found := srv.cache.Get(cacheKey); found {
+		return cached.(models.Machine).Approved, nil
+	}
+
+	machine, err := srv.repository.GetByUserAndName(user.ID, machineName)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+
+	if machine == nil {
+		var created bool
+		if machine, created, err = srv.insertOrGet(&models.Machine{UserID: user.ID, Name: machineName, Approved: false}); err != nil {
+			return false, err
+		}
+		if !created {
+			// registered by a concurrent request, which already notified the user
+			srv.cache.SetDefault(cacheKey, *machine)
+			return machine.Approved, nil
+		}
+		slog.Info("new machine pending approval", "userID", user.ID, "machine", machineName)
+
+		if user.HasTrustedEmail() {
+			go func(user *models.User, machineName string) {
+				if err := srv.mailService.SendMachineApprovalRequest(user, machineName); err != nil {
+					config.Log().Error("failed to send machine approval request", "userID", user.ID, "error", err)
+				}
+			}(user, machineName)
+		}
+	}
+
+	srv.cache.SetDefault(cacheKey, *machine)
+	return machine.Approved, nil
+}
+
+// ApproveAll marks all the given machines as approved without notifying the user, e.g. for machines already known when enabling approvals
+func (srv *MachineService) ApproveAll(user *models.User, machineNames []string) error {
+	for _, name := range machineNames {
+		machine, err := srv.repository.GetByUserAndName(user.ID, name)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if machine == nil {
+			if machine, _, err = srv.insertOrGet(&models.Machine{UserID: user.ID, Name: name, Approved: true}); err != nil {
+				return err
+			}
+		}
+		if !machine.Approved {
+			machine.Approved, machine.Rejected = true, false
+			if _, err := srv.repository.Update(machine); err != nil {
+				return err
+			}
+		}
+		srv.cache.Delete(srv.getHash(user.ID, name))
+	}
+	return nil
+}
+
+// SetCountry records where the given machine sends heartbeats from, and registers the machine, if it is not known, yet
+func (srv *MachineService) SetCountry(user *models.User, machineName, country string) error {
+	cacheKey := srv.getCountryHash(user.ID, machineName)
+	if cached, found := srv.cache.Get(cacheKey); found && cached.(string) == country {
+		return nil
+	}
+
+	machine, err := srv.repository.GetByUserAndName(user.ID, machineName)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if machine == nil {
+		// machines pending approval are always registered by IsApproved before, so all others are implicitly trusted
+		if machine, _, err = srv.insertOrGet(&models.Machine{UserID: user.ID, Name: machineName, Approved: !user.RequireMachineApproval, Country: country}); err != nil {
+			return err
+		}
+	}
+	if machine.Country != country {
+		machine.Country = country
+		if _, err := srv.repository.UpdateCountry(machine); err != nil {
+			return err
+		}
+	}
+
+	srv.cache.SetDefault(cacheKey, country)
+	return nil
+}
+
+// ClearCountries forgets where any of the user's machines sent heartbeats from, e.g. after the user opted out of location tracking
+func (srv *MachineService) ClearCountries(user *models.User) error {
+	if err := srv.repository.ClearCountryByUser(user.ID); err != nil {
+		return err
+	}
+	prefix := srv.getCountryHash(user.ID, "")
+	for key := range srv.cache.Items() {
+		if strings.HasPrefix(key, prefix) {
+			srv.cache.Delete(key)
+		}
+	}
+	return nil
+}
+
+// Quarantine holds back heartbeats from machines pending approval until the user approves them. Heartbeats from rejected machines
+// and those exceeding the configured maximum number of quarantined heartbeats per machine are dropped.
+func (srv *MachineService) Quarantine(heartbeats []*models.Heartbeat) error {
+	quarantined := make([]*models.QuarantinedHeartbeat, 0, len(heartbeats))
+	remaining := make(map[string]int64) // per user and machine
+
+	for _, hb := range heartbeats {
+		key := srv.getHash(hb.UserID, hb.Machine)
+		if _, ok := remaining[key]; !ok {
+			n, err := srv.countQuarantinable(hb.UserID, hb.Machine)
+			if err != nil {
+				return err
+			}
+			if n == 0 {
+				slog.Debug("dropping heartbeats from rejected machine or exceeding quarantine limit", "userID", hb.UserID, "machine", hb.Machine)
+			}
+			remaining[key] = n
+		}
+		if remaining[key] == 0 {
+			continue
+		}
+		remaining[key]--
+
+		data, err := json.Marshal(hb)
+		if err != nil {
+			return err
+		}
+		quarantined = append(quarantined, &models.QuarantinedHeartbeat{UserID: hb.UserID, Machine: hb.Machine, Data: string(data)})
+	}
+	return srv.repository.InsertQuarantined(quarantined)
+}
+
+// Approve marks the given machine as approved and releases all of its quarantined heartbeats
+func (srv *MachineService) Approve(user *models.User, machineName string) (int, error) {
+	machine, err := srv.repository.GetByUserAndName(user.ID, machineName)
+	if err != nil {
+		return 0, err
+	}
+
+	machine.Approved, machine.Rejected = true, false
+	if _, err := srv.repository.Update(machine); err != nil {
+		return 0, err
+	}
+	srv.cache.Delete(srv.getHash(user.ID, machineName))
+
+	quarantined, err := srv.repository.GetQuarantinedByUserAndMachine(user.ID, machineName)
+	if err != nil {
+		return 0, err
+	}
+
+	heartbeats := make([]*models.Heartbeat, 0, len(quarantined))
+	for _, q := range quarantined {
+		var hb models.Heartbeat
+		if err := json.Unmarshal([]byte(q.Data), &hb); err != nil {
+			config.Log().Error("failed to restore quarantined heartbeat", "userID", user.ID, "id", q.ID, "error", err)
+			continue
+		}
+		hb.User = user
+		hb.UserID = user.ID
+		heartbeats = append(heartbeats, hb.Hashed())
+	}
+
+	if err := srv.heartbeatService.InsertBatch(heartbeats); err != nil {
+		return 0, err
+	}
+	if err := srv.repository.DeleteQuarantinedByUserAndMachine(user.ID, machineName); err != nil {
+		return 0, err
+	}
+
+	slog.Info("approved machine", "userID", user.ID, "machine", machineName, "released", len(heartbeats))
+	return len(heartbeats), nil
+}
+
+// Reject marks the given machine as rejected and removes all of its quarantined heartbeats. The machine is kept, so that
+// its further heartbeats are dropped silently instead of having it registered as pending (and the user notified) again.
+func (srv *MachineService) Reject(user *models.User, machineName string) error {
+	machine, err := srv.repository.GetByUserAndName(user.ID, machineName)
+	if err != nil {
+		return err
+	}
+
+	machine.Approved, machine.Rejected = false, true
+	if _, err := srv.repository.Update(machine); err != nil {
+		return err
+	}
+	srv.cache.Delete(srv.getHash(user.ID, machineName))
+
+	if err := srv.repository.DeleteQuarantinedByUserAndMachine(user.ID, machineName); err != nil {
+		return err
+	}
+
+	slog.Info("rejected machine", "userID", user.ID, "machine", machineName)
+	return nil
+}
+
+// DeleteHeartbeats removes all of the user's heartbeats from the given machine and returns the time range they covered, so that summaries can be re-generated
+func (srv *MachineService) DeleteHeartbeats(user *models.User, machineName string) (*models.Interval, int64, error) {
+	interval, err := srv.heartbeatService.GetMachineIntervalByUser(user, machineName)
+	if err != nil {
+		return nil, 0, err
+	}
+	if interval == nil {
+		return nil, 0, ErrMachineNoHeartbeats
+	}
+
+	n, err := srv.heartbeatService.DeleteByUserAndMachineBefore(user, machineName, interval.End)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	slog.Info("deleted heartbeats of machine", "userID", user.ID, "machine", machineName, "heartbeats", n)
+	return interval, n, nil
+}
+
+// ReassignHeartbeats moves all of the user's heartbeats from the given machine to the target user, e.g. after a machine was set up with the wrong api key,
+// and returns the time range they covered, so that both users' summaries can be re-generated
+func (srv *MachineService) ReassignHeartbeats(user, target *models.User, machineName string) (*models.Interval, int64, error) {
+	if target == nil || target.ID == user.ID {
+		return nil, 0, ErrMachineReassignInvalid
+	}
+
+	interval, err := srv.heartbeatService.GetMachineIntervalByUser(user, machineName)
+	if err != nil {
+		return nil, 0, err
+	}
+	if interval == nil {
+		return nil, 0, ErrMachineNoHeartbeats
+	}
+
+	// heartbeats are copied month by month to limit memory usage and only deleted from the original user once all of them were copied
+	var copied int64
+	for _, chunk := range splitRangeByMonths(interval.Start, interval.End.Add(time.Second)) {
+		n, err := srv.heartbeatService.CopyMachineToUser(user, target, machineName, chunk[0], chunk[1])
+		if err != nil {
+			return nil, 0, err
+		}
+		copied += n
+	}
+
+	if _, err := srv.heartbeatService.DeleteByUserAndMachineBefore(user, machineName, interval.End); err != nil {
+		return nil, 0, err
+	}
+
+	slog.Info("reassigned heartbeats of machine", "userID", user.ID, "targetUserID", target.ID, "machine", machineName, "heartbeats", copied)
+	return interval, copied, nil
+}
+
+// insertOrGet registers the given machine, unless it was inserted by a concurrent request in the meantime, in which case the existing one is returned
+func (srv *MachineService) insertOrGet(machine *models.Machine) (*models.Machine, bool, error) {
+	inserted, insertErr := srv.repository.Insert(machine)
+	if insertErr == nil {
+		return inserted, true, nil
+	}
+	existing, err := srv.repository.GetByUserAndName(machine.UserID, machine.Name)
+	if err != nil {
+		return nil, false, insertErr
+	}
+	return existing, false, nil
+}
+
+// countQuarantinable returns how many more heartbeats of the given machine may be quarantined
+func (srv *MachineService) countQuarantinable(userId, machineName string) (int64, error) {
+	var machine *models.Machine
+	if cached, found := srv.cache.Get(srv.getHash(userId, machineName)); found {
+		m := cached.(models.Machine)
+		machine = &m
+	} else {
+		var err error
+		if machine, err = srv.repository.GetByUserAndName(userId, machineName); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, err
+		}
+	}
+	if machine != nil && machine.Rejected {
+		return 0, nil
+	}
+
+	limit := srv.config.App.MaxQuarantinedHeartbeats
+	if limit < 0 {
+		return math.MaxInt64, nil
+	}
+	count, err := srv.repository.CountQuarantinedByUserAndMachine(userId, machineName)
+	if err != nil {
+		return 0, err
+	}
+	return max(0, limit-count), nil
+}
+
+func (srv *MachineService) getHash(userId, machineName string) string {
+	return userId + "__" + machineName
+}
+
+func (srv *MachineService) getCountryHash(userId, machineName string) string {
+	return "country__" + srv.getHash(userId, machineName)
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func setupMachineServiceTest(maxQuarantined int64) {
+	cfg := config.Empty()
+	cfg.App.MaxQuarantinedHeartbeats = maxQuarantined
+	config.Set(cfg)
+}
+
+func TestMachineService_IsApproved_ConcurrentRegistration(t *testing.T) {
+	setupMachineServiceTest(-1)
+
+	user := &models.User{ID: TestUserId}
+	existing := &models.Machine{ID: 1, UserID: TestUserId, Name: "laptop"}
+
+	machineRepo := new(mocks.MachineRepositoryMock)
+	machineRepo.On("GetByUserAndName", TestUserId, "laptop").Return(nil, gorm.ErrRecordNotFound).Once()
+	machineRepo.On("Insert", mock.Anything).Return(nil, errors.New("UNIQUE constraint failed: machines.user_id, machines.name")).Once()
+	machineRepo.On("GetByUserAndName", TestUserId, "laptop").Return(existing, nil).Once()
+
+	sut := NewMachineService(machineRepo, new(mocks.HeartbeatServiceMock), nil)
+
+	approved, err := sut.IsApproved(user, "laptop")
+	assert.Nil(t, err)
+	assert.False(t, approved)
+
+	// served from cache
+	approved, err = sut.IsApproved(user, "laptop")
+	assert.Nil(t, err)
+	assert.False(t, approved)
+	machineRepo.AssertNumberOfCalls(t, "GetByUserAndName", 2)
+}
+
+func TestMachineService_Approve(t *testing.T) {
+	setupMachineServiceTest(-1)
+
+	user := &models.User{ID: TestUserId}
+	machine := &models.Machine{ID: 1, UserID: TestUserId, Name: "laptop", Rejected: true}
+
+	hb := &models.Heartbeat{UserID: TestUserId, Machine: "laptop", Entity: "main.go", Project: "wakapi", Time: models.CustomTime(time.Now())}
+	data, _ := json.Marshal(hb)
+
+	machineRepo := new(mocks.MachineRepositoryMock)
+	machineRepo.On("GetByUserAndName", TestUserId, "laptop").Return(machine, nil)
+	machineRepo.On("Update", machine).Return(machine, nil)
+	machineRepo.On("GetQuarantinedByUserAndMachine", TestUserId, "laptop").Return([]*models.QuarantinedHeartbeat{
+		{ID: 1, UserID: TestUserId, Machine: "laptop", Data: string(data)},
+		{ID: 2, UserID: TestUserId, Machine: "laptop", Data: "invalid"},
+	}, nil)
+	machineRepo.On("DeleteQuarantinedByUserAndMachine", TestUserId, "laptop").Return(nil)
+
+	heartbeatService := new(mocks.HeartbeatServiceMock)
+	heartbeatService.On("InsertBatch", mock.Anything).Return(nil)
+
+	sut := NewMachineService(machineRepo, heartbeatService, nil)
+
+	released, err := sut.Approve(user, "laptop")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, released)
+	assert.True(t, machine.Approved)
+	assert.False(t, machine.Rejected)
+
+	restored := heartbeatService.Calls[0].Arguments.Get(0).([]*models.Heartbeat)
+	assert.Len(t, restored, 1)
+	assert.Equal(t, "main.go", restored[0].Entity)
+	assert.Equal(t, user, restored[0].User)
+	assert.NotEmpty(t, restored[0].Hash)
+	machineRepo.AssertCalled(t, "DeleteQuarantinedByUserAndMachine", TestUserId, "laptop")
+}
+
+func TestMachineService_Reject(t *testing.T) {
+	setupMachineServiceTest(-1)
+
+	user := &models.User{ID: TestUserId}
+	machine := &models.Machine{ID: 1, UserID: TestUserId, Name: "laptop"}
+
+	machineRepo := new(mocks.MachineRepositoryMock)
+	machineRepo.On("GetByUserAndName", TestUserId, "laptop").Return(machine, nil)
+	machineRepo.On("Update", machine).Return(machine, nil)
+	machineRepo.On("DeleteQuarantinedByUserAndMachine", TestUserId, "laptop").Return(nil)
+	machineRepo.On("InsertQuarantined", mock.Anything).Return(nil)
+
+	sut := NewMachineService(machineRepo, new(mocks.HeartbeatServiceMock), nil)
+
+	assert.Nil(t, sut.Reject(user, "laptop"))
+	assert.True(t, machine.Rejected)
+	assert.False(t, machine.Approved)
+	machineRepo.AssertNotCalled(t, "Delete", mock.Anything)
+
+	// machine is still known, so it is neither registered again nor are its heartbeats kept
+	approved, err := sut.IsApproved(user, "laptop")
+	assert.Nil(t, err)
+	assert.False(t, approved)
+	machineRepo.AssertNotCalled(t, "Insert", mock.Anything)
+
+	assert.Nil(t, sut.Quarantine([]*models.Heartbeat{{UserID: TestUserId, Machine: "laptop"}}))
+	assert.Empty(t, machineRepo.Calls[len(machineRepo.Calls)-1].Arguments.Get(0).([]*models.QuarantinedHeartbeat))
+	machineRepo.AssertNotCalled(t, "CountQuarantinedByUserAndMachine", mock.Anything, mock.Anything)
+}
+
+func TestMachineService_Quarantine_Limit(t *testing.T) {
+	setupMachineServiceTest(3)
+
+	machineRepo := new(mocks.MachineRepositoryMock)
+	machineRepo.On("GetByUserAndName", TestUserId, "laptop").Return(&models.Machine{UserID: TestUserId, Name: "laptop"}, nil)
+	machineRepo.On("GetByUserAndName", TestUserId, "desktop").Return(&models.Machine{UserID: TestUserId, Name: "desktop"}, nil)
+	machineRepo.On("CountQuarantinedByUserAndMachine", TestUserId, "laptop").Return(int64(2), nil)
+	machineRepo.On("CountQuarantinedByUserAndMachine", TestUserId, "desktop").Return(int64(3), nil)
+	machineRepo.On("InsertQuarantined", mock.Anything).Return(nil)
+
+	sut := NewMachineService(machineRepo, new(mocks.HeartbeatServiceMock), nil)
+
+	heartbeats := []*models.Heartbeat{
+		{UserID: TestUserId, Machine: "laptop", Entity: "a.go"},
+		{UserID: TestUserId, Machine: "desktop", Entity: "b.go"},
+		{UserID: TestUserId, Machine: "laptop", Entity: "c.go"},
+	}
+	assert.Nil(t, sut.Quarantine(heartbeats))
+
+	quarantined := machineRepo.Calls[len(machineRepo.Calls)-1].Arguments.Get(0).([]*models.QuarantinedHeartbeat)
+	assert.Len(t, quarantined, 1)
+	assert.Equal(t, "laptop", quarantined[0].Machine)
+	assert.Contains(t, quarantined[0].Data, "a.go")
+	machineRepo.AssertNumberOfCalls(t, "CountQuarantinedByUserAndMachine", 2)
+}
+
+func TestMachineService_ReassignHeartbeats(t *testing.T) {
+	config.Set(config.Empty())
+
+	user, target := &models.User{ID: TestUserId}, &models.User{ID: "target"}
+	interval := &models.Interval{Start: time.Date(2024, 1, 20, 10, 0, 0, 0, time.UTC), End: time.Date(2024, 3, 10, 10, 0, 0, 0, time.UTC)}
+
+	heartbeatService := new(mocks.HeartbeatServiceMock)
+	heartbeatService.On("GetMachineIntervalByUser", user, "vm").Return(interval, nil)
+	heartbeatService.On("GetMachineIntervalByUser", user, "unknown").Return(nil, nil)
+	heartbeatService.On("CopyMachineToUser", user, target, "vm", mock.Anything, mock.Anything).Return(int64(5), nil)
+	heartbeatService.On("DeleteByUserAndMachineBefore", user, "vm", interval.End).Return(int64(10), nil)
+
+	sut := NewMachineService(nil, heartbeatService, nil)
+
+	_, _, err := sut.ReassignHeartbeats(user, user, "vm")
+	assert.ErrorIs(t, err, ErrMachineReassignInvalid)
+	_, _, err = sut.ReassignHeartbeats(user, target, "unknown")
+	assert.ErrorIs(t, err, ErrMachineNoHeartbeats)
+
+	result, n, err := sut.ReassignHeartbeats(user, target, "vm")
+	assert.Nil(t, err)
+	assert.Equal(t, interval, result)
+	assert.Equal(t, int64(10), n)
+	heartbeatService.AssertNumberOfCalls(t, "CopyMachineToUser", 2) // one per month
+	heartbeatService.AssertNumberOfCalls(t, "DeleteByUserAndMachineBefore", 1)
+}
@@ -3,9 +3,12 @@ package mail
 import (
 	"bytes"
 	"fmt"
+	"html/template"
+	"strconv"
 	"time"
 
 	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/i18n"
 	"github.com/hackclub/hackatime/models"
 	"github.com/hackclub/hackatime/routes"
 	"github.com/hackclub/hackatime/services"
@@ -22,25 +25,43 @@ const (
 	tplNameWakatimeFailureNotification = "wakatime_connection_failure"
 	tplNameReport                      = "report"
 	tplNameSubscriptionNotification    = "subscription_expiring"
-	subjectWelcome                     = "Hackatime - Welcome!"
-	subjectPasswordReset               = "Hackatime - Password Reset"
-	subjectImportNotification          = "Hackatime - Data Import Finished"
-	subjectWakatimeFailureNotification = "Hackatime - WakaTime Connection Failure"
-	subjectReport                      = "Hackatime - Report from %s"
-	subjectSubscriptionNotification    = "Hackatime - Subscription expiring / expired"
+	tplNameMachineApproval             = "machine_approval"
+	tplNameApiKeyRevoked               = "api_key_revoked"
+	tplNameExportNotification          = "export_finished"
+	tplNameEmailVerification           = "verify_email"
+	tplNameInactivityAlert             = "inactivity_alert"
+	tplNameLanguageGoalsReport         = "language_goals_report"
+	tplNameNotification                = "notification"
+	tplNameMentorSummary               = "mentor_summary"
+	subjectWelcome                     = "mail.subject.welcome"
+	subjectPasswordReset               = "mail.subject.password_reset"
+	subjectImportNotification          = "mail.subject.import_finished"
+	subjectWakatimeFailureNotification = "mail.subject.wakatime_failure"
+	subjectReport                      = "mail.subject.report"
+	subjectSubscriptionNotification    = "mail.subject.subscription_expiring"
+	subjectMachineApproval             = "mail.subject.machine_approval"
+	subjectApiKeyRevoked               = "mail.subject.api_key_revoked"
+	subjectExportNotification          = "mail.subject.export_finished"
+	subjectEmailVerification           = "mail.subject.verify_email"
+	subjectInactivityAlert             = "mail.subject.inactivity_alert"
+	subjectLanguageGoalsReport         = "mail.subject.language_goals_report"
+	subjectMentorSummary               = "mail.subject.mentor_summary"
 )
 
 type SendingService interface {
 	Send(*models.Mail) error
+	Check() error
 }
 
 type MailService struct {
-	config         *conf.Config
-	sendingService SendingService
-	templates      utils.TemplateMap
+	config                      *conf.Config
+	sendingService              SendingService
+	templates                   utils.TemplateMap
+	notificationTemplateService services.INotificationTemplateService
 }
 
-func NewMailService() services.IMailService {
+// NewMailService creates the mail service, notification templates customized by operators are ignored if no service for them is given
+func NewMailService(notificationTemplateService services.INotificationTemplateService) services.IMailService {
 	config := conf.Get()
 
 	var sendingService SendingService
@@ -59,7 +80,12 @@ func NewMailService() services.IMailService {
 		panic(err)
 	}
 
-	return &MailService{sendingService: sendingService, config: config, templates: templates}
+	return &MailService{sendingService: sendingService, config: config, templates: templates, notificationTemplateService: notificationTemplateService}
+}
+
+// Check verifies that the configured mail server accepts connections and credentials
+func (m *MailService) Check() error {
+	return m.sendingService.Check()
 }
 
 func (m *MailService) SendWelcome(recipient *models.User) error {
@@ -70,7 +96,7 @@ func (m *MailService) SendWelcome(recipient *models.User) error {
 	mail := &models.Mail{
 		From:    models.MailAddress(m.config.Mail.Sender),
 		To:      models.MailAddresses([]models.MailAddress{models.MailAddress(recipient.Email)}),
-		Subject: subjectWelcome,
+		Subject: i18n.T(recipient.Lang(), subjectWelcome),
 	}
 	mail.WithHTML(tpl.String())
 	return m.sendingService.Send(mail)
@@ -84,7 +110,7 @@ func (m *MailService) SendPasswordReset(recipient *models.User, resetLink string
 	mail := &models.Mail{
 		From:    models.MailAddress(m.config.Mail.Sender),
 		To:      models.MailAddresses([]models.MailAddress{models.MailAddress(recipient.Email)}),
-		Subject: subjectPasswordReset,
+		Subject: i18n.T(recipient.Lang(), subjectPasswordReset),
 	}
 	mail.WithHTML(tpl.String())
 	return m.sendingService.Send(mail)
@@ -101,7 +127,7 @@ func (m *MailService) SendWakatimeFailureNotification(recipient *models.User, nu
 	mail := &models.Mail{
 		From:    models.MailAddress(m.config.Mail.Sender),
 		To:      models.MailAddresses([]models.MailAddress{models.MailAddress(recipient.Email)}),
-		Subject: subjectWakatimeFailureNotification,
+		Subject: i18n.T(recipient.Lang(), subjectWakatimeFailureNotification),
 	}
 	mail.WithHTML(tpl.String())
 	return m.sendingService.Send(mail)
@@ -119,7 +145,7 @@ func (m *MailService) SendImportNotification(recipient *models.User, duration ti
 	mail := &models.Mail{
 		From:    models.MailAddress(m.config.Mail.Sender),
 		To:      models.MailAddresses([]models.MailAddress{models.MailAddress(recipient.Email)}),
-		Subject: subjectImportNotification,
+		Subject: i18n.T(recipient.Lang(), subjectImportNotification),
 	}
 	mail.WithHTML(tpl.String())
 	return m.sendingService.Send(mail)
@@ -133,7 +159,7 @@ func (m *MailService) SendReport(recipient *models.User, report *models.Report)
 	mail := &models.Mail{
 		From:    models.MailAddress(m.config.Mail.Sender),
 		To:      models.MailAddresses([]models.MailAddress{models.MailAddress(recipient.Email)}),
-		Subject: fmt.Sprintf(subjectReport, helpers.FormatDateHuman(time.Now().In(recipient.TZ()))),
+		Subject: i18n.T(recipient.Lang(), subjectReport, helpers.FormatDateHuman(time.Now().In(recipient.TZ()))),
 	}
 	mail.WithHTML(tpl.String())
 	return m.sendingService.Send(mail)
@@ -151,7 +177,153 @@ func (m *MailService) SendSubscriptionNotification(recipient *models.User, hasEx
 	mail := &models.Mail{
 		From:    models.MailAddress(m.config.Mail.Sender),
 		To:      models.MailAddresses([]models.MailAddress{models.MailAddress(recipient.Email)}),
-		Subject: subjectSubscriptionNotification,
+		Subject: i18n.T(recipient.Lang(), subjectSubscriptionNotification),
+	}
+	mail.WithHTML(tpl.String())
+	return m.sendingService.Send(mail)
+}
+
+func (m *MailService) SendMachineApprovalRequest(recipient *models.User, machineName string) error {
+	tpl, err := m.getMachineApprovalTemplate(MachineApprovalTplData{
+		PublicUrl: m.config.Server.PublicUrl,
+		Machine:   machineName,
+	})
+	if err != nil {
+		return err
+	}
+	mail := &models.Mail{
+		From:    models.MailAddress(m.config.Mail.Sender),
+		To:      models.MailAddresses([]models.MailAddress{models.MailAddress(recipient.Email)}),
+		Subject: i18n.T(recipient.Lang(), subjectMachineApproval),
+	}
+	mail.WithHTML(tpl.String())
+	return m.sendingService.Send(mail)
+}
+
+func (m *MailService) SendApiKeyRevokedNotification(recipient *models.User, leakUrl string) error {
+	tpl, err := m.getApiKeyRevokedTemplate(ApiKeyRevokedTplData{
+		PublicUrl: m.config.Server.PublicUrl,
+		Url:       leakUrl,
+	})
+	if err != nil {
+		return err
+	}
+	mail := &models.Mail{
+		From:    models.MailAddress(m.config.Mail.Sender),
+		To:      models.MailAddresses([]models.MailAddress{models.MailAddress(recipient.Email)}),
+		Subject: i18n.T(recipient.Lang(), subjectApiKeyRevoked),
+	}
+	mail.WithHTML(tpl.String())
+	return m.sendingService.Send(mail)
+}
+
+func (m *MailService) SendInactivityAlert(recipient *models.User, lastHeartbeat time.Time) error {
+	tpl, subject, err := m.getCustomNotificationTemplate(models.NotificationKindInactivityAlert, models.NewInactivityAlertNotification(recipient, lastHeartbeat, m.config.Server.PublicUrl), i18n.T(recipient.Lang(), subjectInactivityAlert))
+	if tpl == nil {
+		tpl, err = m.getInactivityAlertTemplate(InactivityAlertTplData{
+			PublicUrl:     m.config.Server.PublicUrl,
+			LastHeartbeat: lastHeartbeat.In(recipient.TZ()).Format(m.config.App.DateTimeFormat),
+		})
+	}
+	if err != nil {
+		return err
+	}
+	mail := &models.Mail{
+		From:    models.MailAddress(m.config.Mail.Sender),
+		To:      models.MailAddresses([]models.MailAddress{models.MailAddress(recipient.Email)}),
+		Subject: subject,
+	}
+	mail.WithHTML(tpl.String())
+	return m.sendingService.Send(mail)
+}
+
+func (m *MailService) SendLanguageGoalsReport(recipient *models.User, progress []*models.LanguageGoalProgress) error {
+	if len(progress) == 0 {
+		return nil
+	}
+
+	data := LanguageGoalsReportTplData{
+		PublicUrl: m.config.Server.PublicUrl,
+		From:      progress[0].From,
+		To:        progress[0].To.AddDate(0, 0, -1),
+		Goals:     make([]*LanguageGoalsReportItem, 0, len(progress)),
+	}
+	for _, p := range progress {
+		language := p.Goal.Language
+		if p.Goal.Category != "" {
+			language = fmt.Sprintf("%s, %s only", language, p.Goal.Category)
+		}
+		data.Goals = append(data.Goals, &LanguageGoalsReportItem{
+			Language:     language,
+			MinPercent:   strconv.FormatFloat(p.Goal.MinPercent, 'f', -1, 64),
+			Percent:      strconv.FormatFloat(p.Percent, 'f', 1, 64),
+			LanguageTime: p.LanguageTime,
+			Reached:      p.Reached,
+		})
+	}
+
+	tpl, subject, err := m.getCustomNotificationTemplate(models.NotificationKindLanguageGoalsReport, models.NewLanguageGoalsReportNotification(recipient, progress, m.config.Server.PublicUrl), i18n.T(recipient.Lang(), subjectLanguageGoalsReport))
+	if tpl == nil {
+		tpl, err = m.getLanguageGoalsReportTemplate(data)
+	}
+	if err != nil {
+		return err
+	}
+	mail := &models.Mail{
+		From:    models.MailAddress(m.config.Mail.Sender),
+		To:      models.MailAddresses([]models.MailAddress{models.MailAddress(recipient.Email)}),
+		Subject: subject,
+	}
+	mail.WithHTML(tpl.String())
+	return m.sendingService.Send(mail)
+}
+
+// SendMentorSummary sends the weekly summary of a user to one of their mentors, in the user's language
+func (m *MailService) SendMentorSummary(mentor *models.Mentor, summary *models.MentorSummary) error {
+	tpl, err := m.getMentorSummaryTemplate(MentorSummaryTplData{PublicUrl: m.config.Server.PublicUrl, Summary: summary})
+	if err != nil {
+		return err
+	}
+	mail := &models.Mail{
+		From:    models.MailAddress(m.config.Mail.Sender),
+		To:      models.MailAddresses([]models.MailAddress{models.MailAddress(mentor.Email)}),
+		Subject: i18n.T(summary.User.Lang(), subjectMentorSummary, summary.User.DisplayName()),
+	}
+	mail.WithHTML(tpl.String())
+	return m.sendingService.Send(mail)
+}
+
+func (m *MailService) SendExportNotification(recipient *models.User, downloadUrl string) error {
+	tpl, err := m.getExportNotificationTemplate(ExportNotificationTplData{
+		PublicUrl:   m.config.Server.PublicUrl,
+		DownloadUrl: downloadUrl,
+		Expiry:      helpers.FmtWakatimeDuration(m.config.Objects.GetLinkExpiry()),
+	})
+	if err != nil {
+		return err
+	}
+	mail := &models.Mail{
+		From:    models.MailAddress(m.config.Mail.Sender),
+		To:      models.MailAddresses([]models.MailAddress{models.MailAddress(recipient.Email)}),
+		Subject: i18n.T(recipient.Lang(), subjectExportNotification),
+	}
+	mail.WithHTML(tpl.String())
+	return m.sendingService.Send(mail)
+}
+
+func (m *MailService) SendEmailVerification(recipient *models.User, verificationLink string) error {
+	tpl, err := m.getEmailVerificationTemplate(EmailVerificationTplData{
+		PublicUrl:        m.config.Server.PublicUrl,
+		VerificationLink: verificationLink,
+		Expiry:           helpers.FmtWakatimeDuration(conf.EmailVerificationTokenTtl),
+	})
+	if err != nil {
+		return err
+	}
+	mail := &models.Mail{
+		From:    models.MailAddress(m.config.Mail.Sender),
+		To:      models.MailAddresses([]models.MailAddress{models.MailAddress(recipient.Email)}),
+		Subject: i18n.T(recipient.Lang(), subjectEmailVerification),
 	}
 	mail.WithHTML(tpl.String())
 	return m.sendingService.Send(mail)
@@ -205,6 +377,88 @@ func (m *MailService) getSubscriptionNotificationTemplate(data SubscriptionNotif
 	return &rendered, nil
 }
 
+func (m *MailService) getMachineApprovalTemplate(data MachineApprovalTplData) (*bytes.Buffer, error) {
+	var rendered bytes.Buffer
+	if err := m.templates[m.fmtName(tplNameMachineApproval)].Execute(&rendered, data); err != nil {
+		return nil, err
+	}
+	return &rendered, nil
+}
+
+func (m *MailService) getInactivityAlertTemplate(data InactivityAlertTplData) (*bytes.Buffer, error) {
+	var rendered bytes.Buffer
+	if err := m.templates[m.fmtName(tplNameInactivityAlert)].Execute(&rendered, data); err != nil {
+		return nil, err
+	}
+	return &rendered, nil
+}
+
+func (m *MailService) getLanguageGoalsReportTemplate(data LanguageGoalsReportTplData) (*bytes.Buffer, error) {
+	var rendered bytes.Buffer
+	if err := m.templates[m.fmtName(tplNameLanguageGoalsReport)].Execute(&rendered, data); err != nil {
+		return nil, err
+	}
+	return &rendered, nil
+}
+
+func (m *MailService) getMentorSummaryTemplate(data MentorSummaryTplData) (*bytes.Buffer, error) {
+	var rendered bytes.Buffer
+	if err := m.templates[m.fmtName(tplNameMentorSummary)].Execute(&rendered, data); err != nil {
+		return nil, err
+	}
+	return &rendered, nil
+}
+
+func (m *MailService) getApiKeyRevokedTemplate(data ApiKeyRevokedTplData) (*bytes.Buffer, error) {
+	var rendered bytes.Buffer
+	if err := m.templates[m.fmtName(tplNameApiKeyRevoked)].Execute(&rendered, data); err != nil {
+		return nil, err
+	}
+	return &rendered, nil
+}
+
+func (m *MailService) getExportNotificationTemplate(data ExportNotificationTplData) (*bytes.Buffer, error) {
+	var rendered bytes.Buffer
+	if err := m.templates[m.fmtName(tplNameExportNotification)].Execute(&rendered, data); err != nil {
+		return nil, err
+	}
+	return &rendered, nil
+}
+
+func (m *MailService) getEmailVerificationTemplate(data EmailVerificationTplData) (*bytes.Buffer, error) {
+	var rendered bytes.Buffer
+	if err := m.templates[m.fmtName(tplNameEmailVerification)].Execute(&rendered, data); err != nil {
+		return nil, err
+	}
+	return &rendered, nil
+}
+
+// getCustomNotificationTemplate renders the operator's template for the kind of notification into the common mail layout, if there is one.
+// A nil buffer is returned if the built-in template is to be used, which includes custom ones failing to render.
+func (m *MailService) getCustomNotificationTemplate(kind string, data interface{}, subject string) (*bytes.Buffer, string, error) {
+	if m.notificationTemplateService == nil {
+		return nil, subject, nil
+	}
+
+	custom, err := m.notificationTemplateService.Render(kind, models.NotificationChannelMail, data)
+	if err != nil {
+		conf.Log().Error("failed to render custom notification template, using built-in one", "kind", kind, "error", err)
+		return nil, subject, nil
+	}
+	if custom == nil {
+		return nil, subject, nil
+	}
+
+	var rendered bytes.Buffer
+	if err := m.templates[m.fmtName(tplNameNotification)].Execute(&rendered, NotificationTplData{PublicUrl: m.config.Server.PublicUrl, Body: template.HTML(custom.Body)}); err != nil {
+		return nil, subject, err
+	}
+	if custom.Subject != "" {
+		subject = custom.Subject
+	}
+	return &rendered, subject, nil
+}
+
 func (m *MailService) fmtName(name string) string {
 	return fmt.Sprintf("%s.tpl.html", name)
 }
@@ -12,3 +12,7 @@ func (n *NoopSendingService) Send(mail *models.Mail) error {
 	slog.Info("noop mail service doing nothing instead of sending password reset mail", "to", mail.To.Strings())
 	return nil
 }
+
+func (n *NoopSendingService) Check() error {
+	return nil
+}
@@ -30,6 +30,52 @@ func NewSMTPSendingService(config conf.SMTPMailConfig) *SMTPSendingService {
 func (s *SMTPSendingService) Send(mail *models.Mail) error {
 	mail = mail.Sanitized()
 
+	c, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if err = c.Mail(mail.From.Raw(), nil); err != nil {
+		return err
+	}
+
+	for _, addr := range mail.To.RawStrings() {
+		if err = c.Rcpt(addr, nil); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, mail.Reader())
+	if err != nil {
+		return err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// Check connects and authenticates to the smtp server without sending any mail, e.g. to validate the configuration
+func (s *SMTPSendingService) Check() error {
+	c, err := s.connect()
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return c.Quit()
+}
+
+// connect dials the smtp server, upgrades to starttls if offered and authenticates, callers have to close the returned client
+func (s *SMTPSendingService) connect() (*smtp.Client, error) {
 	dial := smtp.Dial
 	if s.config.TLS {
 		dial = func(addr string) (*smtp.Client, error) {
@@ -39,9 +85,8 @@ func (s *SMTPSendingService) Send(mail *models.Mail) error {
 
 	c, err := dial(s.config.ConnStr())
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer c.Close()
 
 	// if server offers starttls, automatically switch to starttls instead
 	// for backwards-compatibility, we switch to starttls even if forced tls was requested
@@ -50,60 +95,38 @@ func (s *SMTPSendingService) Send(mail *models.Mail) error {
 		cNew, err := smtp.DialStartTLS(s.config.ConnStr(), &tls.Config{InsecureSkipVerify: s.config.SkipVerify})
 
 		if err != nil {
+			c.Close()
 			if errSmtp, ok := err.(*smtp.SMTPError); ok {
 				if errSmtp.Code == 503 {
 					// TLS already active
 				}
-				return err
+				return nil, err
 			} else {
-				return err
+				return nil, err
 			}
 		}
 
 		// swap old client with new one
 		c.Close()
 		c = cNew
-		defer c.Close()
 	}
 
 	if s.auth != nil {
 		if ok, _ := c.Extension("AUTH"); !ok {
-			return errors.New("smtp: server doesn't support AUTH")
+			c.Close()
+			return nil, errors.New("smtp: server doesn't support AUTH")
 		}
 
 		if len(s.config.Username) == 0 || len(s.config.Password) == 0 {
-			return errors.New("smtp: server requires authentication, but no authentication is provided")
+			c.Close()
+			return nil, errors.New("smtp: server requires authentication, but no authentication is provided")
 		}
 
 		if err = c.Auth(s.auth); err != nil {
-			return err
-		}
-	}
-
-	if err = c.Mail(mail.From.Raw(), nil); err != nil {
-		return err
-	}
-
-	for _, addr := range mail.To.RawStrings() {
-		if err = c.Rcpt(addr, nil); err != nil {
-			return err
+			c.Close()
+			return nil, err
 		}
 	}
 
-	w, err := c.Data()
-	if err != nil {
-		return err
-	}
-
-	_, err = io.Copy(w, mail.Reader())
-	if err != nil {
-		return err
-	}
-
-	err = w.Close()
-	if err != nil {
-		return err
-	}
-
-	return c.Quit()
+	return c, nil
 }
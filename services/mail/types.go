@@ -1,6 +1,11 @@
 package mail
 
-import "github.com/hackclub/hackatime/models"
+import (
+	"html/template"
+	"time"
+
+	"github.com/hackclub/hackatime/models"
+)
 
 type WelcomeTplData struct {
 	PublicUrl string
@@ -33,3 +38,55 @@ type SubscriptionNotificationTplData struct {
 	HasExpired          bool
 	DataRetentionMonths int
 }
+
+type MachineApprovalTplData struct {
+	PublicUrl string
+	Machine   string
+}
+
+type ApiKeyRevokedTplData struct {
+	PublicUrl string
+	Url       string
+}
+
+type InactivityAlertTplData struct {
+	PublicUrl     string
+	LastHeartbeat string
+}
+
+type LanguageGoalsReportTplData struct {
+	PublicUrl string
+	From      time.Time
+	To        time.Time // last day of the week, inclusive
+	Goals     []*LanguageGoalsReportItem
+}
+
+type LanguageGoalsReportItem struct {
+	Language     string
+	MinPercent   string
+	Percent      string
+	LanguageTime time.Duration
+	Reached      bool
+}
+
+type MentorSummaryTplData struct {
+	PublicUrl string
+	Summary   *models.MentorSummary
+}
+
+type ExportNotificationTplData struct {
+	PublicUrl   string
+	DownloadUrl string
+	Expiry      string
+}
+
+type EmailVerificationTplData struct {
+	PublicUrl        string
+	VerificationLink string
+	Expiry           string
+}
+
+type NotificationTplData struct {
+	PublicUrl string
+	Body      template.HTML // rendered from an operator's template, which is trusted
+}
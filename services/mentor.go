@@ -0,0 +1,178 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"github.com/duke-git/lancet/v2/slice"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/muety/artifex/v2"
+)
+
+var (
+	ErrMentorInvalid  = errors.New("invalid mentor")
+	ErrMentorNotFound = errors.New("mentor not found")
+)
+
+// MentorService manages the mentors of users, e.g. teachers or parents, and sends them a weekly summary of the user's coding time along with the weekly reports.
+// The summary only holds the total and the top project, no files, languages or other details.
+type MentorService struct {
+	config         *config.Config
+	repository     repositories.IMentorRepository
+	userService    IUserService
+	summaryService ISummaryService
+	mailService    IMailService
+	queueDefault   *artifex.Dispatcher
+	queueWorkers   *artifex.Dispatcher
+}
+
+func NewMentorService(mentorRepository repositories.IMentorRepository, userService IUserService, summaryService ISummaryService, mailService IMailService) *MentorService {
+	return &MentorService{
+		config:         config.Get(),
+		repository:     mentorRepository,
+		userService:    userService,
+		summaryService: summaryService,
+		mailService:    mailService,
+		queueDefault:   config.GetDefaultQueue(),
+		queueWorkers:   config.GetQueue(config.QueueReports),
+	}
+}
+
+func (srv *MentorService) Schedule() {
+	slog.Info("scheduling mentor summaries")
+
+	if _, err := srv.queueDefault.DispatchCron(func() {
+		mentors, err := srv.repository.GetAll()
+		if err != nil {
+			config.Log().Error("failed to fetch mentors for weekly summaries", "error", err)
+			return
+		}
+
+		slog.Info("scheduling mentor summaries", "mentorCount", len(mentors))
+		for _, m := range mentors {
+			mentor := m
+			if err := srv.queueWorkers.Dispatch(func() {
+				if err := srv.SendSummary(mentor); err != nil {
+					config.Log().Error("failed to send mentor summary", "userID", mentor.UserID, "mentorID", mentor.ID, "error", err)
+				}
+				time.Sleep(reportDelay)
+			}); err != nil {
+				config.Log().Error("failed to dispatch mentor summary job", "userID", mentor.UserID, "mentorID", mentor.ID, "error", err)
+			}
+		}
+	}, srv.config.App.GetWeeklyReportCron()); err != nil {
+		config.Log().Error("failed to schedule mentor summaries", "error", err)
+	}
+}
+
+func (srv *MentorService) GetByUser(userId string) ([]*models.Mentor, error) {
+	return srv.repository.GetByUser(userId)
+}
+
+func (srv *MentorService) Create(user *models.User, mentor *models.Mentor) (*models.Mentor, error) {
+	if !srv.config.Mail.Enabled {
+		return nil, fmt.Errorf("%w: mail is disabled on this instance", ErrMentorInvalid)
+	}
+	if !user.HasTrustedEmail() {
+		// keeps throwaway accounts from sending mails to arbitrary addresses
+		return nil, fmt.Errorf("%w: you need to set (and verify) an e-mail address first", ErrMentorInvalid)
+	}
+	if !mentor.IsValid() {
+		return nil, fmt.Errorf("%w: invalid e-mail address", ErrMentorInvalid)
+	}
+
+	existing, err := srv.repository.GetByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) >= models.MaxMentorsPerUser {
+		return nil, fmt.Errorf("%w: at most %d mentors are allowed", ErrMentorInvalid, models.MaxMentorsPerUser)
+	}
+	if slice.ContainBy[*models.Mentor](existing, func(m *models.Mentor) bool { return m.Email == mentor.Email }) {
+		return nil, fmt.Errorf("%w: already added", ErrMentorInvalid)
+	}
+
+	token, err := generateShareToken()
+	if err != nil {
+		return nil, err
+	}
+	mentor.ID, mentor.UserID, mentor.Token = 0, user.ID, token
+	return srv.repository.Insert(mentor)
+}
+
+func (srv *MentorService) Delete(user *models.User, id uint) error {
+	mentors, err := srv.repository.GetByUser(user.ID)
+	if err != nil {
+		return err
+	}
+	if !slice.ContainBy[*models.Mentor](mentors, func(m *models.Mentor) bool { return m.ID == id }) {
+		return ErrMentorNotFound
+	}
+	return srv.repository.DeleteByUserAndId(user.ID, id)
+}
+
+func (srv *MentorService) GetByToken(token string) (*models.Mentor, error) {
+	mentor, err := srv.repository.GetByToken(token)
+	if err != nil {
+		return nil, ErrMentorNotFound
+	}
+	return mentor, nil
+}
+
+// Unsubscribe removes the mentor identified by the token of their unsubscribe link
+func (srv *MentorService) Unsubscribe(token string) (*models.Mentor, error) {
+	mentor, err := srv.GetByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if err := srv.repository.DeleteByToken(token); err != nil {
+		return nil, err
+	}
+	return mentor, nil
+}
+
+// BuildSummary sums up the user's coding time of the past seven days
+func (srv *MentorService) BuildSummary(user *models.User, mentor *models.Mentor) (*models.MentorSummary, error) {
+	to := time.Now().In(user.TZ())
+	from := to.Add(-reportRange)
+
+	summary, err := srv.summaryService.Aliased(from, to, user, srv.summaryService.Retrieve, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.MentorSummary{
+		User:           user,
+		From:           from,
+		To:             to,
+		Total:          summary.TotalTime(),
+		UnsubscribeUrl: fmt.Sprintf("%s/mentors/unsubscribe?token=%s", srv.config.Server.GetPublicUrl(), url.QueryEscape(mentor.Token)),
+	}
+	if top := summary.MaxBy(models.SummaryProject); top != nil {
+		result.TopProject, result.TopProjectTotal = top.Key, top.TotalFixed()
+	}
+	return result, nil
+}
+
+// SendSummary mails the user's weekly summary to the mentor, unless the user is suspended, deactivated or a service account
+func (srv *MentorService) SendSummary(mentor *models.Mentor) error {
+	user, err := srv.userService.GetUserById(mentor.UserID)
+	if err != nil {
+		return err
+	}
+	if user.IsServiceAccount || user.Deactivated || user.IsSuspended() {
+		slog.Warn("not sending mentor summary for inactive user", "userID", user.ID, "mentorID", mentor.ID)
+		return nil
+	}
+
+	summary, err := srv.BuildSummary(user, mentor)
+	if err != nil {
+		return err
+	}
+	return srv.mailService.SendMentorSummary(mentor, summary)
+}
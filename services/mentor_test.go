@@ -0,0 +1,118 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMentorService_Create(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Env = "dev" // skips mx lookups of mentor addresses
+	cfg.Mail.Enabled = true
+	config.Set(cfg)
+
+	user := &models.User{ID: TestUserId, Email: "john@example.org"}
+	existing := &models.Mentor{ID: 1, UserID: user.ID, Email: "teacher@example.org"}
+
+	repo := new(mocks.MentorRepositoryMock)
+	repo.On("GetByUser", user.ID).Return([]*models.Mentor{existing}, nil)
+	repo.On("Insert", mock.Anything).Return(&models.Mentor{}, nil)
+
+	sut := NewMentorService(repo, nil, nil, nil)
+
+	_, err := sut.Create(user, models.NewMentor(" Parent@Example.org "))
+	assert.Nil(t, err)
+	inserted := repo.Calls[len(repo.Calls)-1].Arguments.Get(0).(*models.Mentor)
+	assert.Equal(t, "parent@example.org", inserted.Email)
+	assert.Equal(t, user.ID, inserted.UserID)
+	assert.Len(t, inserted.Token, 32)
+
+	for _, email := range []string{"", "not an address", "teacher@example.org"} {
+		_, err := sut.Create(user, models.NewMentor(email))
+		assert.True(t, errors.Is(err, ErrMentorInvalid), email)
+	}
+
+	_, err = sut.Create(&models.User{ID: "jane"}, models.NewMentor("parent@example.org"))
+	assert.True(t, errors.Is(err, ErrMentorInvalid))
+
+	repo.AssertNumberOfCalls(t, "Insert", 1)
+}
+
+func TestMentorService_Create_Limit(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Env = "dev"
+	cfg.Mail.Enabled = true
+	config.Set(cfg)
+
+	user := &models.User{ID: TestUserId, Email: "john@example.org"}
+	existing := make([]*models.Mentor, models.MaxMentorsPerUser)
+	for i := range existing {
+		existing[i] = &models.Mentor{ID: uint(i + 1), UserID: user.ID}
+	}
+
+	repo := new(mocks.MentorRepositoryMock)
+	repo.On("GetByUser", user.ID).Return(existing, nil)
+
+	sut := NewMentorService(repo, nil, nil, nil)
+
+	_, err := sut.Create(user, models.NewMentor("parent@example.org"))
+	assert.True(t, errors.Is(err, ErrMentorInvalid))
+	repo.AssertNotCalled(t, "Insert", mock.Anything)
+}
+
+func TestMentorService_Delete(t *testing.T) {
+	config.Set(config.Empty())
+
+	user := &models.User{ID: TestUserId}
+
+	repo := new(mocks.MentorRepositoryMock)
+	repo.On("GetByUser", user.ID).Return([]*models.Mentor{{ID: 1, UserID: user.ID}}, nil)
+	repo.On("DeleteByUserAndId", user.ID, uint(1)).Return(nil)
+
+	sut := NewMentorService(repo, nil, nil, nil)
+
+	assert.Nil(t, sut.Delete(user, 1))
+	assert.True(t, errors.Is(sut.Delete(user, 2), ErrMentorNotFound))
+	repo.AssertNumberOfCalls(t, "DeleteByUserAndId", 1)
+}
+
+func TestMentorService_SendSummary(t *testing.T) {
+	config.Set(config.Empty())
+
+	suspendedAt := models.CustomTime(time.Now())
+	users := map[string]*models.User{
+		"active":      {ID: "active"},
+		"suspended":   {ID: "suspended", SuspendedAt: &suspendedAt},
+		"deactivated": {ID: "deactivated", Deactivated: true},
+		"service":     {ID: "service", IsServiceAccount: true},
+	}
+
+	userService := new(mocks.UserServiceMock)
+	for id, u := range users {
+		userService.On("GetUserById", id).Return(u, nil)
+	}
+
+	summaryService := new(mocks.SummaryServiceMock)
+	summaryService.On("Aliased", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&models.Summary{}, nil)
+
+	mailService := new(mocks.MailServiceMock)
+	mailService.On("SendMentorSummary", mock.Anything, mock.Anything).Return(nil)
+
+	sut := NewMentorService(nil, userService, summaryService, mailService)
+
+	for id := range users {
+		assert.Nil(t, sut.SendSummary(&models.Mentor{ID: 1, UserID: id, Email: "parent@example.org"}), id)
+	}
+
+	mailService.AssertNumberOfCalls(t, "SendMentorSummary", 1)
+	sent := mailService.Calls[0].Arguments.Get(1).(*models.MentorSummary)
+	assert.Equal(t, "active", sent.User.ID)
+	summaryService.AssertNumberOfCalls(t, "Aliased", 1)
+}
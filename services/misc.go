@@ -224,11 +224,11 @@ func (srv *MiscService) NotifyExpiringSubscription() {
 			}
 		}
 
-		// skip users without e-mail address
+		// skip users without (verified) e-mail address
 		// skip users who already received a notification before
 		// skip users who either never had a subscription before or intentionally deleted it
 		// skip users who have upcoming auto-renewal (everyone except users who chose to cancel subscription at later date)
-		if alreadySent || u.Email == "" || u.SubscribedUntil == nil || (u.SubscriptionRenewal != nil && u.SubscriptionRenewal.T().After(now)) {
+		if alreadySent || !u.HasTrustedEmail() || u.SubscribedUntil == nil || (u.SubscriptionRenewal != nil && u.SubscriptionRenewal.T().After(now)) {
 			continue
 		}
 
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"math"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/models"
+)
+
+var (
+	ErrNotificationTemplateUnsupported = errors.New("unsupported notification kind or channel")
+	ErrNotificationTemplateInvalid     = errors.New("invalid notification template")
+)
+
+// notificationTemplateFuncs are the only functions available to custom templates besides go's builtins, the data they are rendered with only holds plain values
+var notificationTemplateFuncs = map[string]interface{}{
+	"date":     helpers.FormatDateHuman,
+	"datetime": helpers.FormatDateTimeHuman,
+	"duration": helpers.FmtWakatimeDuration,
+	"percent": func(f float64) string {
+		return strconv.FormatFloat(math.Round(f*10)/10, 'f', -1, 64)
+	},
+	"inc": func(i int) int {
+		return i + 1
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// NotificationTemplateService manages the templates operators can replace the built-in content of notifications with, e.g. to brand or translate them.
+// Templates apply to the whole instance and are stored as key-values, one per kind and channel.
+type NotificationTemplateService struct {
+	config          *config.Config
+	keyValueService IKeyValueService
+}
+
+func NewNotificationTemplateService(keyValueService IKeyValueService) *NotificationTemplateService {
+	return &NotificationTemplateService{
+		config:          config.Get(),
+		keyValueService: keyValueService,
+	}
+}
+
+// GetAll returns the custom templates currently in use
+func (srv *NotificationTemplateService) GetAll() ([]*models.NotificationTemplate, error) {
+	kvs, err := srv.keyValueService.GetByPrefix(config.KeyNotificationTemplate + "_")
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make([]*models.NotificationTemplate, 0, len(kvs))
+	for _, kv := range kvs {
+		var t models.NotificationTemplate
+		if err := json.Unmarshal([]byte(kv.Value), &t); err != nil || kv.Key != notificationTemplateKey(t.Kind, t.Channel) {
+			continue // underscores are wildcards in like-queries
+		}
+		templates = append(templates, &t)
+	}
+	return templates, nil
+}
+
+// Get returns the custom template for the kind of notification on the channel, or nil if the built-in content is used
+func (srv *NotificationTemplateService) Get(kind, channel string) (*models.NotificationTemplate, error) {
+	if !models.IsValidNotificationChannel(kind, channel) {
+		return nil, ErrNotificationTemplateUnsupported
+	}
+
+	kv, err := srv.keyValueService.GetString(notificationTemplateKey(kind, channel))
+	if err != nil || kv.Value == "" {
+		return nil, nil
+	}
+	var t models.NotificationTemplate
+	if err := json.Unmarshal([]byte(kv.Value), &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (srv *NotificationTemplateService) Set(t *models.NotificationTemplate) error {
+	if _, err := srv.Preview(t); err != nil {
+		return err
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return srv.keyValueService.PutString(&models.KeyStringValue{Key: notificationTemplateKey(t.Kind, t.Channel), Value: string(data)})
+}
+
+// Reset discards the custom template, so that the built-in content is used again
+func (srv *NotificationTemplateService) Reset(kind, channel string) error {
+	if !models.IsValidNotificationChannel(kind, channel) {
+		return ErrNotificationTemplateUnsupported
+	}
+	return srv.keyValueService.DeleteString(notificationTemplateKey(kind, channel))
+}
+
+// Preview renders the template with placeholder data, which also validates it, as references to unknown fields only fail upon execution
+func (srv *NotificationTemplateService) Preview(t *models.NotificationTemplate) (*models.RenderedNotification, error) {
+	if !models.IsValidNotificationChannel(t.Kind, t.Channel) {
+		return nil, ErrNotificationTemplateUnsupported
+	}
+	if t.Channel != models.NotificationChannelMail {
+		t.Subject = ""
+	}
+	if strings.TrimSpace(t.Template) == "" || len(t.Template)+len(t.Subject) > models.NotificationTemplateMaxLength {
+		return nil, ErrNotificationTemplateInvalid
+	}
+
+	rendered, err := renderNotificationTemplate(t, sampleNotificationData(t.Kind))
+	if err != nil {
+		return nil, errors.Join(ErrNotificationTemplateInvalid, err)
+	}
+	return rendered, nil
+}
+
+// Render renders the custom template for the kind of notification on the channel, or returns nil if the built-in content is to be used
+func (srv *NotificationTemplateService) Render(kind, channel string, data interface{}) (*models.RenderedNotification, error) {
+	t, err := srv.Get(kind, channel)
+	if err != nil || t == nil {
+		return nil, err
+	}
+	return renderNotificationTemplate(t, data)
+}
+
+func notificationTemplateKey(kind, channel string) string {
+	return fmt.Sprintf("%s_%s_%s", config.KeyNotificationTemplate, kind, channel)
+}
+
+func renderNotificationTemplate(t *models.NotificationTemplate, data interface{}) (*models.RenderedNotification, error) {
+	var rendered models.RenderedNotification
+	var err error
+
+	if t.Subject != "" {
+		if rendered.Subject, err = executeTextTemplate(t.Subject, data); err != nil {
+			return nil, err
+		}
+	}
+	if t.Channel == models.NotificationChannelMail {
+		rendered.Body, err = executeHtmlTemplate(t.Template, data) // escapes the data, e.g. user names
+	} else {
+		rendered.Body, err = executeTextTemplate(t.Template, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rendered, nil
+}
+
+func executeTextTemplate(text string, data interface{}) (string, error) {
+	tpl, err := template.New("notification").Funcs(notificationTemplateFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func executeHtmlTemplate(text string, data interface{}) (string, error) {
+	tpl, err := htmltemplate.New("notification").Funcs(notificationTemplateFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func sampleNotificationData(kind string) interface{} {
+	publicUrl := config.Get().Server.GetPublicUrl()
+	weekStart := datetime.BeginOfWeek(time.Now().AddDate(0, 0, -7), time.Monday)
+
+	switch kind {
+	case models.NotificationKindInactivityAlert:
+		return &models.InactivityAlertNotification{
+			UserID:        "john",
+			Name:          "John Doe",
+			PublicUrl:     publicUrl,
+			LastHeartbeat: time.Now().Add(-50 * time.Hour),
+		}
+	case models.NotificationKindLanguageGoalsReport:
+		return &models.LanguageGoalsReportNotification{
+			UserID:    "john",
+			Name:      "John Doe",
+			PublicUrl: publicUrl,
+			From:      weekStart,
+			To:        weekStart.AddDate(0, 0, 6),
+			Goals: []*models.LanguageGoalsReportNotificationItem{
+				{Language: "Go", MinPercent: 50, Percent: 62.5, LanguageTime: 10 * time.Hour, Reached: true},
+				{Language: "Rust", Category: "coding", MinPercent: 25, Percent: 12.5, LanguageTime: 2 * time.Hour},
+			},
+		}
+	case models.NotificationKindSeasonWinners:
+		return &models.SeasonWinnersNotification{
+			Season: models.SeasonName(config.SeasonPeriodWeekly, weekStart),
+			From:   weekStart,
+			To:     weekStart.AddDate(0, 0, 7),
+			Winners: []*models.SeasonWinnersNotificationItem{
+				{Rank: 1, UserID: "john", Total: 30 * time.Hour},
+				{Rank: 2, UserID: "jane", Total: 25 * time.Hour},
+			},
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNotificationTemplateService_Set(t *testing.T) {
+	config.Set(config.Empty())
+
+	keyValueServiceMock := new(mocks.KeyValueServiceMock)
+	keyValueServiceMock.On("PutString", mock.Anything).Return(nil)
+
+	sut := NewNotificationTemplateService(keyValueServiceMock)
+
+	err := sut.Set(&models.NotificationTemplate{Kind: models.NotificationKindSeasonWinners, Channel: models.NotificationChannelMail, Template: "hello"})
+	assert.ErrorIs(t, err, ErrNotificationTemplateUnsupported)
+
+	err = sut.Set(&models.NotificationTemplate{Kind: models.NotificationKindInactivityAlert, Channel: models.NotificationChannelTelegram, Template: "{{ .Unknown }}"})
+	assert.ErrorIs(t, err, ErrNotificationTemplateInvalid)
+
+	err = sut.Set(&models.NotificationTemplate{Kind: models.NotificationKindInactivityAlert, Channel: models.NotificationChannelTelegram, Template: "{{ exec }}"})
+	assert.ErrorIs(t, err, ErrNotificationTemplateInvalid)
+
+	err = sut.Set(&models.NotificationTemplate{Kind: models.NotificationKindInactivityAlert, Channel: models.NotificationChannelTelegram, Template: " "})
+	assert.ErrorIs(t, err, ErrNotificationTemplateInvalid)
+	keyValueServiceMock.AssertNotCalled(t, "PutString", mock.Anything)
+
+	tpl := &models.NotificationTemplate{Kind: models.NotificationKindInactivityAlert, Channel: models.NotificationChannelTelegram, Subject: "ignored", Template: "Hallo {{ .Name }}!"}
+	assert.Nil(t, sut.Set(tpl))
+	assert.Empty(t, tpl.Subject)
+	keyValueServiceMock.AssertCalled(t, "PutString", &models.KeyStringValue{
+		Key:   "notification_template_inactivity_alert_telegram",
+		Value: `{"kind":"inactivity_alert","channel":"telegram","template":"Hallo {{ .Name }}!"}`,
+	})
+}
+
+func TestNotificationTemplateService_Render(t *testing.T) {
+	config.Set(config.Empty())
+
+	keyValueServiceMock := new(mocks.KeyValueServiceMock)
+	keyValueServiceMock.On("GetString", "notification_template_language_goals_report_mail").Return(&models.KeyStringValue{
+		Value: `{"kind":"language_goals_report","channel":"mail","subject":"Goals of {{ .Name }}","template":"<p>{{ .Name }}</p>{{ range .Goals }}<p>{{ upper .Language }}: {{ percent .Percent }} %</p>{{ end }}"}`,
+	}, nil)
+	keyValueServiceMock.On("GetString", mock.Anything).Return((*models.KeyStringValue)(nil), errors.New("not found"))
+
+	sut := NewNotificationTemplateService(keyValueServiceMock)
+
+	data := &models.LanguageGoalsReportNotification{
+		Name:  "<b>John</b>",
+		Goals: []*models.LanguageGoalsReportNotificationItem{{Language: "Go", Percent: 62.54, LanguageTime: time.Hour}},
+	}
+
+	rendered, err := sut.Render(models.NotificationKindLanguageGoalsReport, models.NotificationChannelMail, data)
+	assert.Nil(t, err)
+	assert.Equal(t, "Goals of <b>John</b>", rendered.Subject)
+	assert.Equal(t, "<p>&lt;b&gt;John&lt;/b&gt;</p><p>GO: 62.5 %</p>", rendered.Body)
+
+	rendered, err = sut.Render(models.NotificationKindLanguageGoalsReport, models.NotificationChannelTelegram, data)
+	assert.Nil(t, err)
+	assert.Nil(t, rendered)
+}
@@ -0,0 +1,116 @@
+package services
+
+import (
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/services/objects"
+	"github.com/muety/artifex/v2"
+)
+
+const objectsCleanupEvery = 1 * time.Hour
+
+type ObjectStorageService struct {
+	config       *config.Config
+	store        objects.ObjectStore
+	queueDefault *artifex.Dispatcher
+}
+
+func NewObjectStorageService() *ObjectStorageService {
+	conf := config.Get()
+	return &ObjectStorageService{
+		config:       conf,
+		store:        objects.GetStore(conf),
+		queueDefault: config.GetDefaultQueue(),
+	}
+}
+
+func (srv *ObjectStorageService) Schedule() {
+	slog.Info("scheduling expired objects cleanup", "provider", srv.store.Name())
+
+	if _, err := srv.queueDefault.DispatchEvery(srv.runCleanup, objectsCleanupEvery); err != nil {
+		config.Log().Error("failed to schedule expired objects cleanup", "error", err)
+	}
+}
+
+// Store persists the given artifact and returns a signed, time-limited download link for it
+func (srv *ObjectStorageService) Store(key string, data []byte, contentType string) (string, error) {
+	if err := srv.store.Put(key, data, contentType); err != nil {
+		return "", err
+	}
+	return srv.SignedUrl(key)
+}
+
+func (srv *ObjectStorageService) SignedUrl(key string) (string, error) {
+	return srv.store.SignedUrl(key, srv.config.Objects.GetLinkExpiry())
+}
+
+// Retrieve returns a locally stored object after verifying the download link's signature
+// objects in s3 are downloaded from the storage directly instead
+func (srv *ObjectStorageService) Retrieve(key, expires, signature string) ([]byte, error) {
+	localStore, ok := srv.store.(*objects.LocalStore)
+	if !ok {
+		return nil, objects.ErrNotFound
+	}
+	if err := localStore.Verify(key, expires, signature); err != nil {
+		return nil, err
+	}
+	return localStore.Get(key)
+}
+
+// SignedUploadUrl returns a time-limited target to upload an object of at most maxSize bytes to, either a presigned post policy
+// for s3 or a link pointing to the objects api endpoint
+func (srv *ObjectStorageService) SignedUploadUrl(key string, expiry time.Duration, maxSize int64) (*objects.UploadTarget, error) {
+	return srv.store.SignedUploadUrl(key, expiry, maxSize)
+}
+
+// Upload appends a chunk to a locally stored upload after verifying the upload link's signature and returns the size uploaded so far
+// uploads to s3 are sent to the storage directly instead
+func (srv *ObjectStorageService) Upload(key, expires, signature string, offset int64, data io.Reader, maxSize int64) (int64, error) {
+	localStore, ok := srv.store.(*objects.LocalStore)
+	if !ok {
+		return 0, objects.ErrNotFound
+	}
+	if err := localStore.VerifyUpload(key, expires, signature); err != nil {
+		return 0, err
+	}
+	return localStore.Append(key, offset, data, maxSize)
+}
+
+// CompleteUpload makes a local upload readable and rejects any further chunks, s3 uploads are complete once they were put
+func (srv *ObjectStorageService) CompleteUpload(key string) error {
+	if localStore, ok := srv.store.(*objects.LocalStore); ok {
+		return localStore.Commit(key)
+	}
+	return nil
+}
+
+// Size returns the size of a stored object, local uploads have to be completed first
+func (srv *ObjectStorageService) Size(key string) (int64, error) {
+	return srv.store.Size(key)
+}
+
+func (srv *ObjectStorageService) Open(key string) (io.ReadCloser, error) {
+	return srv.store.Open(key)
+}
+
+func (srv *ObjectStorageService) Delete(key string) error {
+	return srv.store.Delete(key)
+}
+
+func (srv *ObjectStorageService) DeleteExpired() (int, error) {
+	return srv.store.DeleteExpired(srv.config.Objects.GetTtl())
+}
+
+func (srv *ObjectStorageService) runCleanup() {
+	n, err := srv.DeleteExpired()
+	if err != nil {
+		config.Log().Error("failed to delete expired objects", "provider", srv.store.Name(), "error", err)
+		return
+	}
+	if n > 0 {
+		slog.Info("deleted expired objects", "provider", srv.store.Name(), "count", n)
+	}
+}
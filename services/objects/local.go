@@ -0,0 +1,257 @@
+package objects
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const uploadSignaturePrefix = "upload:" // keys can't contain colons, so upload and download signatures never collide
+
+// LocalStore keeps objects in a directory on disk, download links point to the objects api endpoint and are signed with hmac-sha256.
+// uploads are sent to the same endpoint in one or more chunks and only become an object once completed, see Append and Commit.
+type LocalStore struct {
+	path    string
+	baseUrl string
+	secret  []byte
+}
+
+func NewLocalStore(path, baseUrl string, secret []byte) *LocalStore {
+	return &LocalStore{
+		path:    path,
+		baseUrl: baseUrl,
+		secret:  secret,
+	}
+}
+
+func (s *LocalStore) Name() string {
+	return "local"
+}
+
+// Put writes the object to disk, the content type is later inferred from the key's file extension
+func (s *LocalStore) Put(key string, data []byte, contentType string) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+
+	target := s.resolve(key)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+
+	// write to temporary file first to not serve partial objects
+	tmp := target + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, target)
+}
+
+func (s *LocalStore) Get(key string) ([]byte, error) {
+	if !ValidKey(key) {
+		return nil, ErrInvalidKey
+	}
+	data, err := os.ReadFile(s.resolve(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (s *LocalStore) Open(key string) (io.ReadCloser, error) {
+	if !ValidKey(key) {
+		return nil, ErrInvalidKey
+	}
+	f, err := os.Open(s.resolve(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return f, err
+}
+
+func (s *LocalStore) Delete(key string) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+	for _, target := range []string{s.resolve(key), s.resolvePart(key)} {
+		if err := os.Remove(target); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *LocalStore) SignedUrl(key string, expiry time.Duration) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", s.sign(key, expires))
+	return fmt.Sprintf("%s/api/objects/%s?%s", s.baseUrl, key, query.Encode()), nil
+}
+
+// SignedUploadUrl returns a link to put chunks of the object to, its signature differs from the download link's one
+// the maximum size isn't part of the link, but has to be enforced by the caller of Append
+func (s *LocalStore) SignedUploadUrl(key string, expiry time.Duration, maxSize int64) (*UploadTarget, error) {
+	if !ValidKey(key) {
+		return nil, ErrInvalidKey
+	}
+	expires := strconv.FormatInt(time.Now().Add(expiry).Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("signature", s.sign(uploadSignaturePrefix+key, expires))
+	return &UploadTarget{
+		Url:    fmt.Sprintf("%s/api/objects/%s?%s", s.baseUrl, key, query.Encode()),
+		Method: http.MethodPut,
+	}, nil
+}
+
+// Size returns the size of a stored or completely uploaded object
+func (s *LocalStore) Size(key string) (int64, error) {
+	if !ValidKey(key) {
+		return 0, ErrInvalidKey
+	}
+	info, err := os.Stat(s.resolve(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// Verify checks a signature created by SignedUrl and whether the link is still valid
+func (s *LocalStore) Verify(key, expires, signature string) error {
+	return s.verify(key, expires, signature)
+}
+
+// VerifyUpload checks a signature created by SignedUploadUrl and whether the link is still valid
+func (s *LocalStore) VerifyUpload(key, expires, signature string) error {
+	return s.verify(uploadSignaturePrefix+key, expires, signature)
+}
+
+// Append writes a chunk of an upload, which starts at the given offset. Chunks must be sent in order, so the offset has to match
+// the size uploaded so far, which is returned in any case, to let clients resume interrupted uploads.
+func (s *LocalStore) Append(key string, offset int64, data io.Reader, maxSize int64) (int64, error) {
+	if !ValidKey(key) {
+		return 0, ErrInvalidKey
+	}
+	if _, err := os.Stat(s.resolve(key)); err == nil {
+		return 0, ErrUploadCompleted
+	}
+
+	target := s.resolvePart(key)
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	size, err := f.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, err
+	}
+	if offset != size {
+		return size, ErrUploadOffset
+	}
+
+	n, err := io.Copy(f, io.LimitReader(data, maxSize-size+1))
+	if err == nil && size+n > maxSize {
+		err = ErrUploadTooLarge
+	}
+	if err != nil {
+		// discard the partially written chunk, so that it can be sent again
+		if truncErr := f.Truncate(size); truncErr != nil {
+			return size, errors.Join(err, truncErr)
+		}
+		return size, err
+	}
+	return size + n, nil
+}
+
+// Commit completes an upload, after which it can be read as an object, but not be appended to anymore
+func (s *LocalStore) Commit(key string) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+	if _, err := os.Stat(s.resolve(key)); err == nil {
+		return ErrUploadCompleted
+	}
+	err := os.Rename(s.resolvePart(key), s.resolve(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+// DeleteExpired removes all objects, which were last written longer ago than the given ttl
+func (s *LocalStore) DeleteExpired(ttl time.Duration) (int, error) {
+	var count int
+	cutoff := time.Now().Add(-ttl)
+
+	err := filepath.WalkDir(s.path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().Before(cutoff) {
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+
+	return count, err
+}
+
+func (s *LocalStore) resolve(key string) string {
+	return filepath.Join(s.path, filepath.FromSlash(key))
+}
+
+func (s *LocalStore) resolvePart(key string) string {
+	return s.resolve(key) + ".part"
+}
+
+func (s *LocalStore) verify(subject, expires, signature string) error {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return ErrInvalidSignature
+	}
+	if !hmac.Equal([]byte(s.sign(subject, expires)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func (s *LocalStore) sign(key, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
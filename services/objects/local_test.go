@@ -0,0 +1,111 @@
+package objects
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidKey(t *testing.T) {
+	assert.True(t, ValidKey("exports/hackatime_2024-01-01_2024-01-08.csv"))
+	assert.True(t, ValidKey("avatar.svg"))
+	assert.False(t, ValidKey(""))
+	assert.False(t, ValidKey("/etc/passwd"))
+	assert.False(t, ValidKey("../secret"))
+	assert.False(t, ValidKey("exports/../../secret"))
+	assert.False(t, ValidKey("exports//foo"))
+	assert.False(t, ValidKey("exports/foo bar"))
+}
+
+func TestLocalStore_SignedUrl(t *testing.T) {
+	sut := NewLocalStore(t.TempDir(), "http://localhost:3000", []byte("secret"))
+
+	assert.Nil(t, sut.Put("exports/foo.csv", []byte("foo"), "text/csv"))
+
+	link, err := sut.SignedUrl("exports/foo.csv", time.Hour)
+	assert.Nil(t, err)
+
+	u, _ := url.Parse(link)
+	assert.Equal(t, "/api/objects/exports/foo.csv", u.Path)
+
+	expires, signature := u.Query().Get("expires"), u.Query().Get("signature")
+	assert.Nil(t, sut.Verify("exports/foo.csv", expires, signature))
+	assert.ErrorIs(t, sut.Verify("exports/bar.csv", expires, signature), ErrInvalidSignature)
+	assert.ErrorIs(t, sut.Verify("exports/foo.csv", "1", signature), ErrInvalidSignature)
+	assert.ErrorIs(t, NewLocalStore(t.TempDir(), "", []byte("other")).Verify("exports/foo.csv", expires, signature), ErrInvalidSignature)
+
+	data, err := sut.Get("exports/foo.csv")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("foo"), data)
+}
+
+func TestLocalStore_Upload(t *testing.T) {
+	sut := NewLocalStore(t.TempDir(), "http://localhost:3000", []byte("secret"))
+
+	target, err := sut.SignedUploadUrl("imports/foo.json", time.Hour, 16)
+	assert.Nil(t, err)
+	assert.Equal(t, http.MethodPut, target.Method)
+
+	u, _ := url.Parse(target.Url)
+	expires, signature := u.Query().Get("expires"), u.Query().Get("signature")
+	assert.Nil(t, sut.VerifyUpload("imports/foo.json", expires, signature))
+	assert.ErrorIs(t, sut.Verify("imports/foo.json", expires, signature), ErrInvalidSignature) // upload links can't be used for downloading
+
+	size, err := sut.Append("imports/foo.json", 0, strings.NewReader("{\"days\":"), 16)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(8), size)
+
+	size, err = sut.Append("imports/foo.json", 0, strings.NewReader("[]}"), 16)
+	assert.ErrorIs(t, err, ErrUploadOffset)
+	assert.Equal(t, int64(8), size)
+
+	size, err = sut.Append("imports/foo.json", 8, strings.NewReader("[], \"foo\": \"bar\"}"), 16)
+	assert.ErrorIs(t, err, ErrUploadTooLarge)
+	assert.Equal(t, int64(8), size)
+
+	_, err = sut.Get("imports/foo.json")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = sut.Size("imports/foo.json")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	size, err = sut.Append("imports/foo.json", 8, strings.NewReader("[]}"), 16)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(11), size)
+
+	assert.Nil(t, sut.Commit("imports/foo.json"))
+	assert.ErrorIs(t, sut.Commit("imports/foo.json"), ErrUploadCompleted)
+	_, err = sut.Append("imports/foo.json", 12, strings.NewReader(" "), 16)
+	assert.ErrorIs(t, err, ErrUploadCompleted)
+
+	data, err := sut.Get("imports/foo.json")
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("{\"days\":[]}"), data)
+
+	size, err = sut.Size("imports/foo.json")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(11), size)
+}
+
+func TestLocalStore_DeleteExpired(t *testing.T) {
+	sut := NewLocalStore(t.TempDir(), "", []byte("secret"))
+
+	assert.Nil(t, sut.Put("exports/old.csv", []byte("old"), "text/csv"))
+	assert.Nil(t, sut.Put("exports/new.csv", []byte("new"), "text/csv"))
+
+	old := time.Now().Add(-48 * time.Hour)
+	assert.Nil(t, os.Chtimes(sut.resolve("exports/old.csv"), old, old))
+
+	n, err := sut.DeleteExpired(24 * time.Hour)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, n)
+
+	_, err = sut.Get("exports/old.csv")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = sut.Get("exports/new.csv")
+	assert.Nil(t, err)
+}
@@ -0,0 +1,66 @@
+package objects
+
+import (
+	"errors"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+)
+
+var (
+	ErrInvalidKey       = errors.New("invalid object key")
+	ErrNotFound         = errors.New("object not found")
+	ErrInvalidSignature = errors.New("invalid or expired signature")
+	ErrUploadCompleted  = errors.New("upload was already completed")
+	ErrUploadOffset     = errors.New("chunk offset does not match the uploaded size")
+	ErrUploadTooLarge   = errors.New("upload exceeds the maximum size")
+)
+
+var keyRegex = regexp.MustCompile(`^[a-zA-Z0-9_\-.]+(/[a-zA-Z0-9_\-.]+)*$`)
+
+// ObjectStore persists generated artifacts (e.g. export archives) and hands out time-limited download links for them.
+// it also hands out upload links for files too large to be sent to the api directly (e.g. import archives), which are read back as a stream.
+type ObjectStore interface {
+	Name() string
+	Put(key string, data []byte, contentType string) error
+	Get(key string) ([]byte, error)
+	Open(key string) (io.ReadCloser, error)
+	Delete(key string) error
+	SignedUrl(key string, expiry time.Duration) (string, error)
+	SignedUploadUrl(key string, expiry time.Duration, maxSize int64) (*UploadTarget, error)
+	Size(key string) (int64, error)
+	DeleteExpired(ttl time.Duration) (int, error)
+}
+
+// UploadTarget tells a client where and how to upload an object to
+type UploadTarget struct {
+	Url    string
+	Method string
+	Fields map[string]string // form fields to send along with a multipart post request, followed by the object as field "file"
+}
+
+// GetStore returns the object store for the configured provider
+func GetStore(c *config.Config) ObjectStore {
+	if c.Objects.Provider == config.ObjectsProviderS3 {
+		return NewS3Store(c.Objects.S3)
+	}
+	return NewLocalStore(c.Objects.Path, c.Server.GetPublicUrl(), []byte(c.Objects.SigningSecret))
+}
+
+// ValidKey checks whether the given key is a relative, slash-separated path without any traversal
+func ValidKey(key string) bool {
+	return keyRegex.MatchString(key) && path.Clean(key) == key && !hasDotSegment(key)
+}
+
+func hasDotSegment(key string) bool {
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "." || segment == ".." {
+			return true
+		}
+	}
+	return false
+}
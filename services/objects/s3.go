@@ -0,0 +1,236 @@
+package objects
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/utils"
+)
+
+// S3Store keeps objects in an s3-compatible object storage using path-style urls, download links are presigned urls
+// see https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-query-string-auth.html
+type S3Store struct {
+	config     config.ObjectsS3Config
+	creds      utils.AwsCredentials
+	httpClient *http.Client
+}
+
+type s3ListBucketResult struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+func NewS3Store(config config.ObjectsS3Config) *S3Store {
+	if config.Endpoint == "" {
+		config.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", config.Region)
+	}
+	return &S3Store{
+		config:     config,
+		creds:      utils.AwsCredentials{Region: config.Region, AccessKey: config.AccessKey, SecretKey: config.SecretKey},
+		httpClient: &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+func (s *S3Store) Name() string {
+	return "s3"
+}
+
+func (s *S3Store) Put(key string, data []byte, contentType string) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+
+	req, err := http.NewRequest(http.MethodPut, s.objectUrl(key).String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	utils.SignAwsV4(req, data, s.creds, time.Now().UTC())
+
+	_, err = s.do(req)
+	return err
+}
+
+func (s *S3Store) Get(key string) ([]byte, error) {
+	if !ValidKey(key) {
+		return nil, ErrInvalidKey
+	}
+	return s.doPresigned(http.MethodGet, s.objectUrl(key))
+}
+
+// Open streams the object, without a timeout, as objects opened this way (e.g. uploaded import archives) may be huge
+func (s *S3Store) Open(key string) (io.ReadCloser, error) {
+	if !ValidKey(key) {
+		return nil, ErrInvalidKey
+	}
+
+	u := utils.PresignAwsV4(http.MethodGet, s.objectUrl(key), s.creds, 5*time.Minute, time.Now().UTC())
+	res, err := (&http.Client{}).Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		res.Body.Close()
+		return nil, ErrNotFound
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		res.Body.Close()
+		return nil, fmt.Errorf("s3 responded with status %d", res.StatusCode)
+	}
+	return res.Body, nil
+}
+
+func (s *S3Store) Delete(key string) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+	_, err := s.doPresigned(http.MethodDelete, s.objectUrl(key))
+	return err
+}
+
+func (s *S3Store) SignedUrl(key string, expiry time.Duration) (string, error) {
+	if !ValidKey(key) {
+		return "", ErrInvalidKey
+	}
+	return utils.PresignAwsV4(http.MethodGet, s.objectUrl(key), s.creds, expiry, time.Now().UTC()).String(), nil
+}
+
+// SignedUploadUrl returns a presigned post policy to upload the object with in a single multipart request (up to 5 gb).
+// unlike a presigned put url, the policy lets s3 reject objects larger than the given maximum size. it can't be revoked though,
+// so anything uploaded once again after the object was consumed is left to DeleteExpired.
+func (s *S3Store) SignedUploadUrl(key string, expiry time.Duration, maxSize int64) (*UploadTarget, error) {
+	if !ValidKey(key) {
+		return nil, ErrInvalidKey
+	}
+
+	conditions := []interface{}{
+		map[string]string{"bucket": s.config.Bucket},
+		map[string]string{"key": s.config.Prefix + key},
+		[]interface{}{"content-length-range", 1, maxSize},
+	}
+	fields, err := utils.SignAwsV4PostPolicy(conditions, s.creds, expiry, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	fields["key"] = s.config.Prefix + key
+
+	return &UploadTarget{Url: s.bucketUrl().String(), Method: http.MethodPost, Fields: fields}, nil
+}
+
+// Size returns the size of the object as reported by s3 upon a head request
+func (s *S3Store) Size(key string) (int64, error) {
+	if !ValidKey(key) {
+		return 0, ErrInvalidKey
+	}
+
+	u := utils.PresignAwsV4(http.MethodHead, s.objectUrl(key), s.creds, 5*time.Minute, time.Now().UTC())
+	res, err := s.httpClient.Head(u.String())
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return 0, ErrNotFound
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return 0, fmt.Errorf("s3 responded with status %d", res.StatusCode)
+	}
+	return res.ContentLength, nil
+}
+
+// DeleteExpired removes all objects below the configured prefix, which were last modified longer ago than the given ttl
+// alternatively, consider setting up a lifecycle rule for the bucket
+func (s *S3Store) DeleteExpired(ttl time.Duration) (int, error) {
+	var count int
+	var continuationToken string
+	cutoff := time.Now().Add(-ttl)
+
+	for {
+		listUrl := s.bucketUrl()
+		query := url.Values{}
+		query.Set("list-type", "2")
+		if s.config.Prefix != "" {
+			query.Set("prefix", s.config.Prefix)
+		}
+		if continuationToken != "" {
+			query.Set("continuation-token", continuationToken)
+		}
+		listUrl.RawQuery = query.Encode()
+
+		body, err := s.doPresigned(http.MethodGet, listUrl)
+		if err != nil {
+			return count, err
+		}
+
+		var result s3ListBucketResult
+		if err := xml.Unmarshal(body, &result); err != nil {
+			return count, err
+		}
+
+		for _, obj := range result.Contents {
+			if !obj.LastModified.Before(cutoff) {
+				continue
+			}
+			if _, err := s.doPresigned(http.MethodDelete, s.rawObjectUrl(obj.Key)); err != nil {
+				return count, err
+			}
+			count++
+		}
+
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return count, nil
+		}
+		continuationToken = result.NextContinuationToken
+	}
+}
+
+func (s *S3Store) bucketUrl() *url.URL {
+	u, _ := url.Parse(fmt.Sprintf("%s/%s", strings.TrimSuffix(s.config.Endpoint, "/"), s.config.Bucket))
+	return u
+}
+
+func (s *S3Store) objectUrl(key string) *url.URL {
+	return s.rawObjectUrl(s.config.Prefix + key)
+}
+
+func (s *S3Store) rawObjectUrl(fullKey string) *url.URL {
+	u := s.bucketUrl()
+	return u.JoinPath(fullKey)
+}
+
+func (s *S3Store) doPresigned(method string, u *url.URL) ([]byte, error) {
+	req, err := http.NewRequest(method, utils.PresignAwsV4(method, u, s.creds, 5*time.Minute, time.Now().UTC()).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.do(req)
+}
+
+func (s *S3Store) do(req *http.Request) ([]byte, error) {
+	res, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, _ := io.ReadAll(res.Body)
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, fmt.Errorf("s3 responded with status %d: %s", res.StatusCode, string(body))
+	}
+	return body, nil
+}
@@ -0,0 +1,60 @@
+package objects
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestS3Store_SignedUploadUrl(t *testing.T) {
+	sut := NewS3Store(config.ObjectsS3Config{Endpoint: "https://s3.example.org", Bucket: "hackatime", Region: "eu-central-1", AccessKey: "key", SecretKey: "secret", Prefix: "objects/"})
+
+	target, err := sut.SignedUploadUrl("imports/foo.json", time.Hour, 1024)
+	assert.Nil(t, err)
+	assert.Equal(t, http.MethodPost, target.Method)
+	assert.Equal(t, "https://s3.example.org/hackatime", target.Url)
+	assert.Equal(t, "objects/imports/foo.json", target.Fields["key"])
+	assert.Len(t, target.Fields["x-amz-signature"], 64)
+
+	raw, err := base64.StdEncoding.DecodeString(target.Fields["policy"])
+	assert.Nil(t, err)
+
+	var policy struct {
+		Expiration string        `json:"expiration"`
+		Conditions []interface{} `json:"conditions"`
+	}
+	assert.Nil(t, json.Unmarshal(raw, &policy))
+	assert.Contains(t, policy.Conditions, map[string]interface{}{"key": "objects/imports/foo.json"})
+	assert.Contains(t, policy.Conditions, []interface{}{"content-length-range", float64(1), float64(1024)})
+	assert.Contains(t, policy.Conditions, map[string]interface{}{"x-amz-credential": target.Fields["x-amz-credential"]})
+
+	_, err = sut.SignedUploadUrl("../foo.json", time.Hour, 1024)
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
+
+func TestS3Store_Size(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodHead, r.Method)
+		if r.URL.Path != "/hackatime/objects/imports/foo.json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", "2048")
+	}))
+	defer server.Close()
+
+	sut := NewS3Store(config.ObjectsS3Config{Endpoint: server.URL, Bucket: "hackatime", Region: "eu-central-1", Prefix: "objects/"})
+
+	size, err := sut.Size("imports/foo.json")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2048), size)
+
+	_, err = sut.Size("imports/bar.json")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
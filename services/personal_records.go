@@ -0,0 +1,159 @@
+package services
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/hackclub/hackatime/utils"
+	"github.com/muety/artifex/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	recordsUpdateEvery = 6 * time.Hour
+	recordsChunkDays   = 30 // number of days of heartbeats to load at once
+)
+
+// PersonalRecordsService keeps users' personal records up to date by processing every completed day once, instead of scanning their entire history on every request
+type PersonalRecordsService struct {
+	config        *config.Config
+	repository    repositories.IPersonalRecordsRepository
+	heartbeatSrvc IHeartbeatService
+	userSrvc      IUserService
+	queueDefault  *artifex.Dispatcher
+	queueWorkers  *artifex.Dispatcher
+	pending       sync.Map
+}
+
+func NewPersonalRecordsService(personalRecordsRepo repositories.IPersonalRecordsRepository, heartbeatService IHeartbeatService, userService IUserService) *PersonalRecordsService {
+	return &PersonalRecordsService{
+		config:        config.Get(),
+		repository:    personalRecordsRepo,
+		heartbeatSrvc: heartbeatService,
+		userSrvc:      userService,
+		queueDefault:  config.GetDefaultQueue(),
+		queueWorkers:  config.GetQueue(config.QueueProcessing),
+	}
+}
+
+func (srv *PersonalRecordsService) Schedule() {
+	slog.Info("scheduling personal records updates")
+
+	if _, err := srv.queueDefault.DispatchEvery(srv.runUpdateAll, recordsUpdateEvery); err != nil {
+		config.Log().Error("failed to schedule personal records updates", "error", err)
+	}
+}
+
+// GetByUser returns the user's records, if computed already, otherwise an update is triggered in the background and nil is returned
+func (srv *PersonalRecordsService) GetByUser(user *models.User) (*models.PersonalRecords, error) {
+	records, err := srv.repository.GetByUser(user.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if records == nil {
+		srv.dispatchUpdate(user, nil)
+	}
+	return records, nil
+}
+
+// UpdateByUser processes all of the user's heartbeats of completed days, which weren't processed before
+// first is the time of the user's very first heartbeat and only needed if no records exist, yet
+func (srv *PersonalRecordsService) UpdateByUser(user *models.User, first *time.Time) error {
+	records, err := srv.repository.GetByUser(user.ID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	if records == nil {
+		if first == nil {
+			if first, err = srv.getFirstHeartbeatTime(user); err != nil || first == nil {
+				return err
+			}
+		}
+		records = models.NewPersonalRecords(user)
+		records.ProcessedUntil = models.CustomTime(datetime.BeginOfDay(first.In(user.TZ())))
+	}
+
+	until := utils.BeginOfToday(user.TZ())
+	for from := records.ProcessedUntil.T(); from.Before(until); {
+		to := from.AddDate(0, 0, recordsChunkDays)
+		if to.After(until) {
+			to = until
+		}
+
+		heartbeats, err := srv.heartbeatSrvc.GetAllWithin(from, to, user)
+		if err != nil {
+			return err
+		}
+		records.Process(heartbeats, to, user.HeartbeatsTimeout(), user.TZ(), user.WeekStart())
+
+		// persist after every chunk to not start over if a user's long history fails to process at some point
+		if err := srv.repository.Upsert(records); err != nil {
+			return err
+		}
+		from = to
+	}
+
+	return nil
+}
+
+func (srv *PersonalRecordsService) runUpdateAll() {
+	firstHeartbeats, err := srv.heartbeatSrvc.GetFirstByUsers()
+	if err != nil {
+		config.Log().Error("failed to fetch first heartbeats for personal records update", "error", err)
+		return
+	}
+
+	users, err := srv.userSrvc.GetAllMapped()
+	if err != nil {
+		config.Log().Error("failed to fetch users for personal records update", "error", err)
+		return
+	}
+
+	slog.Info("updating personal records", "userCount", len(firstHeartbeats))
+
+	for _, entry := range firstHeartbeats {
+		user, ok := users[entry.User]
+		if !ok || entry.Time.T().IsZero() {
+			continue
+		}
+		first := entry.Time.T()
+		srv.dispatchUpdate(user, &first)
+	}
+}
+
+func (srv *PersonalRecordsService) dispatchUpdate(user *models.User, first *time.Time) {
+	if _, running := srv.pending.LoadOrStore(user.ID, true); running {
+		return
+	}
+
+	if err := srv.queueWorkers.Dispatch(func() {
+		defer srv.pending.Delete(user.ID)
+		if err := srv.UpdateByUser(user, first); err != nil {
+			config.Log().Error("failed to update personal records", "userID", user.ID, "error", err)
+		}
+	}); err != nil {
+		srv.pending.Delete(user.ID)
+		config.Log().Error("failed to dispatch personal records update", "userID", user.ID, "error", err)
+	}
+}
+
+func (srv *PersonalRecordsService) getFirstHeartbeatTime(user *models.User) (*time.Time, error) {
+	firstHeartbeats, err := srv.heartbeatSrvc.GetFirstByUsers()
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range firstHeartbeats {
+		if entry.User == user.ID && !entry.Time.T().IsZero() {
+			first := entry.Time.T()
+			return &first, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/utils"
+	"github.com/patrickmn/go-cache"
+)
+
+const pluginReleaseLatestCacheKey = "latest"
+
+var ErrPluginReleaseNotFound = errors.New("release not found")
+
+// PluginReleaseService serves wakatime-cli releases from a local directory, which is optionally populated on demand from an
+// upstream github repository, so that plugins of air-gapped deployments can check for and download updates from this instance
+type PluginReleaseService struct {
+	config     *config.Config
+	cache      *cache.Cache
+	httpClient *http.Client
+}
+
+func NewPluginReleaseService() *PluginReleaseService {
+	cfg := config.Get()
+	return &PluginReleaseService{
+		config:     cfg,
+		cache:      cache.New(cfg.PluginUpdates.GetCacheTtl(), 1*time.Hour),
+		httpClient: &http.Client{Timeout: 5 * time.Minute}, // release assets are tens of megabytes
+	}
+}
+
+// GetLatest returns the latest release, preferably the upstream's one, and falls back to the latest locally available release
+// asset download urls point to this instance
+func (srv *PluginReleaseService) GetLatest() (*models.PluginRelease, error) {
+	if srv.config.PluginUpdates.UpstreamUrl != "" {
+		release, err := srv.getLatestUpstream()
+		if err == nil {
+			return srv.withLocalUrls(release), nil
+		}
+		config.Log().Warn("failed to fetch latest upstream plugin release, falling back to local ones", "error", err)
+	}
+
+	release, err := srv.getLatestLocal()
+	if err != nil {
+		return nil, err
+	}
+	return srv.withLocalUrls(release), nil
+}
+
+// OpenAsset opens the given release asset, mirroring it from the upstream first, if not available locally
+func (srv *PluginReleaseService) OpenAsset(tag, name string) (*os.File, error) {
+	if !models.ValidatePluginReleaseName(tag) || !models.ValidatePluginReleaseName(name) {
+		return nil, ErrPluginReleaseNotFound
+	}
+
+	assetPath := filepath.Join(srv.config.PluginUpdates.Path, tag, name)
+	f, err := os.Open(assetPath)
+	if err == nil {
+		return f, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) || srv.config.PluginUpdates.UpstreamUrl == "" {
+		return nil, ErrPluginReleaseNotFound
+	}
+
+	if err := srv.mirrorAsset(tag, name, assetPath); err != nil {
+		return nil, err
+	}
+	return os.Open(assetPath)
+}
+
+func (srv *PluginReleaseService) getLatestUpstream() (*models.PluginRelease, error) {
+	if release, found := srv.cache.Get(pluginReleaseLatestCacheKey); found {
+		return release.(*models.PluginRelease), nil
+	}
+
+	url := strings.TrimSuffix(srv.config.PluginUpdates.UpstreamUrl, "/") + "/releases/latest"
+	res, err := utils.RaiseForStatus(srv.httpClient.Get(url))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var release models.PluginRelease
+	if err := json.NewDecoder(res.Body).Decode(&release); err != nil {
+		return nil, err
+	}
+	if !models.ValidatePluginReleaseName(release.TagName) {
+		return nil, fmt.Errorf("invalid upstream release tag '%s'", release.TagName)
+	}
+
+	srv.cache.SetDefault(pluginReleaseLatestCacheKey, &release)
+	return &release, nil
+}
+
+func (srv *PluginReleaseService) getLatestLocal() (*models.PluginRelease, error) {
+	entries, err := os.ReadDir(srv.config.PluginUpdates.Path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, ErrPluginReleaseNotFound
+		}
+		return nil, err
+	}
+
+	var latest os.DirEntry
+	for _, e := range entries {
+		if e.IsDir() && models.ValidatePluginReleaseName(e.Name()) && (latest == nil || models.ComparePluginVersions(e.Name(), latest.Name()) > 0) {
+			latest = e
+		}
+	}
+	if latest == nil {
+		return nil, ErrPluginReleaseNotFound
+	}
+
+	assetEntries, err := os.ReadDir(filepath.Join(srv.config.PluginUpdates.Path, latest.Name()))
+	if err != nil {
+		return nil, err
+	}
+
+	release := &models.PluginRelease{TagName: latest.Name(), Name: latest.Name(), Assets: []*models.PluginReleaseAsset{}}
+	for _, e := range assetEntries {
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() || !models.ValidatePluginReleaseName(e.Name()) {
+			continue
+		}
+		if info.ModTime().After(release.PublishedAt) {
+			release.PublishedAt = info.ModTime()
+		}
+		release.Assets = append(release.Assets, &models.PluginReleaseAsset{Name: e.Name(), Size: info.Size()})
+	}
+	return release, nil
+}
+
+func (srv *PluginReleaseService) mirrorAsset(tag, name, assetPath string) error {
+	url := srv.upstreamDownloadUrl(tag, name)
+	res, err := srv.httpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return ErrPluginReleaseNotFound
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download release asset from upstream, got status %d", res.StatusCode)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(assetPath), 0755); err != nil {
+		return err
+	}
+
+	// write to a temporary file first, so that concurrent requests never serve partial downloads
+	tmp, err := os.CreateTemp(filepath.Dir(assetPath), name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := io.Copy(tmp, res.Body); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), assetPath)
+}
+
+// upstreamDownloadUrl converts the github api url of a repository into the download url of a release asset
+// e.g. https://api.github.com/repos/wakatime/wakatime-cli -> https://github.com/wakatime/wakatime-cli/releases/download/<tag>/<name>
+func (srv *PluginReleaseService) upstreamDownloadUrl(tag, name string) string {
+	base := strings.TrimSuffix(srv.config.PluginUpdates.UpstreamUrl, "/")
+	base = strings.Replace(base, "://api.github.com/repos/", "://github.com/", 1)
+	return fmt.Sprintf("%s/releases/download/%s/%s", base, tag, name)
+}
+
+func (srv *PluginReleaseService) withLocalUrls(release *models.PluginRelease) *models.PluginRelease {
+	result := *release
+	result.Assets = make([]*models.PluginReleaseAsset, 0, len(release.Assets))
+	for _, a := range release.Assets {
+		if !models.ValidatePluginReleaseName(a.Name) {
+			continue
+		}
+		result.Assets = append(result.Assets, &models.PluginReleaseAsset{
+			Name:               a.Name,
+			Size:               a.Size,
+			BrowserDownloadUrl: fmt.Sprintf("%s/api/plugins/releases/download/%s/%s", srv.config.Server.GetPublicUrl(), release.TagName, a.Name),
+		})
+	}
+	return &result
+}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPluginReleaseService_Local(t *testing.T) {
+	dir := t.TempDir()
+	for _, tag := range []string{"v1.9.0", "v1.10.2", "v1.10.0"} {
+		assert.Nil(t, os.MkdirAll(filepath.Join(dir, tag), 0755))
+		assert.Nil(t, os.WriteFile(filepath.Join(dir, tag, "wakatime-cli-linux-amd64.zip"), []byte(tag), 0644))
+	}
+
+	cfg := config.Empty()
+	cfg.PluginUpdates.Path = dir
+	config.Set(cfg)
+
+	sut := NewPluginReleaseService()
+
+	release, err := sut.GetLatest()
+	assert.Nil(t, err)
+	assert.Equal(t, "v1.10.2", release.TagName)
+	assert.Len(t, release.Assets, 1)
+	assert.Equal(t, int64(len("v1.10.2")), release.Assets[0].Size)
+	assert.Contains(t, release.Assets[0].BrowserDownloadUrl, "/api/plugins/releases/download/v1.10.2/wakatime-cli-linux-amd64.zip")
+
+	f, err := sut.OpenAsset("v1.9.0", "wakatime-cli-linux-amd64.zip")
+	assert.Nil(t, err)
+	data, _ := io.ReadAll(f)
+	f.Close()
+	assert.Equal(t, "v1.9.0", string(data))
+
+	_, err = sut.OpenAsset("..", "v1.9.0")
+	assert.ErrorIs(t, err, ErrPluginReleaseNotFound)
+	_, err = sut.OpenAsset("v1.9.0", "wakatime-cli-windows-amd64.zip")
+	assert.ErrorIs(t, err, ErrPluginReleaseNotFound)
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/models"
+	"github.com/patrickmn/go-cache"
+)
+
+const profileTopN = 10
+
+type ProfileService struct {
+	config         *config.Config
+	cache          *cache.Cache
+	summaryService ISummaryService
+}
+
+func NewProfileService(summaryService ISummaryService) *ProfileService {
+	return &ProfileService{
+		config:         config.Get(),
+		cache:          cache.New(1*time.Hour, 1*time.Hour),
+		summaryService: summaryService,
+	}
+}
+
+// GetByUser returns the user's public profile, regardless of whether it is enabled, which is up to the caller to check
+func (srv *ProfileService) GetByUser(user *models.User) (*models.Profile, error) {
+	cacheKey := fmt.Sprintf("profile_%s_%v_%v_%v", user.ID, user.ProfileLanguages, user.ProfileProjects, user.ProfileActivity)
+	if result, found := srv.cache.Get(cacheKey); found {
+		return result.(*models.Profile), nil
+	}
+
+	err, from, to := helpers.ResolveIntervalTZ(models.IntervalPast12Months, user.TZ())
+	if err != nil {
+		return nil, err
+	}
+
+	summary, err := srv.summaryService.Aliased(from, to, user, srv.summaryService.Retrieve, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	profile := &models.Profile{
+		Username:    user.ID,
+		DisplayName: user.Name,
+		TotalTime:   summary.TotalTime(),
+	}
+	if user.ProfileLanguages {
+		profile.Languages = topProfileEntries(summary.Languages)
+	}
+	if user.ProfileProjects {
+		profile.Projects = topProfileEntries(summary.Projects)
+	}
+	if user.ProfileActivity {
+		profile.ActivityChartUrl = fmt.Sprintf("%s/api/activity/chart/%s.svg", srv.config.Server.GetPublicUrl(), user.ID)
+	}
+
+	srv.cache.SetDefault(cacheKey, profile)
+	return profile, nil
+}
+
+func topProfileEntries(items models.SummaryItems) []*models.ProfileEntry {
+	sorted := make(models.SummaryItems, len(items))
+	copy(sorted, items)
+	sort.Sort(sort.Reverse(sorted))
+
+	var total time.Duration
+	for _, item := range sorted {
+		total += item.TotalFixed()
+	}
+
+	entries := make([]*models.ProfileEntry, 0, profileTopN)
+	for _, item := range sorted {
+		if len(entries) >= profileTopN {
+			break
+		}
+		if item.Total == 0 || item.Key == models.UnknownSummaryKey {
+			continue
+		}
+		entries = append(entries, &models.ProfileEntry{
+			Key:     item.Key,
+			Total:   item.TotalFixed(),
+			Percent: float64(item.TotalFixed()) / float64(total) * 100,
+		})
+	}
+	return entries
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/patrickmn/go-cache"
+)
+
+type ProjectMetadataService struct {
+	config     *config.Config
+	cache      *cache.Cache
+	repository repositories.IProjectMetadataRepository
+}
+
+func NewProjectMetadataService(projectMetadataRepository repositories.IProjectMetadataRepository) *ProjectMetadataService {
+	return &ProjectMetadataService{
+		config:     config.Get(),
+		repository: projectMetadataRepository,
+		cache:      cache.New(24*time.Hour, 24*time.Hour),
+	}
+}
+
+func (srv *ProjectMetadataService) GetByUser(userId string) ([]*models.ProjectMetadata, error) {
+	if metadata, found := srv.cache.Get(userId); found {
+		return metadata.([]*models.ProjectMetadata), nil
+	}
+
+	metadata, err := srv.repository.GetByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	srv.cache.Set(userId, metadata, cache.DefaultExpiration)
+	return metadata, nil
+}
+
+// GetByUserMapped returns the user's project metadata, indexed by project key
+func (srv *ProjectMetadataService) GetByUserMapped(userId string) (map[string]*models.ProjectMetadata, error) {
+	metadata, err := srv.GetByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	mapped := make(map[string]*models.ProjectMetadata, len(metadata))
+	for _, m := range metadata {
+		mapped[m.ProjectKey] = m
+	}
+	return mapped, nil
+}
+
+func (srv *ProjectMetadataService) Upsert(metadata *models.ProjectMetadata) (*models.ProjectMetadata, error) {
+	result, err := srv.repository.Upsert(metadata)
+	if err != nil {
+		return nil, err
+	}
+	srv.cache.Delete(metadata.UserID)
+	return result, nil
+}
+
+func (srv *ProjectMetadataService) Delete(userId, projectKey string) error {
+	err := srv.repository.DeleteByUserAndProject(userId, projectKey)
+	srv.cache.Delete(userId)
+	return err
+}
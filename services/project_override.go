@@ -0,0 +1,86 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/patrickmn/go-cache"
+)
+
+// ProjectOverrideService determines the project of incoming heartbeats, instead of blindly trusting the client-provided project field
+type ProjectOverrideService struct {
+	config     *config.Config
+	cache      *cache.Cache
+	repository repositories.IProjectOverrideRepository
+}
+
+func NewProjectOverrideService(projectOverrideRepository repositories.IProjectOverrideRepository) *ProjectOverrideService {
+	return &ProjectOverrideService{
+		config:     config.Get(),
+		repository: projectOverrideRepository,
+		cache:      cache.New(24*time.Hour, 24*time.Hour),
+	}
+}
+
+func (srv *ProjectOverrideService) GetByUser(userId string) ([]*models.ProjectOverride, error) {
+	if overrides, found := srv.cache.Get(userId); found {
+		return overrides.([]*models.ProjectOverride), nil
+	}
+
+	overrides, err := srv.repository.GetByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	srv.cache.Set(userId, overrides, cache.DefaultExpiration)
+	return overrides, nil
+}
+
+func (srv *ProjectOverrideService) Upsert(override *models.ProjectOverride) (*models.ProjectOverride, error) {
+	override.Path = models.NormalizeProjectOverridePath(override.Path)
+	result, err := srv.repository.Upsert(override)
+	if err != nil {
+		return nil, err
+	}
+	srv.cache.Delete(override.UserID)
+	return result, nil
+}
+
+func (srv *ProjectOverrideService) Delete(userId, path string) error {
+	err := srv.repository.DeleteByUserAndPath(userId, models.NormalizeProjectOverridePath(path))
+	srv.cache.Delete(userId)
+	return err
+}
+
+// Resolve sets the heartbeat's project, in order of precedence, from the user's most specific override matching the file's path,
+// the client-provided project, the alternate project name and finally the name of the project root folder derived from git metadata
+func (srv *ProjectOverrideService) Resolve(user *models.User, heartbeat *models.Heartbeat) error {
+	if heartbeat.Type == "file" {
+		overrides, err := srv.GetByUser(user.ID)
+		if err != nil {
+			return err
+		}
+
+		entity := strings.ReplaceAll(heartbeat.Entity, "\\", "/")
+		var match *models.ProjectOverride
+		for _, o := range overrides {
+			if o.Matches(entity) && (match == nil || len(o.Path) > len(match.Path)) {
+				match = o
+			}
+		}
+		if match != nil {
+			heartbeat.Project = match.Project
+			return nil
+		}
+	}
+
+	if heartbeat.Project == "" {
+		heartbeat.Project = heartbeat.AlternateProject
+	}
+	if heartbeat.Project == "" {
+		heartbeat.Project = heartbeat.InferProject()
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/gofrs/uuid/v5"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/utils"
+	"github.com/patrickmn/go-cache"
+)
+
+const (
+	projectRenameResummarizeRetries  = 30 // summaries can't be re-generated while the user's regular aggregation is running
+	projectRenameResummarizeInterval = 10 * time.Second
+	projectRenamePollInterval        = 1 * time.Second
+)
+
+var (
+	ErrProjectRenameInvalid  = errors.New("invalid project names")
+	ErrProjectRenameNotFound = errors.New("project not found")
+	ErrProjectRenameRunning  = errors.New("another project is currently being renamed")
+)
+
+// ProjectRenameService renames a project across all of a user's history, so that renaming a repository doesn't split its statistics
+type ProjectRenameService struct {
+	config          *config.Config
+	heartbeatSrvc   IHeartbeatService
+	timeEntrySrvc   ITimeEntryService
+	aggregationSrvc IAggregationService
+	jobs            *cache.Cache
+	runningUsers    sync.Map
+}
+
+func NewProjectRenameService(heartbeatService IHeartbeatService, timeEntryService ITimeEntryService, aggregationService IAggregationService) *ProjectRenameService {
+	return &ProjectRenameService{
+		config:          config.Get(),
+		heartbeatSrvc:   heartbeatService,
+		timeEntrySrvc:   timeEntryService,
+		aggregationSrvc: aggregationService,
+		jobs:            cache.New(24*time.Hour, 1*time.Hour),
+	}
+}
+
+// Rename starts renaming the user's project in the background and returns the job to track its progress. Heartbeats and time
+// entries are updated first, then the user's summaries are re-generated for the period the project was worked on.
+func (srv *ProjectRenameService) Rename(user *models.User, oldProject, newProject string) (*models.ProjectRenameJob, error) {
+	newProject = strings.TrimSpace(newProject)
+	if oldProject == "" || newProject == "" || oldProject == newProject || utf8.RuneCountInString(newProject) > models.HeartbeatMaxProjectLength {
+		return nil, ErrProjectRenameInvalid
+	}
+
+	interval, err := srv.heartbeatSrvc.GetProjectIntervalByUser(user, oldProject)
+	if err != nil {
+		return nil, err
+	}
+	if interval == nil {
+		return nil, ErrProjectRenameNotFound
+	}
+
+	if _, running := srv.runningUsers.LoadOrStore(user.ID, true); running {
+		return nil, ErrProjectRenameRunning
+	}
+
+	chunks := splitRangeByMonths(datetime.BeginOfDay(interval.Start), interval.End.Add(time.Second))
+	job := &models.ProjectRenameJob{
+		ID:          uuid.Must(uuid.NewV4()).String(),
+		UserID:      user.ID,
+		OldProject:  oldProject,
+		NewProject:  newProject,
+		Status:      models.ProjectRenameStatusRunning,
+		ChunksTotal: len(chunks),
+		StartedAt:   time.Now(),
+	}
+	srv.jobs.SetDefault(job.ID, job)
+
+	go func() {
+		defer srv.runningUsers.Delete(user.ID)
+		job.Finish(srv.rename(job, user, interval, chunks))
+	}()
+
+	return job, nil
+}
+
+func (srv *ProjectRenameService) GetJob(id string) (*models.ProjectRenameJob, bool) {
+	if job, ok := srv.jobs.Get(id); ok {
+		return job.(*models.ProjectRenameJob), true
+	}
+	return nil, false
+}
+
+func (srv *ProjectRenameService) rename(job *models.ProjectRenameJob, user *models.User, interval *models.Interval, chunks [][]time.Time) error {
+	slog.Info("renaming project", "userID", user.ID, "from", job.OldProject, "to", job.NewProject, "chunks", len(chunks))
+
+	for _, chunk := range chunks {
+		n, err := srv.heartbeatSrvc.RenameProject(user, job.OldProject, job.NewProject, chunk[0], chunk[1])
+		if err != nil {
+			return err
+		}
+		job.AdvanceHeartbeats(n)
+	}
+
+	n, err := srv.timeEntrySrvc.RenameProject(user.ID, job.OldProject, job.NewProject)
+	if err != nil {
+		return err
+	}
+	job.SetTimeEntriesUpdated(n)
+
+	// today's summary is computed on the fly
+	if !interval.Start.Before(utils.BeginOfToday(time.Local)) {
+		return nil
+	}
+
+	var resummarizeJob *models.ResummarizeJob
+	for i := 0; i < projectRenameResummarizeRetries; i++ {
+		if resummarizeJob, err = srv.aggregationSrvc.Resummarize(user, interval.Start, interval.End); !errors.Is(err, ErrAggregationInProgress) {
+			break
+		}
+		time.Sleep(projectRenameResummarizeInterval)
+	}
+	if err != nil {
+		// heartbeats were renamed nevertheless, so summaries can still be fixed later on
+		return fmt.Errorf("renamed heartbeats, but failed to re-generate summaries, please retry using /api/admin/resummarize: %w", err)
+	}
+	job.SetResummarizeJob(resummarizeJob)
+
+	for resummarizeJob.IsRunning() {
+		time.Sleep(projectRenamePollInterval)
+	}
+
+	slog.Info("finished renaming project", "userID", user.ID, "from", job.OldProject, "to", job.NewProject, "heartbeats", job.Snapshot().HeartbeatsUpdated)
+	return nil
+}
+
+func splitRangeByMonths(from, to time.Time) [][]time.Time {
+	intervals := make([][]time.Time, 0)
+	for t1 := from; t1.Before(to); {
+		t2 := t1.AddDate(0, 1, 0)
+		if t2.After(to) {
+			t2 = to
+		}
+		intervals = append(intervals, []time.Time{t1, t2})
+		t1 = t2
+	}
+	return intervals
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProjectRenameService_Rename_Invalid(t *testing.T) {
+	config.Set(config.Empty())
+
+	user := &models.User{ID: TestUserId}
+	heartbeatService := new(mocks.HeartbeatServiceMock)
+	heartbeatService.On("GetProjectIntervalByUser", user, "unknown").Return(nil, nil)
+
+	sut := NewProjectRenameService(heartbeatService, nil, nil)
+
+	_, err := sut.Rename(user, TestProject1, " ")
+	assert.ErrorIs(t, err, ErrProjectRenameInvalid)
+	_, err = sut.Rename(user, TestProject1, TestProject1)
+	assert.ErrorIs(t, err, ErrProjectRenameInvalid)
+	_, err = sut.Rename(user, "", TestProject2)
+	assert.ErrorIs(t, err, ErrProjectRenameInvalid)
+	_, err = sut.Rename(user, "unknown", TestProject2)
+	assert.ErrorIs(t, err, ErrProjectRenameNotFound)
+
+	heartbeatService.AssertNumberOfCalls(t, "GetProjectIntervalByUser", 1)
+}
+
+func TestSplitRangeByMonths(t *testing.T) {
+	from := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	chunks := splitRangeByMonths(from, to)
+	assert.Len(t, chunks, 3)
+	assert.Equal(t, from, chunks[0][0])
+	assert.Equal(t, time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC), chunks[0][1])
+	assert.Equal(t, chunks[0][1], chunks[1][0])
+	assert.Equal(t, to, chunks[2][1])
+
+	assert.Empty(t, splitRangeByMonths(to, from))
+}
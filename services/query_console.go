@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+)
+
+var (
+	ErrQueryConsoleDisabled  = errors.New("query console is disabled")
+	ErrQueryConsoleStatement = errors.New("only single select, with, explain and show statements are allowed")
+	ErrQueryConsoleTimeout   = errors.New("query timed out")
+	ErrQueryConsoleFailed    = errors.New("query failed")
+	ErrQueryConsoleSensitive = errors.New("without a restricted database role, statements may neither select all columns nor reference passwords, api keys or tokens")
+)
+
+var (
+	queryConsoleKeywordRegex = regexp.MustCompile(`(?i)^\s*(select|with|explain|show|describe|values)\b`)
+	queryConsoleFileRegex    = regexp.MustCompile(`(?i)\binto\s+(outfile|dumpfile)\b`)
+	// e.g. data-modifying common table expressions (WITH ... DELETE) or EXPLAIN ANALYZE, which actually runs the statement on postgres
+	queryConsoleWriteRegex = regexp.MustCompile(`(?i)\b(insert|update|delete|merge|drop|alter|create|truncate|grant|revoke|attach|detach)\b`)
+	// columns holding credentials, which must not be read on the main connection
+	queryConsoleSensitiveRegex = regexp.MustCompile(`(?i)\b(password|api_key|api_key_sealed|wakatime_api_key|reset_token|simple_token|token|secret|device_code|slack_webhook_url)\b`)
+	queryConsoleCountAllRegex  = regexp.MustCompile(`(?i)\bcount\s*\(\s*\*\s*\)`)
+)
+
+// QueryConsoleService lets admins run read-only queries for debugging without direct database access, e.g. on managed deployments.
+// Statements are checked up front, but what actually prevents writes is the read-only transaction and, ideally, a restricted database role.
+// A restricted role is required for mysql and postgres. Sqlite has no roles, so statements on it may not reference credential columns.
+type QueryConsoleService struct {
+	config     *config.Config
+	repository repositories.IQueryConsoleRepository
+}
+
+func NewQueryConsoleService(queryConsoleRepo repositories.IQueryConsoleRepository) *QueryConsoleService {
+	return &QueryConsoleService{
+		config:     config.Get(),
+		repository: queryConsoleRepo,
+	}
+}
+
+func (srv *QueryConsoleService) Run(query string) (*models.QueryConsoleResult, error) {
+	if !srv.config.QueryConsole.Enabled {
+		return nil, ErrQueryConsoleDisabled
+	}
+
+	query, ok := checkConsoleQuery(query)
+	if !ok {
+		return nil, ErrQueryConsoleStatement
+	}
+	if (srv.config.Db.IsSQLite() || srv.config.QueryConsole.DSN == "") && !checkConsoleQueryColumns(query) {
+		return nil, ErrQueryConsoleSensitive
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(srv.config.QueryConsole.TimeoutSec)*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	result, err := srv.repository.Query(ctx, query, srv.config.QueryConsole.MaxRows)
+	if ctx.Err() != nil {
+		return nil, ErrQueryConsoleTimeout
+	}
+	if err != nil {
+		return nil, errors.Join(ErrQueryConsoleFailed, err)
+	}
+	result.TookMs = time.Since(start).Milliseconds()
+	return result, nil
+}
+
+// checkConsoleQuery strips a trailing semicolon and tells whether the statement is a single, reading one
+func checkConsoleQuery(query string) (string, bool) {
+	query = strings.TrimSuffix(strings.TrimSpace(query), ";")
+	if strings.Contains(query, ";") {
+		return "", false // no multiple statements, even though semicolons in literals are rejected as well
+	}
+	if !queryConsoleKeywordRegex.MatchString(query) || queryConsoleFileRegex.MatchString(query) || queryConsoleWriteRegex.MatchString(query) {
+		return "", false
+	}
+	return query, true
+}
+
+// checkConsoleQueryColumns tells whether the statement neither references a credential column nor selects all columns of a table,
+// which would otherwise allow to read credentials through select * or columns renamed in a common table expression
+func checkConsoleQueryColumns(query string) bool {
+	if queryConsoleSensitiveRegex.MatchString(query) {
+		return false
+	}
+	return !strings.Contains(queryConsoleCountAllRegex.ReplaceAllString(query, ""), "*")
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryConsoleService_Run_Disabled(t *testing.T) {
+	config.Set(config.Empty())
+
+	sut := NewQueryConsoleService(nil)
+
+	result, err := sut.Run("SELECT 1")
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, ErrQueryConsoleDisabled)
+}
+
+func TestCheckConsoleQuery(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected string
+		ok       bool
+	}{
+		{"SELECT id FROM users", "SELECT id FROM users", true},
+		{"  select count(*) from heartbeats;  ", "select count(*) from heartbeats", true},
+		{"WITH t AS (SELECT 1) SELECT * FROM t", "WITH t AS (SELECT 1) SELECT * FROM t", true},
+		{"EXPLAIN SELECT 1", "EXPLAIN SELECT 1", true},
+		{"SELECT 1; DELETE FROM users", "", false},
+		{"DELETE FROM users", "", false},
+		{"UPDATE users SET is_admin = true", "", false},
+		{"selection", "", false},
+		{"SELECT * FROM users INTO OUTFILE '/tmp/users'", "", false},
+		{"select id from users into dumpfile '/tmp/users'", "", false},
+		{"WITH t AS (SELECT 1) DELETE FROM users", "", false},
+		{"WITH deleted AS (DELETE FROM users RETURNING *) SELECT * FROM deleted", "", false},
+		{"EXPLAIN ANALYZE UPDATE users SET is_admin = true", "", false},
+		{"SELECT 1;; SELECT 2", "", false},
+		{"SELECT 1;\nDROP TABLE users;", "", false},
+		{"SELECT updated_at, created_at FROM users", "SELECT updated_at, created_at FROM users", true},
+		{"", "", false},
+	}
+
+	for _, tc := range testCases {
+		query, ok := checkConsoleQuery(tc.query)
+		assert.Equal(t, tc.ok, ok, tc.query)
+		assert.Equal(t, tc.expected, query, tc.query)
+	}
+}
+
+func TestCheckConsoleQueryColumns(t *testing.T) {
+	testCases := []struct {
+		query string
+		ok    bool
+	}{
+		{"SELECT id, email FROM users", true},
+		{"SELECT count(*) FROM heartbeats", true},
+		{"SELECT COUNT( * ) FROM heartbeats GROUP BY user_id", true},
+		{"SELECT api_key_prefix FROM users", true},
+		{"SELECT * FROM users", false},
+		{"SELECT u.* FROM users u", false},
+		{"WITH t(a, b, c) AS (SELECT * FROM users) SELECT c FROM t", false},
+		{"SELECT password FROM users", false},
+		{"SELECT id FROM users WHERE \"API_KEY\" LIKE 'a%'", false},
+		{"SELECT substr(wakatime_api_key, 1, 4) FROM users", false},
+		{"SELECT url, secret FROM leaderboard_webhooks", false},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.ok, checkConsoleQueryColumns(tc.query), tc.query)
+	}
+}
+
+func TestQueryConsoleService_Run_Sensitive(t *testing.T) {
+	cfg := config.Empty()
+	cfg.QueryConsole.Enabled = true
+	cfg.QueryConsole.MaxRows = 10
+	cfg.QueryConsole.TimeoutSec = 1
+	config.Set(cfg)
+
+	sut := NewQueryConsoleService(nil)
+
+	result, err := sut.Run("SELECT * FROM users")
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, ErrQueryConsoleSensitive)
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid/v5"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+)
+
+var (
+	ErrRegistrationClosed   = errors.New("registration is disabled on this server")
+	ErrInviteCodeInvalid    = errors.New("invite code invalid or expired")
+	ErrInviteCodeRequired   = errors.New("registration on this server requires an invite code")
+	ErrRegistrationDomain   = errors.New("registration on this server is restricted to e-mail addresses of certain domains")
+	ErrRegistrationPolicy   = errors.New("invalid registration policy")
+	ErrInviteCodeParameters = errors.New("invalid invite code limits")
+)
+
+// RegistrationService enforces who may sign up, according to the registration policy, and manages invite codes
+type RegistrationService struct {
+	config          *config.Config
+	keyValueService IKeyValueService
+	repository      repositories.IInviteCodeRepository
+}
+
+func NewRegistrationService(keyValueService IKeyValueService, inviteCodeRepository repositories.IInviteCodeRepository) *RegistrationService {
+	return &RegistrationService{
+		config:          config.Get(),
+		keyValueService: keyValueService,
+		repository:      inviteCodeRepository,
+	}
+}
+
+// GetPolicy returns the policy set by an admin at runtime, if any, and falls back to the configured one
+func (srv *RegistrationService) GetPolicy() *models.RegistrationPolicy {
+	if kv, err := srv.keyValueService.GetString(config.KeyRegistrationPolicy); err == nil && kv.Value != "" {
+		var policy models.RegistrationPolicy
+		if err := json.Unmarshal([]byte(kv.Value), &policy); err == nil && policy.IsValid() {
+			return &policy
+		}
+		config.Log().Warn("ignoring invalid registration policy", "value", kv.Value)
+	}
+	return models.NewRegistrationPolicyFrom(srv.config)
+}
+
+func (srv *RegistrationService) SetPolicy(policy *models.RegistrationPolicy) error {
+	domains := make([]string, 0, len(policy.Domains))
+	for _, d := range policy.Domains {
+		if d = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(d), "@")); d != "" {
+			domains = append(domains, d)
+		}
+	}
+	policy.Mode, policy.Domains = strings.ToLower(policy.Mode), domains
+
+	if !policy.IsValid() {
+		return ErrRegistrationPolicy
+	}
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return err
+	}
+	return srv.keyValueService.PutString(&models.KeyStringValue{Key: config.KeyRegistrationPolicy, Value: string(data)})
+}
+
+// ResetPolicy discards the policy set at runtime, so that the configured one applies again
+func (srv *RegistrationService) ResetPolicy() error {
+	return srv.keyValueService.DeleteString(config.KeyRegistrationPolicy)
+}
+
+// CheckSignup tells whether the signup is admitted by the current policy, and returns the invite code to be redeemed, if one was given
+func (srv *RegistrationService) CheckSignup(signup *models.Signup) (*models.InviteCode, error) {
+	var inviteCode *models.InviteCode
+	if signup.InviteCode != "" {
+		code, err := srv.repository.GetByCode(signup.InviteCode)
+		if err != nil || !code.IsUsable(time.Now()) {
+			return nil, ErrInviteCodeInvalid
+		}
+		inviteCode = code
+	}
+
+	policy := srv.GetPolicy()
+	switch policy.Mode {
+	case config.RegistrationModeOpen:
+		return inviteCode, nil
+	case config.RegistrationModeInvite:
+		if inviteCode == nil {
+			return nil, ErrInviteCodeRequired
+		}
+		return inviteCode, nil
+	case config.RegistrationModeDomain:
+		if inviteCode == nil && !policy.MatchesDomain(signup.Email) {
+			return nil, ErrRegistrationDomain
+		}
+		return inviteCode, nil
+	default:
+		return nil, ErrRegistrationClosed
+	}
+}
+
+// RedeemInviteCode counts a use of the code, failing if it was used up in the meantime
+func (srv *RegistrationService) RedeemInviteCode(code *models.InviteCode) error {
+	if err := srv.repository.IncrementUses(code.Code); err != nil {
+		return ErrInviteCodeInvalid
+	}
+	return nil
+}
+
+func (srv *RegistrationService) GetInviteCodes() ([]*models.InviteCode, error) {
+	return srv.repository.GetAll()
+}
+
+// CreateInviteCode generates a new code, which can be used maxUses times (unlimited if 0) and expires after validFor (never if 0)
+func (srv *RegistrationService) CreateInviteCode(createdBy string, maxUses int, validFor time.Duration) (*models.InviteCode, error) {
+	if maxUses < 0 || validFor < 0 {
+		return nil, ErrInviteCodeParameters
+	}
+
+	code := &models.InviteCode{
+		Code:      uuid.Must(uuid.NewV4()).String()[0:8],
+		CreatedBy: createdBy,
+		MaxUses:   maxUses,
+		CreatedAt: models.CustomTime(time.Now()),
+	}
+	if validFor > 0 {
+		expiresAt := models.CustomTime(time.Now().Add(validFor))
+		code.ExpiresAt = &expiresAt
+	}
+	return srv.repository.Insert(code)
+}
+
+func (srv *RegistrationService) DeleteInviteCode(code string) error {
+	return srv.repository.Delete(code)
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistrationService_CheckSignup(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Security.RegistrationMode = config.RegistrationModeDomain
+	cfg.Security.RegistrationDomains = "school.edu"
+	config.Set(cfg)
+
+	expiredAt := models.CustomTime(time.Now().Add(-time.Hour))
+	valid := &models.InviteCode{Code: "valid", CreatedBy: "teacher", MaxUses: 2, Uses: 1}
+	usedUp := &models.InviteCode{Code: "usedup", MaxUses: 1, Uses: 1}
+	expired := &models.InviteCode{Code: "expired", ExpiresAt: &expiredAt}
+
+	keyValueServiceMock := new(mocks.KeyValueServiceMock)
+	keyValueServiceMock.On("GetString", config.KeyRegistrationPolicy).Return(&models.KeyStringValue{}, errors.New("not found"))
+
+	inviteCodeRepoMock := new(mocks.InviteCodeRepositoryMock)
+	inviteCodeRepoMock.On("GetByCode", "valid").Return(valid, nil)
+	inviteCodeRepoMock.On("GetByCode", "usedup").Return(usedUp, nil)
+	inviteCodeRepoMock.On("GetByCode", "expired").Return(expired, nil)
+	inviteCodeRepoMock.On("GetByCode", "unknown").Return(&models.InviteCode{}, errors.New("not found"))
+
+	sut := NewRegistrationService(keyValueServiceMock, inviteCodeRepoMock)
+
+	code, err := sut.CheckSignup(&models.Signup{Email: "student@cs.school.edu"})
+	assert.Nil(t, err)
+	assert.Nil(t, code)
+
+	_, err = sut.CheckSignup(&models.Signup{Email: "student@gmail.com"})
+	assert.ErrorIs(t, err, ErrRegistrationDomain)
+
+	code, err = sut.CheckSignup(&models.Signup{Email: "student@gmail.com", InviteCode: "valid"})
+	assert.Nil(t, err)
+	assert.Equal(t, "teacher", code.CreatedBy)
+
+	for _, c := range []string{"usedup", "expired", "unknown"} {
+		_, err = sut.CheckSignup(&models.Signup{Email: "student@school.edu", InviteCode: c})
+		assert.ErrorIs(t, err, ErrInviteCodeInvalid)
+	}
+}
+
+func TestRegistrationService_CheckSignup_RuntimePolicy(t *testing.T) {
+	cfg := config.Empty()
+	cfg.Security.AllowSignup = true
+	config.Set(cfg)
+
+	keyValueServiceMock := new(mocks.KeyValueServiceMock)
+	keyValueServiceMock.On("GetString", config.KeyRegistrationPolicy).Return(&models.KeyStringValue{Value: `{"mode": "invite"}`}, nil)
+
+	sut := NewRegistrationService(keyValueServiceMock, new(mocks.InviteCodeRepositoryMock))
+
+	assert.Equal(t, config.RegistrationModeInvite, sut.GetPolicy().Mode)
+	_, err := sut.CheckSignup(&models.Signup{Email: "someone@example.org"})
+	assert.ErrorIs(t, err, ErrInviteCodeRequired)
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/patrickmn/go-cache"
+)
+
+// RelayRuleService manages the rules applied to heartbeats before relaying them to wakatime
+type RelayRuleService struct {
+	config     *config.Config
+	cache      *cache.Cache
+	repository repositories.IRelayRuleRepository
+}
+
+func NewRelayRuleService(relayRuleRepository repositories.IRelayRuleRepository) *RelayRuleService {
+	return &RelayRuleService{
+		config:     config.Get(),
+		repository: relayRuleRepository,
+		cache:      cache.New(24*time.Hour, 24*time.Hour),
+	}
+}
+
+func (srv *RelayRuleService) GetByUser(userId string) (models.RelayRules, error) {
+	if rules, found := srv.cache.Get(userId); found {
+		return rules.(models.RelayRules), nil
+	}
+
+	rules, err := srv.repository.GetByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	srv.cache.Set(userId, models.RelayRules(rules), cache.DefaultExpiration)
+	return rules, nil
+}
+
+func (srv *RelayRuleService) Create(rule *models.RelayRule) (*models.RelayRule, error) {
+	result, err := srv.repository.Insert(rule)
+	if err != nil {
+		return nil, err
+	}
+	srv.cache.Delete(rule.UserID)
+	return result, nil
+}
+
+func (srv *RelayRuleService) Delete(userId string, id uint) error {
+	err := srv.repository.DeleteByUserAndId(userId, id)
+	srv.cache.Delete(userId)
+	return err
+}
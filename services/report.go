@@ -75,9 +75,9 @@ func (srv *ReportService) Schedule() {
 			return
 		}
 
-		// filter users who have their email set
+		// filter users who have their email set (and verified, if required)
 		users = slice.Filter[*models.User](users, func(i int, u *models.User) bool {
-			return u.Email != ""
+			return u.HasTrustedEmail()
 		})
 
 		// schedule jobs, throttled by one job per x seconds
@@ -93,8 +93,8 @@ func (srv *ReportService) Schedule() {
 }
 
 func (srv *ReportService) SendReport(user *models.User, duration time.Duration) error {
-	if user.Email == "" {
-		slog.Warn("not generating report as no e-mail address is set", "userID", user.ID)
+	if !user.HasTrustedEmail() {
+		slog.Warn("not generating report as no (verified) e-mail address is set", "userID", user.ID)
 		return nil
 	}
 
@@ -0,0 +1,158 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"text/template"
+	"time"
+
+	"github.com/duke-git/lancet/v2/slice"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/muety/artifex/v2"
+	"gorm.io/gorm"
+)
+
+const (
+	checkScrapbookSessionsEvery = 5 * time.Minute
+	scrapbookMaxLookback        = 24 * time.Hour
+)
+
+// ScrapbookService posts a snapshot of every completed coding session of opted-in users to hack club's scrapbook / arcade.
+// A session is considered completed once no heartbeats were received for the configured gap.
+type ScrapbookService struct {
+	config           *config.Config
+	userService      IUserService
+	heartbeatService IHeartbeatService
+	queueDefault     *artifex.Dispatcher
+	queueWorkers     *artifex.Dispatcher
+}
+
+func NewScrapbookService(userService IUserService, heartbeatService IHeartbeatService) *ScrapbookService {
+	return &ScrapbookService{
+		config:           config.Get(),
+		userService:      userService,
+		heartbeatService: heartbeatService,
+		queueDefault:     config.GetDefaultQueue(),
+		queueWorkers:     config.GetQueue(config.QueueProcessing),
+	}
+}
+
+func (srv *ScrapbookService) Schedule() {
+	if !srv.config.Scrapbook.Enabled {
+		return
+	}
+
+	slog.Info("scheduling scrapbook session posts")
+	if _, err := srv.queueDefault.DispatchEvery(srv.CheckAll, checkScrapbookSessionsEvery); err != nil {
+		config.Log().Error("failed to schedule scrapbook jobs", "error", err)
+	}
+}
+
+func (srv *ScrapbookService) CheckAll() {
+	users, err := srv.userService.GetAll()
+	if err != nil {
+		config.Log().Error("failed to fetch users for scrapbook posts", "error", err)
+		return
+	}
+
+	users = slice.Filter[*models.User](users, func(i int, u *models.User) bool {
+		return u.ScrapbookEnabled && !u.IsServiceAccount && !u.Deactivated && !u.IsSuspended()
+	})
+
+	now := time.Now()
+	for _, u := range users {
+		user := u
+		if err := srv.queueWorkers.Dispatch(func() {
+			if _, err := srv.Check(user, now); err != nil {
+				config.Log().Error("failed to post scrapbook session for user", "userID", user.ID, "error", err)
+			}
+		}); err != nil {
+			config.Log().Error("failed to dispatch scrapbook check for user", "userID", user.ID, "error", err)
+		}
+	}
+}
+
+// Check posts the user's latest session, if it was completed and not posted yet, and reports whether it was posted
+func (srv *ScrapbookService) Check(user *models.User, now time.Time) (bool, error) {
+	if !user.ScrapbookEnabled {
+		return false, nil
+	}
+
+	latest, err := srv.heartbeatService.GetLatestByUser(user)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	end := latest.Time.T()
+	gap := time.Duration(srv.config.Scrapbook.SessionGapMin) * time.Minute
+	if now.Sub(end) < gap {
+		return false, nil // still ongoing
+	}
+	if user.LastScrapbookPostAt != nil && !user.LastScrapbookPostAt.T().Before(end) {
+		return false, nil // already handled
+	}
+
+	from := end.Add(-scrapbookMaxLookback)
+	if user.LastScrapbookPostAt != nil && user.LastScrapbookPostAt.T().After(from) {
+		from = user.LastScrapbookPostAt.T().Add(time.Second) // don't post the tail of an already posted session again
+	}
+	heartbeats, err := srv.heartbeatService.GetAllWithin(from, end.Add(time.Second), user)
+	if err != nil {
+		return false, err
+	}
+
+	var posted bool
+	session := models.FindLastScrapbookSession(heartbeats, gap, user.HeartbeatsTimeout())
+	if session != nil && session.Duration >= time.Duration(srv.config.Scrapbook.MinSessionMin)*time.Minute {
+		if err := srv.post(user, session); err != nil {
+			return false, err // retry next time
+		}
+		posted = true
+		slog.Info("posted session to scrapbook", "userID", user.ID, "project", session.Project, "duration", session.Duration)
+	}
+
+	handledAt := models.CustomTime(end)
+	user.LastScrapbookPostAt = &handledAt
+	if _, err := srv.userService.UpdateField(user, "last_scrapbook_post_at", handledAt); err != nil {
+		return posted, err
+	}
+	return posted, nil
+}
+
+func (srv *ScrapbookService) post(user *models.User, session *models.ScrapbookSession) error {
+	tpl, err := srv.getTemplate(user)
+	if err != nil {
+		return err
+	}
+
+	post, err := models.NewScrapbookPost(user, session, tpl)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(post)
+	if err != nil {
+		return err
+	}
+
+	headers := map[string]string{}
+	if token := srv.config.Scrapbook.ApiToken; token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+	return postWebhookJson(srv.config.Scrapbook.ApiUrl, data, headers)
+}
+
+// getTemplate returns the user's own template, falling back to the server's default if none is set or it's broken
+func (srv *ScrapbookService) getTemplate(user *models.User) (*template.Template, error) {
+	if user.ScrapbookTemplate != "" {
+		if tpl, err := models.ParseScrapbookTemplate(user.ScrapbookTemplate); err == nil {
+			return tpl, nil
+		}
+	}
+	return models.ParseScrapbookTemplate(srv.config.Scrapbook.DefaultTemplate)
+}
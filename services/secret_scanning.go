@@ -0,0 +1,187 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/patrickmn/go-cache"
+)
+
+const (
+	githubSecretScanningKeysUrl = "https://api.github.com/meta/public_keys/secret_scanning"
+	secretScanningKeysMinAge    = 1 * time.Minute  // github's key list is fetched at most once within this interval
+	secretScanningUnknownKeyTtl = 10 * time.Minute // how long to remember key identifiers, which are not in github's list
+)
+
+var (
+	ErrSecretScanningSignature  = errors.New("invalid secret scanning signature")
+	ErrSecretScanningUnknownKey = errors.New("unknown public key identifier")
+)
+
+type SecretScanningService struct {
+	config      *config.Config
+	cache       *cache.Cache
+	httpClient  *http.Client
+	keysUrl     string
+	lastFetch   time.Time
+	lock        sync.Mutex
+	userService IUserService
+	mailService IMailService
+}
+
+func NewSecretScanningService(userService IUserService, mailService IMailService) *SecretScanningService {
+	return &SecretScanningService{
+		config:      config.Get(),
+		cache:       cache.New(1*time.Hour, 1*time.Hour),
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+		keysUrl:     githubSecretScanningKeysUrl,
+		userService: userService,
+		mailService: mailService,
+	}
+}
+
+// VerifySignature checks the ecdsa signature sent along with a secret scanning alert payload by github
+func (srv *SecretScanningService) VerifySignature(payload []byte, keyId, signature string) error {
+	publicKey, err := srv.getPublicKey(keyId)
+	if err != nil {
+		return err
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSecretScanningSignature, err)
+	}
+
+	digest := sha256.Sum256(payload)
+	if !ecdsa.VerifyASN1(publicKey, digest[:], sig) {
+		return ErrSecretScanningSignature
+	}
+	return nil
+}
+
+// RevokeLeaked resets the api keys of all users whose keys were reported as leaked and notifies them
+func (srv *SecretScanningService) RevokeLeaked(alerts []*models.SecretScanningAlert) []*models.SecretScanningFeedback {
+	feedback := make([]*models.SecretScanningFeedback, 0, len(alerts))
+
+	for _, alert := range alerts {
+		label := models.SecretScanningLabelFalsePositive
+
+		if user, err := srv.userService.GetUserByKey(alert.Token); err == nil && user != nil {
+			label = models.SecretScanningLabelTruePositive
+
+			if _, err := srv.userService.ResetApiKey(user); err != nil {
+				config.Log().Error("failed to revoke leaked api key", "userID", user.ID, "error", err)
+			} else {
+				slog.Warn("revoked leaked api key", "userID", user.ID, "url", alert.Url)
+
+				if user.HasTrustedEmail() {
+					if err := srv.mailService.SendApiKeyRevokedNotification(user, alert.Url); err != nil {
+						config.Log().Error("failed to send api key revocation notification", "userID", user.ID, "error", err)
+					}
+				}
+			}
+		}
+
+		feedback = append(feedback, &models.SecretScanningFeedback{
+			TokenRaw:  alert.Token,
+			TokenType: alert.Type,
+			Label:     label,
+		})
+	}
+
+	return feedback
+}
+
+// getPublicKey looks up the key with the given identifier in github's list of public keys. All keys of the list are cached,
+// identifiers not contained in it are remembered for a while and the list is re-fetched at most once a minute.
+func (srv *SecretScanningService) getPublicKey(keyId string) (*ecdsa.PublicKey, error) {
+	if key, found := srv.cache.Get(keyId); found {
+		if key == nil {
+			return nil, ErrSecretScanningUnknownKey
+		}
+		return key.(*ecdsa.PublicKey), nil
+	}
+
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+
+	// might have been fetched by a concurrent request in the meantime
+	if key, found := srv.cache.Get(keyId); found {
+		if key == nil {
+			return nil, ErrSecretScanningUnknownKey
+		}
+		return key.(*ecdsa.PublicKey), nil
+	}
+
+	if time.Since(srv.lastFetch) < secretScanningKeysMinAge {
+		return nil, fmt.Errorf("%w: key list was fetched recently", ErrSecretScanningUnknownKey)
+	}
+	srv.lastFetch = time.Now()
+
+	keys, err := srv.fetchPublicKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, k := range keys.PublicKeys {
+		publicKey, err := parseSecretScanningKey(k.Key)
+		if err != nil {
+			config.Log().Warn("failed to parse secret scanning public key", "keyID", k.KeyIdentifier, "error", err)
+			continue
+		}
+		srv.cache.SetDefault(k.KeyIdentifier, publicKey)
+	}
+
+	if key, found := srv.cache.Get(keyId); found {
+		return key.(*ecdsa.PublicKey), nil
+	}
+	srv.cache.Set(keyId, nil, secretScanningUnknownKeyTtl)
+	return nil, ErrSecretScanningUnknownKey
+}
+
+func (srv *SecretScanningService) fetchPublicKeys() (*models.SecretScanningPublicKeys, error) {
+	res, err := srv.httpClient.Get(srv.keysUrl)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch secret scanning keys, got status %d", res.StatusCode)
+	}
+
+	var keys models.SecretScanningPublicKeys
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1024*1024)).Decode(&keys); err != nil {
+		return nil, err
+	}
+	return &keys, nil
+}
+
+func parseSecretScanningKey(key string) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode([]byte(key))
+	if block == nil {
+		return nil, errors.New("failed to decode public key")
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, ok := parsed.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, errors.New("unexpected public key type")
+	}
+	return publicKey, nil
+}
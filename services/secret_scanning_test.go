@@ -0,0 +1,109 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupSecretScanningKeys(t *testing.T) (*ecdsa.PrivateKey, *httptest.Server, *int) {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&privateKey.PublicKey)
+	assert.Nil(t, err)
+
+	var fetches int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		json.NewEncoder(w).Encode(&models.SecretScanningPublicKeys{PublicKeys: []*models.SecretScanningPublicKey{
+			{KeyIdentifier: "key1", Key: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), IsCurrent: true},
+		}})
+	}))
+	t.Cleanup(server.Close)
+
+	return privateKey, server, &fetches
+}
+
+func signSecretScanningPayload(t *testing.T, key *ecdsa.PrivateKey, payload []byte) string {
+	digest := sha256.Sum256(payload)
+	sig, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	assert.Nil(t, err)
+	return base64.StdEncoding.EncodeToString(sig)
+}
+
+func TestSecretScanningService_VerifySignature(t *testing.T) {
+	config.Set(config.Empty())
+
+	privateKey, server, fetches := setupSecretScanningKeys(t)
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	payload := []byte(`[{"token":"abc","type":"hackatime_api_key","url":"https://github.com"}]`)
+
+	sut := NewSecretScanningService(nil, nil)
+	sut.keysUrl = server.URL
+
+	assert.Nil(t, sut.VerifySignature(payload, "key1", signSecretScanningPayload(t, privateKey, payload)))
+	assert.ErrorIs(t, sut.VerifySignature([]byte(`[]`), "key1", signSecretScanningPayload(t, privateKey, payload)), ErrSecretScanningSignature)
+	assert.ErrorIs(t, sut.VerifySignature(payload, "key1", signSecretScanningPayload(t, otherKey, payload)), ErrSecretScanningSignature)
+	assert.ErrorIs(t, sut.VerifySignature(payload, "key1", "not base64"), ErrSecretScanningSignature)
+	assert.Equal(t, 1, *fetches) // known keys are cached
+}
+
+func TestSecretScanningService_VerifySignature_UnknownKey(t *testing.T) {
+	config.Set(config.Empty())
+
+	privateKey, server, fetches := setupSecretScanningKeys(t)
+	payload := []byte(`[]`)
+	signature := signSecretScanningPayload(t, privateKey, payload)
+
+	sut := NewSecretScanningService(nil, nil)
+	sut.keysUrl = server.URL
+
+	assert.ErrorIs(t, sut.VerifySignature(payload, "unknown", signature), ErrSecretScanningUnknownKey)
+	assert.ErrorIs(t, sut.VerifySignature(payload, "unknown", signature), ErrSecretScanningUnknownKey)
+	assert.Equal(t, 1, *fetches) // unknown key is remembered
+
+	// other unknown keys don't cause the list to be re-fetched right away either
+	assert.ErrorIs(t, sut.VerifySignature(payload, "unknown2", signature), ErrSecretScanningUnknownKey)
+	assert.Equal(t, 1, *fetches)
+
+	// but keys from the list fetched before are still accepted
+	assert.Nil(t, sut.VerifySignature(payload, "key1", signature))
+}
+
+func TestSecretScanningService_RevokeLeaked(t *testing.T) {
+	config.Set(config.Empty())
+
+	user := &models.User{ID: TestUserId, ApiKey: "leaked-key"}
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserByKey", "leaked-key").Return(user, nil)
+	userServiceMock.On("GetUserByKey", "unknown-key").Return((*models.User)(nil), errors.New("not found"))
+	userServiceMock.On("ResetApiKey", user).Return(user, nil)
+
+	sut := NewSecretScanningService(userServiceMock, nil)
+
+	feedback := sut.RevokeLeaked([]*models.SecretScanningAlert{
+		{Token: "leaked-key", Type: "hackatime_api_key", Url: "https://github.com/foo/bar"},
+		{Token: "unknown-key", Type: "hackatime_api_key"},
+	})
+
+	assert.Len(t, feedback, 2)
+	assert.Equal(t, models.SecretScanningLabelTruePositive, feedback[0].Label)
+	assert.Equal(t, "leaked-key", feedback[0].TokenRaw)
+	assert.Equal(t, models.SecretScanningLabelFalsePositive, feedback[1].Label)
+	userServiceMock.AssertNumberOfCalls(t, "ResetApiKey", 1) // user has no e-mail address, so no notification is sent
+}
@@ -1,17 +1,29 @@
 package services
 
 import (
+	"context"
+	"io"
+	"net"
+	"os"
 	"time"
 
 	datastructure "github.com/duke-git/lancet/v2/datastructure/set"
 	"github.com/hackclub/hackatime/models"
 	"github.com/hackclub/hackatime/models/types"
+	"github.com/hackclub/hackatime/services/objects"
 	"github.com/hackclub/hackatime/utils"
 )
 
+// contextScoped is optionally implemented by services, which can be bound to a request's context, e.g. to cancel long-running queries
+type contextScoped[T any] interface {
+	WithContext(context.Context) T
+}
+
 type IAggregationService interface {
 	Schedule()
 	AggregateSummaries(set datastructure.Set[string]) error
+	Resummarize(*models.User, time.Time, time.Time) (*models.ResummarizeJob, error)
+	GetResummarizeJob(string) (*models.ResummarizeJob, bool)
 }
 
 type IMiscService interface {
@@ -34,9 +46,13 @@ type IAliasService interface {
 type IHeartbeatService interface {
 	Insert(*models.Heartbeat) error
 	InsertBatch([]*models.Heartbeat) error
+	Normalize(*models.Heartbeat) *models.Heartbeat
+	IsBlocked(*models.User, *models.Heartbeat) bool
+	GetTruncatedCounts() map[string]int64
 	Count(bool) (int64, error)
 	CountByUser(*models.User) (int64, error)
 	CountByUsers([]*models.User) ([]*models.CountByUser, error)
+	CountActiveDaysByUser(*models.User, [][]time.Time, *models.Filters) (int64, error)
 	GetAllWithin(time.Time, time.Time, *models.User) ([]*models.Heartbeat, error)
 	GetAllWithinByFilters(time.Time, time.Time, *models.User, *models.Filters) ([]*models.Heartbeat, error)
 	GetFirstByUsers() ([]*models.TimeByUser, error)
@@ -48,6 +64,14 @@ type IHeartbeatService interface {
 	DeleteByUser(*models.User) error
 	DeleteByUserBefore(*models.User, time.Time) error
 	GetUserProjectStats(*models.User, time.Time, time.Time, *utils.PageParams, bool) ([]*models.ProjectStats, error)
+	GetUserAgentStats(*models.User) ([]*models.UserAgentStats, error)
+	GetMachineStats(*models.User) ([]*models.MachineStats, error)
+	GetChangesSince(*models.User, *models.HeartbeatChangesCursor, int) (*models.HeartbeatChanges, error)
+	GetProjectIntervalByUser(*models.User, string) (*models.Interval, error)
+	RenameProject(*models.User, string, string, time.Time, time.Time) (int64, error)
+	GetMachineIntervalByUser(*models.User, string) (*models.Interval, error)
+	CopyMachineToUser(*models.User, *models.User, string, time.Time, time.Time) (int64, error)
+	DeleteByUserAndMachineBefore(*models.User, string, time.Time) (int64, error)
 }
 
 type IDiagnosticsService interface {
@@ -70,6 +94,107 @@ type ILanguageMappingService interface {
 	Delete(mapping *models.LanguageMapping) error
 }
 
+type IRegistrationService interface {
+	GetPolicy() *models.RegistrationPolicy
+	SetPolicy(*models.RegistrationPolicy) error
+	ResetPolicy() error
+	CheckSignup(*models.Signup) (*models.InviteCode, error)
+	RedeemInviteCode(*models.InviteCode) error
+	GetInviteCodes() ([]*models.InviteCode, error)
+	CreateInviteCode(string, int, time.Duration) (*models.InviteCode, error)
+	DeleteInviteCode(string) error
+}
+
+type IDeviceAuthService interface {
+	Start(string) (*models.DeviceAuthorization, error)
+	GetByUserCode(string) (*models.DeviceAuthorization, error)
+	Approve(string, *models.User, bool) error
+	Deny(string) error
+	Poll(string) (*models.DeviceAuthorization, error)
+}
+
+type ICanonicalNameService interface {
+	GetAll() ([]*models.CanonicalName, error)
+	Create(*models.CanonicalName) (*models.CanonicalName, error)
+	Delete(uint) error
+	Canonicalize(*models.Heartbeat)
+	Apply() (*models.CanonicalizationResult, error)
+	SetAggregationService(IAggregationService)
+}
+
+type IConcurrencyService interface {
+	Schedule()
+	Track(string, time.Time)
+	CountActive(time.Time) int
+	GetWithin(time.Time, time.Time) ([]*models.ConcurrencySample, error)
+}
+
+type IUserPreferenceService interface {
+	GetByUserAndNamespace(string, string) (models.UserPreferences, error)
+	Set(*models.UserPreference) (*models.UserPreference, error)
+	Delete(string, string, string) error
+	DeleteNamespace(string, string) error
+}
+
+type IProjectMetadataService interface {
+	GetByUser(string) ([]*models.ProjectMetadata, error)
+	GetByUserMapped(string) (map[string]*models.ProjectMetadata, error)
+	Upsert(*models.ProjectMetadata) (*models.ProjectMetadata, error)
+	Delete(string, string) error
+}
+
+type IProjectOverrideService interface {
+	GetByUser(string) ([]*models.ProjectOverride, error)
+	Upsert(*models.ProjectOverride) (*models.ProjectOverride, error)
+	Delete(string, string) error
+	Resolve(*models.User, *models.Heartbeat) error
+}
+
+type IRelayRuleService interface {
+	GetByUser(string) (models.RelayRules, error)
+	Create(*models.RelayRule) (*models.RelayRule, error)
+	Delete(string, uint) error
+}
+
+type ITagRuleService interface {
+	GetByUser(string) (models.TagRules, error)
+	Create(*models.TagRule) (*models.TagRule, error)
+	Delete(string, uint) error
+	Apply(*models.User, *models.Heartbeat) error
+}
+
+type ICompareService interface {
+	GetConsents(*models.User) (*models.CompareConsents, error)
+	Consent(*models.User, string) error
+	Revoke(*models.User, string) error
+	Compare(*models.User, string, time.Time, time.Time) (*models.UserComparison, error)
+}
+
+type IGeoService interface {
+	Enabled() bool
+	Country(net.IP) string
+	Track(*models.User, string, net.IP) error
+	Summarize(*models.User, time.Time, time.Time) (*models.LocationSummary, error)
+}
+
+type IAnalyticsService interface {
+	Query(*models.User, *models.AnalyticsQuery) (*models.AnalyticsResult, error)
+}
+
+type ITeamService interface {
+	Schedule()
+	GetAll() ([]*models.Team, error)
+	GetById(uint) (*models.Team, error)
+	Create(*models.Team) (*models.Team, error)
+	Update(*models.Team) (*models.Team, error)
+	Delete(uint) error
+	AddMember(*models.Team, string) (*models.TeamMember, error)
+	RemoveMember(*models.Team, string) error
+	PostDigest(*models.Team) error
+	RenderDigest(*models.Team) (string, error)
+	BuildDigest(*models.Team, time.Time, time.Time) (*models.TeamDigest, error)
+}
+
 type IProjectLabelService interface {
 	GetById(uint) (*models.ProjectLabel, error)
 	GetByUser(string) ([]*models.ProjectLabel, error)
@@ -79,17 +204,55 @@ type IProjectLabelService interface {
 	Delete(*models.ProjectLabel) error
 }
 
+type IMachineService interface {
+	GetByUser(string) ([]*models.Machine, error)
+	CountQuarantinedByUser(string) (map[string]int64, error)
+	IsApproved(*models.User, string) (bool, error)
+	ApproveAll(*models.User, []string) error
+	SetCountry(*models.User, string, string) error
+	ClearCountries(*models.User) error
+	Quarantine([]*models.Heartbeat) error
+	Approve(*models.User, string) (int, error)
+	Reject(*models.User, string) error
+	DeleteHeartbeats(*models.User, string) (*models.Interval, int64, error)
+	ReassignHeartbeats(*models.User, *models.User, string) (*models.Interval, int64, error)
+}
+
 type IMailService interface {
+	Check() error
 	SendWelcome(*models.User) error
 	SendPasswordReset(*models.User, string) error
 	SendWakatimeFailureNotification(*models.User, int) error
 	SendImportNotification(*models.User, time.Duration, int) error
 	SendReport(*models.User, *models.Report) error
 	SendSubscriptionNotification(*models.User, bool) error
+	SendMachineApprovalRequest(*models.User, string) error
+	SendApiKeyRevokedNotification(*models.User, string) error
+	SendExportNotification(*models.User, string) error
+	SendEmailVerification(*models.User, string) error
+	SendInactivityAlert(*models.User, time.Time) error
+	SendLanguageGoalsReport(*models.User, []*models.LanguageGoalProgress) error
+	SendMentorSummary(*models.Mentor, *models.MentorSummary) error
+}
+
+type IConfigCheckService interface {
+	RunAll() []*models.ConfigCheck
+	LogAll()
+}
+
+type IPluginReleaseService interface {
+	GetLatest() (*models.PluginRelease, error)
+	OpenAsset(string, string) (*os.File, error)
+}
+
+type ISecretScanningService interface {
+	VerifySignature([]byte, string, string) error
+	RevokeLeaked([]*models.SecretScanningAlert) []*models.SecretScanningFeedback
 }
 
 type IDurationService interface {
 	Get(time.Time, time.Time, *models.User, *models.Filters) (models.Durations, error)
+	GetOverlap(time.Time, time.Time, *models.User) (*models.DurationOverlap, error)
 }
 
 type ISummaryService interface {
@@ -99,23 +262,179 @@ type ISummaryService interface {
 	GetLatestByUser() ([]*models.TimeByUser, error)
 	DeleteByUser(string) error
 	DeleteByUserBefore(string, time.Time) error
+	DeleteByUserWithin(string, time.Time, time.Time) error
 	Insert(*models.Summary) error
+	GetVersion(string) int64
 }
 
 type IActivityService interface {
 	GetChart(*models.User, *models.IntervalKey, bool, bool, bool) (string, error)
 }
 
+type IChartService interface {
+	RenderWeekly(*models.User, bool, bool) ([]byte, error)
+	RenderLanguages(*models.User, *models.IntervalKey, bool, bool) ([]byte, error)
+}
+
 type IReportService interface {
 	Schedule()
 	SendReport(*models.User, time.Duration) error
 }
 
+type IExportService interface {
+	Schedule()
+	RunExport(time.Duration) error
+	GenerateCsv([]*models.User, time.Time, time.Time) ([]byte, error)
+	RunWakatimeExport(*models.User) (string, error)
+	GenerateWakatimeArchive(*models.User, time.Time, time.Time) ([]byte, error)
+	RunHtmlExport(*models.User, time.Time, time.Time) (string, error)
+	GenerateHtmlArchive(*models.User, time.Time, time.Time) ([]byte, error)
+	GeneratePdf(*models.User, time.Time, time.Time) ([]byte, error)
+}
+
+type IStreamService interface {
+	Schedule()
+}
+
+type IActivityGraphService interface {
+	Schedule()
+	GetByUser(*models.User, int) (*models.ActivityGraph, error)
+	Generate(*models.User, int) (*models.ActivityGraph, error)
+}
+
+type IProfileService interface {
+	GetByUser(*models.User) (*models.Profile, error)
+}
+
+type ILanguageGoalService interface {
+	Schedule()
+	GetByUser(string) ([]*models.LanguageGoal, error)
+	Create(*models.LanguageGoal) (*models.LanguageGoal, error)
+	Delete(string, uint) error
+	GetProgress(*models.User, []*models.LanguageGoal) ([]*models.LanguageGoalProgress, error)
+	NotifyAll()
+	Notify(string, []*models.LanguageGoal, time.Time) (bool, error)
+}
+
+type IUserAvatarService interface {
+	GetByUser(string) (*models.UserAvatar, error)
+	Upload(*models.User, []byte) error
+	Delete(*models.User) error
+}
+
+type IInactivityAlertService interface {
+	Schedule()
+	CheckAll()
+	Check(*models.User, time.Time) (bool, error)
+}
+
+type ITimeEntryService interface {
+	GetByUserAndId(string, uint) (*models.TimeEntry, error)
+	GetByUserWithin(string, time.Time, time.Time) ([]*models.TimeEntry, error)
+	Create(*models.TimeEntry) (*models.TimeEntry, error)
+	PutExternal(*models.TimeEntry) (*models.TimeEntry, *models.TimeEntry, error)
+	Delete(*models.TimeEntry) error
+	RenameProject(string, string, string) (int64, error)
+}
+
+type ITrackingPauseService interface {
+	GetByUser(string) ([]*models.TrackingPause, error)
+	IsPaused(*models.User, time.Time) (bool, error)
+	Create(*models.User, *models.TrackingPause) (*models.TrackingPause, error)
+	End(*models.User, uint) error
+}
+
+type IProjectRenameService interface {
+	Rename(*models.User, string, string) (*models.ProjectRenameJob, error)
+	GetJob(string) (*models.ProjectRenameJob, bool)
+}
+
+type IScrapbookService interface {
+	Schedule()
+	CheckAll()
+	Check(*models.User, time.Time) (bool, error)
+}
+
+type IObjectStorageService interface {
+	Schedule()
+	Store(string, []byte, string) (string, error)
+	SignedUrl(string) (string, error)
+	Retrieve(string, string, string) ([]byte, error)
+	SignedUploadUrl(string, time.Duration, int64) (*objects.UploadTarget, error)
+	Upload(string, string, string, int64, io.Reader, int64) (int64, error)
+	CompleteUpload(string) error
+	Size(string) (int64, error)
+	Open(string) (io.ReadCloser, error)
+	Delete(string) error
+	DeleteExpired() (int, error)
+}
+
+type ILoadSheddingService interface {
+	Schedule()
+	Evaluate()
+	IsDegraded() bool
+	RetryAfter() time.Duration
+	Spool([]*models.Heartbeat) error
+	FlushSpool() error
+	CountSpooled() int
+	RecordShed(string, int)
+	GetShedCounts() map[string]int64
+}
+
+type IDevDataService interface {
+	Generate(*models.DevDataParams) (*models.DevDataResult, error)
+}
+
 type IHousekeepingService interface {
 	Schedule()
 	CleanUserDataBefore(*models.User, time.Time) error
 }
 
+type ITelegramService interface {
+	Setup()
+	CreateLink(*models.User) (*models.TelegramLink, error)
+	Unlink(*models.User) (bool, error)
+	HandleUpdate(*models.TelegramUpdate) error
+	Notify(*models.User, string) (bool, error)
+	SendInactivityAlert(*models.User, time.Time) (bool, error)
+	SendLanguageGoalsReport(*models.User, []*models.LanguageGoalProgress) (bool, error)
+}
+
+type INotificationTemplateService interface {
+	GetAll() ([]*models.NotificationTemplate, error)
+	Get(string, string) (*models.NotificationTemplate, error)
+	Set(*models.NotificationTemplate) error
+	Reset(string, string) error
+	Preview(*models.NotificationTemplate) (*models.RenderedNotification, error)
+	Render(string, string, interface{}) (*models.RenderedNotification, error)
+}
+
+type IJanitorService interface {
+	Schedule()
+	Run()
+	GetRemovedCounts() map[string]int64
+}
+
+type IPersonalRecordsService interface {
+	Schedule()
+	GetByUser(*models.User) (*models.PersonalRecords, error)
+	UpdateByUser(*models.User, *time.Time) error
+}
+
+type ILegalService interface {
+	RequiresConsent(*models.User) (bool, error)
+	Accept(*models.User, string) error
+	GetDocument(string) (string, error)
+}
+
+type IYearReviewService interface {
+	Schedule()
+	GetByUser(*models.User, int) (*models.YearReview, error)
+	GetByShareToken(string) (*models.YearReview, error)
+	Generate(*models.User, int) (*models.YearReview, error)
+	RenderImage(*models.YearReview) ([]byte, error)
+}
+
 type ILeaderboardService interface {
 	GetDefaultScope() *models.IntervalKey
 	Schedule()
@@ -128,14 +447,23 @@ type ILeaderboardService interface {
 	GetAggregatedByIntervalAndUser(*models.IntervalKey, string, *uint8, bool) (models.Leaderboard, error)
 	GenerateByUser(*models.User, *models.IntervalKey) (*models.LeaderboardItem, error)
 	GenerateAggregatedByUser(*models.User, *models.IntervalKey, uint8) ([]*models.LeaderboardItem, error)
+	IsEligible(*models.User, *models.IntervalKey) (bool, string)
+	CloseSeason() (*models.LeaderboardSeason, error)
+	GetSeasons() ([]*models.LeaderboardSeason, error)
+	GetSeasonStandings(string, *utils.PageParams, bool) (*models.LeaderboardSeason, []*models.LeaderboardSeasonStanding, error)
 }
 
 type IUserService interface {
 	GetUserById(string) (*models.User, error)
+	GetUserByRef(string) (*models.User, error)
 	GetUserByKey(string) (*models.User, error)
 	GetUserByEmail(string) (*models.User, error)
 	GetUserByResetToken(string) (*models.User, error)
+	GetUserBySimpleToken(string) (*models.User, error)
 	GetUserByStripeCustomerId(string) (*models.User, error)
+	GetExternalIds(*models.User) ([]*models.UserExternalId, error)
+	SetExternalId(*models.User, string, string) (*models.UserExternalId, error)
+	DeleteExternalId(*models.User, string) (bool, error)
 	GetAll() ([]*models.User, error)
 	GetAllMapped() (map[string]*models.User, error)
 	GetMany([]string) ([]*models.User, error)
@@ -145,15 +473,62 @@ type IUserService interface {
 	GetActive(bool) ([]*models.User, error)
 	Count() (int64, error)
 	CreateOrGet(*models.Signup, bool) (*models.User, bool, error)
+	CreateServiceAccount(string, string) (*models.User, bool, error)
+	CreateInvited(*models.Invite, string) (*models.User, bool, error)
+	Suspend(*models.User, string, *models.User) (*models.User, error)
+	Unsuspend(*models.User, *models.User) (*models.User, error)
 	Update(*models.User) (*models.User, error)
+	UpdateField(*models.User, string, interface{}) (*models.User, error)
 	Delete(*models.User) error
 	ResetApiKey(*models.User) (*models.User, error)
+	ResetSimpleToken(*models.User) (*models.User, error)
 	SetWakatimeApiCredentials(*models.User, string, string) (*models.User, error)
 	GenerateResetToken(*models.User) (*models.User, error)
 	FlushCache()
 	FlushUserCache(string)
 }
 
+type IEmailVerificationService interface {
+	SendVerification(*models.User) error
+	GenerateToken(*models.User, time.Time) (string, error)
+	Verify(string) (*models.User, error)
+}
+
+type ILoginThrottleService interface {
+	GetLockout(string, string) time.Duration
+	RequiresCaptcha(string, string) bool
+	RegisterFailure(*models.LoginAttempt) time.Duration
+	RegisterSuccess(*models.LoginAttempt)
+}
+
 type IShopService interface {
 	GetProducts() ([]*models.Product, error)
 }
+
+type IQueryConsoleService interface {
+	Run(string) (*models.QueryConsoleResult, error)
+}
+
+type IMentorService interface {
+	Schedule()
+	GetByUser(string) ([]*models.Mentor, error)
+	Create(*models.User, *models.Mentor) (*models.Mentor, error)
+	Delete(*models.User, uint) error
+	GetByToken(string) (*models.Mentor, error)
+	Unsubscribe(string) (*models.Mentor, error)
+	BuildSummary(*models.User, *models.Mentor) (*models.MentorSummary, error)
+	SendSummary(*models.Mentor) error
+}
+
+type ILeaderboardWebhookService interface {
+	GetByUser(string) (*models.LeaderboardWebhook, error)
+	Set(*models.LeaderboardWebhook) (*models.LeaderboardWebhook, error)
+	Delete(string) error
+	Notify(*models.LeaderboardRankChanges)
+}
+
+type IImportService interface {
+	CreateUpload(*models.User) (*models.ImportJob, error)
+	Complete(*models.User, string) (*models.ImportJob, error)
+	Get(*models.User, string) (*models.ImportJob, error)
+}
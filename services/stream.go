@@ -0,0 +1,130 @@
+package services
+
+import (
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/services/streams"
+	"github.com/leandro-lugaresi/hub"
+)
+
+const (
+	streamEventHeartbeat = "heartbeat"
+	streamEventSummary   = "summary"
+
+	streamBatchSize     = 500
+	streamFlushInterval = 1 * time.Second
+)
+
+type streamEvent struct {
+	Type    string            `json:"type"`
+	UserID  string            `json:"user_id"`
+	Time    models.CustomTime `json:"time"`
+	Payload interface{}       `json:"payload"`
+}
+
+type streamEntry struct {
+	topic   string
+	message *streams.Message
+}
+
+// StreamService publishes every accepted heartbeat and every generated summary to the configured message brokers.
+// Events are buffered in memory and published in batches, i.e. delivery is best-effort and events might get lost upon shutdown or when the buffer overflows.
+type StreamService struct {
+	config     *config.Config
+	eventBus   *hub.Hub
+	publishers []streams.Publisher
+	entries    chan *streamEntry
+}
+
+func NewStreamService() *StreamService {
+	conf := config.Get()
+	return &StreamService{
+		config:     conf,
+		eventBus:   config.EventBus(),
+		publishers: streams.GetPublishers(conf),
+		entries:    make(chan *streamEntry, conf.Streams.BufferSize),
+	}
+}
+
+func (srv *StreamService) Schedule() {
+	if !srv.config.Streams.Enabled {
+		return
+	}
+	if len(srv.publishers) == 0 {
+		slog.Warn("streams are enabled, but no broker is configured")
+		return
+	}
+
+	slog.Info("publishing event streams", "publishers", len(srv.publishers))
+
+	sub := srv.eventBus.Subscribe(0, config.EventHeartbeatCreate, config.EventSummaryCreate)
+	go func(sub *hub.Subscription) {
+		for m := range sub.Receiver {
+			switch m.Name {
+			case config.EventHeartbeatCreate:
+				heartbeat := m.Fields[config.FieldPayload].(*models.Heartbeat)
+				srv.enqueue(srv.config.Streams.HeartbeatsTopic, &streamEvent{Type: streamEventHeartbeat, UserID: heartbeat.UserID, Time: heartbeat.Time, Payload: heartbeat})
+			case config.EventSummaryCreate:
+				summary := m.Fields[config.FieldPayload].(*models.Summary)
+				srv.enqueue(srv.config.Streams.SummariesTopic, &streamEvent{Type: streamEventSummary, UserID: summary.UserID, Time: summary.ToTime, Payload: summary})
+			}
+		}
+	}(&sub)
+
+	go srv.run()
+}
+
+// enqueue is called synchronously from the event bus, so it must never block
+func (srv *StreamService) enqueue(topic string, event *streamEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		config.Log().Error("failed to encode stream event", "type", event.Type, "error", err)
+		return
+	}
+
+	select {
+	case srv.entries <- &streamEntry{topic: topic, message: &streams.Message{Key: event.UserID, Value: data}}:
+	default:
+		slog.Warn("stream buffer is full, dropping event", "type", event.Type, "userID", event.UserID)
+	}
+}
+
+func (srv *StreamService) run() {
+	ticker := time.NewTicker(streamFlushInterval)
+	defer ticker.Stop()
+
+	batch := make(map[string][]*streams.Message)
+	var count int
+
+	for {
+		select {
+		case entry := <-srv.entries:
+			batch[entry.topic] = append(batch[entry.topic], entry.message)
+			if count++; count < streamBatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if count == 0 {
+				continue
+			}
+		}
+
+		srv.flush(batch)
+		batch = make(map[string][]*streams.Message)
+		count = 0
+	}
+}
+
+func (srv *StreamService) flush(batch map[string][]*streams.Message) {
+	for topic, messages := range batch {
+		for _, p := range srv.publishers {
+			if err := p.Publish(topic, messages); err != nil {
+				config.Log().Error("failed to publish stream events", "publisher", p.Name(), "topic", topic, "count", len(messages), "error", err)
+			}
+		}
+	}
+}
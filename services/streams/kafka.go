@@ -0,0 +1,96 @@
+package streams
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+)
+
+// publishes to kafka through a confluent-compatible rest proxy (v2 api)
+// see https://docs.confluent.io/platform/current/kafka-rest/api.html#records-v2
+const (
+	kafkaContentType = "application/vnd.kafka.json.v2+json"
+	kafkaAccept      = "application/vnd.kafka.v2+json"
+)
+
+type kafkaRecord struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+type kafkaProduceRequest struct {
+	Records []*kafkaRecord `json:"records"`
+}
+
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+type KafkaPublisher struct {
+	config     config.StreamKafkaConfig
+	httpClient *http.Client
+}
+
+func NewKafkaPublisher(config config.StreamKafkaConfig) *KafkaPublisher {
+	return &KafkaPublisher{
+		config:     config,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (p *KafkaPublisher) Name() string {
+	return "kafka"
+}
+
+func (p *KafkaPublisher) Publish(topic string, messages []*Message) error {
+	payload := &kafkaProduceRequest{Records: make([]*kafkaRecord, len(messages))}
+	for i, m := range messages {
+		payload.Records[i] = &kafkaRecord{Key: m.Key, Value: m.Value}
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("%s/topics/%s", strings.TrimSuffix(p.config.RestUrl, "/"), url.PathEscape(topic)), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", kafkaContentType)
+	req.Header.Set("Accept", kafkaAccept)
+	if p.config.Username != "" {
+		req.SetBasicAuth(p.config.Username, p.config.Password)
+	}
+
+	res, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("kafka rest proxy responded with status %d", res.StatusCode)
+	}
+
+	// the proxy responds with 200 even if single records were rejected
+	var result kafkaProduceResponse
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return err
+	}
+	for _, o := range result.Offsets {
+		if o.ErrorCode != nil {
+			return fmt.Errorf("kafka rejected record with error %d (%s)", *o.ErrorCode, o.Error)
+		}
+	}
+	return nil
+}
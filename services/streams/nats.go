@@ -0,0 +1,203 @@
+package streams
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+)
+
+// minimal subset of the nats client protocol, just enough to publish messages
+// see https://docs.nats.io/reference/reference-protocols/nats-protocol
+const (
+	natsDefaultPort = "4222"
+	natsTimeout     = 10 * time.Second
+)
+
+type natsInfo struct {
+	TlsRequired bool `json:"tls_required"`
+}
+
+type natsConnect struct {
+	Verbose     bool   `json:"verbose"`
+	Pedantic    bool   `json:"pedantic"`
+	TlsRequired bool   `json:"tls_required"`
+	Name        string `json:"name"`
+	Lang        string `json:"lang"`
+	Protocol    int    `json:"protocol"`
+	User        string `json:"user,omitempty"`
+	Pass        string `json:"pass,omitempty"`
+	AuthToken   string `json:"auth_token,omitempty"`
+}
+
+// NatsPublisher keeps a single connection open, which is re-established upon errors.
+// Messages are published to core nats, i.e. they're only persisted if a jetstream stream is bound to the subject.
+// Nats has no notion of message keys, consumers have to rely on the user id inside the event payload instead.
+type NatsPublisher struct {
+	config config.StreamNatsConfig
+	conn   net.Conn
+	reader *bufio.Reader
+	lock   sync.Mutex
+}
+
+func NewNatsPublisher(config config.StreamNatsConfig) *NatsPublisher {
+	return &NatsPublisher{config: config}
+}
+
+func (p *NatsPublisher) Name() string {
+	return "nats"
+}
+
+func (p *NatsPublisher) Publish(subject string, messages []*Message) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	err := p.publish(subject, messages)
+	if err != nil {
+		// connection might have gone stale in the meantime, retry once with a fresh one
+		p.close()
+		if err = p.publish(subject, messages); err != nil {
+			p.close()
+		}
+	}
+	return err
+}
+
+func (p *NatsPublisher) publish(subject string, messages []*Message) error {
+	if p.conn == nil {
+		if err := p.connect(); err != nil {
+			return err
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, m := range messages {
+		buf.WriteString(fmt.Sprintf("PUB %s %d\r\n", subject, len(m.Value)))
+		buf.Write(m.Value)
+		buf.WriteString("\r\n")
+	}
+	buf.WriteString("PING\r\n") // server processes commands in order, so a pong confirms all messages were accepted
+
+	p.conn.SetDeadline(time.Now().Add(natsTimeout))
+	if _, err := p.conn.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	return p.awaitPong()
+}
+
+func (p *NatsPublisher) connect() error {
+	u, err := url.Parse(p.config.Url)
+	if err != nil {
+		return fmt.Errorf("invalid nats url: %v", err)
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), natsDefaultPort)
+	}
+	useTls := u.Scheme == "tls"
+
+	conn, err := net.DialTimeout("tcp", host, natsTimeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(natsTimeout))
+
+	reader := bufio.NewReader(conn)
+	line, err := readNatsLine(reader)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("unexpected nats greeting: %s", line)
+	}
+
+	var info natsInfo
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to parse nats info: %v", err)
+	}
+
+	if useTls || info.TlsRequired {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+		if err := tlsConn.Handshake(); err != nil {
+			conn.Close()
+			return err
+		}
+		conn, reader = tlsConn, bufio.NewReader(tlsConn)
+	}
+
+	connect := &natsConnect{
+		TlsRequired: useTls || info.TlsRequired,
+		Name:        "hackatime",
+		Lang:        "go",
+		Protocol:    1,
+		User:        p.config.Username,
+		Pass:        p.config.Password,
+		AuthToken:   p.config.Token,
+	}
+	if u.User != nil && connect.User == "" {
+		connect.User = u.User.Username()
+		connect.Pass, _ = u.User.Password()
+	}
+
+	data, _ := json.Marshal(connect)
+	if _, err := conn.Write([]byte(fmt.Sprintf("CONNECT %s\r\nPING\r\n", data))); err != nil {
+		conn.Close()
+		return err
+	}
+
+	p.conn, p.reader = conn, reader
+	if err := p.awaitPong(); err != nil {
+		p.close()
+		return err
+	}
+	return nil
+}
+
+func (p *NatsPublisher) awaitPong() error {
+	for {
+		line, err := readNatsLine(p.reader)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := p.conn.Write([]byte("PONG\r\n")); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return errors.New("nats error: " + strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "-ERR")), "'"))
+		}
+		// +OK and asynchronous INFO updates are ignored
+	}
+}
+
+func (p *NatsPublisher) close() {
+	if p.conn != nil {
+		p.conn.Close()
+	}
+	p.conn, p.reader = nil, nil
+}
+
+func readNatsLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
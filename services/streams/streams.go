@@ -0,0 +1,27 @@
+package streams
+
+import (
+	"github.com/hackclub/hackatime/config"
+)
+
+type Message struct {
+	Key   string // used for partitioning, where supported by the broker
+	Value []byte
+}
+
+type Publisher interface {
+	Name() string
+	Publish(string, []*Message) error
+}
+
+// GetPublishers returns all stream publishers, which are configured
+func GetPublishers(c *config.Config) []Publisher {
+	publishers := make([]Publisher, 0)
+	if c.Streams.Nats.Url != "" {
+		publishers = append(publishers, NewNatsPublisher(c.Streams.Nats))
+	}
+	if c.Streams.Kafka.RestUrl != "" {
+		publishers = append(publishers, NewKafkaPublisher(c.Streams.Kafka))
+	}
+	return publishers
+}
@@ -0,0 +1,140 @@
+package streams
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNatsPublisher_Publish(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+
+	received := make(chan string, 10)
+	connects := make(chan string, 10)
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveFakeNats(conn, connects, received)
+		}
+	}()
+
+	sut := NewNatsPublisher(config.StreamNatsConfig{Url: "nats://" + listener.Addr().String(), Token: "s3cr3t"})
+
+	err = sut.Publish("hackatime.heartbeats", []*Message{{Key: "user1", Value: []byte(`{"a":1}`)}, {Key: "user2", Value: []byte(`{"b":2}`)}})
+	assert.Nil(t, err)
+	assert.Contains(t, <-connects, `"auth_token":"s3cr3t"`)
+	assert.Equal(t, "hackatime.heartbeats "+`{"a":1}`, <-received)
+	assert.Equal(t, "hackatime.heartbeats "+`{"b":2}`, <-received)
+
+	// connection is reused
+	err = sut.Publish("hackatime.summaries", []*Message{{Key: "user1", Value: []byte(`{}`)}})
+	assert.Nil(t, err)
+	assert.Equal(t, "hackatime.summaries {}", <-received)
+	assert.Empty(t, connects)
+
+	// reconnects after connection was lost
+	sut.conn.Close()
+	err = sut.Publish("hackatime.summaries", []*Message{{Key: "user1", Value: []byte(`{}`)}})
+	assert.Nil(t, err)
+	assert.NotEmpty(t, <-connects)
+	assert.Equal(t, "hackatime.summaries {}", <-received)
+}
+
+func TestNatsPublisher_Publish_AuthError(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err)
+	defer listener.Close()
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("INFO {\"auth_required\":true}\r\n"))
+			bufio.NewReader(conn).ReadString('\n')
+			conn.Write([]byte("-ERR 'Authorization Violation'\r\n"))
+			conn.Close()
+		}
+	}()
+
+	sut := NewNatsPublisher(config.StreamNatsConfig{Url: "nats://" + listener.Addr().String()})
+	err = sut.Publish("hackatime.heartbeats", []*Message{{Value: []byte(`{}`)}})
+	assert.EqualError(t, err, "nats error: Authorization Violation")
+	assert.Nil(t, sut.conn)
+}
+
+func TestKafkaPublisher_Publish(t *testing.T) {
+	var request kafkaProduceRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/topics/hackatime.heartbeats", r.URL.Path)
+		assert.Equal(t, kafkaContentType, r.Header.Get("Content-Type"))
+		user, pass, _ := r.BasicAuth()
+		assert.Equal(t, "admin", user)
+		assert.Equal(t, "s3cr3t", pass)
+
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&request))
+		if len(request.Records) > 1 {
+			w.Write([]byte(`{"offsets":[{"partition":0,"offset":1,"error_code":null,"error":null},{"error_code":50002,"error":"record too large"}]}`))
+			return
+		}
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":1,"error_code":null,"error":null}]}`))
+	}))
+	defer server.Close()
+
+	sut := NewKafkaPublisher(config.StreamKafkaConfig{RestUrl: server.URL + "/", Username: "admin", Password: "s3cr3t"})
+
+	err := sut.Publish("hackatime.heartbeats", []*Message{{Key: "user1", Value: []byte(`{"a":1}`)}})
+	assert.Nil(t, err)
+	assert.Len(t, request.Records, 1)
+	assert.Equal(t, "user1", request.Records[0].Key)
+	assert.JSONEq(t, `{"a":1}`, string(request.Records[0].Value))
+
+	err = sut.Publish("hackatime.heartbeats", []*Message{{Key: "user1", Value: []byte(`{}`)}, {Key: "user2", Value: []byte(`{}`)}})
+	assert.EqualError(t, err, "kafka rejected record with error 50002 (record too large)")
+}
+
+// serveFakeNats speaks just enough of the nats protocol to accept a connection and published messages
+func serveFakeNats(conn net.Conn, connects, received chan<- string) {
+	defer conn.Close()
+	conn.Write([]byte("INFO {\"server_id\":\"test\"}\r\n"))
+
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+
+		switch {
+		case strings.HasPrefix(line, "CONNECT "):
+			connects <- strings.TrimPrefix(line, "CONNECT ")
+		case line == "PING":
+			conn.Write([]byte("PONG\r\n"))
+		case strings.HasPrefix(line, "PUB "):
+			parts := strings.Split(line, " ")
+			n, _ := strconv.Atoi(parts[2])
+			payload := make([]byte, n+2)
+			if _, err := io.ReadFull(reader, payload); err != nil {
+				return
+			}
+			received <- parts[1] + " " + string(payload[:n])
+		}
+	}
+}
@@ -1,10 +1,13 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/becheran/wildmatch-go"
@@ -18,6 +21,8 @@ import (
 	"github.com/patrickmn/go-cache"
 )
 
+var ErrSummaryTimeout = errors.New("summary took too long to compute, try narrowing the requested time range")
+
 type SummaryService struct {
 	config              *config.Config
 	cache               *cache.Cache
@@ -27,6 +32,10 @@ type SummaryService struct {
 	durationService     IDurationService
 	aliasService        IAliasService
 	projectLabelService IProjectLabelService
+	degraded            *atomic.Bool
+	versions            *sync.Map // user id -> time of the latest change to any data the user's summaries depend on
+	cacheIndex          *sync.Map // user id -> *summaryCacheIndex
+	startVersion        int64
 }
 
 func NewSummaryService(summaryRepo repositories.ISummaryRepository, heartbeatService IHeartbeatService, durationService IDurationService, aliasService IAliasService, projectLabelService IProjectLabelService) *SummaryService {
@@ -39,30 +48,101 @@ func NewSummaryService(summaryRepo repositories.ISummaryRepository, heartbeatSer
 		durationService:     durationService,
 		aliasService:        aliasService,
 		projectLabelService: projectLabelService,
+		degraded:            &atomic.Bool{},
+		versions:            &sync.Map{},
+		cacheIndex:          &sync.Map{},
+		startVersion:        time.Now().UnixNano(),
 	}
 
-	sub1 := srv.eventBus.Subscribe(0, config.TopicProjectLabel)
+	// keep the index in sync with expired summaries
+	srv.cache.OnEvicted(func(key string, value interface{}) {
+		if summary, ok := value.(*models.Summary); ok {
+			if index, ok := srv.cacheIndex.Load(summary.UserID); ok {
+				index.(*summaryCacheIndex).remove(key)
+			}
+		}
+	})
+
+	sub1 := srv.eventBus.Subscribe(0, config.TopicProjectLabel, config.TopicTimeEntry)
 	go func(sub *hub.Subscription) {
 		for m := range sub.Receiver {
 			srv.invalidateUserCache(m.Fields[config.FieldUserId].(string))
 		}
 	}(&sub1)
 
+	sub2 := srv.eventBus.Subscribe(0, config.TopicLoadShedding)
+	go func(sub *hub.Subscription) {
+		for m := range sub.Receiver {
+			srv.degraded.Store(m.Name == config.EventLoadSheddingStart)
+		}
+	}(&sub2)
+
+	sub3 := srv.eventBus.Subscribe(0, config.EventHeartbeatCreate, config.EventHeartbeatUpdate, config.EventUserUpdate)
+	go func(sub *hub.Subscription) {
+		for m := range sub.Receiver {
+			if m.Name == config.EventHeartbeatUpdate {
+				if userId := m.Fields[config.FieldUserId].(string); userId != "" {
+					srv.invalidateUserCache(userId)
+				} else {
+					srv.invalidateCache()
+				}
+				continue
+			}
+
+			switch payload := m.Fields[config.FieldPayload].(type) {
+			case *models.Heartbeat:
+				srv.bumpVersion(payload.UserID)
+				srv.invalidateHeartbeatDay(payload)
+			case *models.User:
+				srv.bumpVersion(payload.ID)
+			}
+		}
+	}(&sub3)
+
+	return srv
+}
+
+// WithContext returns a copy of the service sharing the same caches, whose database queries are canceled once the given context is done
+// summaries must then be retrieved using the copy's Retrieve and Summarize methods, too
+func (srv *SummaryService) WithContext(ctx context.Context) ISummaryService {
+	scoped := *srv
+	scoped.repository = srv.repository.WithContext(ctx)
+	if s, ok := srv.heartbeatService.(contextScoped[IHeartbeatService]); ok {
+		scoped.heartbeatService = s.WithContext(ctx)
+	}
+	if s, ok := srv.durationService.(contextScoped[IDurationService]); ok {
+		scoped.durationService = s.WithContext(ctx)
+	}
+	return &scoped
+}
+
+// SummaryServiceWithContext binds the given summary service to a request's context, if supported by the implementation
+func SummaryServiceWithContext(srv ISummaryService, ctx context.Context) ISummaryService {
+	if s, ok := srv.(contextScoped[ISummaryService]); ok {
+		return s.WithContext(ctx)
+	}
 	return srv
 }
 
 // Public summary generation methods
 
 // Aliased retrieves or computes a new summary based on the given SummaryRetriever and augments it with entity aliases and project labels
+// while in degraded mode, summaries are served from cache only
 func (srv *SummaryService) Aliased(from, to time.Time, user *models.User, f types.SummaryRetriever, filters *models.Filters, skipCache bool) (*models.Summary, error) {
+	degraded := srv.degraded.Load()
+
 	// Check cache (or skip for sub second-level date precision)
 	cacheKey := srv.getHash(from.String(), to.String(), user.ID, filters.Hash(), "--aliased")
 	if to.Truncate(time.Second).Equal(to) && from.Truncate(time.Second).Equal(from) {
-		if cacheResult, ok := srv.cache.Get(cacheKey); ok && !skipCache {
+		if cacheResult, ok := srv.cache.Get(cacheKey); ok && (!skipCache || degraded) {
 			return cacheResult.(*models.Summary), nil
 		}
 	}
 
+	if degraded {
+		return nil, ErrDegraded
+	}
+
 	// Resolver functions
 	resolveAliases := srv.getAliasResolver(user)
 	resolveAliasesReverse := srv.getAliasReverseResolver(user)
@@ -81,6 +161,9 @@ func (srv *SummaryService) Aliased(from, to time.Time, user *models.User, f type
 
 	// Get actual summary
 	s, err := f(from, to, user, filters)
+	if errors.Is(err, context.DeadlineExceeded) {
+		return nil, ErrSummaryTimeout
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -96,7 +179,7 @@ func (srv *SummaryService) Aliased(from, to time.Time, user *models.User, f type
 		summary.Entities = nil
 	}
 
-	srv.cache.SetDefault(cacheKey, summary)
+	srv.cacheSummary(cacheKey, user, from, to, summary)
 	return summary.Sorted(), nil
 }
 
@@ -106,7 +189,7 @@ func (srv *SummaryService) Retrieve(from, to time.Time, user *models.User, filte
 	// Filtered summaries are not persisted currently
 	// Special case: if (a) filters apply to only one entity type and (b) we're only interested in the summary items of that particular entity type,
 	// we can still fetch the persisted summary and drop all irrelevant parts from it
-	if filters == nil || filters.IsEmpty() || (filters.CountDistinctTypes() == 1 && filters.SelectFilteredOnly) {
+	if filters == nil || filters.IsEmpty() || (filters.CountDistinctTypes() == 1 && filters.SelectFilteredOnly && !filters.Source.Exists() && !filters.Tag.Exists()) {
 		// Get all already existing, pre-generated summaries that fall into the requested interval
 		result, err := srv.repository.GetByUserWithin(user, from, to)
 		if err == nil {
@@ -216,6 +299,7 @@ func (srv *SummaryService) Summarize(from, to time.Time, user *models.User, filt
 		Entities:         entityItems,
 		Categories:       categoryItems,
 		NumHeartbeats:    durations.TotalNumHeartbeats(),
+		EditStats:        durations.TotalEditStats(),
 	}
 
 	return summary.Sorted(), nil
@@ -237,9 +321,21 @@ func (srv *SummaryService) DeleteByUserBefore(userId string, t time.Time) error
 	return srv.repository.DeleteByUserBefore(userId, t)
 }
 
+func (srv *SummaryService) DeleteByUserWithin(userId string, from, to time.Time) error {
+	srv.invalidateUserCache(userId)
+	return srv.repository.DeleteByUserWithin(userId, from, to)
+}
+
 func (srv *SummaryService) Insert(summary *models.Summary) error {
 	srv.invalidateUserCache(summary.UserID)
-	return srv.repository.Insert(summary)
+	if err := srv.repository.Insert(summary); err != nil {
+		return err
+	}
+	srv.eventBus.Publish(hub.Message{
+		Name:   config.EventSummaryCreate,
+		Fields: map[string]interface{}{config.FieldPayload: summary},
+	})
+	return nil
 }
 
 // Private summary generation and utility methods
@@ -372,6 +468,7 @@ func (srv *SummaryService) mergeSummaries(summaries []*models.Summary) (*models.
 		finalSummary.Entities = srv.mergeSummaryItems(finalSummary.Entities, s.Entities)
 		finalSummary.Categories = srv.mergeSummaryItems(finalSummary.Categories, s.Categories)
 		finalSummary.NumHeartbeats += s.NumHeartbeats
+		finalSummary.EditStats = finalSummary.EditStats.Add(s.EditStats)
 
 		processed[hash] = true
 	}
@@ -414,7 +511,7 @@ func (srv *SummaryService) mergeSummaryItems(existing []*models.SummaryItem, new
 
 func (srv *SummaryService) getMissingIntervals(from, to time.Time, summaries []*models.Summary, precise bool) []*models.Interval {
 	if len(summaries) == 0 {
-		return []*models.Interval{{from, to}}
+		return []*models.Interval{{Start: from, End: to}}
 	}
 
 	intervals := make([]*models.Interval, 0)
@@ -467,7 +564,22 @@ func (srv *SummaryService) getHash(args ...string) string {
 	return strings.Join(args, "__")
 }
 
+// GetVersion returns a value that changes whenever new heartbeats arrive for the user or anything else happens, which might alter the user's summaries
+// clients polling for summaries can use it to skip recomputing them if nothing has changed
+func (srv *SummaryService) GetVersion(userId string) int64 {
+	if v, ok := srv.versions.Load(userId); ok {
+		return v.(int64)
+	}
+	return srv.startVersion
+}
+
+func (srv *SummaryService) bumpVersion(userId string) {
+	srv.versions.Store(userId, time.Now().UnixNano())
+}
+
 func (srv *SummaryService) invalidateUserCache(userId string) {
+	srv.bumpVersion(userId)
+	srv.cacheIndex.Delete(userId)
 	for key := range srv.cache.Items() {
 		if strings.Contains(key, userId) {
 			srv.cache.Delete(key)
@@ -475,6 +587,82 @@ func (srv *SummaryService) invalidateUserCache(userId string) {
 	}
 }
 
+// invalidateCache drops all cached summaries, e.g. after heartbeats of all users were modified
+func (srv *SummaryService) invalidateCache() {
+	bump := func(userId, _ any) bool {
+		srv.bumpVersion(userId.(string))
+		return true
+	}
+	srv.versions.Range(bump)
+	srv.cacheIndex.Range(bump)
+	srv.cacheIndex.Clear()
+	srv.cache.Flush()
+}
+
+// cacheSummary caches the summary until it expires or heartbeats are added to any of the days it covers in retrospect, summaries
+// are indexed by their time range for the latter
+func (srv *SummaryService) cacheSummary(key string, user *models.User, from, to time.Time, summary *models.Summary) {
+	srv.cache.SetDefault(key, summary)
+
+	index, _ := srv.cacheIndex.LoadOrStore(user.ID, newSummaryCacheIndex())
+	index.(*summaryCacheIndex).add(key, from, to)
+}
+
+// invalidateHeartbeatDay drops all of the user's cached summaries overlapping the day the given heartbeat belongs to, but keeps all others
+func (srv *SummaryService) invalidateHeartbeatDay(heartbeat *models.Heartbeat) {
+	index, ok := srv.cacheIndex.Load(heartbeat.UserID)
+	if !ok {
+		return
+	}
+
+	// without knowing the user's time zone, the day may be off by up to a day in either direction
+	from, to := heartbeat.Time.T().Add(-24*time.Hour), heartbeat.Time.T().Add(24*time.Hour)
+	if heartbeat.User != nil {
+		from = datetime.BeginOfDay(heartbeat.Time.T().In(heartbeat.User.TZ()))
+		to = from.AddDate(0, 0, 1)
+	}
+
+	for _, key := range index.(*summaryCacheIndex).removeOverlapping(from, to) {
+		srv.cache.Delete(key)
+	}
+}
+
+// summaryCacheIndex keeps track of the time ranges covered by a user's cached summaries, to selectively invalidate them
+type summaryCacheIndex struct {
+	lock    sync.Mutex
+	entries map[string][2]time.Time
+}
+
+func newSummaryCacheIndex() *summaryCacheIndex {
+	return &summaryCacheIndex{entries: make(map[string][2]time.Time)}
+}
+
+func (i *summaryCacheIndex) add(key string, from, to time.Time) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.entries[key] = [2]time.Time{from, to}
+}
+
+func (i *summaryCacheIndex) remove(key string) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	delete(i.entries, key)
+}
+
+func (i *summaryCacheIndex) removeOverlapping(from, to time.Time) []string {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	keys := make([]string, 0)
+	for key, r := range i.entries {
+		if r[0].Before(to) && r[1].After(from) {
+			keys = append(keys, key)
+			delete(i.entries, key)
+		}
+	}
+	return keys
+}
+
 func (srv *SummaryService) getAliasResolver(user *models.User) models.AliasResolver {
 	return func(t uint8, k string) string {
 		s, _ := srv.aliasService.GetAliasOrDefault(user.ID, t, k)
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// golden tests compute summaries from fixture heartbeat datasets and compare them to previously recorded outputs byte by byte,
+// so that changes to the aggregation logic can't silently alter historical numbers.
+// after intentionally changing the logic, re-record the outputs with `go test ./services -run TestSummaryGolden -update-golden` and review the diff.
+var updateGolden = flag.Bool("update-golden", false, "overwrite golden summary files with the current outputs")
+
+const goldenDir = "testdata/golden"
+
+// goldenFixture is a dataset of raw heartbeats, as sent by clients, to be summarized within the given range
+type goldenFixture struct {
+	Description          string              `json:"description"`
+	From                 time.Time           `json:"from"`
+	To                   time.Time           `json:"to"`
+	HeartbeatsTimeoutSec int                 `json:"heartbeats_timeout_sec"` // user's custom timeout, default if zero
+	Heartbeats           []*models.Heartbeat `json:"heartbeats"`
+}
+
+type goldenItem struct {
+	Key     string `json:"key"`
+	Seconds int64  `json:"seconds"`
+}
+
+type goldenDay struct {
+	Date          string `json:"date"`
+	Seconds       int64  `json:"seconds"`
+	NumHeartbeats int    `json:"num_heartbeats"`
+}
+
+// goldenOutput is a deterministic representation of a summary, i.e. independent of map iteration order and sorting of ties
+type goldenOutput struct {
+	From          string                  `json:"from"`
+	To            string                  `json:"to"`
+	TotalSeconds  int64                   `json:"total_seconds"`
+	NumDurations  int                     `json:"num_durations"`
+	NumHeartbeats int                     `json:"num_heartbeats"`
+	EditStats     models.EditStats        `json:"edit_stats"`
+	Items         map[string][]goldenItem `json:"items"`
+	Daily         []goldenDay             `json:"daily"`
+}
+
+func TestSummaryGolden(t *testing.T) {
+	config.Set(config.Empty())
+
+	fixtures, err := filepath.Glob(filepath.Join(goldenDir, "*.heartbeats.json"))
+	assert.Nil(t, err)
+	assert.NotEmpty(t, fixtures)
+
+	for _, fixturePath := range fixtures {
+		name := strings.TrimSuffix(filepath.Base(fixturePath), ".heartbeats.json")
+		t.Run(name, func(t *testing.T) {
+			fixture := loadGoldenFixture(t, fixturePath)
+			actual := renderGoldenOutput(t, fixture)
+
+			goldenPath := filepath.Join(goldenDir, name+".golden.json")
+			if *updateGolden {
+				assert.Nil(t, os.WriteFile(goldenPath, actual, 0644))
+				return
+			}
+
+			expected, err := os.ReadFile(goldenPath)
+			if !assert.Nil(t, err, "missing golden file, run with -update-golden to create it") {
+				return
+			}
+			assert.Equal(t, string(expected), string(actual), "summary of %s (%s) changed", name, fixture.Description)
+		})
+	}
+}
+
+func loadGoldenFixture(t *testing.T, path string) *goldenFixture {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fixture goldenFixture
+	if err := json.Unmarshal(data, &fixture); err != nil {
+		t.Fatal(err)
+	}
+	for _, h := range fixture.Heartbeats {
+		h.UserID = TestUserId
+	}
+	sort.SliceStable(fixture.Heartbeats, func(i, j int) bool {
+		return fixture.Heartbeats[i].Time.T().Before(fixture.Heartbeats[j].Time.T())
+	})
+	return &fixture
+}
+
+func renderGoldenOutput(t *testing.T, fixture *goldenFixture) []byte {
+	user := &models.User{ID: TestUserId, HeartbeatsTimeoutSec: fixture.HeartbeatsTimeoutSec}
+
+	heartbeatService := new(mocks.HeartbeatServiceMock)
+	heartbeatService.On("GetAllWithin", fixture.From, fixture.To, user).Return(filterHeartbeats(fixture.From, fixture.To, fixture.Heartbeats), nil)
+	for day := fixture.From; day.Before(fixture.To); day = day.AddDate(0, 0, 1) {
+		heartbeatService.On("GetAllWithin", day, day.AddDate(0, 0, 1), user).Return(filterHeartbeats(day, day.AddDate(0, 0, 1), fixture.Heartbeats), nil)
+	}
+
+	durationService := NewDurationService(heartbeatService, nil)
+	sut := NewSummaryService(new(mocks.SummaryRepositoryMock), heartbeatService, durationService, new(mocks.AliasServiceMock), new(mocks.ProjectLabelServiceMock))
+
+	durations, err := durationService.Get(fixture.From, fixture.To, user, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summary, err := sut.Summarize(fixture.From, fixture.To, user, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := &goldenOutput{
+		From:          summary.FromTime.T().UTC().Format(time.RFC3339Nano),
+		To:            summary.ToTime.T().UTC().Format(time.RFC3339Nano),
+		TotalSeconds:  int64(summary.TotalTime().Seconds()),
+		NumDurations:  len(durations),
+		NumHeartbeats: summary.NumHeartbeats,
+		EditStats:     summary.EditStats,
+		Items:         make(map[string][]goldenItem),
+		Daily:         make([]goldenDay, 0),
+	}
+
+	for _, summaryType := range models.PersistedSummaryTypes() {
+		items := make([]goldenItem, 0)
+		for _, item := range *summary.GetByType(summaryType) {
+			items = append(items, goldenItem{Key: item.Key, Seconds: int64(item.TotalFixed().Seconds())})
+		}
+		sort.Slice(items, func(i, j int) bool {
+			if items[i].Seconds != items[j].Seconds {
+				return items[i].Seconds > items[j].Seconds
+			}
+			return items[i].Key < items[j].Key
+		})
+		output.Items[models.GetEntityColumn(summaryType)] = items
+	}
+
+	// daily summaries are what gets persisted and later merged, so they must add up consistently, too
+	for day := fixture.From; day.Before(fixture.To); day = day.AddDate(0, 0, 1) {
+		daily, err := sut.Summarize(day, day.AddDate(0, 0, 1), user, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		output.Daily = append(output.Daily, goldenDay{
+			Date:          day.Format(time.DateOnly),
+			Seconds:       int64(daily.TotalTime().Seconds()),
+			NumHeartbeats: daily.NumHeartbeats,
+		})
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(output); err != nil {
+		t.Fatal(fmt.Errorf("failed to encode golden output: %w", err))
+	}
+	return buf.Bytes()
+}
@@ -1,13 +1,18 @@
 package services
 
 import (
+	"context"
+	"fmt"
 	"math/rand"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/hackclub/hackatime/config"
 	"github.com/hackclub/hackatime/mocks"
 	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/utils"
+	"github.com/leandro-lugaresi/hub"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
@@ -425,6 +430,22 @@ func (suite *SummaryServiceTestSuite) TestSummaryService_Aliased() {
 	assert.Nil(suite.T(), result.Branches)
 }
 
+func (suite *SummaryServiceTestSuite) TestSummaryService_Aliased_Timeout() {
+	sut := NewSummaryService(suite.SummaryRepository, suite.HeartbeatService, suite.DurationService, suite.AliasService, suite.ProjectLabelService)
+
+	suite.AliasService.On("InitializeUser", suite.TestUser.ID).Return(nil)
+	suite.ProjectLabelService.On("GetByUser", suite.TestUser.ID).Return([]*models.ProjectLabel{}, nil)
+
+	from, to := suite.TestStartTime, suite.TestStartTime.Add(1*time.Hour)
+	retrieve := func(time.Time, time.Time, *models.User, *models.Filters) (*models.Summary, error) {
+		return nil, fmt.Errorf("query failed: %w", context.DeadlineExceeded)
+	}
+
+	result, err := sut.Aliased(from, to, suite.TestUser, retrieve, nil, false)
+	assert.ErrorIs(suite.T(), err, ErrSummaryTimeout)
+	assert.Nil(suite.T(), result)
+}
+
 func (suite *SummaryServiceTestSuite) TestSummaryService_Aliased_ProjectLabels() {
 	sut := NewSummaryService(suite.SummaryRepository, suite.HeartbeatService, suite.DurationService, suite.AliasService, suite.ProjectLabelService)
 
@@ -505,6 +526,89 @@ func (suite *SummaryServiceTestSuite) TestSummaryService_Filters() {
 	assert.Contains(suite.T(), effectiveFilters.Label, TestProjectLabel3)
 }
 
+func (suite *SummaryServiceTestSuite) TestSummaryService_Aliased_InvalidateByDay() {
+	sut := NewSummaryService(suite.SummaryRepository, suite.HeartbeatService, suite.DurationService, suite.AliasService, suite.ProjectLabelService)
+
+	suite.AliasService.On("InitializeUser", TestUserId).Return(nil)
+	suite.AliasService.On("GetAliasOrDefault", TestUserId, mock.Anything, mock.Anything).Return("", nil)
+	suite.ProjectLabelService.On("GetByUser", TestUserId).Return([]*models.ProjectLabel{}, nil)
+
+	var numCalls int
+	retrieve := func(from, to time.Time, user *models.User, filters *models.Filters) (*models.Summary, error) {
+		numCalls++
+		summary := models.NewEmptySummary()
+		summary.UserID = user.ID
+		summary.FromTime, summary.ToTime = models.CustomTime(from), models.CustomTime(to)
+		return summary, nil
+	}
+
+	user := &models.User{ID: TestUserId, Location: "UTC"}
+	today := utils.BeginOfToday(time.UTC)
+	day1, day2, day3 := today.AddDate(0, 0, -3), today.AddDate(0, 0, -2), today.AddDate(0, 0, -1)
+
+	sut.Aliased(day1, day2, user, retrieve, nil, false)
+	sut.Aliased(day2, day3, user, retrieve, nil, false)
+	sut.Aliased(day1, today, user, retrieve, nil, false)
+	assert.Equal(suite.T(), 3, numCalls)
+
+	// heartbeat in retrospect only affects summaries overlapping its day
+	sut.invalidateHeartbeatDay(&models.Heartbeat{UserID: TestUserId, User: user, Time: models.CustomTime(day2.Add(3 * time.Hour))})
+
+	sut.Aliased(day1, day2, user, retrieve, nil, false)
+	assert.Equal(suite.T(), 3, numCalls)
+	sut.Aliased(day2, day3, user, retrieve, nil, false)
+	assert.Equal(suite.T(), 4, numCalls)
+	sut.Aliased(day1, today, user, retrieve, nil, false)
+	assert.Equal(suite.T(), 5, numCalls)
+
+	// summaries of completed days expire nevertheless
+	_, expiration, _ := sut.cache.GetWithExpiration(sut.getHash(day1.String(), day2.String(), TestUserId, (*models.Filters)(nil).Hash(), "--aliased"))
+	assert.False(suite.T(), expiration.IsZero())
+	assert.WithinDuration(suite.T(), time.Now().Add(24*time.Hour), expiration, time.Minute)
+}
+
+func (suite *SummaryServiceTestSuite) TestSummaryService_Aliased_InvalidateOnHeartbeatUpdate() {
+	sut := NewSummaryService(suite.SummaryRepository, suite.HeartbeatService, suite.DurationService, suite.AliasService, suite.ProjectLabelService)
+
+	suite.AliasService.On("InitializeUser", mock.Anything).Return(nil)
+	suite.AliasService.On("GetAliasOrDefault", mock.Anything, mock.Anything, mock.Anything).Return("", nil)
+	suite.ProjectLabelService.On("GetByUser", mock.Anything).Return([]*models.ProjectLabel{}, nil)
+
+	retrieve := func(from, to time.Time, user *models.User, filters *models.Filters) (*models.Summary, error) {
+		summary := models.NewEmptySummary()
+		summary.UserID = user.ID
+		summary.FromTime, summary.ToTime = models.CustomTime(from), models.CustomTime(to)
+		return summary, nil
+	}
+	isCached := func(user *models.User, from, to time.Time) bool {
+		_, ok := sut.cache.Get(sut.getHash(from.String(), to.String(), user.ID, (*models.Filters)(nil).Hash(), "--aliased"))
+		return ok
+	}
+	publish := func(userId string) {
+		config.EventBus().Publish(hub.Message{Name: config.EventHeartbeatUpdate, Fields: map[string]interface{}{config.FieldUserId: userId}})
+	}
+
+	user1, user2 := &models.User{ID: "invalidate-user1", Location: "UTC"}, &models.User{ID: "invalidate-user2", Location: "UTC"}
+	to := utils.BeginOfToday(time.UTC).AddDate(0, 0, -1)
+	from := to.AddDate(0, 0, -7)
+
+	sut.Aliased(from, to, user1, retrieve, nil, false)
+	sut.Aliased(from, to, user2, retrieve, nil, false)
+	version1, version2 := sut.GetVersion(user1.ID), sut.GetVersion(user2.ID)
+
+	// e.g. heartbeats deleted or renamed in retrospect
+	publish(user1.ID)
+	assert.Eventually(suite.T(), func() bool { return !isCached(user1, from, to) }, time.Second, 5*time.Millisecond)
+	assert.True(suite.T(), isCached(user2, from, to))
+	assert.NotEqual(suite.T(), version1, sut.GetVersion(user1.ID))
+	assert.Equal(suite.T(), version2, sut.GetVersion(user2.ID))
+
+	// heartbeats of all users modified
+	publish("")
+	assert.Eventually(suite.T(), func() bool { return !isCached(user2, from, to) }, time.Second, 5*time.Millisecond)
+	assert.NotEqual(suite.T(), version2, sut.GetVersion(user2.ID))
+}
+
 func (suite *SummaryServiceTestSuite) TestSummaryService_getMissingIntervals() {
 	sut := NewSummaryService(suite.SummaryRepository, suite.HeartbeatService, suite.DurationService, suite.AliasService, suite.ProjectLabelService)
 
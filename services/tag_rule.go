@@ -0,0 +1,63 @@
+package services
+
+import (
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/patrickmn/go-cache"
+)
+
+// TagRuleService manages the rules by which incoming heartbeats are tagged, see models.TagRule
+type TagRuleService struct {
+	config     *config.Config
+	cache      *cache.Cache
+	repository repositories.ITagRuleRepository
+}
+
+func NewTagRuleService(tagRuleRepository repositories.ITagRuleRepository) *TagRuleService {
+	return &TagRuleService{
+		config:     config.Get(),
+		repository: tagRuleRepository,
+		cache:      cache.New(24*time.Hour, 24*time.Hour),
+	}
+}
+
+func (srv *TagRuleService) GetByUser(userId string) (models.TagRules, error) {
+	if rules, found := srv.cache.Get(userId); found {
+		return rules.(models.TagRules), nil
+	}
+
+	rules, err := srv.repository.GetByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	srv.cache.Set(userId, models.TagRules(rules), cache.DefaultExpiration)
+	return rules, nil
+}
+
+func (srv *TagRuleService) Create(rule *models.TagRule) (*models.TagRule, error) {
+	result, err := srv.repository.Insert(rule)
+	if err != nil {
+		return nil, err
+	}
+	srv.cache.Delete(rule.UserID)
+	return result, nil
+}
+
+func (srv *TagRuleService) Delete(userId string, id uint) error {
+	err := srv.repository.DeleteByUserAndId(userId, id)
+	srv.cache.Delete(userId)
+	return err
+}
+
+// Apply adds the tags of all of the user's matching rules to the heartbeat
+func (srv *TagRuleService) Apply(user *models.User, heartbeat *models.Heartbeat) error {
+	rules, err := srv.GetByUser(user.ID)
+	if err != nil {
+		return err
+	}
+	rules.Apply(heartbeat)
+	return nil
+}
@@ -0,0 +1,284 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/muety/artifex/v2"
+)
+
+var (
+	ErrTeamInvalid         = errors.New("invalid team, a name is required, the webhook url must use https and work hours must be given as hh:mm")
+	ErrTeamTemplateInvalid = errors.New("invalid digest template")
+	ErrTeamMemberConflict  = errors.New("user is already a member of the team")
+	ErrTeamNoWebhook       = errors.New("team has no slack webhook url")
+)
+
+var teamDigestFuncs = template.FuncMap{
+	"date":     helpers.FormatDateHuman,
+	"duration": helpers.FmtWakatimeDuration,
+	"inc": func(i int) int {
+		return i + 1
+	},
+}
+
+// TeamService manages teams of users and posts a weekly digest of their activity to each team's slack channel
+type TeamService struct {
+	config          *config.Config
+	repository      repositories.ITeamRepository
+	userService     IUserService
+	summaryService  ISummaryService
+	durationService IDurationService
+	queueDefault    *artifex.Dispatcher
+}
+
+func NewTeamService(teamRepo repositories.ITeamRepository, userService IUserService, summaryService ISummaryService, durationService IDurationService) *TeamService {
+	return &TeamService{
+		config:          config.Get(),
+		repository:      teamRepo,
+		userService:     userService,
+		summaryService:  summaryService,
+		durationService: durationService,
+		queueDefault:    config.GetDefaultQueue(),
+	}
+}
+
+func (srv *TeamService) Schedule() {
+	slog.Info("scheduling team digests")
+	if _, err := srv.queueDefault.DispatchCron(srv.PostAllDigests, srv.config.App.GetTeamDigestCron()); err != nil {
+		config.Log().Error("failed to schedule team digests", "error", err)
+	}
+}
+
+func (srv *TeamService) GetAll() ([]*models.Team, error) {
+	return srv.repository.GetAll()
+}
+
+func (srv *TeamService) GetById(id uint) (*models.Team, error) {
+	return srv.repository.GetById(id)
+}
+
+func (srv *TeamService) Create(team *models.Team) (*models.Team, error) {
+	if err := srv.validate(team); err != nil {
+		return nil, err
+	}
+	return srv.repository.Insert(team)
+}
+
+func (srv *TeamService) Update(team *models.Team) (*models.Team, error) {
+	if err := srv.validate(team); err != nil {
+		return nil, err
+	}
+	return srv.repository.Update(team)
+}
+
+func (srv *TeamService) Delete(id uint) error {
+	if _, err := srv.repository.GetById(id); err != nil {
+		return err
+	}
+	return srv.repository.Delete(id)
+}
+
+func (srv *TeamService) AddMember(team *models.Team, userId string) (*models.TeamMember, error) {
+	if team.HasMember(userId) {
+		return nil, ErrTeamMemberConflict
+	}
+	if _, err := srv.userService.GetUserById(userId); err != nil {
+		return nil, err
+	}
+	return srv.repository.InsertMember(&models.TeamMember{TeamID: team.ID, UserID: userId})
+}
+
+func (srv *TeamService) RemoveMember(team *models.Team, userId string) error {
+	return srv.repository.DeleteMember(team.ID, userId)
+}
+
+// PostAllDigests posts last week's digest of every team that has a slack channel configured
+func (srv *TeamService) PostAllDigests() {
+	teams, err := srv.repository.GetAll()
+	if err != nil {
+		config.Log().Error("failed to fetch teams for digests", "error", err)
+		return
+	}
+
+	for _, team := range teams {
+		if team.SlackWebhookUrl == "" || len(team.Members) == 0 {
+			continue
+		}
+		if err := srv.PostDigest(team); err != nil {
+			config.Log().Error("failed to post team digest", "teamID", team.ID, "error", err)
+		}
+	}
+}
+
+func (srv *TeamService) PostDigest(team *models.Team) error {
+	if team.SlackWebhookUrl == "" {
+		return ErrTeamNoWebhook
+	}
+
+	text, err := srv.RenderDigest(team)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("posting team digest", "teamID", team.ID, "members", len(team.Members))
+
+	data, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return err
+	}
+	return postWebhookJson(team.SlackWebhookUrl, data, nil)
+}
+
+// RenderDigest renders the team's template with the digest of the last full week, i.e. the seven days before today
+func (srv *TeamService) RenderDigest(team *models.Team) (string, error) {
+	to := datetime.BeginOfDay(time.Now())
+	digest, err := srv.BuildDigest(team, to.AddDate(0, 0, -7), to)
+	if err != nil {
+		return "", err
+	}
+	return renderTeamDigest(team, digest)
+}
+
+// BuildDigest sums up the team members' coding time between from and to, compared to the same period right before to find the most improved member.
+// Each member's time is split into work and off hours according to the team's schedule, applied in the member's own time zone.
+func (srv *TeamService) BuildDigest(team *models.Team, from, to time.Time) (*models.TeamDigest, error) {
+	previousFrom := from.Add(-to.Sub(from))
+	digest := &models.TeamDigest{
+		Team:    team.Name,
+		From:    from,
+		To:      to.Add(-time.Nanosecond),
+		Members: len(team.Members),
+	}
+	projects := map[string]time.Duration{}
+
+	for _, m := range team.Members {
+		user, err := srv.userService.GetUserById(m.UserID)
+		if err != nil {
+			return nil, err
+		}
+
+		summary, err := srv.summaryService.Aliased(from, to, user, srv.summaryService.Retrieve, nil, false)
+		if err != nil {
+			return nil, err
+		}
+		previous, err := srv.summaryService.Aliased(previousFrom, from, user, srv.summaryService.Retrieve, nil, false)
+		if err != nil {
+			return nil, err
+		}
+
+		total := summary.TotalTime()
+		if total > 0 {
+			digest.ActiveMembers++
+		}
+		digest.Total += total
+		for _, p := range summary.Projects {
+			projects[p.Key] += p.TotalFixed()
+		}
+
+		if total > 0 {
+			hours, err := srv.splitWorkHours(team, user, from, to, total)
+			if err != nil {
+				return nil, err
+			}
+			digest.WorkHours += hours.WorkHours
+			digest.OffHours += hours.OffHours
+			digest.MemberHours = append(digest.MemberHours, hours)
+		}
+
+		improvement := total - previous.TotalTime()
+		if improvement > 0 && (digest.MostImproved == nil || improvement > digest.MostImproved.Total-digest.MostImproved.Previous) {
+			digest.MostImproved = &models.TeamDigestImprovement{UserID: user.ID, Total: total, Previous: previous.TotalTime()}
+		}
+	}
+
+	for key, total := range projects {
+		digest.TopProjects = append(digest.TopProjects, &models.TeamDigestProject{Key: key, Total: total})
+	}
+	sort.Slice(digest.TopProjects, func(i, j int) bool {
+		if digest.TopProjects[i].Total == digest.TopProjects[j].Total {
+			return digest.TopProjects[i].Key < digest.TopProjects[j].Key
+		}
+		return digest.TopProjects[i].Total > digest.TopProjects[j].Total
+	})
+	if len(digest.TopProjects) > models.TeamDigestTopProjects {
+		digest.TopProjects = digest.TopProjects[:models.TeamDigestTopProjects]
+	}
+
+	sort.SliceStable(digest.MemberHours, func(i, j int) bool {
+		return digest.MemberHours[i].OffHours > digest.MemberHours[j].OffHours
+	})
+
+	return digest, nil
+}
+
+// splitWorkHours classifies a member's durations into work and off hours, the summary's total is authoritative, because durations
+// aren't subject to the same aliasing and may thus slightly differ from it
+func (srv *TeamService) splitWorkHours(team *models.Team, user *models.User, from, to time.Time, total time.Duration) (*models.TeamDigestMemberHours, error) {
+	durations, err := srv.durationService.Get(from, to, user, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var work time.Duration
+	for _, d := range durations {
+		work += team.WorkTime(d.Time.T(), d.Time.T().Add(d.Duration), user.TZ())
+	}
+	work = min(work, total)
+
+	return &models.TeamDigestMemberHours{UserID: user.ID, Total: total, WorkHours: work, OffHours: total - work}, nil
+}
+
+func (srv *TeamService) validate(team *models.Team) error {
+	team.Name = strings.TrimSpace(team.Name)
+	team.SlackWebhookUrl = strings.TrimSpace(team.SlackWebhookUrl)
+	team.WorkDays = strings.ToLower(strings.ReplaceAll(team.WorkDays, " ", ""))
+	if team.WorkDays == "" {
+		team.WorkDays = models.DefaultTeamWorkDays
+	}
+	if team.WorkStart == "" && team.WorkEnd == "" {
+		team.WorkStart, team.WorkEnd = models.DefaultTeamWorkStart, models.DefaultTeamWorkEnd
+	}
+	if !team.IsValid() {
+		return ErrTeamInvalid
+	}
+	// render with placeholder data to also catch references to unknown fields, which only fail upon execution
+	placeholder := &models.TeamDigest{
+		OffHours:     time.Minute,
+		MemberHours:  []*models.TeamDigestMemberHours{{OffHours: time.Minute}},
+		MostImproved: &models.TeamDigestImprovement{},
+	}
+	if _, err := renderTeamDigest(team, placeholder); err != nil {
+		return errors.Join(ErrTeamTemplateInvalid, err)
+	}
+	return nil
+}
+
+func renderTeamDigest(team *models.Team, digest *models.TeamDigest) (string, error) {
+	text := team.DigestTemplate
+	if strings.TrimSpace(text) == "" {
+		text = models.DefaultTeamDigestTemplate
+	}
+
+	tpl, err := template.New("digest").Funcs(teamDigestFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, digest); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTeamService_BuildDigest(t *testing.T) {
+	config.Set(config.Empty())
+
+	user1, user2, user3 := &models.User{ID: "user1", Location: "Europe/Berlin"}, &models.User{ID: "user2", Location: "UTC"}, &models.User{ID: "user3"}
+	team := &models.Team{Name: "Team Rocket", WorkDays: models.DefaultTeamWorkDays, WorkStart: "09:00", WorkEnd: "17:00", Members: []*models.TeamMember{{UserID: user1.ID}, {UserID: user2.ID}, {UserID: user3.ID}}}
+
+	to := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, 0, -7)
+	previousFrom := from.AddDate(0, 0, -7)
+
+	summaryOf := func(projects map[string]time.Duration) *models.Summary {
+		summary := &models.Summary{}
+		for key, total := range projects {
+			summary.Projects = append(summary.Projects, &models.SummaryItem{Type: models.SummaryProject, Key: key, Total: total / time.Second})
+		}
+		return summary
+	}
+
+	userService := new(mocks.UserServiceMock)
+	summaryService := new(mocks.SummaryServiceMock)
+	for _, u := range []*models.User{user1, user2, user3} {
+		userService.On("GetUserById", u.ID).Return(u, nil)
+	}
+	summaryService.On("Aliased", from, to, user1, mock.Anything, mock.Anything).Return(summaryOf(map[string]time.Duration{TestProject1: 3 * time.Hour, TestProject2: 30 * time.Minute}), nil)
+	summaryService.On("Aliased", previousFrom, from, user1, mock.Anything, mock.Anything).Return(summaryOf(map[string]time.Duration{TestProject1: 1 * time.Hour}), nil)
+	summaryService.On("Aliased", from, to, user2, mock.Anything, mock.Anything).Return(summaryOf(map[string]time.Duration{TestProject2: 1 * time.Hour}), nil)
+	summaryService.On("Aliased", previousFrom, from, user2, mock.Anything, mock.Anything).Return(summaryOf(map[string]time.Duration{TestProject2: 2 * time.Hour}), nil)
+	summaryService.On("Aliased", mock.Anything, mock.Anything, user3, mock.Anything, mock.Anything).Return(summaryOf(nil), nil)
+
+	durationService := new(mocks.DurationServiceMock)
+	durationService.On("Get", from, to, user1, mock.Anything).Return(models.Durations{
+		// monday, 16:00 - 18:00 in berlin, one hour each
+		{Time: models.CustomTime(time.Date(2024, 6, 3, 14, 0, 0, 0, time.UTC)), Duration: 2 * time.Hour},
+		// saturday
+		{Time: models.CustomTime(time.Date(2024, 6, 8, 10, 0, 0, 0, time.UTC)), Duration: 90 * time.Minute},
+	}, nil)
+	durationService.On("Get", from, to, user2, mock.Anything).Return(models.Durations{
+		// tuesday, within work hours in utc, but not in berlin
+		{Time: models.CustomTime(time.Date(2024, 6, 4, 16, 0, 0, 0, time.UTC)), Duration: 1 * time.Hour},
+	}, nil)
+
+	sut := NewTeamService(nil, userService, summaryService, durationService)
+
+	digest, err := sut.BuildDigest(team, from, to)
+	assert.Nil(t, err)
+	assert.Equal(t, 4*time.Hour+30*time.Minute, digest.Total)
+	assert.Equal(t, 3, digest.Members)
+	assert.Equal(t, 2, digest.ActiveMembers)
+	assert.Len(t, digest.TopProjects, 2)
+	assert.Equal(t, TestProject1, digest.TopProjects[0].Key)
+	assert.Equal(t, 90*time.Minute, digest.TopProjects[1].Total)
+	assert.Equal(t, user1.ID, digest.MostImproved.UserID)
+	assert.Equal(t, 1*time.Hour, digest.MostImproved.Previous)
+	assert.Equal(t, 2*time.Hour, digest.WorkHours)
+	assert.Equal(t, 2*time.Hour+30*time.Minute, digest.OffHours)
+	assert.Len(t, digest.MemberHours, 2)
+	assert.Equal(t, user1.ID, digest.MemberHours[0].UserID)
+	assert.Equal(t, 2*time.Hour+30*time.Minute, digest.MemberHours[0].OffHours)
+	assert.Equal(t, 1*time.Hour, digest.MemberHours[1].WorkHours)
+	durationService.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, user3, mock.Anything)
+
+	text, err := renderTeamDigest(team, digest)
+	assert.Nil(t, err)
+	assert.Contains(t, text, "*Weekly digest of Team Rocket*")
+	assert.Contains(t, text, "*4 hrs 30 mins* coded by 2 of 3 members (2 hrs 0 mins during work hours, 2 hrs 30 mins off hours)")
+	assert.Contains(t, text, "• user1 – 2 hrs 30 mins of 3 hrs 30 mins")
+	assert.NotContains(t, text, "• user2")
+	assert.Contains(t, text, "1. "+TestProject1+" – 3 hrs 0 mins")
+	assert.Contains(t, text, "Most improved: *user1* (1 hrs 0 mins → 3 hrs 30 mins)")
+}
+
+func TestTeamService_Create_InvalidTemplate(t *testing.T) {
+	config.Set(config.Empty())
+
+	sut := NewTeamService(nil, nil, nil, nil)
+
+	_, err := sut.Create(&models.Team{Name: " "})
+	assert.ErrorIs(t, err, ErrTeamInvalid)
+	_, err = sut.Create(&models.Team{Name: "Team Rocket", SlackWebhookUrl: "http://hooks.slack.com/services/T00/B00/XXX"})
+	assert.ErrorIs(t, err, ErrTeamInvalid)
+	_, err = sut.Create(&models.Team{Name: "Team Rocket", DigestTemplate: "{{ .Team "})
+	assert.ErrorIs(t, err, ErrTeamTemplateInvalid)
+	_, err = sut.Create(&models.Team{Name: "Team Rocket", DigestTemplate: "{{ .Unknown }}"})
+	assert.ErrorIs(t, err, ErrTeamTemplateInvalid)
+	_, err = sut.Create(&models.Team{Name: "Team Rocket", WorkStart: "18:00", WorkEnd: "09:00"})
+	assert.ErrorIs(t, err, ErrTeamInvalid)
+	_, err = sut.Create(&models.Team{Name: "Team Rocket", WorkDays: "monday,someday"})
+	assert.ErrorIs(t, err, ErrTeamInvalid)
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/models"
+	"github.com/patrickmn/go-cache"
+)
+
+const (
+	telegramLinkTTL     = 15 * time.Minute
+	telegramMaxProjects = 3
+	telegramNotLinked   = "This chat isn't linked to an account, yet. Create a link code in your settings and send it here as /start <code>."
+	telegramHelpText    = "Commands:\n/today - your coding time today\n/week - your coding time this week\n/stop - unlink this chat from your account\n\nYou'll also receive goal and inactivity alerts here."
+)
+
+var ErrTelegramDisabled = errors.New("telegram bot is disabled on this instance")
+
+// TelegramService runs a telegram bot, which users can link to their account to query their coding time and receive alerts.
+// Chats are linked by sending a short-lived code to the bot and stored as external id of the user, pending codes are only kept in memory.
+type TelegramService struct {
+	config                      *config.Config
+	cache                       *cache.Cache
+	userService                 IUserService
+	summaryService              ISummaryService
+	notificationTemplateService INotificationTemplateService
+}
+
+func NewTelegramService(userService IUserService, summaryService ISummaryService, notificationTemplateService INotificationTemplateService) *TelegramService {
+	return &TelegramService{
+		config:                      config.Get(),
+		cache:                       cache.New(telegramLinkTTL, telegramLinkTTL),
+		userService:                 userService,
+		summaryService:              summaryService,
+		notificationTemplateService: notificationTemplateService,
+	}
+}
+
+// Setup registers the webhook telegram is supposed to deliver updates to
+func (srv *TelegramService) Setup() {
+	if !srv.config.Telegram.Enabled() {
+		return
+	}
+
+	slog.Info("registering telegram bot webhook")
+	if err := srv.call("setWebhook", map[string]interface{}{
+		"url":             srv.config.Server.GetPublicUrl() + "/api/telegram/webhook",
+		"secret_token":    srv.config.Telegram.GetWebhookSecret(),
+		"allowed_updates": []string{"message"},
+	}); err != nil {
+		config.Log().Error("failed to register telegram webhook", "error", err)
+	}
+}
+
+// CreateLink generates a code for the user to send to the bot from the chat they want to link
+func (srv *TelegramService) CreateLink(user *models.User) (*models.TelegramLink, error) {
+	if !srv.config.Telegram.Enabled() {
+		return nil, ErrTelegramDisabled
+	}
+
+	code := make([]byte, 8)
+	if _, err := rand.Read(code); err != nil {
+		return nil, err
+	}
+
+	link := &models.TelegramLink{
+		Code:      hex.EncodeToString(code),
+		ExpiresAt: time.Now().Add(telegramLinkTTL),
+		UserID:    user.ID,
+	}
+	if username := srv.config.Telegram.BotUsername; username != "" {
+		link.Url = fmt.Sprintf("https://t.me/%s?start=%s", username, link.Code)
+	}
+
+	srv.cache.SetDefault(link.Code, link)
+	return link, nil
+}
+
+func (srv *TelegramService) Unlink(user *models.User) (bool, error) {
+	return srv.userService.DeleteExternalId(user, models.ExternalIdProviderTelegram)
+}
+
+// HandleUpdate answers the bot commands contained in an update received from telegram, other messages are ignored
+func (srv *TelegramService) HandleUpdate(update *models.TelegramUpdate) error {
+	if update.Message == nil || update.Message.Chat == nil {
+		return nil
+	}
+
+	chatId := strconv.FormatInt(update.Message.Chat.Id, 10)
+	command, arg := update.Message.Command()
+
+	var reply string
+	var err error
+	switch command {
+	case "":
+		return nil
+	case "/start":
+		if arg == "" {
+			reply = telegramNotLinked + "\n\n" + telegramHelpText
+		} else {
+			reply, err = srv.link(chatId, arg)
+		}
+	case "/today":
+		reply, err = srv.statsReply(chatId, "today", "Today")
+	case "/week":
+		reply, err = srv.statsReply(chatId, "week", "This week")
+	case "/stop":
+		reply, err = srv.unlinkReply(chatId)
+	default:
+		reply = telegramHelpText
+	}
+	if err != nil {
+		reply = "Sorry, something went wrong. Please try again later."
+		config.Log().Error("failed to handle telegram command", "command", command, "error", err)
+	}
+
+	return srv.sendMessage(chatId, reply)
+}
+
+// Notify sends the text to the user's linked chat, if any, and reports whether it was sent
+func (srv *TelegramService) Notify(user *models.User, text string) (bool, error) {
+	if !srv.config.Telegram.Enabled() {
+		return false, nil
+	}
+
+	externalIds, err := srv.userService.GetExternalIds(user)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range externalIds {
+		if id.Provider == models.ExternalIdProviderTelegram {
+			return true, srv.sendMessage(id.ExternalId, text)
+		}
+	}
+	return false, nil
+}
+
+func (srv *TelegramService) SendInactivityAlert(user *models.User, lastHeartbeat time.Time) (bool, error) {
+	if !srv.config.Telegram.Enabled() {
+		return false, nil
+	}
+
+	data := models.NewInactivityAlertNotification(user, lastHeartbeat, srv.config.Server.GetPublicUrl())
+	return srv.Notify(user, srv.renderNotification(models.NotificationKindInactivityAlert, data, func() string {
+		return fmt.Sprintf(
+			"⚠️ No coding activity since %s. If you've been coding in the meantime, your editor plugin might have stopped sending heartbeats.",
+			data.LastHeartbeat.Format(srv.config.App.DateTimeFormat),
+		)
+	}))
+}
+
+func (srv *TelegramService) SendLanguageGoalsReport(user *models.User, progress []*models.LanguageGoalProgress) (bool, error) {
+	if !srv.config.Telegram.Enabled() || len(progress) == 0 {
+		return false, nil
+	}
+
+	data := models.NewLanguageGoalsReportNotification(user, progress, srv.config.Server.GetPublicUrl())
+	return srv.Notify(user, srv.renderNotification(models.NotificationKindLanguageGoalsReport, data, func() string {
+		var sb strings.Builder
+		sb.WriteString("🎯 Your language goals last week:\n")
+		for _, g := range data.Goals {
+			status := "❌"
+			if g.Reached {
+				status = "✅"
+			}
+			fmt.Fprintf(&sb, "%s %s: %.0f%% of %.0f%%", status, g.Language, g.Percent, g.MinPercent)
+			if g.Category != "" {
+				fmt.Fprintf(&sb, " (%s)", g.Category)
+			}
+			sb.WriteString("\n")
+		}
+		return strings.TrimSpace(sb.String())
+	}))
+}
+
+// renderNotification renders the operator's template for the kind of notification, if any, and falls back to the built-in text otherwise
+func (srv *TelegramService) renderNotification(kind string, data interface{}, builtIn func() string) string {
+	custom, err := srv.notificationTemplateService.Render(kind, models.NotificationChannelTelegram, data)
+	if err != nil {
+		config.Log().Error("failed to render custom notification template, using built-in one", "kind", kind, "error", err)
+	}
+	if custom == nil || custom.Body == "" {
+		return builtIn()
+	}
+	return custom.Body
+}
+
+func (srv *TelegramService) link(chatId, code string) (string, error) {
+	item, found := srv.cache.Get(code)
+	if !found {
+		return "This code is invalid or has expired, please create a new one in your settings.", nil
+	}
+	link := item.(*models.TelegramLink)
+
+	user, err := srv.userService.GetUserById(link.UserID)
+	if err != nil {
+		return "", err
+	}
+	if _, err := srv.userService.SetExternalId(user, models.ExternalIdProviderTelegram, chatId); err != nil {
+		if errors.Is(err, ErrExternalIdTaken) {
+			return "This chat is already linked to another account, send /stop from it first.", nil
+		}
+		return "", err
+	}
+	srv.cache.Delete(code)
+
+	slog.Info("linked telegram chat", "userID", user.ID)
+	return fmt.Sprintf("This chat is now linked to %s.\n\n%s", user.ID, telegramHelpText), nil
+}
+
+func (srv *TelegramService) unlinkReply(chatId string) (string, error) {
+	user := srv.linkedUser(chatId)
+	if user == nil {
+		return telegramNotLinked, nil
+	}
+	if _, err := srv.Unlink(user); err != nil {
+		return "", err
+	}
+	return "This chat is no longer linked to your account.", nil
+}
+
+func (srv *TelegramService) statsReply(chatId, interval, title string) (string, error) {
+	user := srv.linkedUser(chatId)
+	if user == nil {
+		return telegramNotLinked, nil
+	}
+
+	err, from, to := helpers.ResolveIntervalRawTZWeekStart(interval, user.TZ(), user.WeekStart())
+	if err != nil {
+		return "", err
+	}
+	summary, err := srv.summaryService.Aliased(from, to, user, srv.summaryService.Retrieve, nil, false)
+	if err != nil {
+		return "", err
+	}
+	return formatTelegramStats(title, summary), nil
+}
+
+// linkedUser returns the user the chat is linked to, or nil if it isn't linked
+func (srv *TelegramService) linkedUser(chatId string) *models.User {
+	user, err := srv.userService.GetUserByRef(models.ExternalIdProviderTelegram + models.ExternalUserRefSeparator + chatId)
+	if err != nil {
+		return nil // unknown external ids fall through to a lookup by username, which fails
+	}
+	return user
+}
+
+func (srv *TelegramService) sendMessage(chatId, text string) error {
+	return srv.call("sendMessage", map[string]interface{}{
+		"chat_id":                  chatId,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	})
+}
+
+func (srv *TelegramService) call(method string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	endpoint := fmt.Sprintf("%s/bot%s/%s", strings.TrimSuffix(srv.config.Telegram.ApiUrl, "/"), srv.config.Telegram.BotToken, method)
+	if err := postWebhookJson(endpoint, data, nil); err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err // the url contains the bot token, which must not end up in logs
+		}
+		return err
+	}
+	return nil
+}
+
+func formatTelegramStats(title string, summary *models.Summary) string {
+	total := summary.TotalTime()
+	if total == 0 {
+		return fmt.Sprintf("%s: no coding activity, yet.", title)
+	}
+
+	projects := make(models.SummaryItems, len(summary.Projects))
+	copy(projects, summary.Projects)
+	sort.SliceStable(projects, func(i, j int) bool {
+		return projects[i].TotalFixed() > projects[j].TotalFixed()
+	})
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s: %s", title, helpers.FmtWakatimeDuration(total))
+	for i, p := range projects {
+		if i == telegramMaxProjects || p.TotalFixed() == 0 {
+			break
+		}
+		fmt.Fprintf(&sb, "\n• %s: %s", p.Key, helpers.FmtWakatimeDuration(p.TotalFixed()))
+	}
+	return sb.String()
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTelegramService_HandleUpdate(t *testing.T) {
+	var sent []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/bottoken/sendMessage", r.URL.Path)
+		var payload map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&payload)
+		sent = append(sent, payload)
+	}))
+	defer server.Close()
+
+	cfg := config.Empty()
+	cfg.Telegram.BotToken = "token"
+	cfg.Telegram.BotUsername = "hackatime_bot"
+	cfg.Telegram.ApiUrl = server.URL
+	config.Set(cfg)
+
+	user := &models.User{ID: TestUserId}
+	message := func(text string) *models.TelegramUpdate {
+		return &models.TelegramUpdate{Message: &models.TelegramMessage{Chat: &models.TelegramChat{Id: 42}, Text: text}}
+	}
+
+	userServiceMock := new(mocks.UserServiceMock)
+	userServiceMock.On("GetUserById", TestUserId).Return(user, nil)
+	userServiceMock.On("SetExternalId", user, models.ExternalIdProviderTelegram, "42").Return(&models.UserExternalId{}, nil)
+	userServiceMock.On("GetUserByRef", "telegram:42").Return(user, nil)
+	userServiceMock.On("GetUserByRef", "telegram:43").Return((*models.User)(nil), errors.New("not found"))
+
+	summaryServiceMock := new(mocks.SummaryServiceMock)
+	summaryServiceMock.On("Aliased", mock.Anything, mock.Anything, user, mock.Anything, mock.Anything).Return(&models.Summary{
+		Projects: models.SummaryItems{
+			{Type: models.SummaryProject, Key: TestProject1, Total: 30 * time.Minute / time.Second},
+			{Type: models.SummaryProject, Key: TestProject2, Total: 90 * time.Minute / time.Second},
+		},
+	}, nil)
+
+	sut := NewTelegramService(userServiceMock, summaryServiceMock, nil)
+
+	link, err := sut.CreateLink(user)
+	assert.Nil(t, err)
+	assert.Equal(t, "https://t.me/hackatime_bot?start="+link.Code, link.Url)
+
+	assert.Nil(t, sut.HandleUpdate(message("/start "+link.Code)))
+	userServiceMock.AssertCalled(t, "SetExternalId", user, models.ExternalIdProviderTelegram, "42")
+	assert.Nil(t, sut.HandleUpdate(message("/start "+link.Code))) // codes can only be used once
+	userServiceMock.AssertNumberOfCalls(t, "SetExternalId", 1)
+
+	assert.Nil(t, sut.HandleUpdate(message("/today@hackatime_bot")))
+	assert.Nil(t, sut.HandleUpdate(&models.TelegramUpdate{Message: &models.TelegramMessage{Chat: &models.TelegramChat{Id: 43}, Text: "/week"}}))
+	assert.Nil(t, sut.HandleUpdate(message("just chatting")))
+
+	assert.Len(t, sent, 4)
+	assert.Equal(t, "42", sent[0]["chat_id"])
+	assert.Contains(t, sent[0]["text"], "now linked")
+	assert.Contains(t, sent[1]["text"], "invalid or has expired")
+	assert.Equal(t, "Today: 2 hrs 0 mins\n• "+TestProject2+": 1 hrs 30 mins\n• "+TestProject1+": 0 hrs 30 mins", sent[2]["text"])
+	assert.Equal(t, "43", sent[3]["chat_id"])
+	assert.Equal(t, telegramNotLinked, sent[3]["text"])
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/leandro-lugaresi/hub"
+	"gorm.io/gorm"
+)
+
+// TimeEntryService manages manually entered time, which is merged into summaries as durations of the manual source, and annotations
+type TimeEntryService struct {
+	config     *config.Config
+	eventBus   *hub.Hub
+	repository repositories.ITimeEntryRepository
+}
+
+func NewTimeEntryService(timeEntryRepository repositories.ITimeEntryRepository) *TimeEntryService {
+	return &TimeEntryService{
+		config:     config.Get(),
+		eventBus:   config.EventBus(),
+		repository: timeEntryRepository,
+	}
+}
+
+func (srv *TimeEntryService) GetByUserAndId(userId string, id uint) (*models.TimeEntry, error) {
+	return srv.repository.GetByUserAndId(userId, id)
+}
+
+func (srv *TimeEntryService) GetByUserWithin(userId string, from, to time.Time) ([]*models.TimeEntry, error) {
+	return srv.repository.GetByUserWithin(userId, from, to)
+}
+
+func (srv *TimeEntryService) Create(entry *models.TimeEntry) (*models.TimeEntry, error) {
+	result, err := srv.repository.Insert(entry)
+	if err != nil {
+		return nil, err
+	}
+	srv.notifyUpdate(result, false)
+	return result, nil
+}
+
+// PutExternal creates an external entry or, if the user already has one with the same external id, replaces it
+// returns the stored entry and, if one was replaced, its previous version
+func (srv *TimeEntryService) PutExternal(entry *models.TimeEntry) (*models.TimeEntry, *models.TimeEntry, error) {
+	previous, err := srv.repository.GetByUserAndExternalId(entry.UserID, entry.ExternalID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		result, err := srv.Create(entry)
+		return result, nil, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entry.ID, entry.CreatedAt = previous.ID, previous.CreatedAt
+	result, err := srv.repository.Update(entry)
+	if err != nil {
+		return nil, nil, err
+	}
+	srv.eventBus.Publish(hub.Message{
+		Name:   config.EventTimeEntryUpdate,
+		Fields: map[string]interface{}{config.FieldPayload: result, config.FieldUserId: result.UserID},
+	})
+	return result, previous, nil
+}
+
+func (srv *TimeEntryService) Delete(entry *models.TimeEntry) error {
+	if err := srv.repository.DeleteByUserAndId(entry.UserID, entry.ID); err != nil {
+		return err
+	}
+	srv.notifyUpdate(entry, true)
+	return nil
+}
+
+// RenameProject moves all of the user's time entries of the old project to the new one
+func (srv *TimeEntryService) RenameProject(userId, oldProject, newProject string) (int64, error) {
+	n, err := srv.repository.RenameProject(userId, oldProject, newProject)
+	if err != nil {
+		return 0, err
+	}
+	srv.eventBus.Publish(hub.Message{
+		Name:   config.EventTimeEntryUpdate,
+		Fields: map[string]interface{}{config.FieldUserId: userId},
+	})
+	return n, nil
+}
+
+func (srv *TimeEntryService) notifyUpdate(entry *models.TimeEntry, isDelete bool) {
+	name := config.EventTimeEntryCreate
+	if isDelete {
+		name = config.EventTimeEntryDelete
+	}
+	srv.eventBus.Publish(hub.Message{
+		Name:   name,
+		Fields: map[string]interface{}{config.FieldPayload: entry, config.FieldUserId: entry.UserID},
+	})
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/patrickmn/go-cache"
+)
+
+var (
+	ErrTrackingPauseInvalid  = errors.New("invalid tracking pause")
+	ErrTrackingPauseNotFound = errors.New("tracking pause not found")
+)
+
+// TrackingPauseService manages periods, e.g. vacations, during which a user's heartbeats are discarded and their streak is frozen
+type TrackingPauseService struct {
+	config     *config.Config
+	cache      *cache.Cache
+	repository repositories.ITrackingPauseRepository
+}
+
+func NewTrackingPauseService(trackingPauseRepository repositories.ITrackingPauseRepository) *TrackingPauseService {
+	return &TrackingPauseService{
+		config:     config.Get(),
+		cache:      cache.New(24*time.Hour, 24*time.Hour),
+		repository: trackingPauseRepository,
+	}
+}
+
+func (srv *TrackingPauseService) GetByUser(userId string) ([]*models.TrackingPause, error) {
+	if pauses, found := srv.cache.Get(userId); found {
+		return pauses.([]*models.TrackingPause), nil
+	}
+
+	pauses, err := srv.repository.GetByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	srv.cache.Set(userId, pauses, cache.DefaultExpiration)
+	return pauses, nil
+}
+
+// IsPaused reports whether a heartbeat of the user at the given time is to be discarded
+func (srv *TrackingPauseService) IsPaused(user *models.User, t time.Time) (bool, error) {
+	pauses, err := srv.GetByUser(user.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, p := range pauses {
+		if p.Contains(t) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Create pauses tracking for the given period, which must neither start in the past (to not freeze streaks in retrospect) nor overlap another pause
+func (srv *TrackingPauseService) Create(user *models.User, pause *models.TrackingPause) (*models.TrackingPause, error) {
+	pause.UserID = user.ID
+	if !pause.IsValid() {
+		return nil, fmt.Errorf("%w: must end after it starts and must not be longer than a year", ErrTrackingPauseInvalid)
+	}
+	if pause.FromTime.T().Before(datetime.BeginOfDay(time.Now().In(user.TZ()))) {
+		return nil, fmt.Errorf("%w: must not start in the past", ErrTrackingPauseInvalid)
+	}
+
+	pauses, err := srv.GetByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if models.IsPausedWithin(pauses, pause.FromTime.T(), pause.ToTime.T()) {
+		return nil, fmt.Errorf("%w: overlaps another pause", ErrTrackingPauseInvalid)
+	}
+
+	result, err := srv.repository.Insert(pause)
+	if err != nil {
+		return nil, err
+	}
+	srv.cache.Delete(user.ID)
+	return result, nil
+}
+
+// End resumes tracking right away, i.e. cuts an active pause short or cancels an upcoming one
+// past pauses can't be removed, so that the streak they froze stays explainable
+func (srv *TrackingPauseService) End(user *models.User, id uint) error {
+	pauses, err := srv.GetByUser(user.ID)
+	if err != nil {
+		return err
+	}
+
+	var pause *models.TrackingPause
+	for _, p := range pauses {
+		if p.ID == id {
+			pause = p
+		}
+	}
+	if pause == nil {
+		return ErrTrackingPauseNotFound
+	}
+
+	defer srv.cache.Delete(user.ID)
+
+	switch {
+	case pause.IsUpcoming():
+		return srv.repository.DeleteByUserAndId(user.ID, pause.ID)
+	case pause.IsActive():
+		ended := *pause
+		ended.ToTime = models.CustomTime(time.Now())
+		_, err := srv.repository.Update(&ended)
+		return err
+	default:
+		return fmt.Errorf("%w: already over", ErrTrackingPauseInvalid)
+	}
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/mocks"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTrackingPauseService_Create(t *testing.T) {
+	config.Set(config.Empty())
+
+	user := &models.User{ID: TestUserId, Location: "UTC"}
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	existing := &models.TrackingPause{ID: 1, UserID: user.ID, FromTime: models.CustomTime(today.AddDate(0, 0, 10)), ToTime: models.CustomTime(today.AddDate(0, 0, 20))}
+
+	repo := new(mocks.TrackingPauseRepositoryMock)
+	repo.On("GetByUser", user.ID).Return([]*models.TrackingPause{existing}, nil)
+	repo.On("Insert", mock.Anything).Return(&models.TrackingPause{}, nil)
+
+	sut := NewTrackingPauseService(repo)
+
+	pause := func(fromDays, toDays int) *models.TrackingPause {
+		return &models.TrackingPause{FromTime: models.CustomTime(today.AddDate(0, 0, fromDays)), ToTime: models.CustomTime(today.AddDate(0, 0, toDays))}
+	}
+
+	_, err := sut.Create(user, pause(0, 7))
+	assert.Nil(t, err)
+
+	for _, p := range []*models.TrackingPause{pause(-1, 7), pause(7, 0), pause(5, 12), pause(0, 400)} {
+		_, err := sut.Create(user, p)
+		assert.True(t, errors.Is(err, ErrTrackingPauseInvalid))
+	}
+	repo.AssertNumberOfCalls(t, "Insert", 1)
+
+	paused, _ := sut.IsPaused(user, today.AddDate(0, 0, 15))
+	assert.True(t, paused)
+	paused, _ = sut.IsPaused(user, today.AddDate(0, 0, 20))
+	assert.False(t, paused)
+}
+
+func TestTrackingPauseService_End(t *testing.T) {
+	config.Set(config.Empty())
+
+	user := &models.User{ID: TestUserId, Location: "UTC"}
+	now := time.Now()
+	upcoming := &models.TrackingPause{ID: 1, UserID: user.ID, FromTime: models.CustomTime(now.Add(24 * time.Hour)), ToTime: models.CustomTime(now.Add(48 * time.Hour))}
+	active := &models.TrackingPause{ID: 2, UserID: user.ID, FromTime: models.CustomTime(now.Add(-24 * time.Hour)), ToTime: models.CustomTime(now.Add(24 * time.Hour))}
+	past := &models.TrackingPause{ID: 3, UserID: user.ID, FromTime: models.CustomTime(now.Add(-72 * time.Hour)), ToTime: models.CustomTime(now.Add(-48 * time.Hour))}
+
+	repo := new(mocks.TrackingPauseRepositoryMock)
+	repo.On("GetByUser", user.ID).Return([]*models.TrackingPause{past, active, upcoming}, nil)
+	repo.On("DeleteByUserAndId", user.ID, uint(1)).Return(nil)
+	repo.On("Update", mock.Anything).Return(&models.TrackingPause{}, nil)
+
+	sut := NewTrackingPauseService(repo)
+
+	assert.Nil(t, sut.End(user, 1))
+	repo.AssertCalled(t, "DeleteByUserAndId", user.ID, uint(1))
+
+	assert.Nil(t, sut.End(user, 2))
+	ended := repo.Calls[len(repo.Calls)-1].Arguments.Get(0).(*models.TrackingPause)
+	assert.Equal(t, uint(2), ended.ID)
+	assert.False(t, ended.ToTime.T().After(time.Now()))
+
+	assert.True(t, errors.Is(sut.End(user, 3), ErrTrackingPauseInvalid))
+	assert.True(t, errors.Is(sut.End(user, 4), ErrTrackingPauseNotFound))
+}
@@ -15,23 +15,28 @@ import (
 	"github.com/hackclub/hackatime/utils"
 	"github.com/leandro-lugaresi/hub"
 	"github.com/patrickmn/go-cache"
+	"gorm.io/gorm"
 )
 
+var ErrExternalIdTaken = errors.New("external id is already assigned to another user")
+
 type UserService struct {
 	config      *config.Config
 	cache       *cache.Cache
 	eventBus    *hub.Hub
 	mailService IMailService
 	repository  repositories.IUserRepository
+	externalIds repositories.IUserExternalIdRepository
 }
 
-func NewUserService(mailService IMailService, userRepo repositories.IUserRepository) *UserService {
+func NewUserService(mailService IMailService, userRepo repositories.IUserRepository, externalIdRepo repositories.IUserExternalIdRepository) *UserService {
 	srv := &UserService{
 		config:      config.Get(),
 		eventBus:    config.EventBus(),
 		cache:       cache.New(1*time.Hour, 2*time.Hour),
 		mailService: mailService,
 		repository:  userRepo,
+		externalIds: externalIdRepo,
 	}
 
 	sub1 := srv.eventBus.Subscribe(0, config.EventWakatimeFailure)
@@ -46,7 +51,7 @@ func NewUserService(mailService IMailService, userRepo repositories.IUserReposit
 				config.Log().Error("failed to set wakatime api key for user", "userID", user.ID)
 			}
 
-			if user.Email != "" {
+			if user.HasTrustedEmail() {
 				if err := mailService.SendWakatimeFailureNotification(user, n); err != nil {
 					config.Log().Error("failed to send wakatime failure notification mail to user", "userID", user.ID)
 				} else {
@@ -77,6 +82,47 @@ func (srv *UserService) GetUserById(userId string) (*models.User, error) {
 	return u, nil
 }
 
+// GetUserByRef resolves either a username or an external user reference like "slack:U012AB3CD"
+func (srv *UserService) GetUserByRef(ref string) (*models.User, error) {
+	if provider, externalId, ok := models.ParseExternalUserRef(ref); ok {
+		mapping, err := srv.externalIds.GetByProviderAndExternalId(provider, externalId)
+		if err == nil {
+			return srv.GetUserById(mapping.UserID)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		// fall through for legacy usernames containing a colon
+	}
+	return srv.GetUserById(ref)
+}
+
+func (srv *UserService) GetExternalIds(user *models.User) ([]*models.UserExternalId, error) {
+	return srv.externalIds.GetByUser(user.ID)
+}
+
+// SetExternalId assigns the external id of the given provider to the user, replacing a previous one
+func (srv *UserService) SetExternalId(user *models.User, provider, externalId string) (*models.UserExternalId, error) {
+	if !models.ValidateExternalIdProvider(provider) || !models.ValidateExternalId(externalId) {
+		return nil, errors.New("invalid provider or external id")
+	}
+
+	if existing, err := srv.externalIds.GetByProviderAndExternalId(provider, externalId); err == nil && existing.UserID != user.ID {
+		return nil, ErrExternalIdTaken
+	}
+
+	mapping := &models.UserExternalId{UserID: user.ID, Provider: provider, ExternalId: externalId}
+	if err := srv.externalIds.Upsert(mapping); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+func (srv *UserService) DeleteExternalId(user *models.User, provider string) (bool, error) {
+	n, err := srv.externalIds.DeleteByUserAndProvider(user.ID, provider)
+	return n > 0, err
+}
+
 func (srv *UserService) GetUserByKey(key string) (*models.User, error) {
 	if key == "" {
 		return nil, errors.New("key must not be empty")
@@ -86,7 +132,13 @@ func (srv *UserService) GetUserByKey(key string) (*models.User, error) {
 		return u.(*models.User), nil
 	}
 
-	u, err := srv.repository.FindOne(models.User{ApiKey: key})
+	var u *models.User
+	var err error
+	if store := srv.config.Security.ApiKeyStore(); store != nil {
+		u, err = srv.getUserBySealedKey(store, key)
+	} else {
+		u, err = srv.repository.FindOne(models.User{ApiKey: key})
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -95,6 +147,26 @@ func (srv *UserService) GetUserByKey(key string) (*models.User, error) {
 	return u, nil
 }
 
+// getUserBySealedKey looks up candidates by the key's prefix and verifies every one of them, so that timing doesn't tell which matched
+func (srv *UserService) getUserBySealedKey(store utils.ApiKeyStore, key string) (*models.User, error) {
+	candidates, err := srv.repository.GetByApiKeyPrefix(utils.ApiKeyPrefix(key))
+	if err != nil {
+		return nil, err
+	}
+
+	var match *models.User
+	for _, c := range candidates {
+		if store.Verify(key, c.ApiKeySealed) && match == nil {
+			match = c
+		}
+	}
+	if match == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	match.ApiKey = key // hashed keys can't be recovered otherwise
+	return match, nil
+}
+
 func (srv *UserService) GetUserByEmail(email string) (*models.User, error) {
 	if email == "" {
 		return nil, errors.New("email must not be empty")
@@ -109,6 +181,13 @@ func (srv *UserService) GetUserByResetToken(resetToken string) (*models.User, er
 	return srv.repository.FindOne(models.User{ResetToken: resetToken})
 }
 
+func (srv *UserService) GetUserBySimpleToken(token string) (*models.User, error) {
+	if token == "" {
+		return nil, errors.New("token must not be empty")
+	}
+	return srv.repository.FindOne(models.User{SimpleToken: token})
+}
+
 func (srv *UserService) GetUserByStripeCustomerId(customerId string) (*models.User, error) {
 	if customerId == "" {
 		return nil, errors.New("customer id must not be empty")
@@ -183,7 +262,7 @@ func (srv *UserService) CreateOrGet(signup *models.Signup, isAdmin bool) (*model
 		InvitedBy: signup.InvitedBy,
 	}
 
-	if hash, err := utils.HashPassword(u.Password, srv.config.Security.PasswordSalt); err != nil {
+	if hash, err := srv.config.Security.PasswordHasher().Hash(u.Password, srv.config.Security.PasswordSalt); err != nil {
 		return nil, false, err
 	} else {
 		u.Password = hash
@@ -192,18 +271,93 @@ func (srv *UserService) CreateOrGet(signup *models.Signup, isAdmin bool) (*model
 	return srv.repository.InsertOrGet(u)
 }
 
+// CreateServiceAccount creates a user for integrations, which has neither a password nor an e-mail address and thus can't log in
+func (srv *UserService) CreateServiceAccount(username, name string) (*models.User, bool, error) {
+	u := &models.User{
+		ID:               username,
+		Name:             name,
+		ApiKey:           uuid.Must(uuid.NewV4()).String(),
+		IsServiceAccount: true,
+	}
+	return srv.repository.InsertOrGet(u)
+}
+
+// CreateInvited creates a user on behalf of an admin with a random password and a pre-issued api key, which only starts working
+// once the user followed the invite link (i.e. a password reset link) to set their own password
+func (srv *UserService) CreateInvited(invite *models.Invite, invitedBy string) (*models.User, bool, error) {
+	password := uuid.Must(uuid.NewV4()).String()
+	hash, err := srv.config.Security.PasswordHasher().Hash(password, srv.config.Security.PasswordSalt)
+	if err != nil {
+		return nil, false, err
+	}
+
+	u := &models.User{
+		ID:            invite.Username,
+		ApiKey:        uuid.Must(uuid.NewV4()).String(),
+		Email:         invite.Email,
+		Password:      hash,
+		ResetToken:    uuid.Must(uuid.NewV4()).String(),
+		InvitedBy:     invitedBy,
+		InvitePending: true,
+	}
+	return srv.repository.InsertOrGet(u)
+}
+
 func (srv *UserService) Update(user *models.User) (*models.User, error) {
 	srv.FlushUserCache(user.ID)
 	srv.notifyUpdate(user)
 	return srv.repository.Update(user)
 }
 
+// UpdateField only writes a single column, as opposed to Update, so that concurrent changes to the user's other fields aren't reverted
+func (srv *UserService) UpdateField(user *models.User, key string, value interface{}) (*models.User, error) {
+	srv.FlushUserCache(user.ID)
+	updated, err := srv.repository.UpdateField(user, key, value)
+	if err != nil {
+		return nil, err
+	}
+	srv.notifyUpdate(updated)
+	return updated, nil
+}
+
+// Suspend blocks the user from logging in and sending heartbeats until unsuspended again, without deleting any data
+func (srv *UserService) Suspend(user *models.User, reason string, admin *models.User) (*models.User, error) {
+	now := models.CustomTime(time.Now())
+	user.SuspendedAt = &now
+	user.SuspensionReason = reason
+
+	updated, err := srv.Update(user)
+	if err != nil {
+		return nil, err
+	}
+	slog.Warn("suspended user", "audit", true, "userID", user.ID, "admin", admin.ID, "reason", reason)
+	return updated, nil
+}
+
+func (srv *UserService) Unsuspend(user *models.User, admin *models.User) (*models.User, error) {
+	user.SuspendedAt = nil
+	user.SuspensionReason = ""
+
+	updated, err := srv.Update(user)
+	if err != nil {
+		return nil, err
+	}
+	slog.Warn("unsuspended user", "audit", true, "userID", user.ID, "admin", admin.ID)
+	return updated, nil
+}
+
 func (srv *UserService) ResetApiKey(user *models.User) (*models.User, error) {
 	srv.FlushUserCache(user.ID)
 	user.ApiKey = uuid.Must(uuid.NewV4()).String()
 	return srv.Update(user)
 }
 
+func (srv *UserService) ResetSimpleToken(user *models.User) (*models.User, error) {
+	srv.FlushUserCache(user.ID)
+	user.SimpleToken = uuid.Must(uuid.NewV4()).String()
+	return srv.Update(user)
+}
+
 func (srv *UserService) SetWakatimeApiCredentials(user *models.User, apiKey string, apiUrl string) (*models.User, error) {
 	srv.FlushUserCache(user.ID)
 
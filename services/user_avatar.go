@@ -0,0 +1,76 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/patrickmn/go-cache"
+)
+
+var ErrAvatarInvalid = errors.New("avatar must be a png, jpeg, gif or webp image of at most 256 kb")
+
+// UserAvatarService stores uploaded avatars in the database, they're small and rarely change, so they're cached in memory once requested
+type UserAvatarService struct {
+	config     *config.Config
+	cache      *cache.Cache
+	repository repositories.IUserAvatarRepository
+	userSrvc   IUserService
+}
+
+func NewUserAvatarService(userAvatarRepo repositories.IUserAvatarRepository, userService IUserService) *UserAvatarService {
+	return &UserAvatarService{
+		config:     config.Get(),
+		cache:      cache.New(1*time.Hour, 1*time.Hour),
+		repository: userAvatarRepo,
+		userSrvc:   userService,
+	}
+}
+
+func (srv *UserAvatarService) GetByUser(userId string) (*models.UserAvatar, error) {
+	if avatar, found := srv.cache.Get(userId); found {
+		return avatar.(*models.UserAvatar), nil
+	}
+	avatar, err := srv.repository.GetByUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	srv.cache.SetDefault(userId, avatar)
+	return avatar, nil
+}
+
+// Upload replaces the user's uploaded avatar and makes it their active one
+func (srv *UserAvatarService) Upload(user *models.User, data []byte) error {
+	contentType, ok := models.DetectAvatarContentType(data)
+	if !ok {
+		return ErrAvatarInvalid
+	}
+
+	now := models.CustomTime(time.Now())
+	if err := srv.repository.Upsert(&models.UserAvatar{UserID: user.ID, Data: data, ContentType: contentType, UpdatedAt: now}); err != nil {
+		return err
+	}
+	srv.cache.Delete(user.ID)
+
+	user.AvatarSource = models.AvatarSourceUpload
+	user.AvatarUpdatedAt = &now
+	_, err := srv.userSrvc.Update(user)
+	return err
+}
+
+// Delete removes the user's uploaded avatar and falls back to the default one, if it was active
+func (srv *UserAvatarService) Delete(user *models.User) error {
+	if err := srv.repository.DeleteByUser(user.ID); err != nil {
+		return err
+	}
+	srv.cache.Delete(user.ID)
+
+	if user.AvatarSource == models.AvatarSourceUpload {
+		user.AvatarSource = models.AvatarSourceDefault
+	}
+	user.AvatarUpdatedAt = nil
+	_, err := srv.userSrvc.Update(user)
+	return err
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/patrickmn/go-cache"
+)
+
+var ErrTooManyPreferences = fmt.Errorf("a namespace must not hold more than %d preferences", models.MaxPreferencesPerNamespace)
+
+type UserPreferenceService struct {
+	config     *config.Config
+	cache      *cache.Cache
+	repository repositories.IUserPreferenceRepository
+}
+
+func NewUserPreferenceService(userPreferenceRepository repositories.IUserPreferenceRepository) *UserPreferenceService {
+	return &UserPreferenceService{
+		config:     config.Get(),
+		repository: userPreferenceRepository,
+		cache:      cache.New(1*time.Hour, 1*time.Hour),
+	}
+}
+
+func (srv *UserPreferenceService) GetByUserAndNamespace(userId, namespace string) (models.UserPreferences, error) {
+	cacheKey := srv.getCacheKey(userId, namespace)
+	if preferences, found := srv.cache.Get(cacheKey); found {
+		return preferences.(models.UserPreferences), nil
+	}
+
+	result, err := srv.repository.GetByUserAndNamespace(userId, namespace)
+	if err != nil {
+		return nil, err
+	}
+	preferences := models.NewUserPreferencesFrom(result)
+	srv.cache.SetDefault(cacheKey, preferences)
+	return preferences, nil
+}
+
+// Set creates or overwrites a single preference, while a namespace may only hold a limited number of them
+func (srv *UserPreferenceService) Set(preference *models.UserPreference) (*models.UserPreference, error) {
+	if !preference.IsValid() {
+		return nil, errors.New("invalid preference")
+	}
+
+	existing, err := srv.GetByUserAndNamespace(preference.UserID, preference.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := existing[preference.Key]; !ok && len(existing) >= models.MaxPreferencesPerNamespace {
+		return nil, ErrTooManyPreferences
+	}
+
+	result, err := srv.repository.Upsert(preference)
+	srv.cache.Delete(srv.getCacheKey(preference.UserID, preference.Namespace))
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (srv *UserPreferenceService) Delete(userId, namespace, key string) error {
+	err := srv.repository.DeleteByUserAndNamespaceAndKey(userId, namespace, key)
+	srv.cache.Delete(srv.getCacheKey(userId, namespace))
+	return err
+}
+
+func (srv *UserPreferenceService) DeleteNamespace(userId, namespace string) error {
+	err := srv.repository.DeleteByUserAndNamespace(userId, namespace)
+	srv.cache.Delete(srv.getCacheKey(userId, namespace))
+	return err
+}
+
+func (srv *UserPreferenceService) getCacheKey(userId, namespace string) string {
+	return fmt.Sprintf("%s/%s", userId, namespace)
+}
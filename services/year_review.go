@@ -0,0 +1,405 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alitto/pond"
+	"github.com/duke-git/lancet/v2/condition"
+	"github.com/duke-git/lancet/v2/datetime"
+	"github.com/duke-git/lancet/v2/slice"
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/helpers"
+	"github.com/hackclub/hackatime/models"
+	"github.com/hackclub/hackatime/repositories"
+	"github.com/hackclub/hackatime/utils"
+	"github.com/muety/artifex/v2"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+	"gorm.io/gorm"
+)
+
+const (
+	yearReviewTopN        = 5
+	yearReviewImageWidth  = 400
+	yearReviewImageHeight = 300
+	yearReviewImageScale  = 2
+	yearReviewLineHeight  = 18
+)
+
+var ErrYearNotCompleted = errors.New("year review is only available for past years")
+
+// YearReviewService generates a "wrapped"-style review of every user's past year each January, which can then be shared via a public link
+type YearReviewService struct {
+	config       *config.Config
+	repository   repositories.IYearReviewRepository
+	summarySrvc  ISummaryService
+	userSrvc     IUserService
+	pauseSrvc    ITrackingPauseService
+	queueDefault *artifex.Dispatcher
+	queueWorkers *artifex.Dispatcher
+	pending      sync.Map
+}
+
+func NewYearReviewService(yearReviewRepo repositories.IYearReviewRepository, summaryService ISummaryService, userService IUserService, trackingPauseService ITrackingPauseService) *YearReviewService {
+	return &YearReviewService{
+		config:       config.Get(),
+		repository:   yearReviewRepo,
+		summarySrvc:  summaryService,
+		userSrvc:     userService,
+		pauseSrvc:    trackingPauseService,
+		queueDefault: config.GetDefaultQueue(),
+		queueWorkers: config.GetQueue(config.QueueProcessing),
+	}
+}
+
+func (srv *YearReviewService) Schedule() {
+	slog.Info("scheduling year review generation")
+
+	_, err := srv.queueDefault.DispatchCron(func() {
+		year := time.Now().Year() - 1
+
+		users, err := srv.userSrvc.GetAll()
+		if err != nil {
+			config.Log().Error("failed to fetch users for year review generation", "error", err)
+			return
+		}
+
+		slog.Info("generating year reviews", "year", year, "userCount", len(users))
+		for _, u := range users {
+			srv.dispatchGenerate(u, year)
+		}
+	}, srv.config.App.GetYearReviewCron())
+
+	if err != nil {
+		config.Log().Error("failed to schedule year review generation", "error", err)
+	}
+}
+
+// GetByUser returns the user's review of the given year, if generated already, otherwise generation is triggered in the background and nil is returned
+func (srv *YearReviewService) GetByUser(user *models.User, year int) (*models.YearReview, error) {
+	if !srv.isCompleted(user, year) {
+		return nil, ErrYearNotCompleted
+	}
+
+	review, err := srv.repository.GetByUserAndYear(user.ID, year)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if review == nil {
+		srv.dispatchGenerate(user, year)
+	}
+	return review, nil
+}
+
+func (srv *YearReviewService) GetByShareToken(token string) (*models.YearReview, error) {
+	return srv.repository.GetByShareToken(token)
+}
+
+// Generate computes the user's review of the given year and persists it, replacing a previously generated one
+func (srv *YearReviewService) Generate(user *models.User, year int) (*models.YearReview, error) {
+	if !srv.isCompleted(user, year) {
+		return nil, ErrYearNotCompleted
+	}
+
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, user.TZ())
+	to := from.AddDate(1, 0, 0)
+
+	summary, err := srv.summarySrvc.Aliased(from, to, user, srv.summarySrvc.Retrieve, nil, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// skip the expensive day-by-day breakdown for users, who weren't active at all
+	var (
+		days   []*models.Summary
+		pauses []*models.TrackingPause
+	)
+	if summary.TotalTime() > 0 {
+		if days, err = retrieveDailySummaries(srv.summarySrvc, user, from, to); err != nil {
+			return nil, err
+		}
+		if pauses, err = srv.pauseSrvc.GetByUser(user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	review, err := srv.repository.GetByUserAndYear(user.ID, year)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if review == nil {
+		token, err := generateShareToken()
+		if err != nil {
+			return nil, err
+		}
+		review = &models.YearReview{UserID: user.ID, Year: year, ShareToken: token}
+	}
+	review.Stats = computeYearReviewStats(user, summary, days, pauses)
+	review.CreatedAt = models.CustomTime(time.Now())
+
+	if err := srv.repository.Upsert(review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// RenderImage draws a shareable png card of the given review
+func (srv *YearReviewService) RenderImage(review *models.YearReview) ([]byte, error) {
+	var (
+		colorBackground = utils.HexToRGBA(colorMinDark)
+		colorAccent     = utils.HexToRGBA(colorMaxDark)
+		colorForeground = utils.HexToRGBA(textDark)
+	)
+
+	img := image.NewRGBA(image.Rect(0, 0, yearReviewImageWidth, yearReviewImageHeight))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: colorBackground}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, yearReviewImageWidth, 4), &image.Uniform{C: colorAccent}, image.Point{}, draw.Src)
+
+	y := 24
+	writeLine := func(text string, c color.Color) {
+		d := &font.Drawer{Dst: img, Src: image.NewUniform(c), Face: basicfont.Face7x13, Dot: fixed.P(16, y)}
+		d.DrawString(text)
+		y += yearReviewLineHeight
+	}
+
+	for _, line := range yearReviewLines(review) {
+		if y > yearReviewImageHeight-yearReviewLineHeight {
+			break
+		}
+		writeLine(line.text, condition.TernaryOperator[bool, color.Color](line.highlight, colorAccent, colorForeground))
+	}
+
+	y = yearReviewImageHeight - 8
+	writeLine(srv.config.Server.GetPublicUrl(), colorAccent)
+
+	// basic font is tiny, so upscale the whole image afterward
+	scaled := image.NewRGBA(image.Rect(0, 0, yearReviewImageWidth*yearReviewImageScale, yearReviewImageHeight*yearReviewImageScale))
+	xdraw.NearestNeighbor.Scale(scaled, scaled.Bounds(), img, img.Bounds(), draw.Src, nil)
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, scaled); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// retrieveDailySummaries fetches one summary per day in parallel, failed days are left nil and the last error is returned
+func retrieveDailySummaries(summarySrvc ISummaryService, user *models.User, from, to time.Time) ([]*models.Summary, error) {
+	intervals := utils.SplitRangeByDays(from, to)
+	summaries := make([]*models.Summary, len(intervals))
+
+	wp := pond.New(utils.HalfCPUs(), 0)
+	mut := sync.Mutex{}
+	var lastErr error
+
+	for i, interval := range intervals {
+		i := i
+		interval := interval
+
+		wp.Submit(func() {
+			summary, err := summarySrvc.Retrieve(interval[0], interval[1], user, nil)
+			mut.Lock()
+			defer mut.Unlock()
+			if err != nil {
+				lastErr = err
+				return
+			}
+			summaries[i] = summary
+		})
+	}
+
+	wp.StopAndWait()
+	return summaries, lastErr
+}
+
+func (srv *YearReviewService) dispatchGenerate(user *models.User, year int) {
+	key := fmt.Sprintf("%s_%d", user.ID, year)
+	if _, running := srv.pending.LoadOrStore(key, true); running {
+		return
+	}
+
+	if err := srv.queueWorkers.Dispatch(func() {
+		defer srv.pending.Delete(key)
+		if _, err := srv.Generate(user, year); err != nil {
+			config.Log().Error("failed to generate year review", "userID", user.ID, "year", year, "error", err)
+		}
+	}); err != nil {
+		srv.pending.Delete(key)
+		config.Log().Error("failed to dispatch year review generation", "userID", user.ID, "year", year, "error", err)
+	}
+}
+
+func (srv *YearReviewService) isCompleted(user *models.User, year int) bool {
+	return year > 0 && year < time.Now().In(user.TZ()).Year()
+}
+
+// computeYearReviewStats derives the review from the year's total and daily summaries, inactive days within one of the given pauses don't break a streak
+func computeYearReviewStats(user *models.User, summary *models.Summary, days []*models.Summary, pauses []*models.TrackingPause) *models.YearReviewStats {
+	summary = summary.Sorted()
+
+	stats := &models.YearReviewStats{
+		Username:     user.ID,
+		TotalTime:    summary.TotalTime(),
+		TopLanguages: topYearReviewEntries(summary.Languages),
+		TopProjects:  topYearReviewEntries(summary.Projects),
+		TopEditors:   topYearReviewEntries(summary.Editors),
+		FunFacts:     []string{},
+	}
+
+	var (
+		weekdayTotals = make(map[time.Weekday]time.Duration)
+		monthTotals   = make(map[time.Month]time.Duration)
+		weekTotals    = make(map[time.Time]time.Duration)
+		streakStart   time.Time
+		streakDays    int
+	)
+
+	for _, day := range days {
+		if day == nil {
+			continue
+		}
+		start, total := day.FromTime.T().In(user.TZ()), day.TotalTime()
+
+		if total == 0 {
+			if !models.IsPausedWithin(pauses, day.FromTime.T(), day.ToTime.T()) {
+				streakDays = 0
+			}
+			continue
+		}
+
+		stats.ActiveDays++
+		weekdayTotals[start.Weekday()] += total
+		monthTotals[start.Month()] += total
+		weekTotals[datetime.BeginOfWeek(start, user.WeekStart())] += total
+
+		if stats.BusiestDay == nil || total > stats.BusiestDay.Total {
+			stats.BusiestDay = &models.YearReviewPeriod{Start: start, Total: total}
+		}
+
+		if streakDays == 0 {
+			streakStart = start
+		}
+		streakDays++
+		if stats.LongestStreak == nil || streakDays > stats.LongestStreak.Days {
+			stats.LongestStreak = &models.YearReviewStreak{Start: streakStart, Days: streakDays}
+		}
+	}
+
+	for week, total := range weekTotals {
+		if stats.BusiestWeek == nil || total > stats.BusiestWeek.Total || (total == stats.BusiestWeek.Total && week.Before(stats.BusiestWeek.Start)) {
+			stats.BusiestWeek = &models.YearReviewPeriod{Start: week, Total: total}
+		}
+	}
+
+	if stats.ActiveDays == 0 {
+		return stats
+	}
+	stats.DailyAverage = stats.TotalTime / time.Duration(stats.ActiveDays)
+
+	stats.FunFacts = append(stats.FunFacts, fmt.Sprintf("You coded on %d out of %d days.", stats.ActiveDays, len(days)))
+	if weekday, total := maxByKey(weekdayTotals); total > 0 {
+		stats.FunFacts = append(stats.FunFacts, fmt.Sprintf("%s was your most productive day of the week.", weekday))
+	}
+	if month, total := maxByKey(monthTotals); total > 0 {
+		stats.FunFacts = append(stats.FunFacts, fmt.Sprintf("You were most active in %s with %s.", month, helpers.FmtWakatimeDuration(total)))
+	}
+	if n := len(slice.Filter[*models.SummaryItem](summary.Languages, func(i int, item *models.SummaryItem) bool {
+		return item.Total > 0 && item.Key != models.UnknownSummaryKey
+	})); n > 1 {
+		stats.FunFacts = append(stats.FunFacts, fmt.Sprintf("You wrote code in %d different languages.", n))
+	}
+	if fullDays := int(stats.TotalTime / (24 * time.Hour)); fullDays > 0 {
+		stats.FunFacts = append(stats.FunFacts, fmt.Sprintf("That's the equivalent of %d full days of non-stop coding.", fullDays))
+	}
+
+	return stats
+}
+
+func topYearReviewEntries(items models.SummaryItems) []*models.YearReviewEntry {
+	entries := make([]*models.YearReviewEntry, 0, yearReviewTopN)
+	for _, item := range items {
+		if len(entries) >= yearReviewTopN {
+			break
+		}
+		if item.Total == 0 || item.Key == models.UnknownSummaryKey {
+			continue
+		}
+		entries = append(entries, &models.YearReviewEntry{Key: item.Key, Total: item.TotalFixed()})
+	}
+	return entries
+}
+
+type yearReviewLine struct {
+	text      string
+	highlight bool
+}
+
+func yearReviewLines(review *models.YearReview) []yearReviewLine {
+	stats := review.Stats
+	if stats == nil {
+		stats = &models.YearReviewStats{}
+	}
+
+	lines := []yearReviewLine{
+		{text: fmt.Sprintf("%s's %d in code", stats.Username, review.Year), highlight: true},
+		{text: fmt.Sprintf("Total: %s on %d days", helpers.FmtWakatimeDuration(stats.TotalTime), stats.ActiveDays)},
+	}
+	if len(stats.TopLanguages) > 0 {
+		keys := slice.Map[*models.YearReviewEntry, string](stats.TopLanguages, func(i int, e *models.YearReviewEntry) string { return e.Key })
+		lines = append(lines, yearReviewLine{text: fmt.Sprintf("Top languages: %s", strings.Join(keys[:min(len(keys), 3)], ", "))})
+	}
+	if len(stats.TopProjects) > 0 {
+		lines = append(lines, yearReviewLine{text: fmt.Sprintf("Top project: %s", stats.TopProjects[0].Key)})
+	}
+	if stats.BusiestWeek != nil {
+		lines = append(lines, yearReviewLine{text: fmt.Sprintf("Busiest week: %s (%s)", helpers.FormatDateHuman(stats.BusiestWeek.Start), helpers.FmtWakatimeDuration(stats.BusiestWeek.Total))})
+	}
+	if stats.LongestStreak != nil {
+		lines = append(lines, yearReviewLine{text: fmt.Sprintf("Longest streak: %d days", stats.LongestStreak.Days)})
+	}
+	for _, fact := range stats.FunFacts {
+		lines = append(lines, yearReviewLine{text: fact})
+	}
+	return lines
+}
+
+// maxByKey returns the key with the highest total, preferring the smaller key on ties to be deterministic
+func maxByKey[K time.Weekday | time.Month](totals map[K]time.Duration) (K, time.Duration) {
+	keys := make([]K, 0, len(totals))
+	for k := range totals {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+
+	var maxKey K
+	var maxTotal time.Duration
+	for _, k := range keys {
+		if totals[k] > maxTotal {
+			maxKey, maxTotal = k, totals[k]
+		}
+	}
+	return maxKey, maxTotal
+}
+
+func generateShareToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/hackclub/hackatime/config"
+	"github.com/hackclub/hackatime/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComputeYearReviewStats(t *testing.T) {
+	user := &models.User{ID: "user1", Location: "UTC"}
+	secs := func(d time.Duration) time.Duration { return d / time.Second } // summary items hold seconds
+
+	total := models.NewEmptySummary()
+	total.Languages = models.SummaryItems{
+		{Key: "Go", Total: secs(10 * time.Hour)},
+		{Key: "Python", Total: secs(3 * time.Hour)},
+		{Key: models.UnknownSummaryKey, Total: secs(1 * time.Hour)},
+	}
+	total.Projects = models.SummaryItems{{Key: "hackatime", Total: secs(14 * time.Hour)}}
+
+	day := func(date string, d time.Duration) *models.Summary {
+		from, _ := time.Parse(time.DateOnly, date)
+		s := models.NewEmptySummary()
+		s.FromTime = models.CustomTime(from)
+		s.ToTime = models.CustomTime(from.AddDate(0, 0, 1))
+		if d > 0 {
+			s.Projects = models.SummaryItems{{Key: "hackatime", Total: secs(d)}}
+		}
+		return s
+	}
+
+	days := []*models.Summary{
+		day("2024-01-01", 1*time.Hour), // monday
+		day("2024-01-02", 2*time.Hour),
+		day("2024-01-03", 3*time.Hour),
+		day("2024-01-04", 0),
+		day("2024-01-05", 1*time.Hour),
+		day("2024-01-08", 7*time.Hour), // monday of next week
+		nil,
+	}
+
+	stats := computeYearReviewStats(user, total, days, nil)
+
+	assert.Equal(t, 14*time.Hour, stats.TotalTime)
+	assert.Equal(t, 5, stats.ActiveDays)
+	assert.Equal(t, 14*time.Hour/5, stats.DailyAverage)
+	assert.Equal(t, []*models.YearReviewEntry{{Key: "Go", Total: 10 * time.Hour}, {Key: "Python", Total: 3 * time.Hour}}, stats.TopLanguages)
+	assert.Equal(t, "2024-01-08", stats.BusiestDay.Start.Format(time.DateOnly))
+	assert.Equal(t, 7*time.Hour, stats.BusiestDay.Total)
+	assert.Equal(t, "2024-01-01", stats.BusiestWeek.Start.Format(time.DateOnly))
+	assert.Equal(t, 7*time.Hour, stats.BusiestWeek.Total) // ties go to the earlier week
+	assert.Equal(t, "2024-01-01", stats.LongestStreak.Start.Format(time.DateOnly))
+	assert.Equal(t, 3, stats.LongestStreak.Days)
+	assert.Contains(t, stats.FunFacts, "Monday was your most productive day of the week.")
+	assert.Contains(t, stats.FunFacts, "You wrote code in 2 different languages.")
+
+	// the inactive day was spent on vacation (the weekend after is not part of the list at all)
+	vacationStart, _ := time.Parse(time.DateOnly, "2024-01-04")
+	pauses := []*models.TrackingPause{{FromTime: models.CustomTime(vacationStart), ToTime: models.CustomTime(vacationStart.AddDate(0, 0, 1))}}
+
+	stats = computeYearReviewStats(user, total, days, pauses)
+	assert.Equal(t, 5, stats.ActiveDays)
+	assert.Equal(t, "2024-01-01", stats.LongestStreak.Start.Format(time.DateOnly))
+	assert.Equal(t, 5, stats.LongestStreak.Days)
+}
+
+func TestComputeYearReviewStats_Empty(t *testing.T) {
+	stats := computeYearReviewStats(&models.User{ID: "user1"}, models.NewEmptySummary(), nil, nil)
+
+	assert.Zero(t, stats.TotalTime)
+	assert.Zero(t, stats.ActiveDays)
+	assert.Nil(t, stats.BusiestWeek)
+	assert.Nil(t, stats.LongestStreak)
+	assert.Empty(t, stats.FunFacts)
+}
+
+func TestYearReviewService_RenderImage(t *testing.T) {
+	config.Set(config.Empty())
+
+	sut := NewYearReviewService(nil, nil, nil, nil)
+	data, err := sut.RenderImage(&models.YearReview{
+		Year: 2024,
+		Stats: &models.YearReviewStats{
+			Username:      "user1",
+			TotalTime:     100 * time.Hour,
+			ActiveDays:    42,
+			TopLanguages:  []*models.YearReviewEntry{{Key: "Go", Total: 80 * time.Hour}},
+			LongestStreak: &models.YearReviewStreak{Days: 12},
+			FunFacts:      []string{"You coded on 42 out of 366 days."},
+		},
+	})
+
+	assert.Nil(t, err)
+	img, err := png.Decode(bytes.NewReader(data))
+	assert.Nil(t, err)
+	assert.Equal(t, yearReviewImageWidth*yearReviewImageScale, img.Bounds().Dx())
+}
@@ -0,0 +1,120 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+const (
+	ApiKeyStoragePlain     = "plain"
+	ApiKeyStorageHashed    = "hashed"
+	ApiKeyStorageEncrypted = "encrypted"
+	ApiKeyPrefixLength     = 8
+
+	apiKeyHashedPrefix    = "hmac-sha256$"
+	apiKeyEncryptedPrefix = "aes-gcm$"
+)
+
+// ApiKeyStore seals api keys before storing them at rest. Keys are looked up by their (plain text) prefix and then verified against the sealed value.
+type ApiKeyStore interface {
+	Seal(key string) (string, error)
+	// Open recovers the plain key from its sealed form, which is only possible for encrypted keys, but not for hashed ones
+	Open(sealed string) (string, bool)
+	// Verify checks the key against its sealed form in constant time
+	Verify(key, sealed string) bool
+}
+
+func ApiKeyPrefix(key string) string {
+	if len(key) < ApiKeyPrefixLength {
+		return key
+	}
+	return key[:ApiKeyPrefixLength]
+}
+
+// NewHashedApiKeyStore creates a store that keeps hmacs of api keys only, so they can't be shown to their users after creation anymore.
+// as opposed to passwords, api keys are random and long enough to not require a slow hash function.
+func NewHashedApiKeyStore(secret []byte) ApiKeyStore {
+	return &hashedApiKeyStore{secret: secret}
+}
+
+// NewEncryptedApiKeyStore creates a store that encrypts api keys using aes-gcm with a key derived from the given secret
+func NewEncryptedApiKeyStore(secret []byte) (ApiKeyStore, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("encrypting api keys requires a secret")
+	}
+	key := sha256.Sum256(secret)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptedApiKeyStore{aead: aead}, nil
+}
+
+type hashedApiKeyStore struct {
+	secret []byte
+}
+
+func (s *hashedApiKeyStore) Seal(key string) (string, error) {
+	return apiKeyHashedPrefix + s.hash(key), nil
+}
+
+func (s *hashedApiKeyStore) Open(string) (string, bool) {
+	return "", false
+}
+
+func (s *hashedApiKeyStore) Verify(key, sealed string) bool {
+	if !strings.HasPrefix(sealed, apiKeyHashedPrefix) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(s.hash(key)), []byte(strings.TrimPrefix(sealed, apiKeyHashedPrefix))) == 1
+}
+
+func (s *hashedApiKeyStore) hash(key string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(key))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+type encryptedApiKeyStore struct {
+	aead cipher.AEAD
+}
+
+func (s *encryptedApiKeyStore) Seal(key string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return apiKeyEncryptedPrefix + base64.RawStdEncoding.EncodeToString(s.aead.Seal(nonce, nonce, []byte(key), nil)), nil
+}
+
+func (s *encryptedApiKeyStore) Open(sealed string) (string, bool) {
+	if !strings.HasPrefix(sealed, apiKeyEncryptedPrefix) {
+		return "", false
+	}
+	data, err := base64.RawStdEncoding.DecodeString(strings.TrimPrefix(sealed, apiKeyEncryptedPrefix))
+	if err != nil || len(data) < s.aead.NonceSize() {
+		return "", false
+	}
+	plain, err := s.aead.Open(nil, data[:s.aead.NonceSize()], data[s.aead.NonceSize():], nil)
+	if err != nil {
+		return "", false
+	}
+	return string(plain), true
+}
+
+func (s *encryptedApiKeyStore) Verify(key, sealed string) bool {
+	plain, ok := s.Open(sealed)
+	return ok && subtle.ConstantTimeCompare([]byte(key), []byte(plain)) == 1
+}
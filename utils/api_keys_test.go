@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApiKeyStore_SealAndVerify(t *testing.T) {
+	encrypted, err := NewEncryptedApiKeyStore([]byte("secret"))
+	assert.Nil(t, err)
+	stores := []ApiKeyStore{NewHashedApiKeyStore([]byte("secret")), encrypted}
+
+	for _, store := range stores {
+		sealed, err := store.Seal("2a1b7c3d-0000-4000-8000-000000000000")
+		assert.Nil(t, err)
+		assert.NotContains(t, sealed, "2a1b7c3d")
+		assert.True(t, store.Verify("2a1b7c3d-0000-4000-8000-000000000000", sealed))
+		assert.False(t, store.Verify("2a1b7c3d-0000-4000-8000-000000000001", sealed))
+	}
+
+	sealed, _ := encrypted.Seal("some-key")
+	plain, ok := encrypted.Open(sealed)
+	assert.True(t, ok)
+	assert.Equal(t, "some-key", plain)
+
+	other, _ := NewEncryptedApiKeyStore([]byte("other secret"))
+	assert.False(t, other.Verify("some-key", sealed))
+
+	_, ok = stores[0].Open(sealed)
+	assert.False(t, ok)
+}
+
+func TestApiKeyPrefix(t *testing.T) {
+	assert.Equal(t, "2a1b7c3d", ApiKeyPrefix("2a1b7c3d-0000-4000-8000-000000000000"))
+	assert.Equal(t, "short", ApiKeyPrefix("short"))
+}
@@ -51,43 +51,100 @@ func ExtractBearerAuth(r *http.Request) (key string, err error) {
 
 // password hashing
 
-func ComparePassword(hashed, plain, pepper string) bool {
-	if hashed[0:10] == "$argon2id$" {
-		return CompareArgon2Id(hashed, plain, pepper)
+const (
+	PasswordHashArgon2Id = "argon2id"
+	PasswordHashBcrypt   = "bcrypt"
+)
+
+// PasswordHasher creates new password hashes using a specific algorithm and set of parameters
+type PasswordHasher interface {
+	Hash(plain, pepper string) (string, error)
+	// NeedsRehash tells whether the given hash was created using a different algorithm or different parameters than this hasher's
+	NeedsRehash(hashed string) bool
+}
+
+type Argon2IdHasher struct {
+	params *argon2id.Params
+}
+
+// NewArgon2IdHasher creates an argon2id hasher, zero values fall back to the library's defaults (memory in kib)
+func NewArgon2IdHasher(memory, iterations uint32, parallelism uint8) *Argon2IdHasher {
+	params := *argon2id.DefaultParams
+	if memory > 0 {
+		params.Memory = memory
 	}
-	return CompareBcrypt(hashed, plain, pepper)
+	if iterations > 0 {
+		params.Iterations = iterations
+	}
+	if parallelism > 0 {
+		params.Parallelism = parallelism
+	}
+	return &Argon2IdHasher{params: &params}
 }
 
-func HashPassword(plain, pepper string) (string, error) {
-	return HashArgon2Id(plain, pepper)
+func (h *Argon2IdHasher) Hash(plain, pepper string) (string, error) {
+	return argon2id.CreateHash(strings.TrimSpace(plain)+pepper, h.params)
 }
 
-func CompareBcrypt(hashed, plain, pepper string) bool {
-	plainPepperedPassword := []byte(strings.TrimSpace(plain) + pepper)
-	err := bcrypt.CompareHashAndPassword([]byte(hashed), plainPepperedPassword)
-	return err == nil
+func (h *Argon2IdHasher) NeedsRehash(hashed string) bool {
+	if !isArgon2IdHash(hashed) {
+		return true
+	}
+	params, _, _, err := argon2id.DecodeHash(hashed)
+	if err != nil {
+		return true
+	}
+	return params.Memory != h.params.Memory || params.Iterations != h.params.Iterations || params.Parallelism != h.params.Parallelism || params.KeyLength != h.params.KeyLength
 }
 
-func HashBcrypt(plain, pepper string) (string, error) {
-	plainPepperedPassword := []byte(strings.TrimSpace(plain) + pepper)
-	bytes, err := bcrypt.GenerateFromPassword(plainPepperedPassword, bcrypt.DefaultCost)
+type BcryptHasher struct {
+	cost int
+}
+
+// NewBcryptHasher creates a bcrypt hasher, a zero cost falls back to the library's default
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost <= 0 {
+		cost = bcrypt.DefaultCost
+	}
+	return &BcryptHasher{cost: cost}
+}
+
+func (h *BcryptHasher) Hash(plain, pepper string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(strings.TrimSpace(plain)+pepper), h.cost)
 	if err == nil {
 		return string(bytes), nil
 	}
 	return "", err
 }
 
+func (h *BcryptHasher) NeedsRehash(hashed string) bool {
+	if isArgon2IdHash(hashed) {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(hashed))
+	return err != nil || cost != h.cost
+}
+
+// ComparePassword checks the given password against a hash of any of the supported algorithms
+func ComparePassword(hashed, plain, pepper string) bool {
+	if isArgon2IdHash(hashed) {
+		return CompareArgon2Id(hashed, plain, pepper)
+	}
+	return CompareBcrypt(hashed, plain, pepper)
+}
+
+func CompareBcrypt(hashed, plain, pepper string) bool {
+	plainPepperedPassword := []byte(strings.TrimSpace(plain) + pepper)
+	err := bcrypt.CompareHashAndPassword([]byte(hashed), plainPepperedPassword)
+	return err == nil
+}
+
 func CompareArgon2Id(hashed, plain, pepper string) bool {
 	plainPepperedPassword := strings.TrimSpace(plain) + pepper
 	match, err := argon2id.ComparePasswordAndHash(plainPepperedPassword, hashed)
 	return err == nil && match
 }
 
-func HashArgon2Id(plain, pepper string) (string, error) {
-	plainPepperedPassword := strings.TrimSpace(plain) + pepper
-	hash, err := argon2id.CreateHash(plainPepperedPassword, argon2id.DefaultParams)
-	if err == nil {
-		return hash, nil
-	}
-	return "", err
+func isArgon2IdHash(hashed string) bool {
+	return strings.HasPrefix(hashed, "$argon2id$")
 }
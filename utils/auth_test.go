@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordHasher_HashAndCompare(t *testing.T) {
+	hashers := []PasswordHasher{NewArgon2IdHasher(1024, 1, 1), NewBcryptHasher(4)}
+	for _, hasher := range hashers {
+		hash, err := hasher.Hash(" secret ", "pepper")
+		assert.Nil(t, err)
+		assert.True(t, ComparePassword(hash, "secret", "pepper"))
+		assert.False(t, ComparePassword(hash, "secret", "salt"))
+		assert.False(t, ComparePassword(hash, "wrong", "pepper"))
+		assert.False(t, hasher.NeedsRehash(hash))
+	}
+	assert.False(t, ComparePassword("", "secret", "pepper"))
+}
+
+func TestPasswordHasher_NeedsRehash(t *testing.T) {
+	argonHash, _ := NewArgon2IdHasher(1024, 1, 1).Hash("secret", "")
+	bcryptHash, _ := NewBcryptHasher(4).Hash("secret", "")
+
+	assert.True(t, NewArgon2IdHasher(1024, 1, 1).NeedsRehash(bcryptHash))
+	assert.True(t, NewArgon2IdHasher(2048, 1, 1).NeedsRehash(argonHash))
+	assert.True(t, NewArgon2IdHasher(1024, 2, 1).NeedsRehash(argonHash))
+	assert.True(t, NewArgon2IdHasher(1024, 1, 2).NeedsRehash(argonHash))
+	assert.True(t, NewBcryptHasher(4).NeedsRehash(argonHash))
+	assert.True(t, NewBcryptHasher(5).NeedsRehash(bcryptHash))
+	assert.True(t, NewBcryptHasher(4).NeedsRehash("garbage"))
+}
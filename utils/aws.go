@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// AwsCredentials holds everything required to sign requests against an s3-compatible object storage
+type AwsCredentials struct {
+	Region    string
+	AccessKey string
+	SecretKey string
+}
+
+// SignAwsV4 adds header-based aws signature v4 authentication to an s3 request
+// see https://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-header-based-auth.html
+func SignAwsV4(req *http.Request, payload []byte, creds AwsCredentials, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := fmt.Sprintf(
+		"content-type:%s\nhost:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n",
+		req.Header.Get("Content-Type"), req.URL.Host, payloadHash, amzDate,
+	)
+	canonicalRequest := strings.Join([]string{req.Method, req.URL.EscapedPath(), req.URL.RawQuery, canonicalHeaders, signedHeaders, payloadHash}, "\n")
+
+	scope := awsScope(dateStamp, creds.Region)
+	signature := awsSignature(creds, dateStamp, amzDate, scope, canonicalRequest)
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", creds.AccessKey, scope, signedHeaders, signature))
+}
+
+// PresignAwsV4 returns a copy of the given url, which grants time-limited access to anyone holding it
+// see https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-query-string-auth.html
+func PresignAwsV4(method string, objectUrl *url.URL, creds AwsCredentials, expiry time.Duration, now time.Time) *url.URL {
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+	scope := awsScope(dateStamp, creds.Region)
+
+	query := objectUrl.Query()
+	query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	query.Set("X-Amz-Credential", fmt.Sprintf("%s/%s", creds.AccessKey, scope))
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", fmt.Sprintf("%d", int64(expiry.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	canonicalQuery := strings.ReplaceAll(query.Encode(), "+", "%20")
+
+	canonicalRequest := strings.Join([]string{method, objectUrl.EscapedPath(), canonicalQuery, fmt.Sprintf("host:%s\n", objectUrl.Host), "host", "UNSIGNED-PAYLOAD"}, "\n")
+	signature := awsSignature(creds, dateStamp, amzDate, scope, canonicalRequest)
+
+	signedUrl := *objectUrl
+	signedUrl.RawQuery = canonicalQuery + "&X-Amz-Signature=" + signature
+	return &signedUrl
+}
+
+// SignAwsV4PostPolicy returns the form fields for a browser-based upload via post, which s3 only accepts as long as it satisfies
+// all of the given policy conditions (e.g. a content-length-range) and the policy didn't expire
+// see https://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-HTTPPOSTConstructPolicy.html
+func SignAwsV4PostPolicy(conditions []interface{}, creds AwsCredentials, expiry time.Duration, now time.Time) (map[string]string, error) {
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+	credential := fmt.Sprintf("%s/%s", creds.AccessKey, awsScope(dateStamp, creds.Region))
+
+	fields := map[string]string{
+		"x-amz-algorithm":  "AWS4-HMAC-SHA256",
+		"x-amz-credential": credential,
+		"x-amz-date":       amzDate,
+	}
+	conditions = append(conditions,
+		map[string]string{"x-amz-algorithm": fields["x-amz-algorithm"]},
+		map[string]string{"x-amz-credential": fields["x-amz-credential"]},
+		map[string]string{"x-amz-date": fields["x-amz-date"]},
+	)
+
+	policy, err := json.Marshal(map[string]interface{}{
+		"expiration": now.Add(expiry).Format("2006-01-02T15:04:05.000Z"),
+		"conditions": conditions,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fields["policy"] = base64.StdEncoding.EncodeToString(policy)
+	fields["x-amz-signature"] = hex.EncodeToString(hmacSha256(awsSigningKey(creds, dateStamp), []byte(fields["policy"])))
+	return fields, nil
+}
+
+func awsScope(dateStamp, region string) string {
+	return fmt.Sprintf("%s/%s/s3/aws4_request", dateStamp, region)
+}
+
+func awsSignature(creds AwsCredentials, dateStamp, amzDate, scope, canonicalRequest string) string {
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+	return hex.EncodeToString(hmacSha256(awsSigningKey(creds, dateStamp), []byte(stringToSign)))
+}
+
+func awsSigningKey(creds AwsCredentials, dateStamp string) []byte {
+	signingKey := hmacSha256([]byte("AWS4"+creds.SecretKey), []byte(dateStamp))
+	signingKey = hmacSha256(signingKey, []byte(creds.Region))
+	signingKey = hmacSha256(signingKey, []byte("s3"))
+	return hmacSha256(signingKey, []byte("aws4_request"))
+}
+
+func sha256Hex(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func hmacSha256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
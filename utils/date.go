@@ -16,7 +16,11 @@ func BeginOfToday(tz *time.Location) time.Time {
 }
 
 func BeginOfThisWeek(tz *time.Location) time.Time {
-	return datetime.BeginOfWeek(time.Now().In(tz), time.Monday)
+	return BeginOfThisWeekFrom(tz, time.Monday)
+}
+
+func BeginOfThisWeekFrom(tz *time.Location, weekStart time.Weekday) time.Time {
+	return datetime.BeginOfWeek(time.Now().In(tz), weekStart)
 }
 
 func BeginOfThisMonth(tz *time.Location) time.Time {
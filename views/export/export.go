@@ -0,0 +1,6 @@
+package export
+
+import "embed"
+
+//go:embed *.html
+var TemplateFiles embed.FS